@@ -0,0 +1,65 @@
+// Command bootstrap prepares a fresh PostgreSQL database: it creates the
+// system accounts, supported currencies and default transaction limits, then
+// prints an admin token to administer the new environment with. Running it
+// again only creates what is missing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/config"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+	"Crypto.com/pkg/utils"
+)
+
+func main() {
+	admin := flag.String("admin", "admin", "subject of the admin token to issue, empty to issue none")
+	tokenTTL := flag.Duration("admin-token-ttl", 24*time.Hour, "lifetime of the admin token")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	utils.Init(cfg.Environment == "production", cfg.LogPath)
+	// Entries logged with a context carry its operation
+	utils.Log.AddHook(operation.LogHook{})
+
+	if cfg.DBDriver == config.DBDriverSQLite {
+		log.Fatal("Bootstrap requires PostgreSQL; the SQLite schema is applied by the server")
+	}
+	if *admin != "" && cfg.JWTSigningKey == "" {
+		log.Fatal("JWT_SIGNING_KEY must be set to issue an admin token")
+	}
+
+	pool, db, err := postgres.OpenPool(context.Background(), cfg.PostgresURL(), postgres.PoolConfig{
+		MaxConns:               int32(cfg.DBMaxOpenConns),
+		MaxConnLifetime:        cfg.DBConnMaxLifetime,
+		MaxConnIdleTime:        cfg.DBConnMaxIdleTime,
+		StatementCacheCapacity: cfg.DBStatementCacheCapacity,
+	})
+	if err != nil {
+		log.Fatal("Error connecting to PostgreSQL:", err)
+	}
+	defer pool.Close()
+	defer db.Close()
+
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "bootstrap", Channel: operation.ChannelJob})
+	service := services.NewBootstrapService(postgres.NewBootstrapRepository(db, utils.Log), utils.Log)
+	if _, err := service.Run(ctx, services.DefaultBootstrapPlan()); err != nil {
+		log.Fatal("Bootstrap failed:", err)
+	}
+
+	if *admin == "" {
+		return
+	}
+	token, err := auth.Sign([]byte(cfg.JWTSigningKey), cfg.JWTIssuer, *admin, []string{auth.RoleAdmin}, *tokenTTL)
+	if err != nil {
+		log.Fatal("Error issuing admin token:", err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,124 @@
+// Command checker scans the PostgreSQL database for wallet data that
+// disagrees: transactions crediting missing wallets, negative balances,
+// cached balances differing from stored ones and stored balances differing
+// from the ledger. It prints a JSON repair plan and exits with status 2 when
+// it finds issues. Once reviewed, the plan is applied with -apply, naming
+// the actor and reason recorded with every repair; repairs whose wallet
+// changed since the plan was made are skipped as stale.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"Crypto.com/internal/config"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/services"
+	"Crypto.com/pkg/utils"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "wallets read per query")
+	apply := flag.String("apply", "", "repair plan file to apply, - for stdin")
+	actor := flag.String("actor", "", "who applies the repairs, required with -apply")
+	reason := flag.String("reason", "", "why the repairs are applied, required with -apply")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	utils.Init(cfg.Environment == "production", cfg.LogPath)
+	// Entries logged with a context carry its operation
+	utils.Log.AddHook(operation.LogHook{})
+
+	if cfg.DBDriver == config.DBDriverSQLite {
+		log.Fatal("Checker requires PostgreSQL")
+	}
+	if *apply != "" && (*actor == "" || *reason == "") {
+		log.Fatal("-actor and -reason must be set to apply a repair plan")
+	}
+
+	pool, db, err := postgres.OpenPool(context.Background(), cfg.PostgresURL(), postgres.PoolConfig{
+		MaxConns:               int32(cfg.DBMaxOpenConns),
+		MaxConnLifetime:        cfg.DBConnMaxLifetime,
+		MaxConnIdleTime:        cfg.DBConnMaxIdleTime,
+		StatementCacheCapacity: cfg.DBStatementCacheCapacity,
+	})
+	if err != nil {
+		log.Fatal("Error connecting to PostgreSQL:", err)
+	}
+	defer pool.Close()
+	defer db.Close()
+
+	// Without Redis, cached balances are neither checked nor invalidated
+	var cache redis.CacheRepository
+	if !cfg.RedisDisabled {
+		client := goredis.NewClient(&goredis.Options{
+			Addr:     cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort),
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		defer client.Close()
+		cache = redis.NewCacheRepository(client, time.Hour, utils.Log)
+	}
+
+	checker := services.NewConsistencyChecker(postgres.NewConsistencyRepository(db, utils.Log), cache, *batchSize, utils.Log)
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+
+	if *apply == "" {
+		ctx := operation.With(context.Background(), operation.Operation{Actor: "checker", Channel: operation.ChannelJob})
+		plan, err := checker.Check(ctx)
+		if err != nil {
+			log.Fatal("Consistency check failed:", err)
+		}
+		if err := out.Encode(plan); err != nil {
+			log.Fatal("Error writing repair plan:", err)
+		}
+		if len(plan.Issues) > 0 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	plan, err := readPlan(*apply)
+	if err != nil {
+		log.Fatal("Error reading repair plan:", err)
+	}
+	ctx := operation.With(context.Background(), operation.Operation{Actor: *actor, Channel: operation.ChannelAdmin, Reason: *reason})
+	outcomes := checker.Apply(ctx, plan)
+	if err := out.Encode(outcomes); err != nil {
+		log.Fatal("Error writing repair outcomes:", err)
+	}
+	for _, outcome := range outcomes {
+		if outcome.Status == models.RepairFailed {
+			os.Exit(1)
+		}
+	}
+}
+
+func readPlan(path string) (*models.RepairPlan, error) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var plan models.RepairPlan
+	if err := json.NewDecoder(in).Decode(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
@@ -0,0 +1,100 @@
+// Command loadtest replays month-end traffic against a deployment, staging
+// and never production: the salary-day deposit burst, the spending that
+// follows and the statements downloaded once the month has closed. It
+// deposits into dedicated wallets, prints a JSON report of the throughput,
+// latency and errors of every endpoint per phase and exits with status 2
+// when an endpoint exceeds its budget in any phase.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/config"
+	"Crypto.com/internal/loadtest"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "root of the wallet API")
+	users := flag.Int("users", 200, "wallets traffic is spread across")
+	userPrefix := flag.String("user-prefix", "loadtest-", "prefix of the user IDs of the wallets")
+	scale := flag.Float64("scale", 1, "multiplier of the request rates of the profile")
+	duration := flag.Duration("duration", 10*time.Minute, "length of the whole profile")
+	concurrency := flag.Int("concurrency", 256, "requests in flight before requests are dropped")
+	seedAmount := flag.String("seed-amount", "100000.00", "amount deposited into every wallet first, empty to skip")
+	token := flag.String("token", "", "bearer token, signed as an admin from JWT_SIGNING_KEY when empty")
+	budgets := flag.String("budgets", "", "JSON file of endpoint budgets replacing the defaults")
+	flag.Parse()
+
+	if *token == "" {
+		cfg := config.LoadConfig()
+		if cfg.JWTSigningKey == "" {
+			log.Fatal("-token or JWT_SIGNING_KEY must be set")
+		}
+		signed, err := auth.Sign([]byte(cfg.JWTSigningKey), cfg.JWTIssuer, "loadtest", []string{auth.RoleAdmin}, *duration+time.Hour)
+		if err != nil {
+			log.Fatal("Error signing token:", err)
+		}
+		*token = signed
+	}
+
+	limits := loadtest.DefaultBudgets
+	if *budgets != "" {
+		var err error
+		if limits, err = readBudgets(*budgets); err != nil {
+			log.Fatal("Error reading budgets:", err)
+		}
+	}
+
+	ids := make([]string, *users)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s%d", *userPrefix, i)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := loadtest.NewRunner(loadtest.Config{
+		BaseURL:     *baseURL,
+		Token:       *token,
+		Users:       ids,
+		Concurrency: *concurrency,
+	})
+	if *seedAmount != "" {
+		if err := runner.Seed(ctx, *seedAmount); err != nil {
+			log.Fatal("Error seeding wallets:", err)
+		}
+	}
+
+	report := runner.Run(ctx, loadtest.MonthEnd(*duration, *scale))
+	violations := report.Evaluate(limits)
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(report); err != nil {
+		log.Fatal("Error writing report:", err)
+	}
+	if len(violations) > 0 {
+		os.Exit(2)
+	}
+}
+
+func readBudgets(path string) ([]loadtest.Budget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var budgets []loadtest.Budget
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
@@ -1,66 +1,1062 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
 	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
 
+	"Crypto.com/internal/auth"
 	"Crypto.com/internal/config"
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/funding"
+	"Crypto.com/internal/graph"
 	"Crypto.com/internal/handlers"
+	"Crypto.com/internal/masking"
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/otp"
+	"Crypto.com/internal/payouts"
+	"Crypto.com/internal/rates"
+	"Crypto.com/internal/repositories/memory"
 	"Crypto.com/internal/repositories/postgres"
 	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/repositories/sqlite"
 	"Crypto.com/internal/services"
+	"Crypto.com/internal/tracing"
+	"Crypto.com/pkg/buildinfo"
 	"Crypto.com/pkg/utils"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 	utils.Init(cfg.Environment == "production", cfg.LogPath)
+	// Entries logged with a context carry its request ID and operation
+	utils.Log.AddHook(operation.LogHook{})
 
-	// Initialize PostgreSQL
-	connStr := "postgres://" + cfg.DBUser + ":" + cfg.DBPassword + "@" + cfg.DBHost + ":" + cfg.DBPort + "/" + cfg.DBName
-	db, err := sql.Open("pgx", connStr)
+	info := buildinfo.Get()
+	utils.Log.WithFields(logrus.Fields{
+		"version":     info.Version,
+		"commit":      info.Commit,
+		"buildDate":   info.BuildDate,
+		"goVersion":   info.GoVersion,
+		"environment": cfg.Environment,
+	}).Info("Starting wallet service")
+
+	// `server migrate` applies the schema migrations and exits;
+	// `server rebuild-projections` verifies the wallet event streams and
+	// rebuilds the balances projected from them
+	migrateOnly := len(os.Args) > 1 && os.Args[1] == "migrate"
+	rebuildOnly := len(os.Args) > 1 && os.Args[1] == "rebuild-projections"
+
+	if cfg.JWTSigningKey == "" && !migrateOnly && !rebuildOnly {
+		log.Fatal("JWT_SIGNING_KEY must be set")
+	}
+
+	if cfg.SandboxEnabled && cfg.Environment == "production" {
+		log.Fatal("SANDBOX_ENABLED cannot be set in production")
+	}
+
+	var err error
+	maskingPolicies, err := masking.Parse(cfg.MaskingPolicies)
 	if err != nil {
-		log.Fatal("Error connecting to PostgreSQL:", err)
+		log.Fatal("Invalid MASKING_POLICIES:", err)
+	}
+
+	// Export traces when a collector is configured
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.TracingEndpoint != "" {
+		shutdownTracing, err = tracing.Setup(context.Background(), cfg.TracingEndpoint, cfg.TracingServiceName, cfg.TracingSampleRatio)
+		if err != nil {
+			log.Fatal("Error setting up tracing:", err)
+		}
+		utils.Log.WithField("endpoint", cfg.TracingEndpoint).Info("Exporting traces")
+	}
+
+	// Initialize storage. The sqlite driver runs the whole service as a single
+	// binary with an in-memory cache.
+	var db *sql.DB
+	// The pool behind db with PostgreSQL, whose connections are exported as
+	// metrics
+	var dbPool *pgxpool.Pool
+	var walletRepo postgres.WalletRepository
+	var cacheRepo redis.CacheRepository = redis.NewNoopCacheRepository()
+	// The Redis balance cache, whose memory the cache memory guard watches
+	var redisClient *goredis.Client
+	var redisCache *redis.CacheRepositoryImpl
+	// Shortens the TTL of balances whose invalidation failed
+	var cacheShortener services.CacheTTLShortener
+	// Without Redis every instance runs the scheduler; claiming runs in the
+	// database still executes each occurrence once
+	var schedulerLock redis.LeaderLock = redis.NewLocalLeaderLock()
+	// Without Redis only the database serializes operations on a wallet
+	var walletLock redis.WalletLock
+	// Without Redis only changes made by this instance wake balance waiters
+	var balanceNotifier redis.BalanceNotifier = redis.NewLocalBalanceNotifier()
+	var redisNotifier *redis.RedisBalanceNotifier
+	// Without Redis kill switches only apply to the instance they were set on
+	var killSwitchRepo redis.KillSwitchRepository = redis.NewLocalKillSwitchRepository()
+	// Without Redis rate limits apply per instance
+	var rateLimiter redis.RateLimiter = redis.NewLocalRateLimiter()
+	cacheStatus := handlers.DependencyDisabled
+	postgresOnly := cfg.DBDriver != config.DBDriverSQLite
+	// The event-sourced engine records deposits, withdrawals and transfers
+	// only, so features moving funds otherwise are disabled as with SQLite
+	var eventSourced bool
+	switch cfg.WalletStorage {
+	case config.WalletStorageState:
+	case config.WalletStorageEvents:
+		if !postgresOnly {
+			log.Fatal("WALLET_STORAGE=events requires DB_DRIVER=postgres")
+		}
+		eventSourced = true
+	default:
+		log.Fatalf("Unknown WALLET_STORAGE %q", cfg.WalletStorage)
+	}
+	if rebuildOnly && !eventSourced {
+		log.Fatal("rebuild-projections requires WALLET_STORAGE=events")
+	}
+	ledgerFeatures := postgresOnly && !eventSourced
+	storage := cfg.DBDriver
+	if eventSourced {
+		storage = "event-sourced"
+	}
+
+	if postgresOnly {
+		dbPool, db, err = postgres.OpenPool(context.Background(), cfg.PostgresURL(), postgres.PoolConfig{
+			MaxConns:               int32(cfg.DBMaxOpenConns),
+			MinConns:               int32(cfg.DBMinConns),
+			MaxConnLifetime:        cfg.DBConnMaxLifetime,
+			MaxConnIdleTime:        cfg.DBConnMaxIdleTime,
+			StatementCacheCapacity: cfg.DBStatementCacheCapacity,
+		})
+		if err != nil {
+			log.Fatal("Error connecting to PostgreSQL:", err)
+		}
+		defer dbPool.Close()
+		if migrateOnly || cfg.DBAutoMigrate {
+			version, err := postgres.Migrate(context.Background(), db, utils.Log)
+			if err != nil {
+				log.Fatal("Error migrating PostgreSQL database:", err)
+			}
+			utils.Log.WithField("version", version).Info("Database schema up to date")
+		}
+		walletRepoOpts := []postgres.WalletRepositoryOption{postgres.WithPool(dbPool)}
+		switch cfg.WalletLocking {
+		case "pessimistic":
+		case "optimistic":
+			walletRepoOpts = append(walletRepoOpts, postgres.WithOptimisticLocking(cfg.WalletOptimisticAttempts))
+		default:
+			log.Fatalf("Unknown WALLET_LOCKING %q", cfg.WalletLocking)
+		}
+		if eventSourced {
+			walletRepoOpts = append(walletRepoOpts, postgres.WithEventStreams())
+		}
+		if !cfg.ImplicitWalletCreation {
+			walletRepoOpts = append(walletRepoOpts, postgres.WithProvisionedWalletsOnly())
+		}
+		walletRepo = postgres.NewWalletRepository(db, utils.Log, walletRepoOpts...)
+	} else {
+		db, err = sqlite.Open(cfg.SQLitePath)
+		if err != nil {
+			log.Fatal("Error opening SQLite database:", err)
+		}
+		if err := sqlite.Migrate(context.Background(), db, utils.Log); err != nil {
+			log.Fatal("Error migrating SQLite database:", err)
+		}
+		var walletRepoOpts []sqlite.WalletRepositoryOption
+		if !cfg.ImplicitWalletCreation {
+			walletRepoOpts = append(walletRepoOpts, sqlite.WithProvisionedWalletsOnly())
+		}
+		walletRepo = sqlite.NewWalletRepository(db, utils.Log, walletRepoOpts...)
+		cacheRepo = memory.NewCacheRepository(time.Hour)
+		cacheStatus = handlers.DependencyInMemory
 	}
 	defer db.Close()
+	if migrateOnly {
+		return
+	}
+	if rebuildOnly {
+		if !rebuildProjections(postgres.NewWalletEventRepository(db, utils.Log)) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var appMetrics *metrics.Metrics
+	if cfg.MetricsEnabled {
+		appMetrics = metrics.New()
+		if dbPool != nil {
+			appMetrics.WatchDBPool(func() metrics.DBPoolStats {
+				stat := dbPool.Stat()
+				return metrics.DBPoolStats{
+					MaxConns:      stat.MaxConns(),
+					TotalConns:    stat.TotalConns(),
+					IdleConns:     stat.IdleConns(),
+					AcquiredConns: stat.AcquiredConns(),
+					Acquires:      stat.AcquireCount(),
+					EmptyAcquires: stat.EmptyAcquireCount(),
+					AcquireWait:   stat.AcquireDuration(),
+				}
+			})
+		}
+	}
+
+	// Readiness probes; the database is required, the cache is not
+	probes := []handlers.Probe{{
+		Name:     "database",
+		Required: true,
+		Timeout:  cfg.HealthDBTimeout,
+		Check:    db.PingContext,
+	}}
 
-	// Initialize Redis
-	redisClient := goredis.NewClient(&goredis.Options{
-		Addr:     cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
-	})
+	// Initialize Redis. Without it the service runs DB-only.
+	if !postgresOnly {
+		utils.Log.Info("Using in-memory balance cache")
+	} else if cfg.RedisDisabled {
+		utils.Log.Warn("Redis disabled, running without balance cache")
+	} else {
+		redisClient = goredis.NewClient(&goredis.Options{
+			Addr:     cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort),
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		defer redisClient.Close()
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := redisClient.Ping(pingCtx).Err()
+		cancel()
+		if err != nil {
+			utils.Log.WithError(err).Warn("Redis unreachable, running without balance cache")
+			cacheStatus = handlers.DependencyUnavailable
+		} else {
+			redisCache = redis.NewCacheRepository(redisClient, time.Hour, utils.Log)
+			cacheRepo = redisCache
+			cacheShortener = redisCache
+			if cfg.CacheBreakerEnabled {
+				// While Redis fails, cache calls fail fast and balances are
+				// read from the database
+				cacheBreaker := services.NewCacheCircuitBreaker(redisCache, services.CacheCircuitConfig{
+					Failures:    cfg.CacheBreakerFailures,
+					OpenTimeout: cfg.CacheBreakerOpenTimeout,
+				}, appMetrics, utils.Log)
+				cacheRepo = cacheBreaker
+				cacheShortener = cacheBreaker
+				probes = append(probes, handlers.Probe{
+					Name:    "cache_circuit",
+					Timeout: cfg.HealthRedisTimeout,
+					Check:   cacheBreaker.Check,
+				})
+			}
+			schedulerLock = redis.NewLeaderLock(redisClient, "scheduler:leader", 3*cfg.SchedulerPollInterval, utils.Log)
+			if cfg.WalletLockEnabled {
+				walletLock = redis.NewWalletLock(redisClient, cfg.WalletLockTTL, cfg.WalletLockWait, utils.Log)
+			}
+			killSwitchRepo = redis.NewKillSwitchRepository(redisClient, utils.Log)
+			rateLimiter = redis.NewRateLimiter(redisClient, utils.Log)
+			redisNotifier = redis.NewBalanceNotifier(redisClient, utils.Log)
+			balanceNotifier = redisNotifier
+			cacheStatus = handlers.DependencyOK
+			probes = append(probes, handlers.Probe{
+				Name:    "cache",
+				Timeout: cfg.HealthRedisTimeout,
+				Check: func(ctx context.Context) error {
+					return redisClient.Ping(ctx).Err()
+				},
+			})
+		}
+	}
+
+	// Every committed balance change refreshes the cache, which announces it
+	// to requests waiting for the balance
+	cacheRepo = redis.NewNotifyingCacheRepository(cacheRepo, balanceNotifier)
 
 	// Initialize services
-	walletRepo := postgres.NewWalletRepository(db, utils.Log)
-	cacheRepo := redis.NewCacheRepository(redisClient, time.Hour, utils.Log)
-	walletService := services.NewWalletService(walletRepo, cacheRepo, utils.Log)
-	walletHandler := handlers.NewWalletHandler(walletService)
+	killSwitchService := services.NewKillSwitchService(killSwitchRepo, utils.Log)
+	idempotencyRepo := postgres.NewIdempotencyRepository(db, cfg.IdempotencyKeyTTL, utils.Log)
+	walletOpts := []services.WalletServiceOption{
+		services.WithIdempotency(idempotencyRepo),
+		services.WithBalanceNotifier(balanceNotifier),
+		services.WithKillSwitches(killSwitchService),
+	}
+	if walletLock != nil {
+		walletOpts = append(walletOpts, services.WithWalletLock(walletLock))
+	}
+	switch cfg.CacheStrategy {
+	case "write-through":
+		walletOpts = append(walletOpts, services.WithWriteThrough())
+	case "invalidate":
+	default:
+		log.Fatalf("Unknown CACHE_STRATEGY %q", cfg.CacheStrategy)
+	}
 
-	// Create router
+	if appMetrics != nil {
+		walletOpts = append(walletOpts, services.WithMetrics(appMetrics))
+	}
+
+	// Balances whose invalidation failed are invalidated again in the
+	// background and expire sooner when read in the meantime
+	var invalidationRetrier *services.CacheInvalidationRetrier
+	if redisCache != nil {
+		invalidationRetrier = services.NewCacheInvalidationRetrier(cacheRepo, cacheShortener, cfg.CacheStaleTTL, appMetrics, utils.Log)
+		cacheRepo = invalidationRetrier
+	}
+
+	rateProvider := newRateProvider(cfg)
+
+	// Pending transfers, withdrawals and queued deposits rely on Postgres row
+	// locking, historical balances and runtime settings on Postgres-specific SQL
+	var holdHandler *handlers.HoldHandler
+	var overdraftHandler *handlers.OverdraftHandler
+	var withdrawalHandler *handlers.WithdrawalHandler
+	var withdrawalRepo postgres.WithdrawalRepository
+	var depositQueueRepo postgres.DepositQueueRepository
+	var settingsHandler *handlers.SettingsHandler
+	var limitsHandler *handlers.LimitsHandler
+	var complianceHandler *handlers.ComplianceHandler
+	var categorizationHandler *handlers.CategorizationHandler
+	var feeHandler *handlers.FeeHandler
+	var apiKeyHandler *handlers.APIKeyHandler
+	var apiKeys handlers.APIKeyAuthenticator
+	var scheduleHandler *handlers.ScheduleHandler
+	var statementHandler *handlers.StatementHandler
+	var scheduleRepo postgres.ScheduleRepository
+	var topUpHandler *handlers.TopUpHandler
+	var topUpRepo postgres.TopUpRepository
+	var roundUpHandler *handlers.RoundUpHandler
+	var roundUpRepo postgres.RoundUpRepository
+	var analyticsHandler *handlers.AnalyticsHandler
+	var snapshotService *services.SnapshotService
+	var batchOpts []services.BatchServiceOption
+	if eventSourced {
+		walletOpts = append(walletOpts, services.WithBalanceHistory(postgres.NewWalletEventRepository(db, utils.Log)))
+	}
+	if ledgerFeatures {
+		settingsService := services.NewSettingsService(postgres.NewSettingsRepository(db, utils.Log), cfg.SettingsRefreshInterval, utils.Log)
+		settingsHandler = handlers.NewSettingsHandler(settingsService)
+		limitsService := services.NewLimitsService(postgres.NewLimitsRepository(db, utils.Log), utils.Log,
+			services.WithAutoApproveRatio(decimal.NewFromFloat(cfg.LimitAutoApproveRatio)))
+		limitsHandler = handlers.NewLimitsHandler(limitsService)
+		complianceService := services.NewComplianceService(postgres.NewComplianceRepository(db, utils.Log), utils.Log)
+		complianceHandler = handlers.NewComplianceHandler(complianceService)
+		apiKeyService := services.NewAPIKeyService(postgres.NewAPIKeyRepository(db, utils.Log), utils.Log)
+		apiKeyHandler = handlers.NewAPIKeyHandler(apiKeyService)
+		apiKeys = apiKeyService
+		categorizationHandler = handlers.NewCategorizationHandler(services.NewCategorizationService(postgres.NewCategorizationRepository(db, utils.Log), utils.Log))
+		feeService := services.NewFeeService(postgres.NewFeeRepository(db, utils.Log), utils.Log)
+		feeHandler = handlers.NewFeeHandler(feeService)
+		holdRepo := postgres.NewHoldRepository(db, utils.Log)
+		overdraftRepo := postgres.NewOverdraftRepository(db, utils.Log)
+		overdraftHandler = handlers.NewOverdraftHandler(services.NewOverdraftService(overdraftRepo, utils.Log))
+		holdHandler = handlers.NewHoldHandler(services.NewHoldService(holdRepo, cacheRepo, settingsService, limitsService, complianceService, killSwitchService, utils.Log))
+		withdrawalRepo = postgres.NewWithdrawalRepository(db, utils.Log)
+		withdrawalHandler = handlers.NewWithdrawalHandler(services.NewWithdrawalService(withdrawalRepo, settingsService, limitsService, complianceService, killSwitchService, feeService, utils.Log))
+		snapshotRepo := postgres.NewSnapshotRepository(db, utils.Log)
+		snapshotService = services.NewSnapshotService(snapshotRepo, cfg.SnapshotLag, utils.Log)
+		statementHandler = handlers.NewStatementHandler(services.NewStatementService(postgres.NewStatementRepository(db, utils.Log), snapshotRepo, utils.Log))
+		depositQueueRepo = postgres.NewDepositQueueRepository(db, utils.Log, !cfg.ImplicitWalletCreation)
+		scheduleRepo = postgres.NewScheduleRepository(db, utils.Log)
+		scheduleHandler = handlers.NewScheduleHandler(services.NewScheduleService(scheduleRepo, utils.Log))
+		topUpRepo = postgres.NewTopUpRepository(db, utils.Log)
+		topUpHandler = handlers.NewTopUpHandler(services.NewTopUpService(topUpRepo, utils.Log))
+		roundUpRepo = postgres.NewRoundUpRepository(db, utils.Log)
+		roundUpHandler = handlers.NewRoundUpHandler(services.NewRoundUpService(roundUpRepo, utils.Log))
+		analyticsHandler = handlers.NewAnalyticsHandler(services.NewAnalyticsService(postgres.NewAnalyticsRepository(db, utils.Log), utils.Log))
+		walletOpts = append(walletOpts,
+			services.WithHolds(holdRepo),
+			services.WithOverdrafts(overdraftRepo),
+			services.WithDepositQueue(depositQueueRepo),
+			services.WithBalanceHistory(snapshotRepo),
+			services.WithSettings(settingsService),
+			services.WithLimits(limitsService),
+			services.WithCompliance(complianceService),
+			services.WithFees(feeService),
+			services.WithConversions(postgres.NewConversionRepository(db, utils.Log), rateProvider),
+		)
+		batchOpts = append(batchOpts, services.WithAtomicBatches())
+	}
+
+	walletService := services.NewWalletService(walletRepo, cacheRepo, utils.Log, walletOpts...)
+	// Transfers above TRANSFER_CONFIRMATION_THRESHOLD wait for their sender to
+	// confirm them with a one-time password
+	var transferConfirmationService *services.TransferConfirmationService
+	var transferConfirmationHandler *handlers.TransferConfirmationHandler
+	if ledgerFeatures && cfg.TransferConfirmationThreshold > 0 {
+		transferConfirmationService = services.NewTransferConfirmationService(
+			postgres.NewTransferConfirmationRepository(db, utils.Log),
+			walletService,
+			newOTPNotifier(cfg),
+			services.TransferConfirmationConfig{
+				Threshold:   decimal.NewFromInt(int64(cfg.TransferConfirmationThreshold)),
+				TTL:         cfg.TransferConfirmationTTL,
+				MaxAttempts: cfg.TransferConfirmationMaxAttempts,
+				BatchSize:   cfg.TransferConfirmationBatchSize,
+			},
+			utils.Log,
+		)
+		transferConfirmationHandler = handlers.NewTransferConfirmationHandler(transferConfirmationService)
+	}
+	walletHandler := handlers.NewWalletHandler(walletService, ledgerFeatures && cfg.AsyncDepositsEnabled, transferConfirmationService, cfg.TransactionHistoryBodyEnabled)
+	batchService := services.NewBatchService(walletService, postgres.NewBatchRepository(db, utils.Log), utils.Log, batchOpts...)
+	batchHandler := handlers.NewBatchHandler(batchService)
+	killSwitchHandler := handlers.NewKillSwitchHandler(killSwitchService)
+	ratesHandler := handlers.NewRatesHandler(rateProvider)
+	healthHandler := handlers.NewHealthHandler(cacheStatus, probes...)
+
+	// Background jobs stop when jobsCtx is cancelled during shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var jobs sync.WaitGroup
+
+	if redisNotifier != nil {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			redisNotifier.Run(jobsCtx)
+		}()
+	}
+
+	if redisCache != nil && cfg.CacheMemoryGuardEnabled {
+		cacheMemoryGuard := services.NewCacheMemoryGuard(
+			redis.NewCacheMemoryRepository(redisClient, utils.Log),
+			redisCache,
+			redis.NewLeaderLock(redisClient, "cache:memory-guard:leader", 3*cfg.CacheMemoryCheckInterval, utils.Log),
+			appMetrics,
+			services.CacheMemoryConfig{
+				Limit:          cfg.CacheMemoryLimit,
+				HighRatio:      cfg.CacheMemoryHighRatio,
+				TTLScale:       cfg.CacheMemoryTTLScale,
+				CriticalRatio:  cfg.CacheMemoryCriticalRatio,
+				EvictBatch:     cfg.CacheMemoryEvictBatch,
+				IdleAfter:      cfg.CacheMemoryIdleAfter,
+				EvictionPolicy: cfg.CacheEvictionPolicy,
+			},
+			utils.Log,
+		)
+		startJob(jobsCtx, &jobs, cacheMemoryGuard.Run, cfg.CacheMemoryCheckInterval)
+	}
+	if redisCache != nil && cfg.CacheWarmEnabled {
+		cacheWarmer := services.NewCacheWarmer(
+			redis.NewCacheWarmRepository(redisClient, utils.Log),
+			walletService,
+			redis.NewLeaderLock(redisClient, "cache:warmer:leader", 3*cfg.CacheWarmInterval, utils.Log),
+			appMetrics,
+			services.CacheWarmConfig{
+				MinReads: cfg.CacheWarmMinReads,
+				Batch:    cfg.CacheWarmBatch,
+				LeadTime: cfg.CacheWarmLeadTime,
+				HalfLife: cfg.CacheWarmHalfLife,
+			},
+			utils.Log,
+		)
+		startJob(jobsCtx, &jobs, cacheWarmer.Run, cfg.CacheWarmInterval)
+	}
+	if invalidationRetrier != nil {
+		startJob(jobsCtx, &jobs, invalidationRetrier.Run, cfg.CacheInvalidationRetryInterval)
+	}
+
+	// The event outbox, transfer acknowledgments which notify senders
+	// through it, wallet metadata and registered users rely on
+	// Postgres-specific SQL. SQLite provisions wallets without events and
+	// keeps arbitrary user IDs.
+	var webhookKeyHandler *handlers.WebhookKeyHandler
+	var webhookSubscriptionHandler *handlers.WebhookSubscriptionHandler
+	var acknowledgmentHandler *handlers.AcknowledgmentHandler
+	var metadataHandler *handlers.MetadataHandler
+	var userHandler *handlers.UserHandler
+	var defaultCurrency string
+	if len(cfg.DefaultCurrencies) > 0 {
+		defaultCurrency = cfg.DefaultCurrencies[0]
+	}
+	var provisioningRepo postgres.ProvisioningRepository = sqlite.NewProvisioningRepository(db, utils.Log)
+	if postgresOnly {
+		provisioningRepo = postgres.NewProvisioningRepository(db, utils.Log)
+	}
+	provisioningHandler := handlers.NewProvisioningHandler(services.NewProvisioningService(provisioningRepo, defaultCurrency, utils.Log))
+	if postgresOnly {
+		userHandler = handlers.NewUserHandler(services.NewUserService(postgres.NewUserRepository(db, utils.Log), cfg.DefaultCurrencies, utils.Log))
+		acknowledgmentHandler = handlers.NewAcknowledgmentHandler(services.NewAcknowledgmentService(postgres.NewAcknowledgmentRepository(db, utils.Log), utils.Log))
+		metadataHandler = handlers.NewMetadataHandler(services.NewMetadataService(postgres.NewMetadataRepository(db, utils.Log), utils.Log))
+		webhookKeyService := services.NewWebhookKeyService(postgres.NewWebhookKeyRepository(db, utils.Log), cfg.WebhookKeyOverlap, utils.Log)
+		webhookKeyHandler = handlers.NewWebhookKeyHandler(webhookKeyService)
+		webhookSubscriptionService := services.NewWebhookSubscriptionService(
+			postgres.NewWebhookSubscriptionRepository(db, utils.Log),
+			services.WebhookBacklogConfig{
+				Limit:       cfg.WebhookBacklogLimit,
+				AlertEvents: cfg.WebhookBacklogAlert,
+				AlertLag:    cfg.WebhookLagAlert,
+			},
+			appMetrics,
+			utils.Log,
+		)
+		webhookSubscriptionHandler = handlers.NewWebhookSubscriptionHandler(webhookSubscriptionService)
+		outboxRelay := services.NewOutboxRelay(postgres.NewOutboxRepository(db, utils.Log), newEventPublisher(cfg, webhookKeyService), cfg.OutboxBatchSize, utils.Log,
+			services.WithWebhookSubscription(webhookSubscriptionService),
+		)
+		startJob(jobsCtx, &jobs, outboxRelay.Run, cfg.OutboxPollInterval)
+	}
+
+	// Freeze jobs, exposures, the deposit queue, the withdrawal worker, the
+	// scheduler, payment requests, the top-up and round-up workers, balance
+	// reconciliation, trial balances, history indexes and data erasure rely
+	// on Postgres-specific SQL
+	var adminHandler *handlers.AdminHandler
+	var payoutHandler *handlers.PayoutHandler
+	var reconciliationHandler *handlers.ReconciliationHandler
+	var trialBalanceHandler *handlers.TrialBalanceHandler
+	var transactionReviewHandler *handlers.TransactionReviewHandler
+	var auditHandler *handlers.AuditHandler
+	var erasureHandler *handlers.ErasureHandler
+	var paymentRequestHandler *handlers.PaymentRequestHandler
+	var notificationHandler *handlers.NotificationHandler
+	if ledgerFeatures {
+		freezeService := services.NewFreezeService(postgres.NewFreezeRepository(db, utils.Log), utils.Log)
+		exposureService := services.NewExposureService(postgres.NewExposureRepository(db, utils.Log), cfg.ExposureWindowsDays, utils.Log)
+		remediationService := services.NewRemediationService(postgres.NewTransactionRepository(db, utils.Log), utils.Log)
+		ownershipService := services.NewOwnershipService(postgres.NewOwnershipRepository(db, utils.Log), cacheRepo, utils.Log)
+		walletAdminService := services.NewWalletAdminService(postgres.NewWalletAdminRepository(db, utils.Log), cacheRepo, utils.Log)
+		adminHandler = handlers.NewAdminHandler(freezeService, exposureService, remediationService, ownershipService, walletAdminService)
+		transactionReviewHandler = handlers.NewTransactionReviewHandler(services.NewTransactionReviewService(postgres.NewTransactionReviewRepository(db, utils.Log), utils.Log))
+		auditHandler = handlers.NewAuditHandler(services.NewAuditService(postgres.NewAuditRepository(db, utils.Log), utils.Log))
+
+		// Start background jobs
+		startJob(jobsCtx, &jobs, exposureService.Run, cfg.ExposureRefreshInterval)
+		startJob(jobsCtx, &jobs, snapshotService.Run, cfg.SnapshotInterval)
+		// The consumer also runs with ASYNC_DEPOSITS_ENABLED=false so deposits
+		// queued before the mode was switched off are still applied
+		depositConsumer := services.NewDepositConsumer(depositQueueRepo, cacheRepo, cfg.DepositQueueBatchSize, cfg.DepositQueueWorkers, utils.Log)
+		startJob(jobsCtx, &jobs, depositConsumer.Run, cfg.DepositQueuePollInterval)
+		payoutRouter := newPayoutRouter(cfg, appMetrics)
+		payoutHandler = handlers.NewPayoutHandler(payoutRouter)
+		withdrawalWorker := services.NewWithdrawalWorker(withdrawalRepo, payoutRouter, cacheRepo, killSwitchService, cfg.WithdrawalBatchSize, cfg.WithdrawalRetryAfter, utils.Log)
+		startJob(jobsCtx, &jobs, withdrawalWorker.Run, cfg.WithdrawalPollInterval)
+		scheduler := services.NewScheduler(scheduleRepo, walletService, schedulerLock, cfg.SchedulerBatchSize, cfg.SchedulerRetryAfter, utils.Log)
+		startJob(jobsCtx, &jobs, scheduler.Run, cfg.SchedulerPollInterval)
+		paymentRequestService := services.NewPaymentRequestService(postgres.NewPaymentRequestRepository(db, utils.Log), walletService, cfg.PaymentRequestTTL, cfg.PaymentRequestBatchSize, utils.Log)
+		paymentRequestHandler = handlers.NewPaymentRequestHandler(paymentRequestService)
+		startJob(jobsCtx, &jobs, paymentRequestService.Run, cfg.PaymentRequestPollInterval)
+		if transferConfirmationService != nil {
+			startJob(jobsCtx, &jobs, transferConfirmationService.Run, cfg.TransferConfirmationPollInterval)
+		}
+		notificationService := services.NewNotificationService(postgres.NewNotificationRepository(db, utils.Log), cfg.NotificationDigestWindow, cfg.NotificationDigestBatchSize, utils.Log)
+		notificationHandler = handlers.NewNotificationHandler(notificationService)
+		startJob(jobsCtx, &jobs, notificationService.Run, cfg.NotificationDigestPollInterval)
+		topUpWorker := services.NewTopUpWorker(topUpRepo, walletService, newFundingProvider(cfg), killSwitchService, cfg.TopUpBatchSize, cfg.TopUpRetryAfter, utils.Log)
+		startJob(jobsCtx, &jobs, topUpWorker.Run, cfg.TopUpPollInterval)
+		roundUpWorker := services.NewRoundUpWorker(roundUpRepo, cacheRepo, killSwitchService, cfg.RoundUpBatchSize, utils.Log)
+		startJob(jobsCtx, &jobs, roundUpWorker.Run, cfg.RoundUpPollInterval)
+		reconciliationService := services.NewReconciliationService(postgres.NewReconciliationRepository(db, utils.Log), appMetrics, cfg.ReconciliationInterval, cfg.ReconciliationBatchSize, utils.Log)
+		reconciliationHandler = handlers.NewReconciliationHandler(reconciliationService)
+		startJob(jobsCtx, &jobs, reconciliationService.Run, cfg.ReconciliationPollInterval)
+		trialBalanceService := services.NewTrialBalanceService(postgres.NewTrialBalanceRepository(db, utils.Log), utils.Log)
+		trialBalanceHandler = handlers.NewTrialBalanceHandler(trialBalanceService)
+		startJob(jobsCtx, &jobs, trialBalanceService.Run, cfg.TrialBalancePollInterval)
+		historyIndexRotator := services.NewHistoryIndexRotator(postgres.NewHistoryIndexRepository(db, utils.Log), utils.Log)
+		startJob(jobsCtx, &jobs, historyIndexRotator.Run, cfg.HistoryIndexInterval)
+		erasureService := services.NewErasureService(postgres.NewErasureRepository(db, utils.Log), cacheRepo, cfg.ErasureRetention, cfg.ErasureBatchSize, utils.Log)
+		erasureHandler = handlers.NewErasureHandler(erasureService)
+		startJob(jobsCtx, &jobs, erasureService.Run, cfg.ErasurePollInterval)
+	}
+
+	// Create router. SQLite has no users table and legacy deployments keep
+	// their user IDs, so neither checks the format of user IDs.
+	strictUserIDs := postgresOnly && !cfg.LegacyUserIDs
+	handlers.ValidateUserIDs(strictUserIDs)
 	router := gin.Default()
+	// Requests with a method their route does not accept get 405 and an
+	// Allow header instead of 404
+	router.HandleMethodNotAllowed = true
 	router.Use(gin.Recovery())
+	router.Use(handlers.RequestIDHandler())
+	router.Use(handlers.TracingHandler())
 	router.Use(handlers.LoggingHandler(utils.Log))
+	if appMetrics != nil {
+		router.Use(handlers.MetricsHandler(appMetrics))
+		router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+	}
+	router.Use(handlers.OptionsHandler(router, cfg.CORSAllowedOrigins))
+	router.Use(handlers.ErrorHandler())
+	router.Use(handlers.DeadlineHandler(cfg.RequestTimeout, map[string]time.Duration{
+		// Long polls wait for a change before the deadline applies
+		"/api/v1/wallets/:userID/balance/wait": handlers.MaxBalanceWait + cfg.RequestTimeout,
+		"/api/v1/wallets/:userID/stream":       handlers.MaxWalletStream,
+	}))
+	router.Use(handlers.ETagHandler())
+	router.NoRoute(handlers.NotFoundHandler)
+	router.NoMethod(handlers.MethodNotAllowedHandler)
+
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/docs", handlers.DocsHandler)
+	router.GET("/docs/openapi.yaml", handlers.OpenAPIHandler)
+
+	authHandler := handlers.AuthHandler(auth.NewVerifier([]byte(cfg.JWTSigningKey), cfg.JWTIssuer), apiKeys)
+
+	// GraphQL serves the wallet operations of the REST routes below through
+	// the same services. A request may both read and move funds, so its
+	// resolvers check scopes and wallet ownership rather than middleware.
+	graphResolver := graph.NewResolver(walletService, transferConfirmationService, strictUserIDs)
+	router.POST("/graphql",
+		authHandler,
+		handlers.MaskingHandler(maskingPolicies),
+		handlers.OperationHandler(operation.ChannelAPI),
+		handlers.GraphQLHandler(graph.NewExecutableSchema(graph.Config{Resolvers: graphResolver}), utils.Log),
+	)
 
 	// Wallet routes
 	v1 := router.Group("/api/v1")
+	v1.GET("/version", handlers.VersionHandler)
+	v1.GET("/webhooks/events", handlers.EventCatalogHandler)
+	authenticated := v1.Group("",
+		authHandler,
+		handlers.RequireScope(),
+		handlers.MaskingHandler(maskingPolicies),
+	)
 	{
-		wallets := v1.Group("/wallets")
-		wallets.POST("/:userID/deposit", walletHandler.Deposit)
-		wallets.POST("/:userID/withdraw", walletHandler.Withdraw)
-		wallets.POST("/:userID/transfer", walletHandler.Transfer)
-		wallets.GET("/:userID/balance", walletHandler.GetBalance)
-		wallets.GET("/:userID/transactions", walletHandler.TransactionHistory)
+		authenticated.GET("/rates", ratesHandler.GetRates)
+
+		// Users are registered by operators before their wallets are created
+		users := authenticated.Group("/users", handlers.RequireAdmin(), handlers.OperationHandler(operation.ChannelAdmin))
+		if userHandler != nil {
+			users.POST("", userHandler.CreateUser)
+			users.GET("", userHandler.FindUser)
+			users.GET("/:userID", userHandler.GetUser)
+		} else {
+			users.Any("", handlers.UnsupportedHandler(storage))
+			users.Any("/*path", handlers.UnsupportedHandler(storage))
+		}
+
+		// Wallets are created by provisioning, unless deposits create them
+		// with IMPLICIT_WALLET_CREATION
+		authenticated.POST("/wallets", handlers.OperationHandler(operation.ChannelAPI), provisioningHandler.CreateWallet)
+
+		// SQLite has no users table, so its wallets keep arbitrary user IDs
+		wallets := authenticated.Group("/wallets/:userID",
+			handlers.RequireWalletOwner(),
+			handlers.RequireUserID(strictUserIDs),
+			handlers.OperationHandler(operation.ChannelAPI),
+		)
+		wallets.POST("", provisioningHandler.Provision)
+		wallets.POST("/deposit", walletHandler.Deposit)
+		wallets.GET("/deposits/:depositID", walletHandler.GetQueuedDeposit)
+		wallets.POST("/withdraw", walletHandler.Withdraw)
+		wallets.POST("/transfer", walletHandler.Transfer)
+		if transferConfirmationHandler != nil {
+			wallets.GET("/transfer-confirmations/:confirmationID", transferConfirmationHandler.GetTransferConfirmation)
+			wallets.POST("/transfer-confirmations/:confirmationID/confirm", transferConfirmationHandler.ConfirmTransfer)
+		}
+		wallets.GET("/balance", walletHandler.GetBalance)
+		wallets.GET("/balance/wait", walletHandler.WaitForBalance)
+		wallets.GET("/stream", walletHandler.StreamWallet)
+		wallets.GET("/transactions", walletHandler.TransactionHistory)
+		wallets.GET("/transactions/sync", walletHandler.SyncTransactions)
+		wallets.GET("/timeline", walletHandler.Timeline)
+		if acknowledgmentHandler != nil {
+			wallets.POST("/transactions/:transactionID/acknowledgment", acknowledgmentHandler.Acknowledge)
+		} else {
+			wallets.POST("/transactions/:transactionID/acknowledgment", handlers.UnsupportedHandler(storage))
+		}
+		wallets.POST("/reconciliation", walletHandler.Reconcile)
+		if statementHandler != nil {
+			wallets.GET("/statements", statementHandler.GetStatement)
+		} else {
+			wallets.GET("/statements", handlers.UnsupportedHandler(storage))
+		}
+		wallets.POST("/transfers/batch", batchHandler.BatchTransfer)
+		wallets.GET("/transfers/batch/:batchID", batchHandler.GetBatch)
+		if withdrawalHandler != nil {
+			wallets.POST("/withdrawals", withdrawalHandler.RequestWithdrawal)
+			wallets.GET("/withdrawals/:withdrawalID", withdrawalHandler.GetWithdrawal)
+		} else {
+			unsupported := handlers.UnsupportedHandler(storage)
+			wallets.POST("/withdrawals", unsupported)
+			wallets.GET("/withdrawals/:withdrawalID", unsupported)
+		}
+		if holdHandler != nil {
+			wallets.POST("/transfers", holdHandler.CreatePendingTransfer)
+			wallets.GET("/transfers/:transferID", holdHandler.GetPendingTransfer)
+			wallets.POST("/transfers/:transferID/capture", holdHandler.Capture)
+			wallets.POST("/transfers/:transferID/cancel", holdHandler.Cancel)
+		} else {
+			unsupported := handlers.UnsupportedHandler(storage)
+			wallets.POST("/transfers", unsupported)
+			wallets.GET("/transfers/:transferID", unsupported)
+			wallets.POST("/transfers/:transferID/capture", unsupported)
+			wallets.POST("/transfers/:transferID/cancel", unsupported)
+		}
+		if scheduleHandler != nil {
+			wallets.POST("/schedules", scheduleHandler.CreateSchedule)
+			wallets.GET("/schedules", scheduleHandler.ListSchedules)
+			wallets.GET("/schedules/:scheduleID", scheduleHandler.GetSchedule)
+			wallets.GET("/schedules/:scheduleID/runs", scheduleHandler.ListRuns)
+			wallets.POST("/schedules/:scheduleID/pause", scheduleHandler.PauseSchedule)
+			wallets.POST("/schedules/:scheduleID/resume", scheduleHandler.ResumeSchedule)
+			wallets.POST("/schedules/:scheduleID/cancel", scheduleHandler.CancelSchedule)
+		} else {
+			wallets.Any("/schedules", handlers.UnsupportedHandler(storage))
+			wallets.Any("/schedules/*path", handlers.UnsupportedHandler(storage))
+		}
+		if paymentRequestHandler != nil {
+			wallets.POST("/requests", paymentRequestHandler.CreatePaymentRequest)
+			wallets.GET("/requests", paymentRequestHandler.ListPaymentRequests)
+			wallets.GET("/requests/:requestID", paymentRequestHandler.GetPaymentRequest)
+			wallets.POST("/requests/:requestID/accept", paymentRequestHandler.AcceptPaymentRequest)
+			wallets.POST("/requests/:requestID/decline", paymentRequestHandler.DeclinePaymentRequest)
+		} else {
+			wallets.Any("/requests", handlers.UnsupportedHandler(storage))
+			wallets.Any("/requests/*path", handlers.UnsupportedHandler(storage))
+		}
+		if metadataHandler != nil {
+			wallets.GET("/metadata", metadataHandler.GetMetadata)
+			wallets.PUT("/metadata", metadataHandler.PutMetadata)
+		} else {
+			wallets.Any("/metadata", handlers.UnsupportedHandler(storage))
+		}
+		if notificationHandler != nil {
+			wallets.GET("/notifications", notificationHandler.GetPreference)
+			wallets.PUT("/notifications", notificationHandler.PutPreference)
+		} else {
+			wallets.Any("/notifications", handlers.UnsupportedHandler(storage))
+		}
+		if topUpHandler != nil {
+			wallets.PUT("/top-up", topUpHandler.PutRule)
+			wallets.GET("/top-up", topUpHandler.GetRule)
+			wallets.DELETE("/top-up", topUpHandler.DeleteRule)
+			wallets.GET("/top-up/runs", topUpHandler.ListRuns)
+			wallets.POST("/top-up/pause", topUpHandler.PauseRule)
+			wallets.POST("/top-up/resume", topUpHandler.ResumeRule)
+		} else {
+			wallets.Any("/top-up", handlers.UnsupportedHandler(storage))
+			wallets.Any("/top-up/*path", handlers.UnsupportedHandler(storage))
+		}
+		if roundUpHandler != nil {
+			wallets.PUT("/round-up", roundUpHandler.PutRule)
+			wallets.GET("/round-up", roundUpHandler.GetRule)
+			wallets.DELETE("/round-up", roundUpHandler.DeleteRule)
+			wallets.GET("/analytics/monthly", analyticsHandler.Monthly)
+		} else {
+			wallets.Any("/round-up", handlers.UnsupportedHandler(storage))
+			wallets.Any("/analytics/*path", handlers.UnsupportedHandler(storage))
+		}
+		if cfg.SandboxEnabled {
+			faucetService := services.NewFaucetService(walletService, rateLimiter, services.FaucetConfig{
+				Amount:    decimal.NewFromInt(int64(cfg.FaucetAmount)),
+				MaxAmount: decimal.NewFromInt(int64(cfg.FaucetMaxAmount)),
+				Limit:     cfg.FaucetRateLimit,
+				Window:    cfg.FaucetRateWindow,
+			}, utils.Log)
+			wallets.POST("/faucet", handlers.NewFaucetHandler(faucetService).Credit)
+		}
+		if limitsHandler != nil {
+			wallets.GET("/limits", limitsHandler.WalletLimitStatus)
+			wallets.POST("/limits/increase-requests", handlers.RequireStepUp(cfg.StepUpMaxAge), limitsHandler.RequestIncrease)
+			wallets.GET("/limits/increase-requests", limitsHandler.ListIncreaseRequests)
+		} else {
+			wallets.Any("/limits", handlers.UnsupportedHandler(storage))
+			wallets.Any("/limits/*path", handlers.UnsupportedHandler(storage))
+		}
+	}
+
+	// Admin routes
+	admin := authenticated.Group("/admin", handlers.RequireAdmin(), handlers.OperationHandler(operation.ChannelAdmin))
+	if adminHandler != nil {
+		admin.POST("/freeze-jobs/preview", adminHandler.PreviewFreeze)
+		admin.POST("/freeze-jobs", adminHandler.StartFreeze)
+		admin.GET("/freeze-jobs/:jobID", adminHandler.GetFreezeJob)
+		admin.POST("/freeze-jobs/:jobID/unfreeze", adminHandler.UnfreezeCohort)
+		admin.GET("/exposures", adminHandler.ListExposures)
+		admin.GET("/transactions", adminHandler.ListStuckTransactions)
+		admin.POST("/transactions/:transactionID/remediate", adminHandler.RemediateTransaction)
+		admin.GET("/transactions/:transactionID/review", transactionReviewHandler.GetReview)
+		admin.PUT("/transactions/:transactionID/flags/:flag", transactionReviewHandler.SetFlag)
+		admin.DELETE("/transactions/:transactionID/flags/:flag", transactionReviewHandler.RemoveFlag)
+		admin.POST("/transactions/:transactionID/annotations", transactionReviewHandler.Annotate)
+		admin.GET("/audit-log", auditHandler.ListAuditEntries)
+		admin.GET("/wallets", adminHandler.ListWallets)
+		admin.POST("/wallets/:userID/freeze", adminHandler.FreezeWallet)
+		admin.POST("/wallets/:userID/unfreeze", adminHandler.UnfreezeWallet)
+		admin.POST("/wallets/:userID/close", adminHandler.CloseWallet)
+		admin.POST("/wallets/:userID/adjustments", adminHandler.AdjustBalance)
+		admin.PUT("/wallets/:userID/currency", adminHandler.SetWalletCurrency)
+		admin.GET("/wallets/:userID/overdraft", overdraftHandler.GetOverdraft)
+		admin.PUT("/wallets/:userID/overdraft", overdraftHandler.SetOverdraft)
+		admin.POST("/wallets/:userID/reassign", adminHandler.ReassignWallet)
+		admin.POST("/wallets/:userID/merge", adminHandler.MergeWallets)
+		admin.POST("/wallets/:userID/erasure", erasureHandler.RequestErasure)
+		admin.GET("/erasures", erasureHandler.ListErasures)
+		admin.GET("/erasures/:erasureID", erasureHandler.GetErasure)
+		admin.POST("/erasures/:erasureID/cancel", erasureHandler.CancelErasure)
+		admin.GET("/reconciliations", reconciliationHandler.ListRuns)
+		admin.POST("/reconciliations", reconciliationHandler.StartRun)
+		admin.GET("/reconciliations/:runID", reconciliationHandler.GetRun)
+		admin.GET("/reconciliations/:runID/reports", reconciliationHandler.ListReports)
+		admin.GET("/trial-balances", trialBalanceHandler.ListTrialBalances)
+		admin.GET("/trial-balances/:day", trialBalanceHandler.GetTrialBalance)
+		admin.GET("/settings", settingsHandler.ListSettings)
+		admin.GET("/settings/effective", settingsHandler.EffectiveSettings)
+		admin.GET("/settings/changes", settingsHandler.ListSettingChanges)
+		admin.PUT("/settings/:key", settingsHandler.PutSetting)
+		admin.DELETE("/settings/:key", settingsHandler.DeleteSetting)
+		admin.GET("/limits", limitsHandler.ListDefaultLimits)
+		admin.PUT("/limits/:operation/:kind", limitsHandler.PutLimit)
+		admin.DELETE("/limits/:operation/:kind", limitsHandler.DeleteLimit)
+		admin.GET("/wallets/:userID/limits", limitsHandler.ListWalletLimits)
+		admin.PUT("/wallets/:userID/limits/:operation/:kind", limitsHandler.PutLimit)
+		admin.DELETE("/wallets/:userID/limits/:operation/:kind", limitsHandler.DeleteLimit)
+		admin.GET("/limits/increase-requests", limitsHandler.ListIncreaseRequests)
+		admin.POST("/limits/increase-requests/:requestID/approve", limitsHandler.ApproveIncrease)
+		admin.POST("/limits/increase-requests/:requestID/reject", limitsHandler.RejectIncrease)
+		admin.GET("/compliance/policies", complianceHandler.ListPolicies)
+		admin.GET("/compliance/policies/:jurisdiction", complianceHandler.PolicyVersions)
+		admin.PUT("/compliance/policies/:jurisdiction", complianceHandler.PutPolicy)
+		admin.GET("/wallets/:userID/compliance-decisions", complianceHandler.ListDecisions)
+		admin.GET("/categorization/rules", categorizationHandler.ListRules)
+		admin.POST("/categorization/rules", categorizationHandler.CreateRule)
+		admin.DELETE("/categorization/rules/:ruleID", categorizationHandler.DeleteRule)
+		admin.POST("/categorization/recategorize", categorizationHandler.StartRecategorization)
+		admin.GET("/categorization/recategorize", categorizationHandler.GetRecategorization)
+		admin.GET("/fees", feeHandler.ListFees)
+		admin.POST("/fees", feeHandler.CreateFee)
+		admin.PUT("/fees/:feeID", feeHandler.UpdateFee)
+		admin.DELETE("/fees/:feeID", feeHandler.DeleteFee)
+		admin.GET("/kill-switches", killSwitchHandler.ListKillSwitches)
+		admin.PUT("/kill-switches/:operation", killSwitchHandler.DisableOperation)
+		admin.DELETE("/kill-switches/:operation", killSwitchHandler.EnableOperation)
+		admin.GET("/payout-providers", payoutHandler.ListProviders)
+		apiKeyAdmin := admin.Group("/api-keys", handlers.RequireBearerToken())
+		apiKeyAdmin.GET("", apiKeyHandler.ListKeys)
+		apiKeyAdmin.POST("", apiKeyHandler.CreateKey)
+		apiKeyAdmin.POST("/:keyID/revoke", apiKeyHandler.RevokeKey)
+		admin.GET("/webhooks/signing-keys", webhookKeyHandler.ListKeys)
+		admin.POST("/webhooks/signing-keys/rotate", webhookKeyHandler.RotateKey)
+		admin.POST("/webhooks/signing-keys/:keyID/expire", webhookKeyHandler.ExpireKey)
+		admin.GET("/webhooks/subscription", webhookSubscriptionHandler.GetSubscription)
+		admin.POST("/webhooks/subscription/pause", webhookSubscriptionHandler.PauseSubscription)
+		admin.POST("/webhooks/subscription/resume", webhookSubscriptionHandler.ResumeSubscription)
+	} else {
+		admin.Any("/*path", handlers.UnsupportedHandler(storage))
 	}
 
 	// Start server
 	port := ":" + cfg.ServerPort
+	server := &http.Server{Addr: port, Handler: handlers.HeadHandler(router)}
+	// Waiting balance requests return at once instead of holding up shutdown
+	server.RegisterOnShutdown(balanceNotifier.Close)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
 	log.Printf("Server starting on port %s", port)
-	log.Fatal(router.Run(port))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			utils.Log.WithError(err).Error("Server failed")
+		}
+	case <-ctx.Done():
+		utils.Log.WithField("timeout", cfg.ShutdownTimeout).Info("Shutting down, draining in-flight requests")
+	}
+
+	// Stop accepting connections and drain in-flight requests, then stop the
+	// background jobs and cache writes before the deferred Close calls release
+	// the database and Redis clients
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		utils.Log.WithError(err).Warn("Shutdown timed out with requests still in flight")
+	}
+
+	stopJobs()
+	if err := walletService.Close(shutdownCtx); err != nil {
+		utils.Log.WithError(err).Warn("Shutdown cancelled pending cache writes")
+	}
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		utils.Log.Info("Server stopped")
+	case <-shutdownCtx.Done():
+		utils.Log.Warn("Shutdown timed out waiting for background jobs")
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		utils.Log.WithError(err).Warn("Flush pending spans failed")
+	}
+}
+
+// rebuildProjections verifies the hash chains of the wallet event streams and
+// rebuilds the balances projected from them, refusing to project streams
+// that were tampered with. It reports whether the projections were rebuilt.
+func rebuildProjections(repo postgres.WalletEventRepository) bool {
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "rebuild-projections", Channel: operation.ChannelJob})
+
+	verification, err := repo.VerifyStreams(ctx)
+	if err != nil {
+		utils.Log.WithError(err).Error("Verify wallet event streams failed")
+		return false
+	}
+	logger := utils.Log.WithFields(logrus.Fields{"streams": verification.Streams, "events": verification.Events})
+	if len(verification.Breaks) > 0 {
+		for _, b := range verification.Breaks {
+			utils.Log.WithFields(logrus.Fields{"userID": b.UserID, "version": b.Version}).Error("Wallet event stream broken")
+		}
+		logger.WithField("broken", len(verification.Breaks)).Error("Wallet event streams were tampered with, projections not rebuilt")
+		return false
+	}
+	logger.Info("Wallet event streams verified")
+
+	if _, err := repo.RebuildProjections(ctx); err != nil {
+		utils.Log.WithError(err).Error("Rebuild wallet projections failed")
+		return false
+	}
+	return true
+}
+
+// startJob runs a periodic background job until ctx is cancelled
+func startJob(ctx context.Context, wg *sync.WaitGroup, run func(context.Context, time.Duration), interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		run(ctx, interval)
+	}()
+}
+
+// newEventPublisher returns the publisher selected by EVENT_PUBLISHER.
+// Webhook deliveries are signed with the keys of keys.
+func newEventPublisher(cfg *config.Config, keys events.SigningKeySource) events.Publisher {
+	switch cfg.EventPublisher {
+	case "webhook":
+		if cfg.EventWebhookURL == "" {
+			log.Fatal("EVENT_WEBHOOK_URL must be set for the webhook publisher")
+		}
+		return events.NewWebhookPublisher(cfg.EventWebhookURL, cfg.EventWebhookTimeout, keys)
+	case "log":
+		return events.NewLogPublisher(utils.Log)
+	default:
+		log.Fatalf("Unknown EVENT_PUBLISHER %q", cfg.EventPublisher)
+		return nil
+	}
+}
+
+// newOTPNotifier returns the notifier selected by OTP_NOTIFIER, which
+// delivers the one-time passwords of transfer confirmations
+func newOTPNotifier(cfg *config.Config) otp.Notifier {
+	switch cfg.OTPNotifier {
+	case "webhook":
+		if cfg.OTPWebhookURL == "" {
+			log.Fatal("OTP_WEBHOOK_URL must be set for the webhook notifier")
+		}
+		return otp.NewWebhookNotifier(cfg.OTPWebhookURL, cfg.OTPWebhookTimeout)
+	case "log":
+		return otp.NewLogNotifier(utils.Log)
+	default:
+		log.Fatalf("Unknown OTP_NOTIFIER %q", cfg.OTPNotifier)
+		return nil
+	}
+}
+
+// newPayoutRouter returns the payout providers selected by PAYOUT_PROVIDER.
+// PAYOUT_WEBHOOK_URL lists one or more webhook providers as name=url pairs
+// separated by commas; a URL without a name is named "webhook". PAYOUT_ROUTES
+// holds the routing rules of providers by name.
+func newPayoutRouter(cfg *config.Config, appMetrics *metrics.Metrics) *payouts.Router {
+	var providers []payouts.NamedProvider
+	switch cfg.PayoutProvider {
+	case "webhook":
+		if cfg.PayoutWebhookURL == "" {
+			log.Fatal("PAYOUT_WEBHOOK_URL must be set for the webhook payout provider")
+		}
+		names := map[string]bool{}
+		for _, entry := range strings.Split(cfg.PayoutWebhookURL, ",") {
+			name, url, found := strings.Cut(strings.TrimSpace(entry), "=")
+			if !found {
+				name, url = "webhook", name
+			}
+			if name == "" || url == "" || names[name] {
+				log.Fatalf("Invalid PAYOUT_WEBHOOK_URL entry %q: each provider needs a distinct name and a URL", entry)
+			}
+			names[name] = true
+			providers = append(providers, payouts.NamedProvider{
+				Name:     name,
+				Provider: payouts.NewWebhookProvider(url, cfg.PayoutWebhookTimeout),
+			})
+		}
+	case "log":
+		providers = append(providers, payouts.NamedProvider{Name: "log", Provider: payouts.NewLogProvider(utils.Log)})
+	default:
+		log.Fatalf("Unknown PAYOUT_PROVIDER %q", cfg.PayoutProvider)
+	}
+
+	rules, err := payouts.ParseRules(cfg.PayoutRoutes)
+	if err != nil {
+		log.Fatalf("Invalid PAYOUT_ROUTES: %v", err)
+	}
+	for i, provider := range providers {
+		providers[i].Rules = rules[provider.Name]
+		delete(rules, provider.Name)
+	}
+	for name := range rules {
+		log.Fatalf("PAYOUT_ROUTES has rules for %q, which is not a configured payout provider", name)
+	}
+	return payouts.NewRouter(providers, appMetrics)
+}
+
+// newFundingProvider returns the funding provider selected by
+// FUNDING_PROVIDER
+func newFundingProvider(cfg *config.Config) funding.FundingProvider {
+	switch cfg.FundingProvider {
+	case "webhook":
+		if cfg.FundingWebhookURL == "" {
+			log.Fatal("FUNDING_WEBHOOK_URL must be set for the webhook funding provider")
+		}
+		return funding.NewWebhookProvider(cfg.FundingWebhookURL, cfg.FundingWebhookTimeout)
+	case "log":
+		return funding.NewLogProvider(utils.Log)
+	default:
+		log.Fatalf("Unknown FUNDING_PROVIDER %q", cfg.FundingProvider)
+		return nil
+	}
+}
+
+// newRateProvider returns the exchange rate provider selected by
+// FX_PROVIDER: the FX_RATES table, or the rates served at FX_RATES_URL
+func newRateProvider(cfg *config.Config) rates.FXRateProvider {
+	switch cfg.FXProvider {
+	case "http":
+		if cfg.FXRatesURL == "" {
+			log.Fatal("FX_RATES_URL must be set for the http rate provider")
+		}
+		return rates.NewHTTPProvider(cfg.FXRatesURL, cfg.FXRatesTimeout, cfg.FXRatesTTL)
+	case "static":
+		table, err := rates.ParseTable(cfg.FXBaseCurrency, cfg.FXRates)
+		if err != nil {
+			log.Fatalf("Invalid FX_RATES: %v", err)
+		}
+		return rates.NewStaticProvider(table)
+	default:
+		log.Fatalf("Unknown FX_PROVIDER %q", cfg.FXProvider)
+		return nil
+	}
 }
@@ -0,0 +1,120 @@
+// Package apierror defines the error envelope returned by every API endpoint
+// and the machine-readable codes clients can rely on. Codes are stable:
+// messages may be reworded, codes are only ever added.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Codes returned in the code field of error responses and in the error_code
+// of failed batch items
+const (
+	CodeInvalidRequest           = "INVALID_REQUEST"
+	CodeUnauthorized             = "UNAUTHORIZED"
+	CodeStepUpRequired           = "STEP_UP_REQUIRED"
+	CodeForbidden                = "FORBIDDEN"
+	CodeInsufficientScope        = "INSUFFICIENT_SCOPE"
+	CodeNotFound                 = "NOT_FOUND"
+	CodeMethodNotAllowed         = "METHOD_NOT_ALLOWED"
+	CodeConflict                 = "CONFLICT"
+	CodeNotImplemented           = "NOT_IMPLEMENTED"
+	CodeInternal                 = "INTERNAL_ERROR"
+	CodeInvalidAmount            = "INVALID_AMOUNT"
+	CodeInvalidUserID            = "INVALID_USER_ID"
+	CodeInvalidCursor            = "INVALID_CURSOR"
+	CodeUserNotFound             = "USER_NOT_FOUND"
+	CodeUserExists               = "USER_EXISTS"
+	CodeInsufficientBalance      = "INSUFFICIENT_BALANCE"
+	CodeBalanceMismatch          = "BALANCE_MISMATCH"
+	CodeWalletFrozen             = "WALLET_FROZEN"
+	CodeWalletNotFrozen          = "WALLET_NOT_FROZEN"
+	CodeWalletClosed             = "WALLET_CLOSED"
+	CodeWalletNotEmpty           = "WALLET_NOT_EMPTY"
+	CodeWalletExists             = "WALLET_EXISTS"
+	CodePendingTransfers         = "PENDING_TRANSFERS"
+	CodeTransferNotPending       = "TRANSFER_NOT_PENDING"
+	CodeAmountExceedsLimit       = "AMOUNT_EXCEEDS_LIMIT"
+	CodeLimitExceeded            = "LIMIT_EXCEEDED"
+	CodeComplianceDenied         = "COMPLIANCE_DENIED"
+	CodeIdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeWalletBusy               = "WALLET_BUSY"
+	CodeReconciliationTooLarge   = "RECONCILIATION_TOO_LARGE"
+	CodeOperationDisabled        = "OPERATION_DISABLED"
+	CodeCurrencyMismatch         = "CURRENCY_MISMATCH"
+	CodeUnsupportedCurrency      = "UNSUPPORTED_CURRENCY"
+	CodeRatesUnavailable         = "RATES_UNAVAILABLE"
+	CodeRequestTimeout           = "REQUEST_TIMEOUT"
+	CodeRateLimited              = "RATE_LIMITED"
+	CodeInvalidConfirmationCode  = "INVALID_CONFIRMATION_CODE"
+	CodeConfirmationUndelivered  = "CONFIRMATION_UNDELIVERED"
+)
+
+// Error is the JSON envelope of an error response. Status is the HTTP
+// status it is served with.
+type Error struct {
+	Status  int         `json:"-"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest reports an invalid request body or query
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// Internal reports an unexpected failure. The cause is logged, never
+// returned to the client.
+func Internal() *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// WithDetails returns a copy of e carrying details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Rule maps errors matching Err to a status and code. The message is the
+// text of Err unless Message is set, so wrapping context never leaks.
+type Rule struct {
+	Err     error
+	Status  int
+	Code    string
+	Message string
+}
+
+// Mapper translates errors into envelopes with the first matching rule
+type Mapper []Rule
+
+// Map returns err itself when it is an *Error, the envelope of the first
+// rule matching err, or Internal for errors no rule knows
+func (m Mapper) Map(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, rule := range m {
+		if !errors.Is(err, rule.Err) {
+			continue
+		}
+		message := rule.Message
+		if message == "" {
+			message = rule.Err.Error()
+		}
+		return New(rule.Status, rule.Code, message)
+	}
+	return Internal()
+}
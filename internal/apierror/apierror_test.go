@@ -0,0 +1,53 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errOutOfFunds = errors.New("insufficient balance")
+
+func TestMapper_Map(t *testing.T) {
+	mapper := Mapper{
+		{Err: errOutOfFunds, Status: http.StatusBadRequest, Code: CodeInsufficientBalance},
+	}
+
+	t.Run("wrapped errors use the rule and its message", func(t *testing.T) {
+		apiErr := mapper.Map(fmt.Errorf("withdraw user1: %w", errOutOfFunds))
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		assert.Equal(t, CodeInsufficientBalance, apiErr.Code)
+		assert.Equal(t, "insufficient balance", apiErr.Message)
+	})
+
+	t.Run("envelopes are returned as is", func(t *testing.T) {
+		notFound := New(http.StatusNotFound, CodeNotFound, "route not found")
+		assert.Same(t, notFound, mapper.Map(notFound))
+	})
+
+	t.Run("unknown errors are internal and hide their cause", func(t *testing.T) {
+		apiErr := mapper.Map(errors.New("dial tcp 10.0.0.3:5432: connection refused"))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+		assert.Equal(t, CodeInternal, apiErr.Code)
+		assert.Equal(t, "internal server error", apiErr.Message)
+	})
+}
+
+func TestError_JSON(t *testing.T) {
+	base := BadRequest("amount is required")
+	withDetails := base.WithDetails(map[string]string{"field": "amount"})
+	assert.Nil(t, base.Details)
+
+	body, err := json.Marshal(base)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"INVALID_REQUEST","message":"amount is required"}`, string(body))
+
+	body, err = json.Marshal(withDetails)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"INVALID_REQUEST","message":"amount is required","details":{"field":"amount"}}`, string(body))
+}
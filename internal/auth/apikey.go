@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// API key scopes. Read keys may only make GET requests; money movement keys
+// may also deposit, withdraw and transfer, and read as well.
+const (
+	ScopeRead          = "read"
+	ScopeMoneyMovement = "money_movement"
+)
+
+// apiKeyPrefix marks API keys so they are recognizable in logs and by
+// secret scanners
+const apiKeyPrefix = "wk_"
+
+var (
+	ErrInvalidAPIKey     = errors.New("invalid API key")
+	ErrInsufficientScope = errors.New("API key scope does not allow this request")
+)
+
+// NewAPIKey generates an API key. The key is shown to its owner once; only
+// the ID and the hash of the secret are stored.
+func NewAPIKey() (id, key string, secretHash []byte) {
+	idBytes := make([]byte, 8)
+	secret := make([]byte, 32)
+	_, _ = rand.Read(idBytes)
+	_, _ = rand.Read(secret)
+
+	id = hex.EncodeToString(idBytes)
+	encoded := hex.EncodeToString(secret)
+	return id, apiKeyPrefix + id + "_" + encoded, HashAPIKeySecret(encoded)
+}
+
+// ParseAPIKey splits an API key into the ID it is stored under and its secret
+func ParseAPIKey(key string) (id, secret string, err error) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", "", ErrInvalidAPIKey
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+	return id, secret, nil
+}
+
+// HashAPIKeySecret hashes the secret of an API key for storage. Secrets are
+// random, so a fast hash cannot be brute-forced the way passwords can.
+func HashAPIKeySecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// MatchAPIKeySecret compares a secret with a stored hash in constant time
+func MatchAPIKeySecret(secret string, hash []byte) bool {
+	return subtle.ConstantTimeCompare(HashAPIKeySecret(secret), hash) == 1
+}
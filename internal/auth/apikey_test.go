@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	id, key, hash := NewAPIKey()
+	assert.True(t, strings.HasPrefix(key, "wk_"+id+"_"))
+
+	parsedID, secret, err := ParseAPIKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, id, parsedID)
+	assert.True(t, MatchAPIKeySecret(secret, hash))
+	assert.False(t, MatchAPIKeySecret(secret+"0", hash))
+
+	otherID, otherKey, _ := NewAPIKey()
+	assert.NotEqual(t, id, otherID)
+	assert.NotEqual(t, key, otherKey)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	for _, key := range []string{"", "wk_", "wk_abc", "wk__secret", "wk_abc_", "ak_abc_secret"} {
+		_, _, err := ParseAPIKey(key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, key)
+	}
+}
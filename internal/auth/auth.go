@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"Crypto.com/internal/models"
+)
+
+// RoleAdmin grants access to every wallet and to the admin API
+const RoleAdmin = "admin"
+
+// RoleInternal marks trusted internal services, such as upstream settlement
+// systems, whose deposits may be queued and acknowledged with 202 Accepted
+const RoleInternal = "internal"
+
+// RoleSupport and RoleAuditor may read every wallet without changing any.
+// What support staff see is limited by the masking policies.
+const (
+	RoleSupport = "support"
+	RoleAuditor = "auditor"
+)
+
+// MethodMFA is the authentication method reference (RFC 8176) identity
+// providers put in the amr claim of tokens issued after multi-factor
+// authentication
+const MethodMFA = "mfa"
+
+var (
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrStepUpRequired = errors.New("recent multi-factor authentication required")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Roles   []string
+	// StepUpAt is when the caller last completed multi-factor
+	// authentication, zero if the token does not show one
+	StepUpAt time.Time
+	// APIKeyID identifies the API key the caller authenticated with, empty
+	// for bearer tokens
+	APIKeyID string
+	// Scopes limit what an API key may do. Callers with a bearer token are
+	// not limited.
+	Scopes []string
+}
+
+// IsAdmin reports whether the principal has the admin role
+func (p Principal) IsAdmin() bool {
+	return slices.Contains(p.Roles, RoleAdmin)
+}
+
+// IsInternal reports whether the principal is a trusted internal service
+func (p Principal) IsInternal() bool {
+	return slices.Contains(p.Roles, RoleInternal)
+}
+
+// CanAccess reports whether the principal may operate on the wallet of
+// userID, or on a currency sub-account of their own
+func (p Principal) CanAccess(userID string) bool {
+	return p.Subject == models.AccountOwner(userID) || p.IsAdmin()
+}
+
+// SteppedUpWithin reports whether the caller completed multi-factor
+// authentication within maxAge
+func (p Principal) SteppedUpWithin(maxAge time.Duration) bool {
+	return !p.StepUpAt.IsZero() && time.Since(p.StepUpAt) <= maxAge
+}
+
+// HasScope reports whether the principal may make requests of scope. Money
+// movement keys may also read.
+func (p Principal) HasScope(scope string) bool {
+	if p.APIKeyID == "" || slices.Contains(p.Scopes, scope) {
+		return true
+	}
+	return scope == ScopeRead && slices.Contains(p.Scopes, ScopeMoneyMovement)
+}
+
+// CanRead reports whether the principal may read the wallet of userID
+func (p Principal) CanRead(userID string) bool {
+	return p.CanAccess(userID) || slices.Contains(p.Roles, RoleSupport) || slices.Contains(p.Roles, RoleAuditor)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal stored in ctx, if any
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Claims are the JWT claims accepted by the service. The subject is the
+// user ID of the wallet owner. AMR and AuthTime are the OpenID Connect
+// authentication methods and time of the user's last login.
+type Claims struct {
+	Roles    []string         `json:"roles,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Sign issues an HS256 token for subject with roles that expires after ttl.
+// It is meant for bootstrapping; users get their tokens from the identity
+// provider.
+func Sign(signingKey []byte, issuer, subject string, roles []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey)
+}
+
+// Verifier validates HMAC-signed JWTs
+type Verifier struct {
+	key    []byte
+	issuer string
+}
+
+func NewVerifier(signingKey []byte, issuer string) *Verifier {
+	return &Verifier{key: signingKey, issuer: issuer}
+}
+
+// Verify checks the token signature, expiry and issuer and returns the
+// principal it identifies
+func (v *Verifier) Verify(token string) (Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return v.key, nil
+	}, options...)
+	if err != nil {
+		return Principal{}, errors.Join(ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return Principal{}, ErrInvalidToken
+	}
+
+	principal := Principal{Subject: claims.Subject, Roles: claims.Roles}
+	if slices.Contains(claims.AMR, MethodMFA) && claims.AuthTime != nil {
+		principal.StepUpAt = claims.AuthTime.Time
+	}
+	return principal, nil
+}
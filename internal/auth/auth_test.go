@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(t *testing.T, key string, claims Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifier(t *testing.T) {
+	verifier := NewVerifier([]byte("secret"), "wallet_app")
+	valid := jwt.RegisteredClaims{
+		Subject:   "user1",
+		Issuer:    "wallet_app",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		principal, err := verifier.Verify(sign(t, "secret", Claims{Roles: []string{RoleAdmin}, RegisteredClaims: valid}))
+		require.NoError(t, err)
+		assert.Equal(t, "user1", principal.Subject)
+		assert.True(t, principal.IsAdmin())
+	})
+
+	t.Run("step-up", func(t *testing.T) {
+		authTime := jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+		principal, err := verifier.Verify(sign(t, "secret", Claims{AMR: []string{"pwd", MethodMFA}, AuthTime: authTime, RegisteredClaims: valid}))
+		require.NoError(t, err)
+		assert.True(t, principal.SteppedUpWithin(5*time.Minute))
+		assert.False(t, principal.SteppedUpWithin(time.Minute))
+
+		principal, err = verifier.Verify(sign(t, "secret", Claims{AMR: []string{"pwd"}, AuthTime: authTime, RegisteredClaims: valid}))
+		require.NoError(t, err)
+		assert.False(t, principal.SteppedUpWithin(5*time.Minute))
+	})
+
+	t.Run("wrong signing key", func(t *testing.T) {
+		_, err := verifier.Verify(sign(t, "other", Claims{RegisteredClaims: valid}))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := valid
+		claims.Issuer = "someone_else"
+		_, err := verifier.Verify(sign(t, "secret", Claims{RegisteredClaims: claims}))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		claims := valid
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		_, err := verifier.Verify(sign(t, "secret", Claims{RegisteredClaims: claims}))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("missing subject", func(t *testing.T) {
+		claims := valid
+		claims.Subject = ""
+		_, err := verifier.Verify(sign(t, "secret", Claims{RegisteredClaims: claims}))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestSign(t *testing.T) {
+	token, err := Sign([]byte("secret"), "wallet_app", "admin", []string{RoleAdmin}, time.Hour)
+	require.NoError(t, err)
+
+	principal, err := NewVerifier([]byte("secret"), "wallet_app").Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", principal.Subject)
+	assert.True(t, principal.IsAdmin())
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	assert.True(t, Principal{Subject: "user1"}.CanAccess("user1"))
+	assert.False(t, Principal{Subject: "user1"}.CanAccess("user2"))
+	assert.True(t, Principal{Subject: "ops", Roles: []string{RoleAdmin}}.CanAccess("user2"))
+	// The internal role marks the caller as trusted, it grants no access
+	assert.False(t, Principal{Subject: "settlement", Roles: []string{RoleInternal}}.CanAccess("user2"))
+	assert.True(t, Principal{Subject: "settlement", Roles: []string{RoleInternal}}.IsInternal())
+	// Owners operate on the wallets of their other default currencies
+	assert.True(t, Principal{Subject: "user1"}.CanAccess("user1:EUR"))
+	assert.False(t, Principal{Subject: "user1"}.CanAccess("user2:EUR"))
+	assert.False(t, Principal{Subject: "user1"}.CanAccess("savings:user1"))
+}
+
+func TestPrincipal_CanRead(t *testing.T) {
+	assert.True(t, Principal{Subject: "user1"}.CanRead("user1"))
+	assert.False(t, Principal{Subject: "user1"}.CanRead("user2"))
+	assert.True(t, Principal{Subject: "agent7", Roles: []string{RoleSupport}}.CanRead("user2"))
+	assert.True(t, Principal{Subject: "kpmg", Roles: []string{RoleAuditor}}.CanRead("user2"))
+	// Reading does not grant operating on the wallet
+	assert.False(t, Principal{Subject: "agent7", Roles: []string{RoleSupport}}.CanAccess("user2"))
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	// Bearer tokens are not limited by scopes
+	assert.True(t, Principal{Subject: "user1"}.HasScope(ScopeMoneyMovement))
+
+	read := Principal{Subject: "reporting", APIKeyID: "k1", Scopes: []string{ScopeRead}}
+	assert.True(t, read.HasScope(ScopeRead))
+	assert.False(t, read.HasScope(ScopeMoneyMovement))
+
+	movement := Principal{Subject: "settlement", APIKeyID: "k2", Scopes: []string{ScopeMoneyMovement}}
+	assert.True(t, movement.HasScope(ScopeMoneyMovement))
+	assert.True(t, movement.HasScope(ScopeRead))
+}
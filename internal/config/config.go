@@ -1,9 +1,26 @@
 package config
 
 import (
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"Crypto.com/internal/masking"
+)
+
+// Storage drivers
+const (
+	DBDriverPostgres = "postgres"
+	DBDriverSQLite   = "sqlite"
+)
+
+// Wallet storage engines on PostgreSQL
+const (
+	WalletStorageState  = "state"
+	WalletStorageEvents = "events"
 )
 
 type Config struct {
@@ -11,48 +28,431 @@ type Config struct {
 	LogPath string
 
 	// Database related
-	DBHost            string
-	DBPort            string
-	DBUser            string
-	DBPassword        string
-	DBName            string
-	DBSSLMode         string
-	ServerPort        string
-	Environment       string
-	DBMaxOpenConns    int
-	DBMaxIdleConns    int
+	DBDriver        string
+	SQLitePath      string
+	DBHost          string
+	DBPort          string
+	DBUser          string
+	DBPassword      string
+	DBName          string
+	DBSSLMode       string
+	ServerPort      string
+	ShutdownTimeout time.Duration
+	// Deadline of a request, zero for none
+	RequestTimeout time.Duration
+	// Origins browsers may call the API from, none when empty and any with *
+	CORSAllowedOrigins []string
+	Environment        string
+	DBMaxOpenConns     int
+	// Connections the pool keeps open even when idle
+	DBMinConns        int
 	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// Prepared statements cached per connection, zero behind PgBouncer in
+	// transaction pooling mode
+	DBStatementCacheCapacity int
+	// Apply pending schema migrations on startup
+	DBAutoMigrate bool
 
 	// Redis related
 	RedisHost     string
 	RedisPort     int
 	RedisPassword string
 	RedisDB       int
+	RedisDisabled bool
+	// How committed operations update cached balances: "write-through" or
+	// "invalidate"
+	CacheStrategy string
+	// Per-wallet locks serializing withdrawals and transfers across instances
+	WalletLockEnabled bool
+	WalletLockTTL     time.Duration
+	WalletLockWait    time.Duration
+	// Memory guard of the Redis balance cache. Ratios are fractions of
+	// CacheMemoryLimit, or of the Redis maxmemory when zero.
+	CacheMemoryGuardEnabled  bool
+	CacheMemoryCheckInterval time.Duration
+	CacheMemoryLimit         int64
+	CacheMemoryHighRatio     float64
+	CacheMemoryCriticalRatio float64
+	CacheMemoryTTLScale      float64
+	CacheMemoryEvictBatch    int
+	CacheMemoryIdleAfter     time.Duration
+	// Redis maxmemory-policy enforced by the guard, unmanaged when empty
+	CacheEvictionPolicy string
+	// Cached balances of the wallets read at least CacheWarmMinReads times,
+	// with reads halving every CacheWarmHalfLife, are refreshed every
+	// CacheWarmInterval once they expire within CacheWarmLeadTime
+	CacheWarmEnabled  bool
+	CacheWarmInterval time.Duration
+	CacheWarmLeadTime time.Duration
+	CacheWarmMinReads float64
+	CacheWarmBatch    int
+	CacheWarmHalfLife time.Duration
+	// Balances whose invalidation failed are invalidated again every
+	// CacheInvalidationRetryInterval; reading one meanwhile shortens its TTL
+	// to CacheStaleTTL
+	CacheInvalidationRetryInterval time.Duration
+	CacheStaleTTL                  time.Duration
+	// Circuit breaker of the balance cache, opening after CacheBreakerFailures
+	// consecutive failures for CacheBreakerOpenTimeout
+	CacheBreakerEnabled     bool
+	CacheBreakerFailures    int
+	CacheBreakerOpenTimeout time.Duration
+	// How PostgreSQL withdrawals and transfers guard the wallets they change:
+	// "pessimistic" row locks or "optimistic" version checks
+	WalletLocking            string
+	WalletOptimisticAttempts int
+	// How PostgreSQL stores wallets: "state" balances, or "events" streams
+	// the balances are projected from
+	WalletStorage string
+
+	// Auth related
+	JWTSigningKey string
+	JWTIssuer     string
+	// Sensitive requests need a multi-factor login within this age
+	StepUpMaxAge time.Duration
+
+	// Response masking policies per role, as JSON
+	MaskingPolicies string
+
+	// Idempotency related
+	IdempotencyKeyTTL time.Duration
+
+	// Transaction history still reads its parameters from the deprecated
+	// JSON body
+	TransactionHistoryBodyEnabled bool
+
+	// Risk related
+	ExposureWindowsDays     []int
+	ExposureRefreshInterval time.Duration
+
+	// Prometheus metrics on /metrics
+	MetricsEnabled bool
+
+	// OpenTelemetry tracing, disabled without an OTLP/HTTP endpoint
+	TracingEndpoint    string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// Runtime settings are reloaded from the database after this interval
+	SettingsRefreshInterval time.Duration
+
+	// Balance snapshot related
+	SnapshotInterval time.Duration
+	SnapshotLag      time.Duration
+
+	// Queued deposits from internal services
+	AsyncDepositsEnabled     bool
+	DepositQueuePollInterval time.Duration
+	DepositQueueBatchSize    int
+	DepositQueueWorkers      int
+
+	// Withdrawals to external destinations
+	WithdrawalPollInterval time.Duration
+	WithdrawalBatchSize    int
+	WithdrawalRetryAfter   time.Duration
+	PayoutProvider         string
+	PayoutWebhookURL       string
+	PayoutWebhookTimeout   time.Duration
+	// PayoutRoutes holds the routing rules of each payout provider as JSON
+	PayoutRoutes string
+
+	// Exchange rates transfers between wallets of different currencies are
+	// converted at
+	FXProvider     string
+	FXBaseCurrency string
+	FXRates        string
+	FXRatesURL     string
+	FXRatesTimeout time.Duration
+	FXRatesTTL     time.Duration
+
+	// DefaultCurrencies are the currencies users are registered with a
+	// wallet in. The first is the currency of provisioned wallets that do
+	// not pick one; none provisions them without a currency.
+	DefaultCurrencies []string
+
+	// LegacyUserIDs accepts wallet user IDs that are not UUIDs, for
+	// deployments whose wallets predate registered users
+	LegacyUserIDs bool
+
+	// ImplicitWalletCreation lets deposits create the wallets of users
+	// without one; otherwise wallets are created by provisioning only
+	ImplicitWalletCreation bool
+
+	// Limit increases up to this fraction above the current limit are
+	// approved without an admin; 0 sends every request to an admin
+	LimitAutoApproveRatio float64
+
+	// Scheduled and recurring transfers
+	SchedulerPollInterval time.Duration
+	SchedulerBatchSize    int
+	SchedulerRetryAfter   time.Duration
+
+	// Automatic top-ups from linked funding sources
+	TopUpPollInterval     time.Duration
+	TopUpBatchSize        int
+	TopUpRetryAfter       time.Duration
+	FundingProvider       string
+	FundingWebhookURL     string
+	FundingWebhookTimeout time.Duration
+
+	// Round-up savings
+	RoundUpPollInterval time.Duration
+	RoundUpBatchSize    int
+
+	// Sandbox mode and its faucet crediting test funds; never in production
+	SandboxEnabled   bool
+	FaucetAmount     int
+	FaucetMaxAmount  int
+	FaucetRateLimit  int
+	FaucetRateWindow time.Duration
+
+	// Balance reconciliation against the ledger
+	ReconciliationInterval     time.Duration
+	ReconciliationPollInterval time.Duration
+	ReconciliationBatchSize    int
+
+	// Payment requests between users
+	PaymentRequestTTL          time.Duration
+	PaymentRequestPollInterval time.Duration
+	PaymentRequestBatchSize    int
+
+	// Confirmation of transfers above the threshold with a one-time
+	// password; a zero threshold disables it
+	TransferConfirmationThreshold    int
+	TransferConfirmationTTL          time.Duration
+	TransferConfirmationMaxAttempts  int
+	TransferConfirmationPollInterval time.Duration
+	TransferConfirmationBatchSize    int
+	OTPNotifier                      string
+	OTPWebhookURL                    string
+	OTPWebhookTimeout                time.Duration
+
+	// Notification digests of users who chose them
+	NotificationDigestWindow       time.Duration
+	NotificationDigestPollInterval time.Duration
+	NotificationDigestBatchSize    int
+
+	// Daily trial balance
+	TrialBalancePollInterval time.Duration
+
+	// Partial indexes of recent transactions
+	HistoryIndexInterval time.Duration
+
+	// Erasure of the personal data of closed wallets
+	ErasureRetention    time.Duration
+	ErasurePollInterval time.Duration
+	ErasureBatchSize    int
+
+	// Outbox related
+	OutboxPollInterval  time.Duration
+	OutboxBatchSize     int
+	EventPublisher      string
+	EventWebhookURL     string
+	EventWebhookTimeout time.Duration
+
+	// Paused webhook deliveries resume once WebhookBacklogLimit events wait
+	// for them; 0 keeps them paused. The subscription is reported behind
+	// from WebhookBacklogAlert events or events older than WebhookLagAlert.
+	WebhookBacklogLimit int64
+	WebhookBacklogAlert int64
+	WebhookLagAlert     time.Duration
+	WebhookKeyOverlap   time.Duration
+
+	// Readiness probe timeouts
+	HealthDBTimeout    time.Duration
+	HealthRedisTimeout time.Duration
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DBHost:            getEnv("DB_HOST", "localhost"),
-		DBPort:            getEnv("DB_PORT", "5432"),
-		DBUser:            getEnv("DB_USER", "wallet_user"),
-		DBPassword:        getEnv("DB_PASSWORD", "wallet_pass"),
-		DBName:            getEnv("DB_NAME", "wallet_db"),
-		DBSSLMode:         getEnv("DB_SSL_MODE", "disable"),
-		ServerPort:        getEnv("SERVER_PORT", "8080"),
-		Environment:       getEnv("ENVIRONMENT", "development"),
-		DBMaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-		DBMaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
-		DBConnMaxLifetime: time.Duration(getEnvAsInt("DB_CONN_MAX_LIFETIME", 300)) * time.Second,
+		DBDriver:                 getEnv("DB_DRIVER", DBDriverPostgres),
+		SQLitePath:               getEnv("SQLITE_PATH", "./wallet.db"),
+		DBHost:                   getEnv("DB_HOST", "localhost"),
+		DBPort:                   getEnv("DB_PORT", "5432"),
+		DBUser:                   getEnv("DB_USER", "wallet_user"),
+		DBPassword:               getEnv("DB_PASSWORD", "wallet_pass"),
+		DBName:                   getEnv("DB_NAME", "wallet_db"),
+		DBSSLMode:                getEnv("DB_SSL_MODE", "disable"),
+		ServerPort:               getEnv("SERVER_PORT", "8080"),
+		ShutdownTimeout:          time.Duration(getEnvAsInt("SHUTDOWN_TIMEOUT", 30)) * time.Second,
+		RequestTimeout:           time.Duration(getEnvAsInt("REQUEST_TIMEOUT", 10)) * time.Second,
+		CORSAllowedOrigins:       getEnvAsList("CORS_ALLOWED_ORIGINS", nil),
+		Environment:              getEnv("ENVIRONMENT", "development"),
+		DBMaxOpenConns:           getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+		DBMinConns:               getEnvAsInt("DB_MIN_CONNS", 0),
+		DBConnMaxLifetime:        time.Duration(getEnvAsInt("DB_CONN_MAX_LIFETIME", 300)) * time.Second,
+		DBConnMaxIdleTime:        time.Duration(getEnvAsInt("DB_CONN_MAX_IDLE_TIME", 60)) * time.Second,
+		DBStatementCacheCapacity: getEnvAsInt("DB_STATEMENT_CACHE_CAPACITY", 512),
+		DBAutoMigrate:            getEnvAsBool("DB_AUTO_MIGRATE", false),
 
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnvAsInt("REDIS_PORT", 6379),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvAsInt("REDIS_DB", 0),
-		
+		RedisDisabled: getEnvAsBool("REDIS_DISABLED", false),
+		CacheStrategy: getEnv("CACHE_STRATEGY", "write-through"),
+
+		WalletLockEnabled: getEnvAsBool("WALLET_LOCK_ENABLED", true),
+		WalletLockTTL:     time.Duration(getEnvAsInt("WALLET_LOCK_TTL", 10)) * time.Second,
+		WalletLockWait:    time.Duration(getEnvAsInt("WALLET_LOCK_WAIT_MS", 2000)) * time.Millisecond,
+
+		CacheMemoryGuardEnabled:        getEnvAsBool("CACHE_MEMORY_GUARD_ENABLED", true),
+		CacheMemoryCheckInterval:       time.Duration(getEnvAsInt("CACHE_MEMORY_CHECK_INTERVAL", 15)) * time.Second,
+		CacheMemoryLimit:               int64(getEnvAsInt("CACHE_MEMORY_LIMIT_MB", 0)) << 20,
+		CacheMemoryHighRatio:           getEnvAsFloat("CACHE_MEMORY_HIGH_RATIO", 0.75),
+		CacheMemoryCriticalRatio:       getEnvAsFloat("CACHE_MEMORY_CRITICAL_RATIO", 0.9),
+		CacheMemoryTTLScale:            getEnvAsFloat("CACHE_MEMORY_TTL_SCALE", 0.25),
+		CacheMemoryEvictBatch:          getEnvAsInt("CACHE_MEMORY_EVICT_BATCH", 500),
+		CacheMemoryIdleAfter:           time.Duration(getEnvAsInt("CACHE_MEMORY_IDLE_AFTER", 7*24*3600)) * time.Second,
+		CacheEvictionPolicy:            getEnv("CACHE_EVICTION_POLICY", ""),
+		CacheWarmEnabled:               getEnvAsBool("CACHE_WARM_ENABLED", true),
+		CacheWarmInterval:              time.Duration(getEnvAsInt("CACHE_WARM_INTERVAL", 15)) * time.Second,
+		CacheWarmLeadTime:              time.Duration(getEnvAsInt("CACHE_WARM_LEAD_TIME", 60)) * time.Second,
+		CacheWarmMinReads:              getEnvAsFloat("CACHE_WARM_MIN_READS", 20),
+		CacheWarmBatch:                 getEnvAsInt("CACHE_WARM_BATCH", 200),
+		CacheWarmHalfLife:              time.Duration(getEnvAsInt("CACHE_WARM_HALF_LIFE", 600)) * time.Second,
+		CacheInvalidationRetryInterval: time.Duration(getEnvAsInt("CACHE_INVALIDATION_RETRY_INTERVAL", 5)) * time.Second,
+		CacheStaleTTL:                  time.Duration(getEnvAsInt("CACHE_STALE_TTL", 5)) * time.Second,
+		CacheBreakerEnabled:            getEnvAsBool("CACHE_BREAKER_ENABLED", true),
+		CacheBreakerFailures:           getEnvAsInt("CACHE_BREAKER_FAILURES", 5),
+		CacheBreakerOpenTimeout:        time.Duration(getEnvAsInt("CACHE_BREAKER_OPEN_TIMEOUT", 30)) * time.Second,
+
+		WalletLocking:            getEnv("WALLET_LOCKING", "pessimistic"),
+		WalletOptimisticAttempts: getEnvAsInt("WALLET_OPTIMISTIC_ATTEMPTS", 5),
+		WalletStorage:            getEnv("WALLET_STORAGE", WalletStorageState),
+
+		JWTSigningKey: getEnv("JWT_SIGNING_KEY", ""),
+		JWTIssuer:     getEnv("JWT_ISSUER", ""),
+		StepUpMaxAge:  time.Duration(getEnvAsInt("STEP_UP_MAX_AGE", 300)) * time.Second,
+
+		MaskingPolicies: getEnv("MASKING_POLICIES", masking.DefaultPolicies),
+
+		IdempotencyKeyTTL: time.Duration(getEnvAsInt("IDEMPOTENCY_KEY_TTL", 86400)) * time.Second,
+
+		TransactionHistoryBodyEnabled: getEnvAsBool("TRANSACTION_HISTORY_BODY_ENABLED", true),
+
+		ExposureWindowsDays:     getEnvAsIntList("EXPOSURE_WINDOWS_DAYS", []int{7, 30}),
+		ExposureRefreshInterval: time.Duration(getEnvAsInt("EXPOSURE_REFRESH_INTERVAL", 300)) * time.Second,
+
+		MetricsEnabled: getEnvAsBool("METRICS_ENABLED", true),
+
+		TracingEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingServiceName: getEnv("OTEL_SERVICE_NAME", "wallet-service"),
+		TracingSampleRatio: getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+
+		SettingsRefreshInterval: time.Duration(getEnvAsInt("SETTINGS_REFRESH_INTERVAL", 30)) * time.Second,
+
+		SnapshotInterval: time.Duration(getEnvAsInt("SNAPSHOT_INTERVAL", 3600)) * time.Second,
+		SnapshotLag:      time.Duration(getEnvAsInt("SNAPSHOT_LAG", 60)) * time.Second,
+
+		AsyncDepositsEnabled:     getEnvAsBool("ASYNC_DEPOSITS_ENABLED", false),
+		DepositQueuePollInterval: time.Duration(getEnvAsInt("DEPOSIT_QUEUE_POLL_INTERVAL_MS", 500)) * time.Millisecond,
+		DepositQueueBatchSize:    getEnvAsInt("DEPOSIT_QUEUE_BATCH_SIZE", 100),
+		DepositQueueWorkers:      getEnvAsInt("DEPOSIT_QUEUE_WORKERS", 4),
+
+		WithdrawalPollInterval: time.Duration(getEnvAsInt("WITHDRAWAL_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		WithdrawalBatchSize:    getEnvAsInt("WITHDRAWAL_BATCH_SIZE", 50),
+		WithdrawalRetryAfter:   time.Duration(getEnvAsInt("WITHDRAWAL_RETRY_AFTER", 60)) * time.Second,
+		PayoutProvider:         getEnv("PAYOUT_PROVIDER", "log"),
+		PayoutWebhookURL:       getEnv("PAYOUT_WEBHOOK_URL", ""),
+		PayoutWebhookTimeout:   time.Duration(getEnvAsInt("PAYOUT_WEBHOOK_TIMEOUT", 10)) * time.Second,
+		PayoutRoutes:           getEnv("PAYOUT_ROUTES", ""),
+
+		FXProvider:     getEnv("FX_PROVIDER", "static"),
+		FXBaseCurrency: getEnv("FX_BASE_CURRENCY", "USD"),
+		FXRates:        getEnv("FX_RATES", ""),
+		FXRatesURL:     getEnv("FX_RATES_URL", ""),
+		FXRatesTimeout: time.Duration(getEnvAsInt("FX_RATES_TIMEOUT", 5)) * time.Second,
+		FXRatesTTL:     time.Duration(getEnvAsInt("FX_RATES_TTL", 60)) * time.Second,
+
+		// DEFAULT_CURRENCY held the single default currency before
+		DefaultCurrencies: getEnvAsList("DEFAULT_CURRENCIES", getEnvAsList("DEFAULT_CURRENCY", nil)),
+		LegacyUserIDs:     getEnvAsBool("LEGACY_USER_IDS", false),
+
+		ImplicitWalletCreation: getEnvAsBool("IMPLICIT_WALLET_CREATION", false),
+
+		LimitAutoApproveRatio: getEnvAsFloat("LIMIT_AUTO_APPROVE_RATIO", 0.5),
+
+		SchedulerPollInterval: time.Duration(getEnvAsInt("SCHEDULER_POLL_INTERVAL", 15)) * time.Second,
+		SchedulerBatchSize:    getEnvAsInt("SCHEDULER_BATCH_SIZE", 100),
+		SchedulerRetryAfter:   time.Duration(getEnvAsInt("SCHEDULER_RETRY_AFTER", 300)) * time.Second,
+
+		TopUpPollInterval:     time.Duration(getEnvAsInt("TOP_UP_POLL_INTERVAL", 30)) * time.Second,
+		TopUpBatchSize:        getEnvAsInt("TOP_UP_BATCH_SIZE", 100),
+		TopUpRetryAfter:       time.Duration(getEnvAsInt("TOP_UP_RETRY_AFTER", 300)) * time.Second,
+		FundingProvider:       getEnv("FUNDING_PROVIDER", "log"),
+		FundingWebhookURL:     getEnv("FUNDING_WEBHOOK_URL", ""),
+		FundingWebhookTimeout: time.Duration(getEnvAsInt("FUNDING_WEBHOOK_TIMEOUT", 10)) * time.Second,
+
+		RoundUpPollInterval: time.Duration(getEnvAsInt("ROUND_UP_POLL_INTERVAL", 10)) * time.Second,
+		RoundUpBatchSize:    getEnvAsInt("ROUND_UP_BATCH_SIZE", 100),
+
+		SandboxEnabled:   getEnvAsBool("SANDBOX_ENABLED", false),
+		FaucetAmount:     getEnvAsInt("FAUCET_AMOUNT", 100),
+		FaucetMaxAmount:  getEnvAsInt("FAUCET_MAX_AMOUNT", 1000),
+		FaucetRateLimit:  getEnvAsInt("FAUCET_RATE_LIMIT", 5),
+		FaucetRateWindow: time.Duration(getEnvAsInt("FAUCET_RATE_WINDOW", 3600)) * time.Second,
+
+		ReconciliationInterval:     time.Duration(getEnvAsInt("RECONCILIATION_INTERVAL", 86400)) * time.Second,
+		ReconciliationPollInterval: time.Duration(getEnvAsInt("RECONCILIATION_POLL_INTERVAL", 300)) * time.Second,
+		ReconciliationBatchSize:    getEnvAsInt("RECONCILIATION_BATCH_SIZE", 500),
+
+		PaymentRequestTTL:          time.Duration(getEnvAsInt("PAYMENT_REQUEST_TTL", 604800)) * time.Second,
+		PaymentRequestPollInterval: time.Duration(getEnvAsInt("PAYMENT_REQUEST_POLL_INTERVAL", 60)) * time.Second,
+		PaymentRequestBatchSize:    getEnvAsInt("PAYMENT_REQUEST_BATCH_SIZE", 500),
+
+		TransferConfirmationThreshold:    getEnvAsInt("TRANSFER_CONFIRMATION_THRESHOLD", 0),
+		TransferConfirmationTTL:          time.Duration(getEnvAsInt("TRANSFER_CONFIRMATION_TTL", 300)) * time.Second,
+		TransferConfirmationMaxAttempts:  getEnvAsInt("TRANSFER_CONFIRMATION_MAX_ATTEMPTS", 5),
+		TransferConfirmationPollInterval: time.Duration(getEnvAsInt("TRANSFER_CONFIRMATION_POLL_INTERVAL", 60)) * time.Second,
+		TransferConfirmationBatchSize:    getEnvAsInt("TRANSFER_CONFIRMATION_BATCH_SIZE", 500),
+		OTPNotifier:                      getEnv("OTP_NOTIFIER", "log"),
+		OTPWebhookURL:                    getEnv("OTP_WEBHOOK_URL", ""),
+		OTPWebhookTimeout:                time.Duration(getEnvAsInt("OTP_WEBHOOK_TIMEOUT", 10)) * time.Second,
+
+		NotificationDigestWindow:       time.Duration(getEnvAsInt("NOTIFICATION_DIGEST_WINDOW", 3600)) * time.Second,
+		NotificationDigestPollInterval: time.Duration(getEnvAsInt("NOTIFICATION_DIGEST_POLL_INTERVAL", 60)) * time.Second,
+		NotificationDigestBatchSize:    getEnvAsInt("NOTIFICATION_DIGEST_BATCH_SIZE", 100),
+
+		TrialBalancePollInterval: time.Duration(getEnvAsInt("TRIAL_BALANCE_POLL_INTERVAL", 3600)) * time.Second,
+
+		HistoryIndexInterval: time.Duration(getEnvAsInt("HISTORY_INDEX_INTERVAL", 3600)) * time.Second,
+
+		ErasureRetention:    time.Duration(getEnvAsInt("ERASURE_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		ErasurePollInterval: time.Duration(getEnvAsInt("ERASURE_POLL_INTERVAL", 3600)) * time.Second,
+		ErasureBatchSize:    getEnvAsInt("ERASURE_BATCH_SIZE", 100),
+
+		OutboxPollInterval:  time.Duration(getEnvAsInt("OUTBOX_POLL_INTERVAL", 5)) * time.Second,
+		OutboxBatchSize:     getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+		EventPublisher:      getEnv("EVENT_PUBLISHER", "log"),
+		EventWebhookURL:     getEnv("EVENT_WEBHOOK_URL", ""),
+		EventWebhookTimeout: time.Duration(getEnvAsInt("EVENT_WEBHOOK_TIMEOUT", 5)) * time.Second,
+
+		WebhookBacklogLimit: int64(getEnvAsInt("WEBHOOK_BACKLOG_LIMIT", 100000)),
+		WebhookBacklogAlert: int64(getEnvAsInt("WEBHOOK_BACKLOG_ALERT", 10000)),
+		WebhookLagAlert:     time.Duration(getEnvAsInt("WEBHOOK_LAG_ALERT", 900)) * time.Second,
+		WebhookKeyOverlap:   time.Duration(getEnvAsInt("WEBHOOK_KEY_OVERLAP", 86400)) * time.Second,
+
+		HealthDBTimeout:    time.Duration(getEnvAsInt("HEALTH_DB_TIMEOUT_MS", 1000)) * time.Millisecond,
+		HealthRedisTimeout: time.Duration(getEnvAsInt("HEALTH_REDIS_TIMEOUT_MS", 500)) * time.Millisecond,
+
 		LogPath: "./logs/app.log",
 	}
 }
 
+// PostgresURL is the connection string of the PostgreSQL database
+func (c *Config) PostgresURL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DBUser, c.DBPassword),
+		Host:     net.JoinHostPort(c.DBHost, c.DBPort),
+		Path:     "/" + c.DBName,
+		RawQuery: url.Values{"sslmode": {c.DBSSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -72,3 +472,61 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, part := range strings.Split(valueStr, ",") {
+		if value := strings.TrimSpace(part); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsIntList(key string, defaultValue []int) []int {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []int
+	for _, part := range strings.Split(valueStr, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
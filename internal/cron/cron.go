@@ -0,0 +1,154 @@
+// Package cron parses standard five-field cron expressions (minute, hour,
+// day of month, month, day of week) and computes their next activation.
+// Fields accept *, values, ranges (1-5), lists (1,15) and steps (*/15,
+// 0-30/10). Expressions are evaluated in UTC.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// searchLimit bounds the search for the next activation, so expressions that
+// never fire, like 0 0 31 2 *, fail instead of looping
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// Cron matches a day when either day field matches, unless one of them
+	// is *
+	anyDay, anyWeekday bool
+}
+
+// Parse parses a five-field cron expression
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: expected %d fields, got %d", ErrInvalidExpression, len(fields), len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	return &Schedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func parseField(expr string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			parsed, err := strconv.Atoi(item[i+1:])
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("%w: bad step in %s field %q", ErrInvalidExpression, f.name, item)
+			}
+			rangeExpr, step = item[:i], parsed
+		}
+
+		low, high := f.min, f.max
+		if rangeExpr != "*" {
+			var err error
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("%w: bad value in %s field %q", ErrInvalidExpression, f.name, item)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("%w: bad value in %s field %q", ErrInvalidExpression, f.name, item)
+				}
+			} else if step > 1 {
+				// 5/15 means from 5 to the end of the field in steps of 15
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%w: %s field %q is outside %d-%d", ErrInvalidExpression, f.name, item, f.min, f.max)
+		}
+
+		for value := low; value <= high; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first activation strictly after t, in UTC. It returns the
+// zero time when the expression never fires.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for t.Before(limit) {
+		if !has(s.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !has(s.hours, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !has(s.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := has(s.days, t.Day())
+	weekday := has(s.weekdays, int(t.Weekday()))
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
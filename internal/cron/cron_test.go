@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2024, 5, 1, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 8 1 * *", time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2024, 5, 5, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2024, 5, 1, 10, 25, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 15 * 5", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestSchedule_NextIsStrictlyAfter(t *testing.T) {
+	schedule, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, at.Add(24*time.Hour), schedule.Next(at))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalidExpression, expr)
+	}
+}
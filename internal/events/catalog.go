@@ -0,0 +1,389 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/operation"
+)
+
+// Definition describes an event type for integrators: the JSON schema of its
+// payload and a sample envelope
+type Definition struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+	Sample      Event                  `json:"sample"`
+}
+
+type entry struct {
+	eventType   string
+	description string
+	sample      interface{}
+}
+
+var (
+	sampleTime   = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sampleReason = "INC-123"
+)
+
+// registry lists every published event type with a sample payload. Schemas
+// are derived from the payload types so they cannot drift from what is sent.
+var registry = []entry{
+	{
+		eventType:   TypeWalletCredited,
+		description: "Funds were deposited into a wallet",
+		sample: WalletCredited{
+			UserID:        "user1",
+			Amount:        decimal.RequireFromString("100.50"),
+			TransactionID: "1001",
+			Sequence:      12,
+		},
+	},
+	{
+		eventType:   TypeWalletDebited,
+		description: "Funds were withdrawn from a wallet",
+		sample: WalletDebited{
+			UserID:        "user1",
+			Amount:        decimal.RequireFromString("50.25"),
+			TransactionID: "1002",
+			Sequence:      13,
+		},
+	},
+	{
+		eventType:   TypeTransferCompleted,
+		description: "Funds were transferred between two wallets",
+		sample: TransferCompleted{
+			FromUserID:    "user1",
+			ToUserID:      "user2",
+			Amount:        decimal.RequireFromString("25"),
+			TransactionID: "1003",
+			FromSequence:  14,
+			ToSequence:    3,
+		},
+	},
+	{
+		eventType:   TypeTransferAcknowledged,
+		description: "The receiver of a transfer acknowledged it, optionally with a message to the sender",
+		sample: TransferAcknowledged{
+			TransactionID:  "1003",
+			FromUserID:     "user1",
+			ToUserID:       "user2",
+			Amount:         decimal.RequireFromString("25"),
+			Message:        "Thanks for dinner!",
+			AcknowledgedAt: sampleTime,
+		},
+	},
+	{
+		eventType:   TypeTransactionFlagsChanged,
+		description: "An admin set or removed a review flag of a transaction",
+		sample: TransactionFlagsChanged{
+			TransactionID: "1003",
+			FromUserID:    "user1",
+			ToUserID:      "user2",
+			Added:         "suspicious",
+			Flags:         []string{"suspicious"},
+		},
+	},
+	{
+		eventType:   TypeFeeCharged,
+		description: "A fee for a withdrawal or transfer was charged to a wallet",
+		sample: FeeCharged{
+			UserID:          "user1",
+			Amount:          decimal.RequireFromString("0.5"),
+			TransactionID:   "1004",
+			FeeFor:          "1003",
+			Sequence:        15,
+			AccountSequence: 321,
+		},
+	},
+	{
+		eventType:   TypeRoundUpSaved,
+		description: "The round-up of a transfer was moved to the savings sub-account of the wallet",
+		sample: RoundUpSaved{
+			UserID:          "user1",
+			SavingsAccount:  "savings:user1",
+			Amount:          decimal.RequireFromString("0.75"),
+			TransactionID:   "1005",
+			RoundUpFor:      "1003",
+			Sequence:        16,
+			SavingsSequence: 4,
+		},
+	},
+	{
+		eventType:   TypeWalletCreated,
+		description: "A wallet was provisioned",
+		sample: WalletLifecycleChanged{
+			UserID:  "user1",
+			Current: WalletState{Status: "active", Currency: "EUR"},
+		},
+	},
+	{
+		eventType:   TypeWalletFrozen,
+		description: "A wallet was frozen and rejects money movements",
+		sample: WalletLifecycleChanged{
+			UserID:   "user1",
+			Previous: &WalletState{Status: "active"},
+			Current:  WalletState{Status: "frozen"},
+			Reason:   &sampleReason,
+		},
+	},
+	{
+		eventType:   TypeWalletUnfrozen,
+		description: "A frozen wallet was reactivated",
+		sample: WalletLifecycleChanged{
+			UserID:   "user1",
+			Previous: &WalletState{Status: "frozen"},
+			Current:  WalletState{Status: "active"},
+			Reason:   &sampleReason,
+		},
+	},
+	{
+		eventType:   TypeWalletClosed,
+		description: "A wallet was closed and no longer accepts money movements",
+		sample: WalletLifecycleChanged{
+			UserID:   "user1",
+			Previous: &WalletState{Status: "active"},
+			Current:  WalletState{Status: "closed"},
+			Reason:   &sampleReason,
+		},
+	},
+	{
+		eventType:   TypeWalletOwnershipChanged,
+		description: "A wallet and its history were reassigned to a new user ID",
+		sample: WalletOwnershipChanged{
+			PreviousUserID: "user1",
+			NewUserID:      "user1-verified",
+			Reason:         sampleReason,
+		},
+	},
+	{
+		eventType:   TypeWalletOverdraftChanged,
+		description: "An admin changed the overdraft limit of a wallet",
+		sample: WalletOverdraftChanged{
+			UserID:        "user1",
+			PreviousLimit: decimal.Zero,
+			Limit:         decimal.RequireFromString("500"),
+		},
+	},
+	{
+		eventType:   TypeLimitIncreaseRequested,
+		description: "A user requested a limit increase that awaits an admin decision",
+		sample: LimitIncreaseChanged{
+			RequestID:      "4",
+			UserID:         "user1",
+			Operation:      "withdrawal",
+			Kind:           "daily_amount",
+			CurrentValue:   decimal.RequireFromString("1000"),
+			RequestedValue: decimal.RequireFromString("5000"),
+			Status:         "pending",
+		},
+	},
+	{
+		eventType:   TypeLimitIncreaseApproved,
+		description: "A limit increase was approved, automatically or by an admin, and applies",
+		sample: LimitIncreaseChanged{
+			RequestID:      "4",
+			UserID:         "user1",
+			Operation:      "withdrawal",
+			Kind:           "daily_amount",
+			CurrentValue:   decimal.RequireFromString("1000"),
+			RequestedValue: decimal.RequireFromString("5000"),
+			Status:         "approved",
+			DecisionReason: &sampleReason,
+		},
+	},
+	{
+		eventType:   TypeLimitIncreaseRejected,
+		description: "An admin rejected a limit increase",
+		sample: LimitIncreaseChanged{
+			RequestID:      "4",
+			UserID:         "user1",
+			Operation:      "withdrawal",
+			Kind:           "daily_amount",
+			CurrentValue:   decimal.RequireFromString("1000"),
+			RequestedValue: decimal.RequireFromString("5000"),
+			Status:         "rejected",
+			DecisionReason: &sampleReason,
+		},
+	},
+	{
+		eventType:   TypeTopUpFailed,
+		description: "An automatic top-up could not be collected from the funding source or deposited",
+		sample: TopUpFailed{
+			UserID:              "user1",
+			RunID:               "17",
+			Amount:              decimal.RequireFromString("50"),
+			FundingSource:       "card:tok_visa_4242",
+			Error:               "funding charge declined: provider responded with status 402",
+			ConsecutiveFailures: 3,
+			Paused:              true,
+		},
+	},
+	{
+		eventType:   TypePaymentRequestCreated,
+		description: "A user requested a payment from another user, who can accept or decline it",
+		sample:      samplePaymentRequest("pending"),
+	},
+	{
+		eventType:   TypePaymentRequestAccepted,
+		description: "The payer accepted a payment request and the amount was transferred to the requester",
+		sample:      samplePaymentRequest("accepted"),
+	},
+	{
+		eventType:   TypePaymentRequestDeclined,
+		description: "The payer declined a payment request",
+		sample:      samplePaymentRequest("declined"),
+	},
+	{
+		eventType:   TypePaymentRequestExpired,
+		description: "A payment request was neither accepted nor declined before it expired",
+		sample:      samplePaymentRequest("expired"),
+	},
+	{
+		eventType:   TypeNotificationDigest,
+		description: "The money movements of a user in digest mode over its digest window, summarized for a single notification",
+		sample: NotificationDigest{
+			UserID:     "merchant1",
+			From:       sampleTime.Add(-time.Hour),
+			To:         sampleTime.Add(-time.Minute),
+			EventCount: 3,
+			Totals: []DigestTotal{
+				{Type: TypeTransferCompleted, Direction: DigestIn, Count: 2, Amount: decimal.RequireFromString("45.00")},
+				{Type: TypeWalletDebited, Direction: DigestOut, Count: 1, Amount: decimal.RequireFromString("20.00")},
+			},
+			EventIDs: []string{"evt_transfer_completed", "evt_wallet_debited", "evt_transfer_completed_2"},
+		},
+	},
+}
+
+func samplePaymentRequest(status string) PaymentRequestChanged {
+	return PaymentRequestChanged{
+		RequestID:   "9",
+		RequesterID: "user1",
+		PayerID:     "user2",
+		Amount:      decimal.RequireFromString("12.50"),
+		Note:        "Dinner",
+		Status:      status,
+		ExpiresAt:   sampleTime.Add(7 * 24 * time.Hour),
+	}
+}
+
+// Catalog returns the definitions of all event types
+func Catalog() []Definition {
+	definitions := make([]Definition, 0, len(registry))
+	for _, e := range registry {
+		definitions = append(definitions, Definition{
+			Type:        e.eventType,
+			Description: e.description,
+			Schema:      envelopeSchema(e.eventType, e.sample),
+			Sample: Event{
+				ID:         "evt_" + strings.ReplaceAll(e.eventType, ".", "_"),
+				Type:       e.eventType,
+				OccurredAt: sampleTime,
+				Data:       e.sample,
+			},
+		})
+	}
+	return definitions
+}
+
+func envelopeSchema(eventType string, payload interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"title":    eventType,
+		"type":     "object",
+		"required": []string{"id", "type", "occurred_at", "data"},
+		"properties": map[string]interface{}{
+			"id":          map[string]interface{}{"type": "string"},
+			"type":        map[string]interface{}{"const": eventType},
+			"occurred_at": map[string]interface{}{"type": "string", "format": "date-time"},
+			"operation":   schemaOf(reflect.TypeOf(operation.Operation{})),
+			"digests":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"data":        schemaOf(reflect.TypeOf(payload)),
+		},
+	}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+)
+
+// schemaOf derives a JSON schema from a Go type following encoding/json
+// conventions. Pointer and omitempty fields are optional.
+func schemaOf(t reflect.Type) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema map[string]interface{}
+	switch {
+	case t == timeType:
+		schema = map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == decimalType:
+		schema = map[string]interface{}{"type": "string", "pattern": `^-?\d+(\.\d+)?$`}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			schema = map[string]interface{}{"type": "string"}
+		case reflect.Bool:
+			schema = map[string]interface{}{"type": "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = map[string]interface{}{"type": "integer"}
+		case reflect.Float32, reflect.Float64:
+			schema = map[string]interface{}{"type": "number"}
+		case reflect.Slice, reflect.Array:
+			schema = map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+		case reflect.Map:
+			schema = map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+		case reflect.Struct:
+			schema = structSchema(t)
+		default:
+			schema = map[string]interface{}{}
+		}
+	}
+
+	if nullable {
+		schema = map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	return schema
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaOf(field.Type)
+		if field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
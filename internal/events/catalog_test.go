@@ -0,0 +1,52 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	require.Len(t, catalog, len(registry))
+
+	seen := map[string]bool{}
+	for _, definition := range catalog {
+		assert.False(t, seen[definition.Type], "duplicate event type %s", definition.Type)
+		seen[definition.Type] = true
+
+		assert.NotEmpty(t, definition.Description)
+		assert.Equal(t, definition.Type, definition.Sample.Type)
+		_, err := json.Marshal(definition)
+		assert.NoError(t, err)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type payload struct {
+		Name     string  `json:"name"`
+		Count    int     `json:"count,omitempty"`
+		Note     *string `json:"note"`
+		Internal string  `json:"-"`
+	}
+
+	schema := schemaOf(reflect.TypeOf(payload{}))
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["name"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["count"])
+	assert.Contains(t, properties["note"], "anyOf")
+	assert.NotContains(t, properties, "Internal")
+	assert.Equal(t, []string{"name"}, schema["required"])
+}
+
+func TestSchemaOf_Payloads(t *testing.T) {
+	schema := schemaOf(reflect.TypeOf(TransferCompleted{}))
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.Equal(t, "string", properties["amount"].(map[string]interface{})["type"])
+	assert.ElementsMatch(t, []string{"from_user_id", "to_user_id", "amount", "transaction_id", "from_sequence", "to_sequence"}, schema["required"])
+}
@@ -0,0 +1,276 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// Event types
+const (
+	TypeWalletCredited          = "wallet.credited"
+	TypeWalletDebited           = "wallet.debited"
+	TypeTransferCompleted       = "transfer.completed"
+	TypeTransferAcknowledged    = "transfer.acknowledged"
+	TypeTransactionFlagsChanged = "transaction.flags_changed"
+	TypeFeeCharged              = "fee.charged"
+	TypeRoundUpSaved            = "round_up.saved"
+	TypeWalletCreated           = "wallet.created"
+	TypeWalletFrozen            = "wallet.frozen"
+	TypeWalletUnfrozen          = "wallet.unfrozen"
+	TypeWalletClosed            = "wallet.closed"
+
+	TypeWalletOwnershipChanged = "wallet.ownership_changed"
+	TypeWalletOverdraftChanged = "wallet.overdraft_changed"
+
+	TypeLimitIncreaseRequested = "limit_increase.requested"
+	TypeLimitIncreaseApproved  = "limit_increase.approved"
+	TypeLimitIncreaseRejected  = "limit_increase.rejected"
+
+	TypeTopUpFailed = "top_up.failed"
+
+	TypePaymentRequestCreated  = "payment_request.created"
+	TypePaymentRequestAccepted = "payment_request.accepted"
+	TypePaymentRequestDeclined = "payment_request.declined"
+	TypePaymentRequestExpired  = "payment_request.expired"
+
+	TypeNotificationDigest = "notification.digest"
+)
+
+// Event is the envelope delivered for every wallet event. Data holds the
+// payload specific to Type. Operation describes who caused the change and is
+// absent for events recorded outside an operation.
+type Event struct {
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	OccurredAt time.Time            `json:"occurred_at"`
+	Operation  *operation.Operation `json:"operation,omitempty"`
+	// Digests lists the users who get the event in a notification.digest
+	// instead of individually
+	Digests []string    `json:"digests,omitempty"`
+	Data    interface{} `json:"data"`
+}
+
+// New creates an event envelope with a unique ID
+func New(eventType string, data interface{}) Event {
+	return Event{
+		ID:         NewID(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// digestTypes are the event types users can get in digests: the money
+// movements, which high-volume wallets see many of
+var digestTypes = []string{
+	TypeWalletCredited,
+	TypeWalletDebited,
+	TypeTransferCompleted,
+	TypeFeeCharged,
+	TypeRoundUpSaved,
+}
+
+// Digestible reports whether events of eventType can be summarized in
+// digests
+func Digestible(eventType string) bool {
+	return slices.Contains(digestTypes, eventType)
+}
+
+// NewID returns a random event ID
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
+
+// WalletCredited is emitted when funds are deposited into a wallet
+type WalletCredited struct {
+	UserID        string          `json:"user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	TransactionID string          `json:"transaction_id"`
+	// Sequence is the transaction's gapless position in the wallet's ledger
+	Sequence int64 `json:"sequence"`
+}
+
+// WalletDebited is emitted when funds are withdrawn from a wallet
+type WalletDebited struct {
+	UserID        string          `json:"user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	TransactionID string          `json:"transaction_id"`
+	Sequence      int64           `json:"sequence"`
+}
+
+// TransferCompleted is emitted when funds moved between two wallets
+type TransferCompleted struct {
+	FromUserID    string          `json:"from_user_id"`
+	ToUserID      string          `json:"to_user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	TransactionID string          `json:"transaction_id"`
+	// FromSequence and ToSequence position the transfer in the ledgers of the
+	// sender and receiver wallets
+	FromSequence int64 `json:"from_sequence"`
+	ToSequence   int64 `json:"to_sequence"`
+	// Conversion is set when the wallets hold different currencies; the
+	// receiver was credited its converted amount
+	Conversion *models.Conversion `json:"conversion,omitempty"`
+}
+
+// TransferAcknowledged is emitted when the receiver of a transfer
+// acknowledged it, so the sender can be told and shown the message
+type TransferAcknowledged struct {
+	TransactionID  string          `json:"transaction_id"`
+	FromUserID     string          `json:"from_user_id"`
+	ToUserID       string          `json:"to_user_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Message        string          `json:"message,omitempty"`
+	AcknowledgedAt time.Time       `json:"acknowledged_at"`
+}
+
+// TransactionFlagsChanged is emitted when an admin set or removed a review
+// flag of a transaction, so fraud tooling and the data warehouse keep the
+// flags in sync. Flags lists every flag the transaction carries afterwards.
+type TransactionFlagsChanged struct {
+	TransactionID string   `json:"transaction_id"`
+	FromUserID    string   `json:"from_user_id"`
+	ToUserID      string   `json:"to_user_id,omitempty"`
+	Added         string   `json:"added,omitempty"`
+	Removed       string   `json:"removed,omitempty"`
+	Flags         []string `json:"flags"`
+}
+
+// FeeCharged is emitted when a fee moved from the wallet that paid it to the
+// fee account
+type FeeCharged struct {
+	UserID        string          `json:"user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	TransactionID string          `json:"transaction_id"`
+	// FeeFor is the transaction the fee was charged for
+	FeeFor string `json:"fee_for"`
+	// Sequence and AccountSequence position the fee in the ledgers of the
+	// paying wallet and the fee account
+	Sequence        int64 `json:"sequence"`
+	AccountSequence int64 `json:"account_sequence"`
+}
+
+// RoundUpSaved is emitted when the round-up of a transfer moved from the
+// wallet to its savings sub-account
+type RoundUpSaved struct {
+	UserID         string          `json:"user_id"`
+	SavingsAccount string          `json:"savings_account"`
+	Amount         decimal.Decimal `json:"amount"`
+	TransactionID  string          `json:"transaction_id"`
+	// RoundUpFor is the transfer that was rounded up
+	RoundUpFor string `json:"round_up_for"`
+	// Sequence and SavingsSequence position the round-up in the ledgers of
+	// the wallet and its savings sub-account
+	Sequence        int64 `json:"sequence"`
+	SavingsSequence int64 `json:"savings_sequence"`
+}
+
+// WalletState is the lifecycle state of a wallet carried by lifecycle events
+type WalletState struct {
+	Status string `json:"status"`
+	// Currency is set on wallet.created for wallets created with one
+	Currency string `json:"currency,omitempty"`
+}
+
+// WalletLifecycleChanged is the payload of wallet lifecycle events. Previous
+// is null for wallet.created.
+type WalletLifecycleChanged struct {
+	UserID   string       `json:"user_id"`
+	Previous *WalletState `json:"previous"`
+	Current  WalletState  `json:"current"`
+	Reason   *string      `json:"reason"`
+}
+
+// LimitIncreaseChanged is the payload of limit increase events, which let
+// notification services tell the user and the reviewers about a request.
+// DecisionReason is set once an admin decided.
+type LimitIncreaseChanged struct {
+	RequestID      string          `json:"request_id"`
+	UserID         string          `json:"user_id"`
+	Operation      string          `json:"operation"`
+	Kind           string          `json:"kind"`
+	CurrentValue   decimal.Decimal `json:"current_value"`
+	RequestedValue decimal.Decimal `json:"requested_value"`
+	Status         string          `json:"status"`
+	AutoApproved   bool            `json:"auto_approved"`
+	DecisionReason *string         `json:"decision_reason"`
+}
+
+// WalletOwnershipChanged is emitted when a wallet, together with its history,
+// is reassigned to a new user ID
+type WalletOwnershipChanged struct {
+	PreviousUserID string `json:"previous_user_id"`
+	NewUserID      string `json:"new_user_id"`
+	Reason         string `json:"reason"`
+}
+
+// WalletOverdraftChanged is emitted when an admin changes the credit line of
+// a wallet. A limit of zero means the wallet cannot go negative.
+type WalletOverdraftChanged struct {
+	UserID        string          `json:"user_id"`
+	PreviousLimit decimal.Decimal `json:"previous_limit"`
+	Limit         decimal.Decimal `json:"limit"`
+}
+
+// TopUpFailed is emitted when an automatic top-up could not be collected or
+// deposited, so the user can be alerted to fix the funding source. Paused is
+// set when the failure paused the rule.
+type TopUpFailed struct {
+	UserID              string          `json:"user_id"`
+	RunID               string          `json:"run_id"`
+	Amount              decimal.Decimal `json:"amount"`
+	FundingSource       string          `json:"funding_source"`
+	Error               string          `json:"error"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	Paused              bool            `json:"paused"`
+}
+
+// PaymentRequestChanged is the payload of payment request events, which let
+// notification services tell the payer about a new request and the requester
+// about its outcome
+type PaymentRequestChanged struct {
+	RequestID   string          `json:"request_id"`
+	RequesterID string          `json:"requester_id"`
+	PayerID     string          `json:"payer_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Note        string          `json:"note"`
+	Status      string          `json:"status"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+}
+
+// Digest directions
+const (
+	DigestIn  = "in"
+	DigestOut = "out"
+)
+
+// NotificationDigest summarizes the events of a user over its digest window,
+// so notification services send a single notification. Every event listed in
+// the digests of its envelope is summarized in exactly one digest.
+type NotificationDigest struct {
+	UserID string `json:"user_id"`
+	// From and To are when the first and last summarized events occurred
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	EventCount int           `json:"event_count"`
+	Totals     []DigestTotal `json:"totals"`
+	EventIDs   []string      `json:"event_ids"`
+}
+
+// DigestTotal sums the events of a type moving funds in a direction, in or
+// out of the wallet
+type DigestTotal struct {
+	Type      string          `json:"type"`
+	Direction string          `json:"direction"`
+	Count     int             `json:"count"`
+	Amount    decimal.Decimal `json:"amount"`
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	event := New(TypeWalletCredited, WalletCredited{
+		UserID:        "user1",
+		Amount:        decimal.RequireFromString("100.50"),
+		TransactionID: "1001",
+	})
+
+	t.Run("delivers envelope", func(t *testing.T) {
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, event.ID, r.Header.Get("X-Event-ID"))
+			assert.Equal(t, TypeWalletCredited, r.Header.Get("X-Event-Type"))
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		publisher := NewWebhookPublisher(server.URL, time.Second, nil)
+		require.NoError(t, publisher.Publish(context.Background(), event))
+		assert.Equal(t, "100.5", received["data"].(map[string]interface{})["amount"])
+	})
+
+	t.Run("non-2xx fails delivery", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		publisher := NewWebhookPublisher(server.URL, time.Second, nil)
+		assert.ErrorContains(t, publisher.Publish(context.Background(), event), "503")
+	})
+
+	t.Run("signs with every key", func(t *testing.T) {
+		keys := staticKeys{{ID: "2", Secret: "new"}, {ID: "1", Secret: "old"}}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, Sign(keys, timestamp, body), r.Header.Get(SignatureHeader))
+			assert.Regexp(t, `^2=[0-9a-f]{64},1=[0-9a-f]{64}$`, r.Header.Get(SignatureHeader))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		publisher := NewWebhookPublisher(server.URL, time.Second, keys)
+		require.NoError(t, publisher.Publish(context.Background(), event))
+	})
+
+	t.Run("unsigned without keys", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(SignatureHeader))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		publisher := NewWebhookPublisher(server.URL, time.Second, staticKeys{})
+		require.NoError(t, publisher.Publish(context.Background(), event))
+	})
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1714564800.{}" under "secret"
+	signature := Sign([]SigningKey{{ID: "7", Secret: "secret"}}, 1714564800, []byte("{}"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1714564800.{}"))
+	assert.Equal(t, "7="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
+
+type staticKeys []SigningKey
+
+func (k staticKeys) SigningKeys(context.Context) ([]SigningKey, error) {
+	return k, nil
+}
@@ -0,0 +1,106 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Selection is the set of JSON fields a client asked for with ?fields=. An
+// empty selection keeps every field.
+type Selection []string
+
+// Parse reads a comma separated list of fields and checks each against the
+// JSON names of model, a struct or pointer to one. Unknown fields are
+// rejected, naming the fields that are allowed.
+func Parse(raw string, model interface{}) (Selection, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	allowed := Names(model)
+	var selection Selection
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("unknown field %q in fields, allowed: %s", field, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(selection, field) {
+			selection = append(selection, field)
+		}
+	}
+	return selection, nil
+}
+
+// Names returns the JSON names of the exported fields of model in
+// declaration order
+func Names(model interface{}) []string {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Apply serializes value, a DTO or a slice of DTOs, keeping only the selected
+// fields of each object. Without a selection value is returned unchanged.
+func (s Selection) Apply(value interface{}) (interface{}, error) {
+	if len(s) == 0 {
+		return value, nil
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	switch document := document.(type) {
+	case map[string]interface{}:
+		return s.keep(document), nil
+	case []interface{}:
+		for i, item := range document {
+			if object, ok := item.(map[string]interface{}); ok {
+				document[i] = s.keep(object)
+			}
+		}
+	}
+	return document, nil
+}
+
+func (s Selection) keep(object map[string]interface{}) map[string]interface{} {
+	for key := range object {
+		if !slices.Contains(s, key) {
+			delete(object, key)
+		}
+	}
+	return object
+}
@@ -0,0 +1,77 @@
+package fields
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("no fields keeps everything", func(t *testing.T) {
+		selection, err := Parse(" ", models.Balance{})
+		require.NoError(t, err)
+		assert.Empty(t, selection)
+	})
+
+	t.Run("known fields", func(t *testing.T) {
+		selection, err := Parse("available_balance, balance,,balance", models.Balance{})
+		require.NoError(t, err)
+		assert.Equal(t, Selection{"available_balance", "balance"}, selection)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := Parse("balance,total", &models.Balance{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown field "total"`)
+		assert.Contains(t, err.Error(), "balance, held_balance, available_balance")
+	})
+}
+
+func TestNames(t *testing.T) {
+	type dto struct {
+		ID       string `json:"id"`
+		Internal string `json:"-"`
+		Plain    int
+		hidden   bool
+	}
+	assert.Equal(t, []string{"id", "Plain"}, Names(dto{}))
+}
+
+func TestSelection_Apply(t *testing.T) {
+	balance := models.Balance{
+		Total:     decimal.RequireFromString("100.5"),
+		Held:      decimal.RequireFromString("20"),
+		Available: decimal.RequireFromString("80.5"),
+	}
+
+	t.Run("object", func(t *testing.T) {
+		sparse, err := Selection{"available_balance"}.Apply(balance)
+		require.NoError(t, err)
+		body, err := json.Marshal(sparse)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"available_balance":"80.5"}`, string(body))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		id1, id2 := "1", "2"
+		amount := decimal.NewFromInt(5)
+		transactions := []models.Transaction{{ID: &id1, Amount: &amount}, {ID: &id2}}
+
+		sparse, err := Selection{"id", "amount"}.Apply(transactions)
+		require.NoError(t, err)
+		body, err := json.Marshal(sparse)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"id":"1","amount":"5"},{"id":"2"}]`, string(body))
+	})
+
+	t.Run("no selection", func(t *testing.T) {
+		sparse, err := Selection(nil).Apply(balance)
+		require.NoError(t, err)
+		assert.Equal(t, balance, sparse)
+	})
+}
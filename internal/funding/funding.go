@@ -0,0 +1,108 @@
+package funding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrDeclined marks a charge the provider refused for good, for example an
+// expired card or a closed bank account. Other errors are transient and the
+// charge is retried.
+var ErrDeclined = errors.New("funding charge declined")
+
+// Charge is an amount to collect from the funding source linked to a wallet
+// on behalf of an automatic top-up
+type Charge struct {
+	TopUpID  string          `json:"top_up_id"`
+	UserID   string          `json:"user_id"`
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency,omitempty"`
+	Source   string          `json:"source"`
+}
+
+// FundingProvider collects charges from funding sources and returns the
+// provider's reference for them. A charge may be collected more than once
+// when a worker stops before recording the outcome, so providers must treat
+// TopUpID as an idempotency key.
+type FundingProvider interface {
+	Collect(ctx context.Context, charge Charge) (string, error)
+}
+
+// LogProvider writes charges to the application log and reports them as
+// collected. It is used when no funding provider is configured.
+type LogProvider struct {
+	logger *logrus.Logger
+}
+
+func NewLogProvider(logger *logrus.Logger) *LogProvider {
+	return &LogProvider{logger: logger}
+}
+
+func (p *LogProvider) Collect(ctx context.Context, charge Charge) (string, error) {
+	p.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"topUpID": charge.TopUpID,
+		"userID":  charge.UserID,
+		"amount":  charge.Amount,
+	}).Info("Funding charge collected")
+	return "log-" + charge.TopUpID, nil
+}
+
+// WebhookProvider sends each charge as a JSON POST to a fixed URL with the
+// top-up ID in the Idempotency-Key header. A 2xx response carries the
+// provider reference as {"reference": "..."}; other 4xx responses, except
+// 408 and 429, decline the charge.
+type WebhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookProvider(url string, timeout time.Duration) *WebhookProvider {
+	return &WebhookProvider{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *WebhookProvider) Collect(ctx context.Context, charge Charge) (string, error) {
+	body, err := json.Marshal(charge)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", charge.TopUpID)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		var result struct {
+			Reference string `json:"reference"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("decode funding response: %w", err)
+		}
+		return result.Reference, nil
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("%w: provider responded with status %d", ErrDeclined, resp.StatusCode)
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("provider responded with status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,62 @@
+package funding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookProvider(t *testing.T) {
+	charge := Charge{
+		TopUpID: "17",
+		UserID:  "user1",
+		Amount:  decimal.RequireFromString("50"),
+		Source:  "card:tok_visa_4242",
+	}
+
+	t.Run("returns the provider reference", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "17", r.Header.Get("Idempotency-Key"))
+			var received Charge
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			assert.True(t, received.Amount.Equal(charge.Amount))
+			assert.Equal(t, charge.Source, received.Source)
+			_, _ = w.Write([]byte(`{"reference": "ch_123"}`))
+		}))
+		defer server.Close()
+
+		reference, err := NewWebhookProvider(server.URL, time.Second).Collect(context.Background(), charge)
+		require.NoError(t, err)
+		assert.Equal(t, "ch_123", reference)
+	})
+
+	t.Run("client errors decline the charge", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPaymentRequired)
+		}))
+		defer server.Close()
+
+		_, err := NewWebhookProvider(server.URL, time.Second).Collect(context.Background(), charge)
+		assert.ErrorIs(t, err, ErrDeclined)
+	})
+
+	t.Run("server errors and throttling are transient", func(t *testing.T) {
+		for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+
+			_, err := NewWebhookProvider(server.URL, time.Second).Collect(context.Background(), charge)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrDeclined)
+			server.Close()
+		}
+	})
+}
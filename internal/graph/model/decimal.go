@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+)
+
+// MarshalDecimal writes d as a string, like the REST API, so clients keep
+// its precision
+func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(d.String()))
+	})
+}
+
+// UnmarshalDecimal reads a decimal sent as a string or a number. Decimals
+// the ledger cannot store are rejected before they reach a resolver.
+func UnmarshalDecimal(v any) (decimal.Decimal, error) {
+	var d decimal.Decimal
+	var err error
+	switch v := v.(type) {
+	case string:
+		d, err = decimal.NewFromString(v)
+	case json.Number:
+		d, err = decimal.NewFromString(v.String())
+	case int64:
+		d = decimal.NewFromInt(v)
+	case int:
+		d = decimal.NewFromInt(int64(v))
+	case float64:
+		d = decimal.NewFromFloat(v)
+	default:
+		return decimal.Decimal{}, fmt.Errorf("%T is not a decimal", v)
+	}
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("%v is not a decimal", v)
+	}
+	if !models.FitsLedger(d) {
+		return decimal.Decimal{}, fmt.Errorf("must have at most %d integer digits and %d decimal places", models.MaxAmountIntegerDigits, models.MaxAmountScale)
+	}
+	return d, nil
+}
@@ -0,0 +1,126 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"Crypto.com/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+type Balance struct {
+	UserID           string          `json:"userId"`
+	Balance          decimal.Decimal `json:"balance"`
+	HeldBalance      decimal.Decimal `json:"heldBalance"`
+	AvailableBalance decimal.Decimal `json:"availableBalance"`
+	OverdraftLimit   decimal.Decimal `json:"overdraftLimit"`
+	OverdraftUsed    decimal.Decimal `json:"overdraftUsed"`
+}
+
+type MetadataEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type MetadataInput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
+
+// The memo, reference and metadata recorded with a transaction
+type TransactionDetails struct {
+	Memo        *string          `json:"memo,omitempty"`
+	ReferenceID *string          `json:"referenceId,omitempty"`
+	Metadata    []*MetadataInput `json:"metadata,omitempty"`
+}
+
+// Narrows a history like the query parameters of the REST history
+type TransactionFilter struct {
+	From        *time.Time       `json:"from,omitempty"`
+	To          *time.Time       `json:"to,omitempty"`
+	ReferenceID *string          `json:"referenceId,omitempty"`
+	Memo        *string          `json:"memo,omitempty"`
+	Metadata    []*MetadataInput `json:"metadata,omitempty"`
+}
+
+type TransactionPage struct {
+	Transactions []*models.Transaction `json:"transactions"`
+	HasMore      bool                  `json:"hasMore"`
+	NextCursor   *string               `json:"nextCursor,omitempty"`
+}
+
+type TransferResult struct {
+	Status TransferStatus `json:"status"`
+	// The balance of the sender once the transfer completed
+	Balance *Balance `json:"balance,omitempty"`
+	// The confirmation to confirm through REST when the transfer waits for one
+	ConfirmationID *string `json:"confirmationId,omitempty"`
+}
+
+type TransferStatus string
+
+const (
+	// The funds moved
+	TransferStatusCompleted TransferStatus = "COMPLETED"
+	// The transfer waits for the sender to confirm it with the code sent to them
+	TransferStatusPendingConfirmation TransferStatus = "PENDING_CONFIRMATION"
+)
+
+var AllTransferStatus = []TransferStatus{
+	TransferStatusCompleted,
+	TransferStatusPendingConfirmation,
+}
+
+func (e TransferStatus) IsValid() bool {
+	switch e {
+	case TransferStatusCompleted, TransferStatusPendingConfirmation:
+		return true
+	}
+	return false
+}
+
+func (e TransferStatus) String() string {
+	return string(e)
+}
+
+func (e *TransferStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TransferStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TransferStatus", str)
+	}
+	return nil
+}
+
+func (e TransferStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TransferStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TransferStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+// Package graph serves the wallet over GraphQL. The schema is in
+// schema.graphqls; generated.go and model/models_gen.go are generated from it
+// by gqlgen, see gqlgen.yml.
+package graph
+
+import (
+	"context"
+	"net/http"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/graph/model"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+// Resolver resolves every field through the services behind the REST
+// endpoints. The routes of the REST API check ownership, scopes and user IDs
+// in middleware; a GraphQL request names its wallets in its arguments, so
+// resolvers check them with authorize.
+type Resolver struct {
+	wallets *services.WalletService
+	// confirmations holds transfers above the confirmation threshold until
+	// their sender confirms them; nil transfers every amount at once
+	confirmations *services.TransferConfirmationService
+	// strictUserIDs rejects user IDs not in the format users are registered
+	// with, as RequireUserID does
+	strictUserIDs bool
+}
+
+func NewResolver(wallets *services.WalletService, confirmations *services.TransferConfirmationService, strictUserIDs bool) *Resolver {
+	return &Resolver{wallets: wallets, confirmations: confirmations, strictUserIDs: strictUserIDs}
+}
+
+// authorize rejects callers that may not use the wallet of userID: API keys
+// without scope, and callers that do not own the wallet. Support staff and
+// auditors may only read.
+func (r *Resolver) authorize(ctx context.Context, userID, scope string) error {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !principal.HasScope(scope) {
+		return auth.ErrInsufficientScope
+	}
+	allowed := principal.CanAccess(userID)
+	if scope == auth.ScopeRead {
+		allowed = principal.CanRead(userID)
+	}
+	if !ok || !allowed {
+		return apierror.New(http.StatusForbidden, apierror.CodeForbidden, "access to this wallet is not allowed")
+	}
+	return r.validateUserID(userID)
+}
+
+func (r *Resolver) validateUserID(userID string) error {
+	if r.strictUserIDs && !services.ValidUserID(userID) {
+		return postgres.ErrInvalidUserID
+	}
+	return nil
+}
+
+// operationContext attaches the idempotency key and the transaction details
+// of a mutation to ctx, as the REST handlers do with the Idempotency-Key
+// header and the request body
+func operationContext(ctx context.Context, details *model.TransactionDetails, idempotencyKey *string) (context.Context, error) {
+	if idempotencyKey != nil && *idempotencyKey != "" {
+		if len(*idempotencyKey) > 255 {
+			return nil, apierror.BadRequest("idempotencyKey must be at most 255 characters")
+		}
+		ctx = operation.WithIdempotencyKey(ctx, *idempotencyKey)
+	}
+	if details == nil {
+		details = &model.TransactionDetails{}
+	}
+	return services.WithTransactionDetails(ctx, operation.Details{
+		Memo:      deref(details.Memo),
+		Reference: deref(details.ReferenceID),
+		Metadata:  metadataMap(details.Metadata),
+	})
+}
+
+// balanceMismatch adds the current balance and version of the wallet to a
+// balance mismatch so the client can refresh its view before retrying, like
+// the 412 of the REST API
+func (r *Resolver) balanceMismatch(ctx context.Context, userID string, err error) error {
+	balance, version, balanceErr := r.wallets.GetCurrentBalance(ctx, userID)
+	if balanceErr != nil {
+		return err
+	}
+	return apierror.New(http.StatusPreconditionFailed, apierror.CodeBalanceMismatch, err.Error()).
+		WithDetails(map[string]any{"balance": balance, "version": version})
+}
+
+func (r *Resolver) balance(ctx context.Context, userID string) (*model.Balance, error) {
+	balance, err := r.wallets.GetBalanceDetails(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return newBalance(userID, balance), nil
+}
+
+func newBalance(userID string, balance models.Balance) *model.Balance {
+	return &model.Balance{
+		UserID:           userID,
+		Balance:          balance.Total,
+		HeldBalance:      balance.Held,
+		AvailableBalance: balance.Available,
+		OverdraftLimit:   balance.OverdraftLimit,
+		OverdraftUsed:    balance.OverdraftUsed,
+	}
+}
+
+func metadataMap(entries []*model.MetadataInput) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	metadata := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		metadata[entry.Key] = entry.Value
+	}
+	return metadata
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func operationDetails(filter *model.TransactionFilter) operation.Details {
+	return operation.Details{Memo: deref(filter.Memo), Reference: deref(filter.ReferenceID), Metadata: metadataMap(filter.Metadata)}
+}
@@ -0,0 +1,158 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/graph/model"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+	"github.com/shopspring/decimal"
+)
+
+// Deposit is the resolver for the deposit field.
+func (r *mutationResolver) Deposit(ctx context.Context, userID string, amount decimal.Decimal, details *model.TransactionDetails, idempotencyKey *string) (*model.Balance, error) {
+	if err := r.authorize(ctx, userID, auth.ScopeMoneyMovement); err != nil {
+		return nil, err
+	}
+	ctx, err := operationContext(ctx, details, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.wallets.Deposit(ctx, userID, amount); err != nil {
+		return nil, err
+	}
+	return r.balance(ctx, userID)
+}
+
+// Withdraw is the resolver for the withdraw field.
+func (r *mutationResolver) Withdraw(ctx context.Context, userID string, amount decimal.Decimal, expectedBalance *decimal.Decimal, details *model.TransactionDetails, idempotencyKey *string) (*model.Balance, error) {
+	if err := r.authorize(ctx, userID, auth.ScopeMoneyMovement); err != nil {
+		return nil, err
+	}
+	ctx, err := operationContext(ctx, details, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.wallets.Withdraw(ctx, userID, amount, expectedBalance); err != nil {
+		if errors.Is(err, postgres.ErrBalanceMismatch) {
+			return nil, r.balanceMismatch(ctx, userID, err)
+		}
+		return nil, err
+	}
+	return r.balance(ctx, userID)
+}
+
+// Transfer is the resolver for the transfer field.
+func (r *mutationResolver) Transfer(ctx context.Context, userID string, receiverID string, amount decimal.Decimal, expectedBalance *decimal.Decimal, details *model.TransactionDetails, idempotencyKey *string) (*model.TransferResult, error) {
+	if err := r.authorize(ctx, userID, auth.ScopeMoneyMovement); err != nil {
+		return nil, err
+	}
+	if err := r.validateUserID(receiverID); err != nil {
+		return nil, err
+	}
+	ctx, err := operationContext(ctx, details, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.confirmations != nil && r.confirmations.Required(amount) {
+		confirmation, err := r.confirmations.Request(ctx, userID, receiverID, amount, expectedBalance)
+		if err != nil {
+			return nil, err
+		}
+		return &model.TransferResult{Status: model.TransferStatusPendingConfirmation, ConfirmationID: &confirmation.ID}, nil
+	}
+
+	if err := r.wallets.Transfer(ctx, userID, receiverID, amount, expectedBalance); err != nil {
+		if errors.Is(err, postgres.ErrBalanceMismatch) {
+			return nil, r.balanceMismatch(ctx, userID, err)
+		}
+		return nil, err
+	}
+	balance, err := r.balance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TransferResult{Status: model.TransferStatusCompleted, Balance: balance}, nil
+}
+
+// Balance is the resolver for the balance field.
+func (r *queryResolver) Balance(ctx context.Context, userID string) (*model.Balance, error) {
+	if err := r.authorize(ctx, userID, auth.ScopeRead); err != nil {
+		return nil, err
+	}
+	return r.balance(ctx, userID)
+}
+
+// Transactions is the resolver for the transactions field.
+func (r *queryResolver) Transactions(ctx context.Context, userID string, first *int, after *string, filter *model.TransactionFilter) (*model.TransactionPage, error) {
+	if err := r.authorize(ctx, userID, auth.ScopeRead); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &model.TransactionFilter{}
+	}
+
+	cursor := deref(after)
+	window, err := services.NewHistoryWindow(filter.From, filter.To, false, cursor)
+	if err != nil {
+		return nil, err
+	}
+	window, err = services.FilterHistoryWindow(window, operationDetails(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	// GetTransactionPage reads 50 transactions when first is missing or
+	// above 100, like the REST history
+	limit := 0
+	if first != nil {
+		limit = *first
+	}
+	transactions, nextCursor, err := r.wallets.GetTransactionPage(ctx, userID, cursor, window, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.TransactionPage{
+		Transactions: make([]*models.Transaction, len(transactions)),
+		HasMore:      nextCursor != "",
+	}
+	for i := range transactions {
+		page.Transactions[i] = &transactions[i]
+	}
+	if nextCursor != "" {
+		page.NextCursor = &nextCursor
+	}
+	return page, nil
+}
+
+// Metadata is the resolver for the metadata field.
+func (r *transactionResolver) Metadata(ctx context.Context, obj *models.Transaction) ([]*model.MetadataEntry, error) {
+	entries := make([]*model.MetadataEntry, 0, len(obj.Metadata))
+	for _, key := range slices.Sorted(maps.Keys(obj.Metadata)) {
+		entries = append(entries, &model.MetadataEntry{Key: key, Value: obj.Metadata[key]})
+	}
+	return entries, nil
+}
+
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+func (r *Resolver) Transaction() TransactionResolver { return &transactionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type transactionResolver struct{ *Resolver }
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type AcknowledgmentHandler struct {
+	service *services.AcknowledgmentService
+}
+
+func NewAcknowledgmentHandler(service *services.AcknowledgmentService) *AcknowledgmentHandler {
+	return &AcknowledgmentHandler{service: service}
+}
+
+// Acknowledge acknowledges a transfer the wallet received, with an optional
+// thank-you message for the sender
+func (h *AcknowledgmentHandler) Acknowledge(c *gin.Context) {
+	var request struct {
+		Message string `json:"message"`
+	}
+
+	// The body is optional; without one the transfer is acknowledged
+	// without a message
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	acknowledgment, err := h.service.Acknowledge(c.Request.Context(), c.Param("userID"), c.Param("transactionID"), request.Message)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, acknowledgment)
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type AdminHandler struct {
+	freezes     *services.FreezeService
+	exposures   *services.ExposureService
+	remediation *services.RemediationService
+	ownership   *services.OwnershipService
+	wallets     *services.WalletAdminService
+}
+
+func NewAdminHandler(freezes *services.FreezeService, exposures *services.ExposureService, remediation *services.RemediationService, ownership *services.OwnershipService, wallets *services.WalletAdminService) *AdminHandler {
+	return &AdminHandler{freezes: freezes, exposures: exposures, remediation: remediation, ownership: ownership, wallets: wallets}
+}
+
+type freezeCriteriaRequest struct {
+	Label              *string          `json:"label"`
+	Country            *string          `json:"country" binding:"omitempty,len=2"`
+	CounterpartyID     *string          `json:"counterparty_id"`
+	MinExposure        *decimal.Decimal `json:"min_exposure" binding:"omitempty,gt=0"`
+	ExposureWindowDays int              `json:"exposure_window_days" binding:"omitempty,gt=0,lte=365"`
+}
+
+func (r freezeCriteriaRequest) criteria() models.FreezeCriteria {
+	return models.FreezeCriteria{
+		Label:              r.Label,
+		Country:            r.Country,
+		CounterpartyID:     r.CounterpartyID,
+		MinExposure:        r.MinExposure,
+		ExposureWindowDays: r.ExposureWindowDays,
+	}
+}
+
+func (h *AdminHandler) PreviewFreeze(c *gin.Context) {
+	var request freezeCriteriaRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	count, err := h.freezes.Preview(c.Request.Context(), request.criteria())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"affected_wallets": count})
+}
+
+func (h *AdminHandler) StartFreeze(c *gin.Context) {
+	var request struct {
+		Criteria freezeCriteriaRequest `json:"criteria" binding:"required"`
+		Reason   string                `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	job, err := h.freezes.StartFreeze(c.Request.Context(), request.Criteria.criteria(), request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *AdminHandler) GetFreezeJob(c *gin.Context) {
+	job, err := h.freezes.GetJob(c.Request.Context(), c.Param("jobID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *AdminHandler) UnfreezeCohort(c *gin.Context) {
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	job, err := h.freezes.StartUnfreeze(c.Request.Context(), c.Param("jobID"), request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *AdminHandler) ListExposures(c *gin.Context) {
+	var request struct {
+		UserID         *string          `form:"user_id"`
+		CounterpartyID *string          `form:"counterparty_id"`
+		WindowDays     int              `form:"window_days" binding:"required,gt=0"`
+		MinVolume      *decimal.Decimal `form:"min_volume"`
+		Limit          int              `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if request.UserID != nil && request.CounterpartyID != nil {
+		exposure, err := h.exposures.GetExposure(c.Request.Context(), *request.UserID, *request.CounterpartyID, request.WindowDays)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"exposures": []models.Exposure{*exposure}})
+		return
+	}
+
+	exposures, err := h.exposures.ListExposures(c.Request.Context(), models.ExposureFilter{
+		UserID:     request.UserID,
+		WindowDays: request.WindowDays,
+		MinVolume:  request.MinVolume,
+		Limit:      request.Limit,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exposures": exposures})
+}
+
+func (h *AdminHandler) ListStuckTransactions(c *gin.Context) {
+	var request struct {
+		Status    string `form:"status"`
+		Flag      string `form:"flag"`
+		OlderThan string `form:"older_than"`
+		Limit     int    `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	var olderThan time.Duration
+	if request.OlderThan != "" {
+		var err error
+		if olderThan, err = time.ParseDuration(request.OlderThan); err != nil || olderThan < 0 {
+			abortWithError(c, apierror.BadRequest("older_than must be a duration such as 30m or 1h"))
+			return
+		}
+	}
+
+	transactions, err := h.remediation.ListStuck(c.Request.Context(), models.StuckTransactionFilter{
+		Status:    request.Status,
+		Flag:      request.Flag,
+		OlderThan: olderThan,
+		Limit:     request.Limit,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+func (h *AdminHandler) RemediateTransaction(c *gin.Context) {
+	var request struct {
+		Action string `json:"action" binding:"required,oneof=retry fail escalate"`
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	txn, err := h.remediation.Remediate(c.Request.Context(), c.Param("transactionID"), request.Action, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, txn)
+}
+
+func (h *AdminHandler) ReassignWallet(c *gin.Context) {
+	var request struct {
+		NewUserID string `json:"new_user_id" binding:"required,user_id"`
+		Reason    string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	change, err := h.ownership.ReassignWallet(c.Request.Context(), c.Param("userID"), request.NewUserID, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+func (h *AdminHandler) MergeWallets(c *gin.Context) {
+	var request struct {
+		TargetUserID string `json:"target_user_id" binding:"required,user_id"`
+		Reason       string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	merge, err := h.ownership.MergeWallets(c.Request.Context(), c.Param("userID"), request.TargetUserID, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merge)
+}
+
+func (h *AdminHandler) ListWallets(c *gin.Context) {
+	var request struct {
+		Status     *string          `form:"status" binding:"omitempty,oneof=active frozen closed"`
+		Label      *string          `form:"label"`
+		Country    *string          `form:"country" binding:"omitempty,len=2"`
+		MinBalance *decimal.Decimal `form:"min_balance"`
+		MaxBalance *decimal.Decimal `form:"max_balance"`
+		After      string           `form:"after"`
+		Limit      int              `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	selection, ok := selectFields(c, models.Wallet{})
+	if !ok {
+		return
+	}
+
+	// metadata.<key>=<value> matches wallets whose metadata holds key with
+	// value
+	var metadata map[string]string
+	for param, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(param, "metadata."); ok {
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			metadata[key] = values[0]
+		}
+	}
+
+	wallets, nextAfter, err := h.wallets.ListWallets(c.Request.Context(), models.WalletFilter{
+		Status:     request.Status,
+		Label:      request.Label,
+		Country:    request.Country,
+		MinBalance: request.MinBalance,
+		MaxBalance: request.MaxBalance,
+		Metadata:   metadata,
+		After:      request.After,
+		Limit:      request.Limit,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	items, ok := sparse(c, selection, wallets)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"wallets":    items,
+		"next_after": nullableCursor(nextAfter),
+	})
+}
+
+func (h *AdminHandler) FreezeWallet(c *gin.Context) {
+	h.setWalletStatus(c, h.wallets.Freeze)
+}
+
+func (h *AdminHandler) UnfreezeWallet(c *gin.Context) {
+	h.setWalletStatus(c, h.wallets.Unfreeze)
+}
+
+func (h *AdminHandler) CloseWallet(c *gin.Context) {
+	h.setWalletStatus(c, h.wallets.Close)
+}
+
+func (h *AdminHandler) setWalletStatus(c *gin.Context, apply func(ctx context.Context, userID, reason string) error) {
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if err := apply(c.Request.Context(), c.Param("userID"), request.Reason); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminHandler) AdjustBalance(c *gin.Context) {
+	var request struct {
+		Amount     decimal.Decimal `json:"amount" binding:"required,amount"`
+		ReasonCode string          `json:"reason_code" binding:"required"`
+		Note       string          `json:"note" binding:"max=500"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	adjustment, err := h.wallets.AdjustBalance(c.Request.Context(), c.Param("userID"), request.Amount, request.ReasonCode, request.Note)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustment)
+}
+
+// SetWalletCurrency sets the currency a wallet holds. Transfers between
+// wallets of different currencies are converted at the current rate.
+func (h *AdminHandler) SetWalletCurrency(c *gin.Context) {
+	var request struct {
+		Currency string `json:"currency" binding:"required,currency"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	userID := c.Param("userID")
+	currency, err := h.wallets.SetCurrency(c.Request.Context(), userID, request.Currency)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "currency": currency})
+}
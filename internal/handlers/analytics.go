@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type AnalyticsHandler struct {
+	service *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(service *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// Monthly returns the income, spending by category and round-ups of the
+// wallet over the month query parameter (YYYY-MM), the current month by
+// default
+func (h *AnalyticsHandler) Monthly(c *gin.Context) {
+	summary, err := h.service.Monthly(c.Request.Context(), c.Param("userID"), c.Query("month"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateKey issues an API key. The response is the only time the key is
+// shown.
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var request struct {
+		Name      string     `json:"name" binding:"required"`
+		Subject   string     `json:"subject" binding:"required"`
+		Roles     []string   `json:"roles"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	key, secret, err := h.service.Create(c.Request.Context(), models.APIKey{
+		Name:      request.Name,
+		Subject:   request.Subject,
+		Roles:     request.Roles,
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": secret})
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	key, err := h.service.Revoke(c.Request.Context(), c.Param("keyID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
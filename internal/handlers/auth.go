@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+)
+
+// APIKeyHeader carries the API key of service-to-service callers
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves an API key to the principal it authenticates
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (auth.Principal, error)
+}
+
+// AuthHandler authenticates requests with a bearer JWT, or with an API key
+// when keys is set and the request carries X-API-Key, and stores the
+// principal in the request context
+func AuthHandler(verifier *auth.Verifier, keys APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal auth.Principal
+		var err error
+		if key := c.GetHeader(APIKeyHeader); key != "" && keys != nil {
+			principal, err = keys.Authenticate(c.Request.Context(), key)
+		} else {
+			token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				abortWithError(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "missing bearer token"))
+				return
+			}
+			principal, err = verifier.Verify(token)
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireWalletOwner rejects requests on a :userID the caller does not own,
+// unless the caller is an admin. Support staff and auditors may only read.
+func RequireWalletOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFrom(c.Request.Context())
+		allowed := principal.CanAccess(c.Param("userID"))
+		if c.Request.Method == http.MethodGet {
+			allowed = principal.CanRead(c.Param("userID"))
+		}
+		if !ok || !allowed {
+			abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "access to this wallet is not allowed"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireStepUp rejects requests from callers who did not complete
+// multi-factor authentication within maxAge. The challenge tells clients to
+// send the user through MFA and retry with the new token.
+func RequireStepUp(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFrom(c.Request.Context())
+		if !principal.SteppedUpWithin(maxAge) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_user_authentication", acr_values="mfa"`)
+			abortWithError(c, auth.ErrStepUpRequired)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests from API keys without the scope their
+// method needs: read for GET requests, money movement for every other one.
+// Bearer tokens carry no scopes and pass.
+func RequireScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := auth.ScopeMoneyMovement
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = auth.ScopeRead
+		}
+
+		principal, _ := auth.PrincipalFrom(c.Request.Context())
+		if !principal.HasScope(scope) {
+			abortWithError(c, auth.ErrInsufficientScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireBearerToken rejects requests authenticated with an API key, for
+// endpoints only people may use, such as managing API keys
+func RequireBearerToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFrom(c.Request.Context())
+		if principal.APIKeyID != "" {
+			abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "API keys cannot use this endpoint"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests from callers without the admin role
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFrom(c.Request.Context())
+		if !ok || !principal.IsAdmin() {
+			abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "admin role required"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+type BatchHandler struct {
+	service *services.BatchService
+}
+
+func NewBatchHandler(service *services.BatchService) *BatchHandler {
+	return &BatchHandler{service: service}
+}
+
+func (h *BatchHandler) BatchTransfer(c *gin.Context) {
+	senderID := c.Param("userID")
+
+	var request struct {
+		Mode      string `json:"mode" binding:"required,oneof=best_effort atomic"`
+		Transfers []struct {
+			ReceiverID string          `json:"receiver_id" binding:"required,user_id"`
+			Amount     decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		} `json:"transfers" binding:"required,min=1,max=100,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	items := make([]services.BatchTransferItem, 0, len(request.Transfers))
+	for _, transfer := range request.Transfers {
+		items = append(items, services.BatchTransferItem{
+			ReceiverID: transfer.ReceiverID,
+			Amount:     transfer.Amount,
+		})
+	}
+
+	batch, err := h.service.BatchTransfer(c.Request.Context(), senderID, request.Mode, items)
+	if err != nil {
+		if batch != nil {
+			// Transfers were applied but the summary could not be stored
+			_ = c.Error(err)
+			abortWithError(c, apierror.Internal().WithDetails(gin.H{"batch": batch}))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if batch.FailedCount > 0 && batch.Mode == models.BatchModeBestEffort {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, batch)
+}
+
+func (h *BatchHandler) GetBatch(c *gin.Context) {
+	userID := c.Param("userID")
+
+	batch, err := h.service.GetBatch(c.Request.Context(), c.Param("batchID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if batch.SenderID != userID {
+		abortWithError(c, postgres.ErrBatchNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
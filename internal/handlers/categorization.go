@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type CategorizationHandler struct {
+	service *services.CategorizationService
+}
+
+func NewCategorizationHandler(service *services.CategorizationService) *CategorizationHandler {
+	return &CategorizationHandler{service: service}
+}
+
+func (h *CategorizationHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *CategorizationHandler) CreateRule(c *gin.Context) {
+	var request struct {
+		Category            string           `json:"category" binding:"required"`
+		Priority            int              `json:"priority"`
+		CounterpartyPattern *string          `json:"counterparty_pattern"`
+		MinAmount           *decimal.Decimal `json:"min_amount"`
+		MaxAmount           *decimal.Decimal `json:"max_amount"`
+		Type                *string          `json:"type"`
+		Channel             *string          `json:"channel"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	rule, err := h.service.CreateRule(c.Request.Context(), models.CategorizationRule{
+		Category:            request.Category,
+		Priority:            request.Priority,
+		CounterpartyPattern: request.CounterpartyPattern,
+		MinAmount:           request.MinAmount,
+		MaxAmount:           request.MaxAmount,
+		Type:                request.Type,
+		Channel:             request.Channel,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *CategorizationHandler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Request.Context(), c.Param("ruleID")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StartRecategorization reapplies the rules to recorded transactions,
+// optionally only to those created at or after ?since=
+func (h *CategorizationHandler) StartRecategorization(c *gin.Context) {
+	var since *time.Time
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			abortWithError(c, apierror.BadRequest("since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = &parsed
+	}
+
+	run, err := h.service.StartRecategorization(c.Request.Context(), since)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+func (h *CategorizationHandler) GetRecategorization(c *gin.Context) {
+	run, ok := h.service.LatestRecategorization()
+	if !ok {
+		abortWithError(c, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "no recategorization has run on this instance"))
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type ComplianceHandler struct {
+	service *services.ComplianceService
+}
+
+func NewComplianceHandler(service *services.ComplianceService) *ComplianceHandler {
+	return &ComplianceHandler{service: service}
+}
+
+// ListPolicies returns the policy version in effect for every jurisdiction
+func (h *ComplianceHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.service.Policies(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// PolicyVersions returns every version of the policy of a jurisdiction,
+// newest first; "default" names the default policy
+func (h *ComplianceHandler) PolicyVersions(c *gin.Context) {
+	policies, err := h.service.PolicyVersions(c.Request.Context(), c.Param("jurisdiction"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": policies})
+}
+
+// PutPolicy records a new version of the policy of a jurisdiction
+func (h *ComplianceHandler) PutPolicy(c *gin.Context) {
+	var request struct {
+		AllowedCurrencies   []string         `json:"allowed_currencies"`
+		MaxBalance          *decimal.Decimal `json:"max_balance"`
+		ExternalWithdrawals *bool            `json:"external_withdrawals" binding:"required"`
+		Reason              string           `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	policy, err := h.service.SetPolicy(c.Request.Context(), models.CompliancePolicy{
+		Jurisdiction:        c.Param("jurisdiction"),
+		AllowedCurrencies:   request.AllowedCurrencies,
+		MaxBalance:          request.MaxBalance,
+		ExternalWithdrawals: *request.ExternalWithdrawals,
+		Reason:              request.Reason,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// ListDecisions returns the latest compliance decisions on operations of the
+// wallet with the policy version each was taken on
+func (h *ComplianceHandler) ListDecisions(c *gin.Context) {
+	decisions, err := h.service.Decisions(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": c.Param("userID"), "decisions": decisions})
+}
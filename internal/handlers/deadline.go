@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadlineHandler bounds the context of every request by timeout, so the
+// database and cache calls made on its behalf are cancelled once it is
+// exceeded. Routes listed in routes by pattern get their own timeout, such
+// as long polls. A zero timeout leaves a request unbounded.
+func DeadlineHandler(timeout time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := timeout
+		if route, ok := routes[c.FullPath()]; ok {
+			limit = route
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/openapi"
+)
+
+// swaggerUIVersion pins the Swagger UI assets loaded by the docs page
+const swaggerUIVersion = "5.17.14"
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Wallet API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/docs/openapi.yaml", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// OpenAPIHandler serves the OpenAPI specification of the API
+func OpenAPIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", openapi.Spec())
+}
+
+// DocsHandler serves Swagger UI rendering the OpenAPI specification
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
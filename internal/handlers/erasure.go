@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type ErasureHandler struct {
+	service *services.ErasureService
+}
+
+func NewErasureHandler(service *services.ErasureService) *ErasureHandler {
+	return &ErasureHandler{service: service}
+}
+
+// RequestErasure closes an empty wallet and schedules the erasure of the
+// personal data of its owner after the retention period
+func (h *ErasureHandler) RequestErasure(c *gin.Context) {
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	erasure, err := h.service.Request(c.Request.Context(), c.Param("userID"), request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, erasure)
+}
+
+// ListErasures returns the latest erasures, newest first, optionally
+// filtered by status
+func (h *ErasureHandler) ListErasures(c *gin.Context) {
+	var request struct {
+		Status string `form:"status"`
+		Limit  int    `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	erasures, err := h.service.List(c.Request.Context(), request.Status, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"erasures": erasures})
+}
+
+// GetErasure returns an erasure
+func (h *ErasureHandler) GetErasure(c *gin.Context) {
+	erasure, err := h.service.Get(c.Request.Context(), c.Param("erasureID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, erasure)
+}
+
+// CancelErasure cancels a pending erasure; the wallet stays closed
+func (h *ErasureHandler) CancelErasure(c *gin.Context) {
+	erasure, err := h.service.Cancel(c.Request.Context(), c.Param("erasureID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, erasure)
+}
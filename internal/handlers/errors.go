@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/rates"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+// errorRules gives every error the services return the same status and code
+// on all endpoints. Errors not listed are internal errors.
+var errorRules = apierror.Mapper{
+	// Invalid input
+	{Err: postgres.ErrInvalidUserID, Status: http.StatusBadRequest, Code: apierror.CodeInvalidUserID},
+	{Err: postgres.ErrInvalidAmount, Status: http.StatusBadRequest, Code: apierror.CodeInvalidAmount},
+	{Err: services.ErrInvalidCursor, Status: http.StatusBadRequest, Code: apierror.CodeInvalidCursor},
+	{Err: postgres.ErrInvalidLimit, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: postgres.ErrInvalidWindow, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: postgres.ErrEmptyCriteria, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: postgres.ErrInvalidIdempotencyKey, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidHistoryRange, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidTransactionDetails, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidPeriod, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrFutureTimestamp, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrEmptyBatch, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrUnsupportedBatchMode, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidReasonCode, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidStuckStatus, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrTransactionFilterNeeded, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidTransactionFlag, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidAnnotation, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidAuditAction, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidOverdraftLimit, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidSettingValue, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidSettingScope, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrUnknownLimit, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidLimitValue, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidRule, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidAmountRange, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrUnknownRuleType, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrUnknownRuleChannel, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidSchedule, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrLimitNotIncreased, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidLimitRequestFilter, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidJurisdiction, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidPolicy, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidAPIKeyRequest, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidWebhookKeyOverlap, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidCurrencyCode, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidExternalID, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrUnknownFeeOperation, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidFee, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidTopUpRule, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidRoundUpUnit, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidMonth, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidDay, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidErasureStatus, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidInitialStatus, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidPaymentRequest, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidPaymentRequestFilter, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidNotificationPreference, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidAcknowledgment, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrInvalidMetadata, Status: http.StatusBadRequest, Code: apierror.CodeInvalidRequest},
+	{Err: services.ErrConversionTooSmall, Status: http.StatusBadRequest, Code: apierror.CodeInvalidAmount},
+	{Err: services.ErrInvalidFaucetAmount, Status: http.StatusBadRequest, Code: apierror.CodeInvalidAmount},
+
+	// Balance and wallet state
+	{Err: postgres.ErrInsufficientBalance, Status: http.StatusBadRequest, Code: apierror.CodeInsufficientBalance},
+	{Err: postgres.ErrBalanceMismatch, Status: http.StatusPreconditionFailed, Code: apierror.CodeBalanceMismatch},
+	{Err: postgres.ErrWalletFrozen, Status: http.StatusForbidden, Code: apierror.CodeWalletFrozen},
+	{Err: postgres.ErrWalletClosed, Status: http.StatusGone, Code: apierror.CodeWalletClosed},
+	{Err: postgres.ErrWalletNotFrozen, Status: http.StatusConflict, Code: apierror.CodeWalletNotFrozen},
+	{Err: postgres.ErrWalletNotEmpty, Status: http.StatusConflict, Code: apierror.CodeWalletNotEmpty},
+	{Err: postgres.ErrWalletExists, Status: http.StatusConflict, Code: apierror.CodeWalletExists},
+	{Err: postgres.ErrWalletAlreadyExists, Status: http.StatusConflict, Code: apierror.CodeWalletExists},
+	{Err: postgres.ErrUserExists, Status: http.StatusConflict, Code: apierror.CodeUserExists},
+	{Err: postgres.ErrPendingTransfers, Status: http.StatusConflict, Code: apierror.CodePendingTransfers},
+	{Err: postgres.ErrHoldNotPending, Status: http.StatusConflict, Code: apierror.CodeTransferNotPending},
+	{Err: services.ErrWalletBusy, Status: http.StatusConflict, Code: apierror.CodeWalletBusy},
+	{Err: postgres.ErrWalletContended, Status: http.StatusConflict, Code: apierror.CodeWalletBusy},
+
+	// Currencies
+	{Err: postgres.ErrCurrencyMismatch, Status: http.StatusUnprocessableEntity, Code: apierror.CodeCurrencyMismatch},
+	{Err: rates.ErrUnsupportedCurrency, Status: http.StatusUnprocessableEntity, Code: apierror.CodeUnsupportedCurrency},
+	{Err: rates.ErrUnavailable, Status: http.StatusServiceUnavailable, Code: apierror.CodeRatesUnavailable},
+
+	// Limits
+	{Err: services.ErrAmountExceedsLimit, Status: http.StatusUnprocessableEntity, Code: apierror.CodeAmountExceedsLimit},
+	{Err: services.ErrLimitExceeded, Status: http.StatusUnprocessableEntity, Code: apierror.CodeLimitExceeded},
+	{Err: services.ErrComplianceDenied, Status: http.StatusForbidden, Code: apierror.CodeComplianceDenied},
+	{Err: services.ErrReconciliationTooLarge, Status: http.StatusUnprocessableEntity, Code: apierror.CodeReconciliationTooLarge},
+
+	// Idempotency
+	{Err: services.ErrIdempotencyKeyReused, Status: http.StatusUnprocessableEntity, Code: apierror.CodeIdempotencyKeyReused},
+	{Err: services.ErrIdempotencyInProgress, Status: http.StatusConflict, Code: apierror.CodeIdempotencyKeyInProgress},
+
+	// Not found
+	{Err: postgres.ErrUserNotFound, Status: http.StatusNotFound, Code: apierror.CodeUserNotFound},
+	{Err: postgres.ErrTransactionNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrQueuedDepositNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrBatchNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrHoldNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrWithdrawalNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrFreezeJobNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrLimitNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrSettingNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrRuleNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrScheduleNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrLimitRequestNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: services.ErrNoLimitToIncrease, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrPolicyNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrAPIKeyNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrWebhookKeyNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrCurrencyNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrFeeNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrTopUpRuleNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrRoundUpRuleNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrReconciliationRunNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrTrialBalanceNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrErasureNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrPaymentRequestNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: postgres.ErrTransferConfirmationNotFound, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: services.ErrUnknownSetting, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+	{Err: services.ErrUnknownKillSwitch, Status: http.StatusNotFound, Code: apierror.CodeNotFound},
+
+	// Conflicting admin operations
+	{Err: services.ErrActionNotAllowed, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrStatusChanged, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrNotAFreezeJob, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrJobNotFinished, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrRecategorizationBusy, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrInvalidScheduleTransition, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrScheduleStatusChanged, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrLimitRequestPending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrLimitRequestNotPending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrFeeExists, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrInvalidTopUpTransition, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrTopUpRuleStatusChanged, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrWebhookKeyCurrent, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrWebhookSubscriptionPaused, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrWebhookSubscriptionNotPaused, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrErasurePending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrErasureNotPending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrErasureNotAllowed, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrPaymentRequestNotPending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrPaymentRequestExpired, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrTransferConfirmationNotPending, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: services.ErrTransferConfirmationExpired, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrTransferAlreadyAcknowledged, Status: http.StatusConflict, Code: apierror.CodeConflict},
+	{Err: postgres.ErrMetadataVersionMismatch, Status: http.StatusConflict, Code: apierror.CodeConflict},
+
+	// Features the storage driver does not provide
+	{Err: services.ErrBalanceHistoryUnsupported, Status: http.StatusNotImplemented, Code: apierror.CodeNotImplemented},
+	{Err: services.ErrQueuedDepositsUnsupported, Status: http.StatusNotImplemented, Code: apierror.CodeNotImplemented},
+	{Err: services.ErrIdempotencyUnsupported, Status: http.StatusNotImplemented, Code: apierror.CodeNotImplemented},
+	{Err: services.ErrAtomicBatchesUnsupported, Status: http.StatusNotImplemented, Code: apierror.CodeNotImplemented},
+
+	// Transfer confirmations
+	{Err: postgres.ErrInvalidConfirmationCode, Status: http.StatusUnprocessableEntity, Code: apierror.CodeInvalidConfirmationCode},
+	{Err: services.ErrConfirmationCodeUndelivered, Status: http.StatusServiceUnavailable, Code: apierror.CodeConfirmationUndelivered},
+
+	// Operations disabled by an operator during an incident
+	{Err: services.ErrOperationDisabled, Status: http.StatusServiceUnavailable, Code: apierror.CodeOperationDisabled},
+
+	// Callers over a rate limit; the response carries Retry-After
+	{Err: services.ErrFaucetRateLimited, Status: http.StatusTooManyRequests, Code: apierror.CodeRateLimited},
+
+	// Requests that outlived their deadline, see DeadlineHandler
+	{Err: context.DeadlineExceeded, Status: http.StatusGatewayTimeout, Code: apierror.CodeRequestTimeout, Message: "request timed out"},
+
+	{Err: auth.ErrInvalidToken, Status: http.StatusUnauthorized, Code: apierror.CodeUnauthorized},
+	{Err: auth.ErrStepUpRequired, Status: http.StatusUnauthorized, Code: apierror.CodeStepUpRequired},
+	{Err: auth.ErrInvalidAPIKey, Status: http.StatusUnauthorized, Code: apierror.CodeUnauthorized},
+	{Err: auth.ErrInsufficientScope, Status: http.StatusForbidden, Code: apierror.CodeInsufficientScope},
+}
+
+// ErrorHandler renders the last error attached to the request with
+// abortWithError as the JSON error envelope. It must run after LoggingHandler
+// and MetricsHandler so they record the status it responds with.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		apiErr := toAPIError(c.Errors.Last().Err)
+		c.JSON(apiErr.Status, apiErr)
+	}
+}
+
+// abortWithError stops the request with err, which ErrorHandler renders
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+func toAPIError(err error) *apierror.Error {
+	// A failed transfer of an atomic batch is reported with its position
+	var itemErr *postgres.BatchItemError
+	if errors.As(err, &itemErr) {
+		return toAPIError(itemErr.Err).WithDetails(gin.H{"index": itemErr.Index, "receiver_id": itemErr.ReceiverID})
+	}
+
+	// The limit that was hit tells the client how much headroom is left
+	var exceeded *services.LimitExceededError
+	if errors.As(err, &exceeded) {
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeLimitExceeded, exceeded.Error()).
+			WithDetails(gin.H{"limit": exceeded})
+	}
+
+	// The policy that denied an operation is named so it can be audited
+	var denied *services.ComplianceDeniedError
+	if errors.As(err, &denied) {
+		return errorRules.Map(err).WithDetails(gin.H{"policy": denied})
+	}
+
+	// The operator message explains the outage to the client
+	var disabled *services.OperationDisabledError
+	if errors.As(err, &disabled) {
+		return apierror.New(http.StatusServiceUnavailable, apierror.CodeOperationDisabled, disabled.Error()).
+			WithDetails(gin.H{"kill_switch": disabled})
+	}
+	return errorRules.Map(err)
+}
+
+// NotFoundHandler answers requests that match no route
+func NotFoundHandler(c *gin.Context) {
+	abortWithError(c, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "route not found"))
+}
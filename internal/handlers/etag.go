@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxETagBody is the largest response ETagHandler holds back
+const maxETagBody = 1 << 20
+
+// ETagHandler tags successful GET responses with a strong ETag derived from
+// their body and answers 304 Not Modified when If-None-Match names it, so
+// clients can revalidate without downloading the response again. Held back
+// responses are sent with their Content-Length. Responses flushed early or
+// larger than maxETagBody, such as statements, are streamed untagged.
+func ETagHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		writer := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.streaming {
+			return
+		}
+		body := writer.body.Bytes()
+		if c.Writer.Status() == http.StatusOK && len(body) > 0 {
+			sum := sha256.Sum256(body)
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			c.Header("ETag", etag)
+			if etagMatches(c.GetHeader("If-None-Match"), etag) {
+				c.Status(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		if len(body) > 0 {
+			c.Header("Content-Length", strconv.Itoa(len(body)))
+			_, _ = c.Writer.Write(body)
+		} else if writer.wroteHeader {
+			c.Writer.WriteHeaderNow()
+		}
+	}
+}
+
+// etagMatches reports whether the If-None-Match header ifNoneMatch names
+// etag, comparing weakly as RFC 9110 requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter holds the response body back until it has been tagged, and
+// streams it once it is flushed or grows past maxETagBody
+type etagWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	streaming   bool
+	wroteHeader bool
+}
+
+func (w *etagWriter) Write(data []byte) (int, error) {
+	if !w.streaming && w.body.Len()+len(data) > maxETagBody {
+		w.stream()
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow holds the header back with the body
+func (w *etagWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.wroteHeader = true
+}
+
+func (w *etagWriter) Written() bool {
+	return w.streaming || w.wroteHeader || w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *etagWriter) Flush() {
+	w.stream()
+	w.ResponseWriter.Flush()
+}
+
+// stream sends what was held back and passes later writes through
+func (w *etagWriter) stream() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
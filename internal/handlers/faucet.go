@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/services"
+)
+
+type FaucetHandler struct {
+	service *services.FaucetService
+}
+
+func NewFaucetHandler(service *services.FaucetService) *FaucetHandler {
+	return &FaucetHandler{service: service}
+}
+
+// Credit credits sandbox test funds to the wallet. Requests are limited per
+// API key, or per token subject for bearer tokens.
+func (h *FaucetHandler) Credit(c *gin.Context) {
+	var request struct {
+		Amount decimal.Decimal `json:"amount" binding:"omitempty,gt=0,amount"`
+	}
+
+	// The body is optional; without one the default amount is credited
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	principal, _ := auth.PrincipalFrom(c.Request.Context())
+	key := "subject:" + principal.Subject
+	if principal.APIKeyID != "" {
+		key = "key:" + principal.APIKeyID
+	}
+
+	credit, err := h.service.Credit(c.Request.Context(), key, c.Param("userID"), request.Amount)
+	var limitErr *services.FaucetLimitError
+	if errors.As(err, &limitErr) {
+		c.Header("Retry-After", limitErr.RetryAfterSeconds())
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type FeeHandler struct {
+	service *services.FeeService
+}
+
+func NewFeeHandler(service *services.FeeService) *FeeHandler {
+	return &FeeHandler{service: service}
+}
+
+type feeRequest struct {
+	Operation  string          `json:"operation" binding:"required"`
+	Currency   string          `json:"currency" binding:"omitempty,currency"`
+	MinAmount  decimal.Decimal `json:"min_amount"`
+	FlatFee    decimal.Decimal `json:"flat_fee"`
+	PercentFee decimal.Decimal `json:"percent_fee"`
+}
+
+func (r feeRequest) fee() models.Fee {
+	return models.Fee{
+		Operation:  r.Operation,
+		Currency:   r.Currency,
+		MinAmount:  r.MinAmount,
+		FlatFee:    r.FlatFee,
+		PercentFee: r.PercentFee,
+	}
+}
+
+func (h *FeeHandler) ListFees(c *gin.Context) {
+	fees, err := h.service.ListFees(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fees": fees})
+}
+
+func (h *FeeHandler) CreateFee(c *gin.Context) {
+	var request feeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	fee, err := h.service.CreateFee(c.Request.Context(), request.fee())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, fee)
+}
+
+func (h *FeeHandler) UpdateFee(c *gin.Context) {
+	var request feeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	fee, err := h.service.UpdateFee(c.Request.Context(), c.Param("feeID"), request.fee())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fee)
+}
+
+func (h *FeeHandler) DeleteFee(c *gin.Context) {
+	if err := h.service.DeleteFee(c.Request.Context(), c.Param("feeID")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/fields"
+)
+
+// selectFields reads the fields query parameter, validated against the JSON
+// fields of model. It responds with 400 and returns false for unknown fields.
+func selectFields(c *gin.Context, model interface{}) (fields.Selection, bool) {
+	selection, err := fields.Parse(c.Query("fields"), model)
+	if err != nil {
+		abortWithError(c, apierror.BadRequest(err.Error()))
+		return nil, false
+	}
+	return selection, true
+}
+
+// sparse serializes value with only the selected fields. Callers respond
+// with the result; a value that cannot be serialized aborts with an
+// internal error.
+func sparse(c *gin.Context, selection fields.Selection, value interface{}) (interface{}, bool) {
+	result, err := selection.Apply(value)
+	if err != nil {
+		abortWithError(c, err)
+		return nil, false
+	}
+	return result, true
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"Crypto.com/internal/apierror"
+)
+
+// GraphQLHandler serves POST /graphql with schema. It must run after
+// AuthHandler and OperationHandler; resolvers check scopes and wallet
+// ownership themselves, as a request may read and move funds at once.
+//
+// Errors carry the code and details the REST API responds with in their
+// extensions, so clients handle both the same way:
+//
+//	{"message": "insufficient balance", "path": ["withdraw"], "extensions": {"code": "INSUFFICIENT_BALANCE"}}
+func GraphQLHandler(schema graphql.ExecutableSchema, logger *logrus.Logger) gin.HandlerFunc {
+	server := handler.New(schema)
+	server.AddTransport(transport.POST{})
+	server.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	server.Use(extension.Introspection{})
+
+	// Resolver errors are told apart from invalid requests, which gqlgen
+	// reports through the same presenter
+	server.AroundFields(func(ctx context.Context, next graphql.Resolver) (any, error) {
+		res, err := next(ctx)
+		if err != nil {
+			return res, resolverError{err}
+		}
+		return res, nil
+	})
+	server.SetRecoverFunc(func(ctx context.Context, recovered any) error {
+		logger.WithContext(ctx).WithField("panic", recovered).Error("GraphQLHandler - Resolver panicked")
+		return apierror.Internal()
+	})
+	server.SetErrorPresenter(func(ctx context.Context, err error) *gqlerror.Error {
+		presented := graphql.DefaultErrorPresenter(ctx, err)
+
+		var resolverErr resolverError
+		var apiErr *apierror.Error
+		switch {
+		case errors.As(err, &resolverErr):
+			apiErr = toAPIError(resolverErr.err)
+			if apiErr.Status >= http.StatusInternalServerError {
+				logger.WithContext(ctx).WithError(resolverErr.err).Error("GraphQLHandler - Resolve field failed")
+			}
+		case errors.As(err, &apiErr):
+		default:
+			// The request could not be parsed, validated or its arguments
+			// read; gqlgen's message says which
+			if presented.Extensions == nil {
+				presented.Extensions = map[string]any{}
+			}
+			if _, ok := presented.Extensions["code"]; !ok {
+				presented.Extensions["code"] = apierror.CodeInvalidRequest
+			}
+			return presented
+		}
+
+		presented.Message = apiErr.Message
+		presented.Extensions = map[string]any{"code": apiErr.Code}
+		if apiErr.Details != nil {
+			presented.Extensions["details"] = apiErr.Details
+		}
+		return presented
+	})
+
+	return func(c *gin.Context) {
+		// Masking policies rewrite REST responses field by field and do not
+		// know GraphQL responses
+		if isMasked(c) {
+			abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "GraphQL is not available to your role"))
+			return
+		}
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// resolverError marks an error returned by a resolver
+type resolverError struct {
+	err error
+}
+
+func (e resolverError) Error() string {
+	return e.err.Error()
+}
+
+func (e resolverError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/graph"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/services"
+	"Crypto.com/mocks"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func serveGraphQL(t *testing.T, repo *mocks.MockWalletRepository, principal auth.Principal, query string) graphQLResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	resolver := graph.NewResolver(services.NewWalletService(repo, redis.NewNoopCacheRepository(), logger), nil, false)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST("/graphql", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	}, GraphQLHandler(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}), logger))
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+	request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var response graphQLResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	owner := auth.Principal{Subject: "user1"}
+
+	t.Run("balance", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		repo.EXPECT().GetBalance(gomock.Any(), "user1").Return(decimal.NewFromInt(150), nil)
+
+		response := serveGraphQL(t, repo, owner, `{ balance(userId: "user1") { userId balance availableBalance } }`)
+		require.Empty(t, response.Errors)
+		assert.JSONEq(t, `{"userId": "user1", "balance": "150", "availableBalance": "150"}`, string(response.Data["balance"]))
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		id, txnType, amount, createdAt := "txn1", "deposit", decimal.NewFromInt(10), time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		repo.EXPECT().GetTransactionsBefore(gomock.Any(), "user1", nil, gomock.Any(), 3).
+			DoAndReturn(func(_ context.Context, _ string, _ *models.TransactionCursor, window models.HistoryWindow, _ int) ([]models.Transaction, error) {
+				require.NotNil(t, window.ReferenceID)
+				assert.Equal(t, "rent", *window.ReferenceID)
+				return []models.Transaction{{ID: &id, Type: &txnType, FromUserID: &id, Amount: &amount, CreatedAt: &createdAt, Metadata: map[string]string{"b": "2", "a": "1"}}}, nil
+			})
+
+		response := serveGraphQL(t, repo, owner, `{ transactions(userId: "user1", first: 2, filter: {referenceId: "rent"}) {
+			transactions { id amount metadata { key value } } hasMore nextCursor } }`)
+		require.Empty(t, response.Errors)
+		assert.JSONEq(t, `{
+			"transactions": [{"id": "txn1", "amount": "10", "metadata": [{"key": "a", "value": "1"}, {"key": "b", "value": "2"}]}],
+			"hasMore": false, "nextCursor": null
+		}`, string(response.Data["transactions"]))
+	})
+
+	t.Run("withdraw reports the error code of the REST API", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		repo.EXPECT().Withdraw(gomock.Any(), "user1", decimal.RequireFromString("25.5"), nil).Return(postgres.ErrInsufficientBalance)
+
+		response := serveGraphQL(t, repo, owner, `mutation { withdraw(userId: "user1", amount: "25.5") { balance } }`)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, apierror.CodeInsufficientBalance, response.Errors[0].Extensions["code"])
+	})
+
+	t.Run("balance mismatch carries the current balance", func(t *testing.T) {
+		expected := decimal.NewFromInt(100)
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		repo.EXPECT().Transfer(gomock.Any(), "user1", "user2", decimal.NewFromInt(5), &expected).Return(postgres.ErrBalanceMismatch)
+		repo.EXPECT().GetVersionedBalance(gomock.Any(), "user1").Return(decimal.NewFromInt(80), int64(7), nil)
+
+		response := serveGraphQL(t, repo, owner, `mutation { transfer(userId: "user1", receiverId: "user2", amount: 5, expectedBalance: "100") { status } }`)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, apierror.CodeBalanceMismatch, response.Errors[0].Extensions["code"])
+		assert.Equal(t, map[string]any{"balance": "80", "version": float64(7)}, response.Errors[0].Extensions["details"])
+	})
+
+	t.Run("other wallets are forbidden", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+
+		response := serveGraphQL(t, repo, owner, `mutation { deposit(userId: "user2", amount: "10") { balance } }`)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, apierror.CodeForbidden, response.Errors[0].Extensions["code"])
+	})
+
+	t.Run("read only API keys cannot move funds", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		readOnly := auth.Principal{Subject: "user1", APIKeyID: "key1", Scopes: []string{auth.ScopeRead}}
+
+		response := serveGraphQL(t, repo, readOnly, `mutation { deposit(userId: "user1", amount: "10") { balance } }`)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, apierror.CodeInsufficientScope, response.Errors[0].Extensions["code"])
+	})
+
+	t.Run("amounts the ledger cannot store are invalid", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+
+		response := serveGraphQL(t, repo, owner, `mutation { deposit(userId: "user1", amount: "0.000000001") { balance } }`)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, apierror.CodeInvalidRequest, response.Errors[0].Extensions["code"])
+	})
+}
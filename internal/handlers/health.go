@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+)
+
+// Dependency statuses reported by the health endpoints
+const (
+	DependencyOK          = "ok"
+	DependencyInMemory    = "in_memory"
+	DependencyDisabled    = "disabled"
+	DependencyUnavailable = "unavailable"
+)
+
+// Probe is a readiness check of one dependency. The service is ready only
+// when every required probe passes within its timeout.
+type Probe struct {
+	Name     string
+	Required bool
+	Timeout  time.Duration
+	Check    func(ctx context.Context) error
+}
+
+type probeResult struct {
+	Status    string `json:"status"`
+	Required  bool   `json:"required"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type HealthHandler struct {
+	cacheStatus string
+	probes      []Probe
+}
+
+func NewHealthHandler(cacheStatus string, probes ...Probe) *HealthHandler {
+	return &HealthHandler{cacheStatus: cacheStatus, probes: probes}
+}
+
+// Livez reports that the process is up and serving HTTP. It checks no
+// dependency so a database outage does not get the pod restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Healthz reports whether the service runs with all its optional
+// dependencies. A degraded service still serves every request, without the
+// balance cache.
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	status := "ok"
+	if h.cacheStatus == DependencyDisabled || h.cacheStatus == DependencyUnavailable {
+		status = "degraded"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": status,
+		"cache":  h.cacheStatus,
+	})
+}
+
+// Readyz probes every dependency concurrently and responds with 503 when a
+// required one is unreachable, so the instance is taken out of rotation
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	results := make(map[string]probeResult, len(h.probes)+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, probe := range h.probes {
+		wg.Add(1)
+		go func(probe Probe) {
+			defer wg.Done()
+			result := runProbe(c.Request.Context(), probe)
+			mu.Lock()
+			results[probe.Name] = result
+			mu.Unlock()
+		}(probe)
+	}
+	wg.Wait()
+
+	// A cache that is not probed is reported with its startup status
+	if _, ok := results["cache"]; !ok {
+		results["cache"] = probeResult{Status: h.cacheStatus}
+	}
+
+	ready := true
+	for _, result := range results {
+		if result.Required && result.Status != DependencyOK {
+			ready = false
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": results,
+	})
+}
+
+func runProbe(ctx context.Context, probe Probe) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe.Check(ctx)
+	result := probeResult{
+		Status:    DependencyOK,
+		Required:  probe.Required,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = DependencyUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// UnsupportedHandler answers requests for features the configured storage
+// driver does not provide
+func UnsupportedHandler(driver string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		abortWithError(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "not supported with the "+driver+" storage driver"))
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/services"
+)
+
+type HoldHandler struct {
+	service *services.HoldService
+}
+
+func NewHoldHandler(service *services.HoldService) *HoldHandler {
+	return &HoldHandler{service: service}
+}
+
+func (h *HoldHandler) CreatePendingTransfer(c *gin.Context) {
+	senderID := c.Param("userID")
+
+	var request struct {
+		ReceiverID string          `json:"receiver_id" binding:"required,user_id"`
+		Amount     decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	hold, err := h.service.CreatePendingTransfer(c.Request.Context(), senderID, request.ReceiverID, request.Amount)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) GetPendingTransfer(c *gin.Context) {
+	hold, err := h.service.GetPendingTransfer(c.Request.Context(), c.Param("userID"), c.Param("transferID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (h *HoldHandler) Capture(c *gin.Context) {
+	hold, err := h.service.Capture(c.Request.Context(), c.Param("userID"), c.Param("transferID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (h *HoldHandler) Cancel(c *gin.Context) {
+	hold, err := h.service.Cancel(c.Request.Context(), c.Param("userID"), c.Param("transferID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
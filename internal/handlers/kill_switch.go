@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type KillSwitchHandler struct {
+	service *services.KillSwitchService
+}
+
+func NewKillSwitchHandler(service *services.KillSwitchService) *KillSwitchHandler {
+	return &KillSwitchHandler{service: service}
+}
+
+func (h *KillSwitchHandler) ListKillSwitches(c *gin.Context) {
+	killSwitches, err := h.service.List(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"kill_switches": killSwitches})
+}
+
+// DisableOperation engages the kill switch of an operation. The message is
+// returned to every caller of the operation until it is enabled again.
+func (h *KillSwitchHandler) DisableOperation(c *gin.Context) {
+	var request struct {
+		Message string `json:"message" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	killSwitch, err := h.service.Disable(c.Request.Context(), c.Param("operation"), request.Message)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, killSwitch)
+}
+
+func (h *KillSwitchHandler) EnableOperation(c *gin.Context) {
+	if err := h.service.Enable(c.Request.Context(), c.Param("operation")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
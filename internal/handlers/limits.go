@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type LimitsHandler struct {
+	service *services.LimitsService
+}
+
+func NewLimitsHandler(service *services.LimitsService) *LimitsHandler {
+	return &LimitsHandler{service: service}
+}
+
+func (h *LimitsHandler) ListDefaultLimits(c *gin.Context) {
+	limits, err := h.service.Defaults(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"limits": limits})
+}
+
+func (h *LimitsHandler) ListWalletLimits(c *gin.Context) {
+	limits, err := h.service.Effective(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": c.Param("userID"), "limits": limits})
+}
+
+// WalletLimitStatus returns the limits of the wallet with how much of them
+// the user has used and has left
+func (h *LimitsHandler) WalletLimitStatus(c *gin.Context) {
+	limits, err := h.service.Status(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": c.Param("userID"), "limits": limits})
+}
+
+// RequestIncrease asks for a limit of the wallet to be raised. An approved
+// request answers 201; one waiting for an admin answers 202.
+func (h *LimitsHandler) RequestIncrease(c *gin.Context) {
+	var request struct {
+		Operation string          `json:"operation" binding:"required"`
+		Kind      string          `json:"kind" binding:"required"`
+		Value     decimal.Decimal `json:"value" binding:"required"`
+		Reason    string          `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	increase, err := h.service.RequestIncrease(c.Request.Context(), c.Param("userID"), request.Operation, request.Kind, request.Value, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	status := http.StatusAccepted
+	if increase.Status == models.LimitRequestApproved {
+		status = http.StatusCreated
+	}
+	c.JSON(status, increase)
+}
+
+// ListIncreaseRequests returns the latest increase requests of the wallet on
+// /wallets/:userID routes, or of every wallet, filtered by the status query
+// parameter
+func (h *LimitsHandler) ListIncreaseRequests(c *gin.Context) {
+	requests, err := h.service.IncreaseRequests(c.Request.Context(), c.Param("userID"), c.Query("status"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+func (h *LimitsHandler) ApproveIncrease(c *gin.Context) {
+	h.decideIncrease(c, h.service.ApproveIncrease)
+}
+
+func (h *LimitsHandler) RejectIncrease(c *gin.Context) {
+	h.decideIncrease(c, h.service.RejectIncrease)
+}
+
+func (h *LimitsHandler) decideIncrease(c *gin.Context, decide func(ctx context.Context, requestID, reason string) (*models.LimitIncreaseRequest, error)) {
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	increase, err := decide(c.Request.Context(), c.Param("requestID"), request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, increase)
+}
+
+// PutLimit sets a default limit, or the limit of the wallet on
+// /wallets/:userID routes
+func (h *LimitsHandler) PutLimit(c *gin.Context) {
+	var request struct {
+		Value  decimal.Decimal `json:"value" binding:"required"`
+		Reason string          `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	limit, err := h.service.Set(c.Request.Context(), c.Param("userID"), c.Param("operation"), c.Param("kind"), request.Value, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// DeleteLimit removes a default limit, or the limit of the wallet on
+// /wallets/:userID routes so the default applies again
+func (h *LimitsHandler) DeleteLimit(c *gin.Context) {
+	if err := h.service.Clear(c.Request.Context(), c.Param("userID"), c.Param("operation"), c.Param("kind")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
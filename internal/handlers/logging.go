@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,7 +19,7 @@ func LoggingHandler(logger *logrus.Logger) gin.HandlerFunc {
 		end := time.Now()
 		latency := end.Sub(start)
 
-		l := logger.WithFields(logrus.Fields{
+		l := logger.WithContext(c.Request.Context()).WithFields(logrus.Fields{
 			"status":    c.Writer.Status(),
 			"method":    c.Request.Method,
 			"path":      path,
@@ -28,10 +29,14 @@ func LoggingHandler(logger *logrus.Logger) gin.HandlerFunc {
 			"latency":   latency,
 		})
 
-		if len(c.Errors) > 0 {
-			l.Error(c.Errors.String())
-		} else {
+		switch {
+		case len(c.Errors) == 0:
 			l.Info("Request handled")
+		case c.Writer.Status() >= http.StatusInternalServerError:
+			l.Error(c.Errors.String())
+		default:
+			// Client errors are expected and answered with their code
+			l.Warn(c.Errors.String())
 		}
 	}
 }
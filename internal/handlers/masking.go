@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/masking"
+)
+
+// maskedKey marks requests whose responses are masked
+const maskedKey = "masked"
+
+// MaskingHandler rewrites the JSON responses of callers whose role has a
+// masking policy, so every role is served by the same endpoints. Responses
+// that cannot be masked are withheld and replaced by an internal error.
+// Endpoints responding with other content check isMasked and refuse instead.
+func MaskingHandler(policies masking.Policies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFrom(c.Request.Context())
+		policy, ok := policies.For(principal.Roles)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Set(maskedKey, true)
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+		if strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "application/json") {
+			masked, err := policy.Apply(body, c.Param("userID"))
+			if err != nil {
+				// ErrorHandler responds once nothing has been written
+				_ = c.Error(err)
+				return
+			}
+			body = masked
+		}
+		_, _ = c.Writer.Write(body)
+	}
+}
+
+// isMasked reports whether the caller's responses are masked
+func isMasked(c *gin.Context) bool {
+	return c.GetBool(maskedKey)
+}
+
+// bufferedWriter holds the response body back until it has been masked
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
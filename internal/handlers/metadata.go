@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type MetadataHandler struct {
+	service *services.MetadataService
+}
+
+func NewMetadataHandler(service *services.MetadataService) *MetadataHandler {
+	return &MetadataHandler{service: service}
+}
+
+// GetMetadata returns the metadata of the wallet with its version
+func (h *MetadataHandler) GetMetadata(c *gin.Context) {
+	metadata, err := h.service.Get(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// PutMetadata replaces the metadata of the wallet, at the given version when
+// one is sent
+func (h *MetadataHandler) PutMetadata(c *gin.Context) {
+	var request struct {
+		Metadata map[string]string `json:"metadata" binding:"required"`
+		Version  *int64            `json:"version"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	metadata, err := h.service.Set(c.Request.Context(), c.Param("userID"), request.Metadata, request.Version)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+)
+
+// methodOrder is the order methods are listed in Allow headers
+var methodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// CORS headers browsers may send and read
+var (
+	corsAllowedHeaders = strings.Join([]string{"Authorization", "Content-Type", "If-None-Match", IdempotencyKeyHeader, APIKeyHeader, RequestIDHeader, "traceparent", "tracestate"}, ", ")
+	corsExposedHeaders = strings.Join([]string{"Content-Disposition", "ETag", "Retry-After", RequestIDHeader}, ", ")
+)
+
+// corsMaxAge is how long browsers may cache a preflight response
+const corsMaxAge = 10 * time.Minute
+
+// HeadHandler serves HEAD requests as GET requests of the same URL, so every
+// read endpoint answers HEAD with the status and headers of a GET, such as
+// its Content-Length and ETag. The server drops the body of HEAD responses.
+func HeadHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			r = r.Clone(r.Context())
+			r.Method = http.MethodGet
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// OptionsHandler answers OPTIONS requests with the methods of their route in
+// the Allow header, and as CORS preflights when they come from one of
+// allowedOrigins, before authentication as browsers send preflights without
+// credentials. Other requests from allowed origins get the CORS headers
+// that let browsers read their responses. An origin of * allows any. The
+// router must handle methods not allowed, which lists the methods of
+// unmatched requests.
+func OptionsHandler(router *gin.Engine, allowedOrigins []string) gin.HandlerFunc {
+	var once sync.Once
+	var routes map[string][]string
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		cors := origin != "" && (slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin))
+		if len(allowedOrigins) > 0 {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if cors {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+		if c.Request.Method != http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		// Routes only match OPTIONS when they accept any method; otherwise
+		// the router lists the methods of the path
+		var methods []string
+		if route := c.FullPath(); route != "" {
+			once.Do(func() {
+				routes = map[string][]string{}
+				for _, info := range router.Routes() {
+					routes[info.Path] = append(routes[info.Path], info.Method)
+				}
+			})
+			methods = routes[route]
+		} else if allow := c.Writer.Header().Get("Allow"); allow != "" {
+			methods = strings.Split(allow, ", ")
+		}
+		if len(methods) == 0 {
+			c.Next()
+			return
+		}
+
+		allow := allowedMethods(methods)
+		c.Header("Allow", allow)
+		if cors && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", allow)
+			c.Header("Access-Control-Allow-Headers", corsAllowedHeaders)
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// MethodNotAllowedHandler answers requests whose route does not accept their
+// method, listing the methods it does in the Allow header
+func MethodNotAllowedHandler(c *gin.Context) {
+	c.Header("Allow", allowedMethods(strings.Split(c.Writer.Header().Get("Allow"), ", ")))
+	abortWithError(c, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "method not allowed"))
+}
+
+// allowedMethods lists methods in the order of methodOrder, with HEAD when
+// GET is allowed and OPTIONS, which every route answers
+func allowedMethods(methods []string) string {
+	allowed := make([]string, 0, len(methodOrder))
+	for _, method := range methodOrder {
+		switch {
+		case method == http.MethodOptions,
+			method == http.MethodHead && slices.Contains(methods, http.MethodGet),
+			slices.Contains(methods, method):
+			allowed = append(allowed, method)
+		}
+	}
+	return strings.Join(allowed, ", ")
+}
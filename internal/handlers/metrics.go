@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/metrics"
+)
+
+// MetricsHandler records the latency of every request under its route
+// pattern. Requests that match no route share the "unmatched" label.
+func MetricsHandler(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetPreference returns how the wallet owner is notified of money movements
+func (h *NotificationHandler) GetPreference(c *gin.Context) {
+	preference, err := h.service.Get(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// PutPreference switches the wallet owner between immediate notifications
+// and digests
+func (h *NotificationHandler) PutPreference(c *gin.Context) {
+	var request struct {
+		Mode          string `json:"mode" binding:"required"`
+		WindowSeconds *int64 `json:"window_seconds"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	var window *time.Duration
+	if request.WindowSeconds != nil {
+		w := time.Duration(*request.WindowSeconds) * time.Second
+		window = &w
+	}
+
+	preference, err := h.service.Set(c.Request.Context(), c.Param("userID"), request.Mode, window)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
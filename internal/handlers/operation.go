@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/operation"
+)
+
+// OperationHandler starts the operation of a request arriving through
+// channel, with the authenticated principal as actor. It must run after
+// AuthHandler. The request ID set by RequestIDHandler is kept. The client
+// address is stored alongside for the audit log.
+func OperationHandler(channel string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, _ := operation.From(c.Request.Context())
+		op.Channel = channel
+		if principal, ok := auth.PrincipalFrom(c.Request.Context()); ok {
+			op.Actor = principal.Subject
+		}
+
+		ctx := operation.WithClientIP(operation.With(c.Request.Context(), op), c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
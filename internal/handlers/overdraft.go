@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/services"
+)
+
+type OverdraftHandler struct {
+	service *services.OverdraftService
+}
+
+func NewOverdraftHandler(service *services.OverdraftService) *OverdraftHandler {
+	return &OverdraftHandler{service: service}
+}
+
+// GetOverdraft returns the credit line of a wallet and how much of it is used
+func (h *OverdraftHandler) GetOverdraft(c *gin.Context) {
+	overdraft, err := h.service.Get(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overdraft)
+}
+
+// SetOverdraft sets the credit line of a wallet
+func (h *OverdraftHandler) SetOverdraft(c *gin.Context) {
+	var request struct {
+		Limit *decimal.Decimal `json:"limit" binding:"required,amount"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	overdraft, err := h.service.SetLimit(c.Request.Context(), c.Param("userID"), *request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overdraft)
+}
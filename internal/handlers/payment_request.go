@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type PaymentRequestHandler struct {
+	service *services.PaymentRequestService
+}
+
+func NewPaymentRequestHandler(service *services.PaymentRequestService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{service: service}
+}
+
+// CreatePaymentRequest requests a payment from another wallet on behalf of
+// the wallet in the path
+func (h *PaymentRequestHandler) CreatePaymentRequest(c *gin.Context) {
+	var request struct {
+		PayerID          string          `json:"payer_id" binding:"required,user_id"`
+		Amount           decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		Note             string          `json:"note"`
+		ExpiresInSeconds *int64          `json:"expires_in_seconds"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	input := services.PaymentRequestInput{
+		PayerID: request.PayerID,
+		Amount:  request.Amount,
+		Note:    request.Note,
+	}
+	if request.ExpiresInSeconds != nil {
+		expiresIn := time.Duration(*request.ExpiresInSeconds) * time.Second
+		input.ExpiresIn = &expiresIn
+	}
+
+	paymentRequest, err := h.service.Create(c.Request.Context(), c.Param("userID"), input)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+paymentRequest.ID)
+	c.JSON(http.StatusCreated, paymentRequest)
+}
+
+// ListPaymentRequests returns the requests the wallet received or sent,
+// newest first
+func (h *PaymentRequestHandler) ListPaymentRequests(c *gin.Context) {
+	var request struct {
+		Side   string `form:"side"`
+		Status string `form:"status"`
+		Limit  int    `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	requests, err := h.service.List(c.Request.Context(), c.Param("userID"), request.Side, request.Status, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+func (h *PaymentRequestHandler) GetPaymentRequest(c *gin.Context) {
+	paymentRequest, err := h.service.Get(c.Request.Context(), c.Param("userID"), c.Param("requestID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentRequest)
+}
+
+// AcceptPaymentRequest pays a request the wallet received
+func (h *PaymentRequestHandler) AcceptPaymentRequest(c *gin.Context) {
+	h.decide(c, h.service.Accept)
+}
+
+// DeclinePaymentRequest declines a request the wallet received
+func (h *PaymentRequestHandler) DeclinePaymentRequest(c *gin.Context) {
+	h.decide(c, h.service.Decline)
+}
+
+func (h *PaymentRequestHandler) decide(c *gin.Context, apply func(ctx context.Context, payerID, requestID string) (*models.PaymentRequest, error)) {
+	paymentRequest, err := apply(c.Request.Context(), c.Param("userID"), c.Param("requestID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentRequest)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/payouts"
+)
+
+type PayoutHandler struct {
+	router *payouts.Router
+}
+
+func NewPayoutHandler(router *payouts.Router) *PayoutHandler {
+	return &PayoutHandler{router: router}
+}
+
+// ListProviders reports the recent success rate and latency of each payout
+// provider, which new withdrawals are routed by
+func (h *PayoutHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.router.Health()})
+}
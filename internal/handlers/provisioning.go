@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/services"
+)
+
+type ProvisioningHandler struct {
+	service *services.ProvisioningService
+}
+
+func NewProvisioningHandler(service *services.ProvisioningService) *ProvisioningHandler {
+	return &ProvisioningHandler{service: service}
+}
+
+// provisionRequest holds the optional settings of a new wallet
+type provisionRequest struct {
+	Currency string            `json:"currency" binding:"omitempty,currency"`
+	Metadata map[string]string `json:"metadata"`
+	Status   string            `json:"status" binding:"omitempty,oneof=active frozen"`
+}
+
+func (r provisionRequest) options() services.ProvisionOptions {
+	return services.ProvisionOptions{Currency: r.Currency, Metadata: r.Metadata, Status: r.Status}
+}
+
+// CreateWallet serves POST /wallets, creating the empty wallet of the user
+// in the body
+func (h *ProvisioningHandler) CreateWallet(c *gin.Context) {
+	var request struct {
+		UserID string `json:"user_id" binding:"required,user_id"`
+		provisionRequest
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	principal, _ := auth.PrincipalFrom(c.Request.Context())
+	if !principal.CanAccess(request.UserID) {
+		abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "access to this wallet is not allowed"))
+		return
+	}
+
+	h.provision(c, request.UserID, request.provisionRequest)
+}
+
+// Provision serves POST /wallets/:userID, creating the empty wallet of the
+// user, in the default currency unless the body picks one
+func (h *ProvisioningHandler) Provision(c *gin.Context) {
+	var request provisionRequest
+
+	// The body is optional; without one the wallet is active and in the
+	// default currency
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	h.provision(c, c.Param("userID"), request)
+}
+
+func (h *ProvisioningHandler) provision(c *gin.Context, userID string, request provisionRequest) {
+	wallet, err := h.service.Provision(c.Request.Context(), userID, request.options())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, wallet)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/rates"
+)
+
+type RatesHandler struct {
+	provider rates.FXRateProvider
+}
+
+func NewRatesHandler(provider rates.FXRateProvider) *RatesHandler {
+	return &RatesHandler{provider: provider}
+}
+
+// GetRates returns the rate table transfers are converted at. With from and
+// to query parameters it returns the single rate between the two currencies.
+func (h *RatesHandler) GetRates(c *gin.Context) {
+	table, err := h.provider.Rates(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	from := strings.ToUpper(c.Query("from"))
+	to := strings.ToUpper(c.Query("to"))
+	if from == "" && to == "" {
+		c.JSON(http.StatusOK, table)
+		return
+	}
+	if from == "" || to == "" {
+		abortWithError(c, apierror.BadRequest("from and to must be set together"))
+		return
+	}
+
+	rate, err := table.Rate(from, to)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "rate": rate, "as_of": table.AsOf})
+}
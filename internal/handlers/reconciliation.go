@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type ReconciliationHandler struct {
+	service *services.ReconciliationService
+}
+
+func NewReconciliationHandler(service *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: service}
+}
+
+func (h *ReconciliationHandler) ListRuns(c *gin.Context) {
+	var request struct {
+		Limit int `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	runs, err := h.service.List(c.Request.Context(), request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// StartRun reconciles every wallet now. A run already in progress is
+// returned instead of starting another.
+func (h *ReconciliationHandler) StartRun(c *gin.Context) {
+	run, err := h.service.Start(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+func (h *ReconciliationHandler) GetRun(c *gin.Context) {
+	run, err := h.service.Get(c.Request.Context(), c.Param("runID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListReports returns the wallets whose balance differed from their ledger
+// in the run
+func (h *ReconciliationHandler) ListReports(c *gin.Context) {
+	var request struct {
+		Limit int `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	reports, err := h.service.Reports(c.Request.Context(), c.Param("runID"), request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/operation"
+)
+
+// RequestIDHeader carries the ID correlating a request with its logs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the client supplied request IDs accepted
+const maxRequestIDLength = 128
+
+// RequestIDHandler gives every request an ID: the X-Request-ID header sent by
+// the client or a proxy, or a new random one when it is missing or not a
+// plain token. The ID is returned in the response header and stored in the
+// operation of the request, so every entry logged with its context carries
+// it. It must run before the other middlewares.
+func RequestIDHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(operation.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts IDs of letters, digits and -_.: so that client input
+// cannot forge log lines or headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
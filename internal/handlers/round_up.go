@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/services"
+)
+
+type RoundUpHandler struct {
+	service *services.RoundUpService
+}
+
+func NewRoundUpHandler(service *services.RoundUpService) *RoundUpHandler {
+	return &RoundUpHandler{service: service}
+}
+
+// PutRule sets the round-up savings rule of the wallet
+func (h *RoundUpHandler) PutRule(c *gin.Context) {
+	var request struct {
+		Unit decimal.Decimal `json:"unit" binding:"required,gt=0,amount"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	rule, err := h.service.PutRule(c.Request.Context(), c.Param("userID"), request.Unit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetRule returns the round-up rule of the wallet with its savings balance
+func (h *RoundUpHandler) GetRule(c *gin.Context) {
+	rule, err := h.service.GetRule(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *RoundUpHandler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Request.Context(), c.Param("userID")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
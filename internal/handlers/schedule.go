@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type ScheduleHandler struct {
+	service *services.ScheduleService
+}
+
+func NewScheduleHandler(service *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+// CreateSchedule schedules recurring transfers from the wallet on a cron
+// expression or an interval
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var request struct {
+		ReceiverID      string          `json:"receiver_id" binding:"required,user_id"`
+		Amount          decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		Cron            *string         `json:"cron"`
+		IntervalSeconds *int64          `json:"interval_seconds"`
+		StartAt         *time.Time      `json:"start_at"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	schedule, err := h.service.Create(c.Request.Context(), c.Param("userID"), services.TransferScheduleRequest{
+		ToUserID:        request.ReceiverID,
+		Amount:          request.Amount,
+		Cron:            request.Cron,
+		IntervalSeconds: request.IntervalSeconds,
+		StartAt:         request.StartAt,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+schedule.ID)
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.service.List(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	schedule, err := h.service.Get(c.Request.Context(), c.Param("userID"), c.Param("scheduleID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ListRuns returns the latest runs of a schedule with their outcome
+func (h *ScheduleHandler) ListRuns(c *gin.Context) {
+	runs, err := h.service.Runs(c.Request.Context(), c.Param("userID"), c.Param("scheduleID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (h *ScheduleHandler) PauseSchedule(c *gin.Context) {
+	h.transition(c, h.service.Pause)
+}
+
+func (h *ScheduleHandler) ResumeSchedule(c *gin.Context) {
+	h.transition(c, h.service.Resume)
+}
+
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	h.transition(c, h.service.Cancel)
+}
+
+func (h *ScheduleHandler) transition(c *gin.Context, apply func(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error)) {
+	schedule, err := apply(c.Request.Context(), c.Param("userID"), c.Param("scheduleID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
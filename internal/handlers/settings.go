@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type SettingsHandler struct {
+	service *services.SettingsService
+}
+
+func NewSettingsHandler(service *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{service: service}
+}
+
+type settingScopeRequest struct {
+	Scope   string `json:"scope" binding:"required"`
+	ScopeID string `json:"scope_id"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+func (h *SettingsHandler) ListSettings(c *gin.Context) {
+	settings, err := h.service.List(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+func (h *SettingsHandler) PutSetting(c *gin.Context) {
+	var request struct {
+		settingScopeRequest
+		Value string `json:"value" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	setting, err := h.service.Set(c.Request.Context(), c.Param("key"), request.Scope, request.ScopeID, request.Value, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+func (h *SettingsHandler) DeleteSetting(c *gin.Context) {
+	var request settingScopeRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	if err := h.service.Clear(c.Request.Context(), c.Param("key"), request.Scope, request.ScopeID, request.Reason); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SettingsHandler) EffectiveSettings(c *gin.Context) {
+	target := models.SettingTarget{
+		TenantID: c.Query("tenant_id"),
+		Currency: c.Query("currency"),
+		UserID:   c.Query("user_id"),
+	}
+
+	settings, err := h.service.Effective(c.Request.Context(), target)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"target": target, "settings": settings})
+}
+
+func (h *SettingsHandler) ListSettingChanges(c *gin.Context) {
+	var request struct {
+		Key   string `form:"key"`
+		Limit int    `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	changes, err := h.service.Changes(c.Request.Context(), request.Key, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changes": changes})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+	"Crypto.com/internal/statements"
+)
+
+type StatementHandler struct {
+	service *services.StatementService
+}
+
+func NewStatementHandler(service *services.StatementService) *StatementHandler {
+	return &StatementHandler{service: service}
+}
+
+// GetStatement streams the account statement of a period as a CSV or PDF
+// attachment. Once the statement has started, errors can no longer change
+// the response; the statement then ends without its closing balance.
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	userID := c.Param("userID")
+
+	var request struct {
+		From   time.Time `form:"from" binding:"required"`
+		To     time.Time `form:"to" binding:"required"`
+		Format string    `form:"format" binding:"omitempty,oneof=csv pdf"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+	if request.Format == "" {
+		request.Format = models.StatementFormatCSV
+	}
+
+	// Statements list every counterparty and balance, which masked roles
+	// may not see
+	if isMasked(c) {
+		abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "statements are not available to your role"))
+		return
+	}
+
+	statement, err := h.service.Prepare(c.Request.Context(), userID, request.From, request.To)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	renderer, err := statements.New(request.Format, c.Writer)
+	if err != nil {
+		abortWithError(c, apierror.BadRequest(err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%s-%s.%s", userID,
+		statement.From.UTC().Format("20060102"), statement.To.UTC().Format("20060102"), request.Format)
+	c.Header("Content-Type", statements.ContentType(request.Format))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	if err := h.service.Write(c.Request.Context(), statement, renderer); err != nil {
+		_ = c.Error(err)
+	}
+}
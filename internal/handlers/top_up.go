@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+type TopUpHandler struct {
+	service *services.TopUpService
+}
+
+func NewTopUpHandler(service *services.TopUpService) *TopUpHandler {
+	return &TopUpHandler{service: service}
+}
+
+// PutRule sets the automatic top-up rule of the wallet
+func (h *TopUpHandler) PutRule(c *gin.Context) {
+	var request struct {
+		Threshold     decimal.Decimal `json:"threshold" binding:"amount"`
+		Amount        decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		FundingSource string          `json:"funding_source" binding:"required,max=255"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	rule, err := h.service.PutRule(c.Request.Context(), c.Param("userID"), request.Threshold, request.Amount, request.FundingSource)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *TopUpHandler) GetRule(c *gin.Context) {
+	rule, err := h.service.GetRule(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *TopUpHandler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Request.Context(), c.Param("userID")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRuns returns the latest top-ups of the wallet with their outcome
+func (h *TopUpHandler) ListRuns(c *gin.Context) {
+	runs, err := h.service.Runs(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (h *TopUpHandler) PauseRule(c *gin.Context) {
+	h.transition(c, h.service.Pause)
+}
+
+func (h *TopUpHandler) ResumeRule(c *gin.Context) {
+	h.transition(c, h.service.Resume)
+}
+
+func (h *TopUpHandler) transition(c *gin.Context, apply func(ctx context.Context, userID string) (*models.TopUpRule, error)) {
+	rule, err := apply(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
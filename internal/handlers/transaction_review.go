@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type TransactionReviewHandler struct {
+	service *services.TransactionReviewService
+}
+
+func NewTransactionReviewHandler(service *services.TransactionReviewService) *TransactionReviewHandler {
+	return &TransactionReviewHandler{service: service}
+}
+
+// GetReview returns the flags and annotations of a transaction
+func (h *TransactionReviewHandler) GetReview(c *gin.Context) {
+	review, err := h.service.Get(c.Request.Context(), c.Param("transactionID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// SetFlag sets a review flag on a transaction
+func (h *TransactionReviewHandler) SetFlag(c *gin.Context) {
+	review, err := h.service.Flag(c.Request.Context(), c.Param("transactionID"), c.Param("flag"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// RemoveFlag removes a review flag from a transaction
+func (h *TransactionReviewHandler) RemoveFlag(c *gin.Context) {
+	review, err := h.service.Unflag(c.Request.Context(), c.Param("transactionID"), c.Param("flag"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// Annotate attaches a note to a transaction
+func (h *TransactionReviewHandler) Annotate(c *gin.Context) {
+	var request struct {
+		Note string `json:"note" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	annotation, err := h.service.Annotate(c.Request.Context(), c.Param("transactionID"), request.Note)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type TransferConfirmationHandler struct {
+	service *services.TransferConfirmationService
+}
+
+func NewTransferConfirmationHandler(service *services.TransferConfirmationService) *TransferConfirmationHandler {
+	return &TransferConfirmationHandler{service: service}
+}
+
+// GetTransferConfirmation returns a transfer waiting for the confirmation of
+// the wallet in the path
+func (h *TransferConfirmationHandler) GetTransferConfirmation(c *gin.Context) {
+	confirmation, err := h.service.Get(c.Request.Context(), c.Param("userID"), c.Param("confirmationID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, confirmation)
+}
+
+// ConfirmTransfer makes a held transfer with the one-time password sent to
+// its sender
+func (h *TransferConfirmationHandler) ConfirmTransfer(c *gin.Context) {
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	confirmation, err := h.service.Confirm(c.Request.Context(), c.Param("userID"), c.Param("confirmationID"), request.Code)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, confirmation)
+}
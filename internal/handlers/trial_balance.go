@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type TrialBalanceHandler struct {
+	service *services.TrialBalanceService
+}
+
+func NewTrialBalanceHandler(service *services.TrialBalanceService) *TrialBalanceHandler {
+	return &TrialBalanceHandler{service: service}
+}
+
+// ListTrialBalances returns the latest days closed, newest first
+func (h *TrialBalanceHandler) ListTrialBalances(c *gin.Context) {
+	var request struct {
+		Limit int `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	trialBalances, err := h.service.List(c.Request.Context(), request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trial_balances": trialBalances})
+}
+
+// GetTrialBalance returns the trial balance of a day with its figures
+func (h *TrialBalanceHandler) GetTrialBalance(c *gin.Context) {
+	trialBalance, err := h.service.Get(c.Request.Context(), c.Param("day"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trialBalance)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+type UserHandler struct {
+	service *services.UserService
+}
+
+func NewUserHandler(service *services.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// CreateUser registers a customer with a new user ID
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var request struct {
+		ExternalID string `json:"external_id"`
+	}
+
+	// The body is optional; without one the user has no external ID
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), request.ExternalID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetUser returns a registered user
+func (h *UserHandler) GetUser(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// FindUser looks a user up by the external ID of the integrator
+func (h *UserHandler) FindUser(c *gin.Context) {
+	var request struct {
+		ExternalID string `form:"external_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	user, err := h.service.FindUser(c.Request.Context(), request.ExternalID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RequireUserID rejects requests on a :userID that is not a registered
+// user ID before they reach the database. Deployments whose wallets predate
+// registered users turn the check off.
+func RequireUserID(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enabled && !services.ValidUserID(c.Param("userID")) {
+			abortWithError(c, postgres.ErrInvalidUserID)
+			return
+		}
+		c.Next()
+	}
+}
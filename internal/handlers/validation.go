@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/services"
+)
+
+func init() {
+	// Let numeric binding rules such as gt=0 apply to decimal amounts, the
+	// amount rule reject amounts the ledger cannot store, the currency rule
+	// codes the services would refuse and the user_id rule malformed user IDs.
+	// Fields are reported under the names clients send.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(decimalValue, decimal.Decimal{})
+		_ = v.RegisterValidation("amount", validAmount)
+		_ = v.RegisterValidation("currency", validCurrency)
+		_ = v.RegisterValidation("user_id", validUserID)
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+// strictUserIDs is set by ValidateUserIDs
+var strictUserIDs atomic.Bool
+
+// ValidateUserIDs makes the user_id rule require user IDs in the format
+// users are registered with, as RequireUserID does for the user ID in the
+// path. Otherwise any user ID passes, as wallets with legacy user IDs and
+// SQLite wallets keep arbitrary ones.
+func ValidateUserIDs(enabled bool) {
+	strictUserIDs.Store(enabled)
+}
+
+// FieldError says why one field of a request was rejected. Rule is the
+// binding rule that failed, such as required or amount.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// bindingError turns an error binding a request body or query string into
+// an INVALID_REQUEST whose details list the rejected fields:
+//
+//	{"fields": [{"field": "amount", "rule": "gt", "message": "amount must be greater than 0"}]}
+//
+// Errors not tied to a field, such as a body that is not JSON, have no
+// details.
+func bindingError(err error) *apierror.Error {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		messages := make([]string, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := fieldPath(fe)
+			message := field + " " + ruleMessage(fe)
+			fields = append(fields, FieldError{Field: field, Rule: fe.Tag(), Message: message})
+			messages = append(messages, message)
+		}
+		return apierror.BadRequest(strings.Join(messages, "; ")).WithDetails(map[string]interface{}{"fields": fields})
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		message := typeErr.Field + " must be " + jsonType(typeErr.Type)
+		return apierror.BadRequest(message).WithDetails(map[string]interface{}{
+			"fields": []FieldError{{Field: typeErr.Field, Rule: "type", Message: message}},
+		})
+	case errors.Is(err, io.EOF):
+		return apierror.BadRequest("request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return apierror.BadRequest("request body is not valid JSON")
+	}
+	return apierror.BadRequest(err.Error())
+}
+
+// fieldName names a struct field as clients send it: its JSON key, or its
+// query parameter for query strings
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath is the path of a rejected field from the root of the request,
+// such as items[2].amount
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// ruleMessage describes the rule a field failed, to follow the field name
+func ruleMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	sized := fe.Kind() == reflect.String || fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+	unit := "characters"
+	if fe.Kind() != reflect.String {
+		unit = "items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "amount":
+		return fmt.Sprintf("must have at most %d integer digits and %d decimal places", models.MaxAmountIntegerDigits, models.MaxAmountScale)
+	case "currency":
+		return "must be a currency code of 3 to 10 letters or digits"
+	case "user_id":
+		return "must be a lowercase UUID"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "len":
+		if sized {
+			return fmt.Sprintf("must have exactly %s %s", param, unit)
+		}
+		return "must be " + param
+	case "min", "gte":
+		if sized {
+			return fmt.Sprintf("must have at least %s %s", param, unit)
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if sized {
+			return fmt.Sprintf("must have at most %s %s", param, unit)
+		}
+		return "must be at most " + param
+	case "gt":
+		if sized {
+			return fmt.Sprintf("must have more than %s %s", param, unit)
+		}
+		return "must be greater than " + param
+	case "lt":
+		if sized {
+			return fmt.Sprintf("must have fewer than %s %s", param, unit)
+		}
+		return "must be less than " + param
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
+
+// jsonType names the JSON type of values decoded into t
+func jsonType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(decimal.Decimal{}) {
+		return "a number or a numeric string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}
+
+// validCurrency implements the currency rule
+func validUserID(fl validator.FieldLevel) bool {
+	return !strictUserIDs.Load() || services.ValidUserID(fl.Field().String())
+}
+
+func validCurrency(fl validator.FieldLevel) bool {
+	return services.ValidCurrencyCode(fl.Field().String())
+}
+
+func decimalValue(field reflect.Value) interface{} {
+	value, ok := field.Interface().(decimal.Decimal)
+	if !ok {
+		return nil
+	}
+	// Converting an amount such as 1e999999999 to a float takes minutes.
+	// Amounts out of range keep their sign for the numeric rules and are
+	// rejected by the amount rule.
+	if !models.FitsLedger(value) {
+		return float64(value.Sign())
+	}
+	return value.InexactFloat64()
+}
+
+// validAmount implements the amount rule. Custom types reach validators
+// converted by decimalValue, so the decimal is read from the parent struct.
+func validAmount(fl validator.FieldLevel) bool {
+	field := fl.Parent().FieldByName(fl.StructFieldName())
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return true
+		}
+		field = field.Elem()
+	}
+	value, ok := field.Interface().(decimal.Decimal)
+	return ok && models.FitsLedger(value)
+}
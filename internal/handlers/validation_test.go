@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/apierror"
+)
+
+type validationRequest struct {
+	Amount   decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+	Currency string          `json:"currency" binding:"omitempty,currency"`
+	Items    []struct {
+		UserID string `json:"user_id" binding:"required"`
+	} `json:"items" binding:"max=2,dive"`
+	Count      int    `json:"count"`
+	ReceiverID string `json:"receiver_id" binding:"omitempty,user_id"`
+}
+
+func bindBody(t *testing.T, body string) *apierror.Error {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var request validationRequest
+	err := c.ShouldBindJSON(&request)
+	require.Error(t, err)
+	return bindingError(err)
+}
+
+func TestBindingError(t *testing.T) {
+	t.Run("lists every rejected field under its JSON name", func(t *testing.T) {
+		apiErr := bindBody(t, `{"amount": "0.000000001", "currency": "us", "items": [{}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		assert.Equal(t, apierror.CodeInvalidRequest, apiErr.Code)
+		assert.Equal(t, map[string]interface{}{"fields": []FieldError{
+			{Field: "amount", Rule: "amount", Message: "amount must have at most 12 integer digits and 8 decimal places"},
+			{Field: "currency", Rule: "currency", Message: "currency must be a currency code of 3 to 10 letters or digits"},
+			{Field: "items[0].user_id", Rule: "required", Message: "items[0].user_id is required"},
+		}}, apiErr.Details)
+		assert.Equal(t, "amount must have at most 12 integer digits and 8 decimal places; "+
+			"currency must be a currency code of 3 to 10 letters or digits; items[0].user_id is required", apiErr.Message)
+	})
+
+	t.Run("describes the bounds of numbers and lists", func(t *testing.T) {
+		apiErr := bindBody(t, `{"amount": "-1", "items": [{"user_id": "a"}, {"user_id": "b"}, {"user_id": "c"}]}`)
+
+		assert.Equal(t, map[string]interface{}{"fields": []FieldError{
+			{Field: "amount", Rule: "gt", Message: "amount must be greater than 0"},
+			{Field: "items", Rule: "max", Message: "items must have at most 2 items"},
+		}}, apiErr.Details)
+	})
+
+	t.Run("a value of the wrong type", func(t *testing.T) {
+		apiErr := bindBody(t, `{"amount": "10", "count": "ten"}`)
+
+		assert.Equal(t, "count must be an integer", apiErr.Message)
+		assert.Equal(t, map[string]interface{}{"fields": []FieldError{
+			{Field: "count", Rule: "type", Message: "count must be an integer"},
+		}}, apiErr.Details)
+	})
+
+	t.Run("user IDs are checked once strict user IDs are enabled", func(t *testing.T) {
+		body := `{"amount": "10", "receiver_id": "USER-1"}`
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		var request validationRequest
+		require.NoError(t, c.ShouldBindJSON(&request))
+
+		ValidateUserIDs(true)
+		defer ValidateUserIDs(false)
+		assert.Equal(t, map[string]interface{}{"fields": []FieldError{
+			{Field: "receiver_id", Rule: "user_id", Message: "receiver_id must be a lowercase UUID"},
+		}}, bindBody(t, body).Details)
+	})
+
+	t.Run("a body that is not JSON has no field details", func(t *testing.T) {
+		apiErr := bindBody(t, `{"amount": "10"`)
+		assert.Equal(t, "request body is not valid JSON", apiErr.Message)
+		assert.Nil(t, apiErr.Details)
+
+		apiErr = bindBody(t, ``)
+		assert.Equal(t, "request body is required", apiErr.Message)
+	})
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/pkg/buildinfo"
+)
+
+func VersionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
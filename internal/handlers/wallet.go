@@ -1,61 +1,133 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/fields"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
 	"Crypto.com/internal/repositories/postgres"
 	"Crypto.com/internal/services"
 )
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 type WalletHandler struct {
 	service *services.WalletService
+	// queueDeposits acknowledges deposits from internal services with 202
+	// Accepted and leaves them to the deposit consumer
+	queueDeposits bool
+	// confirmations holds transfers above the confirmation threshold until
+	// their sender confirms them; nil transfers every amount at once
+	confirmations *services.TransferConfirmationService
+	// historyBody still reads transaction history requests from the
+	// deprecated JSON body
+	historyBody bool
 }
 
-func NewWalletHandler(service *services.WalletService) *WalletHandler {
-	return &WalletHandler{service: service}
+func NewWalletHandler(service *services.WalletService, queueDeposits bool, confirmations *services.TransferConfirmationService, historyBody bool) *WalletHandler {
+	return &WalletHandler{service: service, queueDeposits: queueDeposits, confirmations: confirmations, historyBody: historyBody}
 }
 
 func (h *WalletHandler) Deposit(c *gin.Context) {
 	userID := c.Param("userID")
 
 	var request struct {
-		Amount float64 `json:"amount" binding:"required,gt=0"`
+		Amount decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		transactionDetails
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindingError(err))
 		return
 	}
 
-	if err := h.service.Deposit(c.Request.Context(), userID, request.Amount); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx, ok := operationContext(c, request.transactionDetails)
+	if !ok {
+		return
+	}
+
+	if principal, _ := auth.PrincipalFrom(ctx); h.queueDeposits && principal.IsInternal() {
+		h.queueDeposit(c, ctx, userID, request.Amount)
+		return
+	}
+
+	if err := h.service.Deposit(ctx, userID, request.Amount); err != nil {
+		abortWithError(c, err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
+// queueDeposit accepts a deposit for the deposit consumer. The response
+// points to the deposit status, where the outcome can be followed.
+func (h *WalletHandler) queueDeposit(c *gin.Context, ctx context.Context, userID string, amount decimal.Decimal) {
+	deposit, err := h.service.QueueDeposit(ctx, userID, amount)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// The idempotency key belongs to a deposit that was applied synchronously
+	if deposit == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/deposit")+"/deposits/"+deposit.ID)
+	c.JSON(http.StatusAccepted, deposit)
+}
+
+// GetQueuedDeposit returns the status of a deposit accepted with 202 Accepted
+func (h *WalletHandler) GetQueuedDeposit(c *gin.Context) {
+	deposit, err := h.service.GetQueuedDeposit(c.Request.Context(), c.Param("userID"), c.Param("depositID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deposit)
+}
+
 func (h *WalletHandler) Withdraw(c *gin.Context) {
 	userID := c.Param("userID")
 
 	var request struct {
-		Amount float64 `json:"amount" binding:"required,gt=0"`
+		Amount          decimal.Decimal  `json:"amount" binding:"required,gt=0,amount"`
+		ExpectedBalance *decimal.Decimal `json:"expected_balance" binding:"omitempty,gte=0,amount"`
+		transactionDetails
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	ctx, ok := operationContext(c, request.transactionDetails)
+	if !ok {
 		return
 	}
 
-	if err := h.service.Withdraw(c.Request.Context(), userID, request.Amount); err != nil {
-		status := http.StatusInternalServerError
-		if err.Error() == "insufficient balance" {
-			status = http.StatusBadRequest
+	if err := h.service.Withdraw(ctx, userID, request.Amount, request.ExpectedBalance); err != nil {
+		if errors.Is(err, postgres.ErrBalanceMismatch) {
+			h.preconditionFailed(c, userID, err)
+			return
 		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
@@ -66,76 +138,593 @@ func (h *WalletHandler) Transfer(c *gin.Context) {
 	senderID := c.Param("userID")
 
 	var request struct {
-		ReceiverID string  `json:"receiver_id" binding:"required"`
-		Amount     float64 `json:"amount" binding:"required,gt=0"`
+		ReceiverID      string           `json:"receiver_id" binding:"required,user_id"`
+		Amount          decimal.Decimal  `json:"amount" binding:"required,gt=0,amount"`
+		ExpectedBalance *decimal.Decimal `json:"expected_balance" binding:"omitempty,gte=0,amount"`
+		transactionDetails
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	ctx, ok := operationContext(c, request.transactionDetails)
+	if !ok {
 		return
 	}
 
-	if err := h.service.Transfer(c.Request.Context(), senderID, request.ReceiverID, request.Amount); err != nil {
-		status := http.StatusInternalServerError
-		if err.Error() == "insufficient balance" {
-			status = http.StatusBadRequest
+	if h.confirmations != nil && h.confirmations.Required(request.Amount) {
+		h.requestConfirmation(c, ctx, senderID, request.ReceiverID, request.Amount, request.ExpectedBalance)
+		return
+	}
+
+	if err := h.service.Transfer(ctx, senderID, request.ReceiverID, request.Amount, request.ExpectedBalance); err != nil {
+		if errors.Is(err, postgres.ErrBalanceMismatch) {
+			h.preconditionFailed(c, senderID, err)
+			return
 		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
+// requestConfirmation holds a transfer above the confirmation threshold and
+// responds with 202 Accepted. The response points to the confirmation, which
+// the sender confirms with the code sent to them.
+func (h *WalletHandler) requestConfirmation(c *gin.Context, ctx context.Context, senderID, receiverID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) {
+	confirmation, err := h.confirmations.Request(ctx, senderID, receiverID, amount, expectedBalance)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/transfer")+"/transfer-confirmations/"+confirmation.ID)
+	c.JSON(http.StatusAccepted, confirmation)
+}
+
+// transactionDetails are the optional fields a client attaches to the
+// transaction a deposit, withdrawal or transfer records
+type transactionDetails struct {
+	Memo        string            `json:"memo"`
+	ReferenceID string            `json:"reference_id"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+func (d transactionDetails) details() operation.Details {
+	return operation.Details{Memo: d.Memo, Reference: d.ReferenceID, Metadata: d.Metadata}
+}
+
+// operationContext attaches the Idempotency-Key header and the transaction
+// details to the request context. It aborts and returns false when either is
+// invalid.
+func operationContext(c *gin.Context, details transactionDetails) (context.Context, bool) {
+	ctx, ok := idempotencyContext(c)
+	if !ok {
+		return nil, false
+	}
+	ctx, err := services.WithTransactionDetails(ctx, details.details())
+	if err != nil {
+		abortWithError(c, err)
+		return nil, false
+	}
+	return ctx, true
+}
+
+// idempotencyContext attaches the Idempotency-Key header, if any, to the
+// request context. It aborts with 400 and returns false for invalid keys.
+func idempotencyContext(c *gin.Context) (context.Context, bool) {
+	ctx := c.Request.Context()
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		return ctx, true
+	}
+	if len(key) > 255 {
+		abortWithError(c, apierror.BadRequest("Idempotency-Key must be at most 255 characters"))
+		return nil, false
+	}
+	return operation.WithIdempotencyKey(ctx, key), true
+}
+
+// preconditionFailed responds with 412 and the current wallet balance and
+// version in the details so the client can refresh its view before retrying.
+// The balance is read from the database, as the cached one may be the stale
+// balance the client had.
+func (h *WalletHandler) preconditionFailed(c *gin.Context, userID string, err error) {
+	balance, version, balanceErr := h.service.GetCurrentBalance(c.Request.Context(), userID)
+	if balanceErr != nil {
+		abortWithError(c, err)
+		return
+	}
+	abortWithError(c, toAPIError(err).WithDetails(gin.H{"balance": balance, "version": version}))
+}
+
 func (h *WalletHandler) GetBalance(c *gin.Context) {
 	userID := c.Param("userID")
 
-	balance, err := h.service.GetBalance(c.Request.Context(), userID)
+	if at, ok := c.GetQuery("at"); ok {
+		h.balanceAt(c, userID, at)
+		return
+	}
+
+	selection, ok := selectFields(c, models.Balance{})
+	if !ok {
+		return
+	}
+
+	balance, err := h.service.GetBalanceDetails(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"balance": balance})
+	response, ok := sparse(c, selection, balance)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
+const (
+	defaultBalanceWait = 30 * time.Second
+	// MaxBalanceWait is the longest a balance long poll waits for a change
+	MaxBalanceWait = 60 * time.Second
+)
+
+// WaitForBalance serves GET /balance/wait?timeout=30s&since_version=N. It
+// returns as soon as the balance version passes since_version, or with the
+// current balance and changed set to false after the timeout.
+func (h *WalletHandler) WaitForBalance(c *gin.Context) {
+	var request struct {
+		Timeout      string `form:"timeout"`
+		SinceVersion int64  `form:"since_version" binding:"gte=0"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	timeout := defaultBalanceWait
+	if request.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(request.Timeout)
+		if err != nil || timeout <= 0 || timeout > MaxBalanceWait {
+			abortWithError(c, apierror.BadRequest("timeout must be a duration between 0s and "+MaxBalanceWait.String()))
+			return
+		}
+	}
+
+	change, err := h.service.WaitForBalanceChange(c.Request.Context(), c.Param("userID"), request.SinceVersion, timeout)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+const (
+	// walletStreamPoll is how often a wallet stream reads changes it was not
+	// notified of, sending a keep-alive when there are none
+	walletStreamPoll = 15 * time.Second
+	// MaxWalletStream is how long a wallet stream lasts before the client
+	// reconnects
+	MaxWalletStream = time.Hour
+)
+
+// StreamWallet serves GET /stream?since=N, pushing the balance and the
+// transactions of the wallet as Server-Sent Events. A reconnecting client
+// resumes after the transaction in its Last-Event-ID header.
+func (h *WalletHandler) StreamWallet(c *gin.Context) {
+	if isMasked(c) {
+		abortWithError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "wallet streams are not available to your role"))
+		return
+	}
+
+	var request struct {
+		Since *int64 `form:"since" binding:"omitempty,gte=0"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	since := int64(-1)
+	if request.Since != nil {
+		since = *request.Since
+	}
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		var err error
+		since, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || since < 0 {
+			abortWithError(c, apierror.BadRequest("Last-Event-ID must be a sync sequence"))
+			return
+		}
+	}
+
+	err := h.service.StreamWallet(c.Request.Context(), c.Param("userID"), since, walletStreamPoll, func(update models.WalletUpdate) error {
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-store")
+			// Keeps proxies such as nginx from buffering the events
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+		}
+		if err := writeWalletUpdate(c.Writer, update); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	// Once the stream started it ends when the client leaves, the stream
+	// lasted MaxWalletStream or the server shuts down
+	if err != nil && !c.Writer.Written() {
+		abortWithError(c, err)
+	}
+}
+
+// writeWalletUpdate writes update as a Server-Sent Event. Transactions carry
+// their sync sequence as event ID, which the client sends back as
+// Last-Event-ID when it reconnects.
+func writeWalletUpdate(w io.Writer, update models.WalletUpdate) error {
+	if update.Type == models.WalletUpdateKeepAlive {
+		_, err := io.WriteString(w, ": keep-alive\n\n")
+		return err
+	}
+
+	var data any = update.Balance
+	id := ""
+	if update.Type == models.WalletUpdateTransaction {
+		data = update.Transaction
+		if sequence := update.Transaction.SyncSequence; sequence != nil {
+			id = "id: " + strconv.FormatInt(*sequence, 10) + "\n"
+		}
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\n%sdata: %s\n\n", update.Type, id, body)
+	return err
+}
+
+// historicalBalance is the response of GET /balance?at=
+type historicalBalance struct {
+	Balance decimal.Decimal `json:"balance"`
+	At      time.Time       `json:"at"`
+}
+
+// balanceAt serves GET /balance?at=<RFC3339>, the balance as of a past instant
+func (h *WalletHandler) balanceAt(c *gin.Context, userID, at string) {
+	timestamp, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		abortWithError(c, apierror.BadRequest("at must be an RFC3339 timestamp"))
+		return
+	}
+
+	selection, ok := selectFields(c, historicalBalance{})
+	if !ok {
+		return
+	}
+
+	balance, err := h.service.GetBalanceAt(c.Request.Context(), userID, timestamp)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	response, ok := sparse(c, selection, historicalBalance{Balance: balance, At: timestamp.UTC()})
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// historyRequest selects a page of transaction history. It is read from the
+// query string; the JSON body it used to be read from is deprecated.
+type historyRequest struct {
+	Cursor string `form:"cursor" json:"cursor"`
+	// Deprecated: page based pagination, use cursor
+	Page  int `form:"page" json:"page" binding:"gte=0"`
+	Limit int `form:"limit" json:"limit" binding:"gte=0"`
+	// Without a range or full_history only recent transactions are read
+	From        *time.Time `form:"from" json:"from"`
+	To          *time.Time `form:"to" json:"to"`
+	FullHistory bool       `form:"full_history" json:"full_history"`
+	// Only transactions with this reference, a memo containing this memo
+	// and every pair of this metadata
+	ReferenceID string            `form:"reference_id" json:"reference_id"`
+	Memo        string            `form:"memo" json:"memo"`
+	Metadata    map[string]string `form:"-" json:"metadata"`
+}
+
+func (r historyRequest) details() operation.Details {
+	return operation.Details{Memo: r.Memo, Reference: r.ReferenceID, Metadata: r.Metadata}
+}
+
+// TransactionHistory serves GET /transactions?limit=&cursor=, a page of
+// history newest first with the total number of transactions in the window
 func (h *WalletHandler) TransactionHistory(c *gin.Context) {
 	userID := c.Param("userID")
 
+	request, ok := h.bindHistoryRequest(c)
+	if !ok {
+		return
+	}
+
+	if request.Limit < 1 || request.Limit > 100 {
+		request.Limit = 50
+	}
+
+	selection, ok := selectFields(c, models.Transaction{})
+	if !ok {
+		return
+	}
+
+	window, err := services.NewHistoryWindow(request.From, request.To, request.FullHistory, request.Cursor)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	window, err = services.FilterHistoryWindow(window, request.details())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if request.Page > 0 && request.Cursor == "" {
+		h.transactionHistoryPage(c, userID, window, selection, request.Page, request.Limit)
+		return
+	}
+
+	transactions, nextCursor, err := h.service.GetTransactionPage(c.Request.Context(), userID, request.Cursor, window, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	total, err := h.service.CountTransactions(c.Request.Context(), userID, window)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	items, ok := sparse(c, selection, transactions)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": items,
+		"limit":        request.Limit,
+		"total":        total,
+		"has_more":     nextCursor != "",
+		"window":       window,
+		"next_cursor":  nullableCursor(nextCursor),
+	})
+}
+
+// bindHistoryRequest reads a history request from the query string, or from
+// the deprecated JSON body while historyBody is set. It aborts and returns
+// false when the request is invalid.
+func (h *WalletHandler) bindHistoryRequest(c *gin.Context) (historyRequest, bool) {
+	var request historyRequest
+
+	if c.Request.ContentLength != 0 {
+		if !h.historyBody {
+			abortWithError(c, apierror.BadRequest("transaction history takes cursor, limit and filters as query parameters, not a body"))
+			return request, false
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return request, false
+		}
+		c.Header("Deprecation", "true")
+		return request, true
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return request, false
+	}
+	if metadata := c.QueryMap("metadata"); len(metadata) > 0 {
+		request.Metadata = metadata
+	}
+	return request, true
+}
+
+// transactionHistoryPage serves the deprecated page/limit pagination. It also
+// returns next_cursor so clients can switch to cursors mid-listing.
+func (h *WalletHandler) transactionHistoryPage(c *gin.Context, userID string, window models.HistoryWindow, selection fields.Selection, page, limit int) {
+	offset := (page - 1) * limit
+
+	transactions, err := h.service.GetTransactionHistory(c.Request.Context(), userID, window, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	total, err := h.service.CountTransactions(c.Request.Context(), userID, window)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	hasMore := offset+len(transactions) < total
+	nextCursor := ""
+	if hasMore && len(transactions) > 0 {
+		nextCursor = services.EncodeTransactionCursor(transactions[len(transactions)-1], window)
+	}
+
+	items, ok := sparse(c, selection, transactions)
+	if !ok {
+		return
+	}
+	c.Header("Deprecation", "true")
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": items,
+		"page":         page,
+		"limit":        limit,
+		"total":        total,
+		"has_more":     hasMore,
+		"window":       window,
+		"next_cursor":  nullableCursor(nextCursor),
+	})
+}
+
+// SyncTransactions serves GET /transactions/sync?since=N, the transactions
+// recorded or changed since the sync sequence N
+func (h *WalletHandler) SyncTransactions(c *gin.Context) {
 	var request struct {
-		Page  int `json:"page" binding:"required"`
-		Limit int `json:"limit" binding:"required,gt=0"`
+		Since int64 `form:"since" binding:"gte=0"`
+		Limit int   `form:"limit" binding:"gte=0"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	selection, ok := selectFields(c, models.Transaction{})
+	if !ok {
+		return
+	}
+
+	changes, err := h.service.SyncTransactions(c.Request.Context(), c.Param("userID"), request.Since, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
-	// Ensure valid pagination values
-	if request.Page < 1 {
-		request.Page = 1
+	items, ok := sparse(c, selection, changes.Transactions)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": items,
+		"next_since":   changes.NextSince,
+		"has_more":     changes.HasMore,
+	})
+}
+
+func nullableCursor(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+	return &cursor
+}
+
+// Timeline serves GET /timeline?limit=&cursor=, a page of the events touching
+// the wallet newest first
+func (h *WalletHandler) Timeline(c *gin.Context) {
+	userID := c.Param("userID")
+
+	var request struct {
+		Cursor string `form:"cursor"`
+		Page   int    `form:"page"`
+		Limit  int    `form:"limit"`
+	}
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
 	}
+
 	if request.Limit < 1 || request.Limit > 100 {
 		request.Limit = 50
 	}
-	offset := (request.Page - 1) * request.Limit
 
-	transactions, err := h.service.GetTransactionHistory(c.Request.Context(), userID, request.Limit, offset)
+	selection, ok := selectFields(c, models.TimelineEvent{})
+	if !ok {
+		return
+	}
+
+	if request.Page > 0 && request.Cursor == "" {
+		h.timelinePage(c, userID, selection, request.Page, request.Limit)
+		return
+	}
+
+	events, nextCursor, err := h.service.GetTimelinePage(c.Request.Context(), userID, request.Cursor, request.Limit)
 	if err != nil {
-		// Handle specific error cases
-		if errors.Is(err, postgres.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
+	items, ok := sparse(c, selection, events)
+	if !ok {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"transactions": transactions,
-		"page":         request.Page,
-		"limit":        request.Limit,
-		"total":        len(transactions),
+		"events":      items,
+		"limit":       request.Limit,
+		"has_more":    nextCursor != "",
+		"next_cursor": nullableCursor(nextCursor),
+	})
+}
+
+// timelinePage serves the deprecated page/limit pagination of the timeline.
+// It also returns next_cursor so clients can switch to cursors mid-listing.
+func (h *WalletHandler) timelinePage(c *gin.Context, userID string, selection fields.Selection, page, limit int) {
+	offset := (page - 1) * limit
+
+	events, err := h.service.GetTimeline(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	nextCursor := ""
+	if len(events) == limit {
+		nextCursor = services.EncodeTimelineCursor(events[len(events)-1])
+	}
+
+	items, ok := sparse(c, selection, events)
+	if !ok {
+		return
+	}
+	c.Header("Deprecation", "true")
+	c.JSON(http.StatusOK, gin.H{
+		"events":      items,
+		"page":        page,
+		"limit":       limit,
+		"next_cursor": nullableCursor(nextCursor),
 	})
 }
+
+// Reconcile compares an integrator's view of a period with the ledger and
+// returns the differences. A mismatch is not an error: the response is 200
+// with matched set to false.
+func (h *WalletHandler) Reconcile(c *gin.Context) {
+	userID := c.Param("userID")
+
+	var request struct {
+		From              time.Time       `json:"from" binding:"required"`
+		To                time.Time       `json:"to" binding:"required"`
+		Count             int             `json:"count" binding:"gte=0"`
+		Sum               decimal.Decimal `json:"sum"`
+		LastTransactionID string          `json:"last_transaction_id"`
+		TransactionIDs    []string        `json:"transaction_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	result, err := h.service.Reconcile(c.Request.Context(), userID, models.ReconciliationClaim{
+		From:              request.From,
+		To:                request.To,
+		Count:             request.Count,
+		Sum:               request.Sum,
+		LastTransactionID: request.LastTransactionID,
+		TransactionIDs:    request.TransactionIDs,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
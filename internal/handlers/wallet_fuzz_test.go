@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/services"
+	"Crypto.com/mocks"
+)
+
+// The fuzz targets send arbitrary bodies and user IDs to the money movement
+// endpoints. A request must never panic or fail with a server error, and an
+// amount reaching the wallet repository must be positive and fit the ledger.
+// The seeds run with go test; go test -fuzz=FuzzDeposit ./internal/handlers
+// explores further.
+
+var fuzzSeeds = []struct {
+	userID string
+	body   string
+}{
+	{"user1", `{"amount": "100.5"}`},
+	{"user1", `{"amount": 100.5, "receiver_id": "user2", "expected_balance": "200"}`},
+	{"user1", `{"amount": "0.00000001", "receiver_id": "user2"}`},
+	{"user1", `{"amount": "0.000000001"}`},
+	{"user1", `{"amount": "999999999999.99999999"}`},
+	{"user1", `{"amount": "1000000000000"}`},
+	{"user1", `{"amount": 1e999999999}`},
+	{"user1", `{"amount": "1e-999999999"}`},
+	{"user1", `{"amount": "-5"}`},
+	{"user1", `{"amount": "NaN"}`},
+	{"user1", `{"amount": null, "receiver_id": null}`},
+	{"user1", `{"amount": "10", "expected_balance": "1e99999999"}`},
+	{"user1", `{"amount": "10", "receiver_id": ""}`},
+	{"user1", `{"amount": ["10"]}`},
+	{"user1", `{"amount": "10"`},
+	{"user1", ``},
+	{"user1", `{"amount": "10", "receiver_id": "用户\u0000"}`},
+	{"ユーザー1", `{"amount": "10", "receiver_id": "👛"}`},
+	{"user/../admin", `{"amount": "10"}`},
+	{"‮user1", `{"amount": "10"}`},
+}
+
+func FuzzDeposit(f *testing.F) {
+	fuzzMoneyMovement(f, "/deposit", func(t *testing.T, repo *mocks.MockWalletRepository) {
+		repo.EXPECT().Deposit(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+			DoAndReturn(func(_ context.Context, _ string, amount decimal.Decimal) error {
+				checkAmount(t, amount, nil)
+				return nil
+			})
+	})
+}
+
+func FuzzWithdraw(f *testing.F) {
+	fuzzMoneyMovement(f, "/withdraw", func(t *testing.T, repo *mocks.MockWalletRepository) {
+		repo.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+			DoAndReturn(func(_ context.Context, _ string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+				checkAmount(t, amount, expectedBalance)
+				return nil
+			})
+	})
+}
+
+func FuzzTransfer(f *testing.F) {
+	fuzzMoneyMovement(f, "/transfer", func(t *testing.T, repo *mocks.MockWalletRepository) {
+		repo.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+			DoAndReturn(func(_ context.Context, _, receiverID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+				if receiverID == "" {
+					t.Error("empty receiver reached the repository")
+				}
+				checkAmount(t, amount, expectedBalance)
+				return nil
+			})
+	})
+}
+
+// checkAmount fails t unless the amounts passed validation rightly
+func checkAmount(t *testing.T, amount decimal.Decimal, expectedBalance *decimal.Decimal) {
+	if !amount.IsPositive() || !models.FitsLedger(amount) {
+		t.Errorf("amount %s reached the repository", amount)
+	}
+	if expectedBalance != nil && (expectedBalance.IsNegative() || !models.FitsLedger(*expectedBalance)) {
+		t.Errorf("expected balance %s reached the repository", expectedBalance)
+	}
+}
+
+func fuzzMoneyMovement(f *testing.F, action string, expect func(t *testing.T, repo *mocks.MockWalletRepository)) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed.userID, seed.body)
+	}
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	f.Fuzz(func(t *testing.T, userID, body string) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockWalletRepository(ctrl)
+		expect(t, repo)
+		handler := NewWalletHandler(services.NewWalletService(repo, redis.NewNoopCacheRepository(), logger), false, nil, false)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		wallets := router.Group("/wallets/:userID")
+		wallets.POST("/deposit", handler.Deposit)
+		wallets.POST("/withdraw", handler.Withdraw)
+		wallets.POST("/transfer", handler.Transfer)
+
+		request := httptest.NewRequest(http.MethodPost, "/wallets/"+url.PathEscape(userID)+action, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		// User IDs that are empty or contain a slash match no route and are
+		// answered with 404
+		if recorder.Code >= http.StatusInternalServerError {
+			t.Errorf("status %d for user %q and body %q: %s", recorder.Code, userID, body, recorder.Body)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/services"
+	"Crypto.com/mocks"
+)
+
+func serveHistory(t *testing.T, repo *mocks.MockWalletRepository, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	handler := NewWalletHandler(services.NewWalletService(repo, redis.NewNoopCacheRepository(), logger), false, nil, false)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/wallets/:userID/transactions", handler.TransactionHistory)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/wallets/user1/transactions?"+query, nil))
+	return recorder
+}
+
+func TestTransactionHistory(t *testing.T) {
+	t.Run("full history reads every transaction", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+		repo.EXPECT().GetTransactionsBefore(gomock.Any(), "user1", nil, models.HistoryWindow{}, 11).Return([]models.Transaction{}, nil)
+		repo.EXPECT().CountTransactions(gomock.Any(), "user1", models.HistoryWindow{}).Return(0, nil)
+
+		recorder := serveHistory(t, repo, "full_history=true&limit=10")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		assert.JSONEq(t, `{"transactions": [], "limit": 10, "total": 0, "has_more": false, "window": {"from": null, "to": null}, "next_cursor": null}`, recorder.Body.String())
+	})
+
+	t.Run("full history with a range", func(t *testing.T) {
+		repo := mocks.NewMockWalletRepository(gomock.NewController(t))
+
+		recorder := serveHistory(t, repo, "full_history=true&from=2024-01-01T00:00:00Z")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type WebhookKeyHandler struct {
+	service *services.WebhookKeyService
+}
+
+func NewWebhookKeyHandler(service *services.WebhookKeyService) *WebhookKeyHandler {
+	return &WebhookKeyHandler{service: service}
+}
+
+// RotateKey creates a new webhook signing key. The response is the only time
+// its secret is shown.
+func (h *WebhookKeyHandler) RotateKey(c *gin.Context) {
+	var request struct {
+		OverlapSeconds *int64 `json:"overlap_seconds" binding:"omitempty,gte=0"`
+	}
+
+	// The body is optional; without one the default overlap applies
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	var overlap *time.Duration
+	if request.OverlapSeconds != nil {
+		window := time.Duration(*request.OverlapSeconds) * time.Second
+		overlap = &window
+	}
+
+	key, secret, err := h.service.Rotate(c.Request.Context(), overlap)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"signing_key": key, "secret": secret})
+}
+
+func (h *WebhookKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signing_keys": keys})
+}
+
+func (h *WebhookKeyHandler) ExpireKey(c *gin.Context) {
+	key, err := h.service.Expire(c.Request.Context(), c.Param("keyID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/services"
+)
+
+type WebhookSubscriptionHandler struct {
+	service *services.WebhookSubscriptionService
+}
+
+func NewWebhookSubscriptionHandler(service *services.WebhookSubscriptionService) *WebhookSubscriptionHandler {
+	return &WebhookSubscriptionHandler{service: service}
+}
+
+// GetSubscription returns whether webhook deliveries are paused and the
+// backlog of events waiting for them
+func (h *WebhookSubscriptionHandler) GetSubscription(c *gin.Context) {
+	subscription, err := h.service.Get(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// PauseSubscription holds webhook deliveries back until they are resumed
+func (h *WebhookSubscriptionHandler) PauseSubscription(c *gin.Context) {
+	var request struct {
+		Reason string `json:"reason" binding:"max=255"`
+	}
+
+	// The body is optional; it only records why deliveries are paused
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, bindingError(err))
+			return
+		}
+	}
+
+	subscription, err := h.service.Pause(c.Request.Context(), request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ResumeSubscription delivers the backlog in order and the events that
+// follow it
+func (h *WebhookSubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	subscription, err := h.service.Resume(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
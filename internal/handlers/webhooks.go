@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/events"
+)
+
+func EventCatalogHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"events": events.Catalog()})
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/services"
+)
+
+type WithdrawalHandler struct {
+	service *services.WithdrawalService
+}
+
+func NewWithdrawalHandler(service *services.WithdrawalService) *WithdrawalHandler {
+	return &WithdrawalHandler{service: service}
+}
+
+// RequestWithdrawal accepts a withdrawal to an external destination. The
+// payout is sent asynchronously; the response points to the withdrawal
+// status.
+func (h *WithdrawalHandler) RequestWithdrawal(c *gin.Context) {
+	userID := c.Param("userID")
+
+	var request struct {
+		Amount      decimal.Decimal `json:"amount" binding:"required,gt=0,amount"`
+		Destination string          `json:"destination" binding:"required,max=255"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, bindingError(err))
+		return
+	}
+
+	withdrawal, err := h.service.RequestWithdrawal(c.Request.Context(), userID, request.Amount, request.Destination)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+withdrawal.ID)
+	c.JSON(http.StatusAccepted, withdrawal)
+}
+
+func (h *WithdrawalHandler) GetWithdrawal(c *gin.Context) {
+	withdrawal, err := h.service.GetWithdrawal(c.Request.Context(), c.Param("userID"), c.Param("withdrawalID"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, withdrawal)
+}
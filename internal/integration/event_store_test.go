@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+func TestEventSourcedWallets(t *testing.T) {
+	repo := postgres.NewWalletRepository(db, logger, postgres.WithEventStreams())
+	events := postgres.NewWalletEventRepository(db, logger)
+	ctx := context.Background()
+	alice, bob := walletID(t, "alice"), walletID(t, "bob")
+
+	require.NoError(t, repo.Deposit(ctx, alice, decimal.NewFromInt(100)))
+	require.NoError(t, repo.Deposit(ctx, bob, decimal.NewFromInt(100)))
+	before := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.Transfer(ctx, alice, bob, decimal.NewFromInt(2), nil))
+			assert.NoError(t, repo.Transfer(ctx, bob, alice, decimal.NewFromInt(1), nil))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, repo.Withdraw(ctx, bob, decimal.RequireFromString("0.5"), nil))
+
+	// The projected balances match the streams
+	verification, err := events.VerifyStreams(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, verification.Breaks)
+	corrected, err := events.RebuildProjections(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, corrected)
+
+	balance, err := repo.GetBalance(ctx, alice)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(80).Equal(balance), "balance is %s", balance)
+
+	// Balances as of a past time are folded from the stream
+	balance, err = events.BalanceAt(ctx, bob, before)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100).Equal(balance), "balance is %s", balance)
+
+	// A balance changed behind the engine's back is projected again
+	_, err = db.ExecContext(ctx, "UPDATE wallets SET balance = 1000000 WHERE user_id = $1", alice)
+	require.NoError(t, err)
+	corrected, err = events.RebuildProjections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, corrected)
+	balance, err = repo.GetBalance(ctx, alice)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(80).Equal(balance), "balance is %s", balance)
+
+	// A rewritten event breaks the chain of its stream
+	_, err = db.ExecContext(ctx, "UPDATE wallet_events SET amount = 1000 WHERE user_id = $1 AND version = 2", bob)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		// Later tests verify the streams too
+		_, _ = db.ExecContext(context.Background(), "DELETE FROM wallet_events WHERE user_id = $1", bob)
+	})
+	verification, err = events.VerifyStreams(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, verification.Breaks, models.StreamBreak{UserID: bob, Version: 2})
+}
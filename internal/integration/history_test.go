@@ -0,0 +1,87 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+// queryRecorder records the statements run on the connections it traces
+type queryRecorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *queryRecorder) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, data.SQL)
+	return ctx
+}
+
+func (r *queryRecorder) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func TestDefaultHistoryReadsRecentIndexes(t *testing.T) {
+	ctx := context.Background()
+	alice := walletID(t, "alice")
+
+	// A year of older transactions makes the full history indexes much
+	// larger than the recent ones
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO transactions (from_user_id, type, amount, created_at)
+		SELECT $1, 'deposit', 1, NOW() - INTERVAL '1 year' - n * INTERVAL '1 hour' FROM generate_series(1, 5000) AS n`,
+		alice)
+	require.NoError(t, err)
+	_, err = postgres.NewHistoryIndexRepository(db, logger).RotateRecentIndexes(ctx, services.RecentHistoryCutoff(time.Now()))
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "ANALYZE transactions")
+	require.NoError(t, err)
+
+	config, err := pgx.ParseConfig(connString)
+	require.NoError(t, err)
+	recorder := &queryRecorder{}
+	config.Tracer = recorder
+	traced := stdlib.OpenDB(*config)
+	defer traced.Close()
+
+	window, err := services.NewHistoryWindow(nil, nil, false, "")
+	require.NoError(t, err)
+	_, err = postgres.NewWalletRepository(traced, logger).GetTransactionsBefore(ctx, alice, nil, window, 10)
+	require.NoError(t, err)
+	require.Len(t, recorder.statements, 1)
+
+	// The statement cache may run a generic plan, made without the values
+	// of the parameters. Sequential scans are disabled so that the plan
+	// shows which indexes the statement can use rather than which is
+	// cheapest on a small table.
+	conn, err := traced.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	var plan string
+	err = conn.Raw(func(driverConn any) error {
+		results, err := driverConn.(*stdlib.Conn).Conn().PgConn().
+			Exec(ctx, "SET enable_seqscan = off; EXPLAIN (GENERIC_PLAN) "+recorder.statements[0]).ReadAll()
+		if err != nil {
+			return err
+		}
+		for _, row := range results[len(results)-1].Rows {
+			plan += string(row[0]) + "\n"
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, plan, "idx_transactions_recent_sender_")
+	assert.Contains(t, plan, "idx_transactions_recent_receiver_")
+}
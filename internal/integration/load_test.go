@@ -0,0 +1,77 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/handlers"
+	"Crypto.com/internal/loadtest"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/services"
+)
+
+// BenchmarkMonthEnd replays a compressed month-end profile against the wallet
+// routes served by PostgreSQL and Redis, and reports the p99 latency of every
+// endpoint over the run and the budgets exceeded. The same harness drives a
+// staging deployment through cmd/loadtest.
+//
+//	go test -tags integration ./internal/integration -run '^$' -bench MonthEnd -benchtime 1x
+func BenchmarkMonthEnd(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	signingKey := []byte("loadtest")
+
+	service, _ := newWalletService(b)
+	statements := services.NewStatementService(postgres.NewStatementRepository(db, logger), postgres.NewSnapshotRepository(db, logger), logger)
+	walletHandler := handlers.NewWalletHandler(service, false, nil, false)
+	statementHandler := handlers.NewStatementHandler(statements)
+
+	router := gin.New()
+	router.Use(handlers.ErrorHandler())
+	wallets := router.Group("/api/v1/wallets/:userID",
+		handlers.AuthHandler(auth.NewVerifier(signingKey, ""), nil),
+		handlers.RequireWalletOwner(),
+		handlers.OperationHandler(operation.ChannelAPI),
+	)
+	wallets.POST("/deposit", walletHandler.Deposit)
+	wallets.POST("/transfer", walletHandler.Transfer)
+	wallets.GET("/balance", walletHandler.GetBalance)
+	wallets.GET("/transactions", walletHandler.TransactionHistory)
+	wallets.GET("/statements", statementHandler.GetStatement)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	token, err := auth.Sign(signingKey, "", "loadtest", []string{auth.RoleAdmin}, time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+	users := make([]string, 50)
+	for i := range users {
+		users[i] = walletID(b, fmt.Sprintf("user%d", i))
+	}
+	runner := loadtest.NewRunner(loadtest.Config{BaseURL: server.URL, Token: token, Users: users, Concurrency: 64})
+	if err := runner.Seed(b.Context(), "100000.00"); err != nil {
+		b.Fatal(err)
+	}
+
+	var report *loadtest.Report
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		report = runner.Run(b.Context(), loadtest.MonthEnd(20*time.Second, 2))
+	}
+	b.StopTimer()
+
+	for _, stats := range report.Total {
+		b.ReportMetric(stats.P99Millis, stats.Endpoint+"-p99-ms")
+		b.ReportMetric(stats.Throughput, stats.Endpoint+"-req/s")
+	}
+	b.ReportMetric(float64(len(report.Evaluate(loadtest.DefaultBudgets))), "violations")
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// lockingModes are the repositories compared by the hot wallet tests
+var lockingModes = []struct {
+	name string
+	opts []postgres.WalletRepositoryOption
+}{
+	{name: "pessimistic"},
+	{name: "optimistic", opts: []postgres.WalletRepositoryOption{postgres.WithOptimisticLocking(10)}},
+}
+
+func TestOptimisticWithdrawals(t *testing.T) {
+	repo := postgres.NewWalletRepository(db, logger, postgres.WithOptimisticLocking(50))
+	ctx := context.Background()
+	user := walletID(t, "user")
+	require.NoError(t, repo.Deposit(ctx, user, decimal.NewFromInt(100)))
+
+	// Without the wallet lock of the service, the version alone keeps
+	// concurrent withdrawals from overdrawing the wallet
+	const attempts = 50
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.Withdraw(ctx, user, decimal.NewFromInt(3), nil)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, postgres.ErrInsufficientBalance), errors.Is(err, postgres.ErrWalletContended):
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	balance, err := repo.GetBalance(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100-3*succeeded.Load()).Equal(balance), "Expected %d withdrawals, balance is %s", succeeded.Load(), balance)
+	assert.False(t, balance.IsNegative())
+	assertGaplessSequences(t, user, 1+int(succeeded.Load()))
+}
+
+// BenchmarkHotWallet compares the throughput of both locking modes on a
+// single wallet written by parallel callers, as a merchant or settlement
+// wallet is. Runs the repository directly, without the wallet lock of the
+// service, which would serialize both modes alike.
+//
+//	go test -tags integration ./internal/integration -run '^$' -bench HotWallet -cpu 1,8,32
+func BenchmarkHotWallet(b *testing.B) {
+	ctx := context.Background()
+
+	for _, mode := range lockingModes {
+		repo := postgres.NewWalletRepository(db, logger, mode.opts...)
+
+		b.Run("withdrawals/"+mode.name, func(b *testing.B) {
+			hot := walletID(b, "hot")
+			if err := repo.Deposit(ctx, hot, decimal.NewFromInt(1_000_000_000)); err != nil {
+				b.Fatal(err)
+			}
+			runHot(b, func() error {
+				return repo.Withdraw(ctx, hot, decimal.NewFromInt(1), nil)
+			})
+		})
+
+		b.Run("transfers/"+mode.name, func(b *testing.B) {
+			hot := walletID(b, "hot")
+			sender := walletID(b, "sender")
+			for _, user := range []string{hot, sender} {
+				if err := repo.Deposit(ctx, user, decimal.NewFromInt(1_000_000_000)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			runHot(b, func() error {
+				return repo.Transfer(ctx, sender, hot, decimal.NewFromInt(1), nil)
+			})
+		})
+	}
+}
+
+// runHot runs op from parallel goroutines and reports the operations
+// completed per second and the share given up as contended
+func runHot(b *testing.B, op func() error) {
+	var contended atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := op()
+			if errors.Is(err, postgres.ErrWalletContended) {
+				contended.Add(1)
+			} else if err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	completed := int64(b.N) - contended.Load()
+	b.ReportMetric(float64(completed)/b.Elapsed().Seconds(), "ops/s")
+	b.ReportMetric(float64(contended.Load())/float64(b.N), "contended/op")
+}
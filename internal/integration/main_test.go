@@ -0,0 +1,114 @@
+//go:build integration
+
+// Package integration runs the repositories and services against real
+// PostgreSQL and Redis containers. It needs a Docker daemon:
+//
+//	go test -tags integration ./internal/integration/...
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	db          *sql.DB
+	dbPool      *pgxpool.Pool
+	connString  string
+	redisClient *goredis.Client
+	logger      = logrus.New()
+)
+
+func TestMain(m *testing.M) {
+	logger.SetLevel(logrus.WarnLevel)
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Connect to Docker: %v", err)
+	}
+	pool.MaxWait = 2 * time.Minute
+
+	pg, err := run(pool, "postgres", "16-alpine", "POSTGRES_PASSWORD=secret", "POSTGRES_DB=wallet")
+	if err != nil {
+		log.Fatalf("Start Postgres: %v", err)
+	}
+	rd, err := run(pool, "redis", "7-alpine")
+	if err != nil {
+		_ = pool.Purge(pg)
+		log.Fatalf("Start Redis: %v", err)
+	}
+
+	code := func() int {
+		if err := pool.Retry(func() error {
+			var err error
+			connString = fmt.Sprintf("postgres://postgres:secret@%s/wallet?sslmode=disable", pg.GetHostPort("5432/tcp"))
+			dbPool, db, err = postgres.OpenPool(context.Background(), connString, postgres.PoolConfig{
+				MaxConns:               50,
+				StatementCacheCapacity: 512,
+			})
+			if err != nil {
+				return err
+			}
+			if err := dbPool.Ping(context.Background()); err != nil {
+				dbPool.Close()
+				return err
+			}
+			return nil
+		}); err != nil {
+			log.Printf("Postgres not ready: %v", err)
+			return 1
+		}
+		defer dbPool.Close()
+		defer db.Close()
+
+		redisClient = goredis.NewClient(&goredis.Options{Addr: rd.GetHostPort("6379/tcp")})
+		defer redisClient.Close()
+		if err := pool.Retry(func() error {
+			return redisClient.Ping(context.Background()).Err()
+		}); err != nil {
+			log.Printf("Redis not ready: %v", err)
+			return 1
+		}
+
+		if _, err := postgres.Migrate(context.Background(), db, logger); err != nil {
+			log.Printf("Migrate: %v", err)
+			return 1
+		}
+		return m.Run()
+	}()
+
+	_ = pool.Purge(pg)
+	_ = pool.Purge(rd)
+	os.Exit(code)
+}
+
+// run starts a container that removes itself once stopped, and at the latest
+// after ten minutes if the suite is killed before purging it
+func run(pool *dockertest.Pool, repository, tag string, env ...string) (*dockertest.Resource, error) {
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: repository,
+		Tag:        tag,
+		Env:        env,
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = resource.Expire(600)
+	return resource, nil
+}
@@ -0,0 +1,228 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/internal/services"
+)
+
+// newWalletService wires the wallet service as the server does with Redis:
+// write-through cache and per-wallet locks
+func newWalletService(t testing.TB) (*services.WalletService, *postgres.PostgresWalletRepository) {
+	repo := postgres.NewWalletRepository(db, logger, postgres.WithPool(dbPool))
+	cache := redis.NewCacheRepository(redisClient, time.Hour, logger)
+	service := services.NewWalletService(repo, cache, logger,
+		services.WithWriteThrough(),
+		services.WithWalletLock(redis.NewWalletLock(redisClient, 10*time.Second, 5*time.Second, logger)),
+	)
+	t.Cleanup(service.Wait)
+	return service, repo
+}
+
+// walletID registers a user unique to the test and returns its ID, so tests
+// share the database without seeing each other's wallets
+func walletID(t testing.TB, name string) string {
+	externalID := fmt.Sprintf("%s-%s-%d", t.Name(), name, time.Now().UnixNano())
+	user := &models.User{ExternalID: &externalID}
+	require.NoError(t, postgres.NewUserRepository(db, logger).CreateUser(context.Background(), user, nil))
+	return user.ID
+}
+
+// untilIdle retries op while another operation holds the wallet lock
+func untilIdle(op func() error) error {
+	for {
+		err := op()
+		if !errors.Is(err, services.ErrWalletBusy) {
+			return err
+		}
+	}
+}
+
+func TestConcurrentDeposits(t *testing.T) {
+	service, repo := newWalletService(t)
+	ctx := context.Background()
+	user := walletID(t, "user")
+
+	const workers, deposits = 20, 25
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < deposits; j++ {
+				assert.NoError(t, untilIdle(func() error {
+					return service.Deposit(ctx, user, decimal.NewFromInt(1))
+				}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// No lost updates, and every deposit got its own ledger position
+	assertBalance(t, service, repo, user, decimal.NewFromInt(workers*deposits))
+	assertGaplessSequences(t, user, workers*deposits)
+}
+
+func TestConcurrentWithdrawals(t *testing.T) {
+	service, repo := newWalletService(t)
+	ctx := context.Background()
+	user := walletID(t, "user")
+	require.NoError(t, service.Deposit(ctx, user, decimal.NewFromInt(100)))
+
+	const attempts = 50
+	var succeeded, insufficient atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := untilIdle(func() error {
+				return service.Withdraw(ctx, user, decimal.NewFromInt(3), nil)
+			})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, postgres.ErrInsufficientBalance):
+				insufficient.Add(1)
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Exactly the withdrawals the balance covers succeed
+	assert.Equal(t, int64(33), succeeded.Load())
+	assert.Equal(t, int64(attempts-33), insufficient.Load())
+	assertBalance(t, service, repo, user, decimal.NewFromInt(1))
+	assertGaplessSequences(t, user, 1+33)
+}
+
+func TestConcurrentTransfers(t *testing.T) {
+	service, repo := newWalletService(t)
+	ctx := context.Background()
+
+	const wallets, transfers = 5, 400
+	users := make([]string, wallets)
+	for i := range users {
+		users[i] = walletID(t, fmt.Sprintf("user%d", i))
+		require.NoError(t, service.Deposit(ctx, users[i], decimal.NewFromInt(50)))
+	}
+
+	// Transfers run in both directions between every pair of wallets, the
+	// pattern that deadlocks without ordered locking
+	var wg sync.WaitGroup
+	for i := 0; i < transfers; i++ {
+		from := users[rand.Intn(wallets)]
+		to := users[rand.Intn(wallets)]
+		if from == to {
+			continue
+		}
+		amount := decimal.NewFromInt(int64(1 + rand.Intn(20)))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := untilIdle(func() error {
+				return service.Transfer(ctx, from, to, amount, nil)
+			})
+			if err != nil && !errors.Is(err, postgres.ErrInsufficientBalance) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Funds are neither created nor lost, and no wallet went negative
+	total := decimal.Zero
+	for _, user := range users {
+		balance, err := repo.GetBalance(ctx, user)
+		require.NoError(t, err)
+		assert.False(t, balance.IsNegative(), "%s has a negative balance %s", user, balance)
+		assertBalance(t, service, repo, user, balance)
+		total = total.Add(balance)
+	}
+	assert.True(t, decimal.NewFromInt(wallets*50).Equal(total), "Expected total %d, got %s", wallets*50, total)
+
+	var negative int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM wallets WHERE balance < 0 OR held < 0").Scan(&negative))
+	assert.Zero(t, negative)
+}
+
+// assertBalance checks the ledger balance of user, and that the cached
+// balance served by the service agrees with it
+func assertBalance(t *testing.T, service *services.WalletService, repo *postgres.PostgresWalletRepository, user string, expected decimal.Decimal) {
+	t.Helper()
+	ctx := context.Background()
+
+	stored, err := repo.GetBalance(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(stored), "Expected balance %s, got %s", expected, stored)
+
+	served, err := service.GetBalance(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, stored.Equal(served), "Cached balance %s differs from stored balance %s", served, stored)
+}
+
+// assertGaplessSequences checks that the ledger of user numbers its
+// transactions 1 to count without gaps or duplicates
+func assertGaplessSequences(t *testing.T, user string, count int) {
+	t.Helper()
+
+	var total, highest int
+	require.NoError(t, db.QueryRowContext(context.Background(),
+		"SELECT COUNT(DISTINCT sequence), COALESCE(MAX(sequence), 0) FROM transaction_sequences WHERE user_id = $1", user,
+	).Scan(&total, &highest))
+	assert.Equal(t, count, total)
+	assert.Equal(t, count, highest)
+}
+
+func TestTransactionSync(t *testing.T) {
+	service, _ := newWalletService(t)
+	ctx := context.Background()
+	user, other := walletID(t, "user"), walletID(t, "other")
+
+	require.NoError(t, service.Deposit(ctx, user, decimal.NewFromInt(100)))
+	require.NoError(t, service.Transfer(ctx, user, other, decimal.NewFromInt(30), nil))
+
+	changes, err := service.SyncTransactions(ctx, user, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes.Transactions, 2)
+	assert.Equal(t, int64(2), changes.NextSince)
+	transfer := *changes.Transactions[1].ID
+
+	// A status change moves the transaction to the end of the sync order of
+	// both wallets, without renumbering their ledgers
+	transactions := postgres.NewTransactionRepository(db, logger)
+	require.NoError(t, transactions.UpdateStatus(ctx, transfer, models.TransactionCompleted, models.TransactionEscalated))
+
+	changes, err = service.SyncTransactions(ctx, user, changes.NextSince, 10)
+	require.NoError(t, err)
+	require.Len(t, changes.Transactions, 1)
+	assert.Equal(t, transfer, *changes.Transactions[0].ID)
+	assert.Equal(t, models.TransactionEscalated, *changes.Transactions[0].Status)
+	assert.Equal(t, int64(2), *changes.Transactions[0].Sequence)
+	assert.Equal(t, int64(3), changes.NextSince)
+
+	changes, err = service.SyncTransactions(ctx, other, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, changes.Transactions, 1)
+	assert.Equal(t, transfer, *changes.Transactions[0].ID)
+	assertGaplessSequences(t, user, 2)
+}
@@ -0,0 +1,88 @@
+// Package loadtest replays traffic shapes against a deployment of the wallet
+// API and reports the throughput, latency and errors of every endpoint
+// against error budgets, so capacity can be checked before month-end.
+package loadtest
+
+import "time"
+
+// Endpoints the profiles drive
+const (
+	EndpointDeposit   = "deposit"
+	EndpointTransfer  = "transfer"
+	EndpointBalance   = "balance"
+	EndpointHistory   = "history"
+	EndpointStatement = "statement"
+)
+
+// Load sends Rate requests per second to an endpoint
+type Load struct {
+	Endpoint string
+	Rate     float64
+}
+
+// Phase is a stretch of a profile with a constant mix of traffic
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	Loads    []Load
+}
+
+// Profile is a sequence of phases replayed in order
+type Profile struct {
+	Name   string
+	Phases []Phase
+}
+
+// monthEnd is the shape of month-end traffic at scale 1: the share of the
+// duration each phase takes and its requests per second. Salaries land
+// first and are spent over the following days, while statements are
+// downloaded once the month has closed.
+var monthEnd = []struct {
+	name  string
+	share float64
+	loads []Load
+}{
+	{"baseline", 0.2, []Load{
+		{EndpointDeposit, 2},
+		{EndpointTransfer, 5},
+		{EndpointBalance, 20},
+		{EndpointHistory, 3},
+	}},
+	{"salary-day", 0.2, []Load{
+		{EndpointDeposit, 50},
+		{EndpointTransfer, 8},
+		{EndpointBalance, 40},
+		{EndpointHistory, 5},
+	}},
+	{"spending", 0.3, []Load{
+		{EndpointDeposit, 5},
+		{EndpointTransfer, 25},
+		{EndpointBalance, 35},
+		{EndpointHistory, 8},
+	}},
+	{"statements", 0.3, []Load{
+		{EndpointDeposit, 2},
+		{EndpointTransfer, 8},
+		{EndpointBalance, 20},
+		{EndpointHistory, 10},
+		{EndpointStatement, 12},
+	}},
+}
+
+// MonthEnd returns the month-end profile replayed over duration, with its
+// rates multiplied by scale
+func MonthEnd(duration time.Duration, scale float64) Profile {
+	profile := Profile{Name: "month-end"}
+	for _, phase := range monthEnd {
+		loads := make([]Load, 0, len(phase.loads))
+		for _, load := range phase.loads {
+			loads = append(loads, Load{Endpoint: load.Endpoint, Rate: load.Rate * scale})
+		}
+		profile.Phases = append(profile.Phases, Phase{
+			Name:     phase.name,
+			Duration: time.Duration(float64(duration) * phase.share),
+			Loads:    loads,
+		})
+	}
+	return profile
+}
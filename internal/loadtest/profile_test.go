@@ -0,0 +1,35 @@
+package loadtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonthEnd(t *testing.T) {
+	profile := MonthEnd(10*time.Minute, 2)
+
+	assert.Equal(t, "month-end", profile.Name)
+	var names []string
+	var total time.Duration
+	for _, phase := range profile.Phases {
+		names = append(names, phase.Name)
+		total += phase.Duration
+	}
+	assert.Equal(t, []string{"baseline", "salary-day", "spending", "statements"}, names)
+	assert.Equal(t, 10*time.Minute, total)
+
+	// Salary day is a deposit burst, scaled
+	assert.Contains(t, profile.Phases[1].Loads, Load{Endpoint: EndpointDeposit, Rate: 100})
+	// Statements are only downloaded once the month has closed
+	for _, phase := range profile.Phases[:3] {
+		for _, load := range phase.Loads {
+			assert.NotEqual(t, EndpointStatement, load.Endpoint, phase.Name)
+		}
+	}
+	assert.Contains(t, profile.Phases[3].Loads, Load{Endpoint: EndpointStatement, Rate: 24})
+
+	// The shape itself is left untouched
+	assert.Equal(t, 50.0, monthEnd[1].loads[0].Rate)
+}
@@ -0,0 +1,203 @@
+package loadtest
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EndpointStats sums up the requests sent to an endpoint. Errors are
+// transport failures, 5xx and 429 responses, which count against the error
+// budget; other 4xx responses are rejected requests, such as transfers over
+// the balance. Dropped requests were not sent because Concurrency requests
+// were in flight: the target rate was not reached.
+type EndpointStats struct {
+	Endpoint   string  `json:"endpoint"`
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`
+	Rejected   int     `json:"rejected"`
+	Dropped    int     `json:"dropped"`
+	Throughput float64 `json:"throughput"`
+	ErrorRate  float64 `json:"error_rate"`
+	P50Millis  float64 `json:"p50_ms"`
+	P95Millis  float64 `json:"p95_ms"`
+	P99Millis  float64 `json:"p99_ms"`
+	MaxMillis  float64 `json:"max_ms"`
+}
+
+// PhaseReport is the outcome of a phase of the profile
+type PhaseReport struct {
+	Name            string          `json:"name"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Endpoints       []EndpointStats `json:"endpoints"`
+}
+
+// Report is the outcome of a profile, per phase and over the whole run
+type Report struct {
+	Profile    string          `json:"profile"`
+	Phases     []PhaseReport   `json:"phases"`
+	Total      []EndpointStats `json:"total"`
+	Violations []Violation     `json:"violations"`
+}
+
+// Budget is what an endpoint may not exceed in any phase
+type Budget struct {
+	Endpoint  string  `json:"endpoint"`
+	P99Millis float64 `json:"p99_ms"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// DefaultBudgets are the latency and error budgets of the endpoints the
+// profiles drive
+var DefaultBudgets = []Budget{
+	{Endpoint: EndpointDeposit, P99Millis: 250, ErrorRate: 0.001},
+	{Endpoint: EndpointTransfer, P99Millis: 300, ErrorRate: 0.001},
+	{Endpoint: EndpointBalance, P99Millis: 50, ErrorRate: 0.001},
+	{Endpoint: EndpointHistory, P99Millis: 200, ErrorRate: 0.001},
+	{Endpoint: EndpointStatement, P99Millis: 1000, ErrorRate: 0.005},
+}
+
+// Violation is a budget an endpoint exceeded during a phase
+type Violation struct {
+	Phase    string  `json:"phase"`
+	Endpoint string  `json:"endpoint"`
+	Metric   string  `json:"metric"`
+	Limit    float64 `json:"limit"`
+	Actual   float64 `json:"actual"`
+}
+
+// Evaluate records in the report the budgets exceeded in each phase and
+// returns them
+func (r *Report) Evaluate(budgets []Budget) []Violation {
+	r.Violations = []Violation{}
+	for _, phase := range r.Phases {
+		for _, stats := range phase.Endpoints {
+			for _, budget := range budgets {
+				if budget.Endpoint != stats.Endpoint || stats.Requests == 0 {
+					continue
+				}
+				if budget.P99Millis > 0 && stats.P99Millis > budget.P99Millis {
+					r.Violations = append(r.Violations, Violation{phase.Name, stats.Endpoint, "p99_ms", budget.P99Millis, stats.P99Millis})
+				}
+				if stats.ErrorRate > budget.ErrorRate {
+					r.Violations = append(r.Violations, Violation{phase.Name, stats.Endpoint, "error_rate", budget.ErrorRate, stats.ErrorRate})
+				}
+			}
+		}
+	}
+	return r.Violations
+}
+
+type endpointSamples struct {
+	latencies []time.Duration
+	errors    int
+	rejected  int
+	dropped   int
+}
+
+// recorder collects the outcome of the requests of a phase
+type recorder struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointSamples
+}
+
+func newRecorder() *recorder {
+	return &recorder{endpoints: map[string]*endpointSamples{}}
+}
+
+func (r *recorder) samples(endpoint string) *endpointSamples {
+	samples, ok := r.endpoints[endpoint]
+	if !ok {
+		samples = &endpointSamples{}
+		r.endpoints[endpoint] = samples
+	}
+	return samples
+}
+
+// record adds a request that got status, zero when it failed before a
+// response, after latency
+func (r *recorder) record(endpoint string, status int, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	samples := r.samples(endpoint)
+	samples.latencies = append(samples.latencies, latency)
+	switch {
+	case status == 0 || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests:
+		samples.errors++
+	case status >= http.StatusBadRequest:
+		samples.rejected++
+	}
+}
+
+func (r *recorder) drop(endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples(endpoint).dropped++
+}
+
+// merge adds the samples of other
+func (r *recorder) merge(other *recorder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for endpoint, samples := range other.endpoints {
+		merged := r.samples(endpoint)
+		merged.latencies = append(merged.latencies, samples.latencies...)
+		merged.errors += samples.errors
+		merged.rejected += samples.rejected
+		merged.dropped += samples.dropped
+	}
+}
+
+// stats sums up the samples of every endpoint over elapsed, by endpoint name
+func (r *recorder) stats(elapsed time.Duration) []EndpointStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]EndpointStats, 0, len(r.endpoints))
+	for endpoint, samples := range r.endpoints {
+		latencies := slices.Clone(samples.latencies)
+		slices.Sort(latencies)
+
+		s := EndpointStats{
+			Endpoint: endpoint,
+			Requests: len(latencies),
+			Errors:   samples.errors,
+			Rejected: samples.rejected,
+			Dropped:  samples.dropped,
+		}
+		if elapsed > 0 {
+			s.Throughput = float64(s.Requests) / elapsed.Seconds()
+		}
+		if s.Requests > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+			s.P50Millis = millis(percentile(latencies, 0.50))
+			s.P95Millis = millis(percentile(latencies, 0.95))
+			s.P99Millis = millis(percentile(latencies, 0.99))
+			s.MaxMillis = millis(latencies[len(latencies)-1])
+		}
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b EndpointStats) int {
+		if a.Endpoint < b.Endpoint {
+			return -1
+		}
+		if a.Endpoint > b.Endpoint {
+			return 1
+		}
+		return 0
+	})
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(float64(len(sorted))*p+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
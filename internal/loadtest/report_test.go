@@ -0,0 +1,86 @@
+package loadtest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderStats(t *testing.T) {
+	recorder := newRecorder()
+	for i := 1; i <= 100; i++ {
+		recorder.record(EndpointBalance, http.StatusOK, time.Duration(i)*time.Millisecond)
+	}
+	recorder.record(EndpointTransfer, http.StatusOK, 10*time.Millisecond)
+	recorder.record(EndpointTransfer, http.StatusUnprocessableEntity, 20*time.Millisecond)
+	recorder.record(EndpointTransfer, http.StatusTooManyRequests, 30*time.Millisecond)
+	recorder.record(EndpointTransfer, 0, 40*time.Millisecond)
+	recorder.drop(EndpointTransfer)
+
+	stats := recorder.stats(10 * time.Second)
+	require.Len(t, stats, 2)
+
+	balance := stats[0]
+	assert.Equal(t, EndpointBalance, balance.Endpoint)
+	assert.Equal(t, 100, balance.Requests)
+	assert.Equal(t, 10.0, balance.Throughput)
+	assert.Equal(t, 50.0, balance.P50Millis)
+	assert.Equal(t, 95.0, balance.P95Millis)
+	assert.Equal(t, 99.0, balance.P99Millis)
+	assert.Equal(t, 100.0, balance.MaxMillis)
+	assert.Zero(t, balance.ErrorRate)
+
+	// Rejected requests do not count against the error budget, throttled
+	// and failed ones do
+	transfer := stats[1]
+	assert.Equal(t, EndpointTransfer, transfer.Endpoint)
+	assert.Equal(t, 4, transfer.Requests)
+	assert.Equal(t, 2, transfer.Errors)
+	assert.Equal(t, 1, transfer.Rejected)
+	assert.Equal(t, 1, transfer.Dropped)
+	assert.Equal(t, 0.5, transfer.ErrorRate)
+}
+
+func TestRecorderMerge(t *testing.T) {
+	first, second := newRecorder(), newRecorder()
+	first.record(EndpointDeposit, http.StatusOK, time.Millisecond)
+	second.record(EndpointDeposit, http.StatusInternalServerError, 3*time.Millisecond)
+	second.drop(EndpointDeposit)
+
+	first.merge(second)
+
+	stats := first.stats(time.Second)
+	require.Len(t, stats, 1)
+	assert.Equal(t, 2, stats[0].Requests)
+	assert.Equal(t, 1, stats[0].Errors)
+	assert.Equal(t, 1, stats[0].Dropped)
+	assert.Equal(t, 3.0, stats[0].MaxMillis)
+}
+
+func TestEvaluate(t *testing.T) {
+	report := &Report{Phases: []PhaseReport{
+		{Name: "baseline", Endpoints: []EndpointStats{
+			{Endpoint: EndpointBalance, Requests: 100, P99Millis: 20},
+		}},
+		{Name: "salary-day", Endpoints: []EndpointStats{
+			{Endpoint: EndpointBalance, Requests: 100, P99Millis: 80},
+			{Endpoint: EndpointDeposit, Requests: 100, P99Millis: 100, ErrorRate: 0.02},
+			{Endpoint: EndpointHistory},
+		}},
+	}}
+
+	violations := report.Evaluate([]Budget{
+		{Endpoint: EndpointBalance, P99Millis: 50, ErrorRate: 0.001},
+		{Endpoint: EndpointDeposit, P99Millis: 250, ErrorRate: 0.001},
+		{Endpoint: EndpointHistory, P99Millis: 1},
+	})
+
+	assert.Equal(t, []Violation{
+		{Phase: "salary-day", Endpoint: EndpointBalance, Metric: "p99_ms", Limit: 50, Actual: 80},
+		{Phase: "salary-day", Endpoint: EndpointDeposit, Metric: "error_rate", Limit: 0.001, Actual: 0.02},
+	}, violations)
+	assert.Equal(t, violations, report.Violations)
+}
@@ -0,0 +1,217 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes the deployment a Runner drives
+type Config struct {
+	// BaseURL is the root of the API, such as https://wallet.staging
+	BaseURL string
+	// Token is sent as a bearer token; it must grant access to every user
+	Token string
+	// Users are the wallets requests are spread across
+	Users []string
+	// Concurrency caps the requests in flight; requests due above it are
+	// dropped, not queued, so a slow deployment does not slow the profile
+	Concurrency int
+	// Timeout bounds each request
+	Timeout time.Duration
+}
+
+// Runner replays profiles against a deployment. The requests of a profile
+// are sent at the rates of its phases whatever the latency of the
+// deployment, as month-end clients do.
+type Runner struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewRunner creates a Runner driving the deployment described by cfg
+func NewRunner(cfg Config) *Runner {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 64
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &Runner{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency},
+		},
+	}
+}
+
+// Seed deposits amount into every user, so transfers have funds to move
+func (r *Runner) Seed(ctx context.Context, amount string) error {
+	for _, user := range r.cfg.Users {
+		status, err := r.send(ctx, http.MethodPost, r.walletURL(user, "deposit"), fmt.Sprintf(`{"amount":%q}`, amount))
+		if err != nil {
+			return fmt.Errorf("seed %s: %w", user, err)
+		}
+		if status != http.StatusOK && status != http.StatusAccepted {
+			return fmt.Errorf("seed %s: status %d", user, status)
+		}
+	}
+	return nil
+}
+
+// Run replays profile until it ends or ctx is cancelled and reports the
+// requests it sent. Budgets are left to Report.Evaluate.
+func (r *Runner) Run(ctx context.Context, profile Profile) *Report {
+	report := &Report{Profile: profile.Name}
+	total := newRecorder()
+	start := time.Now()
+
+	for _, phase := range profile.Phases {
+		if ctx.Err() != nil {
+			break
+		}
+		phaseStart := time.Now()
+		recorder := r.runPhase(ctx, phase)
+		elapsed := time.Since(phaseStart)
+
+		report.Phases = append(report.Phases, PhaseReport{
+			Name:            phase.Name,
+			DurationSeconds: elapsed.Seconds(),
+			Endpoints:       recorder.stats(elapsed),
+		})
+		total.merge(recorder)
+	}
+
+	report.Total = total.stats(time.Since(start))
+	return report
+}
+
+// runPhase sends the loads of phase for its duration and waits for the
+// requests in flight
+func (r *Runner) runPhase(ctx context.Context, phase Phase) *recorder {
+	ctx, cancel := context.WithTimeout(ctx, phase.Duration)
+	defer cancel()
+
+	recorder := newRecorder()
+	inFlight := make(chan struct{}, r.cfg.Concurrency)
+	var wg sync.WaitGroup
+
+	var generators sync.WaitGroup
+	for _, load := range phase.Loads {
+		if load.Rate <= 0 {
+			continue
+		}
+		generators.Add(1)
+		go func() {
+			defer generators.Done()
+
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / load.Rate))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				select {
+				case inFlight <- struct{}{}:
+				default:
+					recorder.drop(load.Endpoint)
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer func() {
+						<-inFlight
+						wg.Done()
+					}()
+					r.hit(load.Endpoint, recorder)
+				}()
+			}
+		}()
+	}
+
+	generators.Wait()
+	wg.Wait()
+	return recorder
+}
+
+// hit sends a request to endpoint for a random user and records it. The
+// request outlives the phase so its latency is measured in full.
+func (r *Runner) hit(endpoint string, recorder *recorder) {
+	user := r.cfg.Users[rand.IntN(len(r.cfg.Users))]
+	method, target, body := r.request(endpoint, user)
+
+	start := time.Now()
+	status, _ := r.send(context.Background(), method, target, body)
+	recorder.record(endpoint, status, time.Since(start))
+}
+
+// request returns the method, URL and body of a request to endpoint on
+// behalf of user
+func (r *Runner) request(endpoint, user string) (string, string, string) {
+	switch endpoint {
+	case EndpointDeposit:
+		return http.MethodPost, r.walletURL(user, "deposit"), fmt.Sprintf(`{"amount":"%d.00"}`, 10+rand.IntN(990))
+	case EndpointTransfer:
+		receiver := r.cfg.Users[rand.IntN(len(r.cfg.Users))]
+		for len(r.cfg.Users) > 1 && receiver == user {
+			receiver = r.cfg.Users[rand.IntN(len(r.cfg.Users))]
+		}
+		return http.MethodPost, r.walletURL(user, "transfer"), fmt.Sprintf(`{"amount":"%d.00","receiver_id":%q}`, 1+rand.IntN(20), receiver)
+	case EndpointHistory:
+		return http.MethodGet, r.walletURL(user, "transactions"), `{"limit":20}`
+	case EndpointStatement:
+		// The statement of the month that just closed
+		now := time.Now().UTC()
+		to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		from := to.AddDate(0, -1, 0)
+		query := url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+		return http.MethodGet, r.walletURL(user, "statements") + "?" + query.Encode(), ""
+	default:
+		return http.MethodGet, r.walletURL(user, "balance"), ""
+	}
+}
+
+func (r *Runner) walletURL(user, path string) string {
+	return r.cfg.BaseURL + "/api/v1/wallets/" + url.PathEscape(user) + "/" + path
+}
+
+// send sends a request and reads the whole response, returning its status
+func (r *Runner) send(ctx context.Context, method, target, body string) (int, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,119 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wallet serves the endpoints the profiles drive and records the requests
+type wallet struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (w *wallet) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.mu.Lock()
+	w.requests = append(w.requests, r)
+	w.bodies = append(w.bodies, string(body))
+	w.mu.Unlock()
+
+	switch {
+	case r.Header.Get("Authorization") != "Bearer token":
+		rw.WriteHeader(http.StatusUnauthorized)
+	case strings.HasSuffix(r.URL.Path, "/transfer"):
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	case strings.HasSuffix(r.URL.Path, "/statements"):
+		rw.WriteHeader(http.StatusInternalServerError)
+	default:
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestRunnerSeed(t *testing.T) {
+	handler := &wallet{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	runner := NewRunner(Config{BaseURL: server.URL + "/", Token: "token", Users: []string{"alice", "bob"}})
+	require.NoError(t, runner.Seed(t.Context(), "1000.00"))
+
+	require.Len(t, handler.requests, 2)
+	assert.Equal(t, http.MethodPost, handler.requests[0].Method)
+	assert.Equal(t, "/api/v1/wallets/alice/deposit", handler.requests[0].URL.Path)
+	assert.Equal(t, "/api/v1/wallets/bob/deposit", handler.requests[1].URL.Path)
+	assert.JSONEq(t, `{"amount":"1000.00"}`, handler.bodies[0])
+
+	runner = NewRunner(Config{BaseURL: server.URL, Users: []string{"alice"}})
+	assert.ErrorContains(t, runner.Seed(t.Context(), "1000.00"), "status 401")
+}
+
+func TestRunnerRun(t *testing.T) {
+	handler := &wallet{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	runner := NewRunner(Config{BaseURL: server.URL, Token: "token", Users: []string{"alice", "bob"}})
+	report := runner.Run(t.Context(), Profile{Name: "test", Phases: []Phase{
+		{Name: "deposits", Duration: 200 * time.Millisecond, Loads: []Load{{Endpoint: EndpointDeposit, Rate: 50}}},
+		{Name: "mixed", Duration: 200 * time.Millisecond, Loads: []Load{
+			{Endpoint: EndpointTransfer, Rate: 50},
+			{Endpoint: EndpointStatement, Rate: 50},
+			{Endpoint: EndpointHistory, Rate: 0},
+		}},
+	}})
+
+	assert.Equal(t, "test", report.Profile)
+	require.Len(t, report.Phases, 2)
+	assert.Equal(t, "deposits", report.Phases[0].Name)
+	require.Len(t, report.Phases[0].Endpoints, 1)
+	assert.Equal(t, EndpointDeposit, report.Phases[0].Endpoints[0].Endpoint)
+	assert.NotZero(t, report.Phases[0].Endpoints[0].Requests)
+	assert.Zero(t, report.Phases[0].Endpoints[0].Errors)
+
+	stats := map[string]EndpointStats{}
+	for _, s := range report.Phases[1].Endpoints {
+		stats[s.Endpoint] = s
+	}
+	require.Len(t, stats, 2)
+	assert.Equal(t, stats[EndpointTransfer].Requests, stats[EndpointTransfer].Rejected)
+	assert.Zero(t, stats[EndpointTransfer].Errors)
+	assert.Equal(t, 1.0, stats[EndpointStatement].ErrorRate)
+
+	total := 0
+	for _, s := range report.Total {
+		total += s.Requests
+	}
+	assert.Len(t, handler.requests, total)
+
+	for i, r := range handler.requests {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/transfer"):
+			var body struct {
+				Amount     string `json:"amount"`
+				ReceiverID string `json:"receiver_id"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(handler.bodies[i]), &body))
+			// Users never pay themselves
+			assert.NotEqual(t, strings.Split(r.URL.Path, "/")[4], body.ReceiverID)
+			assert.NotEmpty(t, body.Amount)
+		case strings.HasSuffix(r.URL.Path, "/statements"):
+			from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+			require.NoError(t, err)
+			to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+			require.NoError(t, err)
+			assert.Equal(t, to, from.AddDate(0, 1, 0))
+			assert.Equal(t, 1, to.Day())
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package masking
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/auth"
+)
+
+// DefaultPolicies masks counterparties and large balances for support staff.
+// Auditors and admins see everything.
+const DefaultPolicies = `{
+	"support": {
+		"mask_fields": ["from_user_id", "to_user_id", "counterparty_id", "source_user_id", "target_user_id"],
+		"balance_fields": ["balance", "held_balance", "available_balance"],
+		"balance_ceiling": "10000"
+	}
+}`
+
+// Policy describes what a role may not see in API responses. Fields are
+// matched by their JSON name anywhere in the response, so one policy covers
+// every endpoint.
+type Policy struct {
+	// MaskFields hold user IDs that are masked unless they identify the
+	// wallet being viewed
+	MaskFields []string `json:"mask_fields,omitempty"`
+
+	// BalanceFields are replaced with null when above BalanceCeiling
+	BalanceFields  []string         `json:"balance_fields,omitempty"`
+	BalanceCeiling *decimal.Decimal `json:"balance_ceiling,omitempty"`
+}
+
+// Policies maps roles to the policy applied to their responses
+type Policies map[string]Policy
+
+// Parse reads policies from their JSON configuration
+func Parse(config string) (Policies, error) {
+	var policies Policies
+	if err := json.Unmarshal([]byte(config), &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// For returns the policy of the first role in roles that has one. Admins are
+// never masked.
+func (p Policies) For(roles []string) (Policy, bool) {
+	if slices.Contains(roles, auth.RoleAdmin) {
+		return Policy{}, false
+	}
+	for _, role := range roles {
+		if policy, ok := p[role]; ok {
+			return policy, true
+		}
+	}
+	return Policy{}, false
+}
+
+// Apply masks the JSON document body. ownerID is the user ID of the wallet
+// being viewed, which is never masked; it is empty outside wallet routes.
+func (p Policy) Apply(body []byte, ownerID string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.walk(document, ownerID))
+}
+
+func (p Policy) walk(value interface{}, ownerID string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			switch {
+			case slices.Contains(p.MaskFields, key):
+				if id, ok := field.(string); ok && id != ownerID {
+					value[key] = Mask(id)
+				}
+			case slices.Contains(p.BalanceFields, key):
+				if p.aboveCeiling(field) {
+					value[key] = nil
+				}
+			default:
+				value[key] = p.walk(field, ownerID)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = p.walk(item, ownerID)
+		}
+	}
+	return value
+}
+
+// aboveCeiling reports whether a balance, encoded as a JSON string or number,
+// exceeds the ceiling. Values that are not amounts are left alone.
+func (p Policy) aboveCeiling(field interface{}) bool {
+	if p.BalanceCeiling == nil {
+		return false
+	}
+
+	var amount decimal.Decimal
+	var err error
+	switch field := field.(type) {
+	case string:
+		amount, err = decimal.NewFromString(field)
+	case json.Number:
+		amount, err = decimal.NewFromString(field.String())
+	default:
+		return false
+	}
+	return err == nil && amount.GreaterThan(*p.BalanceCeiling)
+}
+
+// Mask hides all but the first and last two characters of id
+func Mask(id string) string {
+	runes := []rune(id)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
@@ -0,0 +1,63 @@
+package masking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/auth"
+)
+
+func TestPolicies_For(t *testing.T) {
+	policies, err := Parse(DefaultPolicies)
+	require.NoError(t, err)
+
+	_, ok := policies.For(nil)
+	assert.False(t, ok)
+	_, ok = policies.For([]string{auth.RoleAuditor})
+	assert.False(t, ok)
+	_, ok = policies.For([]string{auth.RoleSupport, auth.RoleAdmin})
+	assert.False(t, ok)
+
+	policy, ok := policies.For([]string{auth.RoleSupport})
+	assert.True(t, ok)
+	assert.Equal(t, "10000", policy.BalanceCeiling.String())
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	policies, err := Parse(DefaultPolicies)
+	require.NoError(t, err)
+	policy := policies[auth.RoleSupport]
+
+	t.Run("counterparties are masked", func(t *testing.T) {
+		body := `{"transactions":[
+			{"from_user_id":"user1","to_user_id":"merchant42","amount":"5"},
+			{"from_user_id":"payroll01","to_user_id":"user1","amount":"2500"}
+		]}`
+
+		masked, err := policy.Apply([]byte(body), "user1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"transactions":[
+			{"from_user_id":"user1","to_user_id":"me******42","amount":"5"},
+			{"from_user_id":"pa*****01","to_user_id":"user1","amount":"2500"}
+		]}`, string(masked))
+	})
+
+	t.Run("balances above the ceiling are hidden", func(t *testing.T) {
+		masked, err := policy.Apply([]byte(`{"balance":"25000.5","held_balance":"0","available_balance":25000.5}`), "user1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"balance":null,"held_balance":"0","available_balance":null}`, string(masked))
+	})
+
+	t.Run("not JSON", func(t *testing.T) {
+		_, err := policy.Apply([]byte("plain text"), "user1")
+		assert.Error(t, err)
+	})
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "us**01", Mask("user01"))
+	assert.Equal(t, "****", Mask("abcd"))
+	assert.Equal(t, "", Mask(""))
+}
@@ -0,0 +1,405 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Operation outcomes
+const (
+	OutcomeSuccess             = "success"
+	OutcomeInsufficientBalance = "insufficient_balance"
+	OutcomeRejected            = "rejected"
+	OutcomeError               = "error"
+)
+
+// Metrics holds the Prometheus collectors of the service. Every method is a
+// no-op on a nil *Metrics, so instrumentation is disabled by not creating
+// one.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	operations      *prometheus.CounterVec
+	cacheLookups    *prometheus.CounterVec
+	dbTransactions  *prometheus.HistogramVec
+	invalidationLag *prometheus.HistogramVec
+	invalidationErr *prometheus.CounterVec
+	invalidations   *prometheus.CounterVec
+	retries         *prometheus.CounterVec
+	pendingRetries  prometheus.Gauge
+	payoutDuration  *prometheus.HistogramVec
+	mismatches      prometheus.Gauge
+	cacheMemoryUsed prometheus.Gauge
+	cacheMemoryMax  prometheus.Gauge
+	cachePressure   prometheus.Gauge
+	cacheEvictions  *prometheus.CounterVec
+	cacheWarms      *prometheus.CounterVec
+	cacheCircuit    prometheus.Gauge
+	cacheRejections prometheus.Counter
+	webhookBacklog  prometheus.Gauge
+	webhookLag      prometheus.Gauge
+	webhookPaused   prometheus.Gauge
+}
+
+// New creates the collectors on a dedicated registry, together with the Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_operations_total",
+			Help: "Deposits, withdrawals and transfers by outcome.",
+		}, []string{"operation", "outcome"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_balance_cache_lookups_total",
+			Help: "Balance cache lookups by result (hit or miss).",
+		}, []string{"result"}),
+		dbTransactions: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_db_transaction_duration_seconds",
+			Help:    "Duration of the database transaction of each wallet operation.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+		invalidationLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_cache_invalidation_lag_seconds",
+			Help:    "Time from the commit of a wallet operation until its cached balances are invalidated.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		invalidationErr: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_invalidation_failures_total",
+			Help: "Committed wallet operations whose cached balances could not be invalidated.",
+		}, []string{"operation"}),
+		invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_invalidations_total",
+			Help: "Cached balance invalidations by result (success or failure).",
+		}, []string{"result"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_invalidation_retries_total",
+			Help: "Retries of failed cached balance invalidations by result (success or failure).",
+		}, []string{"result"}),
+		pendingRetries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_cache_invalidations_pending",
+			Help: "Cached balances whose invalidation failed and is waiting to be retried.",
+		}),
+		payoutDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_payout_duration_seconds",
+			Help:    "Latency of payout provider calls by provider and outcome.",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"provider", "outcome"}),
+		mismatches: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_reconciliation_mismatches",
+			Help: "Wallets whose stored balance differed from their ledger in the latest completed reconciliation.",
+		}),
+		cacheMemoryUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_cache_memory_used_bytes",
+			Help: "Memory used by the Redis balance cache.",
+		}),
+		cacheMemoryMax: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_cache_memory_limit_bytes",
+			Help: "Memory the Redis balance cache is guarded against, zero when unlimited.",
+		}),
+		cachePressure: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_cache_memory_pressure",
+			Help: "Memory pressure of the Redis balance cache: 0 normal, 1 high (reduced TTLs), 2 critical (evicting).",
+		}),
+		cacheEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_evictions_total",
+			Help: "Wallets whose cached balance was evicted by the memory guard, by reason (idle or pressure).",
+		}, []string{"reason"}),
+		cacheWarms: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_warms_total",
+			Help: "Balances of hot wallets refreshed in the cache before they expired, by result (success or failure).",
+		}, []string{"result"}),
+		cacheCircuit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_cache_circuit_state",
+			Help: "State of the circuit breaker of the balance cache: 0 closed, 1 half-open, 2 open (calls fail fast).",
+		}),
+		cacheRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_cache_circuit_rejections_total",
+			Help: "Balance cache calls failed fast by the open circuit breaker.",
+		}),
+		webhookBacklog: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_webhook_backlog_events",
+			Help: "Events recorded in the outbox and not delivered to the event webhook yet.",
+		}),
+		webhookLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_webhook_backlog_lag_seconds",
+			Help: "Age of the oldest event not delivered to the event webhook yet.",
+		}),
+		webhookPaused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "wallet_webhook_paused",
+			Help: "1 while deliveries to the event webhook are paused, 0 otherwise.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.operations,
+		m.cacheLookups,
+		m.dbTransactions,
+		m.invalidationLag,
+		m.invalidationErr,
+		m.invalidations,
+		m.retries,
+		m.pendingRetries,
+		m.payoutDuration,
+		m.mismatches,
+		m.cacheMemoryUsed,
+		m.cacheMemoryMax,
+		m.cachePressure,
+		m.cacheEvictions,
+		m.cacheWarms,
+		m.cacheCircuit,
+		m.cacheRejections,
+		m.webhookBacklog,
+		m.webhookLag,
+		m.webhookPaused,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry returns the registry the collectors are registered on
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// ObserveRequest records the latency of an HTTP request. route is the route
+// pattern, not the raw path, to keep label cardinality bounded.
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RecordOperation counts a wallet operation with its outcome
+func (m *Metrics) RecordOperation(operation, outcome string) {
+	if m == nil {
+		return
+	}
+	m.operations.WithLabelValues(operation, outcome).Inc()
+}
+
+// RecordCacheLookup counts a balance cache hit or miss
+func (m *Metrics) RecordCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveDBTransaction records how long the database transaction of a wallet
+// operation took
+func (m *Metrics) ObserveDBTransaction(operation string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.dbTransactions.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ObserveCacheInvalidation records how long after the commit of a wallet
+// operation its cached balances were invalidated. A failed invalidation is
+// counted instead: the stale balance is served until the cache TTL expires.
+func (m *Metrics) ObserveCacheInvalidation(operation string, lag time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.invalidationErr.WithLabelValues(operation).Inc()
+		return
+	}
+	m.invalidationLag.WithLabelValues(operation).Observe(lag.Seconds())
+}
+
+// RecordCacheInvalidation counts an invalidation of a cached balance by
+// whether it succeeded
+func (m *Metrics) RecordCacheInvalidation(err error) {
+	if m == nil {
+		return
+	}
+	m.invalidations.WithLabelValues(invalidationResult(err)).Inc()
+}
+
+// RecordInvalidationRetry counts a retry of a failed invalidation by whether
+// it succeeded
+func (m *Metrics) RecordInvalidationRetry(err error) {
+	if m == nil {
+		return
+	}
+	m.retries.WithLabelValues(invalidationResult(err)).Inc()
+}
+
+// SetPendingInvalidations records the number of failed invalidations waiting
+// to be retried
+func (m *Metrics) SetPendingInvalidations(count int) {
+	if m == nil {
+		return
+	}
+	m.pendingRetries.Set(float64(count))
+}
+
+func invalidationResult(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// ObservePayout records how long a payout provider took to send, reject or
+// fail a payout
+func (m *Metrics) ObservePayout(provider, outcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.payoutDuration.WithLabelValues(provider, outcome).Observe(duration.Seconds())
+}
+
+// SetReconciliationMismatches records the number of wallets whose balance
+// differed from their ledger in the latest completed reconciliation
+func (m *Metrics) SetReconciliationMismatches(count int) {
+	if m == nil {
+		return
+	}
+	m.mismatches.Set(float64(count))
+}
+
+// ObserveCacheMemory records the memory used by the balance cache, the limit
+// it is guarded against and the resulting pressure level
+func (m *Metrics) ObserveCacheMemory(used, limit int64, pressure int) {
+	if m == nil {
+		return
+	}
+	m.cacheMemoryUsed.Set(float64(used))
+	m.cacheMemoryMax.Set(float64(limit))
+	m.cachePressure.Set(float64(pressure))
+}
+
+// RecordCacheEvictions counts the wallets whose cached balance was evicted
+func (m *Metrics) RecordCacheEvictions(reason string, count int) {
+	if m == nil {
+		return
+	}
+	m.cacheEvictions.WithLabelValues(reason).Add(float64(count))
+}
+
+// RecordCacheWarm counts a balance refreshed, or failing to be refreshed, by
+// the cache warmer
+func (m *Metrics) RecordCacheWarm(err error) {
+	if m == nil {
+		return
+	}
+	m.cacheWarms.WithLabelValues(invalidationResult(err)).Inc()
+}
+
+// SetCacheCircuitState records the state of the circuit breaker of the
+// balance cache
+func (m *Metrics) SetCacheCircuitState(state int) {
+	if m == nil {
+		return
+	}
+	m.cacheCircuit.Set(float64(state))
+}
+
+// RecordCacheCircuitRejection counts a cache call failed fast by the open
+// circuit breaker
+func (m *Metrics) RecordCacheCircuitRejection() {
+	if m == nil {
+		return
+	}
+	m.cacheRejections.Inc()
+}
+
+// ObserveWebhookBacklog records the events waiting for delivery to the event
+// webhook, the age of the oldest and whether deliveries are paused
+func (m *Metrics) ObserveWebhookBacklog(events, lagSeconds int64, paused bool) {
+	if m == nil {
+		return
+	}
+	m.webhookBacklog.Set(float64(events))
+	m.webhookLag.Set(float64(lagSeconds))
+	pausedValue := 0.0
+	if paused {
+		pausedValue = 1
+	}
+	m.webhookPaused.Set(pausedValue)
+}
+
+// DBPoolStats is a snapshot of the database connection pool
+type DBPoolStats struct {
+	MaxConns      int32
+	TotalConns    int32
+	IdleConns     int32
+	AcquiredConns int32
+	// Acquisitions so far, those that had to wait for a connection and the
+	// time spent acquiring
+	Acquires      int64
+	EmptyAcquires int64
+	AcquireWait   time.Duration
+}
+
+// WatchDBPool exports the connection pool returned by stats, which is read
+// on every scrape
+func (m *Metrics) WatchDBPool(stats func() DBPoolStats) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(dbPoolCollector{stats: stats})
+}
+
+var (
+	dbPoolMaxConns = prometheus.NewDesc("wallet_db_pool_max_connections",
+		"Connections the database pool may open.", nil, nil)
+	dbPoolConns = prometheus.NewDesc("wallet_db_pool_connections",
+		"Open database connections by state.", []string{"state"}, nil)
+	dbPoolAcquires = prometheus.NewDesc("wallet_db_pool_acquires_total",
+		"Connections acquired from the database pool.", nil, nil)
+	dbPoolEmptyAcquires = prometheus.NewDesc("wallet_db_pool_empty_acquires_total",
+		"Acquisitions that waited for a connection because none was idle.", nil, nil)
+	dbPoolAcquireWait = prometheus.NewDesc("wallet_db_pool_acquire_wait_seconds_total",
+		"Time spent acquiring database connections.", nil, nil)
+)
+
+type dbPoolCollector struct {
+	stats func() DBPoolStats
+}
+
+func (c dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbPoolMaxConns
+	ch <- dbPoolConns
+	ch <- dbPoolAcquires
+	ch <- dbPoolEmptyAcquires
+	ch <- dbPoolAcquireWait
+}
+
+func (c dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(dbPoolMaxConns, prometheus.GaugeValue, float64(stats.MaxConns))
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue, float64(stats.IdleConns), "idle")
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue, float64(stats.AcquiredConns), "acquired")
+	// Connections being established count towards the total only
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue,
+		float64(stats.TotalConns-stats.IdleConns-stats.AcquiredConns), "constructing")
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquires, prometheus.CounterValue, float64(stats.Acquires))
+	ch <- prometheus.MustNewConstMetric(dbPoolEmptyAcquires, prometheus.CounterValue, float64(stats.EmptyAcquires))
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquireWait, prometheus.CounterValue, stats.AcquireWait.Seconds())
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.ObserveRequest("/api/v1/wallets/:userID/balance", "GET", 200, 5*time.Millisecond)
+	m.RecordOperation("withdraw", OutcomeInsufficientBalance)
+	m.RecordCacheLookup(true)
+	m.RecordCacheLookup(false)
+	m.RecordCacheLookup(false)
+	m.ObserveDBTransaction("deposit", 2*time.Millisecond)
+	m.ObserveCacheInvalidation("transfer", time.Millisecond, nil)
+	m.ObserveCacheInvalidation("transfer", time.Millisecond, errors.New("connection refused"))
+	m.RecordCacheInvalidation(nil)
+	m.RecordCacheInvalidation(errors.New("connection refused"))
+	m.RecordInvalidationRetry(nil)
+	m.SetPendingInvalidations(3)
+	m.ObservePayout("bank_a", OutcomeRejected, 300*time.Millisecond)
+	m.SetReconciliationMismatches(2)
+	m.ObserveCacheMemory(900, 1000, 2)
+	m.RecordCacheEvictions("pressure", 500)
+	m.RecordCacheWarm(nil)
+	m.ObserveWebhookBacklog(1200, 60, true)
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.GetCounter() != nil:
+				key := family.GetName()
+				for _, label := range metric.GetLabel() {
+					key += "," + label.GetValue()
+				}
+				values[key] = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				values[family.GetName()] = metric.GetGauge().GetValue()
+			case metric.GetHistogram() != nil:
+				values[family.GetName()] += float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	assert.Equal(t, 1.0, values["http_request_duration_seconds"])
+	assert.Equal(t, 1.0, values["wallet_operations_total,withdraw,insufficient_balance"])
+	assert.Equal(t, 1.0, values["wallet_balance_cache_lookups_total,hit"])
+	assert.Equal(t, 2.0, values["wallet_balance_cache_lookups_total,miss"])
+	assert.Equal(t, 1.0, values["wallet_db_transaction_duration_seconds"])
+	assert.Equal(t, 1.0, values["wallet_cache_invalidation_lag_seconds"])
+	assert.Equal(t, 1.0, values["wallet_cache_invalidation_failures_total,transfer"])
+	assert.Equal(t, 1.0, values["wallet_cache_invalidations_total,success"])
+	assert.Equal(t, 1.0, values["wallet_cache_invalidations_total,failure"])
+	assert.Equal(t, 1.0, values["wallet_cache_invalidation_retries_total,success"])
+	assert.Equal(t, 3.0, values["wallet_cache_invalidations_pending"])
+	assert.Equal(t, 1.0, values["wallet_payout_duration_seconds"])
+	assert.Equal(t, 2.0, values["wallet_reconciliation_mismatches"])
+	assert.Equal(t, 900.0, values["wallet_cache_memory_used_bytes"])
+	assert.Equal(t, 2.0, values["wallet_cache_memory_pressure"])
+	assert.Equal(t, 500.0, values["wallet_cache_evictions_total,pressure"])
+	assert.Equal(t, 1.0, values["wallet_cache_warms_total,success"])
+	assert.Equal(t, 1200.0, values["wallet_webhook_backlog_events"])
+	assert.Equal(t, 60.0, values["wallet_webhook_backlog_lag_seconds"])
+	assert.Equal(t, 1.0, values["wallet_webhook_paused"])
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.True(t, strings.Contains(recorder.Body.String(), `route="/api/v1/wallets/:userID/balance"`))
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	// Disabled metrics record nothing and do not panic
+	m.ObserveRequest("/", "GET", 200, time.Millisecond)
+	m.RecordOperation("deposit", OutcomeSuccess)
+	m.RecordCacheLookup(true)
+	m.ObserveDBTransaction("deposit", time.Millisecond)
+	m.ObserveCacheInvalidation("deposit", time.Millisecond, nil)
+	m.RecordCacheInvalidation(nil)
+	m.RecordInvalidationRetry(nil)
+	m.SetPendingInvalidations(1)
+	m.ObservePayout("bank_a", OutcomeSuccess, time.Millisecond)
+	m.SetReconciliationMismatches(1)
+	m.ObserveCacheMemory(1, 1, 0)
+	m.RecordCacheEvictions("idle", 1)
+	m.RecordCacheWarm(nil)
+	m.ObserveWebhookBacklog(1, 1, false)
+	m.WatchDBPool(func() DBPoolStats { return DBPoolStats{} })
+}
+
+func TestMetrics_DBPool(t *testing.T) {
+	m := New()
+	m.WatchDBPool(func() DBPoolStats {
+		return DBPoolStats{
+			MaxConns: 25, TotalConns: 10, IdleConns: 3, AcquiredConns: 6,
+			Acquires: 400, EmptyAcquires: 12, AcquireWait: 1500 * time.Millisecond,
+		}
+	})
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				key += "," + label.GetValue()
+			}
+			if metric.GetCounter() != nil {
+				values[key] = metric.GetCounter().GetValue()
+			} else if metric.GetGauge() != nil {
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	assert.Equal(t, 25.0, values["wallet_db_pool_max_connections"])
+	assert.Equal(t, 3.0, values["wallet_db_pool_connections,idle"])
+	assert.Equal(t, 6.0, values["wallet_db_pool_connections,acquired"])
+	assert.Equal(t, 1.0, values["wallet_db_pool_connections,constructing"])
+	assert.Equal(t, 400.0, values["wallet_db_pool_acquires_total"])
+	assert.Equal(t, 12.0, values["wallet_db_pool_empty_acquires_total"])
+	assert.Equal(t, 1.5, values["wallet_db_pool_acquire_wait_seconds_total"])
+}
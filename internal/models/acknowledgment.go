@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TransferAcknowledgment is the receiver of a transfer acknowledging it, with
+// an optional message to the sender
+type TransferAcknowledgment struct {
+	TransactionID  string          `json:"transaction_id"`
+	FromUserID     string          `json:"from_user_id"`
+	ToUserID       string          `json:"to_user_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Message        *string         `json:"message,omitempty"`
+	AcknowledgedAt time.Time       `json:"acknowledged_at"`
+}
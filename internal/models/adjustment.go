@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Balance adjustment reason codes
+const (
+	AdjustmentCorrection = "correction"
+	AdjustmentChargeback = "chargeback"
+	AdjustmentFeeRefund  = "fee_refund"
+	AdjustmentGoodwill   = "goodwill"
+	AdjustmentWriteOff   = "write_off"
+)
+
+// AdjustmentReasonCodes lists the reason codes a balance adjustment may carry
+var AdjustmentReasonCodes = []string{
+	AdjustmentCorrection,
+	AdjustmentChargeback,
+	AdjustmentFeeRefund,
+	AdjustmentGoodwill,
+	AdjustmentWriteOff,
+}
+
+// BalanceAdjustment is the audit record of a manual change to a wallet
+// balance. Amount is signed: positive amounts credit the wallet, negative
+// amounts debit it. The change itself is the adjustment transaction
+// TransactionID.
+type BalanceAdjustment struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	ReasonCode    string          `json:"reason_code"`
+	Note          string          `json:"note,omitempty"`
+	Actor         string          `json:"actor"`
+	TransactionID string          `json:"transaction_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
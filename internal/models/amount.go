@@ -0,0 +1,32 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Amounts are stored in NUMERIC(20, 8) columns
+const (
+	MaxAmountIntegerDigits = 12
+	MaxAmountScale         = 8
+)
+
+// FitsLedger reports whether value has at most MaxAmountIntegerDigits
+// integer digits and MaxAmountScale decimals. The exponent is checked before
+// the value is rescaled, which takes minutes for amounts such as
+// 1e999999999.
+func FitsLedger(value decimal.Decimal) bool {
+	if value.IsZero() {
+		return true
+	}
+	digits := int64(value.NumDigits())
+	exponent := int64(value.Exponent())
+	if digits+exponent > MaxAmountIntegerDigits {
+		return false
+	}
+	if exponent >= -MaxAmountScale {
+		return true
+	}
+	// Trailing zeros aside, the decimals of value end at its exponent
+	if -exponent-MaxAmountScale > digits {
+		return false
+	}
+	return value.Truncate(MaxAmountScale).Equal(value)
+}
@@ -0,0 +1,32 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// MonthlyAnalytics summarises the ledger of a wallet over a calendar month
+// (UTC). Income counts deposits and incoming transfers, Spending outgoing
+// transfers and withdrawals, by category in Categories. Net is the change of
+// the balance over the month, adjustments included.
+type MonthlyAnalytics struct {
+	UserID     string             `json:"user_id"`
+	Month      string             `json:"month"`
+	Income     decimal.Decimal    `json:"income"`
+	Spending   decimal.Decimal    `json:"spending"`
+	Fees       decimal.Decimal    `json:"fees"`
+	Net        decimal.Decimal    `json:"net"`
+	Categories []CategorySpending `json:"categories"`
+	RoundUps   RoundUpSummary     `json:"round_ups"`
+}
+
+// CategorySpending totals the spending of one category. Category is null
+// for transactions no categorization rule matched.
+type CategorySpending struct {
+	Category *string         `json:"category"`
+	Count    int             `json:"count"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// RoundUpSummary totals the round-ups saved over a period
+type RoundUpSummary struct {
+	Count int             `json:"count"`
+	Saved decimal.Decimal `json:"saved"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey authenticates a service-to-service caller as Subject with Roles,
+// limited to Scopes. The secret itself is never stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	SecretHash []byte     `json:"-"`
+	Subject    string     `json:"subject"`
+	Roles      []string   `json:"roles"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
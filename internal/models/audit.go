@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Audited actions
+const (
+	AuditDeposit      = "deposit"
+	AuditWithdrawal   = "withdrawal"
+	AuditTransfer     = "transfer"
+	AuditFee          = "fee"
+	AuditAdjustment   = "adjustment"
+	AuditLimitSet     = "limit_set"
+	AuditLimitRemoved = "limit_removed"
+)
+
+// AuditActions lists the audited actions
+var AuditActions = []string{AuditDeposit, AuditWithdrawal, AuditTransfer, AuditFee, AuditAdjustment, AuditLimitSet, AuditLimitRemoved}
+
+// AuditValues are the audited values of a wallet or limit, such as
+// "balance" or "value"
+type AuditValues map[string]decimal.Decimal
+
+// AuditEntry records one state change: the wallet it changed, the values
+// before and after it and who made it from where. A transfer records an
+// entry for each of its wallets. Target names the limit of a limit change
+// as "<operation>.<kind>"; default limits have no user ID. Before is nil
+// for a newly set limit and After for a removed one.
+type AuditEntry struct {
+	ID            int64       `json:"id"`
+	Action        string      `json:"action"`
+	UserID        string      `json:"user_id,omitempty"`
+	Target        string      `json:"target,omitempty"`
+	TransactionID string      `json:"transaction_id,omitempty"`
+	Before        AuditValues `json:"before"`
+	After         AuditValues `json:"after"`
+	Actor         string      `json:"actor,omitempty"`
+	Channel       string      `json:"channel,omitempty"`
+	ClientIP      string      `json:"client_ip,omitempty"`
+	RequestID     string      `json:"request_id,omitempty"`
+	Reason        string      `json:"reason,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// AuditFilter narrows down an audit log query. Nil fields match every
+// entry. Entries are listed newest first; Before continues a listing below
+// the given entry ID.
+type AuditFilter struct {
+	UserID    *string
+	Action    *string
+	Actor     *string
+	RequestID *string
+	From      *time.Time
+	To        *time.Time
+	Before    int64
+	Limit     int
+}
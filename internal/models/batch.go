@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Batch transfer modes
+const (
+	BatchModeBestEffort = "best_effort"
+	BatchModeAtomic     = "atomic"
+)
+
+// Batch item statuses
+const (
+	BatchItemSucceeded = "succeeded"
+	BatchItemFailed    = "failed"
+)
+
+// TransferBatch is the summary record of a batch transfer request
+type TransferBatch struct {
+	ID             string              `json:"id"`
+	SenderID       string              `json:"sender_id"`
+	Mode           string              `json:"mode"`
+	TotalCount     int                 `json:"total_count"`
+	SucceededCount int                 `json:"succeeded_count"`
+	FailedCount    int                 `json:"failed_count"`
+	TotalAmount    decimal.Decimal     `json:"total_amount"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Items          []TransferBatchItem `json:"items"`
+}
+
+// TransferBatchItem is the outcome of a single transfer within a batch
+type TransferBatchItem struct {
+	Index      int             `json:"index"`
+	ReceiverID string          `json:"receiver_id"`
+	Amount     decimal.Decimal `json:"amount"`
+	Status     string          `json:"status"`
+	ErrorCode  *string         `json:"error_code,omitempty"`
+	Error      *string         `json:"error,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// System accounts hold the funds the service itself owns
+const (
+	SystemAccountFees     = "system:fees"
+	SystemAccountTreasury = "system:treasury"
+	SystemAccountSuspense = "system:suspense"
+)
+
+// SystemAccountLabel marks system accounts in wallet listings
+const SystemAccountLabel = "system"
+
+// Currency is a currency the service supports. Decimals cannot exceed the 8
+// decimal places amounts are stored with.
+type Currency struct {
+	Code     string `json:"code"`
+	Decimals int    `json:"decimals"`
+}
+
+// BootstrapPlan lists the records a new environment starts with
+type BootstrapPlan struct {
+	SystemAccounts []string
+	Currencies     []Currency
+	Limits         []Limit
+}
+
+// BootstrapResult counts the records a bootstrap created. Records that
+// already existed are left unchanged and not counted.
+type BootstrapResult struct {
+	SystemAccounts int `json:"system_accounts"`
+	Currencies     int `json:"currencies"`
+	Limits         int `json:"limits"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// CacheMemoryUsage is the memory used by the cache with its configured
+// maximum, zero when unlimited, and the policy applied once it is reached
+type CacheMemoryUsage struct {
+	Used   int64
+	Max    int64
+	Policy string
+}
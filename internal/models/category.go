@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CategorizationRule tags the transactions matching all of its criteria with
+// Category. Unset criteria match any transaction; at least one is set.
+// CounterpartyPattern is a SQL LIKE pattern matched against both user IDs of
+// the transaction.
+type CategorizationRule struct {
+	ID                  string           `json:"id"`
+	Category            string           `json:"category"`
+	Priority            int              `json:"priority"`
+	CounterpartyPattern *string          `json:"counterparty_pattern,omitempty"`
+	MinAmount           *decimal.Decimal `json:"min_amount,omitempty"`
+	MaxAmount           *decimal.Decimal `json:"max_amount,omitempty"`
+	Type                *string          `json:"type,omitempty"`
+	Channel             *string          `json:"channel,omitempty"`
+	CreatedBy           string           `json:"created_by"`
+	CreatedAt           time.Time        `json:"created_at"`
+}
+
+// RecategorizationRun reports the progress of reapplying the rules to
+// recorded transactions. Since limits the run to transactions created at or
+// after it.
+type RecategorizationRun struct {
+	Since      *time.Time `json:"since,omitempty"`
+	Scanned    int        `json:"scanned"`
+	Changed    int        `json:"changed"`
+	Error      *string    `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
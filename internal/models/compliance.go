@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operations evaluated against compliance policies
+const (
+	ComplianceDeposit            = "deposit"
+	ComplianceTransferIn         = "transfer_in"
+	ComplianceExternalWithdrawal = "external_withdrawal"
+)
+
+// Rules of a compliance policy an operation can break
+const (
+	ComplianceRuleCurrency           = "allowed_currencies"
+	ComplianceRuleMaxBalance         = "max_balance"
+	ComplianceRuleExternalWithdrawal = "external_withdrawals"
+)
+
+// CompliancePolicy controls what users registered in a jurisdiction may do.
+// The policy with an empty jurisdiction is the default for users whose
+// jurisdiction has no policy of its own. Policies are versioned: a change
+// records a new version and the latest one applies.
+type CompliancePolicy struct {
+	Jurisdiction string `json:"jurisdiction"`
+	Version      int    `json:"version"`
+	// AllowedCurrencies is empty when every currency is allowed
+	AllowedCurrencies   []string         `json:"allowed_currencies"`
+	MaxBalance          *decimal.Decimal `json:"max_balance,omitempty"`
+	ExternalWithdrawals bool             `json:"external_withdrawals"`
+	Reason              string           `json:"reason"`
+	CreatedBy           string           `json:"created_by"`
+	CreatedAt           time.Time        `json:"created_at"`
+}
+
+// ComplianceSubject is what a policy is evaluated on: the jurisdiction and
+// balance of a user and the policy that applies to them, nil if none does.
+// A user without a wallet has a zero balance and no jurisdiction.
+type ComplianceSubject struct {
+	UserID       string
+	Jurisdiction string
+	Balance      decimal.Decimal
+	Policy       *CompliancePolicy
+}
+
+// ComplianceDecision records the outcome of evaluating an operation against
+// a policy version, for audit. Rule names the rule a denied operation broke.
+type ComplianceDecision struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"user_id"`
+	Operation     string          `json:"operation"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency,omitempty"`
+	Jurisdiction  string          `json:"jurisdiction"`
+	PolicyVersion int             `json:"policy_version"`
+	Allowed       bool            `json:"allowed"`
+	Rule          *string         `json:"rule,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
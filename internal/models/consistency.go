@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kinds of inconsistencies found by the consistency checker
+const (
+	// IssueOrphanedTransactions: transactions credit a wallet that does not
+	// exist
+	IssueOrphanedTransactions = "orphaned_transactions"
+	// IssueNegativeBalance: the stored balance is below zero, which no
+	// wallet is allowed
+	IssueNegativeBalance = "negative_balance"
+	// IssueCacheMismatch: the cached balance differs from the stored one
+	IssueCacheMismatch = "cache_mismatch"
+	// IssueLedgerImbalance: the stored balance differs from the sum of the
+	// wallet's transactions
+	IssueLedgerImbalance = "ledger_imbalance"
+)
+
+// Repairs a repair plan can apply
+const (
+	// RepairSetBalance sets the stored balance to the ledger balance
+	RepairSetBalance = "set_balance"
+	// RepairCreateWallet creates the missing wallet with its ledger balance
+	RepairCreateWallet = "create_wallet"
+	// RepairFreezeWallet freezes an active wallet for review
+	RepairFreezeWallet = "freeze_wallet"
+	// RepairInvalidateCache drops the cached balance
+	RepairInvalidateCache = "invalidate_cache"
+)
+
+// Outcomes of applying a repair
+const (
+	RepairApplied = "applied"
+	// RepairStale: the wallet changed since the plan was made; check again
+	RepairStale  = "stale"
+	RepairFailed = "failed"
+	// RepairManual: the issue has no automatic repair
+	RepairManual = "manual"
+)
+
+// WalletConsistency is a wallet with its balance recomputed from its
+// transactions, read as of the same moment
+type WalletConsistency struct {
+	UserID         string
+	Status         string
+	Balance        decimal.Decimal
+	Ledger         decimal.Decimal
+	OverdraftLimit decimal.Decimal
+}
+
+// ConsistencyIssue is an inconsistency and the repair that fixes it, empty
+// when it needs a person to look at it. The balances are the ones observed;
+// a repair only applies while they still hold.
+type ConsistencyIssue struct {
+	Kind           string           `json:"kind"`
+	UserID         string           `json:"user_id"`
+	TransactionIDs []string         `json:"transaction_ids,omitempty"`
+	Balance        *decimal.Decimal `json:"balance,omitempty"`
+	Ledger         *decimal.Decimal `json:"ledger,omitempty"`
+	Cached         *decimal.Decimal `json:"cached,omitempty"`
+	Repair         string           `json:"repair,omitempty"`
+}
+
+// RepairPlan lists the issues found by a consistency check
+type RepairPlan struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Checked     int                `json:"checked"`
+	Issues      []ConsistencyIssue `json:"issues"`
+}
+
+// RepairOutcome reports what applying the repair of an issue did
+type RepairOutcome struct {
+	Issue  ConsistencyIssue `json:"issue"`
+	Status string           `json:"status"`
+	Error  string           `json:"error,omitempty"`
+}
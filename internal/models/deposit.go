@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Queued deposit statuses
+const (
+	DepositPending = "pending"
+	DepositApplied = "applied"
+	DepositFailed  = "failed"
+)
+
+// QueuedDeposit is a deposit accepted with 202 Accepted and applied later by
+// the deposit consumer. Deposits of one wallet are applied in the order they
+// were queued.
+type QueuedDeposit struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Status        string          `json:"status"`
+	Error         *string         `json:"error,omitempty"`
+	TransactionID *string         `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+	// Memo, ReferenceID and Metadata are recorded with the deposit
+	// transaction once applied
+	Memo        *string           `json:"memo,omitempty"`
+	ReferenceID *string           `json:"reference_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
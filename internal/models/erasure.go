@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Data erasure statuses
+const (
+	ErasurePending   = "pending"
+	ErasureCompleted = "completed"
+	ErasureCancelled = "cancelled"
+)
+
+// ErasedUserPrefix starts the pseudonym replacing the user ID of an erased
+// wallet
+const ErasedUserPrefix = "erased:"
+
+// DataErasure is the request to erase the personal data of a wallet owner.
+// The wallet is closed when the erasure is requested and its data erased once
+// EraseAfter has passed: the user ID is then replaced by Pseudonym across
+// the ledger, and cleared from the erasure itself.
+type DataErasure struct {
+	ID             string     `json:"id"`
+	UserID         *string    `json:"user_id,omitempty"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason"`
+	RequestedBy    string     `json:"requested_by"`
+	RequestedAt    time.Time  `json:"requested_at"`
+	EraseAfter     time.Time  `json:"erase_after"`
+	ErasedAt       *time.Time `json:"erased_at,omitempty"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+	Pseudonym      *string    `json:"pseudonym,omitempty"`
+	RowsAnonymized int64      `json:"rows_anonymized"`
+}
+
+// ErasurePseudonym is the user ID that replaces the erased one of erasure
+// erasureID. It is derived from the erasure, not from the user ID, so it
+// cannot be traced back.
+func ErasurePseudonym(erasureID string) string {
+	return ErasedUserPrefix + erasureID
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Exposure is the cumulative transfer volume between a pair of users over a
+// rolling window, in both directions. UserA is always the lexically smaller ID.
+type Exposure struct {
+	UserA         string          `json:"user_a"`
+	UserB         string          `json:"user_b"`
+	WindowDays    int             `json:"window_days"`
+	Volume        decimal.Decimal `json:"volume"`
+	TransferCount int             `json:"transfer_count"`
+	ComputedAt    time.Time       `json:"computed_at"`
+}
+
+// ExposureFilter narrows down an exposure listing
+type ExposureFilter struct {
+	UserID     *string
+	WindowDays int
+	MinVolume  *decimal.Decimal
+	Limit      int
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FaucetCredit is test funds credited by the sandbox faucet
+type FaucetCredit struct {
+	UserID  string          `json:"user_id"`
+	Amount  decimal.Decimal `json:"amount"`
+	Balance decimal.Decimal `json:"balance"`
+	// Remaining faucet requests of the caller until ResetsAt
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
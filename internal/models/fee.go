@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operations fees are charged on
+const (
+	FeeOperationWithdrawal = "withdrawal"
+	FeeOperationTransfer   = "transfer"
+)
+
+// TransactionTypeFee is the type of the transactions that move a fee from the
+// payer to the fee account
+const TransactionTypeFee = "fee"
+
+// Fee is a fee tier: operations of at least MinAmount in Currency are charged
+// FlatFee plus PercentFee percent of their amount, up to the next tier. Tiers
+// without a currency apply to currencies without tiers of their own,
+// including wallets without a currency.
+type Fee struct {
+	ID         string          `json:"id"`
+	Operation  string          `json:"operation"`
+	Currency   string          `json:"currency"`
+	MinAmount  decimal.Decimal `json:"min_amount"`
+	FlatFee    decimal.Decimal `json:"flat_fee"`
+	PercentFee decimal.Decimal `json:"percent_fee"`
+	UpdatedBy  string          `json:"updated_by"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Charge returns the fee of an operation of amount, rounded to the precision
+// of the ledger
+func (f Fee) Charge(amount decimal.Decimal) decimal.Decimal {
+	return f.FlatFee.Add(amount.Mul(f.PercentFee).Div(decimal.NewFromInt(100))).Round(8)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Freeze job actions
+const (
+	FreezeActionFreeze   = "freeze"
+	FreezeActionUnfreeze = "unfreeze"
+)
+
+// Freeze job statuses
+const (
+	FreezeJobPending   = "pending"
+	FreezeJobRunning   = "running"
+	FreezeJobCompleted = "completed"
+	FreezeJobFailed    = "failed"
+)
+
+// FreezeCriteria selects the wallets affected by a bulk freeze. All set
+// criteria must match. CounterpartyID together with MinExposure selects
+// wallets whose transfer volume with the counterparty over the last
+// ExposureWindowDays reaches MinExposure.
+type FreezeCriteria struct {
+	Label              *string          `json:"label,omitempty"`
+	Country            *string          `json:"country,omitempty"`
+	CounterpartyID     *string          `json:"counterparty_id,omitempty"`
+	MinExposure        *decimal.Decimal `json:"min_exposure,omitempty"`
+	ExposureWindowDays int              `json:"exposure_window_days,omitempty"`
+}
+
+// FreezeJob tracks an asynchronous bulk freeze or the unfreeze of the cohort
+// frozen by a previous job (ParentJobID)
+type FreezeJob struct {
+	ID          string         `json:"id"`
+	Action      string         `json:"action"`
+	Criteria    FreezeCriteria `json:"criteria"`
+	ParentJobID *string        `json:"parent_job_id,omitempty"`
+	Reason      string         `json:"reason"`
+	Status      string         `json:"status"`
+	Total       int            `json:"total"`
+	Processed   int            `json:"processed"`
+	Error       *string        `json:"error,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
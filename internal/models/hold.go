@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Hold statuses
+const (
+	HoldPending  = "pending"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+)
+
+// Hold is a pending transfer. While pending, its amount is held on the
+// sender's wallet and cannot be withdrawn or transferred; capturing it
+// completes the transfer and releasing it returns the funds.
+type Hold struct {
+	ID            string          `json:"id"`
+	FromUserID    string          `json:"from_user_id"`
+	ToUserID      string          `json:"to_user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Status        string          `json:"status"`
+	TransactionID *string         `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Balance is a wallet balance split into the part held by pending transfers
+// and withdrawals and the part available for new operations. Available
+// includes what is left of the wallet's credit line.
+type Balance struct {
+	Total          decimal.Decimal `json:"balance"`
+	Held           decimal.Decimal `json:"held_balance"`
+	Available      decimal.Decimal `json:"available_balance"`
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"`
+	OverdraftUsed  decimal.Decimal `json:"overdraft_used"`
+}
+
+// BalanceChange is the outcome of waiting for a balance to change. Version
+// is the position of the wallet's latest transaction in its ledger.
+type BalanceChange struct {
+	Balance decimal.Decimal `json:"balance"`
+	Version int64           `json:"version"`
+	// Changed is false when the wait timed out with the balance unchanged
+	Changed bool `json:"changed"`
+}
+
+// WalletUpdate is one event of a wallet stream: the balance of the wallet
+// at a new version, or a transaction recorded or changed at a sync
+// sequence. KeepAlive updates carry neither and keep idle streams open.
+type WalletUpdate struct {
+	Type        string
+	Balance     BalanceChange
+	Transaction Transaction
+}
+
+// Wallet update types
+const (
+	WalletUpdateBalance     = "balance"
+	WalletUpdateTransaction = "transaction"
+	WalletUpdateKeepAlive   = "keep-alive"
+)
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Idempotency record statuses
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyRecord tracks a money movement request submitted with an
+// Idempotency-Key. RequestHash fingerprints the request so the key cannot be
+// reused for a different operation.
+type IdempotencyRecord struct {
+	UserID      string
+	Key         string
+	Operation   string
+	RequestHash string
+	Status      string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Operations a kill switch can disable
+const (
+	KillSwitchDeposits    = "deposits"
+	KillSwitchWithdrawals = "withdrawals"
+	KillSwitchTransfers   = "transfers"
+	KillSwitchPayouts     = "payouts"
+)
+
+// KillSwitchOperations lists the operations that can be disabled
+var KillSwitchOperations = []string{
+	KillSwitchDeposits,
+	KillSwitchWithdrawals,
+	KillSwitchTransfers,
+	KillSwitchPayouts,
+}
+
+// KillSwitch disables an operation type for every wallet until an operator
+// enables it again. Message is returned to the callers of the operation.
+type KillSwitch struct {
+	Operation  string    `json:"operation"`
+	Message    string    `json:"message"`
+	DisabledBy string    `json:"disabled_by"`
+	DisabledAt time.Time `json:"disabled_at"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operations subject to transaction limits
+const (
+	LimitOperationWithdrawal = "withdrawal"
+	LimitOperationTransfer   = "transfer"
+)
+
+// Limit kinds. Amount caps and velocity limits apply over a rolling window
+// ending now.
+const (
+	LimitSingleAmount = "single_amount"
+	LimitDailyAmount  = "daily_amount"
+	LimitWeeklyAmount = "weekly_amount"
+	LimitHourlyCount  = "hourly_count"
+	LimitDailyCount   = "daily_count"
+)
+
+// Limit caps an operation of a user. Limits without a user ID are the
+// defaults; a user's own limit of the same operation and kind overrides the
+// default.
+type Limit struct {
+	UserID    string          `json:"user_id,omitempty"`
+	Operation string          `json:"operation"`
+	Kind      string          `json:"kind"`
+	Value     decimal.Decimal `json:"value"`
+	Reason    string          `json:"reason"`
+	UpdatedBy string          `json:"updated_by"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// LimitUsage is the amount and number of operations a user made in a window
+type LimitUsage struct {
+	Amount decimal.Decimal
+	Count  int
+}
+
+// LimitStatus is a limit together with how much of it the user has used in
+// its current window. Used and Remaining are omitted for single amount limits.
+type LimitStatus struct {
+	Limit
+	Used      *decimal.Decimal `json:"used,omitempty"`
+	Remaining *decimal.Decimal `json:"remaining,omitempty"`
+}
+
+// Limit increase request statuses
+const (
+	LimitRequestPending  = "pending"
+	LimitRequestApproved = "approved"
+	LimitRequestRejected = "rejected"
+)
+
+// LimitIncreaseRequest asks to raise a limit of a user to RequestedValue.
+// Small increases are approved when requested; larger ones stay pending until
+// an admin decides.
+type LimitIncreaseRequest struct {
+	ID             string          `json:"id"`
+	UserID         string          `json:"user_id"`
+	Operation      string          `json:"operation"`
+	Kind           string          `json:"kind"`
+	CurrentValue   decimal.Decimal `json:"current_value"`
+	RequestedValue decimal.Decimal `json:"requested_value"`
+	Reason         string          `json:"reason"`
+	Status         string          `json:"status"`
+	AutoApproved   bool            `json:"auto_approved"`
+	RequestedBy    string          `json:"requested_by"`
+	DecidedBy      *string         `json:"decided_by,omitempty"`
+	DecisionReason *string         `json:"decision_reason,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DecidedAt      *time.Time      `json:"decided_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// WalletMetadata is the key-value metadata the client application owning a
+// wallet stores on it. Version counts the changes, so a client can update
+// the metadata without overwriting a concurrent change.
+type WalletMetadata struct {
+	UserID    string            `json:"user_id"`
+	Metadata  map[string]string `json:"metadata"`
+	Version   int64             `json:"version"`
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"`
+}
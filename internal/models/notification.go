@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Notification modes
+const (
+	// NotificationImmediate notifies the user of every event
+	NotificationImmediate = "immediate"
+	// NotificationDigest summarizes the money movements of the user in a
+	// single notification per window
+	NotificationDigest = "digest"
+)
+
+// NotificationPreference is how a user is notified of its money movements.
+// WindowSeconds is set in digest mode only.
+type NotificationPreference struct {
+	UserID        string     `json:"user_id"`
+	Mode          string     `json:"mode"`
+	WindowSeconds int        `json:"window_seconds,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Overdraft is the credit line of a wallet. Withdrawals and transfers may
+// take the balance below zero down to minus Limit; Used is how far below
+// zero it is.
+type Overdraft struct {
+	UserID string          `json:"user_id"`
+	Limit  decimal.Decimal `json:"limit"`
+	Used   decimal.Decimal `json:"used"`
+}
+
+// OverdraftUsed returns how much of a credit line a wallet holding balance
+// uses
+func OverdraftUsed(balance decimal.Decimal) decimal.Decimal {
+	if balance.IsNegative() {
+		return balance.Neg()
+	}
+	return decimal.Zero
+}
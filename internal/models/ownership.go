@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OwnershipChange is the audit record of a wallet reassigned to a new user ID
+type OwnershipChange struct {
+	ID             string    `json:"id"`
+	PreviousUserID string    `json:"previous_user_id"`
+	NewUserID      string    `json:"new_user_id"`
+	Reason         string    `json:"reason"`
+	Actor          string    `json:"actor"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WalletMerge is the report of a duplicate wallet merged into another wallet
+// of the same person. The source balance moved to the target through
+// TransferTransactionID, absent when the source was empty, and the source
+// history was re-linked to the target.
+type WalletMerge struct {
+	ID                    string          `json:"id"`
+	SourceUserID          string          `json:"source_user_id"`
+	TargetUserID          string          `json:"target_user_id"`
+	Amount                decimal.Decimal `json:"amount"`
+	TransferTransactionID *string         `json:"transfer_transaction_id,omitempty"`
+	TransactionsRelinked  int64           `json:"transactions_relinked"`
+	Reason                string          `json:"reason"`
+	Actor                 string          `json:"actor"`
+	CreatedAt             time.Time       `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Payment request statuses
+const (
+	PaymentRequestPending  = "pending"
+	PaymentRequestAccepted = "accepted"
+	PaymentRequestDeclined = "declined"
+	PaymentRequestExpired  = "expired"
+)
+
+// Sides of a payment request, relative to the wallet listing them
+const (
+	PaymentRequestIncoming = "incoming"
+	PaymentRequestOutgoing = "outgoing"
+)
+
+// PaymentRequest asks PayerID to transfer Amount to RequesterID. Accepting
+// it transfers the amount from the payer; a request not accepted or declined
+// by ExpiresAt expires.
+type PaymentRequest struct {
+	ID          string          `json:"id"`
+	RequesterID string          `json:"requester_id"`
+	PayerID     string          `json:"payer_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Note        string          `json:"note"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	DecidedAt   *time.Time      `json:"decided_at,omitempty"`
+}
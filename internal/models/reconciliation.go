@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReconciliationClaim is an integrator's view of a wallet's transactions
+// created in [From, To). Sum is the net amount: credits count positive and
+// debits negative. TransactionIDs is optional; without it the missing
+// transactions are those after LastTransactionID.
+type ReconciliationClaim struct {
+	From              time.Time
+	To                time.Time
+	Count             int
+	Sum               decimal.Decimal
+	LastTransactionID string
+	TransactionIDs    []string
+}
+
+// Reconciliation compares a ReconciliationClaim with the ledger. Missing
+// holds the ledger transactions the integrator does not know about, Extra the
+// IDs it reported that are not in the ledger for the period. Matched is set
+// when the totals agree and both lists are empty.
+type Reconciliation struct {
+	UserID  string               `json:"user_id"`
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	Matched bool                 `json:"matched"`
+	Ledger  ReconciliationTotals `json:"ledger"`
+	Claimed ReconciliationTotals `json:"claimed"`
+	Missing []Transaction        `json:"missing"`
+	Extra   []string             `json:"extra"`
+}
+
+// ReconciliationTotals summarises the transactions of a period
+type ReconciliationTotals struct {
+	Count             int             `json:"count"`
+	Sum               decimal.Decimal `json:"sum"`
+	LastTransactionID string          `json:"last_transaction_id"`
+}
+
+// Balance reconciliation run statuses
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+)
+
+// ReconciliationRun recomputes the balance of every wallet from its ledger
+// and compares it with the stored balance. Wallets are checked in user ID
+// order and LastUserID is the last one checked, so an interrupted run
+// resumes after it.
+type ReconciliationRun struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	LastUserID  string     `json:"-"`
+	Checked     int        `json:"checked"`
+	Mismatched  int        `json:"mismatched"`
+	TriggeredBy string     `json:"triggered_by"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReconciliationReport is a wallet whose stored balance differed from its
+// ledger when a run checked it. Difference is the stored balance minus the
+// ledger balance.
+type ReconciliationReport struct {
+	RunID         string          `json:"run_id"`
+	UserID        string          `json:"user_id"`
+	StoredBalance decimal.Decimal `json:"stored_balance"`
+	LedgerBalance decimal.Decimal `json:"ledger_balance"`
+	Difference    decimal.Decimal `json:"difference"`
+	CheckedAt     time.Time       `json:"checked_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transaction statuses. Transactions applied synchronously are completed
+// when recorded; multi-step pipelines record them as pending first.
+const (
+	TransactionPending   = "pending"
+	TransactionCompleted = "completed"
+	TransactionFailed    = "failed"
+	TransactionEscalated = "escalated"
+)
+
+// Remediation actions an operator can take on a stuck transaction
+const (
+	RemediationRetry    = "retry"
+	RemediationFail     = "fail"
+	RemediationEscalate = "escalate"
+)
+
+// StuckTransaction is a transaction that has not reached a final status,
+// with the remediation actions currently available for it
+type StuckTransaction struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	FromUserID string          `json:"from_user_id"`
+	ToUserID   *string         `json:"to_user_id,omitempty"`
+	Amount     decimal.Decimal `json:"amount"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Actions    []string        `json:"actions"`
+	// Flags are the review flags admins set on the transaction
+	Flags []string `json:"flags,omitempty"`
+}
+
+// StuckTransactionFilter selects transactions by status, review flag or both
+// that have not moved for longer than OlderThan
+type StuckTransactionFilter struct {
+	Status    string
+	Flag      string
+	OlderThan time.Duration
+	Limit     int
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TransactionTypeRoundUp is the type of the transactions that move the
+// round-up of a transfer from the wallet to its savings sub-account
+const TransactionTypeRoundUp = "round_up"
+
+// Round-up statuses
+const (
+	RoundUpSaved   = "saved"
+	RoundUpSkipped = "skipped"
+)
+
+// SavingsAccountLabel marks savings sub-accounts in wallet listings
+const SavingsAccountLabel = "savings"
+
+// SavingsAccount returns the user ID of the savings sub-account of userID
+func SavingsAccount(userID string) string {
+	return "savings:" + userID
+}
+
+// RoundUpRule rounds each outgoing transfer of a wallet made since the rule
+// was created up to a multiple of Unit and saves the difference in the
+// savings sub-account. SavingsBalance is the balance of that account.
+type RoundUpRule struct {
+	UserID         string          `json:"user_id"`
+	Unit           decimal.Decimal `json:"unit"`
+	SavingsAccount string          `json:"savings_account"`
+	SavingsBalance decimal.Decimal `json:"savings_balance"`
+	CreatedBy      string          `json:"created_by"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// RoundUp is the amount a transfer is rounded up by
+type RoundUp struct {
+	TransferID string
+	UserID     string
+	Amount     decimal.Decimal
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transfer schedule statuses
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCancelled = "cancelled"
+)
+
+// Transfer schedule run statuses
+const (
+	ScheduleRunPending   = "pending"
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunFailed    = "failed"
+)
+
+// TransferSchedule transfers Amount from UserID to ToUserID on a cron
+// expression or every IntervalSeconds. NextRunAt is the next occurrence the
+// scheduler executes while the schedule is active.
+type TransferSchedule struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	ToUserID        string          `json:"to_user_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Cron            *string         `json:"cron,omitempty"`
+	IntervalSeconds *int64          `json:"interval_seconds,omitempty"`
+	Status          string          `json:"status"`
+	NextRunAt       time.Time       `json:"next_run_at"`
+	LastRunAt       *time.Time      `json:"last_run_at,omitempty"`
+	CreatedBy       string          `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// TransferScheduleRun records one occurrence of a schedule and its outcome.
+// A run stays pending while its transfer is executed.
+type TransferScheduleRun struct {
+	ID           string          `json:"id"`
+	ScheduleID   string          `json:"schedule_id"`
+	UserID       string          `json:"user_id"`
+	ToUserID     string          `json:"to_user_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	ScheduledFor time.Time       `json:"scheduled_for"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	Error        *string         `json:"error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
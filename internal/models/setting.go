@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Setting scopes, from the most general to the most specific. A wallet
+// setting overrides the setting of its currency, which overrides the one of
+// its tenant, which overrides the default.
+const (
+	SettingScopeDefault  = "default"
+	SettingScopeTenant   = "tenant"
+	SettingScopeCurrency = "currency"
+	SettingScopeWallet   = "wallet"
+
+	// SettingScopeBuiltin marks a resolved value that no scope overrides
+	SettingScopeBuiltin = "builtin"
+)
+
+// Setting is a runtime configuration value at one scope. ScopeID is the
+// tenant ID, currency code or user ID, and empty for the default scope.
+type Setting struct {
+	Key       string    `json:"key"`
+	Scope     string    `json:"scope"`
+	ScopeID   string    `json:"scope_id,omitempty"`
+	Value     string    `json:"value"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SettingChange is the audit record of a setting being set or cleared. A
+// cleared setting has no NewValue, a newly added one no OldValue.
+type SettingChange struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Scope     string    `json:"scope"`
+	ScopeID   string    `json:"scope_id,omitempty"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SettingTarget is what settings are resolved for. Empty fields skip their
+// scope.
+type SettingTarget struct {
+	TenantID string `json:"tenant_id,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+// ResolvedSetting is the effective value of a setting for a target and the
+// scope it comes from
+type ResolvedSetting struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Scope   string `json:"scope"`
+	ScopeID string `json:"scope_id,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statement formats
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+)
+
+// Statement is the header of an account statement for the transactions of a
+// wallet created in [From, To). OpeningBalance is the balance just before
+// From.
+type Statement struct {
+	UserID         string          `json:"user_id"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// StatementLine is a transaction as listed on a statement. Amount is signed
+// from the point of view of the wallet, Balance is the running balance after
+// the transaction and Counterparty the other wallet of a transfer.
+type StatementLine struct {
+	TransactionID string          `json:"transaction_id"`
+	Date          time.Time       `json:"date"`
+	Type          string          `json:"type"`
+	Counterparty  string          `json:"counterparty,omitempty"`
+	Category      string          `json:"category,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Balance       decimal.Decimal `json:"balance"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Timeline event types
+const (
+	TimelineEventTransaction = "transaction"
+	TimelineEventHold        = "hold"
+	TimelineEventStatus      = "status"
+)
+
+// TimelineEvent is a single entry of a wallet's event timeline. Type
+// discriminates the source of the event and Subtype carries the source
+// specific kind (e.g. deposit/withdrawal/transfer for transactions, the
+// status for holds, the new status for wallet status changes).
+type TimelineEvent struct {
+	Type        string           `json:"type"`
+	Subtype     *string          `json:"subtype,omitempty"`
+	ReferenceID *string          `json:"reference_id,omitempty"`
+	FromUserID  *string          `json:"from_user_id,omitempty"`
+	ToUserID    *string          `json:"to_user_id,omitempty"`
+	Amount      *decimal.Decimal `json:"amount,omitempty"`
+	OccurredAt  *time.Time       `json:"occurred_at,omitempty"`
+}
+
+// TimelineCursor is a position in a wallet timeline ordered by occurred_at,
+// newest first, then by event type and by the ID of the event in its source,
+// highest first. A page starting at the cursor holds the events after it.
+type TimelineCursor struct {
+	OccurredAt time.Time
+	Type       string
+	ID         int64
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Top-up rule statuses
+const (
+	TopUpRuleActive = "active"
+	TopUpRulePaused = "paused"
+)
+
+// Top-up run statuses
+const (
+	TopUpRunPending   = "pending"
+	TopUpRunSucceeded = "succeeded"
+	TopUpRunFailed    = "failed"
+)
+
+// TopUpRule deposits Amount from FundingSource whenever the available balance
+// of the wallet of UserID falls below Threshold. ConsecutiveFailures counts
+// the runs that failed since the last successful one; the rule pauses itself
+// once too many failed in a row.
+type TopUpRule struct {
+	UserID              string          `json:"user_id"`
+	Threshold           decimal.Decimal `json:"threshold"`
+	Amount              decimal.Decimal `json:"amount"`
+	FundingSource       string          `json:"funding_source"`
+	Status              string          `json:"status"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	CreatedBy           string          `json:"created_by"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+// TopUpRun records one automatic top-up and its outcome. A run stays pending
+// while its charge is collected and deposited; ProviderReference identifies
+// the collected charge.
+type TopUpRun struct {
+	ID                string          `json:"id"`
+	UserID            string          `json:"user_id"`
+	Amount            decimal.Decimal `json:"amount"`
+	Currency          *string         `json:"currency,omitempty"`
+	FundingSource     string          `json:"funding_source"`
+	Status            string          `json:"status"`
+	Attempts          int             `json:"attempts"`
+	ProviderReference *string         `json:"provider_reference,omitempty"`
+	Error             *string         `json:"error,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
@@ -1,12 +1,95 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Transaction struct {
-	ID         *string    `json:"id,omitempty"`
-	FromUserID *string    `json:"from_user_id,omitempty"`
-	ToUserID   *string    `json:"to_user_id,omitempty"`
-	Amount     *float64   `json:"amount,omitempty"`
-	Type       *string    `json:"type,omitempty"`
-	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ID         *string          `json:"id,omitempty"`
+	FromUserID *string          `json:"from_user_id,omitempty"`
+	ToUserID   *string          `json:"to_user_id,omitempty"`
+	Amount     *decimal.Decimal `json:"amount,omitempty"`
+	Type       *string          `json:"type,omitempty"`
+	CreatedAt  *time.Time       `json:"created_at,omitempty"`
+	// MergedFrom is the user ID the transaction was re-linked from when
+	// a duplicate wallet was merged
+	MergedFrom *string `json:"merged_from,omitempty"`
+	// Sequence is the transaction's gapless position in the ledger of the
+	// wallet whose history is read
+	Sequence *int64 `json:"sequence,omitempty"`
+	// Category is assigned by the categorization rules when the transaction
+	// is recorded
+	Category *string `json:"category,omitempty"`
+	// FromCurrency, ToCurrency, FXRate and ConvertedAmount are set on
+	// transfers between wallets of different currencies: Amount left the
+	// sender and ConvertedAmount reached the receiver
+	FromCurrency    *string          `json:"from_currency,omitempty"`
+	ToCurrency      *string          `json:"to_currency,omitempty"`
+	FXRate          *decimal.Decimal `json:"fx_rate,omitempty"`
+	ConvertedAmount *decimal.Decimal `json:"converted_amount,omitempty"`
+	// FeeFor is set on fee transactions: the transaction the fee was charged
+	// for
+	FeeFor *string `json:"fee_for,omitempty"`
+	// RoundUpFor is set on round-up transactions: the transfer that was
+	// rounded up
+	RoundUpFor *string `json:"round_up_for,omitempty"`
+	// AcknowledgedAt and AcknowledgmentMessage are set on transfers the
+	// receiver acknowledged, the message only when it wrote one
+	AcknowledgedAt        *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgmentMessage *string    `json:"acknowledgment_message,omitempty"`
+	// Memo, ReferenceID and Metadata are attached by the client that
+	// deposited, withdrew or transferred the funds
+	Memo        *string           `json:"memo,omitempty"`
+	ReferenceID *string           `json:"reference_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Status and SyncSequence are read by sync listings. SyncSequence is
+	// the position of the transaction's last change in the wallet's sync
+	// order.
+	Status       *string `json:"status,omitempty"`
+	SyncSequence *int64  `json:"sync_sequence,omitempty"`
+}
+
+// TransactionSync holds the transactions of a wallet recorded or changed
+// since a sync sequence, in sync order. NextSince is the sync sequence to
+// pass on the next sync; HasMore reports that it can be fetched right away.
+type TransactionSync struct {
+	Transactions []Transaction `json:"transactions"`
+	NextSince    int64         `json:"next_since"`
+	HasMore      bool          `json:"has_more"`
+}
+
+// Conversion prices a transfer between wallets of different currencies. The
+// converted amount is rounded down to the precision of the ledger.
+type Conversion struct {
+	FromCurrency    string          `json:"from_currency"`
+	ToCurrency      string          `json:"to_currency"`
+	Rate            decimal.Decimal `json:"rate"`
+	ConvertedAmount decimal.Decimal `json:"converted_amount"`
+}
+
+// TransactionCursor is a position in a transaction history ordered by
+// created_at and id, newest first. A page starting at the cursor holds the
+// transactions strictly older than it. Since carries the start of a default
+// window, so the window does not move between pages.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+	Since     *time.Time
+}
+
+// HistoryWindow bounds a transaction history to the transactions created in
+// [From, To). An unset bound leaves that side open. ReferenceID, Memo and
+// Metadata narrow it further to the transactions with that reference, a memo
+// containing Memo regardless of case, and every pair of Metadata. Cutoff is
+// set on a window read without a range, whose From its page cursors carry,
+// to the start of the partial indexes of recent transactions it reads.
+type HistoryWindow struct {
+	From        *time.Time        `json:"from"`
+	To          *time.Time        `json:"to"`
+	Cutoff      *time.Time        `json:"-"`
+	ReferenceID *string           `json:"reference_id,omitempty"`
+	Memo        *string           `json:"memo,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Flags admins set on transactions they review. Suspicious transactions wait
+// for review, reviewed ones were cleared, and training-excluded ones are left
+// out of the data fraud models are trained on.
+const (
+	FlagSuspicious       = "suspicious"
+	FlagReviewed         = "reviewed"
+	FlagTrainingExcluded = "training_excluded"
+)
+
+// TransactionFlags lists the flags a transaction can carry
+var TransactionFlags = []string{FlagSuspicious, FlagReviewed, FlagTrainingExcluded}
+
+// TransactionFlag is a flag set on a transaction and who set it
+type TransactionFlag struct {
+	Flag      string    `json:"flag"`
+	FlaggedBy string    `json:"flagged_by"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// TransactionAnnotation is a note an admin attached to a transaction
+type TransactionAnnotation struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transaction_id"`
+	Note          string    `json:"note"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TransactionReview is what admins recorded while reviewing a transaction:
+// its flags and its annotations, oldest first
+type TransactionReview struct {
+	TransactionID string                  `json:"transaction_id"`
+	Flags         []TransactionFlag       `json:"flags"`
+	Annotations   []TransactionAnnotation `json:"annotations"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transfer confirmation statuses
+const (
+	TransferConfirmationPending   = "pending"
+	TransferConfirmationConfirmed = "confirmed"
+	TransferConfirmationFailed    = "failed"
+	TransferConfirmationExpired   = "expired"
+)
+
+// TransferConfirmation is a transfer above the confirmation threshold waiting
+// for its sender to enter the one-time password sent to them. Entering it
+// makes the transfer; too many wrong passwords fail the confirmation, and a
+// confirmation not confirmed by ExpiresAt expires.
+type TransferConfirmation struct {
+	ID              string            `json:"id"`
+	SenderID        string            `json:"sender_id"`
+	ReceiverID      string            `json:"receiver_id"`
+	Amount          decimal.Decimal   `json:"amount"`
+	ExpectedBalance *decimal.Decimal  `json:"expected_balance,omitempty"`
+	Memo            string            `json:"memo,omitempty"`
+	ReferenceID     string            `json:"reference_id,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Status          string            `json:"status"`
+	Attempts        int               `json:"attempts"`
+	CreatedAt       time.Time         `json:"created_at"`
+	ExpiresAt       time.Time         `json:"expires_at"`
+	ConfirmedAt     *time.Time        `json:"confirmed_at,omitempty"`
+	// CodeHash is the hash of the one-time password
+	CodeHash string `json:"-"`
+}
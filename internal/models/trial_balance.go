@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TrialBalanceDayFormat is the format of trial balance days
+const TrialBalanceDayFormat = "2006-01-02"
+
+// TrialBalance closes a UTC day: the debits and credits of the wallets of
+// each currency over the day, and the balances of the system accounts at its
+// end. Listings leave Currencies and SystemAccounts out.
+type TrialBalance struct {
+	Day            string                 `json:"day"`
+	GeneratedBy    string                 `json:"generated_by"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+	Currencies     []TrialBalanceCurrency `json:"currencies,omitempty"`
+	SystemAccounts []TrialBalanceAccount  `json:"system_accounts,omitempty"`
+}
+
+// TrialBalanceCurrency totals the movements of the wallets of a currency,
+// nil for wallets without one. A transfer between two such wallets is both
+// a debit and a credit; deposits only credit and withdrawals only debit.
+type TrialBalanceCurrency struct {
+	Currency     *string         `json:"currency"`
+	Debits       decimal.Decimal `json:"debits"`
+	Credits      decimal.Decimal `json:"credits"`
+	Transactions int             `json:"transactions"`
+}
+
+// TrialBalanceAccount is the balance of a system account at the end of the
+// day
+type TrialBalanceAccount struct {
+	Account  string          `json:"account"`
+	Currency *string         `json:"currency"`
+	Balance  decimal.Decimal `json:"balance"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// User kinds. Customers are registered through the users API; system
+// accounts, savings sub-accounts and currency sub-accounts are registered
+// with their wallet.
+const (
+	UserKindCustomer = "customer"
+	UserKindSystem   = "system"
+	UserKindSavings  = "savings"
+	UserKindCurrency = "currency"
+)
+
+// User is a registered owner of a wallet. ExternalID is the integrator's
+// own identifier of the user, unique across users. Wallets are the wallets
+// created with the user, one per default currency.
+type User struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	ExternalID *string   `json:"external_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	Wallets    []Wallet  `json:"wallets,omitempty"`
+}
+
+// currencyAccountPattern matches the user IDs of currency sub-accounts
+var currencyAccountPattern = regexp.MustCompile(`^(.+):([A-Z0-9]{3,10})$`)
+
+// CurrencyAccount returns the user ID of the wallet of userID in currency. A
+// user has one wallet, so the wallets of their other default currencies are
+// sub-accounts.
+func CurrencyAccount(userID, currency string) string {
+	return userID + ":" + currency
+}
+
+// AccountOwner returns the user owning the currency sub-account accountID,
+// or accountID itself for any other account
+func AccountOwner(accountID string) string {
+	if match := currencyAccountPattern.FindStringSubmatch(accountID); match != nil {
+		return match[1]
+	}
+	return accountID
+}
@@ -0,0 +1,48 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Wallet statuses
+const (
+	WalletStatusActive = "active"
+	WalletStatusFrozen = "frozen"
+	WalletStatusClosed = "closed"
+)
+
+// Wallet is a wallet as listed to operators
+type Wallet struct {
+	UserID  string          `json:"user_id"`
+	Balance decimal.Decimal `json:"balance"`
+	Held    decimal.Decimal `json:"held_balance"`
+	Status  string          `json:"status"`
+	Label   *string         `json:"label,omitempty"`
+	Country *string         `json:"country,omitempty"`
+	// Currency is unset on wallets created before currencies were tracked
+	Currency *string `json:"currency,omitempty"`
+	// Metadata is set by the client application owning the wallet
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewWallet is a wallet to provision
+type NewWallet struct {
+	UserID string
+	// Currency is nil for a wallet without a currency
+	Currency *string
+	Metadata map[string]string
+	// Status is active or frozen
+	Status string
+}
+
+// WalletFilter narrows down a wallet listing. Wallets are ordered by user ID;
+// After continues a listing after the given user ID.
+type WalletFilter struct {
+	Status     *string
+	Label      *string
+	Country    *string
+	MinBalance *decimal.Decimal
+	MaxBalance *decimal.Decimal
+	// Metadata matches wallets holding every one of its keys with its value
+	Metadata map[string]string
+	After    string
+	Limit    int
+}
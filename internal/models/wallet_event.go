@@ -0,0 +1,28 @@
+package models
+
+// Types of the events in the wallet streams of the event-sourced storage
+// engine
+const (
+	WalletEventCredited       = "credited"
+	WalletEventDebited        = "debited"
+	WalletEventTransferredOut = "transferred_out"
+	WalletEventTransferredIn  = "transferred_in"
+	// WalletEventOpened starts the stream of a wallet that held funds before
+	// the engine was enabled, with its balance at the time
+	WalletEventOpened = "opened"
+)
+
+// StreamBreak is the first event of a wallet stream whose hash does not
+// match its content or the previous event: it, or an event before it, was
+// changed or removed after it was appended
+type StreamBreak struct {
+	UserID  string `json:"user_id"`
+	Version int64  `json:"version"`
+}
+
+// StreamVerification is the outcome of checking every wallet stream
+type StreamVerification struct {
+	Streams int           `json:"streams"`
+	Events  int           `json:"events"`
+	Breaks  []StreamBreak `json:"breaks"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// WebhookSigningKey signs the event webhook deliveries. The current key has
+// no ExpiresAt; a rotated key keeps signing until ExpiresAt. The secret is
+// only shown when the key is created.
+type WebhookSigningKey struct {
+	ID        string     `json:"id"`
+	Secret    string     `json:"-"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// WebhookSubscription is the delivery state of the event webhook. Deliveries
+// are paused while PausedAt is set.
+type WebhookSubscription struct {
+	PausedAt    *time.Time     `json:"paused_at,omitempty"`
+	PausedBy    *string        `json:"paused_by,omitempty"`
+	PauseReason *string        `json:"pause_reason,omitempty"`
+	ResumedAt   *time.Time     `json:"resumed_at,omitempty"`
+	ResumedBy   *string        `json:"resumed_by,omitempty"`
+	Backlog     WebhookBacklog `json:"backlog"`
+}
+
+// Paused reports whether deliveries are paused
+func (s WebhookSubscription) Paused() bool {
+	return s.PausedAt != nil
+}
+
+// WebhookBacklog is the events recorded but not delivered yet. Limit is the
+// backlog beyond which a pause is lifted, zero when unlimited.
+type WebhookBacklog struct {
+	Events          int64      `json:"events"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LagSeconds      int64      `json:"lag_seconds"`
+	Limit           int64      `json:"limit,omitempty"`
+}
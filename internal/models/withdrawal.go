@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Withdrawal statuses
+const (
+	WithdrawalRequested  = "requested"
+	WithdrawalProcessing = "processing"
+	WithdrawalCompleted  = "completed"
+	WithdrawalFailed     = "failed"
+)
+
+// Withdrawal sends funds to a destination outside the service. While
+// requested or processing, its amount is held on the wallet; the wallet is
+// debited once the payout provider has sent the funds, and the hold is
+// released if the provider rejects the payout. Fee is held with the amount and
+// charged once the funds are sent.
+type Withdrawal struct {
+	ID                string          `json:"id"`
+	UserID            string          `json:"user_id"`
+	Amount            decimal.Decimal `json:"amount"`
+	Fee               decimal.Decimal `json:"fee"`
+	Currency          *string         `json:"currency,omitempty"`
+	Destination       string          `json:"destination"`
+	Status            string          `json:"status"`
+	Attempts          int             `json:"attempts"`
+	Provider          *string         `json:"provider,omitempty"`
+	Route             *PayoutRoute    `json:"route,omitempty"`
+	ProviderReference *string         `json:"provider_reference,omitempty"`
+	Error             *string         `json:"error,omitempty"`
+	TransactionID     *string         `json:"transaction_id,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// PayoutRoute records how the payout of a withdrawal was routed: the
+// providers that could send it, best first, and every failover from one to
+// the next. Reconciliation checks the statements of each provider tried.
+type PayoutRoute struct {
+	Candidates []PayoutCandidate `json:"candidates"`
+	Failovers  []PayoutFailover  `json:"failovers,omitempty"`
+}
+
+// PayoutCandidate is a provider a payout could be sent through and the fee it
+// was estimated to charge
+type PayoutCandidate struct {
+	Provider     string          `json:"provider"`
+	EstimatedFee decimal.Decimal `json:"estimated_fee"`
+}
+
+// PayoutFailover records a payout moved to the next provider after the
+// previous one could not accept it
+type PayoutFailover struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
@@ -0,0 +1,16 @@
+// Package openapi holds the OpenAPI 3 specification of the public API. The
+// specification is maintained by hand next to the handlers and embedded in
+// the binary, so the documentation served always matches the running version.
+package openapi
+
+import (
+	_ "embed"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Spec returns the specification as YAML
+func Spec() []byte {
+	return spec
+}
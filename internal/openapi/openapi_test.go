@@ -0,0 +1,97 @@
+package openapi
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSpec(t *testing.T) {
+	var document map[string]any
+	require.NoError(t, yaml.Unmarshal(Spec(), &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+
+	// Every reference points to a defined component
+	var walk func(node any)
+	walk = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			for key, value := range node {
+				if ref, ok := value.(string); key == "$ref" && ok {
+					assert.NotNil(t, resolve(document, ref), "unresolved reference %s", ref)
+					continue
+				}
+				walk(value)
+			}
+		case []any:
+			for _, value := range node {
+				walk(value)
+			}
+		}
+	}
+	walk(document)
+}
+
+func TestSpec_ErrorCodes(t *testing.T) {
+	var document map[string]any
+	require.NoError(t, yaml.Unmarshal(Spec(), &document))
+
+	documented := map[string]bool{}
+	errorCode, ok := resolve(document, "#/components/schemas/ErrorCode").(map[string]any)
+	require.True(t, ok)
+	for _, code := range errorCode["enum"].([]any) {
+		documented[code.(string)] = true
+	}
+
+	// The codes clients can receive are the Code constants of apierror
+	file, err := parser.ParseFile(token.NewFileSet(), "../apierror/apierror.go", nil, 0)
+	require.NoError(t, err)
+	var codes []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Code") || i >= len(spec.Values) {
+				continue
+			}
+			if literal, ok := spec.Values[i].(*ast.BasicLit); ok && literal.Kind == token.STRING {
+				code, err := strconv.Unquote(literal.Value)
+				require.NoError(t, err)
+				codes = append(codes, code)
+			}
+		}
+		return true
+	})
+	require.NotEmpty(t, codes)
+
+	for _, code := range codes {
+		assert.True(t, documented[code], "error code %s is not documented", code)
+	}
+	assert.Len(t, documented, len(codes))
+}
+
+// resolve returns the node a local reference such as
+// #/components/schemas/Error points to, nil when there is none
+func resolve(document map[string]any, ref string) any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var node any = document
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = object[key]
+	}
+	return node
+}
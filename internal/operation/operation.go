@@ -0,0 +1,162 @@
+package operation
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Channels an operation can arrive through
+const (
+	ChannelAPI   = "api"
+	ChannelAdmin = "admin"
+	ChannelBatch = "batch"
+	ChannelJob   = "job"
+)
+
+// Operation describes who performs a change, through which channel and why.
+// It is created by the HTTP middleware or by the background job doing the
+// work and travels in the context down to the repositories, which record it
+// with transactions and events.
+type Operation struct {
+	Actor          string `json:"actor,omitempty"`
+	Channel        string `json:"channel"`
+	Reason         string `json:"reason,omitempty"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RequestID correlates the operation with the HTTP request it serves
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Fields returns the set attributes of the operation as log fields
+func (o Operation) Fields() logrus.Fields {
+	fields := logrus.Fields{}
+	if o.Actor != "" {
+		fields["actor"] = o.Actor
+	}
+	if o.Channel != "" {
+		fields["channel"] = o.Channel
+	}
+	if o.Reason != "" {
+		fields["reason"] = o.Reason
+	}
+	if o.IdempotencyKey != "" {
+		fields["idempotencyKey"] = o.IdempotencyKey
+	}
+	if o.RequestID != "" {
+		fields["requestID"] = o.RequestID
+	}
+	return fields
+}
+
+type operationKey struct{}
+
+// With returns a copy of ctx carrying op
+func With(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// From returns the operation stored in ctx, if any
+func From(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// WithChannel returns a copy of ctx whose operation arrives through channel,
+// keeping the other attributes
+func WithChannel(ctx context.Context, channel string) context.Context {
+	op, _ := From(ctx)
+	op.Channel = channel
+	return With(ctx, op)
+}
+
+// WithReason returns a copy of ctx whose operation carries reason
+func WithReason(ctx context.Context, reason string) context.Context {
+	op, _ := From(ctx)
+	op.Reason = reason
+	return With(ctx, op)
+}
+
+// WithIdempotencyKey returns a copy of ctx whose operation carries the client
+// supplied idempotency key
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	op, _ := From(ctx)
+	op.IdempotencyKey = key
+	return With(ctx, op)
+}
+
+// WithRequestID returns a copy of ctx whose operation carries the ID of the
+// request it serves
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	op, _ := From(ctx)
+	op.RequestID = requestID
+	return With(ctx, op)
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx whose operation was requested from ip.
+// The address is kept apart from the operation, which is published with
+// events, and only recorded in the audit log.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFrom returns the client address stored in ctx, empty if none
+func ClientIPFrom(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// Details annotate the transaction an operation records: a free-text memo,
+// a reference the client identifies it by, such as an order ID, and
+// key-value metadata. They are kept apart from the operation, which is
+// logged and compared as a whole.
+type Details struct {
+	Memo      string
+	Reference string
+	Metadata  map[string]string
+}
+
+// IsZero reports whether no detail is set
+func (d Details) IsZero() bool {
+	return d.Memo == "" && d.Reference == "" && len(d.Metadata) == 0
+}
+
+type detailsKey struct{}
+
+// WithDetails returns a copy of ctx whose operation records details with its
+// transaction
+func WithDetails(ctx context.Context, details Details) context.Context {
+	return context.WithValue(ctx, detailsKey{}, details)
+}
+
+// DetailsFrom returns the transaction details stored in ctx, zero if none
+func DetailsFrom(ctx context.Context) Details {
+	details, _ := ctx.Value(detailsKey{}).(Details)
+	return details
+}
+
+// LogHook adds the attributes of the operation in the context of a log entry
+// to the entry, so entries logged with WithContext can be correlated with the
+// request or job they belong to. Fields set on the entry take precedence.
+type LogHook struct{}
+
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	op, ok := From(entry.Context)
+	if !ok {
+		return nil
+	}
+	for key, value := range op.Fields() {
+		if _, set := entry.Data[key]; !set {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package operation
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperation(t *testing.T) {
+	_, ok := From(context.Background())
+	assert.False(t, ok)
+
+	ctx := With(context.Background(), Operation{Actor: "user1", Channel: ChannelAPI})
+	ctx = WithIdempotencyKey(ctx, "key1")
+	ctx = WithChannel(ctx, ChannelBatch)
+	ctx = WithReason(ctx, "payroll")
+	ctx = WithRequestID(ctx, "req1")
+
+	op, ok := From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Operation{Actor: "user1", Channel: ChannelBatch, Reason: "payroll", IdempotencyKey: "key1", RequestID: "req1"}, op)
+	assert.Equal(t, logrus.Fields{
+		"actor":          "user1",
+		"channel":        ChannelBatch,
+		"reason":         "payroll",
+		"idempotencyKey": "key1",
+		"requestID":      "req1",
+	}, op.Fields())
+}
+
+func TestDetails(t *testing.T) {
+	assert.True(t, DetailsFrom(context.Background()).IsZero())
+
+	details := Details{Reference: "order-42", Metadata: map[string]string{"invoice": "INV-7"}}
+	ctx := WithDetails(context.Background(), details)
+	assert.Equal(t, details, DetailsFrom(ctx))
+	assert.False(t, DetailsFrom(ctx).IsZero())
+
+	// Details do not change the operation
+	_, ok := From(ctx)
+	assert.False(t, ok)
+}
+
+func TestClientIP(t *testing.T) {
+	assert.Empty(t, ClientIPFrom(context.Background()))
+
+	ctx := WithClientIP(With(context.Background(), Operation{Channel: ChannelAPI}), "203.0.113.7")
+	assert.Equal(t, "203.0.113.7", ClientIPFrom(ctx))
+
+	// The address is not part of the operation published with events
+	op, _ := From(ctx)
+	assert.Equal(t, Operation{Channel: ChannelAPI}, op)
+}
+
+func TestOperation_Fields(t *testing.T) {
+	// Unset attributes are left out of the log
+	assert.Equal(t, logrus.Fields{"channel": ChannelJob}, Operation{Channel: ChannelJob}.Fields())
+}
+
+func TestLogHook(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(LogHook{})
+	hook := test.NewLocal(logger)
+	ctx := WithRequestID(context.Background(), "req1")
+
+	logger.WithContext(ctx).Info("with context")
+	assert.Equal(t, logrus.Fields{"requestID": "req1"}, hook.LastEntry().Data)
+
+	// Fields of the entry win over the operation
+	logger.WithContext(ctx).WithField("requestID", "other").Info("overridden")
+	assert.Equal(t, "other", hook.LastEntry().Data["requestID"])
+
+	logger.Info("without context")
+	assert.Empty(t, hook.LastEntry().Data)
+}
@@ -0,0 +1,118 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// CodeLength is the number of digits of a one-time password
+const CodeLength = 6
+
+// Message delivers the one-time password confirming a transfer to the
+// sender of the transfer
+type Message struct {
+	UserID         string          `json:"user_id"`
+	ConfirmationID string          `json:"confirmation_id"`
+	ReceiverID     string          `json:"receiver_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Code           string          `json:"code"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+// Notifier delivers one-time passwords to users, for example by SMS, email
+// or push notification
+type Notifier interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewCode returns a random code of CodeLength digits
+func NewCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", CodeLength, n.Int64()), nil
+}
+
+// Hash returns the hash of code that is stored in its place
+func Hash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether code hashes to hash, in constant time
+func Matches(hash, code string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(Hash(code))) == 1
+}
+
+// LogNotifier writes one-time passwords to the application log. It is used
+// when no notifier is configured and only suits development, as anyone
+// reading the log can confirm transfers.
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, message Message) error {
+	n.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":         message.UserID,
+		"confirmationID": message.ConfirmationID,
+		"code":           message.Code,
+		"expiresAt":      message.ExpiresAt,
+	}).Info("Transfer confirmation code sent")
+	return nil
+}
+
+// WebhookNotifier sends each message as a JSON POST to a fixed URL, for a
+// messaging service to deliver to the user. Any status other than 2xx fails
+// the delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", message.ConfirmationID)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notifier responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
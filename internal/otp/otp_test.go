@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+	code, err := NewCode()
+	require.NoError(t, err)
+	assert.Len(t, code, CodeLength)
+	assert.Regexp(t, `^[0-9]+$`, code)
+
+	hash := Hash(code)
+	assert.NotContains(t, hash, code)
+	assert.True(t, Matches(hash, code))
+	assert.False(t, Matches(hash, "not-it"))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	message := Message{
+		UserID:         "user1",
+		ConfirmationID: "7",
+		ReceiverID:     "user2",
+		Amount:         decimal.NewFromInt(5000),
+		Code:           "012345",
+		ExpiresAt:      time.Now().Add(5 * time.Minute),
+	}
+
+	t.Run("posts the message", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "7", r.Header.Get("Idempotency-Key"))
+			var received Message
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			assert.Equal(t, "012345", received.Code)
+			assert.True(t, received.Amount.Equal(message.Amount))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhookNotifier(server.URL, time.Second).Send(context.Background(), message))
+	})
+
+	t.Run("other statuses fail the delivery", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		assert.Error(t, NewWebhookNotifier(server.URL, time.Second).Send(context.Background(), message))
+	})
+}
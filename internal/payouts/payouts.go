@@ -0,0 +1,124 @@
+package payouts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrRejected marks a payout the provider refused for good, for example
+	// an invalid destination. Other errors are transient and the payout is
+	// retried.
+	ErrRejected = errors.New("payout rejected")
+	// ErrUnavailable marks a transient failure where the provider certainly
+	// did not accept the payout: it could not be reached, or it answered 429
+	// or 503. Only these payouts may fail over to another provider; after a
+	// timeout the payout may have gone through and is retried with the same
+	// provider.
+	ErrUnavailable = errors.New("payout provider unavailable")
+)
+
+// Payout is an amount to send to an external destination on behalf of a
+// withdrawal
+type Payout struct {
+	WithdrawalID string          `json:"withdrawal_id"`
+	UserID       string          `json:"user_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency,omitempty"`
+	Destination  string          `json:"destination"`
+}
+
+// PayoutProvider sends payouts and returns the provider's reference for them.
+// A payout may be sent more than once when a worker stops before recording
+// the outcome, so providers must treat WithdrawalID as an idempotency key.
+type PayoutProvider interface {
+	Pay(ctx context.Context, payout Payout) (string, error)
+}
+
+// LogProvider writes payouts to the application log and reports them as
+// sent. It is used when no payout provider is configured.
+type LogProvider struct {
+	logger *logrus.Logger
+}
+
+func NewLogProvider(logger *logrus.Logger) *LogProvider {
+	return &LogProvider{logger: logger}
+}
+
+func (p *LogProvider) Pay(ctx context.Context, payout Payout) (string, error) {
+	p.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"withdrawalID": payout.WithdrawalID,
+		"userID":       payout.UserID,
+		"amount":       payout.Amount,
+	}).Info("Payout sent")
+	return "log-" + payout.WithdrawalID, nil
+}
+
+// WebhookProvider sends each payout as a JSON POST to a fixed URL with the
+// withdrawal ID in the Idempotency-Key header. A 2xx response carries the
+// provider reference as {"reference": "..."}; other 4xx responses, except
+// 408 and 429, reject the payout. Connection failures, 429 and 503 report
+// the provider unavailable.
+type WebhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookProvider(url string, timeout time.Duration) *WebhookProvider {
+	return &WebhookProvider{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *WebhookProvider) Pay(ctx context.Context, payout Payout) (string, error) {
+	body, err := json.Marshal(payout)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", payout.WithdrawalID)
+
+	resp, err := p.client.Do(req)
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		var result struct {
+			Reference string `json:"reference"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("decode payout response: %w", err)
+		}
+		return result.Reference, nil
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("%w: provider responded with status %d", ErrRejected, resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("%w: provider responded with status %d", ErrUnavailable, resp.StatusCode)
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("provider responded with status %d", resp.StatusCode)
+	}
+}
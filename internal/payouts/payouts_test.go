@@ -0,0 +1,86 @@
+package payouts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookProvider(t *testing.T) {
+	payout := Payout{
+		WithdrawalID: "42",
+		UserID:       "user1",
+		Amount:       decimal.RequireFromString("25.50"),
+		Destination:  "GB33BUKB20201555555555",
+	}
+
+	t.Run("returns the provider reference", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "42", r.Header.Get("Idempotency-Key"))
+			var received Payout
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			assert.True(t, received.Amount.Equal(payout.Amount))
+			assert.Equal(t, payout.Destination, received.Destination)
+			_, _ = w.Write([]byte(`{"reference": "po_123"}`))
+		}))
+		defer server.Close()
+
+		reference, err := NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+		require.NoError(t, err)
+		assert.Equal(t, "po_123", reference)
+	})
+
+	t.Run("client errors reject the payout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}))
+		defer server.Close()
+
+		_, err := NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+		assert.ErrorIs(t, err, ErrRejected)
+	})
+
+	t.Run("server errors and throttling are transient", func(t *testing.T) {
+		for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+
+			_, err := NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrRejected)
+			server.Close()
+		}
+	})
+
+	t.Run("unreachable and unavailable providers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		_, err := NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+		assert.ErrorIs(t, err, ErrUnavailable)
+
+		// Nothing listens on the URL once the server is closed
+		server.Close()
+		_, err = NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+		assert.ErrorIs(t, err, ErrUnavailable)
+	})
+
+	t.Run("bad gateway may hide a sent payout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := NewWebhookProvider(server.URL, time.Second).Pay(context.Background(), payout)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrUnavailable)
+	})
+}
@@ -0,0 +1,211 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/models"
+)
+
+const (
+	// healthWindow is how long a payout outcome counts towards the health of
+	// its provider. A provider that failed is tried again once its failures
+	// have left the window.
+	healthWindow = 5 * time.Minute
+	// healthSamples caps the outcomes kept per provider
+	healthSamples = 100
+	// minHealthyRate is the success rate below which a provider is reported
+	// unhealthy
+	minHealthyRate = 0.5
+)
+
+// ErrUnknownProvider is returned for a payout assigned to a provider that is
+// no longer configured
+var ErrUnknownProvider = errors.New("unknown payout provider")
+
+// NamedProvider is a payout provider with the name it is routed, reported
+// and recorded on withdrawals by, and the rules of the payouts it takes
+type NamedProvider struct {
+	Name     string
+	Provider PayoutProvider
+	Rules    Rules
+}
+
+// ProviderHealth summarises the payouts a provider handled within the health
+// window. Rejected payouts count as successes: the provider answered.
+type ProviderHealth struct {
+	Name          string     `json:"name"`
+	Rules         Rules      `json:"rules"`
+	Healthy       bool       `json:"healthy"`
+	Payouts       int        `json:"payouts"`
+	Failures      int        `json:"failures"`
+	SuccessRate   float64    `json:"success_rate"`
+	MeanLatencyMS float64    `json:"mean_latency_ms"`
+	LastError     *string    `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+}
+
+type payoutOutcome struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// Router sends payouts through several providers. New payouts are routed to
+// the providers whose rules accept them, healthiest and cheapest first; a
+// payout that was sent before must go to the same provider again, so callers
+// record the route and pass the provider to Pay.
+type Router struct {
+	providers []NamedProvider
+	metrics   *metrics.Metrics
+	now       func() time.Time
+
+	mu          sync.Mutex
+	outcomes    map[string][]payoutOutcome
+	lastError   map[string]string
+	lastErrorAt map[string]time.Time
+}
+
+// NewRouter routes payouts between at least one provider, all with distinct
+// names. The order of providers breaks ties between equally healthy ones.
+func NewRouter(providers []NamedProvider, m *metrics.Metrics) *Router {
+	return &Router{
+		providers:   providers,
+		metrics:     m,
+		now:         time.Now,
+		outcomes:    make(map[string][]payoutOutcome),
+		lastError:   make(map[string]string),
+		lastErrorAt: make(map[string]time.Time),
+	}
+}
+
+// Route returns the providers whose rules accept payout, best first:
+// healthy providers before unhealthy ones, then by estimated fee, success
+// rate and latency. Providers without recent payouts count as fully
+// successful. The route is empty when no provider accepts the payout.
+func (r *Router) Route(payout Payout) []models.PayoutCandidate {
+	type candidate struct {
+		health ProviderHealth
+		fee    decimal.Decimal
+	}
+	var candidates []candidate
+	for i, health := range r.Health() {
+		if rules := r.providers[i].Rules; rules.Accepts(payout) {
+			candidates = append(candidates, candidate{health: health, fee: rules.Fee(payout.Amount)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.health.Healthy != b.health.Healthy {
+			return a.health.Healthy
+		}
+		if !a.fee.Equal(b.fee) {
+			return a.fee.LessThan(b.fee)
+		}
+		if a.health.SuccessRate != b.health.SuccessRate {
+			return a.health.SuccessRate > b.health.SuccessRate
+		}
+		return a.health.MeanLatencyMS < b.health.MeanLatencyMS
+	})
+
+	route := make([]models.PayoutCandidate, len(candidates))
+	for i, c := range candidates {
+		route[i] = models.PayoutCandidate{Provider: c.health.Name, EstimatedFee: c.fee}
+	}
+	return route
+}
+
+// Pay sends payout through the named provider and records the latency and
+// outcome of the call
+func (r *Router) Pay(ctx context.Context, provider string, payout Payout) (string, error) {
+	var selected PayoutProvider
+	for _, p := range r.providers {
+		if p.Name == provider {
+			selected = p.Provider
+		}
+	}
+	if selected == nil {
+		return "", fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+
+	start := r.now()
+	reference, err := selected.Pay(ctx, payout)
+	latency := r.now().Sub(start)
+
+	// A payout cut short by shutdown says nothing about the provider
+	if ctx.Err() != nil {
+		return reference, err
+	}
+
+	outcome := metrics.OutcomeSuccess
+	switch {
+	case errors.Is(err, ErrRejected):
+		outcome = metrics.OutcomeRejected
+	case err != nil:
+		outcome = metrics.OutcomeError
+	}
+	r.metrics.ObservePayout(provider, outcome, latency)
+	r.record(provider, latency, err)
+	return reference, err
+}
+
+func (r *Router) record(provider string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	failed := err != nil && !errors.Is(err, ErrRejected)
+	outcomes := append(r.outcomes[provider], payoutOutcome{at: now, latency: latency, failed: failed})
+	if len(outcomes) > healthSamples {
+		outcomes = outcomes[len(outcomes)-healthSamples:]
+	}
+	r.outcomes[provider] = outcomes
+	if failed {
+		r.lastError[provider] = err.Error()
+		r.lastErrorAt[provider] = now
+	}
+}
+
+// Health returns the health of every provider in the order they were
+// configured
+func (r *Router) Health() []ProviderHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := r.now().Add(-healthWindow)
+	health := make([]ProviderHealth, 0, len(r.providers))
+	for _, p := range r.providers {
+		outcomes := r.outcomes[p.Name]
+		for len(outcomes) > 0 && outcomes[0].at.Before(since) {
+			outcomes = outcomes[1:]
+		}
+		r.outcomes[p.Name] = outcomes
+
+		h := ProviderHealth{Name: p.Name, Rules: p.Rules, Payouts: len(outcomes), SuccessRate: 1}
+		var latency time.Duration
+		for _, outcome := range outcomes {
+			latency += outcome.latency
+			if outcome.failed {
+				h.Failures++
+			}
+		}
+		if h.Payouts > 0 {
+			h.SuccessRate = float64(h.Payouts-h.Failures) / float64(h.Payouts)
+			h.MeanLatencyMS = float64(latency.Microseconds()) / float64(h.Payouts) / 1000
+		}
+		h.Healthy = h.SuccessRate >= minHealthyRate
+		if message, ok := r.lastError[p.Name]; ok {
+			at := r.lastErrorAt[p.Name]
+			h.LastError, h.LastErrorAt = &message, &at
+		}
+		health = append(health, h)
+	}
+	return health
+}
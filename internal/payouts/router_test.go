@@ -0,0 +1,146 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type providerFunc func(ctx context.Context, payout Payout) (string, error)
+
+func (f providerFunc) Pay(ctx context.Context, payout Payout) (string, error) {
+	return f(ctx, payout)
+}
+
+func TestRouter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var bankAErr error
+	bankA := providerFunc(func(ctx context.Context, payout Payout) (string, error) {
+		now = now.Add(200 * time.Millisecond)
+		return "a_" + payout.WithdrawalID, bankAErr
+	})
+	bankB := providerFunc(func(ctx context.Context, payout Payout) (string, error) {
+		now = now.Add(800 * time.Millisecond)
+		return "b_" + payout.WithdrawalID, nil
+	})
+	router := NewRouter([]NamedProvider{{Name: "bank_a", Provider: bankA}, {Name: "bank_b", Provider: bankB}}, nil)
+	router.now = func() time.Time { return now }
+	ctx := context.Background()
+	best := func() string {
+		return router.Route(Payout{Amount: decimal.NewFromInt(10)})[0].Provider
+	}
+
+	t.Run("untried providers are chosen in order", func(t *testing.T) {
+		assert.Equal(t, "bank_a", best())
+	})
+
+	t.Run("the faster provider is preferred", func(t *testing.T) {
+		_, err := router.Pay(ctx, "bank_b", Payout{WithdrawalID: "1"})
+		require.NoError(t, err)
+		reference, err := router.Pay(ctx, "bank_a", Payout{WithdrawalID: "2"})
+		require.NoError(t, err)
+		assert.Equal(t, "a_2", reference)
+
+		assert.Equal(t, "bank_a", best())
+		health := router.Health()
+		assert.Equal(t, 200.0, health[0].MeanLatencyMS)
+		assert.Equal(t, 800.0, health[1].MeanLatencyMS)
+	})
+
+	t.Run("failures move payouts to the healthier provider", func(t *testing.T) {
+		bankAErr = errors.New("connection reset")
+		_, err := router.Pay(ctx, "bank_a", Payout{WithdrawalID: "3"})
+		require.Error(t, err)
+		bankAErr = fmt.Errorf("%w: provider responded with status 422", ErrRejected)
+		_, err = router.Pay(ctx, "bank_a", Payout{WithdrawalID: "4"})
+		require.ErrorIs(t, err, ErrRejected)
+
+		assert.Equal(t, "bank_b", best())
+		health := router.Health()[0]
+		assert.Equal(t, 3, health.Payouts)
+		assert.Equal(t, 1, health.Failures)
+		assert.InDelta(t, 2.0/3, health.SuccessRate, 1e-9)
+		assert.True(t, health.Healthy)
+		require.NotNil(t, health.LastError)
+		assert.Equal(t, "connection reset", *health.LastError)
+	})
+
+	t.Run("failures leave the window", func(t *testing.T) {
+		now = now.Add(healthWindow + time.Second)
+
+		assert.Equal(t, "bank_a", best())
+		health := router.Health()[0]
+		assert.Equal(t, 0, health.Payouts)
+		assert.Equal(t, 1.0, health.SuccessRate)
+		assert.NotNil(t, health.LastError)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := router.Pay(ctx, "bank_c", Payout{WithdrawalID: "5"})
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+	})
+}
+
+func TestRouter_Route(t *testing.T) {
+	ok := providerFunc(func(ctx context.Context, payout Payout) (string, error) {
+		return "ok", nil
+	})
+	down := providerFunc(func(ctx context.Context, payout Payout) (string, error) {
+		return "", fmt.Errorf("%w: provider responded with status 503", ErrUnavailable)
+	})
+	rules, err := ParseRules(`{
+		"cheap_eur": {"currencies": ["eur"], "max_amount": "1000", "fixed_fee": "0.10"},
+		"wire": {"min_amount": "100", "fixed_fee": "5"},
+		"card": {"currencies": ["USD", "EUR"], "percent_fee": "1.5"}
+	}`)
+	require.NoError(t, err)
+	router := NewRouter([]NamedProvider{
+		{Name: "wire", Provider: ok, Rules: rules["wire"]},
+		{Name: "card", Provider: down, Rules: rules["card"]},
+		{Name: "cheap_eur", Provider: ok, Rules: rules["cheap_eur"]},
+	}, nil)
+	providers := func(payout Payout) []string {
+		var names []string
+		for _, candidate := range router.Route(payout) {
+			names = append(names, candidate.Provider)
+		}
+		return names
+	}
+
+	t.Run("filters by currency and amount, cheapest first", func(t *testing.T) {
+		assert.Equal(t, []string{"cheap_eur", "card"}, providers(Payout{Currency: "EUR", Amount: decimal.NewFromInt(50)}))
+		assert.Equal(t, []string{"card", "wire"}, providers(Payout{Currency: "USD", Amount: decimal.NewFromInt(200)}))
+		assert.Equal(t, []string{"wire", "card"}, providers(Payout{Currency: "USD", Amount: decimal.NewFromInt(1000)}))
+
+		route := router.Route(Payout{Currency: "USD", Amount: decimal.NewFromInt(200)})
+		assert.True(t, route[0].EstimatedFee.Equal(decimal.NewFromInt(3)))
+	})
+
+	t.Run("unhealthy providers come last", func(t *testing.T) {
+		_, err := router.Pay(context.Background(), "card", Payout{WithdrawalID: "1"})
+		require.ErrorIs(t, err, ErrUnavailable)
+
+		assert.Equal(t, []string{"wire", "card"}, providers(Payout{Currency: "USD", Amount: decimal.NewFromInt(200)}))
+	})
+
+	t.Run("no provider accepts the payout", func(t *testing.T) {
+		assert.Empty(t, providers(Payout{Currency: "GBP", Amount: decimal.NewFromInt(10)}))
+	})
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, config := range []string{`{"a": {"fixed_fee": "-1"}}`, `{"a": {"min_amount": "10", "max_amount": "5"}}`, `[`} {
+		_, err := ParseRules(config)
+		assert.Error(t, err, config)
+	}
+}
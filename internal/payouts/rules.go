@@ -0,0 +1,65 @@
+package payouts
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rules limit the payouts routed to a provider and estimate what it charges
+// for them. The zero value accepts every payout at no cost.
+type Rules struct {
+	// Currencies is empty when the provider pays out every currency
+	Currencies []string         `json:"currencies,omitempty"`
+	MinAmount  *decimal.Decimal `json:"min_amount,omitempty"`
+	MaxAmount  *decimal.Decimal `json:"max_amount,omitempty"`
+	FixedFee   decimal.Decimal  `json:"fixed_fee"`
+	// PercentFee is charged on the amount, in percent
+	PercentFee decimal.Decimal `json:"percent_fee"`
+}
+
+// Accepts reports whether payout may be routed to the provider
+func (r Rules) Accepts(payout Payout) bool {
+	if len(r.Currencies) > 0 && !slices.Contains(r.Currencies, payout.Currency) {
+		return false
+	}
+	if r.MinAmount != nil && payout.Amount.LessThan(*r.MinAmount) {
+		return false
+	}
+	if r.MaxAmount != nil && payout.Amount.GreaterThan(*r.MaxAmount) {
+		return false
+	}
+	return true
+}
+
+// Fee estimates what the provider charges for a payout of amount
+func (r Rules) Fee(amount decimal.Decimal) decimal.Decimal {
+	return r.FixedFee.Add(amount.Mul(r.PercentFee).Div(decimal.NewFromInt(100)))
+}
+
+// ParseRules reads the rules of each provider, by name, from their JSON
+// configuration. Currency codes are upper-cased.
+func ParseRules(config string) (map[string]Rules, error) {
+	rules := map[string]Rules{}
+	if strings.TrimSpace(config) == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(config), &rules); err != nil {
+		return nil, err
+	}
+	for name, rule := range rules {
+		for i, currency := range rule.Currencies {
+			rule.Currencies[i] = strings.ToUpper(currency)
+		}
+		if rule.FixedFee.IsNegative() || rule.PercentFee.IsNegative() {
+			return nil, errors.New(name + ": fees cannot be negative")
+		}
+		if rule.MinAmount != nil && rule.MaxAmount != nil && rule.MinAmount.GreaterThan(*rule.MaxAmount) {
+			return nil, errors.New(name + ": min_amount cannot exceed max_amount")
+		}
+	}
+	return rules, nil
+}
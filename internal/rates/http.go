@@ -0,0 +1,94 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// HTTPProvider fetches the rate table as JSON from a URL and reuses it for
+// ttl. The response is {"base": "USD", "rates": {"EUR": "0.92"}} with an
+// optional RFC 3339 "as_of"; rates may be strings or numbers. A table older
+// than ttl is never used: when the URL cannot be reached conversions fail
+// with ErrUnavailable rather than use stale rates.
+type HTTPProvider struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	table     Table
+	fetchedAt time.Time
+}
+
+func NewHTTPProvider(url string, timeout, ttl time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}
+}
+
+// Rates returns the cached table, fetching it again once ttl has passed.
+// Concurrent callers share a single fetch.
+func (p *HTTPProvider) Rates(ctx context.Context) (Table, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.fetchedAt.IsZero() && p.now().Sub(p.fetchedAt) < p.ttl {
+		return p.table, nil
+	}
+
+	table, err := p.fetch(ctx)
+	if err != nil {
+		return Table{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	p.table, p.fetchedAt = table, p.now()
+	return table, nil
+}
+
+func (p *HTTPProvider) fetch(ctx context.Context) (Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return Table{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Table{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Table{}, fmt.Errorf("rate provider responded with status %d", resp.StatusCode)
+	}
+
+	var table Table
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return Table{}, fmt.Errorf("decode rates: %w", err)
+	}
+	table.Base = strings.ToUpper(table.Base)
+	if table.Base == "" {
+		return Table{}, fmt.Errorf("rate provider returned no base currency")
+	}
+	rates := make(map[string]decimal.Decimal, len(table.Rates))
+	for code, rate := range table.Rates {
+		rates[strings.ToUpper(code)] = rate
+	}
+	table.Rates = rates
+	if table.AsOf.IsZero() {
+		table.AsOf = p.now().UTC()
+	}
+	return table, nil
+}
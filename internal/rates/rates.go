@@ -0,0 +1,104 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// rateScale is the number of decimals cross rates are computed to. Rates are
+// stored in NUMERIC(30, 12) columns.
+const rateScale = 12
+
+var (
+	// ErrUnsupportedCurrency is returned for a currency the provider has no
+	// rate for
+	ErrUnsupportedCurrency = errors.New("no exchange rate for currency")
+	// ErrUnavailable wraps the errors of a provider that could not be reached
+	ErrUnavailable = errors.New("exchange rates are unavailable")
+)
+
+// Table quotes currencies against a base currency: one unit of Base buys
+// Rates[code] units of code
+type Table struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+	AsOf  time.Time                  `json:"as_of"`
+}
+
+// Rate returns how many units of to one unit of from buys. Rates between two
+// quoted currencies are crossed through the base currency.
+func (t Table) Rate(from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	fromRate, err := t.baseRate(from)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	toRate, err := t.baseRate(to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return toRate.DivRound(fromRate, rateScale), nil
+}
+
+func (t Table) baseRate(currency string) (decimal.Decimal, error) {
+	if currency == t.Base {
+		return decimal.NewFromInt(1), nil
+	}
+	rate, ok := t.Rates[currency]
+	if !ok || !rate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	return rate, nil
+}
+
+// FXRateProvider supplies the exchange rates transfers between wallets of
+// different currencies are converted at
+type FXRateProvider interface {
+	Rates(ctx context.Context) (Table, error)
+}
+
+// StaticProvider quotes a fixed table, typically from configuration
+type StaticProvider struct {
+	table Table
+}
+
+func NewStaticProvider(table Table) *StaticProvider {
+	return &StaticProvider{table: table}
+}
+
+func (p *StaticProvider) Rates(ctx context.Context) (Table, error) {
+	return p.table, nil
+}
+
+// ParseTable reads rates against base written as comma separated CODE=rate
+// pairs, for example "EUR=0.92,GBP=0.79". Codes are upper-cased.
+func ParseTable(base, spec string) (Table, error) {
+	table := Table{
+		Base:  strings.ToUpper(strings.TrimSpace(base)),
+		Rates: make(map[string]decimal.Decimal),
+		AsOf:  time.Now().UTC(),
+	}
+	if table.Base == "" {
+		return Table{}, errors.New("base currency is required")
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		code, value, found := strings.Cut(pair, "=")
+		code = strings.ToUpper(strings.TrimSpace(code))
+		rate, err := decimal.NewFromString(strings.TrimSpace(value))
+		if !found || code == "" || err != nil || !rate.IsPositive() {
+			return Table{}, fmt.Errorf("invalid rate %q: expected CODE=rate with a positive rate", pair)
+		}
+		table.Rates[code] = rate
+	}
+	return table, nil
+}
@@ -0,0 +1,87 @@
+package rates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_Rate(t *testing.T) {
+	table, err := ParseTable("usd", "EUR=0.8, gbp=0.64")
+	require.NoError(t, err)
+
+	tests := []struct {
+		from, to string
+		rate     string
+	}{
+		{"USD", "EUR", "0.8"},
+		{"EUR", "USD", "1.25"},
+		{"EUR", "GBP", "0.8"},
+		{"GBP", "GBP", "1"},
+	}
+	for _, tt := range tests {
+		rate, err := table.Rate(tt.from, tt.to)
+		require.NoError(t, err)
+		assert.True(t, rate.Equal(decimal.RequireFromString(tt.rate)), "%s/%s: %s", tt.from, tt.to, rate)
+	}
+
+	_, err = table.Rate("USD", "JPY")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestParseTable(t *testing.T) {
+	table, err := ParseTable("USD", "")
+	require.NoError(t, err)
+	assert.Empty(t, table.Rates)
+
+	for _, spec := range []string{"EUR", "EUR=abc", "EUR=-1", "=0.9"} {
+		_, err := ParseTable("USD", spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	requests := 0
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"base": "usd", "rates": {"EUR": "0.92", "GBP": 0.79}, "as_of": "2024-05-01T12:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	provider := NewHTTPProvider(server.URL, time.Second, time.Minute)
+	provider.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	t.Run("fetches the table", func(t *testing.T) {
+		table, err := provider.Rates(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "USD", table.Base)
+		assert.True(t, table.Rates["GBP"].Equal(decimal.RequireFromString("0.79")))
+		assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), table.AsOf)
+	})
+
+	t.Run("reuses the table within ttl", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		_, err := provider.Rates(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("stale tables are not used", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		status = http.StatusServiceUnavailable
+
+		_, err := provider.Rates(ctx)
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, 2, requests)
+	})
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+type entry struct {
+	balance   decimal.Decimal
+	expiresAt time.Time
+}
+
+// CacheRepository is an in-process balance cache for single-instance
+// deployments. Misses are reported with redis.Nil like the Redis cache.
+type CacheRepository struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	// versions outlive invalidated entries, see SetVersionedBalance
+	versions map[string]int64
+	ttl      time.Duration
+}
+
+func NewCacheRepository(ttl time.Duration) *CacheRepository {
+	return &CacheRepository{
+		entries:  make(map[string]entry),
+		versions: make(map[string]int64),
+		ttl:      ttl,
+	}
+}
+
+func (r *CacheRepository) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached, ok := r.entries[userID]
+	if !ok {
+		return decimal.Zero, redis.Nil
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(r.entries, userID)
+		return decimal.Zero, redis.Nil
+	}
+	return cached.balance, nil
+}
+
+// ReadThrough is GetBalance: with a single instance there is nobody else
+// loading the balance to wait for
+func (r *CacheRepository) ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error) {
+	return r.GetBalance(ctx, userID)
+}
+
+func (r *CacheRepository) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = r.ttl
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[userID] = entry{balance: balance, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// SetVersionedBalance caches balance unless a newer version was cached since
+// the last time the wallet was read without a version
+func (r *CacheRepository) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	if ttl == 0 {
+		ttl = r.ttl
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.versions[userID]; ok && current > version {
+		return false, nil
+	}
+	r.versions[userID] = version
+	r.entries[userID] = entry{balance: balance, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (r *CacheRepository) InvalidateBalance(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, userID)
+	return nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+func TestCacheRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCacheRepository(time.Hour)
+
+	if _, err := repo.GetBalance(ctx, "user1"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil error, got %v", err)
+	}
+
+	_ = repo.SetBalance(ctx, "user1", decimal.NewFromInt(100), 0)
+	balance, err := repo.GetBalance(ctx, "user1")
+	if err != nil || !balance.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected cached balance 100, got %s (%v)", balance, err)
+	}
+
+	if balance, err := repo.ReadThrough(ctx, "user1"); err != nil || !balance.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected read-through balance 100, got %s (%v)", balance, err)
+	}
+
+	_ = repo.InvalidateBalance(ctx, "user1")
+	if _, err := repo.GetBalance(ctx, "user1"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil error after invalidation, got %v", err)
+	}
+
+	// A balance of an older version does not replace a newer one, even
+	// after the newer one was invalidated
+	_, _ = repo.SetVersionedBalance(ctx, "user2", decimal.NewFromInt(30), 5, 0)
+	if stored, _ := repo.SetVersionedBalance(ctx, "user2", decimal.NewFromInt(20), 4, 0); stored {
+		t.Errorf("Expected older version to be skipped")
+	}
+	_ = repo.InvalidateBalance(ctx, "user2")
+	if stored, _ := repo.SetVersionedBalance(ctx, "user2", decimal.NewFromInt(20), 4, 0); stored {
+		t.Errorf("Expected older version to be skipped after invalidation")
+	}
+	if stored, _ := repo.SetVersionedBalance(ctx, "user2", decimal.Zero, 6, 0); !stored {
+		t.Errorf("Expected newer version to be stored")
+	}
+	if balance, err := repo.GetBalance(ctx, "user2"); err != nil || !balance.IsZero() {
+		t.Errorf("Expected cached balance 0, got %s (%v)", balance, err)
+	}
+
+	expired := NewCacheRepository(-time.Second)
+	_ = expired.SetBalance(ctx, "user1", decimal.NewFromInt(100), 0)
+	if _, err := expired.GetBalance(ctx, "user1"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil error for expired entry, got %v", err)
+	}
+
+	// An explicit ttl overrides the default
+	_ = expired.SetBalance(ctx, "user1", decimal.NewFromInt(100), time.Hour)
+	if _, err := expired.GetBalance(ctx, "user1"); err != nil {
+		t.Errorf("Expected cached balance with explicit ttl, got %v", err)
+	}
+}
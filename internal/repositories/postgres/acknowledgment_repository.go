@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// AcknowledgmentRepository records receivers acknowledging the transfers
+// they received
+type AcknowledgmentRepository interface {
+	AcknowledgeTransfer(ctx context.Context, receiverID, transactionID string, message *string) (*models.TransferAcknowledgment, error)
+}
+
+var ErrTransferAlreadyAcknowledged = errors.New("transfer already acknowledged")
+
+type PostgresAcknowledgmentRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAcknowledgmentRepository(db *sql.DB, logger *logrus.Logger) *PostgresAcknowledgmentRepository {
+	return &PostgresAcknowledgmentRepository{db: db, logger: logger}
+}
+
+// AcknowledgeTransfer records receiverID acknowledging the completed transfer
+// transactionID it received, with message when set, and the event notifying
+// the sender. A transfer is acknowledged once.
+func (r *PostgresAcknowledgmentRepository) AcknowledgeTransfer(ctx context.Context, receiverID, transactionID string, message *string) (*models.TransferAcknowledgment, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":        receiverID,
+		"transactionID": transactionID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("AcknowledgeTransfer - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	acknowledgment := &models.TransferAcknowledgment{TransactionID: transactionID, ToUserID: receiverID, Message: message}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE transactions SET acknowledged_at = NOW(), acknowledgment_message = $3
+		WHERE id::text = $1 AND to_user_id = $2 AND type = 'transfer' AND status = $4
+			AND acknowledged_at IS NULL
+		RETURNING from_user_id, amount, acknowledged_at`,
+		transactionID, receiverID, message, models.TransactionCompleted,
+	).Scan(&acknowledgment.FromUserID, &acknowledgment.Amount, &acknowledgment.AcknowledgedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		var acknowledged bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT acknowledged_at IS NOT NULL FROM transactions
+			WHERE id::text = $1 AND to_user_id = $2 AND type = 'transfer' AND status = $3`,
+			transactionID, receiverID, models.TransactionCompleted,
+		).Scan(&acknowledged)
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Warn("AcknowledgeTransfer - Cannot find received transfer in the database")
+			return nil, ErrTransactionNotFound
+		}
+		if err != nil {
+			logger.WithError(err).Error("AcknowledgeTransfer - Query transfer failed")
+			return nil, err
+		}
+		logger.Warn("AcknowledgeTransfer - Transfer already acknowledged")
+		return nil, ErrTransferAlreadyAcknowledged
+	}
+	if err != nil {
+		logger.WithError(err).Error("AcknowledgeTransfer - Update transfer failed")
+		return nil, err
+	}
+
+	payload := events.TransferAcknowledged{
+		TransactionID:  acknowledgment.TransactionID,
+		FromUserID:     acknowledgment.FromUserID,
+		ToUserID:       acknowledgment.ToUserID,
+		Amount:         acknowledgment.Amount,
+		AcknowledgedAt: acknowledgment.AcknowledgedAt,
+	}
+	if message != nil {
+		payload.Message = *message
+	}
+	if err = enqueueEvent(ctx, sqlTx{tx}, events.New(events.TypeTransferAcknowledged, payload), acknowledgment.FromUserID); err != nil {
+		logger.WithError(err).Error("AcknowledgeTransfer - Record transfer acknowledged event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("AcknowledgeTransfer - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.Info("Transfer acknowledged")
+	return acknowledgment, nil
+}
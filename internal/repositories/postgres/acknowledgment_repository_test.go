@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestAcknowledgmentRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewAcknowledgmentRepository(mockDB, logrus.New())
+	now := time.Now()
+	message := "Thanks for dinner!"
+
+	t.Run("AcknowledgeTransfer", func(t *testing.T) {
+		t.Run("notifies the sender", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions SET acknowledged_at = NOW\(\), acknowledgment_message = \$3`).
+				WithArgs("12", "user2", &message, models.TransactionCompleted).
+				WillReturnRows(sqlmock.NewRows([]string{"from_user_id", "amount", "acknowledged_at"}).AddRow("user1", "25.50", now))
+			mock.ExpectExec(`INSERT INTO outbox_events`).
+				WithArgs(sqlmock.AnyArg(), events.TypeTransferAcknowledged, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			acknowledgment, err := repo.AcknowledgeTransfer(ctx, "user2", "12", &message)
+			require.NoError(t, err)
+			require.Equal(t, "user1", acknowledgment.FromUserID)
+			require.Equal(t, "user2", acknowledgment.ToUserID)
+			require.True(t, decimal.RequireFromString("25.50").Equal(acknowledgment.Amount))
+			require.Equal(t, message, *acknowledgment.Message)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("transfer not received", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions SET acknowledged_at`).WithArgs("12", "user3", nil, models.TransactionCompleted).
+				WillReturnRows(sqlmock.NewRows([]string{"from_user_id", "amount", "acknowledged_at"}))
+			mock.ExpectQuery(`SELECT acknowledged_at IS NOT NULL FROM transactions`).WithArgs("12", "user3", models.TransactionCompleted).
+				WillReturnRows(sqlmock.NewRows([]string{"acknowledged"}))
+			mock.ExpectRollback()
+
+			_, err := repo.AcknowledgeTransfer(ctx, "user3", "12", nil)
+			require.ErrorIs(t, err, ErrTransactionNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already acknowledged", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions SET acknowledged_at`).WithArgs("12", "user2", nil, models.TransactionCompleted).
+				WillReturnRows(sqlmock.NewRows([]string{"from_user_id", "amount", "acknowledged_at"}))
+			mock.ExpectQuery(`SELECT acknowledged_at IS NOT NULL FROM transactions`).WithArgs("12", "user2", models.TransactionCompleted).
+				WillReturnRows(sqlmock.NewRows([]string{"acknowledged"}).AddRow(true))
+			mock.ExpectRollback()
+
+			_, err := repo.AcknowledgeTransfer(ctx, "user2", "12", nil)
+			require.ErrorIs(t, err, ErrTransferAlreadyAcknowledged)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
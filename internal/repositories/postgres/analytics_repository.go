@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// AnalyticsRepository aggregates the ledger of a wallet over a period
+type AnalyticsRepository interface {
+	Summarize(ctx context.Context, userID string, from, to time.Time) (*models.MonthlyAnalytics, error)
+}
+
+type PostgresAnalyticsRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAnalyticsRepository(db *sql.DB, logger *logrus.Logger) *PostgresAnalyticsRepository {
+	return &PostgresAnalyticsRepository{db: db, logger: logger}
+}
+
+// Summarize totals the transactions of userID created in [from, to) that did
+// not fail. The month of the result is left to the caller.
+func (r *PostgresAnalyticsRepository) Summarize(ctx context.Context, userID string, from, to time.Time) (*models.MonthlyAnalytics, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("Summarize - userID cannot be an empty string")
+		return nil, ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"from":   from,
+		"to":     to,
+	})
+
+	summary := models.MonthlyAnalytics{UserID: userID, Categories: []models.CategorySpending{}}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT
+			COALESCE(SUM(CASE
+				WHEN t.to_user_id = w.user_id THEN COALESCE(t.converted_amount, t.amount)
+				WHEN t.type = 'deposit' THEN t.amount
+				ELSE 0 END), 0),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.from_user_id = w.user_id AND t.type IN ('transfer', 'withdrawal')), 0),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.from_user_id = w.user_id AND t.type = $4), 0),
+			COUNT(t.id) FILTER (WHERE t.from_user_id = w.user_id AND t.type = $5),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.from_user_id = w.user_id AND t.type = $5), 0),
+			COALESCE(SUM(`+ledgerEffect+`), 0)
+		FROM wallets w
+		LEFT JOIN transactions t ON (t.from_user_id = w.user_id OR t.to_user_id = w.user_id)
+			AND t.status <> 'failed'
+			AND t.created_at >= $2 AND t.created_at < $3
+		WHERE w.user_id = $1
+		GROUP BY w.user_id`,
+		userID, from, to, models.TransactionTypeFee, models.TransactionTypeRoundUp,
+	).Scan(
+		&summary.Income,
+		&summary.Spending,
+		&summary.Fees,
+		&summary.RoundUps.Count,
+		&summary.RoundUps.Saved,
+		&summary.Net,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("Summarize - Cannot find user in the database")
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("Summarize - Query totals failed")
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT category, COUNT(*), SUM(amount)
+		FROM transactions
+		WHERE from_user_id = $1 AND type IN ('transfer', 'withdrawal')
+			AND status <> 'failed'
+			AND created_at >= $2 AND created_at < $3
+		GROUP BY category
+		ORDER BY SUM(amount) DESC, category`,
+		userID, from, to,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Summarize - Query categories failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category models.CategorySpending
+		if err := rows.Scan(&category.Category, &category.Count, &category.Amount); err != nil {
+			logger.WithError(err).Error("Summarize - Scan category failed")
+			return nil, err
+		}
+		summary.Categories = append(summary.Categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("Summarize - Iterate categories failed")
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestAnalyticsRepository_Summarize(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewAnalyticsRepository(mockDB, logrus.New())
+	from := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	t.Run("totals and categories", func(t *testing.T) {
+		mock.ExpectQuery(`FROM wallets w\s+LEFT JOIN transactions t`).
+			WithArgs("user1", from, to, models.TransactionTypeFee, models.TransactionTypeRoundUp).
+			WillReturnRows(sqlmock.NewRows([]string{"income", "spending", "fees", "round_ups", "saved", "net"}).
+				AddRow("100", "42.5", "1", 3, "1.5", "55"))
+		mock.ExpectQuery(`SELECT category, COUNT\(\*\), SUM\(amount\)`).WithArgs("user1", from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"category", "count", "amount"}).
+				AddRow("groceries", 2, "30").
+				AddRow(nil, 1, "12.5"))
+
+		summary, err := repo.Summarize(ctx, "user1", from, to)
+		require.NoError(t, err)
+		require.True(t, decimal.RequireFromString("42.5").Equal(summary.Spending))
+		require.Equal(t, 3, summary.RoundUps.Count)
+		require.True(t, decimal.RequireFromString("1.5").Equal(summary.RoundUps.Saved))
+		require.Len(t, summary.Categories, 2)
+		require.Equal(t, "groceries", *summary.Categories[0].Category)
+		require.Nil(t, summary.Categories[1].Category)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		mock.ExpectQuery(`FROM wallets w`).WillReturnRows(sqlmock.NewRows([]string{"income"}))
+
+		_, err := repo.Summarize(ctx, "ghost", from, to)
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
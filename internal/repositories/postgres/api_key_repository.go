@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// APIKeyRepository stores the API keys of service-to-service callers
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	// TouchAPIKey records that the key was used at usedAt
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+}
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+const apiKeyColumns = `id, name, secret_hash, subject, roles, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+type PostgresAPIKeyRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, logger *logrus.Logger) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db, logger: logger}
+}
+
+// CreateAPIKey stores key, filling in CreatedAt
+func (r *PostgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{"keyID": key.ID, "subject": key.Subject})
+
+	roles, err := json.Marshal(key.Roles)
+	if err != nil {
+		logger.WithError(err).Error("CreateAPIKey - Encode roles failed")
+		return err
+	}
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		logger.WithError(err).Error("CreateAPIKey - Encode scopes failed")
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (id, name, secret_hash, subject, roles, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		key.ID, key.Name, key.SecretHash, key.Subject, roles, scopes, key.CreatedBy, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreateAPIKey - Create key record failed")
+		return err
+	}
+
+	logger.Info("API key created")
+	return nil
+}
+
+// GetAPIKey returns the key with its secret hash, revoked or not
+func (r *PostgresAPIKeyRepository) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("keyID", id).Error("GetAPIKey - Query key failed")
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns every key, newest first
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at DESC, id`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListAPIKeys - Query keys failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListAPIKeys - Scan keys failed")
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListAPIKeys - Iterate keys failed")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the key and returns it. Revoking a revoked key keeps
+// its original revocation time.
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING `+apiKeyColumns,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("keyID", id).Error("RevokeAPIKey - Update key failed")
+		return nil, err
+	}
+
+	r.logger.WithContext(ctx).WithField("keyID", id).Info("API key revoked")
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)`,
+		id, usedAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("keyID", id).Warn("TouchAPIKey - Update last use failed")
+		return err
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var roles, scopes []byte
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.SecretHash,
+		&key.Subject,
+		&roles,
+		&scopes,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(roles, &key.Roles); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewAPIKeyRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"id", "name", "secret_hash", "subject", "roles", "scopes", "created_by", "created_at",
+		"expires_at", "last_used_at", "revoked_at"}
+
+	t.Run("CreateAPIKey", func(t *testing.T) {
+		key := &models.APIKey{ID: "0123456789abcdef", Name: "settlement", SecretHash: []byte("hash"), Subject: "settlement",
+			Roles: []string{"internal"}, Scopes: []string{"money_movement"}, CreatedBy: "admin1"}
+		mock.ExpectQuery(`INSERT INTO api_keys`).
+			WithArgs("0123456789abcdef", "settlement", []byte("hash"), "settlement", []byte(`["internal"]`), []byte(`["money_movement"]`), "admin1", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+
+		require.NoError(t, repo.CreateAPIKey(ctx, key))
+		require.Equal(t, now, key.CreatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetAPIKey", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, name, secret_hash(.|\n)*FROM api_keys WHERE id = \$1`).
+			WithArgs("0123456789abcdef").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("0123456789abcdef", "settlement", []byte("hash"), "settlement", []byte(`["internal"]`), []byte(`["money_movement"]`), "admin1", now, nil, nil, nil))
+
+		key, err := repo.GetAPIKey(ctx, "0123456789abcdef")
+		require.NoError(t, err)
+		require.Equal(t, []byte("hash"), key.SecretHash)
+		require.Equal(t, []string{"internal"}, key.Roles)
+		require.Equal(t, []string{"money_movement"}, key.Scopes)
+		require.Nil(t, key.RevokedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetAPIKey unknown", func(t *testing.T) {
+		mock.ExpectQuery(`FROM api_keys WHERE id = \$1`).
+			WithArgs("missing").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetAPIKey(ctx, "missing")
+		require.ErrorIs(t, err, ErrAPIKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevokeAPIKey keeps the first revocation", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE api_keys SET revoked_at = COALESCE\(revoked_at, NOW\(\)\)`).
+			WithArgs("0123456789abcdef").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("0123456789abcdef", "settlement", []byte("hash"), "settlement", []byte(`[]`), []byte(`["read"]`), "admin1", now, nil, nil, now))
+
+		key, err := repo.RevokeAPIKey(ctx, "0123456789abcdef")
+		require.NoError(t, err)
+		require.NotNil(t, key.RevokedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TouchAPIKey", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE api_keys SET last_used_at = \$2(.|\n)*last_used_at < \$2`).
+			WithArgs("0123456789abcdef", now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, repo.TouchAPIKey(ctx, "0123456789abcdef", now))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// AuditRepository queries the audit log, which the repositories changing
+// balances and limits append to
+type AuditRepository interface {
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type PostgresAuditRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logger *logrus.Logger) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db, logger: logger}
+}
+
+// ListAuditEntries returns the entries matching filter, newest first
+func (r *PostgresAuditRepository) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListAuditEntries - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	var conditions []string
+	var args []interface{}
+	next := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != nil {
+		conditions = append(conditions, "user_id = "+next(*filter.UserID))
+	}
+	if filter.Action != nil {
+		conditions = append(conditions, "action = "+next(*filter.Action))
+	}
+	if filter.Actor != nil {
+		conditions = append(conditions, "actor = "+next(*filter.Actor))
+	}
+	if filter.RequestID != nil {
+		conditions = append(conditions, "request_id = "+next(*filter.RequestID))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+next(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+next(*filter.To))
+	}
+	if filter.Before > 0 {
+		conditions = append(conditions, "id < "+next(filter.Before))
+	}
+
+	query := `SELECT id, action, user_id, target, COALESCE(transaction_id::text, ''), before_values, after_values,
+		COALESCE(actor, ''), COALESCE(channel, ''), COALESCE(client_ip, ''), COALESCE(request_id, ''), COALESCE(reason, ''), created_at
+		FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ` + next(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListAuditEntries - Query audit log failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Action,
+			&entry.UserID,
+			&entry.Target,
+			&entry.TransactionID,
+			&before,
+			&after,
+			&entry.Actor,
+			&entry.Channel,
+			&entry.ClientIP,
+			&entry.RequestID,
+			&entry.Reason,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListAuditEntries - Scan audit log failed")
+			return nil, err
+		}
+		if entry.Before, err = decodeAuditValues(before); err == nil {
+			entry.After, err = decodeAuditValues(after)
+		}
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("entryID", entry.ID).Error("ListAuditEntries - Decode values failed")
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListAuditEntries - Iterate audit log failed")
+		return nil, err
+	}
+	return entries, nil
+}
+
+func decodeAuditValues(data []byte) (models.AuditValues, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var values models.AuditValues
+	return values, json.Unmarshal(data, &values)
+}
+
+// recordAudit appends entries to the audit log inside tx with the actor,
+// channel, reason, request ID and client address of the operation in ctx.
+// An entry's own reason takes precedence over the operation's.
+func recordAudit(ctx context.Context, tx ledgerTx, entries ...models.AuditEntry) error {
+	op, _ := operation.From(ctx)
+	clientIP := operation.ClientIPFrom(ctx)
+	rows := make([][]interface{}, len(entries))
+	for i, entry := range entries {
+		before, err := encodeAuditValues(entry.Before)
+		if err != nil {
+			return err
+		}
+		after, err := encodeAuditValues(entry.After)
+		if err != nil {
+			return err
+		}
+		reason := entry.Reason
+		if reason == "" {
+			reason = op.Reason
+		}
+		rows[i] = []interface{}{
+			entry.Action, entry.UserID, entry.Target, nullString(entry.TransactionID), before, after,
+			nullString(op.Actor), nullString(op.Channel), nullString(clientIP), nullString(op.RequestID), nullString(reason),
+		}
+	}
+	return bulkExec(ctx, tx,
+		`INSERT INTO audit_log
+		(action, user_id, target, transaction_id, before_values, after_values, actor, channel, client_ip, request_id, reason)
+		VALUES `,
+		rows,
+	)
+}
+
+// nullString is NULL for an empty value
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// encodeAuditValues encodes values for a JSONB column, nil as NULL
+func encodeAuditValues(values models.AuditValues) ([]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	return json.Marshal(values)
+}
+
+// auditBalances returns the audit values of a wallet balance moving from
+// balance by delta
+func auditBalances(balance, delta decimal.Decimal) (before, after models.AuditValues) {
+	return models.AuditValues{"balance": balance}, models.AuditValues{"balance": balance.Add(delta)}
+}
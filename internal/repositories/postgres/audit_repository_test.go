@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// expectAudit expects recordAudit to append entries entries to the audit log
+func expectAudit(mock sqlmock.Sqlmock, entries int64) {
+	mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(0, entries))
+}
+
+func TestAuditRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewAuditRepository(mockDB, logrus.New())
+	columns := []string{"id", "action", "user_id", "target", "transaction_id", "before_values", "after_values",
+		"actor", "channel", "client_ip", "request_id", "reason", "created_at"}
+	now := time.Now()
+
+	t.Run("ListAuditEntries", func(t *testing.T) {
+		userID, action := "user1", models.AuditTransfer
+		from := now.Add(-time.Hour)
+		mock.ExpectQuery(`FROM audit_log WHERE user_id = \$1 AND action = \$2 AND created_at >= \$3 AND id < \$4 ORDER BY id DESC LIMIT \$5`).
+			WithArgs(userID, action, from, int64(40), 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(39), action, userID, "", "12", []byte(`{"balance":"100"}`), []byte(`{"balance":"75"}`), "user1", operation.ChannelAPI, "203.0.113.7", "req-1", "", now).
+				AddRow(int64(31), action, userID, "", "9", []byte(`{"balance":"0"}`), []byte(`{"balance":"100"}`), "user2", operation.ChannelAPI, "", "", "", now))
+
+		entries, err := repo.ListAuditEntries(ctx, models.AuditFilter{UserID: &userID, Action: &action, From: &from, Before: 40, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, int64(39), entries[0].ID)
+		require.Equal(t, "12", entries[0].TransactionID)
+		require.True(t, entries[0].Before["balance"].Equal(decimal.NewFromInt(100)))
+		require.True(t, entries[0].After["balance"].Equal(decimal.NewFromInt(75)))
+		require.Equal(t, "203.0.113.7", entries[0].ClientIP)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListAuditEntries removed limit", func(t *testing.T) {
+		mock.ExpectQuery(`FROM audit_log ORDER BY id DESC LIMIT \$1`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(5), models.AuditLimitRemoved, "", "transfer.daily_amount", "", []byte(`{"value":"500"}`), nil, "admin", operation.ChannelAdmin, "", "", "", now))
+
+		entries, err := repo.ListAuditEntries(ctx, models.AuditFilter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Nil(t, entries[0].After)
+		require.True(t, entries[0].Before["value"].Equal(decimal.NewFromInt(500)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListAuditEntries invalid limit", func(t *testing.T) {
+		_, err := repo.ListAuditEntries(ctx, models.AuditFilter{})
+		require.ErrorIs(t, err, ErrInvalidLimit)
+	})
+
+	t.Run("recordAudit", func(t *testing.T) {
+		ctx := operation.With(ctx, operation.Operation{Actor: "admin", Channel: operation.ChannelAdmin, Reason: "goodwill", RequestID: "req-2"})
+		ctx = operation.WithClientIP(ctx, "198.51.100.4")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO audit_log`).
+			WithArgs(
+				models.AuditAdjustment, "user1", "", "4", []byte(`{"balance":"10"}`), []byte(`{"balance":"15"}`), "admin", operation.ChannelAdmin, "198.51.100.4", "req-2", "goodwill",
+				models.AuditLimitSet, "user1", "transfer.single_amount", nil, []byte(nil), []byte(`{"value":"50"}`), "admin", operation.ChannelAdmin, "198.51.100.4", "req-2", "support ticket",
+			).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		tx, err := mockDB.Begin()
+		require.NoError(t, err)
+		before, after := auditBalances(decimal.NewFromInt(10), decimal.NewFromInt(5))
+		err = recordAudit(ctx, sqlTx{tx},
+			models.AuditEntry{Action: models.AuditAdjustment, UserID: "user1", TransactionID: "4", Before: before, After: after},
+			models.AuditEntry{Action: models.AuditLimitSet, UserID: "user1", Target: "transfer.single_amount", After: models.AuditValues{"value": decimal.NewFromInt(50)}, Reason: "support ticket"},
+		)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
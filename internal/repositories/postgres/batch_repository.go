@@ -0,0 +1,467 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+type BatchRepository interface {
+	CreateBatch(ctx context.Context, batch *models.TransferBatch) error
+	ApplyAtomic(ctx context.Context, batch *models.TransferBatch) error
+	GetBatch(ctx context.Context, batchID string) (*models.TransferBatch, error)
+}
+
+var (
+	ErrBatchNotFound = errors.New("batch not found")
+)
+
+// BatchItemError reports the transfer that failed an atomic batch. It wraps
+// the error of the transfer.
+type BatchItemError struct {
+	Index      int
+	ReceiverID string
+	Err        error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("transfer %d to %s: %v", e.Index, e.ReceiverID, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+type PostgresBatchRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewBatchRepository(db *sql.DB, logger *logrus.Logger) *PostgresBatchRepository {
+	return &PostgresBatchRepository{db: db, logger: logger}
+}
+
+// CreateBatch persists the batch summary together with its items, filling in
+// the generated ID and creation time
+func (r *PostgresBatchRepository) CreateBatch(ctx context.Context, batch *models.TransferBatch) error {
+	if batch.SenderID == "" {
+		r.logger.WithContext(ctx).Warn("CreateBatch - senderID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"senderID": batch.SenderID,
+		"mode":     batch.Mode,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreateBatch - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertBatch(ctx, tx, logger, batch); err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.WithError(err).Error("CreateBatch - Commit DB transaction failed")
+		return err
+	}
+
+	return nil
+}
+
+// ApplyAtomic applies every transfer of the batch and records its summary in
+// a single database transaction, so either all transfers are applied or none
+// is. The first transfer that fails is reported as a *BatchItemError. On
+// success the items, counts and total of the batch are filled in.
+//
+// The transfers are applied in bulk rather than one by one: the wallets are
+// locked and checked up front, each wallet's balance is updated once, and the
+// transaction records, ledger sequences and events are written with
+// multi-row inserts, so the number of statements does not grow with the
+// size of the batch.
+func (r *PostgresBatchRepository) ApplyAtomic(ctx context.Context, batch *models.TransferBatch) error {
+	if batch.SenderID == "" {
+		r.logger.WithContext(ctx).Warn("ApplyAtomic - senderID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"senderID": batch.SenderID,
+		"mode":     batch.Mode,
+		"count":    len(batch.Items),
+	})
+
+	total := decimal.Zero
+	for _, item := range batch.Items {
+		if err := validateTransfer(r.logger.WithContext(ctx), batch.SenderID, item.ReceiverID, item.Amount); err != nil {
+			return &BatchItemError{Index: item.Index, ReceiverID: item.ReceiverID, Err: err}
+		}
+		total = total.Add(item.Amount)
+	}
+
+	for i := range batch.Items {
+		batch.Items[i].Status = models.BatchItemSucceeded
+	}
+	batch.TotalCount = len(batch.Items)
+	batch.SucceededCount = len(batch.Items)
+	batch.FailedCount = 0
+	batch.TotalAmount = total
+
+	err := retryOnDeadlock(ctx, logger, "ApplyAtomic", func() error {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			logger.WithError(err).Error("ApplyAtomic - Begin DB transaction failed")
+			return err
+		}
+		defer tx.Rollback()
+
+		if len(batch.Items) > 0 {
+			if err := moveFundsBulk(ctx, tx, logger, batch.SenderID, batch.Items, total); err != nil {
+				return err
+			}
+		}
+
+		if err := insertBatch(ctx, tx, logger, batch); err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			logger.WithError(err).Error("ApplyAtomic - Commit DB transaction failed")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Atomic batch transfer successful")
+	return nil
+}
+
+// moveFundsBulk applies the transfers of items from senderID inside tx with
+// the same checks and records as moveFunds one transfer at a time. total is
+// the sum of the item amounts.
+func moveFundsBulk(ctx context.Context, tx *sql.Tx, logger *logrus.Entry, senderID string, items []models.TransferBatchItem, total decimal.Decimal) error {
+	itemError := func(item models.TransferBatchItem, err error) error {
+		return &BatchItemError{Index: item.Index, ReceiverID: item.ReceiverID, Err: err}
+	}
+
+	// Receivers in order of first appearance with the amount they receive
+	// and the number of transfers they receive it in
+	var receivers []string
+	credits := make(map[string]decimal.Decimal)
+	counts := map[string]int64{senderID: int64(len(items))}
+	for _, item := range items {
+		if _, ok := credits[item.ReceiverID]; !ok {
+			receivers = append(receivers, item.ReceiverID)
+		}
+		credits[item.ReceiverID] = credits[item.ReceiverID].Add(item.Amount)
+		counts[item.ReceiverID]++
+	}
+
+	wallets, err := lockWallets(ctx, sqlTx{tx}, append([]string{senderID}, receivers...)...)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Lock wallets failed")
+		return err
+	}
+
+	sender, ok := wallets[senderID]
+	if !ok {
+		logger.Warn("ApplyAtomic - Cannot find sender in the database")
+		return itemError(items[0], ErrUserNotFound)
+	}
+	if err := statusError(sender.status); err != nil {
+		logger.WithField("status", sender.status).Warn("ApplyAtomic - Sender wallet is not active")
+		return itemError(items[0], err)
+	}
+
+	// Funds held by pending transfers and withdrawals are not available. The
+	// transfer that would overdraw the wallet fails the batch.
+	available := sender.balance.Sub(sender.held)
+	if available.LessThan(total) {
+		for _, item := range items {
+			available = available.Sub(item.Amount)
+			if available.IsNegative() {
+				logger.WithField("index", item.Index).Warn("ApplyAtomic - Sender balance is too low")
+				return itemError(item, ErrInsufficientBalance)
+			}
+		}
+	}
+
+	for _, item := range items {
+		receiver, ok := wallets[item.ReceiverID]
+		if !ok {
+			logger.WithField("toUserID", item.ReceiverID).Warn("ApplyAtomic - Cannot find receiver in the database")
+			return itemError(item, ErrUserNotFound)
+		}
+		if err := statusError(receiver.status); err != nil {
+			logger.WithFields(logrus.Fields{"toUserID": item.ReceiverID, "status": receiver.status}).Warn("ApplyAtomic - Receiver wallet is not active")
+			return itemError(item, err)
+		}
+		// Batches move funds one to one; only direct transfers convert
+		if err := checkCurrencies(sender.currency, receiver.currency, nil); err != nil {
+			logger.WithField("toUserID", item.ReceiverID).Warn("ApplyAtomic - Wallet currencies do not match")
+			return itemError(item, err)
+		}
+	}
+
+	// Update each balance once
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET balance = balance - $1 WHERE user_id = $2",
+		total, senderID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Update sender balance failed")
+		return err
+	}
+	creditRows := make([][]interface{}, len(receivers))
+	for i, receiver := range receivers {
+		creditRows[i] = []interface{}{receiver, credits[receiver]}
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE wallets AS w SET balance = w.balance + c.amount::numeric
+		FROM (VALUES `+valuesList(len(creditRows), 2)+`) AS c (user_id, amount)
+		WHERE w.user_id = c.user_id`,
+		flatten(creditRows)...,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Update receiver balances failed")
+		return err
+	}
+
+	// Create transaction records. Their IDs come from a sequence and are
+	// assigned in the order of the rows.
+	now := time.Now()
+	actor, channel := provenance(ctx)
+	transactionRows := make([][]interface{}, len(items))
+	for i, item := range items {
+		transactionRows[i] = []interface{}{senderID, item.ReceiverID, item.Amount, "transfer", now, actor, channel}
+	}
+	transactionIDs := make([]int64, 0, len(items))
+	err = bulkQuery(ctx, tx,
+		`INSERT INTO transactions
+		(from_user_id, to_user_id, amount, type, created_at, actor, channel)
+		VALUES `,
+		` RETURNING id`,
+		transactionRows,
+		func(rows *sql.Rows) error {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			transactionIDs = append(transactionIDs, id)
+			return nil
+		},
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Create transaction records failed")
+		return err
+	}
+	slices.Sort(transactionIDs)
+
+	// Reserve the sequence numbers of every wallet at once, then number the
+	// transactions in the order of the batch
+	sequenceRows := [][]interface{}{{senderID, counts[senderID]}}
+	for _, receiver := range receivers {
+		sequenceRows = append(sequenceRows, []interface{}{receiver, counts[receiver]})
+	}
+	next := make(map[string]int64, len(sequenceRows))
+	err = bulkQuery(ctx, tx,
+		`INSERT INTO wallet_sequences (user_id, last_sequence)
+		VALUES `,
+		` ON CONFLICT (user_id) DO UPDATE SET last_sequence = wallet_sequences.last_sequence + EXCLUDED.last_sequence
+		RETURNING user_id, last_sequence`,
+		sequenceRows,
+		func(rows *sql.Rows) error {
+			var userID string
+			var last int64
+			if err := rows.Scan(&userID, &last); err != nil {
+				return err
+			}
+			next[userID] = last - counts[userID] + 1
+			return nil
+		},
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Reserve sequence numbers failed")
+		return err
+	}
+
+	// Balances move in the order of the batch in the audit log
+	balances := make(map[string]decimal.Decimal, len(wallets))
+	for userID, wallet := range wallets {
+		balances[userID] = wallet.balance
+	}
+	numbered := make([][]interface{}, 0, 2*len(items))
+	completed := make([]events.Event, 0, len(items))
+	audited := make([]models.AuditEntry, 0, 2*len(items))
+	for i, item := range items {
+		transactionID := strconv.FormatInt(transactionIDs[i], 10)
+		fromSequence, toSequence := next[senderID], next[item.ReceiverID]
+		next[senderID]++
+		next[item.ReceiverID]++
+		numbered = append(numbered,
+			[]interface{}{transactionIDs[i], senderID, fromSequence},
+			[]interface{}{transactionIDs[i], item.ReceiverID, toSequence},
+		)
+		completed = append(completed, events.New(events.TypeTransferCompleted, events.TransferCompleted{
+			FromUserID:    senderID,
+			ToUserID:      item.ReceiverID,
+			Amount:        item.Amount,
+			TransactionID: transactionID,
+			FromSequence:  fromSequence,
+			ToSequence:    toSequence,
+		}))
+		senderBefore, senderAfter := auditBalances(balances[senderID], item.Amount.Neg())
+		receiverBefore, receiverAfter := auditBalances(balances[item.ReceiverID], item.Amount)
+		balances[senderID] = senderAfter["balance"]
+		balances[item.ReceiverID] = receiverAfter["balance"]
+		audited = append(audited,
+			models.AuditEntry{Action: models.AuditTransfer, UserID: senderID, TransactionID: transactionID, Before: senderBefore, After: senderAfter},
+			models.AuditEntry{Action: models.AuditTransfer, UserID: item.ReceiverID, TransactionID: transactionID, Before: receiverBefore, After: receiverAfter},
+		)
+	}
+	err = bulkExec(ctx, sqlTx{tx},
+		`INSERT INTO transaction_sequences (transaction_id, user_id, sequence)
+		VALUES `,
+		numbered,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Assign sequence numbers failed")
+		return err
+	}
+
+	if err := enqueueEvents(ctx, sqlTx{tx}, completed, senderID); err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Record transfer completed events failed")
+		return err
+	}
+
+	if err := recordAudit(ctx, sqlTx{tx}, audited...); err != nil {
+		logger.WithError(err).Error("ApplyAtomic - Record audit entries failed")
+		return err
+	}
+	return nil
+}
+
+// insertBatch records the batch summary and its items inside tx
+func insertBatch(ctx context.Context, tx *sql.Tx, logger *logrus.Entry, batch *models.TransferBatch) error {
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO transfer_batches
+		(sender_id, mode, total_count, succeeded_count, failed_count, total_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		batch.SenderID, batch.Mode, batch.TotalCount, batch.SucceededCount, batch.FailedCount, batch.TotalAmount,
+	).Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreateBatch - Create batch record failed")
+		return err
+	}
+
+	if len(batch.Items) == 0 {
+		return nil
+	}
+	rows := make([][]interface{}, len(batch.Items))
+	for i, item := range batch.Items {
+		rows[i] = []interface{}{batch.ID, item.Index, item.ReceiverID, item.Amount, item.Status, item.ErrorCode, item.Error}
+	}
+	err = bulkExec(ctx, sqlTx{tx},
+		`INSERT INTO transfer_batch_items
+		(batch_id, item_index, receiver_id, amount, status, error_code, error)
+		VALUES `,
+		rows,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CreateBatch - Create batch item records failed")
+		return err
+	}
+	return nil
+}
+
+// GetBatch returns the batch summary with its items ordered as submitted
+func (r *PostgresBatchRepository) GetBatch(ctx context.Context, batchID string) (*models.TransferBatch, error) {
+	if batchID == "" {
+		r.logger.WithContext(ctx).Warn("GetBatch - batchID cannot be an empty string")
+		return nil, ErrBatchNotFound
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"batchID": batchID,
+	})
+
+	var batch models.TransferBatch
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, sender_id, mode, total_count, succeeded_count, failed_count, total_amount, created_at
+		FROM transfer_batches
+		WHERE id = $1`,
+		batchID,
+	).Scan(
+		&batch.ID,
+		&batch.SenderID,
+		&batch.Mode,
+		&batch.TotalCount,
+		&batch.SucceededCount,
+		&batch.FailedCount,
+		&batch.TotalAmount,
+		&batch.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Warn("GetBatch - Cannot find batch in the database")
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("GetBatch - Query batch failed")
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT item_index, receiver_id, amount, status, error_code, error
+		FROM transfer_batch_items
+		WHERE batch_id = $1
+		ORDER BY item_index`,
+		batchID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("GetBatch - Query batch items failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.TransferBatchItem
+		err := rows.Scan(
+			&item.Index,
+			&item.ReceiverID,
+			&item.Amount,
+			&item.Status,
+			&item.ErrorCode,
+			&item.Error,
+		)
+		if err != nil {
+			logger.WithError(err).Error("GetBatch - Scan batch items failed")
+			return nil, err
+		}
+		batch.Items = append(batch.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("GetBatch - Iterate batch items failed")
+		return nil, err
+	}
+
+	return &batch, nil
+}
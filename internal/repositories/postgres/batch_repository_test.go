@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestBatchRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewBatchRepository(mockDB, logrus.New())
+
+	t.Run("CreateBatch", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			now := time.Now()
+			code, message := "INSUFFICIENT_BALANCE", "insufficient balance"
+			batch := &models.TransferBatch{
+				SenderID:       "user1",
+				Mode:           models.BatchModeBestEffort,
+				TotalCount:     2,
+				SucceededCount: 1,
+				FailedCount:    1,
+				TotalAmount:    decimal.NewFromInt(10),
+				Items: []models.TransferBatchItem{
+					{Index: 0, ReceiverID: "user2", Amount: decimal.NewFromInt(10), Status: models.BatchItemSucceeded},
+					{Index: 1, ReceiverID: "user3", Amount: decimal.NewFromInt(500), Status: models.BatchItemFailed, ErrorCode: &code, Error: &message},
+				},
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO transfer_batches`).WithArgs("user1", models.BatchModeBestEffort, 2, 1, 1, decimal.NewFromInt(10)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("7", now))
+			mock.ExpectExec(`INSERT INTO transfer_batch_items`).WithArgs(
+				"7", 0, "user2", decimal.NewFromInt(10), models.BatchItemSucceeded, nil, nil,
+				"7", 1, "user3", decimal.NewFromInt(500), models.BatchItemFailed, code, message,
+			).WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.CreateBatch(ctx, batch))
+			require.Equal(t, "7", batch.ID)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("invalid sender", func(t *testing.T) {
+			err := repo.CreateBatch(ctx, &models.TransferBatch{})
+			require.ErrorIs(t, err, ErrInvalidUserID)
+		})
+	})
+
+	t.Run("ApplyAtomic", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			batch := &models.TransferBatch{
+				SenderID: "user1",
+				Mode:     models.BatchModeAtomic,
+				Items: []models.TransferBatchItem{
+					{Index: 0, ReceiverID: "user2", Amount: decimal.NewFromInt(10)},
+					{Index: 1, ReceiverID: "user3", Amount: decimal.NewFromInt(20)},
+					{Index: 2, ReceiverID: "user2", Amount: decimal.NewFromInt(5)},
+				},
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, held, status, COALESCE\(currency, ''\), overdraft_limit FROM wallets`).WithArgs("user1", "user2", "user3").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+					AddRow("user1", 200.0, 0.0, "active", "", 0.0).AddRow("user2", 0.0, 0.0, "active", "", 0.0).AddRow("user3", 0.0, 0.0, "active", "", 0.0))
+			// Each balance is updated once
+			mock.ExpectExec(`UPDATE wallets SET balance = balance -`).WithArgs(decimal.NewFromInt(35), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE wallets AS w`).WithArgs("user2", decimal.NewFromInt(15), "user3", decimal.NewFromInt(20)).WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4).AddRow(5))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", int64(3), "user2", int64(2), "user3", int64(1)).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "last_sequence"}).AddRow("user1", 7).AddRow("user2", 2).AddRow("user3", 9))
+			mock.ExpectExec(`INSERT INTO transaction_sequences`).WithArgs(
+				int64(3), "user1", int64(5), int64(3), "user2", int64(1),
+				int64(4), "user1", int64(6), int64(4), "user3", int64(9),
+				int64(5), "user1", int64(7), int64(5), "user2", int64(2),
+			).WillReturnResult(sqlmock.NewResult(0, 6))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(0, 3))
+			expectAudit(mock, 6)
+			mock.ExpectQuery(`INSERT INTO transfer_batches`).WithArgs("user1", models.BatchModeAtomic, 3, 3, 0, decimal.NewFromInt(35)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("8", time.Now()))
+			mock.ExpectExec(`INSERT INTO transfer_batch_items`).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.ApplyAtomic(ctx, batch))
+			require.Equal(t, "8", batch.ID)
+			require.True(t, decimal.NewFromInt(35).Equal(batch.TotalAmount))
+			require.Equal(t, models.BatchItemSucceeded, batch.Items[2].Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("transfer overdrawing the sender fails the batch", func(t *testing.T) {
+			batch := &models.TransferBatch{
+				SenderID: "user1",
+				Mode:     models.BatchModeAtomic,
+				Items: []models.TransferBatchItem{
+					{Index: 0, ReceiverID: "user2", Amount: decimal.NewFromInt(150)},
+					{Index: 1, ReceiverID: "user3", Amount: decimal.NewFromInt(100)},
+				},
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, held, status, COALESCE\(currency, ''\), overdraft_limit FROM wallets`).WithArgs("user1", "user2", "user3").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+					AddRow("user1", 200.0, 0.0, "active", "", 0.0).AddRow("user2", 0.0, 0.0, "active", "", 0.0).AddRow("user3", 0.0, 0.0, "active", "", 0.0))
+			mock.ExpectRollback()
+
+			err := repo.ApplyAtomic(ctx, batch)
+			var itemErr *BatchItemError
+			require.ErrorAs(t, err, &itemErr)
+			require.Equal(t, 1, itemErr.Index)
+			require.ErrorIs(t, err, ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("inactive receiver fails the batch", func(t *testing.T) {
+			batch := &models.TransferBatch{
+				SenderID: "user1",
+				Mode:     models.BatchModeAtomic,
+				Items: []models.TransferBatchItem{
+					{Index: 0, ReceiverID: "user2", Amount: decimal.NewFromInt(10)},
+					{Index: 1, ReceiverID: "user3", Amount: decimal.NewFromInt(10)},
+					{Index: 2, ReceiverID: "user4", Amount: decimal.NewFromInt(10)},
+				},
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, held, status, COALESCE\(currency, ''\), overdraft_limit FROM wallets`).WithArgs("user1", "user2", "user3", "user4").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+					AddRow("user1", 200.0, 0.0, "active", "", 0.0).AddRow("user2", 0.0, 0.0, "active", "", 0.0).AddRow("user3", 0.0, 0.0, models.WalletStatusFrozen, "", 0.0))
+			mock.ExpectRollback()
+
+			err := repo.ApplyAtomic(ctx, batch)
+			var itemErr *BatchItemError
+			require.ErrorAs(t, err, &itemErr)
+			require.Equal(t, 1, itemErr.Index)
+			require.ErrorIs(t, err, ErrWalletFrozen)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("GetBatch", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			now := time.Now()
+			mock.ExpectQuery(`SELECT id, sender_id`).WithArgs("7").WillReturnRows(sqlmock.NewRows(
+				[]string{"id", "sender_id", "mode", "total_count", "succeeded_count", "failed_count", "total_amount", "created_at"},
+			).AddRow("7", "user1", models.BatchModeBestEffort, 1, 1, 0, 10.0, now))
+			mock.ExpectQuery(`SELECT item_index`).WithArgs("7").WillReturnRows(sqlmock.NewRows(
+				[]string{"item_index", "receiver_id", "amount", "status", "error_code", "error"},
+			).AddRow(0, "user2", 10.0, models.BatchItemSucceeded, nil, nil))
+
+			batch, err := repo.GetBatch(ctx, "7")
+			require.NoError(t, err)
+			require.Equal(t, "user1", batch.SenderID)
+			require.Len(t, batch.Items, 1)
+			require.Nil(t, batch.Items[0].ErrorCode)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT id, sender_id`).WithArgs("8").WillReturnError(sql.ErrNoRows)
+			_, err := repo.GetBatch(ctx, "8")
+			require.ErrorIs(t, err, ErrBatchNotFound)
+		})
+	})
+}
+
+// BenchmarkApplyAtomic compares applying an atomic batch in bulk with
+// applying its transfers one by one, as ApplyAtomic did before. Every
+// statement costs a simulated round trip to the database, which dominates
+// both in production.
+//
+//	go test ./internal/repositories/postgres -run '^$' -bench ApplyAtomic
+func BenchmarkApplyAtomic(b *testing.B) {
+	const roundTrip = 200 * time.Microsecond
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	for _, size := range []int{10, 100} {
+		batch := models.TransferBatch{SenderID: "user1", Mode: models.BatchModeAtomic}
+		for i := 0; i < size; i++ {
+			batch.Items = append(batch.Items, models.TransferBatchItem{Index: i, ReceiverID: fmt.Sprintf("user%d", i+2), Amount: decimal.NewFromInt(1)})
+		}
+
+		b.Run(fmt.Sprintf("bulk/%d", size), func(b *testing.B) {
+			mockDB, mock := newBenchmarkDB(b)
+			repo := NewBatchRepository(mockDB, logger)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectBulkApply(mock, batch, roundTrip)
+				run := batch
+				run.Items = slices.Clone(batch.Items)
+				b.StartTimer()
+
+				if err := repo.ApplyAtomic(ctx, &run); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "transfers/s")
+		})
+
+		b.Run(fmt.Sprintf("per_row/%d", size), func(b *testing.B) {
+			mockDB, mock := newBenchmarkDB(b)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectPerRowApply(mock, batch, roundTrip)
+				b.StartTimer()
+
+				if err := applyPerRow(ctx, mockDB, logger.WithField("benchmark", true), batch); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "transfers/s")
+		})
+	}
+}
+
+func newBenchmarkDB(b *testing.B) (*sql.DB, sqlmock.Sqlmock) {
+	anyQuery := sqlmock.QueryMatcherFunc(func(string, string) error { return nil })
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(anyQuery))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { mockDB.Close() })
+	return mockDB, mock
+}
+
+func expectBulkApply(mock sqlmock.Sqlmock, batch models.TransferBatch, roundTrip time.Duration) {
+	n := len(batch.Items)
+	wallets := sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).AddRow(batch.SenderID, 1000000.0, 0.0, "active", "", 0.0)
+	transactions := sqlmock.NewRows([]string{"id"})
+	sequences := sqlmock.NewRows([]string{"user_id", "last_sequence"}).AddRow(batch.SenderID, n)
+	for i, item := range batch.Items {
+		wallets.AddRow(item.ReceiverID, 0.0, 0.0, "active", "", 0.0)
+		transactions.AddRow(i + 1)
+		sequences.AddRow(item.ReceiverID, 1)
+	}
+
+	mock.ExpectBegin().WillDelayFor(roundTrip)
+	mock.ExpectQuery("").WillReturnRows(wallets).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1)).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, int64(n))).WillDelayFor(roundTrip)
+	mock.ExpectQuery("").WillReturnRows(transactions).WillDelayFor(roundTrip)
+	mock.ExpectQuery("").WillReturnRows(sequences).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, int64(2*n))).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, int64(n))).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, int64(2*n))).WillDelayFor(roundTrip)
+	mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now())).WillDelayFor(roundTrip)
+	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, int64(n))).WillDelayFor(roundTrip)
+	mock.ExpectCommit()
+}
+
+func expectPerRowApply(mock sqlmock.Sqlmock, batch models.TransferBatch, roundTrip time.Duration) {
+	mock.ExpectBegin().WillDelayFor(roundTrip)
+	for i, item := range batch.Items {
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+			AddRow(batch.SenderID, 1000000.0, 0.0, "active", "", 0.0).AddRow(item.ReceiverID, 0.0, 0.0, "active", "", 0.0)).WillDelayFor(roundTrip)
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1)).WillDelayFor(roundTrip)
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1)).WillDelayFor(roundTrip)
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 1)).WillDelayFor(roundTrip)
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(i + 1)).WillDelayFor(roundTrip)
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1)).WillDelayFor(roundTrip)
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1)).WillDelayFor(roundTrip)
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 2)).WillDelayFor(roundTrip)
+	}
+	mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("1", time.Now())).WillDelayFor(roundTrip)
+	for range batch.Items {
+		mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(0, 1)).WillDelayFor(roundTrip)
+	}
+	mock.ExpectCommit()
+}
+
+// applyPerRow applies batch with one moveFunds call and one item insert per
+// transfer
+func applyPerRow(ctx context.Context, db *sql.DB, logger *logrus.Entry, batch models.TransferBatch) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range batch.Items {
+		if _, err := moveFunds(ctx, sqlTx{tx}, logger, readLocked, batch.SenderID, item.ReceiverID, item.Amount, decimal.Zero, nil, nil); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transfer_batches
+		(sender_id, mode, total_count, succeeded_count, failed_count, total_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		batch.SenderID, batch.Mode, len(batch.Items), len(batch.Items), 0, decimal.Zero,
+	).Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, item := range batch.Items {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO transfer_batch_items
+			(batch_id, item_index, receiver_id, amount, status, error_code, error)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			batch.ID, item.Index, item.ReceiverID, item.Amount, models.BatchItemSucceeded, nil, nil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// BootstrapRepository creates the records a new environment needs
+type BootstrapRepository interface {
+	Bootstrap(ctx context.Context, plan models.BootstrapPlan) (models.BootstrapResult, error)
+}
+
+type PostgresBootstrapRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewBootstrapRepository(db *sql.DB, logger *logrus.Logger) *PostgresBootstrapRepository {
+	return &PostgresBootstrapRepository{db: db, logger: logger}
+}
+
+// Bootstrap creates the system accounts, currencies and default limits of the
+// plan in one transaction. Existing records are left as they are, so running
+// it again is safe and never undoes changes made since.
+func (r *PostgresBootstrapRepository) Bootstrap(ctx context.Context, plan models.BootstrapPlan) (models.BootstrapResult, error) {
+	var result models.BootstrapResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Bootstrap - Begin DB transaction failed")
+		return result, err
+	}
+	defer tx.Rollback()
+
+	for _, userID := range plan.SystemAccounts {
+		created, err := insertMissing(ctx, tx,
+			`INSERT INTO wallets (user_id, label) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO NOTHING`,
+			userID, models.SystemAccountLabel,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("Bootstrap - Create system account failed")
+			return result, err
+		}
+		result.SystemAccounts += created
+	}
+
+	for _, currency := range plan.Currencies {
+		created, err := insertMissing(ctx, tx,
+			`INSERT INTO currencies (code, decimals) VALUES ($1, $2)
+			ON CONFLICT (code) DO NOTHING`,
+			currency.Code, currency.Decimals,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("currency", currency.Code).Error("Bootstrap - Create currency failed")
+			return result, err
+		}
+		result.Currencies += created
+	}
+
+	for _, limit := range plan.Limits {
+		created, err := insertMissing(ctx, tx,
+			`INSERT INTO transaction_limits (user_id, operation, kind, value, reason, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id, operation, kind) DO NOTHING`,
+			limit.UserID, limit.Operation, limit.Kind, limit.Value, limit.Reason, limit.UpdatedBy,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+				"operation": limit.Operation,
+				"kind":      limit.Kind,
+			}).Error("Bootstrap - Create limit failed")
+			return result, err
+		}
+		result.Limits += created
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Bootstrap - Commit DB transaction failed")
+		return models.BootstrapResult{}, err
+	}
+
+	return result, nil
+}
+
+// insertMissing runs an insert that skips existing rows and returns the
+// number of rows it created
+func insertMissing(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(created), nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestBootstrapRepository_Bootstrap(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewBootstrapRepository(mockDB, logrus.New())
+	plan := models.BootstrapPlan{
+		SystemAccounts: []string{models.SystemAccountFees, models.SystemAccountTreasury},
+		Currencies:     []models.Currency{{Code: "BTC", Decimals: 8}},
+		Limits: []models.Limit{{
+			Operation: models.LimitOperationWithdrawal,
+			Kind:      models.LimitSingleAmount,
+			Value:     decimal.NewFromInt(10000),
+			Reason:    "bootstrap default",
+			UpdatedBy: "bootstrap",
+		}},
+	}
+
+	t.Run("existing records are skipped", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO wallets \(user_id, label\) VALUES \(\$1, \$2\) ON CONFLICT \(user_id\) DO NOTHING`).
+			WithArgs(models.SystemAccountFees, models.SystemAccountLabel).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO wallets`).
+			WithArgs(models.SystemAccountTreasury, models.SystemAccountLabel).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO currencies \(code, decimals\) VALUES \(\$1, \$2\) ON CONFLICT \(code\) DO NOTHING`).
+			WithArgs("BTC", 8).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO transaction_limits .* ON CONFLICT \(user_id, operation, kind\) DO NOTHING`).
+			WithArgs("", models.LimitOperationWithdrawal, models.LimitSingleAmount, decimal.NewFromInt(10000), "bootstrap default", "bootstrap").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		result, err := repo.Bootstrap(ctx, plan)
+		require.NoError(t, err)
+		require.Equal(t, models.BootstrapResult{SystemAccounts: 1, Currencies: 1}, result)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failure rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO wallets`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO wallets`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO currencies`).WillReturnError(errors.New(`relation "currencies" does not exist`))
+		mock.ExpectRollback()
+
+		_, err := repo.Bootstrap(ctx, plan)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
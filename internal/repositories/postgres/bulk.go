@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// maxBulkRows bounds the rows of one multi-row statement, keeping it well
+// below the 65535 bind parameters a PostgreSQL statement accepts
+const maxBulkRows = 1000
+
+// valuesList returns the rows of a multi-row VALUES clause, such as
+// "($1, $2), ($3, $4)", for rows rows of cols columns
+func valuesList(rows, cols int) string {
+	var b strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := 0; j < cols; j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(i*cols + j + 1))
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// bulkExec inserts rows with one multi-row statement per maxBulkRows rows.
+// query ends in "VALUES "; every row has the same number of columns.
+func bulkExec(ctx context.Context, tx ledgerTx, query string, rows [][]interface{}) error {
+	for start := 0; start < len(rows); start += maxBulkRows {
+		chunk := rows[start:min(start+maxBulkRows, len(rows))]
+		if _, err := tx.Exec(ctx, query+valuesList(len(chunk), len(chunk[0])), flatten(chunk)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkQuery is bulkExec for statements returning rows: suffix, such as a
+// RETURNING clause, follows the VALUES and scan is called for every
+// returned row
+func bulkQuery(ctx context.Context, tx *sql.Tx, query, suffix string, rows [][]interface{}, scan func(*sql.Rows) error) error {
+	for start := 0; start < len(rows); start += maxBulkRows {
+		chunk := rows[start:min(start+maxBulkRows, len(rows))]
+		result, err := tx.QueryContext(ctx, query+valuesList(len(chunk), len(chunk[0]))+suffix, flatten(chunk)...)
+		if err != nil {
+			return err
+		}
+		for result.Next() {
+			if err := scan(result); err != nil {
+				result.Close()
+				return err
+			}
+		}
+		err = result.Err()
+		result.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flatten(rows [][]interface{}) []interface{} {
+	args := make([]interface{}, 0, len(rows)*len(rows[0]))
+	for _, row := range rows {
+		args = append(args, row...)
+	}
+	return args
+}
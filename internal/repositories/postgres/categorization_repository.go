@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// CategorizationRepository stores categorization rules and reapplies them to
+// recorded transactions. New transactions are categorized on insert by the
+// transactions_categorize trigger.
+type CategorizationRepository interface {
+	ListRules(ctx context.Context) ([]models.CategorizationRule, error)
+	CreateRule(ctx context.Context, rule *models.CategorizationRule) error
+	DeleteRule(ctx context.Context, ruleID string) error
+	RecategorizeNext(ctx context.Context, afterID int64, since *time.Time, batchSize int) (lastID int64, scanned, changed int, err error)
+}
+
+var ErrRuleNotFound = errors.New("categorization rule not found")
+
+type PostgresCategorizationRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewCategorizationRepository(db *sql.DB, logger *logrus.Logger) *PostgresCategorizationRepository {
+	return &PostgresCategorizationRepository{db: db, logger: logger}
+}
+
+// ListRules returns every rule in the order they are evaluated
+func (r *PostgresCategorizationRepository) ListRules(ctx context.Context) ([]models.CategorizationRule, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, category, priority, counterparty_pattern, min_amount, max_amount, type, channel, created_by, created_at
+		FROM categorization_rules
+		ORDER BY priority DESC, id`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListRules - Query rules failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.CategorizationRule
+	for rows.Next() {
+		var rule models.CategorizationRule
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Category,
+			&rule.Priority,
+			&rule.CounterpartyPattern,
+			&rule.MinAmount,
+			&rule.MaxAmount,
+			&rule.Type,
+			&rule.Channel,
+			&rule.CreatedBy,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListRules - Scan rules failed")
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListRules - Iterate rules failed")
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateRule persists a rule, filling in the generated ID and creation time
+func (r *PostgresCategorizationRepository) CreateRule(ctx context.Context, rule *models.CategorizationRule) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO categorization_rules
+		(category, priority, counterparty_pattern, min_amount, max_amount, type, channel, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		rule.Category, rule.Priority, rule.CounterpartyPattern, rule.MinAmount, rule.MaxAmount, rule.Type, rule.Channel, rule.CreatedBy,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("category", rule.Category).Error("CreateRule - Create rule failed")
+		return err
+	}
+	return nil
+}
+
+// DeleteRule removes a rule. Transactions keep the category it gave them
+// until they are recategorized.
+func (r *PostgresCategorizationRepository) DeleteRule(ctx context.Context, ruleID string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM categorization_rules WHERE id = $1", ruleID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("ruleID", ruleID).Error("DeleteRule - Delete rule failed")
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// RecategorizeNext reapplies the rules to the next batchSize transactions
+// with an ID above afterID, created at or after since when set. It returns
+// the last ID scanned, zero once there are no more transactions, with the
+// number of transactions scanned and whose category changed.
+func (r *PostgresCategorizationRepository) RecategorizeNext(ctx context.Context, afterID int64, since *time.Time, batchSize int) (int64, int, int, error) {
+	var lastID int64
+	var scanned, changed int
+	err := r.db.QueryRowContext(ctx,
+		`WITH batch AS (
+			SELECT id FROM transactions
+			WHERE id > $1 AND ($2::timestamptz IS NULL OR created_at >= $2)
+			ORDER BY id
+			LIMIT $3
+		), changed AS (
+			UPDATE transactions t SET category = transaction_category(t)
+			FROM batch
+			WHERE t.id = batch.id AND t.category IS DISTINCT FROM transaction_category(t)
+			RETURNING t.id
+		)
+		SELECT COALESCE(MAX(id), 0), COUNT(*), (SELECT COUNT(*) FROM changed)
+		FROM batch`,
+		afterID, since, batchSize,
+	).Scan(&lastID, &scanned, &changed)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("afterID", afterID).Error("RecategorizeNext - Recategorize batch failed")
+		return 0, 0, 0, err
+	}
+	return lastID, scanned, changed, nil
+}
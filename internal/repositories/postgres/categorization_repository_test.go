@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestCategorizationRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewCategorizationRepository(mockDB, logrus.New())
+	now := time.Now()
+
+	t.Run("ListRules", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, category, priority, counterparty_pattern, min_amount, max_amount, type, channel, created_by, created_at FROM categorization_rules ORDER BY priority DESC, id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "category", "priority", "counterparty_pattern", "min_amount", "max_amount", "type", "channel", "created_by", "created_at"}).
+				AddRow(3, "payroll", 10, "payroll%", nil, nil, "transfer", nil, "admin1", now).
+				AddRow(1, "large", 0, nil, "10000", nil, nil, nil, "admin1", now))
+
+		rules, err := repo.ListRules(ctx)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		require.Equal(t, "payroll%", *rules[0].CounterpartyPattern)
+		require.Nil(t, rules[0].MinAmount)
+		require.True(t, rules[1].MinAmount.Equal(decimal.NewFromInt(10000)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateRule", func(t *testing.T) {
+		pattern := "merchant:%"
+		rule := &models.CategorizationRule{Category: "shopping", Priority: 5, CounterpartyPattern: &pattern, CreatedBy: "admin1"}
+		mock.ExpectQuery(`INSERT INTO categorization_rules`).
+			WithArgs("shopping", 5, &pattern, nil, nil, nil, nil, "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, now))
+
+		require.NoError(t, repo.CreateRule(ctx, rule))
+		require.Equal(t, "4", rule.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteRule", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM categorization_rules WHERE id = \$1`).WithArgs("4").WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repo.DeleteRule(ctx, "4"))
+
+		mock.ExpectExec(`DELETE FROM categorization_rules`).WithArgs("99").WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, repo.DeleteRule(ctx, "99"), ErrRuleNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RecategorizeNext", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE transactions t SET category = transaction_category\(t\)`).
+			WithArgs(int64(1000), &now, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"max", "count", "changed"}).AddRow(1500, 500, 42))
+
+		lastID, scanned, changed, err := repo.RecategorizeNext(ctx, 1000, &now, 500)
+		require.NoError(t, err)
+		require.Equal(t, int64(1500), lastID)
+		require.Equal(t, 500, scanned)
+		require.Equal(t, 42, changed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
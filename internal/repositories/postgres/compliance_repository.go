@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// ComplianceRepository stores versioned compliance policies per jurisdiction
+// and the decisions taken on them
+type ComplianceRepository interface {
+	ListPolicies(ctx context.Context) ([]models.CompliancePolicy, error)
+	PolicyVersions(ctx context.Context, jurisdiction string) ([]models.CompliancePolicy, error)
+	CreatePolicyVersion(ctx context.Context, policy *models.CompliancePolicy) error
+	Subject(ctx context.Context, userID string) (*models.ComplianceSubject, error)
+	RecordDecision(ctx context.Context, decision *models.ComplianceDecision) error
+	ListDecisions(ctx context.Context, userID string, limit int) ([]models.ComplianceDecision, error)
+}
+
+var ErrPolicyNotFound = errors.New("compliance policy not found")
+
+const policyColumns = `jurisdiction, version, allowed_currencies, max_balance, external_withdrawals, reason, created_by, created_at`
+
+type PostgresComplianceRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewComplianceRepository(db *sql.DB, logger *logrus.Logger) *PostgresComplianceRepository {
+	return &PostgresComplianceRepository{db: db, logger: logger}
+}
+
+// ListPolicies returns the latest version of the policy of every
+// jurisdiction, the default first
+func (r *PostgresComplianceRepository) ListPolicies(ctx context.Context) ([]models.CompliancePolicy, error) {
+	return r.queryPolicies(ctx, "ListPolicies",
+		`SELECT DISTINCT ON (jurisdiction) `+policyColumns+`
+		FROM compliance_policies
+		ORDER BY jurisdiction, version DESC`,
+	)
+}
+
+// PolicyVersions returns every version of the policy of a jurisdiction,
+// newest first
+func (r *PostgresComplianceRepository) PolicyVersions(ctx context.Context, jurisdiction string) ([]models.CompliancePolicy, error) {
+	policies, err := r.queryPolicies(ctx, "PolicyVersions",
+		`SELECT `+policyColumns+`
+		FROM compliance_policies
+		WHERE jurisdiction = $1
+		ORDER BY version DESC`,
+		jurisdiction,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, ErrPolicyNotFound
+	}
+	return policies, nil
+}
+
+func (r *PostgresComplianceRepository) queryPolicies(ctx context.Context, method, query string, args ...interface{}) ([]models.CompliancePolicy, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Query policies failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.CompliancePolicy
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error(method + " - Scan policies failed")
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Iterate policies failed")
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CreatePolicyVersion records policy as the next version of the policy of its
+// jurisdiction, filling in Version and CreatedAt. Concurrent changes of one
+// jurisdiction are serialized.
+func (r *PostgresComplianceRepository) CreatePolicyVersion(ctx context.Context, policy *models.CompliancePolicy) error {
+	logger := r.logger.WithContext(ctx).WithField("jurisdiction", policy.Jurisdiction)
+
+	currencies, err := json.Marshal(policy.AllowedCurrencies)
+	if err != nil {
+		logger.WithError(err).Error("CreatePolicyVersion - Encode allowed currencies failed")
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreatePolicyVersion - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"SELECT pg_advisory_xact_lock(hashtext('compliance_policies'), hashtext($1))",
+		policy.Jurisdiction,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CreatePolicyVersion - Lock jurisdiction failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO compliance_policies
+		(jurisdiction, version, allowed_currencies, max_balance, external_withdrawals, reason, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6
+		FROM compliance_policies
+		WHERE jurisdiction = $1
+		RETURNING version, created_at`,
+		policy.Jurisdiction, currencies, policy.MaxBalance, policy.ExternalWithdrawals, policy.Reason, policy.CreatedBy,
+	).Scan(&policy.Version, &policy.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreatePolicyVersion - Create policy record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreatePolicyVersion - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("version", policy.Version).Info("Compliance policy changed")
+	return nil
+}
+
+// Subject returns the jurisdiction and balance of userID with the latest
+// policy of that jurisdiction, or the default policy when the jurisdiction
+// has none
+func (r *PostgresComplianceRepository) Subject(ctx context.Context, userID string) (*models.ComplianceSubject, error) {
+	subject := &models.ComplianceSubject{UserID: userID}
+	var jurisdiction, reason, createdBy sql.NullString
+	var version sql.NullInt64
+	var currencies []byte
+	var policy models.CompliancePolicy
+	var externalWithdrawals sql.NullBool
+	var createdAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(w.country, ''), COALESCE(w.balance, 0),
+			p.jurisdiction, p.version, p.allowed_currencies, p.max_balance, p.external_withdrawals, p.reason, p.created_by, p.created_at
+		FROM (SELECT $1::text AS user_id) AS u
+		LEFT JOIN wallets w ON w.user_id = u.user_id
+		LEFT JOIN LATERAL (
+			SELECT `+policyColumns+`
+			FROM compliance_policies
+			WHERE jurisdiction IN (COALESCE(w.country, ''), '')
+			ORDER BY jurisdiction DESC, version DESC
+			LIMIT 1
+		) AS p ON TRUE`,
+		userID,
+	).Scan(
+		&subject.Jurisdiction,
+		&subject.Balance,
+		&jurisdiction,
+		&version,
+		&currencies,
+		&policy.MaxBalance,
+		&externalWithdrawals,
+		&reason,
+		&createdBy,
+		&createdAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("Subject - Query policy failed")
+		return nil, err
+	}
+
+	if version.Valid {
+		policy.Jurisdiction = jurisdiction.String
+		policy.Version = int(version.Int64)
+		policy.ExternalWithdrawals = externalWithdrawals.Bool
+		policy.Reason = reason.String
+		policy.CreatedBy = createdBy.String
+		policy.CreatedAt = createdAt.Time
+		if err := json.Unmarshal(currencies, &policy.AllowedCurrencies); err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("Subject - Decode allowed currencies failed")
+			return nil, err
+		}
+		subject.Policy = &policy
+	}
+	return subject, nil
+}
+
+// RecordDecision stores decision, filling in its ID and CreatedAt
+func (r *PostgresComplianceRepository) RecordDecision(ctx context.Context, decision *models.ComplianceDecision) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO compliance_decisions
+		(user_id, operation, amount, currency, jurisdiction, policy_version, allowed, rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id::text, created_at`,
+		decision.UserID, decision.Operation, decision.Amount, decision.Currency,
+		decision.Jurisdiction, decision.PolicyVersion, decision.Allowed, decision.Rule,
+	).Scan(&decision.ID, &decision.CreatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"userID":    decision.UserID,
+			"operation": decision.Operation,
+		}).Error("RecordDecision - Create decision record failed")
+		return err
+	}
+	return nil
+}
+
+// ListDecisions returns up to limit decisions on operations of userID,
+// newest first
+func (r *PostgresComplianceRepository) ListDecisions(ctx context.Context, userID string, limit int) ([]models.ComplianceDecision, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id::text, user_id, operation, amount, currency, jurisdiction, policy_version, allowed, rule, created_at
+		FROM compliance_decisions
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListDecisions - Query decisions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []models.ComplianceDecision
+	for rows.Next() {
+		var decision models.ComplianceDecision
+		err := rows.Scan(
+			&decision.ID,
+			&decision.UserID,
+			&decision.Operation,
+			&decision.Amount,
+			&decision.Currency,
+			&decision.Jurisdiction,
+			&decision.PolicyVersion,
+			&decision.Allowed,
+			&decision.Rule,
+			&decision.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListDecisions - Scan decisions failed")
+			return nil, err
+		}
+		decisions = append(decisions, decision)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListDecisions - Iterate decisions failed")
+		return nil, err
+	}
+	return decisions, nil
+}
+
+func scanPolicy(row rowScanner) (*models.CompliancePolicy, error) {
+	var policy models.CompliancePolicy
+	var currencies []byte
+	err := row.Scan(
+		&policy.Jurisdiction,
+		&policy.Version,
+		&currencies,
+		&policy.MaxBalance,
+		&policy.ExternalWithdrawals,
+		&policy.Reason,
+		&policy.CreatedBy,
+		&policy.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(currencies, &policy.AllowedCurrencies); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestComplianceRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewComplianceRepository(mockDB, logrus.New())
+	now := time.Now()
+	subjectColumns := []string{"country", "balance", "jurisdiction", "version", "allowed_currencies", "max_balance",
+		"external_withdrawals", "reason", "created_by", "created_at"}
+
+	t.Run("Subject with a policy", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COALESCE\(w.country, ''\)(.|\n)*LEFT JOIN LATERAL`).
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(subjectColumns).
+				AddRow("SG", "250", "SG", 2, []byte(`["USDC"]`), "1000", false, "MAS guidance", "admin1", now))
+
+		subject, err := repo.Subject(ctx, "user1")
+		require.NoError(t, err)
+		require.Equal(t, "SG", subject.Jurisdiction)
+		require.True(t, subject.Balance.Equal(decimal.NewFromInt(250)))
+		require.Equal(t, 2, subject.Policy.Version)
+		require.Equal(t, []string{"USDC"}, subject.Policy.AllowedCurrencies)
+		require.True(t, subject.Policy.MaxBalance.Equal(decimal.NewFromInt(1000)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Subject without a policy", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COALESCE\(w.country, ''\)`).
+			WithArgs("user2").
+			WillReturnRows(sqlmock.NewRows(subjectColumns).
+				AddRow("", "0", nil, nil, nil, nil, nil, nil, nil, nil))
+
+		subject, err := repo.Subject(ctx, "user2")
+		require.NoError(t, err)
+		require.Nil(t, subject.Policy)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreatePolicyVersion", func(t *testing.T) {
+		maxBalance := decimal.NewFromInt(1000)
+		policy := &models.CompliancePolicy{Jurisdiction: "SG", AllowedCurrencies: []string{"USDC"}, MaxBalance: &maxBalance, Reason: "MAS guidance", CreatedBy: "admin1"}
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs("SG").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO compliance_policies(.|\n)*COALESCE\(MAX\(version\), 0\) \+ 1`).
+			WithArgs("SG", []byte(`["USDC"]`), &maxBalance, false, "MAS guidance", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "created_at"}).AddRow(3, now))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.CreatePolicyVersion(ctx, policy))
+		require.Equal(t, 3, policy.Version)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PolicyVersions not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.|\n)*FROM compliance_policies WHERE jurisdiction = \$1`).
+			WithArgs("FR").
+			WillReturnRows(sqlmock.NewRows([]string{"jurisdiction"}))
+
+		_, err := repo.PolicyVersions(ctx, "FR")
+		require.ErrorIs(t, err, ErrPolicyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RecordDecision", func(t *testing.T) {
+		rule := models.ComplianceRuleMaxBalance
+		decision := &models.ComplianceDecision{UserID: "user1", Operation: models.ComplianceDeposit, Amount: decimal.NewFromInt(900), Jurisdiction: "SG", PolicyVersion: 3, Rule: &rule}
+		mock.ExpectQuery(`INSERT INTO compliance_decisions`).
+			WithArgs("user1", models.ComplianceDeposit, decimal.NewFromInt(900), "", "SG", 3, false, &rule).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("8", now))
+
+		require.NoError(t, repo.RecordDecision(ctx, decision))
+		require.Equal(t, "8", decision.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// ConsistencyRepository finds wallets whose data disagrees and repairs them
+// under audit
+type ConsistencyRepository interface {
+	CheckWallets(ctx context.Context, afterUserID string, limit int) ([]models.WalletConsistency, error)
+	OrphanedTransactions(ctx context.Context) ([]models.ConsistencyIssue, error)
+	ApplyRepair(ctx context.Context, issue models.ConsistencyIssue) error
+	RecordRepair(ctx context.Context, issue models.ConsistencyIssue) error
+}
+
+var (
+	ErrRepairStale       = errors.New("wallet changed since the plan was made")
+	ErrUnknownRepair     = errors.New("unknown repair")
+	ErrRepairUnattended  = errors.New("repairs need an actor and a reason")
+	errRepairMissingData = errors.New("issue lacks the balances its repair checks")
+)
+
+// walletLedger is the balance of the wallet w.user_id recomputed from all of
+// its transactions that did not fail
+const walletLedger = `COALESCE((
+	SELECT SUM(` + ledgerEffect + `)
+	FROM transactions t
+	WHERE (t.from_user_id = w.user_id OR t.to_user_id = w.user_id)
+		AND t.status <> 'failed'
+), 0)`
+
+type PostgresConsistencyRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewConsistencyRepository(db *sql.DB, logger *logrus.Logger) *PostgresConsistencyRepository {
+	return &PostgresConsistencyRepository{db: db, logger: logger}
+}
+
+// CheckWallets returns up to limit wallets after afterUserID in user ID order
+// with their stored and ledger balances
+func (r *PostgresConsistencyRepository) CheckWallets(ctx context.Context, afterUserID string, limit int) ([]models.WalletConsistency, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT w.user_id, w.status, w.balance, `+walletLedger+`, w.overdraft_limit
+		FROM wallets w
+		WHERE w.user_id > $1
+		ORDER BY w.user_id
+		LIMIT $2`,
+		afterUserID, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("CheckWallets - Query wallets failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []models.WalletConsistency
+	for rows.Next() {
+		var wallet models.WalletConsistency
+		if err := rows.Scan(&wallet.UserID, &wallet.Status, &wallet.Balance, &wallet.Ledger, &wallet.OverdraftLimit); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("CheckWallets - Scan wallet failed")
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, rows.Err()
+}
+
+// OrphanedTransactions returns an issue per missing wallet that transactions
+// credit, with the IDs of those transactions and the ledger balance the
+// wallet would have
+func (r *PostgresConsistencyRepository) OrphanedTransactions(ctx context.Context) ([]models.ConsistencyIssue, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT w.user_id, `+walletLedger+`,
+			(SELECT string_agg(o.id::text, ',' ORDER BY o.id)
+			FROM transactions o
+			WHERE o.to_user_id = w.user_id AND o.status <> 'failed')
+		FROM (
+			SELECT DISTINCT t.to_user_id AS user_id
+			FROM transactions t
+			WHERE t.to_user_id IS NOT NULL AND t.status <> 'failed'
+				AND NOT EXISTS (SELECT 1 FROM wallets x WHERE x.user_id = t.to_user_id)
+		) w
+		ORDER BY w.user_id`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("OrphanedTransactions - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []models.ConsistencyIssue
+	for rows.Next() {
+		var ledger decimal.Decimal
+		var ids string
+		issue := models.ConsistencyIssue{Kind: models.IssueOrphanedTransactions, Repair: models.RepairCreateWallet}
+		if err := rows.Scan(&issue.UserID, &ledger, &ids); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("OrphanedTransactions - Scan wallet failed")
+			return nil, err
+		}
+		issue.Ledger = &ledger
+		issue.TransactionIDs = strings.Split(ids, ",")
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// ApplyRepair applies the database repair of issue and records it in the
+// same transaction, with the actor and reason of the operation in ctx. It
+// fails with ErrRepairStale, changing nothing, when the wallet no longer is
+// as the issue observed it.
+func (r *PostgresConsistencyRepository) ApplyRepair(ctx context.Context, issue models.ConsistencyIssue) error {
+	op, err := repairOperation(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": issue.UserID,
+		"kind":   issue.Kind,
+		"repair": issue.Repair,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ApplyRepair - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	switch issue.Repair {
+	case models.RepairSetBalance:
+		err = setLedgerBalance(ctx, tx, issue)
+	case models.RepairCreateWallet:
+		err = createOrphanedWallet(ctx, tx, issue)
+	case models.RepairFreezeWallet:
+		err = freezeNegativeWallet(ctx, tx, issue, op.Reason)
+	default:
+		return ErrUnknownRepair
+	}
+	if errors.Is(err, ErrRepairStale) || errors.Is(err, errRepairMissingData) {
+		logger.WithError(err).Warn("ApplyRepair - Repair does not apply")
+		return err
+	}
+	if err != nil {
+		logger.WithError(err).Error("ApplyRepair - Repair failed")
+		return err
+	}
+
+	if err = recordRepair(ctx, tx, issue, op); err != nil {
+		logger.WithError(err).Error("ApplyRepair - Record repair failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("ApplyRepair - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Consistency repair applied")
+	return nil
+}
+
+// RecordRepair records a repair applied outside the database, such as a
+// cache invalidation
+func (r *PostgresConsistencyRepository) RecordRepair(ctx context.Context, issue models.ConsistencyIssue) error {
+	op, err := repairOperation(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("RecordRepair - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordRepair(ctx, tx, issue, op); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", issue.UserID).Error("RecordRepair - Record repair failed")
+		return err
+	}
+	return tx.Commit()
+}
+
+// setLedgerBalance sets the stored balance to the ledger balance, provided
+// both still are the ones in issue. The wallet stays locked until tx ends,
+// so no movement slips in between.
+func setLedgerBalance(ctx context.Context, tx *sql.Tx, issue models.ConsistencyIssue) error {
+	if issue.Balance == nil || issue.Ledger == nil {
+		return errRepairMissingData
+	}
+
+	var balance, ledger decimal.Decimal
+	err := tx.QueryRowContext(ctx,
+		`SELECT w.balance FROM wallets w WHERE w.user_id = $1 FOR UPDATE`,
+		issue.UserID,
+	).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx,
+		`SELECT `+walletLedger+` FROM wallets w WHERE w.user_id = $1`,
+		issue.UserID,
+	).Scan(&ledger)
+	if err != nil {
+		return err
+	}
+	if !balance.Equal(*issue.Balance) || !ledger.Equal(*issue.Ledger) {
+		return ErrRepairStale
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE wallets SET balance = $1 WHERE user_id = $2", ledger, issue.UserID)
+	return err
+}
+
+// createOrphanedWallet creates the wallet that orphaned transactions credit
+// with their ledger balance, provided it is still missing and its ledger
+// still is the one in issue
+func createOrphanedWallet(ctx context.Context, tx *sql.Tx, issue models.ConsistencyIssue) error {
+	if issue.Ledger == nil {
+		return errRepairMissingData
+	}
+
+	var ledger decimal.Decimal
+	err := tx.QueryRowContext(ctx,
+		`SELECT `+walletLedger+` FROM (SELECT $1::varchar AS user_id) w`,
+		issue.UserID,
+	).Scan(&ledger)
+	if err != nil {
+		return err
+	}
+	if !ledger.Equal(*issue.Ledger) {
+		return ErrRepairStale
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO wallets (user_id, balance) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING`,
+		issue.UserID, ledger,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrRepairStale
+	}
+
+	event := events.New(events.TypeWalletCreated, events.WalletLifecycleChanged{
+		UserID:  issue.UserID,
+		Current: events.WalletState{Status: models.WalletStatusActive},
+	})
+	return enqueueEvent(ctx, sqlTx{tx}, event, issue.UserID)
+}
+
+// freezeNegativeWallet freezes the wallet, provided it is still active with
+// a balance below its overdraft limit, and records the lifecycle event
+func freezeNegativeWallet(ctx context.Context, tx *sql.Tx, issue models.ConsistencyIssue, reason string) error {
+	result, err := tx.ExecContext(ctx,
+		`UPDATE wallets SET status = $1
+		WHERE user_id = $2 AND status = $3 AND balance < -overdraft_limit`,
+		models.WalletStatusFrozen, issue.UserID, models.WalletStatusActive,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrRepairStale
+	}
+
+	event := events.New(events.TypeWalletFrozen, events.WalletLifecycleChanged{
+		UserID:   issue.UserID,
+		Previous: &events.WalletState{Status: models.WalletStatusActive},
+		Current:  events.WalletState{Status: models.WalletStatusFrozen},
+		Reason:   &reason,
+	})
+	return enqueueEvent(ctx, sqlTx{tx}, event, issue.UserID)
+}
+
+// repairOperation returns the operation in ctx. Repairs are only made by a
+// named actor for a stated reason.
+func repairOperation(ctx context.Context) (operation.Operation, error) {
+	op, _ := operation.From(ctx)
+	if op.Actor == "" || op.Reason == "" {
+		return op, ErrRepairUnattended
+	}
+	return op, nil
+}
+
+// recordRepair writes the audit record of a repair made by op inside tx
+func recordRepair(ctx context.Context, tx *sql.Tx, issue models.ConsistencyIssue, op operation.Operation) error {
+	observed, err := json.Marshal(issue)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO consistency_repairs (repair, kind, user_id, issue, actor, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		issue.Repair, issue.Kind, issue.UserID, observed, op.Actor, op.Reason,
+	)
+	return err
+}
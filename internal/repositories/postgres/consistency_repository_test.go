@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+func TestConsistencyRepository(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewConsistencyRepository(mockDB, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "ops", Channel: operation.ChannelAdmin, Reason: "incident 42"})
+	amount := func(s string) *decimal.Decimal {
+		d := decimal.RequireFromString(s)
+		return &d
+	}
+
+	t.Run("CheckWallets", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT w.user_id, w.status, w.balance, COALESCE`).WithArgs("user1", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "balance", "ledger", "overdraft_limit"}).
+				AddRow("user2", models.WalletStatusActive, "10", "10", "0").
+				AddRow("user3", models.WalletStatusFrozen, "-5", "0", "0"))
+
+		wallets, err := repo.CheckWallets(context.Background(), "user1", 2)
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		require.Equal(t, "user3", wallets[1].UserID)
+		require.True(t, decimal.NewFromInt(-5).Equal(wallets[1].Balance))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OrphanedTransactions", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT w.user_id, COALESCE.+string_agg.+NOT EXISTS`).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "ledger", "ids"}).AddRow("ghost", "15", "4,9"))
+
+		issues, err := repo.OrphanedTransactions(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []models.ConsistencyIssue{{
+			Kind:           models.IssueOrphanedTransactions,
+			UserID:         "ghost",
+			TransactionIDs: []string{"4", "9"},
+			Ledger:         amount("15"),
+			Repair:         models.RepairCreateWallet,
+		}}, issues)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ApplyRepair", func(t *testing.T) {
+		imbalance := models.ConsistencyIssue{
+			Kind:    models.IssueLedgerImbalance,
+			UserID:  "user1",
+			Balance: amount("12"),
+			Ledger:  amount("10"),
+			Repair:  models.RepairSetBalance,
+		}
+
+		t.Run("needs an actor and a reason", func(t *testing.T) {
+			unattended := operation.With(context.Background(), operation.Operation{Actor: "ops"})
+			err := repo.ApplyRepair(unattended, imbalance)
+			require.ErrorIs(t, err, ErrRepairUnattended)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("sets the ledger balance", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT w.balance FROM wallets w WHERE w.user_id = \$1 FOR UPDATE`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("12"))
+			mock.ExpectQuery(`SELECT COALESCE`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"ledger"}).AddRow("10"))
+			mock.ExpectExec(`UPDATE wallets SET balance = \$1 WHERE user_id = \$2`).WithArgs(decimal.NewFromInt(10), "user1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO consistency_repairs`).
+				WithArgs(models.RepairSetBalance, models.IssueLedgerImbalance, "user1", sqlmock.AnyArg(), "ops", "incident 42").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.ApplyRepair(ctx, imbalance))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("stale when the balance moved", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT w.balance FROM wallets w`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("7"))
+			mock.ExpectQuery(`SELECT COALESCE`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"ledger"}).AddRow("5"))
+			mock.ExpectRollback()
+
+			err := repo.ApplyRepair(ctx, imbalance)
+			require.ErrorIs(t, err, ErrRepairStale)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("freezes a negative wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE wallets SET status = \$1`).
+				WithArgs(models.WalletStatusFrozen, "user2", models.WalletStatusActive).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).
+				WithArgs(sqlmock.AnyArg(), events.TypeWalletFrozen, "user2", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(`INSERT INTO consistency_repairs`).
+				WithArgs(models.RepairFreezeWallet, models.IssueNegativeBalance, "user2", sqlmock.AnyArg(), "ops", "incident 42").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			err := repo.ApplyRepair(ctx, models.ConsistencyIssue{
+				Kind:    models.IssueNegativeBalance,
+				UserID:  "user2",
+				Balance: amount("-3"),
+				Ledger:  amount("-3"),
+				Repair:  models.RepairFreezeWallet,
+			})
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("stale when the orphaned wallet was created meanwhile", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT COALESCE.+\$1::varchar AS user_id`).WithArgs("ghost").
+				WillReturnRows(sqlmock.NewRows([]string{"ledger"}).AddRow("15"))
+			mock.ExpectExec(`INSERT INTO wallets`).WithArgs("ghost", decimal.NewFromInt(15)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.ApplyRepair(ctx, models.ConsistencyIssue{
+				Kind:   models.IssueOrphanedTransactions,
+				UserID: "ghost",
+				Ledger: amount("15"),
+				Repair: models.RepairCreateWallet,
+			})
+			require.ErrorIs(t, err, ErrRepairStale)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/tracing"
+)
+
+// ConversionRepository transfers funds between wallets of different
+// currencies
+type ConversionRepository interface {
+	WalletCurrencies(ctx context.Context, userIDs ...string) (map[string]string, error)
+	ConvertTransfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal, conversion models.Conversion, expectedBalance *decimal.Decimal) error
+}
+
+type PostgresConversionRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewConversionRepository(db *sql.DB, logger *logrus.Logger) *PostgresConversionRepository {
+	return &PostgresConversionRepository{db: db, logger: logger}
+}
+
+// WalletCurrencies returns the currency of each wallet of userIDs that has
+// one, by user ID
+func (r *PostgresConversionRepository) WalletCurrencies(ctx context.Context, userIDs ...string) (map[string]string, error) {
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		args[i] = userID
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id, currency FROM wallets
+		WHERE currency IS NOT NULL AND user_id IN `+valuesList(1, len(userIDs)),
+		args...,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("WalletCurrencies - Query wallet currencies failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := make(map[string]string, len(userIDs))
+	for rows.Next() {
+		var userID, currency string
+		if err := rows.Scan(&userID, &currency); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("WalletCurrencies - Scan wallet currencies failed")
+			return nil, err
+		}
+		currencies[userID] = currency
+	}
+	return currencies, rows.Err()
+}
+
+// ConvertTransfer debits amount from the sender and credits the converted
+// amount to the receiver atomically, recording the rate on the transaction.
+// It fails with ErrCurrencyMismatch when a wallet no longer holds the
+// currency the conversion was priced for.
+func (r *PostgresConversionRepository) ConvertTransfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal, conversion models.Conversion, expectedBalance *decimal.Decimal) (err error) {
+	ctx, span := startSpan(ctx, "ConvertTransfer", fromUserID)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateTransfer(r.logger.WithContext(ctx), fromUserID, toUserID, amount); err != nil {
+		return err
+	}
+	if !conversion.ConvertedAmount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("ConvertTransfer - converted amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"fromUserID":      fromUserID,
+		"toUserID":        toUserID,
+		"amount":          amount,
+		"fromCurrency":    conversion.FromCurrency,
+		"toCurrency":      conversion.ToCurrency,
+		"rate":            conversion.Rate,
+		"convertedAmount": conversion.ConvertedAmount,
+	})
+
+	err = retryOnDeadlock(ctx, logger, "ConvertTransfer", func() error {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			logger.WithError(err).Error("ConvertTransfer - Begin DB transaction failed")
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := moveFunds(ctx, sqlTx{tx}, logger, readLocked, fromUserID, toUserID, amount, decimal.Zero, &conversion, expectedBalance); err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			logger.WithError(err).Error("ConvertTransfer - Commit DB transaction failed")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Conversion transfer successful")
+	return nil
+}
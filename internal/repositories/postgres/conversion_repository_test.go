@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestConversionRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewConversionRepository(mockDB, logrus.New())
+	conversion := models.Conversion{
+		FromCurrency:    "EUR",
+		ToCurrency:      "USD",
+		Rate:            decimal.RequireFromString("1.25"),
+		ConvertedAmount: decimal.NewFromInt(125),
+	}
+	wallets := func(fromCurrency, toCurrency string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+			AddRow("user1", 200.0, 0.0, "active", fromCurrency, 0.0).
+			AddRow("user2", 0.0, 0.0, "active", toCurrency, 0.0)
+	}
+
+	t.Run("WalletCurrencies", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT user_id, currency FROM wallets\s+WHERE currency IS NOT NULL AND user_id IN \(\$1, \$2\)`).
+			WithArgs("user1", "user2").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "currency"}).AddRow("user1", "EUR"))
+
+		currencies, err := repo.WalletCurrencies(ctx, "user1", "user2")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"user1": "EUR"}, currencies)
+	})
+
+	t.Run("ConvertTransfer", func(t *testing.T) {
+		t.Run("credits the converted amount and records the rate", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("EUR", "USD"))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(decimal.NewFromInt(125), "user2").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).
+				WithArgs("user1", "user2", decimal.NewFromInt(100), "transfer", sqlmock.AnyArg(), nil, nil, "EUR", "USD", conversion.Rate, conversion.ConvertedAmount, nil, nil, nil).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user2", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeTransferCompleted, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 2)
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.ConvertTransfer(ctx, "user1", "user2", decimal.NewFromInt(100), conversion, nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("receiver currency changed since the conversion was priced", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("EUR", "GBP"))
+			mock.ExpectRollback()
+
+			err := repo.ConvertTransfer(ctx, "user1", "user2", decimal.NewFromInt(100), conversion, nil)
+			require.ErrorIs(t, err, ErrCurrencyMismatch)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
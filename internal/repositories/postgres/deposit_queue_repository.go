@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// DepositQueueRepository stores deposits accepted asynchronously until the
+// deposit consumer applies them
+type DepositQueueRepository interface {
+	Enqueue(ctx context.Context, deposit *models.QueuedDeposit, idempotencyKey string) error
+	GetQueuedDeposit(ctx context.Context, userID, depositID string) (*models.QueuedDeposit, error)
+	GetQueuedDepositByKey(ctx context.Context, userID, idempotencyKey string) (*models.QueuedDeposit, error)
+	PendingUsers(ctx context.Context, limit int) ([]string, error)
+	ApplyNext(ctx context.Context, userID string) (*models.QueuedDeposit, error)
+}
+
+var (
+	ErrQueuedDepositNotFound = errors.New("queued deposit not found")
+)
+
+type PostgresDepositQueueRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+	// provisionedOnly fails queued deposits to users without a wallet
+	// instead of creating one
+	provisionedOnly bool
+}
+
+func NewDepositQueueRepository(db *sql.DB, logger *logrus.Logger, provisionedOnly bool) *PostgresDepositQueueRepository {
+	return &PostgresDepositQueueRepository{db: db, logger: logger, provisionedOnly: provisionedOnly}
+}
+
+// Enqueue records deposit as pending, filling in its ID, status and creation
+// time. idempotencyKey may be empty.
+func (r *PostgresDepositQueueRepository) Enqueue(ctx context.Context, deposit *models.QueuedDeposit, idempotencyKey string) error {
+	if deposit.UserID == "" {
+		r.logger.WithContext(ctx).Warn("Enqueue - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if !deposit.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("Enqueue - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	memo, reference, metadata := transactionDetails(ctx)
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO deposit_queue (user_id, amount, idempotency_key, status, memo, reference_id, metadata)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+		RETURNING id::text, status, created_at`,
+		deposit.UserID, deposit.Amount, idempotencyKey, models.DepositPending, memo, reference, metadata,
+	).Scan(&deposit.ID, &deposit.Status, &deposit.CreatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"userID": deposit.UserID,
+			"amount": deposit.Amount,
+		}).Error("Enqueue - Insert queued deposit failed")
+		return err
+	}
+	if memo.Valid {
+		deposit.Memo = &memo.String
+	}
+	if reference.Valid {
+		deposit.ReferenceID = &reference.String
+	}
+	deposit.Metadata = operation.DetailsFrom(ctx).Metadata
+	return nil
+}
+
+// GetQueuedDeposit returns the queued deposit depositID of userID
+func (r *PostgresDepositQueueRepository) GetQueuedDeposit(ctx context.Context, userID, depositID string) (*models.QueuedDeposit, error) {
+	deposit, err := scanQueuedDeposit(r.db.QueryRowContext(ctx,
+		`SELECT id::text, user_id, amount, status, error, transaction_id::text, created_at, processed_at,
+			memo, reference_id, metadata
+		FROM deposit_queue
+		WHERE user_id = $1 AND id::text = $2`,
+		userID, depositID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQueuedDepositNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("depositID", depositID).Error("GetQueuedDeposit - Query queued deposit failed")
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// GetQueuedDepositByKey returns the deposit of userID queued with
+// idempotencyKey
+func (r *PostgresDepositQueueRepository) GetQueuedDepositByKey(ctx context.Context, userID, idempotencyKey string) (*models.QueuedDeposit, error) {
+	deposit, err := scanQueuedDeposit(r.db.QueryRowContext(ctx,
+		`SELECT id::text, user_id, amount, status, error, transaction_id::text, created_at, processed_at,
+			memo, reference_id, metadata
+		FROM deposit_queue
+		WHERE user_id = $1 AND idempotency_key = $2`,
+		userID, idempotencyKey,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQueuedDepositNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetQueuedDepositByKey - Query queued deposit failed")
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// PendingUsers returns up to limit users with pending deposits, those waiting
+// the longest first
+func (r *PostgresDepositQueueRepository) PendingUsers(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id
+		FROM deposit_queue
+		WHERE status = $1
+		GROUP BY user_id
+		ORDER BY MIN(id)
+		LIMIT $2`,
+		models.DepositPending, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("PendingUsers - Query pending users failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("PendingUsers - Scan pending users failed")
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("PendingUsers - Iterate pending users failed")
+		return nil, err
+	}
+	return users, nil
+}
+
+// ApplyNext applies the oldest pending deposit of userID and returns it, or
+// nil when there is none or another consumer is processing the wallet. The
+// wallet is credited and the deposit marked applied in one transaction, so a
+// deposit is applied exactly once. Deposits into frozen or closed wallets are
+// marked failed; other errors leave the deposit pending for the next attempt.
+// A transaction-scoped advisory lock on the wallet keeps concurrent consumers
+// from applying its deposits out of order.
+func (r *PostgresDepositQueueRepository) ApplyNext(ctx context.Context, userID string) (*models.QueuedDeposit, error) {
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT pg_try_advisory_xact_lock(hashtext('deposit_queue'), hashtext($1))",
+		userID,
+	).Scan(&locked)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Lock wallet queue failed")
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	deposit, err := scanQueuedDeposit(tx.QueryRowContext(ctx,
+		`SELECT id::text, user_id, amount, status, error, transaction_id::text, created_at, processed_at,
+			memo, reference_id, metadata
+		FROM deposit_queue
+		WHERE user_id = $1 AND status = $2
+		ORDER BY id
+		LIMIT 1
+		FOR UPDATE`,
+		userID, models.DepositPending,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Query next deposit failed")
+		return nil, err
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"depositID": deposit.ID,
+		"amount":    deposit.Amount,
+	})
+
+	// The deposit transaction carries the details the deposit was queued with
+	details := operation.Details{Metadata: deposit.Metadata}
+	if deposit.Memo != nil {
+		details.Memo = *deposit.Memo
+	}
+	if deposit.ReferenceID != nil {
+		details.Reference = *deposit.ReferenceID
+	}
+	transactionID, err := creditWallet(operation.WithDetails(ctx, details), sqlTx{tx}, logger, userID, deposit.Amount, r.provisionedOnly)
+	switch {
+	case errors.Is(err, ErrWalletFrozen), errors.Is(err, ErrWalletClosed), errors.Is(err, ErrUserNotFound):
+		reason := err.Error()
+		deposit.Status, deposit.Error = models.DepositFailed, &reason
+	case err != nil:
+		return nil, err
+	default:
+		deposit.Status, deposit.TransactionID = models.DepositApplied, &transactionID
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE deposit_queue
+		SET status = $1, error = $2, transaction_id = $3::int, processed_at = NOW()
+		WHERE id::text = $4
+		RETURNING processed_at`,
+		deposit.Status, deposit.Error, deposit.TransactionID, deposit.ID,
+	).Scan(&deposit.ProcessedAt)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Update queued deposit failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("ApplyNext - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithField("status", deposit.Status).Info("Queued deposit processed")
+	return deposit, nil
+}
+
+func scanQueuedDeposit(row *sql.Row) (*models.QueuedDeposit, error) {
+	var deposit models.QueuedDeposit
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.UserID,
+		&deposit.Amount,
+		&deposit.Status,
+		&deposit.Error,
+		&deposit.TransactionID,
+		&deposit.CreatedAt,
+		&deposit.ProcessedAt,
+		&deposit.Memo,
+		&deposit.ReferenceID,
+		(*metadataColumn)(&deposit.Metadata),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &deposit, nil
+}
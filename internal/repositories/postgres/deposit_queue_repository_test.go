@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestDepositQueueRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewDepositQueueRepository(mockDB, logrus.New(), false)
+	now := time.Now()
+	columns := []string{"id", "user_id", "amount", "status", "error", "transaction_id", "created_at", "processed_at", "memo", "reference_id", "metadata"}
+
+	t.Run("Enqueue", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO deposit_queue`).WithArgs("user1", decimal.NewFromInt(100), "key-1", models.DepositPending, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).AddRow("7", models.DepositPending, now))
+
+		deposit := &models.QueuedDeposit{UserID: "user1", Amount: decimal.NewFromInt(100)}
+		require.NoError(t, repo.Enqueue(ctx, deposit, "key-1"))
+		require.Equal(t, "7", deposit.ID)
+		require.Equal(t, models.DepositPending, deposit.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		require.ErrorIs(t, repo.Enqueue(ctx, &models.QueuedDeposit{UserID: "user1", Amount: decimal.Zero}, ""), ErrInvalidAmount)
+	})
+
+	t.Run("GetQueuedDeposit not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("user1", "8").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetQueuedDeposit(ctx, "user1", "8")
+		require.ErrorIs(t, err, ErrQueuedDepositNotFound)
+	})
+
+	t.Run("PendingUsers", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT user_id\s+FROM deposit_queue`).WithArgs(models.DepositPending, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user2").AddRow("user1"))
+
+		users, err := repo.PendingUsers(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"user2", "user1"}, users)
+	})
+
+	t.Run("ApplyNext", func(t *testing.T) {
+		t.Run("applies the oldest deposit", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+			mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("user1", models.DepositPending).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("7", "user1", "100", models.DepositPending, nil, nil, now, nil, nil, "order-42", nil))
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}).AddRow(false, "150"))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "deposit", sqlmock.AnyArg(), nil, nil, nil, "order-42", nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCredited, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectQuery(`UPDATE deposit_queue`).WithArgs(models.DepositApplied, nil, "3", "7").
+				WillReturnRows(sqlmock.NewRows([]string{"processed_at"}).AddRow(now))
+			mock.ExpectCommit()
+
+			deposit, err := repo.ApplyNext(ctx, "user1")
+			require.NoError(t, err)
+			require.Equal(t, models.DepositApplied, deposit.Status)
+			require.Equal(t, "3", *deposit.TransactionID)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("frozen wallet fails the deposit", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+			mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("user1", models.DepositPending).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("8", "user1", "50", models.DepositPending, nil, nil, now, nil, nil, nil, nil))
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(50)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}))
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("frozen"))
+			mock.ExpectQuery(`UPDATE deposit_queue`).WithArgs(models.DepositFailed, ErrWalletFrozen.Error(), nil, "8").
+				WillReturnRows(sqlmock.NewRows([]string{"processed_at"}).AddRow(now))
+			mock.ExpectCommit()
+
+			deposit, err := repo.ApplyNext(ctx, "user1")
+			require.NoError(t, err)
+			require.Equal(t, models.DepositFailed, deposit.Status)
+			require.Equal(t, ErrWalletFrozen.Error(), *deposit.Error)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("wallet locked by another consumer", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+			mock.ExpectRollback()
+
+			deposit, err := repo.ApplyNext(ctx, "user1")
+			require.NoError(t, err)
+			require.Nil(t, deposit)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("nothing pending", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+			mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("user1", models.DepositPending).WillReturnRows(sqlmock.NewRows(columns))
+			mock.ExpectRollback()
+
+			deposit, err := repo.ApplyNext(ctx, "user1")
+			require.NoError(t, err)
+			require.Nil(t, deposit)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
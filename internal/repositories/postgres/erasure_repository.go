@@ -0,0 +1,453 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// ErasureRepository records erasure requests of wallet owners and carries
+// them out once their retention period has passed
+type ErasureRepository interface {
+	RequestErasure(ctx context.Context, erasure *models.DataErasure) error
+	GetErasure(ctx context.Context, id string) (*models.DataErasure, error)
+	ListErasures(ctx context.Context, status string, limit int) ([]models.DataErasure, error)
+	CancelErasure(ctx context.Context, id string) (*models.DataErasure, error)
+	EraseNext(ctx context.Context) (*models.DataErasure, string, error)
+}
+
+var (
+	ErrErasureNotFound   = errors.New("erasure not found")
+	ErrErasurePending    = errors.New("an erasure is already pending for the wallet")
+	ErrErasureNotPending = errors.New("erasure is not pending")
+	ErrErasureNotAllowed = errors.New("system accounts and savings sub-accounts cannot be erased")
+	ErrNoErasureDue      = errors.New("no erasure due")
+)
+
+// erasedReferences lists the columns besides userReferences that can hold
+// the user ID of a wallet owner and are pseudonymized by an erasure
+var erasedReferences = []struct{ table, column string }{
+	{"transactions", "actor"},
+	{"transactions", "merged_from"},
+	{"transfer_schedules", "user_id"},
+	{"transfer_schedules", "to_user_id"},
+	{"transfer_schedules", "created_by"},
+	{"transfer_schedule_runs", "user_id"},
+	{"transfer_schedule_runs", "to_user_id"},
+	{"limit_increase_requests", "user_id"},
+	{"compliance_decisions", "user_id"},
+	{"top_up_rules", "user_id"},
+	{"top_up_rules", "created_by"},
+	{"top_up_runs", "user_id"},
+	{"round_up_rules", "user_id"},
+	{"round_up_rules", "created_by"},
+	{"round_ups", "user_id"},
+	{"balance_snapshots", "user_id"},
+	{"reconciliation_reports", "user_id"},
+	{"consistency_repairs", "user_id"},
+	{"wallet_ownership_changes", "previous_user_id"},
+	{"wallet_ownership_changes", "new_user_id"},
+	{"wallet_merges", "source_user_id"},
+	{"wallet_merges", "target_user_id"},
+	{"notification_preferences", "user_id"},
+	{"notification_digest_items", "user_id"},
+	{"audit_log", "user_id"},
+	{"audit_log", "actor"},
+}
+
+const erasureColumns = `id::text, user_id, status, reason, requested_by, requested_at, erase_after,
+	erased_at, cancelled_at, pseudonym, rows_anonymized`
+
+type PostgresErasureRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewErasureRepository(db *sql.DB, logger *logrus.Logger) *PostgresErasureRepository {
+	return &PostgresErasureRepository{db: db, logger: logger}
+}
+
+// RequestErasure closes the wallet of erasure.UserID unless it is closed
+// already and records the pending erasure, filling in its ID, status and
+// request time. The wallet and its savings sub-account must be empty.
+func (r *PostgresErasureRepository) RequestErasure(ctx context.Context, erasure *models.DataErasure) error {
+	if erasure.UserID == nil || *erasure.UserID == "" {
+		r.logger.WithContext(ctx).Warn("RequestErasure - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+	userID := *erasure.UserID
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("RequestErasure - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance, held decimal.Decimal
+	var status string
+	var label sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status, label FROM wallets WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&balance, &held, &status, &label)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("RequestErasure - Cannot find wallet in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("RequestErasure - Query wallet failed")
+		return err
+	}
+
+	if label.String == models.SystemAccountLabel || label.String == models.SavingsAccountLabel {
+		logger.Warn("RequestErasure - Wallet cannot be erased")
+		return ErrErasureNotAllowed
+	}
+
+	var savings decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(balance + held), 0) FROM wallets WHERE user_id = $1",
+		models.SavingsAccount(userID),
+	).Scan(&savings)
+	if err != nil {
+		logger.WithError(err).Error("RequestErasure - Query savings balance failed")
+		return err
+	}
+
+	if !balance.IsZero() || !held.IsZero() || !savings.IsZero() {
+		logger.WithFields(logrus.Fields{
+			"balance": balance,
+			"held":    held,
+			"savings": savings,
+		}).Warn("RequestErasure - Wallet is not empty")
+		return ErrWalletNotEmpty
+	}
+
+	if status != models.WalletStatusClosed {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE wallets SET status = $1 WHERE user_id = $2",
+			models.WalletStatusClosed, userID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("RequestErasure - Close wallet failed")
+			return err
+		}
+
+		event := events.New(events.TypeWalletClosed, events.WalletLifecycleChanged{
+			UserID:   userID,
+			Previous: &events.WalletState{Status: status},
+			Current:  events.WalletState{Status: models.WalletStatusClosed},
+			Reason:   &erasure.Reason,
+		})
+		if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+			logger.WithError(err).Error("RequestErasure - Record lifecycle event failed")
+			return err
+		}
+	}
+
+	erasure.Status = models.ErasurePending
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO data_erasures (user_id, status, reason, requested_by, erase_after)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+		RETURNING id::text, requested_at`,
+		userID, erasure.Status, erasure.Reason, erasure.RequestedBy, erasure.EraseAfter,
+	).Scan(&erasure.ID, &erasure.RequestedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("RequestErasure - Erasure already pending")
+		return ErrErasurePending
+	}
+	if err != nil {
+		logger.WithError(err).Error("RequestErasure - Create erasure failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("RequestErasure - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"erasureID":  erasure.ID,
+		"eraseAfter": erasure.EraseAfter,
+	}).Info("Erasure requested")
+	return nil
+}
+
+func (r *PostgresErasureRepository) GetErasure(ctx context.Context, id string) (*models.DataErasure, error) {
+	erasure, err := scanErasure(r.db.QueryRowContext(ctx,
+		`SELECT `+erasureColumns+` FROM data_erasures WHERE id::text = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrErasureNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("erasureID", id).Error("GetErasure - Query erasure failed")
+		return nil, err
+	}
+	return erasure, nil
+}
+
+// ListErasures returns up to limit erasures, newest first, with status or
+// any status when empty
+func (r *PostgresErasureRepository) ListErasures(ctx context.Context, status string, limit int) ([]models.DataErasure, error) {
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListErasures - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+erasureColumns+`
+		FROM data_erasures
+		WHERE $1 = '' OR status = $1
+		ORDER BY id DESC
+		LIMIT $2`,
+		status, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListErasures - Query erasures failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	erasures := []models.DataErasure{}
+	for rows.Next() {
+		erasure, err := scanErasure(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListErasures - Scan erasure failed")
+			return nil, err
+		}
+		erasures = append(erasures, *erasure)
+	}
+	return erasures, rows.Err()
+}
+
+// CancelErasure cancels the pending erasure id. The wallet stays closed.
+func (r *PostgresErasureRepository) CancelErasure(ctx context.Context, id string) (*models.DataErasure, error) {
+	logger := r.logger.WithContext(ctx).WithField("erasureID", id)
+
+	erasure, err := scanErasure(r.db.QueryRowContext(ctx,
+		`UPDATE data_erasures SET status = $2, cancelled_at = NOW()
+		WHERE id::text = $1 AND status = $3
+		RETURNING `+erasureColumns,
+		id, models.ErasureCancelled, models.ErasurePending,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Tell an unknown erasure from one that is no longer pending
+		if _, err := r.GetErasure(ctx, id); err != nil {
+			return nil, err
+		}
+		logger.Warn("CancelErasure - Erasure is not pending")
+		return nil, ErrErasureNotPending
+	}
+	if err != nil {
+		logger.WithError(err).Error("CancelErasure - Update erasure failed")
+		return nil, err
+	}
+
+	logger.Info("Erasure cancelled")
+	return erasure, nil
+}
+
+// EraseNext carries out the oldest pending erasure whose retention period
+// has passed and returns it with the user ID it erased, so the caller can
+// drop what it cached, or fails with ErrNoErasureDue. The user ID of
+// the wallet and of its savings sub-account is replaced by the pseudonym of
+// the erasure everywhere it appears, so balances and the ledger stay
+// consistent, and the free-form personal data is cleared. Erasures being
+// carried out by another instance are skipped.
+func (r *PostgresErasureRepository) EraseNext(ctx context.Context) (*models.DataErasure, string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("EraseNext - Begin DB transaction failed")
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	var id, userID string
+	err = tx.QueryRowContext(ctx,
+		`SELECT id::text, user_id FROM data_erasures
+		WHERE status = $1 AND erase_after <= NOW()
+		ORDER BY erase_after, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`,
+		models.ErasurePending,
+	).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrNoErasureDue
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("EraseNext - Query due erasure failed")
+		return nil, "", err
+	}
+
+	logger := r.logger.WithContext(ctx).WithField("erasureID", id)
+	pseudonym := models.ErasurePseudonym(id)
+
+	// The audit log only accepts changes from erasures
+	if _, err = tx.ExecContext(ctx, "SET LOCAL wallet.audit_erasure = 'on'"); err != nil {
+		logger.WithError(err).Error("EraseNext - Allow audit log pseudonymization failed")
+		return nil, "", err
+	}
+
+	var anonymized int64
+	for _, subject := range []struct{ from, to string }{
+		{userID, pseudonym},
+		{models.SavingsAccount(userID), models.SavingsAccount(pseudonym)},
+	} {
+		count, err := pseudonymize(ctx, tx, subject.from, subject.to)
+		if err != nil {
+			logger.WithError(err).Error("EraseNext - Pseudonymize user failed")
+			return nil, "", err
+		}
+		anonymized += count
+	}
+
+	erasure, err := scanErasure(tx.QueryRowContext(ctx,
+		`UPDATE data_erasures
+		SET status = $2, user_id = NULL, pseudonym = $3, rows_anonymized = $4, erased_at = NOW()
+		WHERE id::text = $1
+		RETURNING `+erasureColumns,
+		id, models.ErasureCompleted, pseudonym, anonymized,
+	))
+	if err != nil {
+		logger.WithError(err).Error("EraseNext - Complete erasure failed")
+		return nil, "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("EraseNext - Commit DB transaction failed")
+		return nil, "", err
+	}
+
+	logger.WithField("rowsAnonymized", anonymized).Info("Personal data erased")
+	return erasure, userID, nil
+}
+
+// pseudonymize replaces userID by pseudonym inside tx wherever it refers to
+// the wallet owner, clears the personal data tied to the wallet and returns
+// the number of rows changed. Nothing changes for a user ID that is not
+// referenced.
+func pseudonymize(ctx context.Context, tx *sql.Tx, userID, pseudonym string) (int64, error) {
+	var total int64
+	exec := func(query string, args ...interface{}) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			total += affected
+		}
+		return nil
+	}
+
+	// Wallets and transactions may only refer to registered users, so the
+	// pseudonym is registered before they move to it. The user with its
+	// external ID is deleted once nothing refers to it anymore.
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO users (id, kind) SELECT $1, kind FROM users WHERE id = $2",
+		pseudonym, userID,
+	)
+	if err != nil {
+		return total, err
+	}
+
+	// Labels other than the savings marker and metadata were given by the
+	// owner
+	err = exec(
+		"UPDATE wallets SET user_id = $1, label = CASE WHEN label = $3 THEN label END, country = NULL, metadata = '{}' WHERE user_id = $2",
+		pseudonym, userID, models.SavingsAccountLabel,
+	)
+	if err != nil {
+		return total, err
+	}
+
+	for _, refs := range [][]struct{ table, column string }{userReferences, erasedReferences} {
+		for _, ref := range refs {
+			err = exec("UPDATE "+ref.table+" SET "+ref.column+" = $1 WHERE "+ref.column+" = $2", pseudonym, userID)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		// Withdrawal destinations are bank accounts or addresses of the owner
+		{"UPDATE withdrawals SET destination = '' WHERE user_id = $1 AND destination <> ''", []interface{}{pseudonym}},
+		// Idempotency keys are chosen by clients and keep request hashes
+		{"DELETE FROM idempotency_keys WHERE user_id = $1", []interface{}{pseudonym}},
+		{"UPDATE deposit_queue SET idempotency_key = NULL WHERE user_id = $1 AND idempotency_key IS NOT NULL", []interface{}{pseudonym}},
+		// Notes of payment requests are written by the users, and so are the
+		// messages acknowledging transfers and the memos of transactions
+		{"UPDATE payment_requests SET note = '' WHERE (requester_id = $1 OR payer_id = $1) AND note <> ''", []interface{}{pseudonym}},
+		{"UPDATE transactions SET acknowledgment_message = NULL, memo = NULL WHERE (from_user_id = $1 OR to_user_id = $1) AND (acknowledgment_message IS NOT NULL OR memo IS NOT NULL)", []interface{}{pseudonym}},
+		{"UPDATE transfer_confirmations SET memo = NULL, metadata = NULL, idempotency_key = NULL WHERE sender_id = $1 OR receiver_id = $1", []interface{}{pseudonym}},
+		// Audit entries keep what changed but not where it was requested from
+		{"UPDATE audit_log SET client_ip = NULL WHERE (user_id = $1 OR actor = $1) AND client_ip IS NOT NULL", []interface{}{pseudonym}},
+		// The exposure job rebuilds the exposures without the erased user
+		{"DELETE FROM counterparty_exposures WHERE user_a = $1 OR user_b = $1", []interface{}{userID}},
+		{"UPDATE settings SET scope_id = $1 WHERE scope = $2 AND scope_id = $3", []interface{}{pseudonym, models.SettingScopeWallet, userID}},
+		// Events keep their shape; the user ID is replaced as a JSON string
+		{`UPDATE outbox_events
+			SET aggregate_id = CASE WHEN aggregate_id = $2 THEN $1 ELSE aggregate_id END,
+				payload = replace(payload::text, to_json($2::text)::text, to_json($1::text)::text)::jsonb,
+				operation = replace(operation::text, to_json($2::text)::text, to_json($1::text)::text)::jsonb
+			WHERE aggregate_id = $2
+				OR strpos(payload::text, to_json($2::text)::text) > 0
+				OR strpos(operation::text, to_json($2::text)::text) > 0`, []interface{}{pseudonym, userID}},
+		{"DELETE FROM users WHERE id = $1", []interface{}{userID}},
+	}
+	for _, statement := range statements {
+		if err = exec(statement.query, statement.args...); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func scanErasure(row rowScanner) (*models.DataErasure, error) {
+	var erasure models.DataErasure
+	var userID, pseudonym sql.NullString
+	var erasedAt, cancelledAt sql.NullTime
+	err := row.Scan(
+		&erasure.ID,
+		&userID,
+		&erasure.Status,
+		&erasure.Reason,
+		&erasure.RequestedBy,
+		&erasure.RequestedAt,
+		&erasure.EraseAfter,
+		&erasedAt,
+		&cancelledAt,
+		&pseudonym,
+		&erasure.RowsAnonymized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		erasure.UserID = &userID.String
+	}
+	if pseudonym.Valid {
+		erasure.Pseudonym = &pseudonym.String
+	}
+	if erasedAt.Valid {
+		erasure.ErasedAt = &erasedAt.Time
+	}
+	if cancelledAt.Valid {
+		erasure.CancelledAt = &cancelledAt.Time
+	}
+	return &erasure, nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestErasureRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewErasureRepository(mockDB, logrus.New())
+	now := time.Now()
+	eraseAfter := now.Add(30 * 24 * time.Hour)
+	columns := []string{"id", "user_id", "status", "reason", "requested_by", "requested_at", "erase_after",
+		"erased_at", "cancelled_at", "pseudonym", "rows_anonymized"}
+	walletColumns := []string{"balance", "held", "status", "label"}
+
+	t.Run("RequestErasure closes the wallet", func(t *testing.T) {
+		userID := "user1"
+		erasure := &models.DataErasure{UserID: &userID, Reason: "deletion request", RequestedBy: "admin", EraseAfter: eraseAfter}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT balance, held, status, label FROM wallets WHERE user_id = \$1 FOR UPDATE`).WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("0", "0", models.WalletStatusActive, nil))
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(balance \+ held\), 0\) FROM wallets`).WithArgs(models.SavingsAccount(userID)).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("0"))
+		mock.ExpectExec(`UPDATE wallets SET status = \$1`).WithArgs(models.WalletStatusClosed, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO data_erasures`).
+			WithArgs(userID, models.ErasurePending, "deletion request", "admin", eraseAfter).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "requested_at"}).AddRow("7", now))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.RequestErasure(ctx, erasure))
+		require.Equal(t, "7", erasure.ID)
+		require.Equal(t, models.ErasurePending, erasure.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RequestErasure already pending", func(t *testing.T) {
+		userID := "user1"
+		erasure := &models.DataErasure{UserID: &userID, Reason: "deletion request", RequestedBy: "admin", EraseAfter: eraseAfter}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM wallets WHERE user_id = \$1 FOR UPDATE`).WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("0", "0", models.WalletStatusClosed, nil))
+		mock.ExpectQuery(`SELECT COALESCE`).WithArgs(models.SavingsAccount(userID)).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("0"))
+		mock.ExpectQuery(`INSERT INTO data_erasures`).WillReturnRows(sqlmock.NewRows([]string{"id", "requested_at"}))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, repo.RequestErasure(ctx, erasure), ErrErasurePending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RequestErasure of a wallet with savings", func(t *testing.T) {
+		userID := "user1"
+		erasure := &models.DataErasure{UserID: &userID, Reason: "deletion request", EraseAfter: eraseAfter}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM wallets WHERE user_id = \$1 FOR UPDATE`).WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("0", "0", models.WalletStatusActive, nil))
+		mock.ExpectQuery(`SELECT COALESCE`).WithArgs(models.SavingsAccount(userID)).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("12.50"))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, repo.RequestErasure(ctx, erasure), ErrWalletNotEmpty)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RequestErasure of a system account", func(t *testing.T) {
+		userID := models.SystemAccountFees
+		erasure := &models.DataErasure{UserID: &userID, Reason: "deletion request", EraseAfter: eraseAfter}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM wallets WHERE user_id = \$1 FOR UPDATE`).WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("0", "0", models.WalletStatusActive, models.SystemAccountLabel))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, repo.RequestErasure(ctx, erasure), ErrErasureNotAllowed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CancelErasure not pending", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE data_erasures SET status = \$2, cancelled_at = NOW\(\)`).
+			WithArgs("7", models.ErasureCancelled, models.ErasurePending).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`FROM data_erasures WHERE id::text = \$1`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("7", nil, models.ErasureCompleted, "deletion request", "admin", now, eraseAfter, now, nil, "erased:7", 12))
+
+		_, err := repo.CancelErasure(ctx, "7")
+		require.ErrorIs(t, err, ErrErasureNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CancelErasure not found", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE data_erasures`).WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`FROM data_erasures WHERE id::text = \$1`).WithArgs("8").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.CancelErasure(ctx, "8")
+		require.ErrorIs(t, err, ErrErasureNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("EraseNext pseudonymizes the wallet and its savings", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id::text, user_id FROM data_erasures .+ FOR UPDATE SKIP LOCKED`).WithArgs(models.ErasurePending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("7", "user1"))
+		mock.ExpectExec(`SET LOCAL wallet.audit_erasure = 'on'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		statements := 1 + len(userReferences) + len(erasedReferences) + 11
+		for _, subject := range []struct{ from, to string }{
+			{"user1", "erased:7"},
+			{models.SavingsAccount("user1"), models.SavingsAccount("erased:7")},
+		} {
+			mock.ExpectExec(`INSERT INTO users \(id, kind\)`).WithArgs(subject.to, subject.from).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE wallets SET user_id = \$1`).WithArgs(subject.to, subject.from, models.SavingsAccountLabel).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			for i := 1; i < statements; i++ {
+				mock.ExpectExec(`UPDATE|DELETE`).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+		}
+		mock.ExpectQuery(`UPDATE data_erasures\s+SET status = \$2, user_id = NULL`).
+			WithArgs("7", models.ErasureCompleted, "erased:7", int64(2*statements)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("7", nil, models.ErasureCompleted, "deletion request", "admin", now, eraseAfter, now, nil, "erased:7", 2*statements))
+		mock.ExpectCommit()
+
+		erasure, userID, err := repo.EraseNext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "user1", userID)
+		require.Nil(t, erasure.UserID)
+		require.Equal(t, "erased:7", *erasure.Pseudonym)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("EraseNext none due", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id::text, user_id FROM data_erasures`).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+		mock.ExpectRollback()
+
+		_, _, err := repo.EraseNext(ctx)
+		require.ErrorIs(t, err, ErrNoErasureDue)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
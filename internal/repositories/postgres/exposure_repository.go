@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+type ExposureRepository interface {
+	Refresh(ctx context.Context, windowDays int) (int, error)
+	GetExposure(ctx context.Context, userID, counterpartyID string, windowDays int) (*models.Exposure, error)
+	ListExposures(ctx context.Context, filter models.ExposureFilter) ([]models.Exposure, error)
+}
+
+var (
+	ErrExposureNotFound = errors.New("exposure not found")
+	ErrInvalidWindow    = errors.New("invalid window")
+)
+
+type PostgresExposureRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewExposureRepository(db *sql.DB, logger *logrus.Logger) *PostgresExposureRepository {
+	return &PostgresExposureRepository{db: db, logger: logger}
+}
+
+// Refresh recomputes the materialized exposures of a window from the
+// transfers within it and returns the number of counterparty pairs
+func (r *PostgresExposureRepository) Refresh(ctx context.Context, windowDays int) (int, error) {
+	if windowDays <= 0 {
+		r.logger.WithContext(ctx).Warn("Refresh - windowDays must be greater than zero")
+		return 0, ErrInvalidWindow
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"windowDays": windowDays,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Refresh - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM counterparty_exposures WHERE window_days = $1",
+		windowDays,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Refresh - Clear exposures failed")
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO counterparty_exposures
+		(user_a, user_b, window_days, volume, transfer_count, computed_at)
+		SELECT LEAST(from_user_id, to_user_id), GREATEST(from_user_id, to_user_id), $1,
+			SUM(amount), COUNT(*), NOW()
+		FROM transactions
+		WHERE type = 'transfer' AND created_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1, 2`,
+		windowDays,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Refresh - Materialize exposures failed")
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.WithError(err).Error("Refresh - Commit DB transaction failed")
+		return 0, err
+	}
+
+	pairs, _ := result.RowsAffected()
+	return int(pairs), nil
+}
+
+// GetExposure returns the exposure between two users for a window
+func (r *PostgresExposureRepository) GetExposure(ctx context.Context, userID, counterpartyID string, windowDays int) (*models.Exposure, error) {
+	if userID == "" || counterpartyID == "" || userID == counterpartyID {
+		r.logger.WithContext(ctx).Warn("GetExposure - userID and counterpartyID must be distinct non-empty strings")
+		return nil, ErrInvalidUserID
+	}
+
+	userA, userB := userID, counterpartyID
+	if userB < userA {
+		userA, userB = userB, userA
+	}
+
+	var exposure models.Exposure
+	err := r.db.QueryRowContext(ctx,
+		`SELECT user_a, user_b, window_days, volume, transfer_count, computed_at
+		FROM counterparty_exposures
+		WHERE user_a = $1 AND user_b = $2 AND window_days = $3`,
+		userA, userB, windowDays,
+	).Scan(
+		&exposure.UserA,
+		&exposure.UserB,
+		&exposure.WindowDays,
+		&exposure.Volume,
+		&exposure.TransferCount,
+		&exposure.ComputedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExposureNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("GetExposure - Query exposure failed")
+		return nil, err
+	}
+
+	return &exposure, nil
+}
+
+// ListExposures returns the exposures of a window ordered by volume, largest first
+func (r *PostgresExposureRepository) ListExposures(ctx context.Context, filter models.ExposureFilter) ([]models.Exposure, error) {
+	if filter.WindowDays <= 0 {
+		r.logger.WithContext(ctx).Warn("ListExposures - windowDays must be greater than zero")
+		return nil, ErrInvalidWindow
+	}
+
+	if filter.Limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListExposures - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	conditions := []string{"window_days = $1"}
+	args := []interface{}{filter.WindowDays}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("(user_a = $%[1]d OR user_b = $%[1]d)", len(args)))
+	}
+	if filter.MinVolume != nil {
+		args = append(args, *filter.MinVolume)
+		conditions = append(conditions, fmt.Sprintf("volume >= $%d", len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_a, user_b, window_days, volume, transfer_count, computed_at
+		FROM counterparty_exposures
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY volume DESC
+		LIMIT $`+fmt.Sprint(len(args)),
+		args...,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListExposures - Query exposures failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exposures []models.Exposure
+	for rows.Next() {
+		var exposure models.Exposure
+		err := rows.Scan(
+			&exposure.UserA,
+			&exposure.UserB,
+			&exposure.WindowDays,
+			&exposure.Volume,
+			&exposure.TransferCount,
+			&exposure.ComputedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListExposures - Scan exposures failed")
+			return nil, err
+		}
+		exposures = append(exposures, exposure)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListExposures - Iterate exposures failed")
+		return nil, err
+	}
+	return exposures, nil
+}
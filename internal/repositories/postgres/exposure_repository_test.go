@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestExposureRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewExposureRepository(mockDB, logrus.New())
+	columns := []string{"user_a", "user_b", "window_days", "volume", "transfer_count", "computed_at"}
+
+	t.Run("Refresh", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM counterparty_exposures`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 4))
+			mock.ExpectExec(`INSERT INTO counterparty_exposures`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 5))
+			mock.ExpectCommit()
+
+			pairs, err := repo.Refresh(ctx, 7)
+			require.NoError(t, err)
+			require.Equal(t, 5, pairs)
+		})
+
+		t.Run("invalid window", func(t *testing.T) {
+			_, err := repo.Refresh(ctx, 0)
+			require.ErrorIs(t, err, ErrInvalidWindow)
+		})
+	})
+
+	t.Run("GetExposure", func(t *testing.T) {
+		t.Run("orders the pair", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT user_a`).WithArgs("user1", "user2", 7).WillReturnRows(
+				sqlmock.NewRows(columns).AddRow("user1", "user2", 7, "60000", 12, time.Now()))
+
+			exposure, err := repo.GetExposure(ctx, "user2", "user1", 7)
+			require.NoError(t, err)
+			require.True(t, decimal.NewFromInt(60000).Equal(exposure.Volume))
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT user_a`).WithArgs("user1", "user3", 7).WillReturnError(sql.ErrNoRows)
+			_, err := repo.GetExposure(ctx, "user1", "user3", 7)
+			require.ErrorIs(t, err, ErrExposureNotFound)
+		})
+
+		t.Run("same user", func(t *testing.T) {
+			_, err := repo.GetExposure(ctx, "user1", "user1", 7)
+			require.ErrorIs(t, err, ErrInvalidUserID)
+		})
+	})
+
+	t.Run("ListExposures", func(t *testing.T) {
+		t.Run("with filters", func(t *testing.T) {
+			userID, minVolume := "user1", decimal.NewFromInt(50000)
+			mock.ExpectQuery(`WHERE window_days = \$1 AND \(user_a = \$2 OR user_b = \$2\) AND volume >= \$3\s+ORDER BY volume DESC\s+LIMIT \$4`).
+				WithArgs(7, userID, minVolume, 10).WillReturnRows(
+				sqlmock.NewRows(columns).AddRow("user1", "user2", 7, "60000", 12, time.Now()))
+
+			exposures, err := repo.ListExposures(ctx, models.ExposureFilter{UserID: &userID, WindowDays: 7, MinVolume: &minVolume, Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, exposures, 1)
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			_, err := repo.ListExposures(ctx, models.ExposureFilter{WindowDays: 7})
+			require.ErrorIs(t, err, ErrInvalidLimit)
+		})
+	})
+}
@@ -0,0 +1,363 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/tracing"
+)
+
+// FeeRepository stores the fee tiers and charges fees together with the
+// withdrawals and transfers they are due on
+type FeeRepository interface {
+	ListFees(ctx context.Context) ([]models.Fee, error)
+	CreateFee(ctx context.Context, fee *models.Fee) error
+	UpdateFee(ctx context.Context, fee *models.Fee) error
+	DeleteFee(ctx context.Context, feeID string) error
+	WalletCurrency(ctx context.Context, userID string) (string, error)
+	WithdrawWithFee(ctx context.Context, userID string, amount, fee decimal.Decimal, expectedBalance *decimal.Decimal) error
+	TransferWithFee(ctx context.Context, fromUserID, toUserID string, amount, fee decimal.Decimal, conversion *models.Conversion, expectedBalance *decimal.Decimal) error
+}
+
+var (
+	ErrFeeNotFound = errors.New("fee not found")
+	ErrFeeExists   = errors.New("a fee tier with this operation, currency and min_amount already exists")
+)
+
+const feeColumns = `id::text, operation, currency, min_amount, flat_fee, percent_fee, updated_by, updated_at`
+
+type PostgresFeeRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewFeeRepository(db *sql.DB, logger *logrus.Logger) *PostgresFeeRepository {
+	return &PostgresFeeRepository{db: db, logger: logger}
+}
+
+// ListFees returns every fee tier by operation, currency and min_amount
+func (r *PostgresFeeRepository) ListFees(ctx context.Context) ([]models.Fee, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+feeColumns+`
+		FROM fees
+		ORDER BY operation, currency, min_amount`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListFees - Query fees failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fees []models.Fee
+	for rows.Next() {
+		fee, err := scanFee(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListFees - Scan fees failed")
+			return nil, err
+		}
+		fees = append(fees, *fee)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListFees - Iterate fees failed")
+		return nil, err
+	}
+	return fees, nil
+}
+
+// CreateFee persists a fee tier, with fee.UpdatedBy as the actor, filling in
+// the generated ID and update time
+func (r *PostgresFeeRepository) CreateFee(ctx context.Context, fee *models.Fee) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO fees (operation, currency, min_amount, flat_fee, percent_fee, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (operation, currency, min_amount) DO NOTHING
+		RETURNING id::text, updated_at`,
+		fee.Operation, fee.Currency, fee.MinAmount, fee.FlatFee, fee.PercentFee, fee.UpdatedBy,
+	).Scan(&fee.ID, &fee.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFeeExists
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"operation": fee.Operation,
+			"currency":  fee.Currency,
+		}).Error("CreateFee - Create fee failed")
+		return err
+	}
+	return nil
+}
+
+// UpdateFee replaces the fee tier fee.ID, with fee.UpdatedBy as the actor.
+// UpdatedAt is filled in on success.
+func (r *PostgresFeeRepository) UpdateFee(ctx context.Context, fee *models.Fee) error {
+	logger := r.logger.WithContext(ctx).WithField("feeID", fee.ID)
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM fees
+			WHERE operation = $1 AND currency = $2 AND min_amount = $3 AND id::text <> $4
+		)`,
+		fee.Operation, fee.Currency, fee.MinAmount, fee.ID,
+	).Scan(&exists)
+	if err != nil {
+		logger.WithError(err).Error("UpdateFee - Query conflicting fee failed")
+		return err
+	}
+	if exists {
+		return ErrFeeExists
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE fees
+		SET operation = $1, currency = $2, min_amount = $3, flat_fee = $4, percent_fee = $5,
+			updated_by = $6, updated_at = NOW()
+		WHERE id::text = $7
+		RETURNING updated_at`,
+		fee.Operation, fee.Currency, fee.MinAmount, fee.FlatFee, fee.PercentFee, fee.UpdatedBy, fee.ID,
+	).Scan(&fee.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFeeNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("UpdateFee - Update fee failed")
+		return err
+	}
+	return nil
+}
+
+// DeleteFee removes a fee tier. Operations it covered fall to the next lower
+// tier, or are free without one.
+func (r *PostgresFeeRepository) DeleteFee(ctx context.Context, feeID string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM fees WHERE id::text = $1", feeID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("feeID", feeID).Error("DeleteFee - Delete fee failed")
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrFeeNotFound
+	}
+	return nil
+}
+
+// WalletCurrency returns the currency of the wallet of userID, empty for a
+// wallet without one or that does not exist
+func (r *PostgresFeeRepository) WalletCurrency(ctx context.Context, userID string) (string, error) {
+	var currency string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COALESCE(currency, '') FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("WalletCurrency - Query wallet currency failed")
+		return "", err
+	}
+	return currency, nil
+}
+
+// WithdrawWithFee deducts amount from the wallet of userID and charges fee to
+// it atomically. The available balance must cover both.
+func (r *PostgresFeeRepository) WithdrawWithFee(ctx context.Context, userID string, amount, fee decimal.Decimal, expectedBalance *decimal.Decimal) (err error) {
+	ctx, span := startSpan(ctx, "WithdrawWithFee", userID)
+	defer func() { tracing.End(span, err) }()
+
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("WithdrawWithFee - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if !amount.IsPositive() || fee.IsNegative() {
+		r.logger.WithContext(ctx).Warn("WithdrawWithFee - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"amount": amount,
+		"fee":    fee,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("WithdrawWithFee - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	transactionID, err := debitWallet(ctx, sqlTx{tx}, logger, readLocked, userID, amount, fee, expectedBalance)
+	if err != nil {
+		return err
+	}
+	if err = chargeFee(ctx, tx, logger, userID, fee, transactionID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("WithdrawWithFee - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Withdraw successful")
+	return nil
+}
+
+// TransferWithFee moves amount between two wallets, converted when
+// conversion is set, and charges fee to the sender atomically. The sender's
+// available balance must cover both.
+func (r *PostgresFeeRepository) TransferWithFee(ctx context.Context, fromUserID, toUserID string, amount, fee decimal.Decimal, conversion *models.Conversion, expectedBalance *decimal.Decimal) (err error) {
+	ctx, span := startSpan(ctx, "TransferWithFee", fromUserID)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateTransfer(r.logger.WithContext(ctx), fromUserID, toUserID, amount); err != nil {
+		return err
+	}
+	if fee.IsNegative() {
+		r.logger.WithContext(ctx).Warn("TransferWithFee - fee cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"fromUserID": fromUserID,
+		"toUserID":   toUserID,
+		"amount":     amount,
+		"fee":        fee,
+	})
+
+	err = retryOnDeadlock(ctx, logger, "TransferWithFee", func() error {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			logger.WithError(err).Error("TransferWithFee - Begin DB transaction failed")
+			return err
+		}
+		defer tx.Rollback()
+
+		transactionID, err := moveFunds(ctx, sqlTx{tx}, logger, readLocked, fromUserID, toUserID, amount, fee, conversion, expectedBalance)
+		if err != nil {
+			return err
+		}
+		if err := chargeFee(ctx, tx, logger, fromUserID, fee, transactionID); err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			logger.WithError(err).Error("TransferWithFee - Commit DB transaction failed")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Transfer successful")
+	return nil
+}
+
+// chargeFee moves fee from the wallet of userID to the fee account inside tx
+// as a fee transaction linked to the transaction feeFor, and records its
+// event. The caller has locked the wallet and checked that it covers the fee.
+// A zero fee is not recorded. The fee account is created when missing.
+func chargeFee(ctx context.Context, tx *sql.Tx, logger *logrus.Entry, userID string, fee decimal.Decimal, feeFor string) error {
+	if !fee.IsPositive() {
+		return nil
+	}
+
+	var balance decimal.Decimal
+	err := tx.QueryRowContext(ctx,
+		"UPDATE wallets SET balance = balance - $1 WHERE user_id = $2 RETURNING balance",
+		fee, userID,
+	).Scan(&balance)
+	if err != nil {
+		logger.WithError(err).Error("Fee - Update payer balance failed")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO wallets (user_id, balance, label)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET balance = wallets.balance + $2`,
+		models.SystemAccountFees, fee, models.SystemAccountLabel,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Fee - Update fee account balance failed")
+		return err
+	}
+
+	var transactionID string
+	actor, channel := provenance(ctx)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions
+		(from_user_id, to_user_id, amount, type, created_at, actor, channel, fee_for)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		userID, models.SystemAccountFees, fee, models.TransactionTypeFee, time.Now(), actor, channel, feeFor,
+	).Scan(&transactionID)
+	if err != nil {
+		logger.WithError(err).Error("Fee - Create transaction record failed")
+		return err
+	}
+
+	sequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, userID)
+	if err != nil {
+		logger.WithError(err).Error("Fee - Assign payer sequence number failed")
+		return err
+	}
+	accountSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, models.SystemAccountFees)
+	if err != nil {
+		logger.WithError(err).Error("Fee - Assign fee account sequence number failed")
+		return err
+	}
+
+	event := events.New(events.TypeFeeCharged, events.FeeCharged{
+		UserID:          userID,
+		Amount:          fee,
+		TransactionID:   transactionID,
+		FeeFor:          feeFor,
+		Sequence:        sequence,
+		AccountSequence: accountSequence,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+		logger.WithError(err).Error("Fee - Record fee charged event failed")
+		return err
+	}
+
+	before, after := auditBalances(balance.Add(fee), fee.Neg())
+	err = recordAudit(ctx, sqlTx{tx}, models.AuditEntry{Action: models.AuditFee, UserID: userID, TransactionID: transactionID, Before: before, After: after})
+	if err != nil {
+		logger.WithError(err).Error("Fee - Record audit entry failed")
+		return err
+	}
+	return nil
+}
+
+func scanFee(row rowScanner) (*models.Fee, error) {
+	var fee models.Fee
+	err := row.Scan(
+		&fee.ID,
+		&fee.Operation,
+		&fee.Currency,
+		&fee.MinAmount,
+		&fee.FlatFee,
+		&fee.PercentFee,
+		&fee.UpdatedBy,
+		&fee.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &fee, nil
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// expectFee expects chargeFee to move fee from userID to the fee account for
+// the transaction feeFor
+func expectFee(mock sqlmock.Sqlmock, userID string, fee decimal.Decimal, feeFor string) {
+	mock.ExpectQuery(`UPDATE wallets SET balance = balance - \$1`).WithArgs(fee, userID).WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("50"))
+	mock.ExpectExec(`INSERT INTO wallets \(user_id, balance, label\)`).WithArgs(models.SystemAccountFees, fee, models.SystemAccountLabel).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(userID, models.SystemAccountFees, fee, models.TransactionTypeFee, sqlmock.AnyArg(), nil, nil, feeFor).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("99"))
+	mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs(userID, "99").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(8))
+	mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs(models.SystemAccountFees, "99").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(40))
+	mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeFeeCharged, userID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(mock, 1)
+}
+
+func TestFeeRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewFeeRepository(mockDB, logrus.New())
+	now := time.Now()
+	fee := func() *models.Fee {
+		return &models.Fee{
+			ID:         "3",
+			Operation:  models.FeeOperationWithdrawal,
+			Currency:   "EUR",
+			MinAmount:  decimal.NewFromInt(1000),
+			FlatFee:    decimal.NewFromInt(1),
+			PercentFee: decimal.RequireFromString("0.5"),
+			UpdatedBy:  "admin1",
+		}
+	}
+
+	t.Run("ListFees", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text, operation, currency, min_amount, flat_fee, percent_fee, updated_by, updated_at\s+FROM fees`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "operation", "currency", "min_amount", "flat_fee", "percent_fee", "updated_by", "updated_at"}).
+				AddRow("1", "transfer", "", "0", "0.1", "0", "admin1", now))
+
+		fees, err := repo.ListFees(ctx)
+		require.NoError(t, err)
+		require.Len(t, fees, 1)
+		require.True(t, fees[0].FlatFee.Equal(decimal.RequireFromString("0.1")))
+		require.Equal(t, "", fees[0].Currency)
+	})
+
+	t.Run("CreateFee", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			f := fee()
+			mock.ExpectQuery(`INSERT INTO fees`).WithArgs(f.Operation, f.Currency, f.MinAmount, f.FlatFee, f.PercentFee, f.UpdatedBy).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}).AddRow("7", now))
+
+			require.NoError(t, repo.CreateFee(ctx, f))
+			require.Equal(t, "7", f.ID)
+		})
+
+		t.Run("tier exists", func(t *testing.T) {
+			f := fee()
+			mock.ExpectQuery(`INSERT INTO fees`).WithArgs(f.Operation, f.Currency, f.MinAmount, f.FlatFee, f.PercentFee, f.UpdatedBy).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}))
+
+			require.ErrorIs(t, repo.CreateFee(ctx, f), ErrFeeExists)
+		})
+	})
+
+	t.Run("UpdateFee", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			f := fee()
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(f.Operation, f.Currency, f.MinAmount, "3").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(`UPDATE fees`).WithArgs(f.Operation, f.Currency, f.MinAmount, f.FlatFee, f.PercentFee, f.UpdatedBy, "3").
+				WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+
+			require.NoError(t, repo.UpdateFee(ctx, f))
+			require.Equal(t, now, f.UpdatedAt)
+		})
+
+		t.Run("another tier has the same minimum", func(t *testing.T) {
+			f := fee()
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(f.Operation, f.Currency, f.MinAmount, "3").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+			require.ErrorIs(t, repo.UpdateFee(ctx, f), ErrFeeExists)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			f := fee()
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(f.Operation, f.Currency, f.MinAmount, "3").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(`UPDATE fees`).WithArgs(f.Operation, f.Currency, f.MinAmount, f.FlatFee, f.PercentFee, f.UpdatedBy, "3").
+				WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+			require.ErrorIs(t, repo.UpdateFee(ctx, f), ErrFeeNotFound)
+		})
+	})
+
+	t.Run("DeleteFee not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM fees`).WithArgs("9").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.ErrorIs(t, repo.DeleteFee(ctx, "9"), ErrFeeNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WithdrawWithFee", func(t *testing.T) {
+		t.Run("debits the amount and charges the fee", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, overdraft_limit FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(101.0, 0.0, "active", 0.0))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "withdrawal", sqlmock.AnyArg(), nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("12"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "12").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(7))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletDebited, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			expectFee(mock, "user1", decimal.NewFromInt(1), "12")
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.WithdrawWithFee(ctx, "user1", decimal.NewFromInt(100), decimal.NewFromInt(1), nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("balance does not cover the fee", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, overdraft_limit FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(100.5, 0.0, "active", 0.0))
+			mock.ExpectRollback()
+
+			err := repo.WithdrawWithFee(ctx, "user1", decimal.NewFromInt(100), decimal.NewFromInt(1), nil)
+			require.ErrorIs(t, err, ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("TransferWithFee", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+			AddRow("user1", 60.0, 10.0, "active", "", 0.0).
+			AddRow("user2", 0.0, 0.0, "active", "", 0.0))
+		mock.ExpectRollback()
+
+		// 50 are available: the transfer fits, the transfer and its fee do not
+		err := repo.TransferWithFee(ctx, "user1", "user2", decimal.NewFromInt(50), decimal.RequireFromString("0.25"), nil, nil)
+		require.ErrorIs(t, err, ErrInsufficientBalance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
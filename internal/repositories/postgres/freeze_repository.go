@@ -0,0 +1,350 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+type FreezeRepository interface {
+	CountWallets(ctx context.Context, criteria models.FreezeCriteria) (int, error)
+	CreateJob(ctx context.Context, job *models.FreezeJob) error
+	GetJob(ctx context.Context, jobID string) (*models.FreezeJob, error)
+	SnapshotCohort(ctx context.Context, job *models.FreezeJob) (int, error)
+	ApplyNext(ctx context.Context, job *models.FreezeJob, batchSize int) (int, error)
+	FinishJob(ctx context.Context, jobID, status string, errMsg *string) error
+}
+
+var (
+	ErrFreezeJobNotFound = errors.New("freeze job not found")
+	ErrEmptyCriteria     = errors.New("at least one criterion is required")
+)
+
+type PostgresFreezeRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewFreezeRepository(db *sql.DB, logger *logrus.Logger) *PostgresFreezeRepository {
+	return &PostgresFreezeRepository{db: db, logger: logger}
+}
+
+// CountWallets returns the number of active wallets matching the criteria
+func (r *PostgresFreezeRepository) CountWallets(ctx context.Context, criteria models.FreezeCriteria) (int, error) {
+	filter, args, err := criteriaFilter(criteria, 1)
+	if err != nil {
+		r.logger.WithContext(ctx).Warn("CountWallets - criteria cannot be empty")
+		return 0, err
+	}
+
+	var count int
+	err = r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM wallets WHERE status = 'active' AND `+filter,
+		args...,
+	).Scan(&count)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("CountWallets - Count wallets failed")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CreateJob persists a pending job, filling in the generated ID and creation time
+func (r *PostgresFreezeRepository) CreateJob(ctx context.Context, job *models.FreezeJob) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"action": job.Action,
+	})
+
+	criteria, err := json.Marshal(job.Criteria)
+	if err != nil {
+		logger.WithError(err).Error("CreateJob - Marshal criteria failed")
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO freeze_jobs (action, criteria, parent_job_id, reason, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		job.Action, criteria, job.ParentJobID, job.Reason, models.FreezeJobPending,
+	).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreateJob - Create job record failed")
+		return err
+	}
+
+	job.Status = models.FreezeJobPending
+	return nil
+}
+
+// GetJob returns the job including its progress
+func (r *PostgresFreezeRepository) GetJob(ctx context.Context, jobID string) (*models.FreezeJob, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"jobID": jobID,
+	})
+
+	var job models.FreezeJob
+	var criteria []byte
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, action, criteria, parent_job_id, reason, status, total, processed, error, created_at, completed_at
+		FROM freeze_jobs
+		WHERE id = $1`,
+		jobID,
+	).Scan(
+		&job.ID,
+		&job.Action,
+		&criteria,
+		&job.ParentJobID,
+		&job.Reason,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.Error,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Warn("GetJob - Cannot find job in the database")
+		return nil, ErrFreezeJobNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("GetJob - Query job failed")
+		return nil, err
+	}
+
+	if err := json.Unmarshal(criteria, &job.Criteria); err != nil {
+		logger.WithError(err).Error("GetJob - Unmarshal criteria failed")
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// SnapshotCohort records the wallets the job applies to and marks the job as
+// running. A freeze job targets the active wallets matching its criteria, an
+// unfreeze job the wallets of the parent cohort that are still frozen.
+func (r *PostgresFreezeRepository) SnapshotCohort(ctx context.Context, job *models.FreezeJob) (int, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"jobID":  job.ID,
+		"action": job.Action,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SnapshotCohort - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var result sql.Result
+	switch job.Action {
+	case models.FreezeActionFreeze:
+		filter, args, err := criteriaFilter(job.Criteria, 2)
+		if err != nil {
+			return 0, err
+		}
+		result, err = tx.ExecContext(ctx,
+			`INSERT INTO freeze_job_wallets (job_id, user_id)
+			SELECT $1, user_id FROM wallets WHERE status = 'active' AND `+filter,
+			append([]interface{}{job.ID}, args...)...,
+		)
+		if err != nil {
+			logger.WithError(err).Error("SnapshotCohort - Snapshot cohort failed")
+			return 0, err
+		}
+	case models.FreezeActionUnfreeze:
+		result, err = tx.ExecContext(ctx,
+			`INSERT INTO freeze_job_wallets (job_id, user_id)
+			SELECT $1, c.user_id
+			FROM freeze_job_wallets c
+			JOIN wallets w ON w.user_id = c.user_id
+			WHERE c.job_id = $2 AND c.processed AND w.status = 'frozen'`,
+			job.ID, job.ParentJobID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("SnapshotCohort - Snapshot cohort failed")
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("unknown freeze job action %q", job.Action)
+	}
+
+	total, err := result.RowsAffected()
+	if err != nil {
+		logger.WithError(err).Error("SnapshotCohort - Read cohort size failed")
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE freeze_jobs SET status = $1, total = $2 WHERE id = $3",
+		models.FreezeJobRunning, total, job.ID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("SnapshotCohort - Update job failed")
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.WithError(err).Error("SnapshotCohort - Commit DB transaction failed")
+		return 0, err
+	}
+
+	job.Status = models.FreezeJobRunning
+	job.Total = int(total)
+	return int(total), nil
+}
+
+// ApplyNext applies the job action to the next batchSize unprocessed wallets
+// of the cohort and advances the job progress. It returns the number of
+// wallets processed, zero once the cohort is exhausted.
+func (r *PostgresFreezeRepository) ApplyNext(ctx context.Context, job *models.FreezeJob, batchSize int) (int, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"jobID":  job.ID,
+		"action": job.Action,
+	})
+
+	from, to, eventType := models.WalletStatusActive, models.WalletStatusFrozen, events.TypeWalletFrozen
+	if job.Action == models.FreezeActionUnfreeze {
+		from, to, eventType = models.WalletStatusFrozen, models.WalletStatusActive, events.TypeWalletUnfrozen
+	}
+	op, err := operationJSON(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Every wallet whose status changes gets a lifecycle event in the outbox,
+	// shaped like events.WalletLifecycleChanged
+	result, err := tx.ExecContext(ctx,
+		`WITH batch AS (
+			UPDATE freeze_job_wallets SET processed = TRUE
+			WHERE job_id = $1 AND user_id IN (
+				SELECT user_id FROM freeze_job_wallets
+				WHERE job_id = $1 AND NOT processed
+				ORDER BY user_id
+				LIMIT $2
+			)
+			RETURNING user_id
+		), changed AS (
+			UPDATE wallets SET status = $3
+			FROM batch
+			WHERE wallets.user_id = batch.user_id AND wallets.status = $4
+			RETURNING wallets.user_id
+		)
+		INSERT INTO outbox_events (event_id, type, aggregate_id, operation, payload)
+		SELECT 'evt_' || replace(gen_random_uuid()::text, '-', ''), $5, user_id, $7::jsonb,
+			jsonb_build_object(
+				'user_id', user_id,
+				'previous', jsonb_build_object('status', $4::text),
+				'current', jsonb_build_object('status', $3::text),
+				'reason', $6::text
+			)
+		FROM changed`,
+		job.ID, batchSize, to, from, eventType, job.Reason, op,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Update wallet statuses failed")
+		return 0, err
+	}
+
+	var processed int
+	err = tx.QueryRowContext(ctx,
+		`UPDATE freeze_jobs
+		SET processed = (SELECT COUNT(*) FROM freeze_job_wallets WHERE job_id = $1 AND processed)
+		WHERE id = $1
+		RETURNING processed`,
+		job.ID,
+	).Scan(&processed)
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Update job progress failed")
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.WithError(err).Error("ApplyNext - Commit DB transaction failed")
+		return 0, err
+	}
+
+	changed, _ := result.RowsAffected()
+	logger.WithField("changed", changed).Debug("ApplyNext - Batch applied")
+
+	advanced := processed - job.Processed
+	job.Processed = processed
+	return advanced, nil
+}
+
+// FinishJob sets the final status of the job
+func (r *PostgresFreezeRepository) FinishJob(ctx context.Context, jobID, status string, errMsg *string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE freeze_jobs SET status = $1, error = $2, completed_at = NOW() WHERE id = $3",
+		status, errMsg, jobID,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("jobID", jobID).Error("FinishJob - Update job failed")
+		return err
+	}
+	return nil
+}
+
+// criteriaFilter builds the SQL condition on wallets for the criteria, with
+// positional arguments numbered from argIndex
+func criteriaFilter(criteria models.FreezeCriteria, argIndex int) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	next := func(value interface{}) string {
+		args = append(args, value)
+		placeholder := fmt.Sprintf("$%d", argIndex)
+		argIndex++
+		return placeholder
+	}
+
+	if criteria.Label != nil {
+		conditions = append(conditions, "label = "+next(*criteria.Label))
+	}
+	if criteria.Country != nil {
+		conditions = append(conditions, "country = "+next(*criteria.Country))
+	}
+	if criteria.CounterpartyID != nil {
+		counterparty := next(*criteria.CounterpartyID)
+		minExposure := decimal.Zero
+		if criteria.MinExposure != nil {
+			minExposure = *criteria.MinExposure
+		}
+		windowDays := criteria.ExposureWindowDays
+		if windowDays <= 0 {
+			windowDays = 7
+		}
+		conditions = append(conditions, fmt.Sprintf(`user_id IN (
+			SELECT CASE WHEN from_user_id = %[1]s THEN to_user_id ELSE from_user_id END
+			FROM transactions
+			WHERE type = 'transfer'
+				AND (from_user_id = %[1]s OR to_user_id = %[1]s)
+				AND created_at >= NOW() - make_interval(days => %[2]s)
+			GROUP BY 1
+			HAVING SUM(amount) >= %[3]s
+		)`, counterparty, next(windowDays), next(minExposure)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, ErrEmptyCriteria
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
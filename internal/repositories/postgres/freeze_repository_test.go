@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+func TestFreezeRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewFreezeRepository(mockDB, logrus.New())
+	label, country := "merchant", "SG"
+
+	t.Run("CountWallets", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM wallets WHERE status = 'active' AND label = \$1 AND country = \$2`).
+				WithArgs(label, country).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+			count, err := repo.CountWallets(ctx, models.FreezeCriteria{Label: &label, Country: &country})
+			require.NoError(t, err)
+			require.Equal(t, 3, count)
+		})
+
+		t.Run("empty criteria", func(t *testing.T) {
+			_, err := repo.CountWallets(ctx, models.FreezeCriteria{})
+			require.ErrorIs(t, err, ErrEmptyCriteria)
+		})
+	})
+
+	t.Run("CreateJob", func(t *testing.T) {
+		now := time.Now()
+		job := &models.FreezeJob{Action: models.FreezeActionFreeze, Criteria: models.FreezeCriteria{Label: &label}, Reason: "incident"}
+		mock.ExpectQuery(`INSERT INTO freeze_jobs`).
+			WithArgs(models.FreezeActionFreeze, []byte(`{"label":"merchant"}`), nil, "incident", models.FreezeJobPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("5", now))
+
+		require.NoError(t, repo.CreateJob(ctx, job))
+		require.Equal(t, "5", job.ID)
+		require.Equal(t, models.FreezeJobPending, job.Status)
+	})
+
+	t.Run("GetJob", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			now := time.Now()
+			mock.ExpectQuery(`SELECT id, action, criteria`).WithArgs("5").WillReturnRows(sqlmock.NewRows(
+				[]string{"id", "action", "criteria", "parent_job_id", "reason", "status", "total", "processed", "error", "created_at", "completed_at"},
+			).AddRow("5", models.FreezeActionFreeze, []byte(`{"label":"merchant"}`), nil, "incident", models.FreezeJobRunning, 10, 4, nil, now, nil))
+
+			job, err := repo.GetJob(ctx, "5")
+			require.NoError(t, err)
+			require.Equal(t, label, *job.Criteria.Label)
+			require.Equal(t, 4, job.Processed)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT id, action, criteria`).WithArgs("6").WillReturnError(sql.ErrNoRows)
+			_, err := repo.GetJob(ctx, "6")
+			require.ErrorIs(t, err, ErrFreezeJobNotFound)
+		})
+	})
+
+	t.Run("SnapshotCohort", func(t *testing.T) {
+		job := &models.FreezeJob{ID: "5", Action: models.FreezeActionFreeze, Criteria: models.FreezeCriteria{Country: &country}}
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO freeze_job_wallets`).WithArgs("5", country).WillReturnResult(sqlmock.NewResult(0, 8))
+		mock.ExpectExec(`UPDATE freeze_jobs SET status`).WithArgs(models.FreezeJobRunning, int64(8), "5").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		total, err := repo.SnapshotCohort(ctx, job)
+		require.NoError(t, err)
+		require.Equal(t, 8, total)
+		require.Equal(t, models.FreezeJobRunning, job.Status)
+	})
+
+	t.Run("ApplyNext", func(t *testing.T) {
+		job := &models.FreezeJob{ID: "5", Action: models.FreezeActionUnfreeze, Processed: 2}
+		ctx := operation.With(ctx, operation.Operation{Channel: operation.ChannelJob})
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE wallets SET status(.|\n)*INSERT INTO outbox_events`).WithArgs("5", 2, models.WalletStatusActive, models.WalletStatusFrozen, events.TypeWalletUnfrozen, "", []byte(`{"channel":"job"}`)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`UPDATE freeze_jobs`).WithArgs("5").WillReturnRows(sqlmock.NewRows([]string{"processed"}).AddRow(4))
+		mock.ExpectCommit()
+
+		processed, err := repo.ApplyNext(ctx, job, 2)
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+		require.Equal(t, 4, job.Processed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCriteriaFilter(t *testing.T) {
+	counterparty, minExposure := "user9", decimal.NewFromInt(50000)
+
+	filter, args, err := criteriaFilter(models.FreezeCriteria{CounterpartyID: &counterparty, MinExposure: &minExposure}, 2)
+	require.NoError(t, err)
+	require.Contains(t, filter, "from_user_id = $2 OR to_user_id = $2")
+	require.Contains(t, filter, "make_interval(days => $3)")
+	require.Contains(t, filter, "HAVING SUM(amount) >= $4")
+	require.Equal(t, []interface{}{counterparty, 7, minExposure}, args)
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// historyIndexLockID keys the advisory lock that keeps instances from
+// building the same history indexes at once
+const historyIndexLockID = 7210332
+
+// recentIndexPrefix names the partial indexes of recent transactions. The
+// cutoff date they cover is appended to the prefix of each side.
+const recentIndexPrefix = "idx_transactions_recent_"
+
+// recentIndexes are the partial indexes built for each cutoff, mirroring the
+// full history indexes of each side of a transaction
+var recentIndexes = []struct{ side, columns, condition string }{
+	{"sender", "from_user_id, created_at DESC, id DESC", ""},
+	{"receiver", "to_user_id, created_at DESC, id DESC", " AND to_user_id IS NOT NULL"},
+}
+
+// HistoryIndexRepository maintains the partial indexes of the recent
+// transactions, which histories read by default. A partial index predicate
+// must be constant, so the indexes are rebuilt as their cutoff moves.
+type HistoryIndexRepository interface {
+	// RotateRecentIndexes builds the indexes of the transactions created
+	// since the UTC day of cutoff unless they exist, then drops those of
+	// other cutoffs. It returns false without changes while another instance
+	// rotates them.
+	RotateRecentIndexes(ctx context.Context, cutoff time.Time) (bool, error)
+}
+
+type PostgresHistoryIndexRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewHistoryIndexRepository(db *sql.DB, logger *logrus.Logger) *PostgresHistoryIndexRepository {
+	return &PostgresHistoryIndexRepository{db: db, logger: logger}
+}
+
+func (r *PostgresHistoryIndexRepository) RotateRecentIndexes(ctx context.Context, cutoff time.Time) (bool, error) {
+	cutoff = cutoff.UTC().Truncate(24 * time.Hour)
+	logger := r.logger.WithContext(ctx).WithField("cutoff", cutoff.Format(time.DateOnly))
+
+	// The advisory lock belongs to a session and indexes are built
+	// concurrently outside a transaction, so every statement runs on the
+	// same connection
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		logger.WithError(err).Error("RotateRecentIndexes - Acquire connection failed")
+		return false, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", historyIndexLockID).Scan(&locked); err != nil {
+		logger.WithError(err).Error("RotateRecentIndexes - Acquire history index lock failed")
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", historyIndexLockID)
+
+	existing, err := r.listRecentIndexes(ctx, conn)
+	if err != nil {
+		logger.WithError(err).Error("RotateRecentIndexes - Query recent indexes failed")
+		return false, err
+	}
+
+	wanted := make(map[string]bool, len(recentIndexes))
+	for _, index := range recentIndexes {
+		name := recentIndexPrefix + index.side + "_" + cutoff.Format("20060102")
+		wanted[name] = true
+
+		valid, ok := existing[name]
+		if ok && valid {
+			continue
+		}
+		// An interrupted concurrent build leaves an invalid index behind
+		if ok {
+			if _, err := conn.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+name); err != nil {
+				logger.WithError(err).WithField("index", name).Error("RotateRecentIndexes - Drop invalid index failed")
+				return false, err
+			}
+		}
+
+		_, err := conn.ExecContext(ctx, fmt.Sprintf(
+			"CREATE INDEX CONCURRENTLY %s ON transactions USING btree (%s) WHERE created_at >= '%s'%s",
+			name, index.columns, cutoff.Format(time.RFC3339), index.condition,
+		))
+		if err != nil {
+			logger.WithError(err).WithField("index", name).Error("RotateRecentIndexes - Create index failed")
+			return false, err
+		}
+		logger.WithField("index", name).Info("Recent history index created")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(existing)) {
+		if wanted[name] {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+name); err != nil {
+			logger.WithError(err).WithField("index", name).Error("RotateRecentIndexes - Drop index failed")
+			return false, err
+		}
+		logger.WithField("index", name).Info("Recent history index dropped")
+	}
+	return true, nil
+}
+
+// listRecentIndexes returns whether each partial index of recent transactions is
+// valid, by name
+func (r *PostgresHistoryIndexRepository) listRecentIndexes(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx,
+		`SELECT c.relname, i.indisvalid
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE i.indrelid = 'transactions'::regclass AND starts_with(c.relname, $1)`,
+		recentIndexPrefix,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var valid bool
+		if err := rows.Scan(&name, &valid); err != nil {
+			return nil, err
+		}
+		indexes[name] = valid
+	}
+	return indexes, rows.Err()
+}
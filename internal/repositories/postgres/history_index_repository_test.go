@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryIndexRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewHistoryIndexRepository(mockDB, logrus.New())
+	cutoff := time.Date(2024, time.May, 1, 15, 0, 0, 0, time.UTC)
+
+	expectLock := func(locked bool) {
+		mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(historyIndexLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(locked))
+	}
+	expectUnlock := func() {
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(historyIndexLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("RotateRecentIndexes", func(t *testing.T) {
+		expectLock(true)
+		mock.ExpectQuery(`SELECT c.relname, i.indisvalid`).WithArgs(recentIndexPrefix).
+			WillReturnRows(sqlmock.NewRows([]string{"relname", "indisvalid"}).
+				AddRow("idx_transactions_recent_sender_20240424", true).
+				AddRow("idx_transactions_recent_receiver_20240424", true).
+				AddRow("idx_transactions_recent_sender_20240501", true).
+				AddRow("idx_transactions_recent_receiver_20240501", false))
+		// The invalid leftover is built again, the up to date index is kept
+		mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS idx_transactions_recent_receiver_20240501$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE INDEX CONCURRENTLY idx_transactions_recent_receiver_20240501 ON transactions USING btree \(to_user_id, created_at DESC, id DESC\) WHERE created_at >= '2024-05-01T00:00:00Z' AND to_user_id IS NOT NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS idx_transactions_recent_receiver_20240424`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS idx_transactions_recent_sender_20240424`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectUnlock()
+
+		rotated, err := repo.RotateRecentIndexes(ctx, cutoff)
+		require.NoError(t, err)
+		require.True(t, rotated)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RotateRecentIndexes builds missing indexes", func(t *testing.T) {
+		expectLock(true)
+		mock.ExpectQuery(`SELECT c.relname, i.indisvalid`).WithArgs(recentIndexPrefix).
+			WillReturnRows(sqlmock.NewRows([]string{"relname", "indisvalid"}))
+		mock.ExpectExec(`CREATE INDEX CONCURRENTLY idx_transactions_recent_sender_20240501 ON transactions USING btree \(from_user_id, created_at DESC, id DESC\) WHERE created_at >= '2024-05-01T00:00:00Z'$`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE INDEX CONCURRENTLY idx_transactions_recent_receiver_20240501`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectUnlock()
+
+		rotated, err := repo.RotateRecentIndexes(ctx, cutoff)
+		require.NoError(t, err)
+		require.True(t, rotated)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RotateRecentIndexes locked by another instance", func(t *testing.T) {
+		expectLock(false)
+
+		rotated, err := repo.RotateRecentIndexes(ctx, cutoff)
+		require.NoError(t, err)
+		require.False(t, rotated)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,388 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// HoldRepository stores pending transfers and the funds they hold
+type HoldRepository interface {
+	CreateHold(ctx context.Context, hold *models.Hold) error
+	CaptureHold(ctx context.Context, holdID string) (*models.Hold, error)
+	ReleaseHold(ctx context.Context, holdID string) (*models.Hold, error)
+	GetHold(ctx context.Context, holdID string) (*models.Hold, error)
+	GetHeldBalance(ctx context.Context, userID string) (decimal.Decimal, error)
+}
+
+var (
+	ErrHoldNotFound   = errors.New("pending transfer not found")
+	ErrHoldNotPending = errors.New("pending transfer was already captured or cancelled")
+)
+
+type PostgresHoldRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewHoldRepository(db *sql.DB, logger *logrus.Logger) *PostgresHoldRepository {
+	return &PostgresHoldRepository{db: db, logger: logger}
+}
+
+// CreateHold places a hold of hold.Amount on the sender's available balance
+// and records the pending transfer, filling in its ID and timestamps
+func (r *PostgresHoldRepository) CreateHold(ctx context.Context, hold *models.Hold) error {
+	if hold.FromUserID == "" || hold.ToUserID == "" || hold.FromUserID == hold.ToUserID {
+		r.logger.WithContext(ctx).Warn("CreateHold - fromUserID and toUserID must be distinct non-empty strings")
+		return ErrInvalidUserID
+	}
+
+	if !hold.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("CreateHold - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"fromUserID": hold.FromUserID,
+		"toUserID":   hold.ToUserID,
+		"amount":     hold.Amount,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreateHold - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance, held decimal.Decimal
+	var status, currency string
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status, COALESCE(currency, '') FROM wallets WHERE user_id = $1 FOR UPDATE",
+		hold.FromUserID,
+	).Scan(&balance, &held, &status, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CreateHold - Cannot find sender in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("CreateHold - Query sender balance failed")
+		return err
+	}
+
+	if err := statusError(status); err != nil {
+		logger.WithField("status", status).Warn("CreateHold - Sender wallet is not active")
+		return err
+	}
+
+	if balance.Sub(held).LessThan(hold.Amount) {
+		logger.Warn("CreateHold - Sender available balance is too low")
+		return ErrInsufficientBalance
+	}
+
+	var receiverStatus, receiverCurrency string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status, COALESCE(currency, '') FROM wallets WHERE user_id = $1",
+		hold.ToUserID,
+	).Scan(&receiverStatus, &receiverCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CreateHold - Cannot find receiver in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("CreateHold - Query receiver status failed")
+		return err
+	}
+	if receiverStatus == models.WalletStatusClosed {
+		logger.Warn("CreateHold - Receiver wallet is closed")
+		return ErrWalletClosed
+	}
+	// Pending transfers are captured one to one; only direct transfers convert
+	if err := checkCurrencies(currency, receiverCurrency, nil); err != nil {
+		logger.Warn("CreateHold - Wallet currencies do not match")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET held = held + $1 WHERE user_id = $2",
+		hold.Amount, hold.FromUserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CreateHold - Update held balance failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO holds (from_user_id, to_user_id, amount, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id::text, created_at, updated_at`,
+		hold.FromUserID, hold.ToUserID, hold.Amount, models.HoldPending,
+	).Scan(&hold.ID, &hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreateHold - Create hold record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreateHold - Commit DB transaction failed")
+		return err
+	}
+
+	hold.Status = models.HoldPending
+	logger.WithField("holdID", hold.ID).Info("Hold placed")
+	return nil
+}
+
+// CaptureHold completes a pending transfer: the held amount leaves the
+// sender's balance and is credited to the receiver
+func (r *PostgresHoldRepository) CaptureHold(ctx context.Context, holdID string) (*models.Hold, error) {
+	logger := r.logger.WithContext(ctx).WithField("holdID", holdID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hold, err := lockPendingHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		hold.FromUserID,
+	).Scan(&status)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Query sender status failed")
+		return nil, err
+	}
+	if err := statusError(status); err != nil {
+		logger.WithField("status", status).Warn("CaptureHold - Sender wallet is not active")
+		return nil, err
+	}
+
+	var senderBalance, receiverBalance decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		"UPDATE wallets SET balance = balance - $1, held = held - $1 WHERE user_id = $2 RETURNING balance",
+		hold.Amount, hold.FromUserID,
+	).Scan(&senderBalance)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Update sender balance failed")
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE wallets SET balance = balance + $1 WHERE user_id = $2 AND status = 'active' RETURNING balance",
+		hold.Amount, hold.ToUserID,
+	).Scan(&receiverBalance)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CaptureHold - Receiver wallet is not active")
+		return nil, ErrWalletFrozen
+	}
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Update receiver balance failed")
+		return nil, err
+	}
+
+	var transactionID string
+	actor, channel := provenance(ctx)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions 
+		(from_user_id, to_user_id, amount, type, created_at, actor, channel) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		hold.FromUserID, hold.ToUserID, hold.Amount, "transfer", time.Now(), actor, channel,
+	).Scan(&transactionID)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Create transaction record failed")
+		return nil, err
+	}
+
+	fromSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, hold.FromUserID)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Assign sender sequence number failed")
+		return nil, err
+	}
+	toSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, hold.ToUserID)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Assign receiver sequence number failed")
+		return nil, err
+	}
+
+	event := events.New(events.TypeTransferCompleted, events.TransferCompleted{
+		FromUserID:    hold.FromUserID,
+		ToUserID:      hold.ToUserID,
+		Amount:        hold.Amount,
+		TransactionID: transactionID,
+		FromSequence:  fromSequence,
+		ToSequence:    toSequence,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, hold.FromUserID); err != nil {
+		logger.WithError(err).Error("CaptureHold - Record transfer completed event failed")
+		return nil, err
+	}
+
+	senderBefore, senderAfter := auditBalances(senderBalance.Add(hold.Amount), hold.Amount.Neg())
+	receiverBefore, receiverAfter := auditBalances(receiverBalance.Sub(hold.Amount), hold.Amount)
+	err = recordAudit(ctx, sqlTx{tx},
+		models.AuditEntry{Action: models.AuditTransfer, UserID: hold.FromUserID, TransactionID: transactionID, Before: senderBefore, After: senderAfter},
+		models.AuditEntry{Action: models.AuditTransfer, UserID: hold.ToUserID, TransactionID: transactionID, Before: receiverBefore, After: receiverAfter},
+	)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Record audit entries failed")
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE holds SET status = $1, transaction_id = $2, updated_at = NOW()
+		WHERE id::text = $3
+		RETURNING updated_at`,
+		models.HoldCaptured, transactionID, holdID,
+	).Scan(&hold.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CaptureHold - Update hold failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CaptureHold - Commit DB transaction failed")
+		return nil, err
+	}
+
+	hold.Status = models.HoldCaptured
+	hold.TransactionID = &transactionID
+	logger.Info("Hold captured")
+	return hold, nil
+}
+
+// ReleaseHold cancels a pending transfer and returns the held amount to the
+// sender's available balance
+func (r *PostgresHoldRepository) ReleaseHold(ctx context.Context, holdID string) (*models.Hold, error) {
+	logger := r.logger.WithContext(ctx).WithField("holdID", holdID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ReleaseHold - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hold, err := lockPendingHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET held = held - $1 WHERE user_id = $2",
+		hold.Amount, hold.FromUserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ReleaseHold - Update held balance failed")
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE holds SET status = $1, updated_at = NOW()
+		WHERE id::text = $2
+		RETURNING updated_at`,
+		models.HoldReleased, holdID,
+	).Scan(&hold.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("ReleaseHold - Update hold failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("ReleaseHold - Commit DB transaction failed")
+		return nil, err
+	}
+
+	hold.Status = models.HoldReleased
+	logger.Info("Hold released")
+	return hold, nil
+}
+
+// GetHold returns a pending transfer by ID
+func (r *PostgresHoldRepository) GetHold(ctx context.Context, holdID string) (*models.Hold, error) {
+	hold, err := scanHold(r.db.QueryRowContext(ctx,
+		`SELECT id::text, from_user_id, to_user_id, amount, status, transaction_id::text, created_at, updated_at
+		FROM holds
+		WHERE id::text = $1`,
+		holdID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("holdID", holdID).Error("GetHold - Query hold failed")
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetHeldBalance returns the amount held on the user's wallet by pending transfers
+func (r *PostgresHoldRepository) GetHeldBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	var held decimal.Decimal
+	err := r.db.QueryRowContext(ctx,
+		"SELECT held FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&held)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetHeldBalance - Query held balance failed")
+		return decimal.Zero, err
+	}
+	return held, nil
+}
+
+// lockPendingHold locks a hold row for the rest of tx, failing unless it is
+// still pending
+func lockPendingHold(ctx context.Context, tx *sql.Tx, holdID string) (*models.Hold, error) {
+	hold, err := scanHold(tx.QueryRowContext(ctx,
+		`SELECT id::text, from_user_id, to_user_id, amount, status, transaction_id::text, created_at, updated_at
+		FROM holds
+		WHERE id::text = $1
+		FOR UPDATE`,
+		holdID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != models.HoldPending {
+		return nil, ErrHoldNotPending
+	}
+	return hold, nil
+}
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	var hold models.Hold
+	err := row.Scan(
+		&hold.ID,
+		&hold.FromUserID,
+		&hold.ToUserID,
+		&hold.Amount,
+		&hold.Status,
+		&hold.TransactionID,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestHoldRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewHoldRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"id", "from_user_id", "to_user_id", "amount", "status", "transaction_id", "created_at", "updated_at"}
+
+	t.Run("CreateHold", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(150.0, 20.0, "active", ""))
+			mock.ExpectQuery(`SELECT status, COALESCE\(currency, ''\) FROM wallets`).WithArgs("user2").WillReturnRows(sqlmock.NewRows([]string{"status", "currency"}).AddRow("active", ""))
+			mock.ExpectExec(`UPDATE wallets SET held = held \+ \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO holds`).WithArgs("user1", "user2", decimal.NewFromInt(100), models.HoldPending).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("5", now, now))
+			mock.ExpectCommit()
+
+			hold := &models.Hold{FromUserID: "user1", ToUserID: "user2", Amount: decimal.NewFromInt(100)}
+			require.NoError(t, repo.CreateHold(ctx, hold))
+			require.Equal(t, "5", hold.ID)
+			require.Equal(t, models.HoldPending, hold.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("held funds are not available", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(150.0, 100.0, "active", ""))
+			mock.ExpectRollback()
+
+			hold := &models.Hold{FromUserID: "user1", ToUserID: "user2", Amount: decimal.NewFromInt(100)}
+			require.ErrorIs(t, repo.CreateHold(ctx, hold), ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("wallets of different currencies", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(150.0, 0.0, "active", "EUR"))
+			mock.ExpectQuery(`SELECT status, COALESCE`).WithArgs("user2").WillReturnRows(sqlmock.NewRows([]string{"status", "currency"}).AddRow("active", "USD"))
+			mock.ExpectRollback()
+
+			hold := &models.Hold{FromUserID: "user1", ToUserID: "user2", Amount: decimal.NewFromInt(100)}
+			require.ErrorIs(t, repo.CreateHold(ctx, hold), ErrCurrencyMismatch)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("CaptureHold", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id::text, from_user_id`).WithArgs("5").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("5", "user1", "user2", "100", models.HoldPending, nil, now, now))
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`UPDATE wallets SET balance = balance - \$1, held = held - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("400"))
+		mock.ExpectQuery(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(decimal.NewFromInt(100), "user2").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("100"))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", "user2", decimal.NewFromInt(100), "transfer", sqlmock.AnyArg(), nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("9"))
+		mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "9").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+		mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user2", "9").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(7))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeTransferCompleted, "user1", sqlmock.AnyArg(), []byte(`{"from_user_id":"user1","to_user_id":"user2","amount":"100","transaction_id":"9","from_sequence":4,"to_sequence":7}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectAudit(mock, 2)
+		mock.ExpectQuery(`UPDATE holds SET status`).WithArgs(models.HoldCaptured, "9", "5").WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		hold, err := repo.CaptureHold(ctx, "5")
+		require.NoError(t, err)
+		require.Equal(t, models.HoldCaptured, hold.Status)
+		require.Equal(t, "9", *hold.TransactionID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReleaseHold", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id::text, from_user_id`).WithArgs("5").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("5", "user1", "user2", "100", models.HoldPending, nil, now, now))
+			mock.ExpectExec(`UPDATE wallets SET held = held - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE holds SET status`).WithArgs(models.HoldReleased, "5").WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+			mock.ExpectCommit()
+
+			hold, err := repo.ReleaseHold(ctx, "5")
+			require.NoError(t, err)
+			require.Equal(t, models.HoldReleased, hold.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already captured", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id::text, from_user_id`).WithArgs("5").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("5", "user1", "user2", "100", models.HoldCaptured, "9", now, now))
+			mock.ExpectRollback()
+
+			_, err := repo.ReleaseHold(ctx, "5")
+			require.ErrorIs(t, err, ErrHoldNotPending)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("GetHold not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text, from_user_id`).WithArgs("6").WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetHold(ctx, "6")
+		require.ErrorIs(t, err, ErrHoldNotFound)
+	})
+}
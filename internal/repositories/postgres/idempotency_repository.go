@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, userID, key string) error
+	Release(ctx context.Context, userID, key string) error
+}
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+)
+
+type PostgresIdempotencyRepository struct {
+	db     *sql.DB
+	ttl    time.Duration
+	logger *logrus.Logger
+}
+
+func NewIdempotencyRepository(db *sql.DB, ttl time.Duration, logger *logrus.Logger) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db, ttl: ttl, logger: logger}
+}
+
+// Reserve claims the key for the request. It returns true when the key was
+// newly reserved; otherwise it returns the existing, unexpired record.
+func (r *PostgresIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	if record.UserID == "" || record.Key == "" {
+		r.logger.WithContext(ctx).Warn("Reserve - userID and key cannot be empty strings")
+		return nil, false, ErrInvalidIdempotencyKey
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":         record.UserID,
+		"idempotencyKey": record.Key,
+		"operation":      record.Operation,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Reserve - Begin DB transaction failed")
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Expired keys may be reused
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND created_at < $3",
+		record.UserID, record.Key, time.Now().Add(-r.ttl),
+	)
+	if err != nil {
+		logger.WithError(err).Error("Reserve - Delete expired key failed")
+		return nil, false, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, operation, request_hash, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO NOTHING`,
+		record.UserID, record.Key, record.Operation, record.RequestHash, models.IdempotencyInProgress,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Reserve - Insert key failed")
+		return nil, false, err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 1 {
+		if err := tx.Commit(); err != nil {
+			logger.WithError(err).Error("Reserve - Commit DB transaction failed")
+			return nil, false, err
+		}
+		record.Status = models.IdempotencyInProgress
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyRecord
+	err = tx.QueryRowContext(ctx,
+		`SELECT user_id, key, operation, request_hash, status, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`,
+		record.UserID, record.Key,
+	).Scan(
+		&existing.UserID,
+		&existing.Key,
+		&existing.Operation,
+		&existing.RequestHash,
+		&existing.Status,
+		&existing.CreatedAt,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Reserve - Query existing key failed")
+		return nil, false, err
+	}
+
+	return &existing, false, nil
+}
+
+// Complete marks the request of a reserved key as successfully applied
+func (r *PostgresIdempotencyRepository) Complete(ctx context.Context, userID, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status = $1 WHERE user_id = $2 AND key = $3",
+		models.IdempotencyCompleted, userID, key,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("idempotencyKey", key).Error("Complete - Update key failed")
+		return err
+	}
+	return nil
+}
+
+// Release frees a reserved key so the request can be retried
+func (r *PostgresIdempotencyRepository) Release(ctx context.Context, userID, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status = $3",
+		userID, key, models.IdempotencyInProgress,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("idempotencyKey", key).Error("Release - Delete key failed")
+		return err
+	}
+	return nil
+}
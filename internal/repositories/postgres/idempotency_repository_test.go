@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewIdempotencyRepository(mockDB, 24*time.Hour, logrus.New())
+
+	t.Run("Reserve", func(t *testing.T) {
+		t.Run("new key", func(t *testing.T) {
+			record := &models.IdempotencyRecord{UserID: "user1", Key: "key1", Operation: "deposit", RequestHash: "hash"}
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM idempotency_keys`).WithArgs("user1", "key1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`INSERT INTO idempotency_keys`).WithArgs("user1", "key1", "deposit", "hash", models.IdempotencyInProgress).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			got, reserved, err := repo.Reserve(ctx, record)
+			require.NoError(t, err)
+			require.True(t, reserved)
+			require.Equal(t, models.IdempotencyInProgress, got.Status)
+		})
+
+		t.Run("existing key", func(t *testing.T) {
+			now := time.Now()
+			record := &models.IdempotencyRecord{UserID: "user1", Key: "key1", Operation: "deposit", RequestHash: "hash"}
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM idempotency_keys`).WithArgs("user1", "key1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`INSERT INTO idempotency_keys`).WithArgs("user1", "key1", "deposit", "hash", models.IdempotencyInProgress).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`SELECT user_id, key`).WithArgs("user1", "key1").WillReturnRows(sqlmock.NewRows(
+				[]string{"user_id", "key", "operation", "request_hash", "status", "created_at"},
+			).AddRow("user1", "key1", "deposit", "hash", models.IdempotencyCompleted, now))
+			mock.ExpectRollback()
+
+			got, reserved, err := repo.Reserve(ctx, record)
+			require.NoError(t, err)
+			require.False(t, reserved)
+			require.Equal(t, models.IdempotencyCompleted, got.Status)
+		})
+
+		t.Run("empty key", func(t *testing.T) {
+			_, _, err := repo.Reserve(ctx, &models.IdempotencyRecord{UserID: "user1"})
+			require.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+		})
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE idempotency_keys SET status`).WithArgs(models.IdempotencyCompleted, "user1", "key1").WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repo.Complete(ctx, "user1", "key1"))
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM idempotency_keys`).WithArgs("user1", "key1", models.IdempotencyInProgress).WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repo.Release(ctx, "user1", "key1"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ledgerTx is a transaction moving funds. Deposits, withdrawals and
+// transfers run on a pgx.Tx of the pool, skipping the database/sql layer;
+// the other writes sharing the ledger helpers adapt their *sql.Tx with
+// sqlTx.
+type ledgerTx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// sqlTx runs a ledgerTx on a database/sql transaction
+type sqlTx struct {
+	tx *sql.Tx
+}
+
+func (t sqlTx) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag(strconv.FormatInt(affected, 10)), nil
+}
+
+func (t sqlTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return sqlRows{rows}, nil
+}
+
+func (t sqlTx) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (t sqlTx) Commit(context.Context) error {
+	return t.tx.Commit()
+}
+
+func (t sqlTx) Rollback(context.Context) error {
+	return t.tx.Rollback()
+}
+
+// sqlRows reads *sql.Rows as pgx.Rows. database/sql exposes neither the
+// command tag, the field descriptions, the raw values nor the connection of
+// its rows, so those are empty.
+type sqlRows struct {
+	*sql.Rows
+}
+
+func (r sqlRows) Close() {
+	_ = r.Rows.Close()
+}
+
+func (r sqlRows) CommandTag() pgconn.CommandTag {
+	return pgconn.CommandTag{}
+}
+
+func (r sqlRows) FieldDescriptions() []pgconn.FieldDescription {
+	return nil
+}
+
+func (r sqlRows) Values() ([]any, error) {
+	columns, err := r.Rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	return values, r.Rows.Scan(dest...)
+}
+
+func (r sqlRows) RawValues() [][]byte {
+	return nil
+}
+
+func (r sqlRows) Conn() *pgx.Conn {
+	return nil
+}
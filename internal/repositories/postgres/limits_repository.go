@@ -0,0 +1,456 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// LimitsRepository stores transaction limits and reports how much of them
+// users have used
+type LimitsRepository interface {
+	ListLimits(ctx context.Context, userID string) ([]models.Limit, error)
+	PutLimit(ctx context.Context, limit *models.Limit) error
+	DeleteLimit(ctx context.Context, userID, operation, kind string) error
+	Usage(ctx context.Context, userID, operation string, since time.Time) (models.LimitUsage, error)
+	CreateIncreaseRequest(ctx context.Context, request *models.LimitIncreaseRequest) error
+	ListIncreaseRequests(ctx context.Context, userID, status string, limit int) ([]models.LimitIncreaseRequest, error)
+	DecideIncreaseRequest(ctx context.Context, requestID, status, decidedBy, reason string) (*models.LimitIncreaseRequest, error)
+}
+
+var (
+	ErrLimitNotFound          = errors.New("limit not found")
+	ErrLimitRequestNotFound   = errors.New("limit increase request not found")
+	ErrLimitRequestPending    = errors.New("a limit increase request for this limit is already pending")
+	ErrLimitRequestNotPending = errors.New("limit increase request is already decided")
+)
+
+const limitRequestColumns = `id::text, user_id, operation, kind, current_value, requested_value, reason, status,
+	auto_approved, requested_by, decided_by, decision_reason, created_at, decided_at`
+
+// pendingUsage selects the requests that count towards the limits of an
+// operation before they produce a transaction: pending transfers and
+// withdrawals waiting for their payout
+var pendingUsage = map[string]string{
+	models.LimitOperationTransfer:   `SELECT amount, created_at FROM holds WHERE from_user_id = $1 AND status = 'pending'`,
+	models.LimitOperationWithdrawal: `SELECT amount, created_at FROM withdrawals WHERE user_id = $1 AND status IN ('requested', 'processing')`,
+}
+
+type PostgresLimitsRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewLimitsRepository(db *sql.DB, logger *logrus.Logger) *PostgresLimitsRepository {
+	return &PostgresLimitsRepository{db: db, logger: logger}
+}
+
+// ListLimits returns the default limits followed by the limits of userID.
+// With an empty userID only the defaults are returned.
+func (r *PostgresLimitsRepository) ListLimits(ctx context.Context, userID string) ([]models.Limit, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id, operation, kind, value, reason, updated_by, updated_at
+		FROM transaction_limits
+		WHERE user_id = '' OR user_id = $1
+		ORDER BY user_id, operation, kind`,
+		userID,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListLimits - Query limits failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []models.Limit
+	for rows.Next() {
+		var limit models.Limit
+		err := rows.Scan(
+			&limit.UserID,
+			&limit.Operation,
+			&limit.Kind,
+			&limit.Value,
+			&limit.Reason,
+			&limit.UpdatedBy,
+			&limit.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListLimits - Scan limits failed")
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListLimits - Iterate limits failed")
+		return nil, err
+	}
+	return limits, nil
+}
+
+// PutLimit creates or replaces a limit, with limit.UpdatedBy as the actor.
+// UpdatedAt is filled in on success.
+func (r *PostgresLimitsRepository) PutLimit(ctx context.Context, limit *models.Limit) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":    limit.UserID,
+		"operation": limit.Operation,
+		"kind":      limit.Kind,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("PutLimit - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = setLimit(ctx, tx, limit); err != nil {
+		logger.WithError(err).Error("PutLimit - Upsert limit failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("PutLimit - Commit DB transaction failed")
+		return err
+	}
+	return nil
+}
+
+// DeleteLimit removes a limit. Removing a user's limit makes the default
+// apply again.
+func (r *PostgresLimitsRepository) DeleteLimit(ctx context.Context, userID, operation, kind string) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":    userID,
+		"operation": operation,
+		"kind":      kind,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("DeleteLimit - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var value decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		"DELETE FROM transaction_limits WHERE user_id = $1 AND operation = $2 AND kind = $3 RETURNING value",
+		userID, operation, kind,
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("DeleteLimit - Cannot find limit in the database")
+		return ErrLimitNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("DeleteLimit - Delete limit failed")
+		return err
+	}
+
+	err = recordAudit(ctx, sqlTx{tx}, models.AuditEntry{
+		Action: models.AuditLimitRemoved,
+		UserID: userID,
+		Target: operation + "." + kind,
+		Before: models.AuditValues{"value": value},
+	})
+	if err != nil {
+		logger.WithError(err).Error("DeleteLimit - Record audit entry failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("DeleteLimit - Commit DB transaction failed")
+		return err
+	}
+	return nil
+}
+
+// Usage returns the amount and number of operations userID made since the
+// given time, counting pending requests and leaving out failed transactions
+func (r *PostgresLimitsRepository) Usage(ctx context.Context, userID, operation string, since time.Time) (models.LimitUsage, error) {
+	var usage models.LimitUsage
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0), COUNT(*)
+		FROM (
+			SELECT amount, created_at FROM transactions
+			WHERE from_user_id = $1 AND type = $2 AND status <> 'failed'
+			UNION ALL
+			`+pendingUsage[operation]+`
+		) AS used
+		WHERE created_at >= $3`,
+		userID, operation, since,
+	).Scan(&usage.Amount, &usage.Count)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"userID":    userID,
+			"operation": operation,
+		}).Error("Usage - Query usage failed")
+		return models.LimitUsage{}, err
+	}
+	return usage, nil
+}
+
+// CreateIncreaseRequest records a limit increase request, filling in its ID
+// and timestamps. A request created approved raises the limit of the user in
+// the same transaction. A user has at most one pending request per limit.
+func (r *PostgresLimitsRepository) CreateIncreaseRequest(ctx context.Context, request *models.LimitIncreaseRequest) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":    request.UserID,
+		"operation": request.Operation,
+		"kind":      request.Kind,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreateIncreaseRequest - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO limit_increase_requests
+		(user_id, operation, kind, current_value, requested_value, reason, status, auto_approved, requested_by, decided_by, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CASE WHEN $7 = 'pending' THEN NULL ELSE NOW() END)
+		ON CONFLICT (user_id, operation, kind) WHERE status = 'pending' DO NOTHING
+		RETURNING id::text, created_at, decided_at`,
+		request.UserID, request.Operation, request.Kind, request.CurrentValue, request.RequestedValue,
+		request.Reason, request.Status, request.AutoApproved, request.RequestedBy, request.DecidedBy,
+	).Scan(&request.ID, &request.CreatedAt, &request.DecidedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CreateIncreaseRequest - Request already pending")
+		return ErrLimitRequestPending
+	}
+	if err != nil {
+		logger.WithError(err).Error("CreateIncreaseRequest - Create request record failed")
+		return err
+	}
+
+	if request.Status == models.LimitRequestApproved {
+		if err = applyIncreaseRequest(ctx, tx, request); err != nil {
+			logger.WithError(err).Error("CreateIncreaseRequest - Upsert limit failed")
+			return err
+		}
+	}
+
+	if err = enqueueEvent(ctx, sqlTx{tx}, limitIncreaseEvent(request), request.UserID); err != nil {
+		logger.WithError(err).Error("CreateIncreaseRequest - Record limit increase event failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreateIncreaseRequest - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"requestID": request.ID,
+		"status":    request.Status,
+	}).Info("Limit increase requested")
+	return nil
+}
+
+// ListIncreaseRequests returns up to limit increase requests, newest first,
+// of userID and with status. Empty filters match every request.
+func (r *PostgresLimitsRepository) ListIncreaseRequests(ctx context.Context, userID, status string, limit int) ([]models.LimitIncreaseRequest, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+limitRequestColumns+`
+		FROM limit_increase_requests
+		WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`,
+		userID, status, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListIncreaseRequests - Query requests failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.LimitIncreaseRequest
+	for rows.Next() {
+		request, err := scanLimitIncreaseRequest(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListIncreaseRequests - Scan requests failed")
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListIncreaseRequests - Iterate requests failed")
+		return nil, err
+	}
+	return requests, nil
+}
+
+// DecideIncreaseRequest approves or rejects a pending request. Approving it
+// sets the limit of the user to the requested value.
+func (r *PostgresLimitsRepository) DecideIncreaseRequest(ctx context.Context, requestID, status, decidedBy, reason string) (*models.LimitIncreaseRequest, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"requestID": requestID,
+		"status":    status,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("DecideIncreaseRequest - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	request, err := scanLimitIncreaseRequest(tx.QueryRowContext(ctx,
+		`SELECT `+limitRequestColumns+`
+		FROM limit_increase_requests
+		WHERE id::text = $1
+		FOR UPDATE`,
+		requestID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("DecideIncreaseRequest - Cannot find request in the database")
+		return nil, ErrLimitRequestNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("DecideIncreaseRequest - Query request failed")
+		return nil, err
+	}
+	if request.Status != models.LimitRequestPending {
+		logger.WithField("current", request.Status).Warn("DecideIncreaseRequest - Request already decided")
+		return nil, ErrLimitRequestNotPending
+	}
+
+	request.Status = status
+	request.DecidedBy = &decidedBy
+	request.DecisionReason = &reason
+	err = tx.QueryRowContext(ctx,
+		`UPDATE limit_increase_requests
+		SET status = $1, decided_by = $2, decision_reason = $3, decided_at = NOW()
+		WHERE id::text = $4
+		RETURNING decided_at`,
+		status, decidedBy, reason, requestID,
+	).Scan(&request.DecidedAt)
+	if err != nil {
+		logger.WithError(err).Error("DecideIncreaseRequest - Update request failed")
+		return nil, err
+	}
+
+	if status == models.LimitRequestApproved {
+		if err = applyIncreaseRequest(ctx, tx, request); err != nil {
+			logger.WithError(err).Error("DecideIncreaseRequest - Upsert limit failed")
+			return nil, err
+		}
+	}
+
+	if err = enqueueEvent(ctx, sqlTx{tx}, limitIncreaseEvent(request), request.UserID); err != nil {
+		logger.WithError(err).Error("DecideIncreaseRequest - Record limit increase event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("DecideIncreaseRequest - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithField("decidedBy", decidedBy).Info("Limit increase decided")
+	return request, nil
+}
+
+// applyIncreaseRequest sets the limit of the user to the value an approved
+// request asked for, with the approver as the actor
+func applyIncreaseRequest(ctx context.Context, tx *sql.Tx, request *models.LimitIncreaseRequest) error {
+	updatedBy := request.RequestedBy
+	if request.DecidedBy != nil {
+		updatedBy = *request.DecidedBy
+	}
+
+	return setLimit(ctx, tx, &models.Limit{
+		UserID:    request.UserID,
+		Operation: request.Operation,
+		Kind:      request.Kind,
+		Value:     request.RequestedValue,
+		Reason:    "limit increase request " + request.ID,
+		UpdatedBy: updatedBy,
+	})
+}
+
+// setLimit creates or replaces limit inside tx and records the change with
+// the value it replaces in the audit log. UpdatedAt is filled in.
+func setLimit(ctx context.Context, tx *sql.Tx, limit *models.Limit) error {
+	var previous decimal.NullDecimal
+	err := tx.QueryRowContext(ctx,
+		`WITH previous AS (
+			SELECT value FROM transaction_limits
+			WHERE user_id = $1 AND operation = $2 AND kind = $3
+			FOR UPDATE
+		)
+		INSERT INTO transaction_limits (user_id, operation, kind, value, reason, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (user_id, operation, kind)
+		DO UPDATE SET value = $4, reason = $5, updated_by = $6, updated_at = NOW()
+		RETURNING updated_at, (SELECT value FROM previous)`,
+		limit.UserID, limit.Operation, limit.Kind, limit.Value, limit.Reason, limit.UpdatedBy,
+	).Scan(&limit.UpdatedAt, &previous)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditEntry{
+		Action: models.AuditLimitSet,
+		UserID: limit.UserID,
+		Target: limit.Operation + "." + limit.Kind,
+		After:  models.AuditValues{"value": limit.Value},
+		Reason: limit.Reason,
+	}
+	if previous.Valid {
+		entry.Before = models.AuditValues{"value": previous.Decimal}
+	}
+	return recordAudit(ctx, sqlTx{tx}, entry)
+}
+
+func limitIncreaseEvent(request *models.LimitIncreaseRequest) events.Event {
+	eventType := events.TypeLimitIncreaseRequested
+	switch request.Status {
+	case models.LimitRequestApproved:
+		eventType = events.TypeLimitIncreaseApproved
+	case models.LimitRequestRejected:
+		eventType = events.TypeLimitIncreaseRejected
+	}
+
+	return events.New(eventType, events.LimitIncreaseChanged{
+		RequestID:      request.ID,
+		UserID:         request.UserID,
+		Operation:      request.Operation,
+		Kind:           request.Kind,
+		CurrentValue:   request.CurrentValue,
+		RequestedValue: request.RequestedValue,
+		Status:         request.Status,
+		AutoApproved:   request.AutoApproved,
+		DecisionReason: request.DecisionReason,
+	})
+}
+
+func scanLimitIncreaseRequest(row rowScanner) (*models.LimitIncreaseRequest, error) {
+	var request models.LimitIncreaseRequest
+	err := row.Scan(
+		&request.ID,
+		&request.UserID,
+		&request.Operation,
+		&request.Kind,
+		&request.CurrentValue,
+		&request.RequestedValue,
+		&request.Reason,
+		&request.Status,
+		&request.AutoApproved,
+		&request.RequestedBy,
+		&request.DecidedBy,
+		&request.DecisionReason,
+		&request.CreatedAt,
+		&request.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
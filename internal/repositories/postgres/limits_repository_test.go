@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestLimitsRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewLimitsRepository(mockDB, logrus.New())
+	now := time.Now()
+
+	t.Run("ListLimits", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT user_id, operation, kind, value, reason, updated_by, updated_at FROM transaction_limits WHERE user_id = '' OR user_id = \$1`).
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "operation", "kind", "value", "reason", "updated_by", "updated_at"}).
+				AddRow("", models.LimitOperationWithdrawal, models.LimitDailyAmount, "1000", "policy", "admin1", now).
+				AddRow("user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, "5000", "vip", "admin1", now))
+
+		limits, err := repo.ListLimits(ctx, "user1")
+		require.NoError(t, err)
+		require.Len(t, limits, 2)
+		require.Equal(t, "user1", limits[1].UserID)
+		require.True(t, limits[1].Value.Equal(decimal.NewFromInt(5000)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PutLimit", func(t *testing.T) {
+		limit := &models.Limit{UserID: "user1", Operation: models.LimitOperationTransfer, Kind: models.LimitHourlyCount, Value: decimal.NewFromInt(10), Reason: "bot activity", UpdatedBy: "admin1"}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`WITH previous AS (.|\n)*INSERT INTO transaction_limits`).
+			WithArgs("user1", models.LimitOperationTransfer, models.LimitHourlyCount, decimal.NewFromInt(10), "bot activity", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at", "value"}).AddRow(now, "20"))
+		expectAudit(mock, 1)
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.PutLimit(ctx, limit))
+		require.Equal(t, now, limit.UpdatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteLimit", func(t *testing.T) {
+		t.Run("removed", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`DELETE FROM transaction_limits`).WithArgs("user1", models.LimitOperationTransfer, models.LimitHourlyCount).WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("10"))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.DeleteLimit(ctx, "user1", models.LimitOperationTransfer, models.LimitHourlyCount))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`DELETE FROM transaction_limits`).WithArgs("user1", models.LimitOperationTransfer, models.LimitDailyCount).WillReturnRows(sqlmock.NewRows([]string{"value"}))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.DeleteLimit(ctx, "user1", models.LimitOperationTransfer, models.LimitDailyCount), ErrLimitNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		since := now.Add(-24 * time.Hour)
+		// Withdrawals waiting for their payout count as well
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\), COUNT\(\*\)(.|\n)*FROM transactions(.|\n)*FROM withdrawals WHERE user_id = \$1 AND status IN \('requested', 'processing'\)`).
+			WithArgs("user1", models.LimitOperationWithdrawal, since).
+			WillReturnRows(sqlmock.NewRows([]string{"sum", "count"}).AddRow("750.5", 3))
+
+		usage, err := repo.Usage(ctx, "user1", models.LimitOperationWithdrawal, since)
+		require.NoError(t, err)
+		require.True(t, usage.Amount.Equal(decimal.RequireFromString("750.5")))
+		require.Equal(t, 3, usage.Count)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateIncreaseRequest", func(t *testing.T) {
+		request := func(status string) *models.LimitIncreaseRequest {
+			return &models.LimitIncreaseRequest{UserID: "user1", Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, CurrentValue: decimal.NewFromInt(1000), RequestedValue: decimal.NewFromInt(1200), Status: status, RequestedBy: "user1"}
+		}
+
+		t.Run("approved raises the limit", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO limit_increase_requests`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "decided_at"}).AddRow("4", now, now))
+			mock.ExpectQuery(`INSERT INTO transaction_limits`).
+				WithArgs("user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, decimal.NewFromInt(1200), "limit increase request 4", "user1").
+				WillReturnRows(sqlmock.NewRows([]string{"updated_at", "value"}).AddRow(now, "1000"))
+			expectAudit(mock, 1)
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeLimitIncreaseApproved, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			approved := request(models.LimitRequestApproved)
+			require.NoError(t, repo.CreateIncreaseRequest(ctx, approved))
+			require.Equal(t, "4", approved.ID)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already pending", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO limit_increase_requests`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "decided_at"}))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.CreateIncreaseRequest(ctx, request(models.LimitRequestPending)), ErrLimitRequestPending)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("DecideIncreaseRequest", func(t *testing.T) {
+		columns := []string{"id", "user_id", "operation", "kind", "current_value", "requested_value", "reason", "status",
+			"auto_approved", "requested_by", "decided_by", "decision_reason", "created_at", "decided_at"}
+		row := func(status string) *sqlmock.Rows {
+			return sqlmock.NewRows(columns).AddRow("5", "user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, "1000", "5000", "travel", status,
+				false, "user1", nil, nil, now, nil)
+		}
+
+		t.Run("rejected", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT (.|\n)*FROM limit_increase_requests(.|\n)*FOR UPDATE`).WithArgs("5").WillReturnRows(row(models.LimitRequestPending))
+			mock.ExpectQuery(`UPDATE limit_increase_requests`).
+				WithArgs(models.LimitRequestRejected, "admin1", "not verified", "5").
+				WillReturnRows(sqlmock.NewRows([]string{"decided_at"}).AddRow(now))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeLimitIncreaseRejected, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			request, err := repo.DecideIncreaseRequest(ctx, "5", models.LimitRequestRejected, "admin1", "not verified")
+			require.NoError(t, err)
+			require.Equal(t, models.LimitRequestRejected, request.Status)
+			require.Equal(t, "admin1", *request.DecidedBy)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already decided", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT (.|\n)*FROM limit_increase_requests`).WithArgs("5").WillReturnRows(row(models.LimitRequestApproved))
+			mock.ExpectRollback()
+
+			_, err := repo.DecideIncreaseRequest(ctx, "5", models.LimitRequestRejected, "admin1", "not verified")
+			require.ErrorIs(t, err, ErrLimitRequestNotPending)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// MetadataRepository stores the key-value metadata of wallets
+type MetadataRepository interface {
+	GetMetadata(ctx context.Context, userID string) (*models.WalletMetadata, error)
+	SetMetadata(ctx context.Context, metadata *models.WalletMetadata, expectedVersion *int64) error
+}
+
+var ErrMetadataVersionMismatch = errors.New("metadata version does not match")
+
+type PostgresMetadataRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewMetadataRepository(db *sql.DB, logger *logrus.Logger) *PostgresMetadataRepository {
+	return &PostgresMetadataRepository{db: db, logger: logger}
+}
+
+// GetMetadata returns the metadata of the wallet of userID
+func (r *PostgresMetadataRepository) GetMetadata(ctx context.Context, userID string) (*models.WalletMetadata, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetMetadata - userID cannot be an empty string")
+		return nil, ErrInvalidUserID
+	}
+
+	metadata := &models.WalletMetadata{UserID: userID}
+	var raw []byte
+	err := r.db.QueryRowContext(ctx,
+		"SELECT metadata, metadata_version, metadata_updated_at FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&raw, &metadata.Version, &metadata.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.WithContext(ctx).WithField("userID", userID).Warn("GetMetadata - Cannot find user in the database")
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetMetadata - Query metadata failed")
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &metadata.Metadata); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetMetadata - Decode metadata failed")
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// SetMetadata replaces the metadata of the wallet of metadata.UserID and
+// fills in its new version. When expectedVersion is set the metadata is only
+// replaced at that version, otherwise it fails with
+// ErrMetadataVersionMismatch.
+func (r *PostgresMetadataRepository) SetMetadata(ctx context.Context, metadata *models.WalletMetadata, expectedVersion *int64) error {
+	if metadata.UserID == "" {
+		r.logger.WithContext(ctx).Warn("SetMetadata - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+	logger := r.logger.WithContext(ctx).WithField("userID", metadata.UserID)
+
+	raw, err := json.Marshal(metadata.Metadata)
+	if err != nil {
+		logger.WithError(err).Error("SetMetadata - Encode metadata failed")
+		return err
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE wallets SET metadata = $2, metadata_version = metadata_version + 1, metadata_updated_at = NOW()
+		WHERE user_id = $1 AND ($3::BIGINT IS NULL OR metadata_version = $3)
+		RETURNING metadata_version, metadata_updated_at`,
+		metadata.UserID, raw, expectedVersion,
+	).Scan(&metadata.Version, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		err = r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)", metadata.UserID).Scan(&exists)
+		if err != nil {
+			logger.WithError(err).Error("SetMetadata - Query wallet failed")
+			return err
+		}
+		if !exists {
+			logger.Warn("SetMetadata - Cannot find user in the database")
+			return ErrUserNotFound
+		}
+		logger.WithField("expectedVersion", *expectedVersion).Warn("SetMetadata - Metadata version does not match")
+		return ErrMetadataVersionMismatch
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetMetadata - Update metadata failed")
+		return err
+	}
+	metadata.UpdatedAt = &updatedAt
+
+	logger.WithField("version", metadata.Version).Info("Wallet metadata updated")
+	return nil
+}
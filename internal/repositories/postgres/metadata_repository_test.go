@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestMetadataRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewMetadataRepository(mockDB, logrus.New())
+	now := time.Now()
+
+	t.Run("GetMetadata", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT metadata, metadata_version, metadata_updated_at FROM wallets`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"metadata", "metadata_version", "metadata_updated_at"}).AddRow([]byte(`{"crm_id":"C-42"}`), 3, now))
+
+		metadata, err := repo.GetMetadata(ctx, "user1")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"crm_id": "C-42"}, metadata.Metadata)
+		require.Equal(t, int64(3), metadata.Version)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetMetadata unknown wallet", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT metadata`).WithArgs("user9").WillReturnRows(sqlmock.NewRows([]string{"metadata", "metadata_version", "metadata_updated_at"}))
+
+		_, err := repo.GetMetadata(ctx, "user9")
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetMetadata", func(t *testing.T) {
+		t.Run("increments the version", func(t *testing.T) {
+			expected := int64(3)
+			mock.ExpectQuery(`UPDATE wallets SET metadata = \$2, metadata_version = metadata_version \+ 1`).
+				WithArgs("user1", []byte(`{"crm_id":"C-43"}`), &expected).
+				WillReturnRows(sqlmock.NewRows([]string{"metadata_version", "metadata_updated_at"}).AddRow(4, now))
+
+			metadata := &models.WalletMetadata{UserID: "user1", Metadata: map[string]string{"crm_id": "C-43"}}
+			require.NoError(t, repo.SetMetadata(ctx, metadata, &expected))
+			require.Equal(t, int64(4), metadata.Version)
+			require.NotNil(t, metadata.UpdatedAt)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("version mismatch", func(t *testing.T) {
+			expected := int64(2)
+			mock.ExpectQuery(`UPDATE wallets SET metadata`).WillReturnRows(sqlmock.NewRows([]string{"metadata_version", "metadata_updated_at"}))
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+			err := repo.SetMetadata(ctx, &models.WalletMetadata{UserID: "user1", Metadata: map[string]string{}}, &expected)
+			require.ErrorIs(t, err, ErrMetadataVersionMismatch)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("unknown wallet", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE wallets SET metadata`).WillReturnRows(sqlmock.NewRows([]string{"metadata_version", "metadata_updated_at"}))
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user9").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+			err := repo.SetMetadata(ctx, &models.WalletMetadata{UserID: "user9", Metadata: map[string]string{}}, nil)
+			require.ErrorIs(t, err, ErrUserNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLockID keys the advisory lock that keeps instances starting
+// together from applying the same migration twice
+const migrationLockID = 7210331
+
+// Migrate applies the embedded migrations that have not been applied yet, in
+// version order, each in its own transaction. Applied versions are recorded
+// in schema_migrations. It returns the schema version reached.
+func Migrate(ctx context.Context, db *sql.DB, logger *logrus.Logger) (int, error) {
+	// The advisory lock belongs to a session, so every statement runs on the
+	// same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("Migrate - Acquire connection failed")
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("Migrate - Acquire migration lock failed")
+		return 0, err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = conn.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			applied_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+		)`,
+	)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("Migrate - Create migrations table failed")
+		return 0, err
+	}
+
+	var current int
+	err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("Migrate - Query schema version failed")
+		return 0, err
+	}
+
+	pending, err := pendingMigrations(current)
+	if err != nil {
+		return current, err
+	}
+
+	for _, migration := range pending {
+		script, err := migrations.ReadFile("migrations/" + migration.name)
+		if err != nil {
+			return current, err
+		}
+
+		if err := applyMigration(ctx, conn, migration.version, string(script)); err != nil {
+			logger.WithContext(ctx).WithError(err).WithField("migration", migration.name).Error("Migrate - Apply migration failed")
+			return current, err
+		}
+		current = migration.version
+		logger.WithContext(ctx).WithField("migration", migration.name).Info("Migration applied")
+	}
+
+	return current, nil
+}
+
+type migration struct {
+	version int
+	name    string
+}
+
+// pendingMigrations returns the embedded migrations above version, in order
+func pendingMigrations(version int) ([]migration, error) {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var pending []migration
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/")
+		v, err := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		if err != nil {
+			return nil, err
+		}
+		if v > version {
+			pending = append(pending, migration{version: v, name: base})
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, version int, script string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	latest, err := pendingMigrations(0)
+	require.NoError(t, err)
+	require.NotEmpty(t, latest)
+	version := latest[len(latest)-1].version
+
+	t.Run("fresh database", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+		for _, migration := range latest {
+			mock.ExpectBegin()
+			mock.ExpectExec(`CREATE|ALTER`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`INSERT INTO schema_migrations \(version\) VALUES \(\$1\)`).WithArgs(migration.version).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		}
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reached, err := Migrate(ctx, mockDB, logrus.New())
+		require.NoError(t, err)
+		require.Equal(t, version, reached)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reached, err := Migrate(ctx, mockDB, logrus.New())
+		require.NoError(t, err)
+		require.Equal(t, version, reached)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
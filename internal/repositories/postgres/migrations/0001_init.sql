@@ -0,0 +1,275 @@
+-- Initial schema. It only creates what is missing, so databases set up by
+-- hand from earlier versions of the README are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id VARCHAR(255) PRIMARY KEY,
+    balance NUMERIC(20, 8) NOT NULL DEFAULT 0.0,
+    held NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    label VARCHAR(100),
+    country CHAR(2)
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id SERIAL PRIMARY KEY,
+    from_user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    to_user_id VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    merged_from VARCHAR(255),
+    actor VARCHAR(255),
+    channel VARCHAR(20)
+);
+
+CREATE TABLE IF NOT EXISTS transfer_batches (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id VARCHAR(255) NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    total_count INT NOT NULL,
+    succeeded_count INT NOT NULL,
+    failed_count INT NOT NULL,
+    total_amount NUMERIC(20, 8) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transfer_batch_items (
+    batch_id BIGINT NOT NULL REFERENCES transfer_batches (id),
+    item_index INT NOT NULL,
+    receiver_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error_code VARCHAR(50),
+    error TEXT,
+    PRIMARY KEY (batch_id, item_index)
+);
+
+CREATE TABLE IF NOT EXISTS freeze_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(20) NOT NULL,
+    criteria JSONB NOT NULL,
+    parent_job_id BIGINT REFERENCES freeze_jobs (id),
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS freeze_job_wallets (
+    job_id BIGINT NOT NULL REFERENCES freeze_jobs (id),
+    user_id VARCHAR(255) NOT NULL,
+    processed BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (job_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS wallet_status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION record_wallet_status_change() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO wallet_status_changes (user_id, from_status, to_status)
+    VALUES (NEW.user_id, OLD.status, NEW.status);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallets_status_change ON wallets;
+CREATE TRIGGER wallets_status_change
+    AFTER UPDATE OF status ON wallets
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION record_wallet_status_change();
+
+CREATE TABLE IF NOT EXISTS counterparty_exposures (
+    user_a VARCHAR(255) NOT NULL,
+    user_b VARCHAR(255) NOT NULL,
+    window_days INT NOT NULL,
+    volume NUMERIC(20, 8) NOT NULL,
+    transfer_count INT NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_a, user_b, window_days)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    operation JSONB,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGSERIAL PRIMARY KEY,
+    from_user_id VARCHAR(255) NOT NULL,
+    to_user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    transaction_id INT REFERENCES transactions (id),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS wallet_ownership_changes (
+    id BIGSERIAL PRIMARY KEY,
+    previous_user_id VARCHAR(255) NOT NULL,
+    new_user_id VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS wallet_merges (
+    id BIGSERIAL PRIMARY KEY,
+    source_user_id VARCHAR(255) NOT NULL,
+    target_user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    transfer_transaction_id INT REFERENCES transactions (id),
+    transactions_relinked BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS wallet_sequences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    last_sequence BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transaction_sequences (
+    transaction_id INT NOT NULL REFERENCES transactions (id),
+    user_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    PRIMARY KEY (transaction_id, user_id),
+    UNIQUE (user_id, sequence)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+    key VARCHAR(100) NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    scope_id VARCHAR(255) NOT NULL DEFAULT '',
+    value TEXT NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (key, scope, scope_id)
+);
+
+CREATE TABLE IF NOT EXISTS setting_changes (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(100) NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    scope_id VARCHAR(255) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    reason TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    user_id VARCHAR(255) NOT NULL,
+    balance NUMERIC(20, 8) NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, taken_at)
+);
+
+CREATE TABLE IF NOT EXISTS deposit_queue (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    idempotency_key VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    transaction_id INT REFERENCES transactions (id),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    processed_at TIMESTAMPTZ,
+    UNIQUE (user_id, idempotency_key)
+);
+
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    destination VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    provider_reference VARCHAR(255),
+    error TEXT,
+    transaction_id INT REFERENCES transactions (id),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    reason_code VARCHAR(20) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    actor VARCHAR(255) NOT NULL,
+    transaction_id INT NOT NULL REFERENCES transactions (id),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    operation VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value NUMERIC(20, 8) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, operation, kind)
+);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(10) PRIMARY KEY,
+    decimals INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Columns added after the first release
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS held NUMERIC(20, 8) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS merged_from VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS actor VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS channel VARCHAR(20);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS operation JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_wallets_balance ON wallets USING btree (balance);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_history ON transactions USING btree (from_user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_history ON transactions USING btree (to_user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_unfinished ON transactions USING btree (status, updated_at) WHERE status IN ('pending', 'escalated');
+CREATE INDEX IF NOT EXISTS idx_holds_from_user ON holds USING btree (from_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_holds_to_user ON holds USING btree (to_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events USING btree (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_deposit_queue_pending ON deposit_queue USING btree (user_id, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user ON withdrawals USING btree (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_withdrawals_open ON withdrawals USING btree (id) WHERE status IN ('requested', 'processing');
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_user ON balance_adjustments USING btree (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_wallet_status_changes_user ON wallet_status_changes USING btree (user_id, created_at);
@@ -0,0 +1,46 @@
+-- Operator-defined rules that tag transactions with a category
+CREATE TABLE categorization_rules (
+    id BIGSERIAL PRIMARY KEY,
+    category VARCHAR(50) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    counterparty_pattern VARCHAR(255),
+    min_amount NUMERIC(20, 8),
+    max_amount NUMERIC(20, 8),
+    type VARCHAR(20),
+    channel VARCHAR(20),
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+ALTER TABLE transactions ADD COLUMN category VARCHAR(50);
+
+-- transaction_category returns the category of the highest priority rule
+-- matching txn, the oldest rule winning ties. Unset criteria match anything.
+CREATE FUNCTION transaction_category(txn transactions) RETURNS VARCHAR AS $$
+    SELECT category
+    FROM categorization_rules r
+    WHERE (r.counterparty_pattern IS NULL
+            OR txn.from_user_id LIKE r.counterparty_pattern
+            OR txn.to_user_id LIKE r.counterparty_pattern)
+        AND (r.min_amount IS NULL OR txn.amount >= r.min_amount)
+        AND (r.max_amount IS NULL OR txn.amount <= r.max_amount)
+        AND (r.type IS NULL OR r.type = txn.type)
+        AND (r.channel IS NULL OR r.channel = txn.channel)
+    ORDER BY r.priority DESC, r.id
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+-- Categorize every transaction as it is written, whichever repository
+-- records it
+CREATE FUNCTION categorize_transaction() RETURNS trigger AS $$
+BEGIN
+    NEW.category := transaction_category(NEW);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_categorize
+    BEFORE INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION categorize_transaction();
+
+CREATE INDEX idx_transactions_category ON transactions USING btree (category, created_at DESC);
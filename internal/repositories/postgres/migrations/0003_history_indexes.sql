@@ -0,0 +1,5 @@
+-- Histories are read within a time window by default. Deposits, withdrawals
+-- and adjustments have no receiver, so the receiver side only indexes the
+-- transactions that have one.
+CREATE INDEX idx_transactions_receiver_transfers ON transactions USING btree (to_user_id, created_at DESC, id DESC) WHERE to_user_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_transactions_receiver_history;
@@ -0,0 +1,39 @@
+-- Recurring transfers executed by the scheduler. Exactly one of cron and
+-- interval_seconds is set.
+CREATE TABLE transfer_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    to_user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    cron VARCHAR(100),
+    interval_seconds BIGINT,
+    status VARCHAR(20) NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    CHECK ((cron IS NULL) <> (interval_seconds IS NULL))
+);
+
+-- One row per due occurrence of a schedule with its outcome. The unique
+-- occurrence keeps a schedule from running twice for the same time.
+CREATE TABLE transfer_schedule_runs (
+    id BIGSERIAL PRIMARY KEY,
+    schedule_id BIGINT NOT NULL REFERENCES transfer_schedules (id),
+    user_id VARCHAR(255) NOT NULL,
+    to_user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (schedule_id, scheduled_for)
+);
+
+CREATE INDEX idx_transfer_schedules_user ON transfer_schedules USING btree (user_id, id);
+CREATE INDEX idx_transfer_schedules_due ON transfer_schedules USING btree (next_run_at) WHERE status = 'active';
+CREATE INDEX idx_transfer_schedule_runs_schedule ON transfer_schedule_runs USING btree (schedule_id, id DESC);
+CREATE INDEX idx_transfer_schedule_runs_pending ON transfer_schedule_runs USING btree (updated_at) WHERE status = 'pending';
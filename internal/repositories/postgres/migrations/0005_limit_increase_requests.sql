@@ -0,0 +1,24 @@
+-- Limit increases requested by users, approved automatically or by an admin
+CREATE TABLE limit_increase_requests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    current_value NUMERIC(20, 8) NOT NULL,
+    requested_value NUMERIC(20, 8) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    auto_approved BOOLEAN NOT NULL DEFAULT FALSE,
+    requested_by VARCHAR(255) NOT NULL,
+    decided_by VARCHAR(255),
+    decision_reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    decided_at TIMESTAMPTZ
+);
+
+-- A user has at most one open request per limit
+CREATE UNIQUE INDEX idx_limit_increase_requests_pending
+    ON limit_increase_requests USING btree (user_id, operation, kind)
+    WHERE status = 'pending';
+CREATE INDEX idx_limit_increase_requests_user ON limit_increase_requests USING btree (user_id, id DESC);
+CREATE INDEX idx_limit_increase_requests_status ON limit_increase_requests USING btree (status, id);
@@ -0,0 +1,30 @@
+-- Compliance policies per jurisdiction. Every change adds a version; the
+-- latest version of a jurisdiction applies. The empty jurisdiction holds the
+-- default policy.
+CREATE TABLE compliance_policies (
+    jurisdiction VARCHAR(2) NOT NULL,
+    version INT NOT NULL,
+    allowed_currencies JSONB NOT NULL DEFAULT '[]',
+    max_balance NUMERIC(20, 8),
+    external_withdrawals BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (jurisdiction, version)
+);
+
+-- Every evaluated operation with the policy version it was decided on
+CREATE TABLE compliance_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    operation VARCHAR(30) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    jurisdiction VARCHAR(2) NOT NULL,
+    policy_version INT NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    rule VARCHAR(30),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_compliance_decisions_user ON compliance_decisions USING btree (user_id, id DESC);
@@ -0,0 +1,15 @@
+-- API keys of service-to-service callers. Only the SHA-256 hash of the
+-- secret is stored; the key is shown once when it is created.
+CREATE TABLE api_keys (
+    id VARCHAR(16) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    secret_hash BYTEA NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    roles JSONB NOT NULL DEFAULT '[]',
+    scopes JSONB NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
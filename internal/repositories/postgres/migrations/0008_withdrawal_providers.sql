@@ -0,0 +1,4 @@
+-- The payout provider a withdrawal is sent through. It is chosen before the
+-- first attempt and kept for the retries, since a provider only recognises
+-- its own idempotency keys.
+ALTER TABLE withdrawals ADD COLUMN provider VARCHAR(100);
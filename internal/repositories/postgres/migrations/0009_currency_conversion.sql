@@ -0,0 +1,12 @@
+-- The currency a wallet holds, one of the bootstrapped currencies. Wallets
+-- without one, including every wallet created before currencies were
+-- tracked, transfer one to one as before.
+ALTER TABLE wallets ADD COLUMN currency VARCHAR(10) REFERENCES currencies (code);
+
+-- A transfer between wallets of different currencies debits amount from the
+-- sender and credits converted_amount, amount at fx_rate, to the receiver
+ALTER TABLE transactions
+    ADD COLUMN from_currency VARCHAR(10),
+    ADD COLUMN to_currency VARCHAR(10),
+    ADD COLUMN fx_rate NUMERIC(30, 12),
+    ADD COLUMN converted_amount NUMERIC(20, 8);
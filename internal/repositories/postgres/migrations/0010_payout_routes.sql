@@ -0,0 +1,6 @@
+-- The currency of the wallet when the withdrawal was requested, and the
+-- route its payout took: the providers that could send it, best first, and
+-- every failover from one provider to the next
+ALTER TABLE withdrawals
+    ADD COLUMN currency VARCHAR(10),
+    ADD COLUMN route JSONB;
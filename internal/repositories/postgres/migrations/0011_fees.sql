@@ -0,0 +1,22 @@
+-- Fee tiers per operation and currency. The empty currency holds the tiers
+-- of currencies without their own. Of the tiers of an operation and currency
+-- the one with the highest min_amount up to the amount applies.
+CREATE TABLE fees (
+    id BIGSERIAL PRIMARY KEY,
+    operation VARCHAR(20) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    min_amount NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    flat_fee NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    percent_fee NUMERIC(10, 6) NOT NULL DEFAULT 0,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (operation, currency, min_amount)
+);
+
+-- A fee is a transaction of its own from the payer to the fee account,
+-- linked to the transaction it was charged for
+ALTER TABLE transactions ADD COLUMN fee_for INT REFERENCES transactions (id);
+
+-- The fee of a withdrawal is held with its amount and charged once the
+-- payout is sent
+ALTER TABLE withdrawals ADD COLUMN fee NUMERIC(20, 8) NOT NULL DEFAULT 0;
@@ -0,0 +1,33 @@
+-- Automatic top-up rule of a wallet: when the available balance falls below
+-- threshold, amount is collected from funding_source and deposited
+CREATE TABLE top_up_rules (
+    user_id VARCHAR(255) PRIMARY KEY,
+    threshold NUMERIC(20, 8) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    funding_source VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- One row per triggered top-up with its outcome. A wallet has at most one
+-- pending top-up, so a balance below the threshold triggers one at a time.
+CREATE TABLE top_up_runs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    currency VARCHAR(10),
+    funding_source VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    provider_reference VARCHAR(255),
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_top_up_runs_one_pending ON top_up_runs USING btree (user_id) WHERE status = 'pending';
+CREATE INDEX idx_top_up_runs_user ON top_up_runs USING btree (user_id, id DESC);
+CREATE INDEX idx_top_up_runs_pending ON top_up_runs USING btree (updated_at) WHERE status = 'pending';
@@ -0,0 +1,28 @@
+-- Balance reconciliation runs recompute the balance of every wallet from the
+-- ledger. Wallets are checked in user_id order and last_user_id is the last
+-- one checked, so an interrupted run resumes after it. At most one run is
+-- running at a time.
+CREATE TABLE reconciliation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    last_user_id VARCHAR(255) NOT NULL DEFAULT '',
+    checked INT NOT NULL DEFAULT 0,
+    mismatched INT NOT NULL DEFAULT 0,
+    triggered_by VARCHAR(255) NOT NULL,
+    started_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX reconciliation_runs_running_idx ON reconciliation_runs ((TRUE)) WHERE status = 'running';
+
+-- Wallets whose stored balance differed from their ledger when a run checked
+-- them. difference is the stored balance minus the ledger balance.
+CREATE TABLE reconciliation_reports (
+    run_id BIGINT NOT NULL REFERENCES reconciliation_runs (id),
+    user_id VARCHAR(255) NOT NULL,
+    stored_balance NUMERIC(20, 8) NOT NULL,
+    ledger_balance NUMERIC(20, 8) NOT NULL,
+    difference NUMERIC(20, 8) NOT NULL,
+    checked_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (run_id, user_id)
+);
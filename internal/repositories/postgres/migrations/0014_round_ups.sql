@@ -0,0 +1,26 @@
+-- Round-up rule of a wallet: each outgoing transfer made since the rule was
+-- created is rounded up to a multiple of unit and the difference moved to the
+-- savings sub-account of the wallet
+CREATE TABLE round_up_rules (
+    user_id VARCHAR(255) PRIMARY KEY,
+    unit NUMERIC(20, 8) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- A round-up is a transaction of its own from the wallet to its savings
+-- sub-account, linked to the transfer it rounds up
+ALTER TABLE transactions ADD COLUMN round_up_for INT REFERENCES transactions (id);
+
+-- Transfers the round-up worker has handled. A transfer the wallet could not
+-- cover the round-up of is recorded as skipped, so each transfer is rounded
+-- up at most once.
+CREATE TABLE round_ups (
+    transfer_id INT PRIMARY KEY REFERENCES transactions (id),
+    user_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    transaction_id INT REFERENCES transactions (id),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,15 @@
+-- Version of every wallet row for optimistic concurrency. Any change of the
+-- row increases it, whichever statement makes it, so a writer that read the
+-- wallet without locking it can tell whether it changed since.
+ALTER TABLE wallets ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+CREATE FUNCTION bump_wallet_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_version
+    BEFORE UPDATE ON wallets
+    FOR EACH ROW EXECUTE FUNCTION bump_wallet_version();
@@ -0,0 +1,14 @@
+-- Repairs applied by the consistency checker, with the issue each one fixed
+-- as it was found and the operation that applied it
+CREATE TABLE consistency_repairs (
+    id BIGSERIAL PRIMARY KEY,
+    repair VARCHAR(30) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    issue JSONB NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    applied_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_consistency_repairs_user ON consistency_repairs USING btree (user_id, applied_at DESC);
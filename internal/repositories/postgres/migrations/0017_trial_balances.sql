@@ -0,0 +1,27 @@
+-- Daily trial balances, the closing artifact of a UTC day. A day is
+-- generated once; its figures never change afterwards.
+CREATE TABLE trial_balances (
+    day DATE PRIMARY KEY,
+    generated_by VARCHAR(255) NOT NULL,
+    generated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Debits and credits of the wallets of each currency over the day.
+-- currency is empty for wallets without one.
+CREATE TABLE trial_balance_currencies (
+    day DATE NOT NULL REFERENCES trial_balances (day),
+    currency VARCHAR(10) NOT NULL,
+    debits NUMERIC(20, 8) NOT NULL,
+    credits NUMERIC(20, 8) NOT NULL,
+    transactions INT NOT NULL,
+    PRIMARY KEY (day, currency)
+);
+
+-- Balances of the system accounts at the end of the day
+CREATE TABLE trial_balance_accounts (
+    day DATE NOT NULL REFERENCES trial_balances (day),
+    account VARCHAR(255) NOT NULL,
+    currency VARCHAR(10),
+    balance NUMERIC(20, 8) NOT NULL,
+    PRIMARY KEY (day, account)
+);
@@ -0,0 +1,13 @@
+-- Secrets signing the event webhook deliveries. They are stored as is, since
+-- signing needs them. A rotated key keeps signing deliveries until
+-- expires_at, so receivers can switch to its successor without dropping any;
+-- the current key has no expiry.
+CREATE TABLE webhook_signing_keys (
+    id BIGSERIAL PRIMARY KEY,
+    secret VARCHAR(100) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_signing_keys_expires_at ON webhook_signing_keys (expires_at);
@@ -0,0 +1,19 @@
+-- Erasure requests of wallet owners. A pending erasure waits for the end of
+-- the retention period; once carried out the user ID is cleared and the
+-- record keeps the pseudonym that replaced it, who asked for it and why.
+CREATE TABLE data_erasures (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    requested_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    erase_after TIMESTAMPTZ NOT NULL,
+    erased_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    pseudonym VARCHAR(255),
+    rows_anonymized BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX idx_data_erasures_pending_user ON data_erasures (user_id) WHERE status = 'pending';
+CREATE INDEX idx_data_erasures_due ON data_erasures (erase_after) WHERE status = 'pending';
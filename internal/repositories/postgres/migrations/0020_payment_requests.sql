@@ -0,0 +1,18 @@
+-- Requests of a user asking another one to pay them. A pending request is
+-- accepted or declined by the payer, or expires at expires_at.
+CREATE TABLE payment_requests (
+    id BIGSERIAL PRIMARY KEY,
+    requester_id VARCHAR(255) NOT NULL,
+    payer_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    note VARCHAR(140) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    decided_at TIMESTAMPTZ,
+    CHECK (requester_id <> payer_id)
+);
+
+CREATE INDEX idx_payment_requests_payer ON payment_requests USING btree (payer_id, id DESC);
+CREATE INDEX idx_payment_requests_requester ON payment_requests USING btree (requester_id, id DESC);
+CREATE INDEX idx_payment_requests_expiring ON payment_requests USING btree (expires_at) WHERE status = 'pending';
@@ -0,0 +1,22 @@
+-- Users who get their money movements in digests, one notification per
+-- window, instead of one notification per event. Users without a row are
+-- notified immediately.
+CREATE TABLE notification_preferences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    digest_window_seconds INT NOT NULL CHECK (digest_window_seconds > 0),
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Events published for a user in digest mode and not yet summarized. Items
+-- are deleted in the transaction recording the digest that summarizes them.
+CREATE TABLE notification_digest_items (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, event_id)
+);
+
+CREATE INDEX idx_notification_digest_items_user ON notification_digest_items USING btree (user_id, id);
@@ -0,0 +1,21 @@
+-- Event streams of the event-sourced storage engine (WALLET_STORAGE=events).
+-- Every deposit, withdrawal and transfer is appended to the stream of each
+-- wallet it moves funds of, and balances are projected from the streams.
+-- Each event carries the hash of the previous event of its stream, so
+-- changing or removing an event breaks the chain of every later one.
+CREATE TABLE wallet_events (
+    user_id VARCHAR(255) NOT NULL,
+    version BIGINT NOT NULL CHECK (version > 0),
+    type VARCHAR(20) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL CHECK (amount > 0),
+    counterparty_id VARCHAR(255),
+    transaction_id INT,
+    actor VARCHAR(255),
+    channel VARCHAR(20),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    previous_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, version)
+);
+
+CREATE INDEX idx_wallet_events_occurred_at ON wallet_events USING btree (user_id, occurred_at);
@@ -0,0 +1,5 @@
+-- Receivers acknowledge the transfers they received once, optionally with a
+-- message to the sender. Both parties see it on the transaction.
+ALTER TABLE transactions
+    ADD COLUMN acknowledged_at TIMESTAMPTZ,
+    ADD COLUMN acknowledgment_message VARCHAR(280);
@@ -0,0 +1,9 @@
+-- Key-value metadata set by the client application owning a wallet, with a
+-- version incremented by every change. The GIN index serves admin listings
+-- filtered by metadata.
+ALTER TABLE wallets
+    ADD COLUMN metadata JSONB DEFAULT '{}' NOT NULL,
+    ADD COLUMN metadata_version BIGINT DEFAULT 0 NOT NULL,
+    ADD COLUMN metadata_updated_at TIMESTAMPTZ;
+
+CREATE INDEX idx_wallets_metadata ON wallets USING gin (metadata jsonb_path_ops);
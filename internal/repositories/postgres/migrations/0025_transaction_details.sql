@@ -0,0 +1,15 @@
+-- Memo, reference and key-value metadata attached by the client to the
+-- transactions it creates, and to the queued deposits that will create them.
+-- The indexes serve transaction histories filtered by reference or metadata.
+ALTER TABLE transactions
+    ADD COLUMN memo TEXT,
+    ADD COLUMN reference_id TEXT,
+    ADD COLUMN metadata JSONB;
+
+ALTER TABLE deposit_queue
+    ADD COLUMN memo TEXT,
+    ADD COLUMN reference_id TEXT,
+    ADD COLUMN metadata JSONB;
+
+CREATE INDEX idx_transactions_reference_id ON transactions (reference_id) WHERE reference_id IS NOT NULL;
+CREATE INDEX idx_transactions_metadata ON transactions USING gin (metadata jsonb_path_ops);
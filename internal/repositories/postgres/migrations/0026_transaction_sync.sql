@@ -0,0 +1,49 @@
+-- Sync sequence of every ledger entry. A wallet's counter advances whenever
+-- one of its transactions is recorded or changes status, whichever statement
+-- does it, so a client that synced up to a sync sequence can fetch only the
+-- entries that changed since. Existing entries start at their sequence.
+CREATE TABLE wallet_sync_sequences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    last_sync_sequence BIGINT NOT NULL
+);
+
+ALTER TABLE transaction_sequences ADD COLUMN sync_sequence BIGINT;
+UPDATE transaction_sequences SET sync_sequence = sequence;
+ALTER TABLE transaction_sequences ALTER COLUMN sync_sequence SET NOT NULL;
+CREATE UNIQUE INDEX idx_transaction_sequences_sync ON transaction_sequences (user_id, sync_sequence);
+
+INSERT INTO wallet_sync_sequences (user_id, last_sync_sequence)
+SELECT user_id, MAX(sync_sequence) FROM transaction_sequences GROUP BY user_id;
+
+-- The counter row stays locked until the writing transaction commits, so the
+-- sync sequences of a wallet become visible in order
+CREATE FUNCTION next_sync_sequence(wallet VARCHAR) RETURNS BIGINT AS $$
+    INSERT INTO wallet_sync_sequences (user_id, last_sync_sequence)
+    VALUES (wallet, 1)
+    ON CONFLICT (user_id) DO UPDATE SET last_sync_sequence = wallet_sync_sequences.last_sync_sequence + 1
+    RETURNING last_sync_sequence
+$$ LANGUAGE sql VOLATILE;
+
+CREATE FUNCTION assign_sync_sequence() RETURNS trigger AS $$
+BEGIN
+    NEW.sync_sequence := next_sync_sequence(NEW.user_id);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transaction_sequences_sync
+    BEFORE INSERT ON transaction_sequences
+    FOR EACH ROW EXECUTE FUNCTION assign_sync_sequence();
+
+CREATE FUNCTION resync_transaction() RETURNS trigger AS $$
+BEGIN
+    UPDATE transaction_sequences SET sync_sequence = next_sync_sequence(user_id)
+    WHERE transaction_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_sync
+    AFTER UPDATE OF status ON transactions
+    FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION resync_transaction();
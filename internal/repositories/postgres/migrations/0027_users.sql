@@ -0,0 +1,43 @@
+-- Registered owners of wallets. Wallets and transactions may only refer to
+-- registered users, so a deposit can no longer create the wallet of an
+-- arbitrary user ID. New users get a UUID; the user IDs wallets were created
+-- with before are registered as they are.
+CREATE TABLE users (
+    id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    kind VARCHAR(20) NOT NULL DEFAULT 'customer',
+    external_id VARCHAR(255) UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+INSERT INTO users (id, kind)
+SELECT user_id, CASE WHEN label IN ('system', 'savings') THEN label ELSE 'customer' END
+FROM wallets;
+
+INSERT INTO users (id)
+SELECT from_user_id FROM transactions
+UNION
+SELECT to_user_id FROM transactions WHERE to_user_id IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+-- System accounts and savings sub-accounts belong to the service and are
+-- registered with their wallet, whichever statement creates it
+CREATE FUNCTION register_account() RETURNS trigger AS $$
+BEGIN
+    IF NEW.label IN ('system', 'savings') THEN
+        INSERT INTO users (id, kind) VALUES (NEW.user_id, NEW.label)
+        ON CONFLICT (id) DO NOTHING;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_register_account
+    BEFORE INSERT ON wallets
+    FOR EACH ROW EXECUTE FUNCTION register_account();
+
+ALTER TABLE wallets ADD CONSTRAINT wallets_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id);
+ALTER TABLE transactions ADD CONSTRAINT transactions_from_user_id_fkey
+    FOREIGN KEY (from_user_id) REFERENCES users (id);
+ALTER TABLE transactions ADD CONSTRAINT transactions_to_user_id_fkey
+    FOREIGN KEY (to_user_id) REFERENCES users (id);
@@ -0,0 +1,13 @@
+-- Delivery state of the event webhook. Integrators pause deliveries during
+-- their maintenance; events keep being recorded in the outbox meanwhile and
+-- are delivered in order once deliveries resume. The table holds one row.
+CREATE TABLE webhook_subscription (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    paused_at TIMESTAMPTZ,
+    paused_by VARCHAR(255),
+    pause_reason VARCHAR(255),
+    resumed_at TIMESTAMPTZ,
+    resumed_by VARCHAR(255)
+);
+
+INSERT INTO webhook_subscription DEFAULT VALUES;
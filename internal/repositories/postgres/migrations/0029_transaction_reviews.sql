@@ -0,0 +1,22 @@
+-- Flags and annotations admins attach to transactions while reviewing them
+-- for fraud. A transaction carries each flag at most once; annotations are
+-- kept in the order they were written.
+CREATE TABLE transaction_flags (
+    transaction_id INT NOT NULL REFERENCES transactions (id),
+    flag VARCHAR(30) NOT NULL CHECK (flag IN ('suspicious', 'reviewed', 'training_excluded')),
+    flagged_by VARCHAR(255) NOT NULL,
+    flagged_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (transaction_id, flag)
+);
+
+CREATE INDEX idx_transaction_flags_flag ON transaction_flags (flag, transaction_id);
+
+CREATE TABLE transaction_annotations (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions (id),
+    note VARCHAR(1000) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_transaction_annotations_transaction_id ON transaction_annotations (transaction_id, id);
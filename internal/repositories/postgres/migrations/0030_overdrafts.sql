@@ -0,0 +1,12 @@
+-- Credit lines let a wallet's balance go negative, down to minus its
+-- overdraft limit, on withdrawals and transfers. The part of a debit paid
+-- from the credit line is recorded against its transaction; the wallet that
+-- drew it is the sender of the transaction.
+ALTER TABLE wallets
+    ADD COLUMN overdraft_limit NUMERIC(20, 8) NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+
+CREATE TABLE overdraft_drawdowns (
+    transaction_id INT PRIMARY KEY REFERENCES transactions (id),
+    amount NUMERIC(20, 8) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,40 @@
+-- Append-only record of every deposit, withdrawal, transfer, balance
+-- adjustment and limit change, written in the transaction making the change.
+-- Rows are never updated or deleted, except by an erasure pseudonymizing the
+-- personal data of a wallet owner, which sets wallet.audit_erasure for its
+-- transaction.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(30) NOT NULL,
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    target VARCHAR(100) NOT NULL DEFAULT '',
+    transaction_id INT,
+    before_values JSONB,
+    after_values JSONB,
+    actor VARCHAR(255),
+    channel VARCHAR(20),
+    client_ip VARCHAR(45),
+    request_id VARCHAR(128),
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_audit_log_user_id ON audit_log (user_id, id);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+
+CREATE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_setting('wallet.audit_erasure', true) = 'on' THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
@@ -0,0 +1,24 @@
+-- Transfers above the confirmation threshold, waiting for the sender to
+-- confirm them with the one-time password sent to them. Only the hash of the
+-- password is kept. A pending confirmation is confirmed, which makes the
+-- transfer, fails after too many wrong passwords or expires at expires_at.
+CREATE TABLE transfer_confirmations (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id VARCHAR(255) NOT NULL,
+    receiver_id VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL,
+    expected_balance NUMERIC(20, 8),
+    memo TEXT,
+    reference_id TEXT,
+    metadata JSONB,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    idempotency_key VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_transfer_confirmations_idempotency ON transfer_confirmations (sender_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX idx_transfer_confirmations_expiring ON transfer_confirmations USING btree (expires_at) WHERE status = 'pending';
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// NotificationRepository stores how users are notified and turns the events
+// of users in digest mode into digests
+type NotificationRepository interface {
+	GetNotificationPreference(ctx context.Context, userID string) (*models.NotificationPreference, error)
+	SetNotificationPreference(ctx context.Context, preference *models.NotificationPreference) error
+	DueDigests(ctx context.Context, now time.Time, limit int) ([]string, error)
+	SendDigest(ctx context.Context, userID string) (int, error)
+}
+
+// maxDigestEvents bounds the events summarized by one digest; the others
+// are left to the next one
+const maxDigestEvents = 5000
+
+type PostgresNotificationRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(db *sql.DB, logger *logrus.Logger) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db, logger: logger}
+}
+
+// GetNotificationPreference returns how userID is notified, immediately
+// unless it chose digests
+func (r *PostgresNotificationRepository) GetNotificationPreference(ctx context.Context, userID string) (*models.NotificationPreference, error) {
+	preference := &models.NotificationPreference{UserID: userID, Mode: models.NotificationDigest}
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx,
+		"SELECT digest_window_seconds, updated_at FROM notification_preferences WHERE user_id = $1",
+		userID,
+	).Scan(&preference.WindowSeconds, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.NotificationPreference{UserID: userID, Mode: models.NotificationImmediate}, nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetNotificationPreference - Query preference failed")
+		return nil, err
+	}
+	preference.UpdatedAt = &updatedAt
+	return preference, nil
+}
+
+// SetNotificationPreference stores how preference.UserID is notified,
+// filling in UpdatedAt in digest mode. Digests need a wallet. Events already
+// waiting for a digest are sent with the next one, at once when the user
+// switches back to immediate notifications.
+func (r *PostgresNotificationRepository) SetNotificationPreference(ctx context.Context, preference *models.NotificationPreference) error {
+	if preference.UserID == "" {
+		r.logger.WithContext(ctx).Warn("SetNotificationPreference - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+	logger := r.logger.WithContext(ctx).WithField("userID", preference.UserID)
+
+	if preference.Mode == models.NotificationImmediate {
+		_, err := r.db.ExecContext(ctx, "DELETE FROM notification_preferences WHERE user_id = $1", preference.UserID)
+		if err != nil {
+			logger.WithError(err).Error("SetNotificationPreference - Delete preference failed")
+			return err
+		}
+		preference.WindowSeconds = 0
+		preference.UpdatedAt = nil
+		return nil
+	}
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO notification_preferences (user_id, digest_window_seconds)
+		SELECT $1, $2 WHERE EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)
+		ON CONFLICT (user_id) DO UPDATE SET digest_window_seconds = EXCLUDED.digest_window_seconds, updated_at = NOW()
+		RETURNING updated_at`,
+		preference.UserID, preference.WindowSeconds,
+	).Scan(&updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("SetNotificationPreference - Wallet not found")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetNotificationPreference - Upsert preference failed")
+		return err
+	}
+	preference.UpdatedAt = &updatedAt
+	return nil
+}
+
+// DueDigests returns up to limit users with a digest due at now: their
+// first waiting event is older than their digest window, or they no longer
+// get digests
+func (r *PostgresNotificationRepository) DueDigests(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT i.user_id
+		FROM notification_digest_items i
+		LEFT JOIN notification_preferences p ON p.user_id = i.user_id
+		GROUP BY i.user_id, p.digest_window_seconds
+		HAVING p.digest_window_seconds IS NULL
+			OR MIN(i.occurred_at) <= $1 - make_interval(secs => p.digest_window_seconds)
+		ORDER BY MIN(i.occurred_at)
+		LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("DueDigests - Query due digests failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("DueDigests - Scan user failed")
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("DueDigests - Iterate users failed")
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// digestItem is an event waiting for the digest of a user
+type digestItem struct {
+	id         int64
+	eventID    string
+	eventType  string
+	payload    []byte
+	occurredAt time.Time
+}
+
+// SendDigest records the notification.digest event summarizing the events
+// waiting for the digest of userID, up to maxDigestEvents, and removes them
+// in the same transaction, so every event is summarized exactly once. It
+// returns how many events the digest summarizes, zero when none were
+// waiting or another instance is sending them.
+func (r *PostgresNotificationRepository) SendDigest(ctx context.Context, userID string) (int, error) {
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SendDigest - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, event_id, type, payload, occurred_at
+		FROM notification_digest_items
+		WHERE user_id = $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		userID, maxDigestEvents,
+	)
+	if err != nil {
+		logger.WithError(err).Error("SendDigest - Query waiting events failed")
+		return 0, err
+	}
+	var items []digestItem
+	for rows.Next() {
+		var item digestItem
+		if err := rows.Scan(&item.id, &item.eventID, &item.eventType, &item.payload, &item.occurredAt); err != nil {
+			rows.Close()
+			logger.WithError(err).Error("SendDigest - Scan waiting event failed")
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("SendDigest - Iterate waiting events failed")
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	digest, err := summarizeDigest(userID, items)
+	if err != nil {
+		logger.WithError(err).Error("SendDigest - Decode waiting event failed")
+		return 0, err
+	}
+	if err := enqueueEvent(ctx, sqlTx{tx}, events.New(events.TypeNotificationDigest, digest), userID); err != nil {
+		logger.WithError(err).Error("SendDigest - Enqueue digest event failed")
+		return 0, err
+	}
+
+	// Removed by ID: events that joined the digest since are left for the
+	// next one
+	for start := 0; start < len(items); start += maxBulkRows {
+		chunk := items[start:min(start+maxBulkRows, len(items))]
+		ids := make([]interface{}, 0, len(chunk))
+		for _, item := range chunk {
+			ids = append(ids, item.id)
+		}
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM notification_digest_items WHERE id IN "+valuesList(1, len(ids)),
+			ids...,
+		)
+		if err != nil {
+			logger.WithError(err).Error("SendDigest - Remove summarized events failed")
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SendDigest - Commit DB transaction failed")
+		return 0, err
+	}
+
+	logger.WithField("events", len(items)).Info("Notification digest recorded")
+	return len(items), nil
+}
+
+// summarizeDigest sums the events of items by type and by direction
+// relative to the wallet of userID
+func summarizeDigest(userID string, items []digestItem) (events.NotificationDigest, error) {
+	digest := events.NotificationDigest{
+		UserID:     userID,
+		From:       items[0].occurredAt,
+		To:         items[0].occurredAt,
+		EventCount: len(items),
+		Totals:     []events.DigestTotal{},
+		EventIDs:   make([]string, 0, len(items)),
+	}
+
+	for _, item := range items {
+		var payload struct {
+			ToUserID   string             `json:"to_user_id"`
+			Amount     decimal.Decimal    `json:"amount"`
+			Conversion *models.Conversion `json:"conversion"`
+		}
+		if err := json.Unmarshal(item.payload, &payload); err != nil {
+			return digest, err
+		}
+
+		direction, amount := events.DigestOut, payload.Amount
+		switch {
+		case item.eventType == events.TypeWalletCredited:
+			direction = events.DigestIn
+		case item.eventType == events.TypeTransferCompleted && payload.ToUserID == userID:
+			direction = events.DigestIn
+			if payload.Conversion != nil {
+				amount = payload.Conversion.ConvertedAmount
+			}
+		}
+
+		i := slices.IndexFunc(digest.Totals, func(total events.DigestTotal) bool {
+			return total.Type == item.eventType && total.Direction == direction
+		})
+		if i < 0 {
+			digest.Totals = append(digest.Totals, events.DigestTotal{Type: item.eventType, Direction: direction, Amount: decimal.Zero})
+			i = len(digest.Totals) - 1
+		}
+		digest.Totals[i].Count++
+		digest.Totals[i].Amount = digest.Totals[i].Amount.Add(amount)
+
+		digest.EventIDs = append(digest.EventIDs, item.eventID)
+		if item.occurredAt.Before(digest.From) {
+			digest.From = item.occurredAt
+		}
+		if item.occurredAt.After(digest.To) {
+			digest.To = item.occurredAt
+		}
+	}
+
+	slices.SortFunc(digest.Totals, func(a, b events.DigestTotal) int {
+		if c := strings.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Direction, b.Direction)
+	})
+	return digest, nil
+}
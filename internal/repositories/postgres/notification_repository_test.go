@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestNotificationRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewNotificationRepository(mockDB, logrus.New())
+	now := time.Now()
+
+	t.Run("GetNotificationPreference defaults to immediate", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT digest_window_seconds, updated_at FROM notification_preferences`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"digest_window_seconds", "updated_at"}))
+
+		preference, err := repo.GetNotificationPreference(ctx, "user1")
+		require.NoError(t, err)
+		require.Equal(t, &models.NotificationPreference{UserID: "user1", Mode: models.NotificationImmediate}, preference)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetNotificationPreference in digest mode", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT digest_window_seconds, updated_at FROM notification_preferences`).WithArgs("merchant1").
+			WillReturnRows(sqlmock.NewRows([]string{"digest_window_seconds", "updated_at"}).AddRow(3600, now))
+
+		preference, err := repo.GetNotificationPreference(ctx, "merchant1")
+		require.NoError(t, err)
+		require.Equal(t, &models.NotificationPreference{UserID: "merchant1", Mode: models.NotificationDigest, WindowSeconds: 3600, UpdatedAt: &now}, preference)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetNotificationPreference to digest", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO notification_preferences(.|\n)*ON CONFLICT`).WithArgs("merchant1", 900).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+
+		preference := &models.NotificationPreference{UserID: "merchant1", Mode: models.NotificationDigest, WindowSeconds: 900}
+		require.NoError(t, repo.SetNotificationPreference(ctx, preference))
+		require.Equal(t, &now, preference.UpdatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetNotificationPreference without a wallet", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO notification_preferences`).WithArgs("ghost", 900).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+		preference := &models.NotificationPreference{UserID: "ghost", Mode: models.NotificationDigest, WindowSeconds: 900}
+		require.ErrorIs(t, repo.SetNotificationPreference(ctx, preference), ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetNotificationPreference to immediate", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM notification_preferences WHERE user_id = \$1`).WithArgs("merchant1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		preference := &models.NotificationPreference{UserID: "merchant1", Mode: models.NotificationImmediate, WindowSeconds: 900}
+		require.NoError(t, repo.SetNotificationPreference(ctx, preference))
+		require.Zero(t, preference.WindowSeconds)
+		require.Nil(t, preference.UpdatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DueDigests", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT i.user_id(.|\n)*HAVING p.digest_window_seconds IS NULL`).WithArgs(now, 50).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("merchant1").AddRow("user2"))
+
+		userIDs, err := repo.DueDigests(ctx, now, 50)
+		require.NoError(t, err)
+		require.Equal(t, []string{"merchant1", "user2"}, userIDs)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SendDigest summarizes and removes the waiting events", func(t *testing.T) {
+		first := now.Add(-time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, event_id, type, payload, occurred_at(.|\n)*FOR UPDATE SKIP LOCKED`).WithArgs("merchant1", maxDigestEvents).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "type", "payload", "occurred_at"}).
+				AddRow(4, "evt_1", events.TypeTransferCompleted, []byte(`{"from_user_id":"user1","to_user_id":"merchant1","amount":"10"}`), first.Add(time.Minute)).
+				AddRow(5, "evt_2", events.TypeTransferCompleted, []byte(`{"from_user_id":"user2","to_user_id":"merchant1","amount":"10","conversion":{"converted_amount":"9.5"}}`), first).
+				AddRow(7, "evt_3", events.TypeTransferCompleted, []byte(`{"from_user_id":"merchant1","to_user_id":"user3","amount":"4"}`), now).
+				AddRow(9, "evt_4", events.TypeFeeCharged, []byte(`{"user_id":"merchant1","amount":"0.5"}`), now))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), events.TypeNotificationDigest, "merchant1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM notification_digest_items WHERE id IN \(\$1, \$2, \$3, \$4\)`).
+			WithArgs(int64(4), int64(5), int64(7), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		sent, err := repo.SendDigest(ctx, "merchant1")
+		require.NoError(t, err)
+		require.Equal(t, 4, sent)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SendDigest with nothing waiting", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, event_id, type, payload, occurred_at`).WithArgs("merchant1", maxDigestEvents).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "type", "payload", "occurred_at"}))
+		mock.ExpectRollback()
+
+		sent, err := repo.SendDigest(ctx, "merchant1")
+		require.NoError(t, err)
+		require.Zero(t, sent)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSummarizeDigest(t *testing.T) {
+	now := time.Now()
+	digest, err := summarizeDigest("merchant1", []digestItem{
+		{eventID: "evt_1", eventType: events.TypeTransferCompleted, payload: []byte(`{"from_user_id":"user1","to_user_id":"merchant1","amount":"10"}`), occurredAt: now},
+		{eventID: "evt_2", eventType: events.TypeTransferCompleted, payload: []byte(`{"from_user_id":"user2","to_user_id":"merchant1","amount":"10","conversion":{"converted_amount":"9.5"}}`), occurredAt: now.Add(-time.Hour)},
+		{eventID: "evt_3", eventType: events.TypeTransferCompleted, payload: []byte(`{"from_user_id":"merchant1","to_user_id":"user3","amount":"4"}`), occurredAt: now.Add(time.Minute)},
+		{eventID: "evt_4", eventType: events.TypeWalletCredited, payload: []byte(`{"user_id":"merchant1","amount":"100"}`), occurredAt: now},
+		{eventID: "evt_5", eventType: events.TypeFeeCharged, payload: []byte(`{"user_id":"merchant1","amount":"0.5"}`), occurredAt: now},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 5, digest.EventCount)
+	require.Equal(t, []string{"evt_1", "evt_2", "evt_3", "evt_4", "evt_5"}, digest.EventIDs)
+	require.Equal(t, now.Add(-time.Hour), digest.From)
+	require.Equal(t, now.Add(time.Minute), digest.To)
+
+	encoded, err := json.Marshal(digest.Totals)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"type": "fee.charged", "direction": "out", "count": 1, "amount": "0.5"},
+		{"type": "transfer.completed", "direction": "in", "count": 2, "amount": "19.5"},
+		{"type": "transfer.completed", "direction": "out", "count": 1, "amount": "4"},
+		{"type": "wallet.credited", "direction": "in", "count": 1, "amount": "100"}
+	]`, string(encoded))
+	require.True(t, digest.Totals[1].Amount.Equal(decimal.RequireFromString("19.5")))
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// walletRead is how a money movement reads the wallets it changes
+type walletRead int
+
+const (
+	// readLocked locks the wallet rows until the transaction ends, so
+	// concurrent writers of a wallet queue behind each other
+	readLocked walletRead = iota
+	// readVersioned reads the wallet rows and their version without locking
+	// them. Their update fails with errVersionConflict when another
+	// transaction changed them since, and the caller runs the movement again.
+	readVersioned
+)
+
+// ErrWalletContended is returned when a wallet read without locking kept
+// changing before it could be updated
+var ErrWalletContended = errors.New("wallet is updated concurrently, retry later")
+
+var errVersionConflict = errors.New("wallet changed since it was read")
+
+// readWallets returns the wallet rows of userIDs by user ID with their
+// version, without locking them; wallets that do not exist are missing
+func readWallets(ctx context.Context, tx ledgerTx, userIDs ...string) (map[string]lockedWallet, error) {
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		args[i] = userID
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT user_id, balance, held, status, COALESCE(currency, ''), overdraft_limit, version FROM wallets
+		WHERE user_id IN `+valuesList(1, len(userIDs)),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := make(map[string]lockedWallet, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var wallet lockedWallet
+		if err := rows.Scan(&userID, &wallet.balance, &wallet.held, &wallet.status, &wallet.currency, &wallet.overdraftLimit, &wallet.version); err != nil {
+			return nil, err
+		}
+		wallets[userID] = wallet
+	}
+	return wallets, rows.Err()
+}
+
+// balanceChange adds delta to the balance of a wallet read at version
+type balanceChange struct {
+	userID  string
+	delta   decimal.Decimal
+	version int64
+}
+
+// swapBalances applies changes inside tx if none of their wallets changed
+// since they were read, and fails with errVersionConflict otherwise. Wallets
+// are updated in user ID order, like lockWallets locks them, so movements
+// updating the same wallets in opposite directions cannot deadlock.
+func swapBalances(ctx context.Context, tx ledgerTx, changes ...balanceChange) error {
+	slices.SortFunc(changes, func(a, b balanceChange) int { return strings.Compare(a.userID, b.userID) })
+	for _, change := range changes {
+		result, err := tx.Exec(ctx,
+			"UPDATE wallets SET balance = balance + $1 WHERE user_id = $2 AND version = $3",
+			change.delta, change.userID, change.version,
+		)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return errVersionConflict
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/operation"
+)
+
+// OutboxRepository gives access to events recorded in the transactional
+// outbox. Events are written by the other repositories in the same DB
+// transaction as the state change they describe.
+type OutboxRepository interface {
+	Dispatch(ctx context.Context, limit int, publish func(context.Context, events.Event) error) (int, error)
+}
+
+type PostgresOutboxRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewOutboxRepository(db *sql.DB, logger *logrus.Logger) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db, logger: logger}
+}
+
+// Dispatch hands up to limit unpublished events to publish in the order they
+// were recorded and marks the delivered ones as published. Dispatching stops
+// at the first failure so ordering is preserved; the failed event is retried
+// on the next call. Rows are locked while publishing so concurrent
+// dispatchers never deliver the same event. Money movements of users in
+// digest mode name them in Digests and join their next digest.
+func (r *PostgresOutboxRepository) Dispatch(ctx context.Context, limit int, publish func(context.Context, events.Event) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, event_id, type, operation, payload, created_at
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Query pending events failed")
+		return 0, err
+	}
+
+	var ids []int64
+	var pending []events.Event
+	for rows.Next() {
+		var id int64
+		var op, payload []byte
+		var event events.Event
+		if err := rows.Scan(&id, &event.ID, &event.Type, &op, &payload, &event.OccurredAt); err != nil {
+			rows.Close()
+			r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Scan event failed")
+			return 0, err
+		}
+		if op != nil {
+			event.Operation = &operation.Operation{}
+			if err := json.Unmarshal(op, event.Operation); err != nil {
+				rows.Close()
+				r.logger.WithContext(ctx).WithError(err).WithField("eventID", event.ID).Error("Dispatch - Decode operation failed")
+				return 0, err
+			}
+		}
+		event.Data = json.RawMessage(payload)
+		ids = append(ids, id)
+		pending = append(pending, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Iterate events failed")
+		return 0, err
+	}
+
+	if err := markDigests(ctx, tx, pending); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Read notification preferences failed")
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	var digestItems [][]interface{}
+	for i, event := range pending {
+		if publishErr = publish(ctx, event); publishErr != nil {
+			r.logger.WithContext(ctx).WithError(publishErr).WithField("eventID", event.ID).Warn("Dispatch - Publish event failed")
+			_, err = tx.ExecContext(ctx,
+				"UPDATE outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = $2",
+				publishErr.Error(), ids[i],
+			)
+			if err != nil {
+				r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Record failure failed")
+				return 0, err
+			}
+			break
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1 WHERE id = $1",
+			ids[i],
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Mark event published failed")
+			return 0, err
+		}
+		for _, userID := range event.Digests {
+			digestItems = append(digestItems, []interface{}{userID, event.ID, event.Type, []byte(event.Data.(json.RawMessage)), event.OccurredAt})
+		}
+		published++
+	}
+
+	// Events join the digests of their users with their publication, so
+	// each is summarized exactly once
+	if len(digestItems) > 0 {
+		err = bulkExec(ctx, sqlTx{tx},
+			`INSERT INTO notification_digest_items (user_id, event_id, type, payload, occurred_at)
+			VALUES `,
+			digestItems,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Add events to digests failed")
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Dispatch - Commit DB transaction failed")
+		return 0, err
+	}
+
+	return published, publishErr
+}
+
+// markDigests lists in the Digests of every digestible event the users it
+// concerns who get their events in digests
+func markDigests(ctx context.Context, tx *sql.Tx, pending []events.Event) error {
+	recipients := make(map[string][]string, len(pending))
+	var candidates []interface{}
+	seen := map[string]bool{}
+	for _, event := range pending {
+		if !events.Digestible(event.Type) {
+			continue
+		}
+		var concerned struct {
+			UserID     string `json:"user_id"`
+			FromUserID string `json:"from_user_id"`
+			ToUserID   string `json:"to_user_id"`
+		}
+		if err := json.Unmarshal(event.Data.(json.RawMessage), &concerned); err != nil {
+			return err
+		}
+		for _, userID := range []string{concerned.UserID, concerned.FromUserID, concerned.ToUserID} {
+			if userID == "" || slices.Contains(recipients[event.ID], userID) {
+				continue
+			}
+			recipients[event.ID] = append(recipients[event.ID], userID)
+			if !seen[userID] {
+				seen[userID] = true
+				candidates = append(candidates, userID)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT user_id FROM notification_preferences WHERE user_id IN "+valuesList(1, len(candidates)),
+		candidates...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	digest := map[string]bool{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return err
+		}
+		digest[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, event := range pending {
+		for _, userID := range recipients[event.ID] {
+			if digest[userID] {
+				pending[i].Digests = append(pending[i].Digests, userID)
+			}
+		}
+	}
+	return nil
+}
+
+// enqueueEvent records event in the outbox as part of tx, together with the
+// operation in ctx
+func enqueueEvent(ctx context.Context, tx ledgerTx, event events.Event, aggregateID string) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	op, err := operationJSON(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO outbox_events (event_id, type, aggregate_id, operation, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.ID, event.Type, aggregateID, op, payload, event.OccurredAt,
+	)
+	return err
+}
+
+// enqueueEvents records events of one aggregate in the outbox inside tx with
+// multi-row inserts
+func enqueueEvents(ctx context.Context, tx ledgerTx, batch []events.Event, aggregateID string) error {
+	op, err := operationJSON(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(batch))
+	for _, event := range batch {
+		payload, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []interface{}{event.ID, event.Type, aggregateID, op, payload, event.OccurredAt})
+	}
+	return bulkExec(ctx, tx,
+		`INSERT INTO outbox_events (event_id, type, aggregate_id, operation, payload, created_at)
+		VALUES `,
+		rows,
+	)
+}
+
+// operationJSON encodes the operation in ctx for the outbox. It is nil, stored
+// as NULL, when ctx carries no operation.
+func operationJSON(ctx context.Context) ([]byte, error) {
+	op, ok := operation.From(ctx)
+	if !ok {
+		return nil, nil
+	}
+	return json.Marshal(op)
+}
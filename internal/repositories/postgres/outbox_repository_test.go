@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/operation"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewOutboxRepository(mockDB, logrus.New())
+	now := time.Now()
+	payload := `{"user_id":"user1","previous":{"status":"active"},"current":{"status":"frozen"},"reason":"INC-1"}`
+
+	pendingRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "event_id", "type", "operation", "payload", "created_at"}).
+			AddRow(1, "evt_1", events.TypeWalletFrozen, []byte(`{"actor":"admin1","channel":"admin","reason":"INC-1"}`), []byte(payload), now).
+			AddRow(2, "evt_2", events.TypeWalletCreated, nil, []byte(`{"user_id":"user2"}`), now)
+	}
+
+	t.Run("Dispatch", func(t *testing.T) {
+		t.Run("publishes in order", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, event_id, type, operation, payload, created_at`).WithArgs(10).WillReturnRows(pendingRows())
+			mock.ExpectExec(`UPDATE outbox_events SET published_at`).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE outbox_events SET published_at`).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			var got []events.Event
+			published, err := repo.Dispatch(ctx, 10, func(ctx context.Context, event events.Event) error {
+				got = append(got, event)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 2, published)
+			require.Equal(t, "evt_1", got[0].ID)
+			require.JSONEq(t, payload, string(got[0].Data.(json.RawMessage)))
+			require.Equal(t, &operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: "INC-1"}, got[0].Operation)
+			require.Nil(t, got[1].Operation)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("adds money movements to the digests of their users", func(t *testing.T) {
+			transfer := `{"from_user_id":"user1","to_user_id":"merchant1","amount":"10"}`
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, event_id, type, operation, payload, created_at`).WithArgs(10).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "type", "operation", "payload", "created_at"}).
+					AddRow(1, "evt_1", events.TypeTransferCompleted, nil, []byte(transfer), now).
+					AddRow(2, "evt_2", events.TypeWalletFrozen, nil, []byte(`{"user_id":"merchant1"}`), now).
+					AddRow(3, "evt_3", events.TypeWalletCredited, nil, []byte(`{"user_id":"user1","amount":"5"}`), now))
+			mock.ExpectQuery(`SELECT user_id FROM notification_preferences WHERE user_id IN \(\$1, \$2\)`).WithArgs("user1", "merchant1").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("merchant1"))
+			mock.ExpectExec(`UPDATE outbox_events SET published_at`).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE outbox_events SET published_at`).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE outbox_events SET published_at`).WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO notification_digest_items`).
+				WithArgs("merchant1", "evt_1", events.TypeTransferCompleted, []byte(transfer), now).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			var got []events.Event
+			published, err := repo.Dispatch(ctx, 10, func(ctx context.Context, event events.Event) error {
+				got = append(got, event)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 3, published)
+			require.Equal(t, []string{"merchant1"}, got[0].Digests)
+			// Lifecycle changes are always notified at once
+			require.Nil(t, got[1].Digests)
+			require.Nil(t, got[2].Digests)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("stops at first failure", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, event_id, type, operation, payload, created_at`).WithArgs(10).WillReturnRows(pendingRows())
+			mock.ExpectExec(`UPDATE outbox_events SET attempts`).WithArgs("broker unavailable", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			published, err := repo.Dispatch(ctx, 10, func(ctx context.Context, event events.Event) error {
+				return errors.New("broker unavailable")
+			})
+			require.ErrorContains(t, err, "broker unavailable")
+			require.Equal(t, 0, published)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// OverdraftRepository manages the credit lines of wallets, stored with the
+// wallet. Withdrawals and transfers draw on them in debitWallet and
+// moveFunds.
+type OverdraftRepository interface {
+	GetOverdraft(ctx context.Context, userID string) (*models.Overdraft, error)
+	SetOverdraftLimit(ctx context.Context, userID string, limit decimal.Decimal) (*models.Overdraft, error)
+}
+
+type PostgresOverdraftRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewOverdraftRepository(db *sql.DB, logger *logrus.Logger) *PostgresOverdraftRepository {
+	return &PostgresOverdraftRepository{db: db, logger: logger}
+}
+
+// GetOverdraft returns the credit line of the wallet of userID and how much
+// of it is used
+func (r *PostgresOverdraftRepository) GetOverdraft(ctx context.Context, userID string) (*models.Overdraft, error) {
+	overdraft := &models.Overdraft{UserID: userID}
+	var balance decimal.Decimal
+	err := r.db.QueryRowContext(ctx,
+		"SELECT balance, overdraft_limit FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&balance, &overdraft.Limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetOverdraft - Query wallet failed")
+		return nil, err
+	}
+
+	overdraft.Used = models.OverdraftUsed(balance)
+	return overdraft, nil
+}
+
+// SetOverdraftLimit sets the credit line of the wallet of userID and records
+// the wallet.overdraft_changed event in the same transaction. Lowering the
+// limit below what is used only stops further draws. Closed wallets fail
+// with ErrWalletClosed.
+func (r *PostgresOverdraftRepository) SetOverdraftLimit(ctx context.Context, userID string, limit decimal.Decimal) (*models.Overdraft, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"limit":  limit,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SetOverdraftLimit - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var balance, previous decimal.Decimal
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, overdraft_limit, status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&balance, &previous, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("SetOverdraftLimit - Cannot find wallet in the database")
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetOverdraftLimit - Lock wallet failed")
+		return nil, err
+	}
+
+	if status == models.WalletStatusClosed {
+		logger.Warn("SetOverdraftLimit - Wallet is closed")
+		return nil, ErrWalletClosed
+	}
+
+	overdraft := &models.Overdraft{UserID: userID, Limit: limit, Used: models.OverdraftUsed(balance)}
+	if previous.Equal(limit) {
+		return overdraft, nil
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET overdraft_limit = $1 WHERE user_id = $2",
+		limit, userID,
+	); err != nil {
+		logger.WithError(err).Error("SetOverdraftLimit - Update wallet failed")
+		return nil, err
+	}
+
+	event := events.New(events.TypeWalletOverdraftChanged, events.WalletOverdraftChanged{
+		UserID:        userID,
+		PreviousLimit: previous,
+		Limit:         limit,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+		logger.WithError(err).Error("SetOverdraftLimit - Record overdraft changed event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SetOverdraftLimit - Commit DB transaction failed")
+		return nil, err
+	}
+	return overdraft, nil
+}
+
+// recordDrawdown records against transactionID the part of debit that the
+// credit line of a wallet holding balance paid for, if any
+func recordDrawdown(ctx context.Context, tx ledgerTx, transactionID string, balance, debit decimal.Decimal) error {
+	drawn := decimal.Min(debit, models.OverdraftUsed(balance.Sub(debit)))
+	if !drawn.IsPositive() {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		"INSERT INTO overdraft_drawdowns (transaction_id, amount) VALUES ($1, $2)",
+		transactionID, drawn,
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestOverdraftRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewOverdraftRepository(mockDB, logrus.New())
+	lockColumns := []string{"balance", "overdraft_limit", "status"}
+
+	t.Run("GetOverdraft", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT balance, overdraft_limit FROM wallets`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "overdraft_limit"}).AddRow("-40", "100"))
+
+		overdraft, err := repo.GetOverdraft(ctx, "user1")
+		require.NoError(t, err)
+		require.True(t, decimal.NewFromInt(100).Equal(overdraft.Limit))
+		require.True(t, decimal.NewFromInt(40).Equal(overdraft.Used))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetOverdraft unknown wallet", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT balance, overdraft_limit FROM wallets`).WithArgs("ghost").
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "overdraft_limit"}))
+
+		_, err := repo.GetOverdraft(ctx, "ghost")
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetOverdraftLimit records an event", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(lockColumns).AddRow("25", "0", models.WalletStatusActive))
+		mock.ExpectExec(`UPDATE wallets SET overdraft_limit = \$1`).WithArgs(decimal.NewFromInt(500), "user1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), events.TypeWalletOverdraftChanged, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","previous_limit":"0","limit":"500"}`), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		overdraft, err := repo.SetOverdraftLimit(ctx, "user1", decimal.NewFromInt(500))
+		require.NoError(t, err)
+		require.True(t, overdraft.Used.IsZero())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetOverdraftLimit unchanged", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(lockColumns).AddRow("25", "500", models.WalletStatusActive))
+		mock.ExpectRollback()
+
+		_, err := repo.SetOverdraftLimit(ctx, "user1", decimal.NewFromInt(500))
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetOverdraftLimit closed wallet", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(lockColumns).AddRow("0", "0", models.WalletStatusClosed))
+		mock.ExpectRollback()
+
+		_, err := repo.SetOverdraftLimit(ctx, "user1", decimal.NewFromInt(500))
+		require.ErrorIs(t, err, ErrWalletClosed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
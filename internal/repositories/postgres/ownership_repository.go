@@ -0,0 +1,378 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+type OwnershipRepository interface {
+	ReassignWallet(ctx context.Context, change *models.OwnershipChange) error
+	MergeWallets(ctx context.Context, merge *models.WalletMerge) error
+}
+
+var (
+	ErrWalletExists     = errors.New("a wallet already exists for the new user ID")
+	ErrPendingTransfers = errors.New("wallet has pending transfers")
+)
+
+// userReferences lists every column outside wallets that refers to a wallet
+// owner and is rewritten when the wallet is reassigned. Counterparty
+// exposures are left alone, the exposure job rebuilds them.
+var userReferences = []struct{ table, column string }{
+	{"transactions", "from_user_id"},
+	{"transactions", "to_user_id"},
+	{"holds", "from_user_id"},
+	{"holds", "to_user_id"},
+	{"transfer_batches", "sender_id"},
+	{"transfer_batch_items", "receiver_id"},
+	{"freeze_job_wallets", "user_id"},
+	{"idempotency_keys", "user_id"},
+	{"wallet_status_changes", "user_id"},
+	{"wallet_sequences", "user_id"},
+	{"wallet_sync_sequences", "user_id"},
+	{"transaction_sequences", "user_id"},
+	{"deposit_queue", "user_id"},
+	{"withdrawals", "user_id"},
+	{"balance_adjustments", "user_id"},
+	{"transaction_limits", "user_id"},
+	{"payment_requests", "requester_id"},
+	{"payment_requests", "payer_id"},
+	{"transfer_confirmations", "sender_id"},
+	{"transfer_confirmations", "receiver_id"},
+}
+
+type PostgresOwnershipRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewOwnershipRepository(db *sql.DB, logger *logrus.Logger) *PostgresOwnershipRepository {
+	return &PostgresOwnershipRepository{db: db, logger: logger}
+}
+
+// ReassignWallet moves the wallet of change.PreviousUserID, with its whole
+// history, to change.NewUserID. The audit record and the ownership-changed
+// event are written in the same transaction; change.ID and CreatedAt are
+// filled in on success.
+func (r *PostgresOwnershipRepository) ReassignWallet(ctx context.Context, change *models.OwnershipChange) error {
+	if change.PreviousUserID == "" || change.NewUserID == "" || change.PreviousUserID == change.NewUserID {
+		r.logger.WithContext(ctx).Warn("ReassignWallet - previous and new user IDs must be distinct non-empty strings")
+		return ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"previousUserID": change.PreviousUserID,
+		"newUserID":      change.NewUserID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		change.PreviousUserID,
+	).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("ReassignWallet - Cannot find wallet in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Query wallet failed")
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)",
+		change.NewUserID,
+	).Scan(&exists)
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Query new user wallet failed")
+		return err
+	}
+	if exists {
+		logger.Warn("ReassignWallet - New user ID already has a wallet")
+		return ErrWalletExists
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET user_id = $1 WHERE user_id = $2",
+		change.NewUserID, change.PreviousUserID,
+	)
+	if isUnregisteredUser(err) {
+		logger.Warn("ReassignWallet - New user is not registered")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Update wallet failed")
+		return err
+	}
+
+	for _, ref := range userReferences {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE "+ref.table+" SET "+ref.column+" = $1 WHERE "+ref.column+" = $2",
+			change.NewUserID, change.PreviousUserID,
+		)
+		if err != nil {
+			logger.WithError(err).WithField("table", ref.table).Error("ReassignWallet - Update history failed")
+			return err
+		}
+	}
+
+	// Wallet-scoped settings follow the wallet
+	_, err = tx.ExecContext(ctx,
+		"UPDATE settings SET scope_id = $1 WHERE scope = $2 AND scope_id = $3",
+		change.NewUserID, models.SettingScopeWallet, change.PreviousUserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Update wallet settings failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wallet_ownership_changes (previous_user_id, new_user_id, reason, actor)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id::text, created_at`,
+		change.PreviousUserID, change.NewUserID, change.Reason, change.Actor,
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("ReassignWallet - Create audit record failed")
+		return err
+	}
+
+	event := events.New(events.TypeWalletOwnershipChanged, events.WalletOwnershipChanged{
+		PreviousUserID: change.PreviousUserID,
+		NewUserID:      change.NewUserID,
+		Reason:         change.Reason,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, change.NewUserID); err != nil {
+		logger.WithError(err).Error("ReassignWallet - Record ownership changed event failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("ReassignWallet - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("actor", change.Actor).Info("Wallet reassigned")
+	return nil
+}
+
+// MergeWallets merges the duplicate wallet merge.SourceUserID into
+// merge.TargetUserID: the source history is re-linked to the target with a
+// merged_from marker, the source balance moves to the target through an
+// internal transfer and the source wallet is closed. The report is filled in
+// and stored on success.
+func (r *PostgresOwnershipRepository) MergeWallets(ctx context.Context, merge *models.WalletMerge) error {
+	if merge.SourceUserID == "" || merge.TargetUserID == "" || merge.SourceUserID == merge.TargetUserID {
+		r.logger.WithContext(ctx).Warn("MergeWallets - source and target user IDs must be distinct non-empty strings")
+		return ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"sourceUserID": merge.SourceUserID,
+		"targetUserID": merge.TargetUserID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("MergeWallets - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock both wallets in a fixed order so concurrent merges cannot deadlock
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_id, balance, status FROM wallets
+		WHERE user_id IN ($1, $2)
+		ORDER BY user_id
+		FOR UPDATE`,
+		merge.SourceUserID, merge.TargetUserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("MergeWallets - Query wallets failed")
+		return err
+	}
+	balances := make(map[string]decimal.Decimal, 2)
+	statuses := make(map[string]string, 2)
+	for rows.Next() {
+		var userID, status string
+		var balance decimal.Decimal
+		if err := rows.Scan(&userID, &balance, &status); err != nil {
+			rows.Close()
+			logger.WithError(err).Error("MergeWallets - Scan wallets failed")
+			return err
+		}
+		balances[userID] = balance
+		statuses[userID] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("MergeWallets - Iterate wallets failed")
+		return err
+	}
+
+	for _, userID := range []string{merge.SourceUserID, merge.TargetUserID} {
+		status, ok := statuses[userID]
+		if !ok {
+			logger.WithField("userID", userID).Warn("MergeWallets - Cannot find wallet in the database")
+			return ErrUserNotFound
+		}
+		if err := statusError(status); err != nil {
+			logger.WithField("userID", userID).Warn("MergeWallets - Wallet is not active")
+			return err
+		}
+	}
+
+	var pending bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM holds
+			WHERE status = $1 AND (from_user_id = $2 OR to_user_id = $2)
+		) OR EXISTS (
+			SELECT 1 FROM withdrawals
+			WHERE user_id = $2 AND status IN ($3, $4)
+		)`,
+		models.HoldPending, merge.SourceUserID, models.WithdrawalRequested, models.WithdrawalProcessing,
+	).Scan(&pending)
+	if err != nil {
+		logger.WithError(err).Error("MergeWallets - Query pending transfers failed")
+		return err
+	}
+	if pending {
+		logger.Warn("MergeWallets - Source wallet has pending transfers")
+		return ErrPendingTransfers
+	}
+
+	merge.Amount = balances[merge.SourceUserID]
+	merge.TransferTransactionID = nil
+	if merge.Amount.IsPositive() {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE wallets SET balance = balance + $1 WHERE user_id = $2",
+			merge.Amount, merge.TargetUserID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Update target balance failed")
+			return err
+		}
+
+		var transactionID string
+		actor, channel := provenance(ctx)
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO transactions 
+			(from_user_id, to_user_id, amount, type, created_at, actor, channel) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`,
+			merge.SourceUserID, merge.TargetUserID, merge.Amount, "transfer", time.Now(), actor, channel,
+		).Scan(&transactionID)
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Create transaction record failed")
+			return err
+		}
+		merge.TransferTransactionID = &transactionID
+
+		fromSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, merge.SourceUserID)
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Assign source sequence number failed")
+			return err
+		}
+		toSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, merge.TargetUserID)
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Assign target sequence number failed")
+			return err
+		}
+
+		event := events.New(events.TypeTransferCompleted, events.TransferCompleted{
+			FromUserID:    merge.SourceUserID,
+			ToUserID:      merge.TargetUserID,
+			Amount:        merge.Amount,
+			TransactionID: transactionID,
+			FromSequence:  fromSequence,
+			ToSequence:    toSequence,
+		})
+		if err = enqueueEvent(ctx, sqlTx{tx}, event, merge.SourceUserID); err != nil {
+			logger.WithError(err).Error("MergeWallets - Record transfer completed event failed")
+			return err
+		}
+	}
+
+	// The merge transfer is re-linked as well, so in the ledger it nets to zero
+	// on the target, whose re-linked history already adds up to the moved
+	// balance. Replaying transactions keeps matching wallet balances.
+	merge.TransactionsRelinked = 0
+	for _, column := range []string{"from_user_id", "to_user_id"} {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE transactions SET "+column+" = $1, merged_from = $2 WHERE "+column+" = $2",
+			merge.TargetUserID, merge.SourceUserID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Re-link transactions failed")
+			return err
+		}
+		relinked, err := result.RowsAffected()
+		if err != nil {
+			logger.WithError(err).Error("MergeWallets - Count re-linked transactions failed")
+			return err
+		}
+		merge.TransactionsRelinked += relinked
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET balance = 0, status = $1 WHERE user_id = $2",
+		models.WalletStatusClosed, merge.SourceUserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("MergeWallets - Close source wallet failed")
+		return err
+	}
+
+	event := events.New(events.TypeWalletClosed, events.WalletLifecycleChanged{
+		UserID:   merge.SourceUserID,
+		Previous: &events.WalletState{Status: models.WalletStatusActive},
+		Current:  events.WalletState{Status: models.WalletStatusClosed},
+		Reason:   &merge.Reason,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, merge.SourceUserID); err != nil {
+		logger.WithError(err).Error("MergeWallets - Record wallet closed event failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wallet_merges
+		(source_user_id, target_user_id, amount, transfer_transaction_id, transactions_relinked, reason, actor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id::text, created_at`,
+		merge.SourceUserID, merge.TargetUserID, merge.Amount, merge.TransferTransactionID,
+		merge.TransactionsRelinked, merge.Reason, merge.Actor,
+	).Scan(&merge.ID, &merge.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("MergeWallets - Create merge report failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("MergeWallets - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"amount":   merge.Amount,
+		"relinked": merge.TransactionsRelinked,
+		"actor":    merge.Actor,
+	}).Info("Wallets merged")
+	return nil
+}
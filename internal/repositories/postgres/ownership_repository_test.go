@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestOwnershipRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewOwnershipRepository(mockDB, logrus.New())
+
+	t.Run("success", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user9").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(`UPDATE wallets SET user_id = \$1`).WithArgs("user9", "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, ref := range userReferences {
+			mock.ExpectExec(`UPDATE `+ref.table+` SET `+ref.column).WithArgs("user9", "user1").WillReturnResult(sqlmock.NewResult(0, 2))
+		}
+		mock.ExpectExec(`UPDATE settings SET scope_id`).WithArgs("user9", models.SettingScopeWallet, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO wallet_ownership_changes`).WithArgs("user1", "user9", "account merge", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("3", now))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletOwnershipChanged, "user9", sqlmock.AnyArg(), []byte(`{"previous_user_id":"user1","new_user_id":"user9","reason":"account merge"}`), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		change := &models.OwnershipChange{PreviousUserID: "user1", NewUserID: "user9", Reason: "account merge", Actor: "admin1"}
+		require.NoError(t, repo.ReassignWallet(ctx, change))
+		require.Equal(t, "3", change.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new user ID taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user2").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := repo.ReassignWallet(ctx, &models.OwnershipChange{PreviousUserID: "user1", NewUserID: "user2"})
+		require.ErrorIs(t, err, ErrWalletExists)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("ghost").WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := repo.ReassignWallet(ctx, &models.OwnershipChange{PreviousUserID: "ghost", NewUserID: "user2"})
+		require.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("same user ID", func(t *testing.T) {
+		err := repo.ReassignWallet(ctx, &models.OwnershipChange{PreviousUserID: "user1", NewUserID: "user1"})
+		require.ErrorIs(t, err, ErrInvalidUserID)
+	})
+
+	t.Run("MergeWallets", func(t *testing.T) {
+		walletColumns := []string{"user_id", "balance", "status"}
+
+		t.Run("success", func(t *testing.T) {
+			now := time.Now()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, status FROM wallets`).WithArgs("user1", "user9").
+				WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("user1", "30", "active").AddRow("user9", "5", "active"))
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(models.HoldPending, "user1", models.WithdrawalRequested, models.WithdrawalProcessing).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(decimal.NewFromInt(30), "user9").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", "user9", decimal.NewFromInt(30), "transfer", sqlmock.AnyArg(), nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("12"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "12").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(5))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user9", "12").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeTransferCompleted, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(`UPDATE transactions SET from_user_id = \$1, merged_from = \$2`).WithArgs("user9", "user1").WillReturnResult(sqlmock.NewResult(0, 4))
+			mock.ExpectExec(`UPDATE transactions SET to_user_id = \$1, merged_from = \$2`).WithArgs("user9", "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE wallets SET balance = 0, status = \$1`).WithArgs(models.WalletStatusClosed, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletClosed, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","previous":{"status":"active"},"current":{"status":"closed"},"reason":"duplicate"}`), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`INSERT INTO wallet_merges`).WithArgs("user1", "user9", decimal.NewFromInt(30), sqlmock.AnyArg(), int64(5), "duplicate", "admin1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("2", now))
+			mock.ExpectCommit()
+
+			merge := &models.WalletMerge{SourceUserID: "user1", TargetUserID: "user9", Reason: "duplicate", Actor: "admin1"}
+			require.NoError(t, repo.MergeWallets(ctx, merge))
+			require.Equal(t, "2", merge.ID)
+			require.Equal(t, int64(5), merge.TransactionsRelinked)
+			require.Equal(t, "12", *merge.TransferTransactionID)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("pending transfers", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, status FROM wallets`).WithArgs("user1", "user9").
+				WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("user1", "30", "active").AddRow("user9", "5", "active"))
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(models.HoldPending, "user1", models.WithdrawalRequested, models.WithdrawalProcessing).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.MergeWallets(ctx, &models.WalletMerge{SourceUserID: "user1", TargetUserID: "user9"})
+			require.ErrorIs(t, err, ErrPendingTransfers)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("target not found", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, status FROM wallets`).WithArgs("user1", "ghost").
+				WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("user1", "30", "active"))
+			mock.ExpectRollback()
+
+			err := repo.MergeWallets(ctx, &models.WalletMerge{SourceUserID: "user1", TargetUserID: "ghost"})
+			require.ErrorIs(t, err, ErrUserNotFound)
+		})
+
+		t.Run("closed source", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id, balance, status FROM wallets`).WithArgs("user1", "user9").
+				WillReturnRows(sqlmock.NewRows(walletColumns).AddRow("user1", "0", "closed").AddRow("user9", "5", "active"))
+			mock.ExpectRollback()
+
+			err := repo.MergeWallets(ctx, &models.WalletMerge{SourceUserID: "user1", TargetUserID: "user9"})
+			require.ErrorIs(t, err, ErrWalletClosed)
+		})
+	})
+}
@@ -0,0 +1,335 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// PaymentRequestRepository stores the payment requests users send each other
+// and records their outcome together with the event notifying it
+type PaymentRequestRepository interface {
+	CreatePaymentRequest(ctx context.Context, request *models.PaymentRequest) error
+	GetPaymentRequest(ctx context.Context, userID, requestID string) (*models.PaymentRequest, error)
+	ListPaymentRequests(ctx context.Context, userID, side, status string, limit int) ([]models.PaymentRequest, error)
+	DecidePaymentRequest(ctx context.Context, payerID, requestID, status string) (*models.PaymentRequest, error)
+	ExpirePaymentRequests(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+var (
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPending = errors.New("payment request is not pending")
+)
+
+const paymentRequestColumns = `id::text, requester_id, payer_id, amount, note, status, created_at, expires_at, decided_at`
+
+type PostgresPaymentRequestRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewPaymentRequestRepository(db *sql.DB, logger *logrus.Logger) *PostgresPaymentRequestRepository {
+	return &PostgresPaymentRequestRepository{db: db, logger: logger}
+}
+
+// CreatePaymentRequest records a pending request and the event notifying the
+// payer, filling in its ID, status and creation time. The payer must have a
+// wallet.
+func (r *PostgresPaymentRequestRepository) CreatePaymentRequest(ctx context.Context, request *models.PaymentRequest) error {
+	if request.RequesterID == "" || request.PayerID == "" {
+		r.logger.WithContext(ctx).Warn("CreatePaymentRequest - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if request.RequesterID == request.PayerID {
+		r.logger.WithContext(ctx).Warn("CreatePaymentRequest - requesterID and payerID cannot be the same")
+		return ErrInvalidUserID
+	}
+
+	if !request.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("CreatePaymentRequest - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"requesterID": request.RequesterID,
+		"payerID":     request.PayerID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreatePaymentRequest - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)",
+		request.PayerID,
+	).Scan(&exists)
+	if err != nil {
+		logger.WithError(err).Error("CreatePaymentRequest - Query payer wallet failed")
+		return err
+	}
+	if !exists {
+		logger.Warn("CreatePaymentRequest - Cannot find payer wallet in the database")
+		return ErrUserNotFound
+	}
+
+	request.Status = models.PaymentRequestPending
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO payment_requests (requester_id, payer_id, amount, note, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id::text, created_at`,
+		request.RequesterID, request.PayerID, request.Amount, request.Note, request.Status, request.ExpiresAt,
+	).Scan(&request.ID, &request.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreatePaymentRequest - Create request failed")
+		return err
+	}
+
+	if err = enqueueEvent(ctx, sqlTx{tx}, paymentRequestEvent(request), request.PayerID); err != nil {
+		logger.WithError(err).Error("CreatePaymentRequest - Record payment request event failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreatePaymentRequest - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"requestID": request.ID,
+		"expiresAt": request.ExpiresAt,
+	}).Info("Payment requested")
+	return nil
+}
+
+// GetPaymentRequest returns the request requestID sent or received by userID
+func (r *PostgresPaymentRequestRepository) GetPaymentRequest(ctx context.Context, userID, requestID string) (*models.PaymentRequest, error) {
+	request, err := scanPaymentRequest(r.db.QueryRowContext(ctx,
+		`SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE id::text = $1 AND (requester_id = $2 OR payer_id = $2)`,
+		requestID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("requestID", requestID).Error("GetPaymentRequest - Query request failed")
+		return nil, err
+	}
+	return request, nil
+}
+
+// ListPaymentRequests returns up to limit requests of userID, newest first:
+// those it received when side is incoming, those it sent when outgoing, and
+// both when empty. An empty status matches every request.
+func (r *PostgresPaymentRequestRepository) ListPaymentRequests(ctx context.Context, userID, side, status string, limit int) ([]models.PaymentRequest, error) {
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListPaymentRequests - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE (($2 <> 'outgoing' AND payer_id = $1) OR ($2 <> 'incoming' AND requester_id = $1))
+			AND ($3 = '' OR status = $3)
+		ORDER BY id DESC
+		LIMIT $4`,
+		userID, side, status, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListPaymentRequests - Query requests failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.PaymentRequest{}
+	for rows.Next() {
+		request, err := scanPaymentRequest(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListPaymentRequests - Scan requests failed")
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListPaymentRequests - Iterate requests failed")
+		return nil, err
+	}
+	return requests, nil
+}
+
+// DecidePaymentRequest moves a pending request received by payerID to status
+// and records the event notifying the requester. It does not move funds.
+func (r *PostgresPaymentRequestRepository) DecidePaymentRequest(ctx context.Context, payerID, requestID, status string) (*models.PaymentRequest, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"requestID": requestID,
+		"status":    status,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("DecidePaymentRequest - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	request, err := scanPaymentRequest(tx.QueryRowContext(ctx,
+		`SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE id::text = $1 AND payer_id = $2
+		FOR UPDATE`,
+		requestID, payerID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("DecidePaymentRequest - Cannot find request in the database")
+		return nil, ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("DecidePaymentRequest - Query request failed")
+		return nil, err
+	}
+	if request.Status != models.PaymentRequestPending {
+		logger.WithField("current", request.Status).Warn("DecidePaymentRequest - Request already decided")
+		return nil, ErrPaymentRequestNotPending
+	}
+
+	request.Status = status
+	err = tx.QueryRowContext(ctx,
+		`UPDATE payment_requests SET status = $1, decided_at = NOW()
+		WHERE id::text = $2
+		RETURNING decided_at`,
+		status, requestID,
+	).Scan(&request.DecidedAt)
+	if err != nil {
+		logger.WithError(err).Error("DecidePaymentRequest - Update request failed")
+		return nil, err
+	}
+
+	if err = enqueueEvent(ctx, sqlTx{tx}, paymentRequestEvent(request), request.RequesterID); err != nil {
+		logger.WithError(err).Error("DecidePaymentRequest - Record payment request event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("DecidePaymentRequest - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.Info("Payment request decided")
+	return request, nil
+}
+
+// ExpirePaymentRequests expires up to limit pending requests whose expiry is
+// before before, oldest first, and records the events notifying their
+// requesters. Requests locked by a concurrent decision are skipped.
+func (r *PostgresPaymentRequestRepository) ExpirePaymentRequests(ctx context.Context, before time.Time, limit int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`UPDATE payment_requests SET status = $1, decided_at = NOW()
+		WHERE id IN (
+			SELECT id FROM payment_requests
+			WHERE status = $2 AND expires_at <= $3
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+paymentRequestColumns,
+		models.PaymentRequestExpired, models.PaymentRequestPending, before, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Expire requests failed")
+		return 0, err
+	}
+
+	var expired []models.PaymentRequest
+	for rows.Next() {
+		request, err := scanPaymentRequest(rows)
+		if err != nil {
+			rows.Close()
+			r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Scan requests failed")
+			return 0, err
+		}
+		expired = append(expired, *request)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Iterate requests failed")
+		return 0, err
+	}
+
+	for i := range expired {
+		if err = enqueueEvent(ctx, sqlTx{tx}, paymentRequestEvent(&expired[i]), expired[i].RequesterID); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Record payment request event failed")
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpirePaymentRequests - Commit DB transaction failed")
+		return 0, err
+	}
+
+	if len(expired) > 0 {
+		r.logger.WithContext(ctx).WithField("expired", len(expired)).Info("Payment requests expired")
+	}
+	return len(expired), nil
+}
+
+func paymentRequestEvent(request *models.PaymentRequest) events.Event {
+	eventType := events.TypePaymentRequestCreated
+	switch request.Status {
+	case models.PaymentRequestAccepted:
+		eventType = events.TypePaymentRequestAccepted
+	case models.PaymentRequestDeclined:
+		eventType = events.TypePaymentRequestDeclined
+	case models.PaymentRequestExpired:
+		eventType = events.TypePaymentRequestExpired
+	}
+
+	return events.New(eventType, events.PaymentRequestChanged{
+		RequestID:   request.ID,
+		RequesterID: request.RequesterID,
+		PayerID:     request.PayerID,
+		Amount:      request.Amount,
+		Note:        request.Note,
+		Status:      request.Status,
+		ExpiresAt:   request.ExpiresAt,
+	})
+}
+
+func scanPaymentRequest(row rowScanner) (*models.PaymentRequest, error) {
+	var request models.PaymentRequest
+	err := row.Scan(
+		&request.ID,
+		&request.RequesterID,
+		&request.PayerID,
+		&request.Amount,
+		&request.Note,
+		&request.Status,
+		&request.CreatedAt,
+		&request.ExpiresAt,
+		&request.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
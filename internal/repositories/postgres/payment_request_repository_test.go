@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestPaymentRequestRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewPaymentRequestRepository(mockDB, logrus.New())
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	columns := []string{"id", "requester_id", "payer_id", "amount", "note", "status", "created_at", "expires_at", "decided_at"}
+
+	t.Run("CreatePaymentRequest notifies the payer", func(t *testing.T) {
+		request := &models.PaymentRequest{RequesterID: "user1", PayerID: "user2", Amount: decimal.NewFromInt(20), Note: "Dinner", ExpiresAt: expiresAt}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM wallets WHERE user_id = \$1\)`).WithArgs("user2").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`INSERT INTO payment_requests`).
+			WithArgs("user1", "user2", request.Amount, "Dinner", models.PaymentRequestPending, expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("9", now))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), "payment_request.created", "user2", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.CreatePaymentRequest(ctx, request))
+		require.Equal(t, "9", request.ID)
+		require.Equal(t, models.PaymentRequestPending, request.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreatePaymentRequest to an unknown payer", func(t *testing.T) {
+		request := &models.PaymentRequest{RequesterID: "user1", PayerID: "ghost", Amount: decimal.NewFromInt(20), ExpiresAt: expiresAt}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("ghost").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, repo.CreatePaymentRequest(ctx, request), ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreatePaymentRequest to oneself", func(t *testing.T) {
+		request := &models.PaymentRequest{RequesterID: "user1", PayerID: "user1", Amount: decimal.NewFromInt(20)}
+		require.ErrorIs(t, repo.CreatePaymentRequest(ctx, request), ErrInvalidUserID)
+	})
+
+	t.Run("DecidePaymentRequest notifies the requester", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM payment_requests\s+WHERE id::text = \$1 AND payer_id = \$2\s+FOR UPDATE`).WithArgs("9", "user2").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("9", "user1", "user2", "20", "Dinner", models.PaymentRequestPending, now, expiresAt, nil))
+		mock.ExpectQuery(`UPDATE payment_requests SET status = \$1, decided_at = NOW\(\)`).WithArgs(models.PaymentRequestDeclined, "9").
+			WillReturnRows(sqlmock.NewRows([]string{"decided_at"}).AddRow(now))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), "payment_request.declined", "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		request, err := repo.DecidePaymentRequest(ctx, "user2", "9", models.PaymentRequestDeclined)
+		require.NoError(t, err)
+		require.Equal(t, models.PaymentRequestDeclined, request.Status)
+		require.NotNil(t, request.DecidedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DecidePaymentRequest already decided", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM payment_requests`).WithArgs("9", "user2").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("9", "user1", "user2", "20", "", models.PaymentRequestExpired, now, expiresAt, now))
+		mock.ExpectRollback()
+
+		_, err := repo.DecidePaymentRequest(ctx, "user2", "9", models.PaymentRequestAccepted)
+		require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpirePaymentRequests", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE payment_requests SET status = \$1.+FOR UPDATE SKIP LOCKED`).
+			WithArgs(models.PaymentRequestExpired, models.PaymentRequestPending, now, 100).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("9", "user1", "user2", "20", "", models.PaymentRequestExpired, now, now, now).
+				AddRow("10", "user3", "user2", "5", "", models.PaymentRequestExpired, now, now, now))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), "payment_request.expired", "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), "payment_request.expired", "user3", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		expired, err := repo.ExpirePaymentRequests(ctx, now, 100)
+		require.NoError(t, err)
+		require.Equal(t, 2, expired)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// PoolConfig sizes the connection pool shared by the repositories
+type PoolConfig struct {
+	MaxConns int32
+	// Connections kept open even when idle, zero to open them on demand
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// Prepared statements cached per connection. Zero disables preparing
+	// statements, as required behind PgBouncer in transaction pooling mode.
+	StatementCacheCapacity int
+}
+
+// OpenPool creates a pgx pool for connString and a *sql.DB drawing its
+// connections from it, which the repositories are built on; WithPool hands the
+// pool to the wallet repository for its hot paths. Queries run
+// through either are prepared once per connection and reused from its
+// statement cache, so the hot balance and transaction queries skip parsing
+// and planning after their first execution. Closing the *sql.DB does not
+// close the pool.
+func OpenPool(ctx context.Context, connString string, cfg PoolConfig) (*pgxpool.Pool, *sql.DB, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	poolConfig.MinConns = cfg.MinConns
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	poolConfig.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	if cfg.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	} else {
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pool, stdlib.OpenDBFromPool(pool), nil
+}
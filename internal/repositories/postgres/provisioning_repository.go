@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// ProvisioningRepository creates wallets before their first deposit
+type ProvisioningRepository interface {
+	ProvisionWallet(ctx context.Context, wallet models.NewWallet) (*models.Wallet, error)
+}
+
+var ErrWalletAlreadyExists = errors.New("wallet already exists")
+
+type PostgresProvisioningRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewProvisioningRepository(db *sql.DB, logger *logrus.Logger) *PostgresProvisioningRepository {
+	return &PostgresProvisioningRepository{db: db, logger: logger}
+}
+
+// ProvisionWallet creates the empty wallet of wallet.UserID, in its
+// currency, status and metadata, and records the wallet.created event in the
+// same transaction. The currency must have been created by the bootstrap
+// command.
+func (r *PostgresProvisioningRepository) ProvisionWallet(ctx context.Context, newWallet models.NewWallet) (*models.Wallet, error) {
+	userID, currency := newWallet.UserID, newWallet.Currency
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("ProvisionWallet - userID cannot be an empty string")
+		return nil, ErrInvalidUserID
+	}
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	metadata := newWallet.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	// A map of strings always encodes
+	rawMetadata, _ := json.Marshal(metadata)
+	var metadataVersion int64
+	if len(metadata) > 0 {
+		metadataVersion = 1
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ProvisionWallet - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if currency != nil {
+		var known bool
+		err = tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM currencies WHERE code = $1)",
+			*currency,
+		).Scan(&known)
+		if err != nil {
+			logger.WithError(err).Error("ProvisionWallet - Query currency failed")
+			return nil, err
+		}
+		if !known {
+			logger.WithField("currency", *currency).Warn("ProvisionWallet - Cannot find currency in the database")
+			return nil, ErrCurrencyNotFound
+		}
+	}
+
+	wallet := &models.Wallet{UserID: userID}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wallets (user_id, currency, status, metadata, metadata_version, metadata_updated_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $5 > 0 THEN NOW() END)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING balance, held, status, currency`,
+		userID, currency, newWallet.Status, rawMetadata, metadataVersion,
+	).Scan(&wallet.Balance, &wallet.Held, &wallet.Status, &wallet.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("ProvisionWallet - Wallet already exists")
+		return nil, ErrWalletAlreadyExists
+	}
+	if isUnregisteredUser(err) {
+		logger.Warn("ProvisionWallet - User is not registered")
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("ProvisionWallet - Create wallet failed")
+		return nil, err
+	}
+	if len(newWallet.Metadata) > 0 {
+		wallet.Metadata = newWallet.Metadata
+	}
+
+	state := events.WalletState{Status: wallet.Status}
+	if wallet.Currency != nil {
+		state.Currency = *wallet.Currency
+	}
+	event := events.New(events.TypeWalletCreated, events.WalletLifecycleChanged{UserID: userID, Current: state})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+		logger.WithError(err).Error("ProvisionWallet - Record wallet created event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("ProvisionWallet - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithField("status", wallet.Status).Info("Wallet provisioned")
+	return wallet, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestProvisioningRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewProvisioningRepository(mockDB, logrus.New())
+	eur := "EUR"
+	columns := []string{"balance", "held", "status", "currency"}
+
+	t.Run("ProvisionWallet", func(t *testing.T) {
+		t.Run("creates the wallet in the currency", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs(eur).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(`INSERT INTO wallets \(user_id, currency, status, metadata, metadata_version, metadata_updated_at\)`).
+				WithArgs("user1", &eur, models.WalletStatusActive, []byte(`{}`), int64(0)).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("0", "0", models.WalletStatusActive, eur))
+			mock.ExpectExec(`INSERT INTO outbox_events`).
+				WithArgs(sqlmock.AnyArg(), events.TypeWalletCreated, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			wallet, err := repo.ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Currency: &eur, Status: models.WalletStatusActive})
+			require.NoError(t, err)
+			require.Equal(t, models.WalletStatusActive, wallet.Status)
+			require.Equal(t, eur, *wallet.Currency)
+			require.True(t, wallet.Balance.IsZero())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("without currency", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user2", nil, models.WalletStatusActive, []byte(`{}`), int64(0)).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("0", "0", models.WalletStatusActive, nil))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			wallet, err := repo.ProvisionWallet(ctx, models.NewWallet{UserID: "user2", Status: models.WalletStatusActive})
+			require.NoError(t, err)
+			require.Nil(t, wallet.Currency)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("frozen with metadata", func(t *testing.T) {
+			metadata := map[string]string{"customer": "C-42"}
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user3", nil, models.WalletStatusFrozen, []byte(`{"customer":"C-42"}`), int64(1)).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("0", "0", models.WalletStatusFrozen, nil))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			wallet, err := repo.ProvisionWallet(ctx, models.NewWallet{UserID: "user3", Metadata: metadata, Status: models.WalletStatusFrozen})
+			require.NoError(t, err)
+			require.Equal(t, models.WalletStatusFrozen, wallet.Status)
+			require.Equal(t, metadata, wallet.Metadata)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("unknown currency", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS`).WithArgs(eur).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			_, err := repo.ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Currency: &eur, Status: models.WalletStatusActive})
+			require.ErrorIs(t, err, ErrCurrencyNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("existing wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", nil, models.WalletStatusActive, []byte(`{}`), int64(0)).WillReturnRows(sqlmock.NewRows(columns))
+			mock.ExpectRollback()
+
+			_, err := repo.ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Status: models.WalletStatusActive})
+			require.ErrorIs(t, err, ErrWalletAlreadyExists)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// ReconciliationRepository recomputes wallet balances from the ledger and
+// records the wallets whose stored balance differs
+type ReconciliationRepository interface {
+	StartReconciliation(ctx context.Context, triggeredBy string) (*models.ReconciliationRun, error)
+	LatestReconciliation(ctx context.Context) (*models.ReconciliationRun, error)
+	GetReconciliation(ctx context.Context, runID string) (*models.ReconciliationRun, error)
+	ListReconciliations(ctx context.Context, limit int) ([]models.ReconciliationRun, error)
+	ReconcileNext(ctx context.Context, runID string, batchSize int) (*models.ReconciliationRun, error)
+	ListReconciliationReports(ctx context.Context, runID string, limit int) ([]models.ReconciliationReport, error)
+}
+
+var ErrReconciliationRunNotFound = errors.New("reconciliation run not found")
+
+const reconciliationRunColumns = `id::text, status, last_user_id, checked, mismatched, triggered_by,
+	started_at, completed_at`
+
+type PostgresReconciliationRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewReconciliationRepository(db *sql.DB, logger *logrus.Logger) *PostgresReconciliationRepository {
+	return &PostgresReconciliationRepository{db: db, logger: logger}
+}
+
+// StartReconciliation creates a running reconciliation run, or returns the
+// run already running: at most one runs at a time
+func (r *PostgresReconciliationRepository) StartReconciliation(ctx context.Context, triggeredBy string) (*models.ReconciliationRun, error) {
+	// The running run may complete between the insert and the select, in
+	// which case the insert is tried again
+	for {
+		run, err := scanReconciliationRun(r.db.QueryRowContext(ctx,
+			`INSERT INTO reconciliation_runs (status, triggered_by)
+			VALUES ($1, $2)
+			ON CONFLICT ((TRUE)) WHERE status = 'running' DO NOTHING
+			RETURNING `+reconciliationRunColumns,
+			models.ReconciliationRunning, triggeredBy,
+		))
+		if err == nil {
+			r.logger.WithContext(ctx).WithFields(logrus.Fields{
+				"runID":       run.ID,
+				"triggeredBy": triggeredBy,
+			}).Info("Reconciliation started")
+			return run, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.WithContext(ctx).WithError(err).Error("StartReconciliation - Create run failed")
+			return nil, err
+		}
+
+		run, err = scanReconciliationRun(r.db.QueryRowContext(ctx,
+			`SELECT `+reconciliationRunColumns+`
+			FROM reconciliation_runs
+			WHERE status = $1`,
+			models.ReconciliationRunning,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("StartReconciliation - Query running run failed")
+			return nil, err
+		}
+		return run, nil
+	}
+}
+
+// LatestReconciliation returns the run started last, whatever its status
+func (r *PostgresReconciliationRepository) LatestReconciliation(ctx context.Context) (*models.ReconciliationRun, error) {
+	run, err := scanReconciliationRun(r.db.QueryRowContext(ctx,
+		`SELECT `+reconciliationRunColumns+`
+		FROM reconciliation_runs
+		ORDER BY id DESC
+		LIMIT 1`,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReconciliationRunNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("LatestReconciliation - Query run failed")
+		return nil, err
+	}
+	return run, nil
+}
+
+// GetReconciliation returns the run with its progress
+func (r *PostgresReconciliationRepository) GetReconciliation(ctx context.Context, runID string) (*models.ReconciliationRun, error) {
+	run, err := scanReconciliationRun(r.db.QueryRowContext(ctx,
+		`SELECT `+reconciliationRunColumns+`
+		FROM reconciliation_runs
+		WHERE id = $1`,
+		runID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReconciliationRunNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("runID", runID).Error("GetReconciliation - Query run failed")
+		return nil, err
+	}
+	return run, nil
+}
+
+// ListReconciliations returns the latest runs, newest first
+func (r *PostgresReconciliationRepository) ListReconciliations(ctx context.Context, limit int) ([]models.ReconciliationRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+reconciliationRunColumns+`
+		FROM reconciliation_runs
+		ORDER BY id DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListReconciliations - Query runs failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.ReconciliationRun{}
+	for rows.Next() {
+		run, err := scanReconciliationRun(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListReconciliations - Scan run failed")
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// ReconcileNext checks the next batchSize wallets of a running run. The
+// balance of each wallet is recomputed from all of its transactions that did
+// not fail and compared with the stored balance in a single statement, so
+// both are read as of the same moment. Mismatches are recorded and the run
+// advanced in the same transaction, which makes a retried batch harmless. The
+// run completes with the batch that reaches the last wallet. The run is
+// returned as it stands afterwards; runs no longer running are returned
+// unchanged.
+func (r *PostgresReconciliationRepository) ReconcileNext(ctx context.Context, runID string, batchSize int) (*models.ReconciliationRun, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"runID": runID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("ReconcileNext - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the run serializes instances working on it
+	run, err := scanReconciliationRun(tx.QueryRowContext(ctx,
+		`SELECT `+reconciliationRunColumns+`
+		FROM reconciliation_runs
+		WHERE id = $1
+		FOR UPDATE`,
+		runID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReconciliationRunNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("ReconcileNext - Lock run failed")
+		return nil, err
+	}
+	if run.Status != models.ReconciliationRunning {
+		return run, nil
+	}
+
+	var lastUserID string
+	var checked, mismatched int
+	err = tx.QueryRowContext(ctx,
+		`WITH batch AS (
+			SELECT w.user_id, w.balance, COALESCE((
+				SELECT SUM(`+ledgerEffect+`)
+				FROM transactions t
+				WHERE (t.from_user_id = w.user_id OR t.to_user_id = w.user_id)
+					AND t.status <> 'failed'
+			), 0) AS ledger
+			FROM wallets w
+			WHERE w.user_id > $2
+			ORDER BY w.user_id
+			LIMIT $3
+		), mismatched AS (
+			INSERT INTO reconciliation_reports (run_id, user_id, stored_balance, ledger_balance, difference)
+			SELECT $1::bigint, user_id, balance, ledger, balance - ledger
+			FROM batch
+			WHERE balance <> ledger
+			ON CONFLICT (run_id, user_id) DO NOTHING
+			RETURNING user_id
+		)
+		SELECT COALESCE(MAX(user_id), ''), COUNT(*), (SELECT COUNT(*) FROM mismatched)
+		FROM batch`,
+		run.ID, run.LastUserID, batchSize,
+	).Scan(&lastUserID, &checked, &mismatched)
+	if err != nil {
+		logger.WithError(err).WithField("lastUserID", run.LastUserID).Error("ReconcileNext - Reconcile batch failed")
+		return nil, err
+	}
+
+	if checked == 0 {
+		lastUserID = run.LastUserID
+	}
+	status := models.ReconciliationRunning
+	if checked < batchSize {
+		status = models.ReconciliationCompleted
+	}
+
+	run, err = scanReconciliationRun(tx.QueryRowContext(ctx,
+		`UPDATE reconciliation_runs
+		SET last_user_id = $1, checked = checked + $2, mismatched = mismatched + $3, status = $4,
+			completed_at = CASE WHEN $5 THEN NOW() END
+		WHERE id = $6
+		RETURNING `+reconciliationRunColumns,
+		lastUserID, checked, mismatched, status, status == models.ReconciliationCompleted, run.ID,
+	))
+	if err != nil {
+		logger.WithError(err).Error("ReconcileNext - Update run failed")
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.WithError(err).Error("ReconcileNext - Commit DB transaction failed")
+		return nil, err
+	}
+
+	if mismatched > 0 {
+		logger.WithField("mismatched", mismatched).Warn("ReconcileNext - Balances differ from the ledger")
+	}
+	return run, nil
+}
+
+// ListReconciliationReports returns up to limit mismatches recorded by the
+// run, the largest differences first
+func (r *PostgresReconciliationRepository) ListReconciliationReports(ctx context.Context, runID string, limit int) ([]models.ReconciliationReport, error) {
+	if _, err := r.GetReconciliation(ctx, runID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT run_id::text, user_id, stored_balance, ledger_balance, difference, checked_at
+		FROM reconciliation_reports
+		WHERE run_id = $1
+		ORDER BY ABS(difference) DESC, user_id
+		LIMIT $2`,
+		runID, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("runID", runID).Error("ListReconciliationReports - Query reports failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.ReconciliationReport{}
+	for rows.Next() {
+		var report models.ReconciliationReport
+		err := rows.Scan(
+			&report.RunID,
+			&report.UserID,
+			&report.StoredBalance,
+			&report.LedgerBalance,
+			&report.Difference,
+			&report.CheckedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListReconciliationReports - Scan report failed")
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+func scanReconciliationRun(row rowScanner) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := row.Scan(
+		&run.ID,
+		&run.Status,
+		&run.LastUserID,
+		&run.Checked,
+		&run.Mismatched,
+		&run.TriggeredBy,
+		&run.StartedAt,
+		&run.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
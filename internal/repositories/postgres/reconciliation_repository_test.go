@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestReconciliationRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewReconciliationRepository(mockDB, logrus.New())
+	now := time.Now()
+	runColumns := []string{"id", "status", "last_user_id", "checked", "mismatched", "triggered_by", "started_at", "completed_at"}
+
+	t.Run("StartReconciliation returns the running run", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO reconciliation_runs .+ON CONFLICT`).
+			WithArgs(models.ReconciliationRunning, "admin").
+			WillReturnRows(sqlmock.NewRows(runColumns))
+		mock.ExpectQuery(`SELECT .+ FROM reconciliation_runs\s+WHERE status = \$1`).
+			WithArgs(models.ReconciliationRunning).
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("3", models.ReconciliationRunning, "user1", 500, 0, "reconciliation", now, nil))
+
+		run, err := repo.StartReconciliation(ctx, "admin")
+		require.NoError(t, err)
+		require.Equal(t, "3", run.ID)
+		require.Equal(t, "reconciliation", run.TriggeredBy)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReconcileNext completes the run with the last batch", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT .+ FROM reconciliation_runs\s+WHERE id = \$1\s+FOR UPDATE`).WithArgs("3").
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("3", models.ReconciliationRunning, "user1", 500, 0, "reconciliation", now, nil))
+		mock.ExpectQuery(`WITH batch AS .+WHERE w.user_id > \$2.+INSERT INTO reconciliation_reports`).
+			WithArgs("3", "user1", 500).
+			WillReturnRows(sqlmock.NewRows([]string{"max", "checked", "mismatched"}).AddRow("user9", 120, 2))
+		mock.ExpectQuery(`UPDATE reconciliation_runs`).
+			WithArgs("user9", 120, 2, models.ReconciliationCompleted, true, "3").
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("3", models.ReconciliationCompleted, "user9", 620, 2, "reconciliation", now, now))
+		mock.ExpectCommit()
+
+		run, err := repo.ReconcileNext(ctx, "3", 500)
+		require.NoError(t, err)
+		require.Equal(t, models.ReconciliationCompleted, run.Status)
+		require.Equal(t, 2, run.Mismatched)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReconcileNext leaves finished runs alone", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT .+ FROM reconciliation_runs`).WithArgs("3").
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("3", models.ReconciliationCompleted, "user9", 620, 2, "reconciliation", now, now))
+		mock.ExpectRollback()
+
+		run, err := repo.ReconcileNext(ctx, "3", 500)
+		require.NoError(t, err)
+		require.Equal(t, 620, run.Checked)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListReconciliationReports unknown run", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM reconciliation_runs`).WithArgs("4").WillReturnRows(sqlmock.NewRows(runColumns))
+
+		_, err := repo.ListReconciliationReports(ctx, "4", 100)
+		require.ErrorIs(t, err, ErrReconciliationRunNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
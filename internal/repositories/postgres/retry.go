@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// deadlockAttempts bounds how often a transaction aborted by a deadlock
+	// or serialization failure is run
+	deadlockAttempts = 3
+
+	pgDeadlockDetected     = "40P01"
+	pgSerializationFailure = "40001"
+)
+
+// deadlockBackoff is the wait before the first retry; it doubles with every
+// further retry
+var deadlockBackoff = 20 * time.Millisecond
+
+// retryOnDeadlock runs a database transaction, running it again when
+// PostgreSQL aborted it to break a deadlock or a serialization failure.
+// Aborted transactions are rolled back, so run starts from a clean state.
+func retryOnDeadlock(ctx context.Context, logger *logrus.Entry, method string, run func() error) error {
+	backoff := deadlockBackoff
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || !isRetryable(err) || attempt == deadlockAttempts {
+			return err
+		}
+
+		logger.WithError(err).WithField("attempt", attempt).Warn(method + " - Transaction aborted by a lock conflict, retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == pgDeadlockDetected || pgErr.Code == pgSerializationFailure)
+}
+
+// conflictBackoff bounds the random wait before the first rerun of a
+// movement whose wallets changed since it read them; the bound doubles with
+// every further rerun, so contending writers spread out
+var conflictBackoff = 5 * time.Millisecond
+
+// retryOnConflict runs a database transaction that reads wallets without
+// locking them up to attempts times, running it again while a wallet changed
+// before its update. It returns ErrWalletContended once the attempts are
+// exhausted.
+func retryOnConflict(ctx context.Context, logger *logrus.Entry, method string, attempts int, run func() error) error {
+	backoff := conflictBackoff
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if !errors.Is(err, errVersionConflict) {
+			return err
+		}
+		if attempt >= attempts {
+			logger.WithField("attempts", attempts).Warn(method + " - Wallet kept changing concurrently, giving up")
+			return ErrWalletContended
+		}
+
+		logger.WithField("attempt", attempt).Debug(method + " - Wallet changed since it was read, retrying")
+		select {
+		case <-ctx.Done():
+			return ErrWalletContended
+		case <-time.After(rand.N(backoff)):
+		}
+		backoff *= 2
+	}
+}
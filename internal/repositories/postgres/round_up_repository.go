@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// RoundUpRepository stores the round-up rules of wallets and moves the
+// round-ups of their transfers to the savings sub-accounts
+type RoundUpRepository interface {
+	PutRoundUpRule(ctx context.Context, rule *models.RoundUpRule) error
+	GetRoundUpRule(ctx context.Context, userID string) (*models.RoundUpRule, error)
+	DeleteRoundUpRule(ctx context.Context, userID string) error
+	PendingRoundUps(ctx context.Context, limit int) ([]models.RoundUp, error)
+	SaveRoundUp(ctx context.Context, roundUp models.RoundUp) (string, error)
+}
+
+var ErrRoundUpRuleNotFound = errors.New("round-up rule not found")
+
+type PostgresRoundUpRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewRoundUpRepository(db *sql.DB, logger *logrus.Logger) *PostgresRoundUpRepository {
+	return &PostgresRoundUpRepository{db: db, logger: logger}
+}
+
+// PutRoundUpRule creates the round-up rule of a wallet or changes its unit.
+// A changed rule keeps applying to the transfers made since it was created.
+func (r *PostgresRoundUpRepository) PutRoundUpRule(ctx context.Context, rule *models.RoundUpRule) error {
+	if rule.UserID == "" {
+		r.logger.WithContext(ctx).Warn("PutRoundUpRule - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if !rule.Unit.IsPositive() {
+		r.logger.WithContext(ctx).Warn("PutRoundUpRule - unit cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": rule.UserID,
+		"unit":   rule.Unit,
+	})
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)`, rule.UserID).Scan(&exists)
+	if err != nil {
+		logger.WithError(err).Error("PutRoundUpRule - Query wallet failed")
+		return err
+	}
+	if !exists {
+		logger.Warn("PutRoundUpRule - Cannot find user in the database")
+		return ErrUserNotFound
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO round_up_rules (user_id, unit, created_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET unit = EXCLUDED.unit, created_by = EXCLUDED.created_by, updated_at = NOW()
+		RETURNING created_at, updated_at`,
+		rule.UserID, rule.Unit, rule.CreatedBy,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("PutRoundUpRule - Save rule failed")
+		return err
+	}
+
+	rule.SavingsAccount = models.SavingsAccount(rule.UserID)
+	logger.Info("Round-up rule saved")
+	return nil
+}
+
+// GetRoundUpRule returns the round-up rule of userID with the balance of its
+// savings sub-account, zero until the first round-up is saved
+func (r *PostgresRoundUpRepository) GetRoundUpRule(ctx context.Context, userID string) (*models.RoundUpRule, error) {
+	rule := models.RoundUpRule{SavingsAccount: models.SavingsAccount(userID)}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT r.user_id, r.unit, COALESCE(s.balance, 0), r.created_by, r.created_at, r.updated_at
+		FROM round_up_rules r
+		LEFT JOIN wallets s ON s.user_id = $2
+		WHERE r.user_id = $1`,
+		userID, rule.SavingsAccount,
+	).Scan(&rule.UserID, &rule.Unit, &rule.SavingsBalance, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRoundUpRuleNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetRoundUpRule - Query rule failed")
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRoundUpRule removes the round-up rule of userID. Savings already
+// moved stay in the savings sub-account.
+func (r *PostgresRoundUpRepository) DeleteRoundUpRule(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM round_up_rules WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("DeleteRoundUpRule - Delete rule failed")
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrRoundUpRuleNotFound
+	}
+
+	r.logger.WithContext(ctx).WithField("userID", userID).Info("Round-up rule deleted")
+	return nil
+}
+
+// PendingRoundUps returns up to limit transfers not handled yet, made by
+// wallets with a round-up rule since the rule was created and not already a
+// multiple of its unit, oldest first, with the amount they are rounded up by
+func (r *PostgresRoundUpRepository) PendingRoundUps(ctx context.Context, limit int) ([]models.RoundUp, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT t.id::text, t.from_user_id, r.unit - MOD(t.amount, r.unit)
+		FROM round_up_rules r
+		JOIN transactions t ON t.from_user_id = r.user_id AND t.created_at >= r.created_at
+		WHERE t.type = 'transfer' AND t.status = $1
+			AND MOD(t.amount, r.unit) <> 0
+			AND NOT EXISTS (SELECT 1 FROM round_ups u WHERE u.transfer_id = t.id)
+		ORDER BY t.id
+		LIMIT $2`,
+		models.TransactionCompleted, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("PendingRoundUps - Query transfers failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roundUps []models.RoundUp
+	for rows.Next() {
+		var roundUp models.RoundUp
+		if err := rows.Scan(&roundUp.TransferID, &roundUp.UserID, &roundUp.Amount); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("PendingRoundUps - Scan transfer failed")
+			return nil, err
+		}
+		roundUps = append(roundUps, roundUp)
+	}
+	return roundUps, rows.Err()
+}
+
+// SaveRoundUp moves the round-up of a transfer from the wallet to its
+// savings sub-account, created with the currency of the wallet when missing,
+// as a round-up transaction linked to the transfer, and records its event. A
+// wallet that is not active or whose available balance cannot cover the
+// round-up is skipped. It returns models.RoundUpSaved or
+// models.RoundUpSkipped, or an empty status when the transfer was already
+// handled.
+func (r *PostgresRoundUpRepository) SaveRoundUp(ctx context.Context, roundUp models.RoundUp) (string, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":     roundUp.UserID,
+		"transferID": roundUp.TransferID,
+		"amount":     roundUp.Amount,
+	})
+	savings := models.SavingsAccount(roundUp.UserID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Begin DB transaction failed")
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// The wallet is locked before the savings sub-account, like the sender
+	// before the receiver of a transfer
+	var balance, held decimal.Decimal
+	var status string
+	var currency sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status, currency FROM wallets WHERE user_id = $1 FOR UPDATE",
+		roundUp.UserID,
+	).Scan(&balance, &held, &status, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("SaveRoundUp - Cannot find user in the database")
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Query user balance failed")
+		return "", err
+	}
+
+	outcome := models.RoundUpSaved
+	if status != models.WalletStatusActive || balance.Sub(held).LessThan(roundUp.Amount) {
+		outcome = models.RoundUpSkipped
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO round_ups (transfer_id, user_id, amount, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (transfer_id) DO NOTHING`,
+		roundUp.TransferID, roundUp.UserID, roundUp.Amount, outcome,
+	)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Record round-up failed")
+		return "", err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return "", nil
+	}
+
+	if outcome == models.RoundUpSaved {
+		if err := saveRoundUp(ctx, tx, logger, roundUp, savings, currency); err != nil {
+			return "", err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Commit DB transaction failed")
+		return "", err
+	}
+
+	logger.WithField("status", outcome).Debug("Round-up handled")
+	return outcome, nil
+}
+
+// saveRoundUp moves the round-up inside tx. The caller has locked the wallet
+// and checked that it covers the round-up.
+func saveRoundUp(ctx context.Context, tx *sql.Tx, logger *logrus.Entry, roundUp models.RoundUp, savings string, currency sql.NullString) error {
+	var balance, savingsBalance decimal.Decimal
+	err := tx.QueryRowContext(ctx,
+		"UPDATE wallets SET balance = balance - $1 WHERE user_id = $2 RETURNING balance",
+		roundUp.Amount, roundUp.UserID,
+	).Scan(&balance)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Update user balance failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wallets (user_id, balance, label, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET balance = wallets.balance + $2
+		RETURNING balance`,
+		savings, roundUp.Amount, models.SavingsAccountLabel, currency,
+	).Scan(&savingsBalance)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Update savings balance failed")
+		return err
+	}
+
+	var transactionID string
+	actor, channel := provenance(ctx)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions
+		(from_user_id, to_user_id, amount, type, created_at, actor, channel, round_up_for)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		roundUp.UserID, savings, roundUp.Amount, models.TransactionTypeRoundUp, time.Now(), actor, channel, roundUp.TransferID,
+	).Scan(&transactionID)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Create transaction record failed")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE round_ups SET transaction_id = $1 WHERE transfer_id = $2",
+		transactionID, roundUp.TransferID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Link round-up transaction failed")
+		return err
+	}
+
+	sequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, roundUp.UserID)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Assign user sequence number failed")
+		return err
+	}
+	savingsSequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, savings)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Assign savings sequence number failed")
+		return err
+	}
+
+	event := events.New(events.TypeRoundUpSaved, events.RoundUpSaved{
+		UserID:          roundUp.UserID,
+		SavingsAccount:  savings,
+		Amount:          roundUp.Amount,
+		TransactionID:   transactionID,
+		RoundUpFor:      roundUp.TransferID,
+		Sequence:        sequence,
+		SavingsSequence: savingsSequence,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, roundUp.UserID); err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Record round-up saved event failed")
+		return err
+	}
+
+	before, after := auditBalances(balance.Add(roundUp.Amount), roundUp.Amount.Neg())
+	savingsBefore, savingsAfter := auditBalances(savingsBalance.Sub(roundUp.Amount), roundUp.Amount)
+	err = recordAudit(ctx, sqlTx{tx},
+		models.AuditEntry{Action: models.AuditTransfer, UserID: roundUp.UserID, TransactionID: transactionID, Before: before, After: after},
+		models.AuditEntry{Action: models.AuditTransfer, UserID: savings, TransactionID: transactionID, Before: savingsBefore, After: savingsAfter},
+	)
+	if err != nil {
+		logger.WithError(err).Error("SaveRoundUp - Record audit entry failed")
+		return err
+	}
+	return nil
+}
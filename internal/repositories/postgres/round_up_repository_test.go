@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestRoundUpRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewRoundUpRepository(mockDB, logrus.New())
+	now := time.Now()
+	roundUp := models.RoundUp{TransferID: "12", UserID: "user1", Amount: decimal.RequireFromString("0.75")}
+
+	t.Run("PutRoundUpRule unknown wallet", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		err := repo.PutRoundUpRule(ctx, &models.RoundUpRule{UserID: "user1", Unit: decimal.NewFromInt(1)})
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetRoundUpRule includes the savings balance", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT r.user_id, r.unit, COALESCE\(s.balance, 0\)`).WithArgs("user1", "savings:user1").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "unit", "balance", "created_by", "created_at", "updated_at"}).
+				AddRow("user1", "1", "3.25", "user1", now, now))
+
+		rule, err := repo.GetRoundUpRule(ctx, "user1")
+		require.NoError(t, err)
+		require.Equal(t, "savings:user1", rule.SavingsAccount)
+		require.True(t, decimal.RequireFromString("3.25").Equal(rule.SavingsBalance))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PendingRoundUps", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT t.id::text, t.from_user_id, r.unit - MOD\(t.amount, r.unit\).+NOT EXISTS`).
+			WithArgs(models.TransactionCompleted, 50).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_user_id", "amount"}).AddRow("12", "user1", "0.75"))
+
+		roundUps, err := repo.PendingRoundUps(ctx, 50)
+		require.NoError(t, err)
+		require.Equal(t, []models.RoundUp{{TransferID: "12", UserID: "user1", Amount: decimal.RequireFromString("0.75")}}, roundUps)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveRoundUp", func(t *testing.T) {
+		t.Run("moves the round-up to savings", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency FROM wallets`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow("10", "0", models.WalletStatusActive, "EUR"))
+			mock.ExpectExec(`INSERT INTO round_ups`).WithArgs("12", "user1", roundUp.Amount, models.RoundUpSaved).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE wallets SET balance = balance - \$1`).WithArgs(roundUp.Amount, "user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("9.25"))
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("savings:user1", roundUp.Amount, models.SavingsAccountLabel, "EUR").
+				WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("0.75"))
+			mock.ExpectQuery(`INSERT INTO transactions`).
+				WithArgs("user1", "savings:user1", roundUp.Amount, models.TransactionTypeRoundUp, sqlmock.AnyArg(), nil, nil, "12").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("13"))
+			mock.ExpectExec(`UPDATE round_ups SET transaction_id`).WithArgs("13", "12").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`WITH counter AS`).WithArgs("user1", "13").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(8))
+			mock.ExpectQuery(`WITH counter AS`).WithArgs("savings:user1", "13").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).
+				WithArgs(sqlmock.AnyArg(), events.TypeRoundUpSaved, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 2)
+			mock.ExpectCommit()
+
+			status, err := repo.SaveRoundUp(ctx, roundUp)
+			require.NoError(t, err)
+			require.Equal(t, models.RoundUpSaved, status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("skips a round-up the balance cannot cover", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency FROM wallets`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow("10", "9.5", models.WalletStatusActive, nil))
+			mock.ExpectExec(`INSERT INTO round_ups`).WithArgs("12", "user1", roundUp.Amount, models.RoundUpSkipped).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			status, err := repo.SaveRoundUp(ctx, roundUp)
+			require.NoError(t, err)
+			require.Equal(t, models.RoundUpSkipped, status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already handled", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency FROM wallets`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow("10", "0", models.WalletStatusActive, nil))
+			mock.ExpectExec(`INSERT INTO round_ups`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			status, err := repo.SaveRoundUp(ctx, roundUp)
+			require.NoError(t, err)
+			require.Empty(t, status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
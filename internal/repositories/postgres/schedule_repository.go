@@ -0,0 +1,368 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// ScheduleRepository stores recurring transfer schedules and the runs the
+// scheduler records for their occurrences
+type ScheduleRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.TransferSchedule) error
+	GetSchedule(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error)
+	ListSchedules(ctx context.Context, userID string) ([]models.TransferSchedule, error)
+	UpdateScheduleStatus(ctx context.Context, userID, scheduleID, from, to string, nextRunAt time.Time) (*models.TransferSchedule, error)
+	ListScheduleRuns(ctx context.Context, userID, scheduleID string, limit int) ([]models.TransferScheduleRun, error)
+	ClaimDueRuns(ctx context.Context, now time.Time, limit int, next func(models.TransferSchedule) time.Time) ([]models.TransferScheduleRun, error)
+	ClaimStaleRuns(ctx context.Context, staleBefore time.Time, limit int) ([]models.TransferScheduleRun, error)
+	CompleteRun(ctx context.Context, runID, status string, runErr *string) error
+}
+
+var (
+	ErrScheduleNotFound      = errors.New("transfer schedule not found")
+	ErrScheduleStatusChanged = errors.New("transfer schedule status changed concurrently")
+)
+
+const scheduleColumns = `id::text, user_id, to_user_id, amount, cron, interval_seconds, status, next_run_at,
+	last_run_at, created_by, created_at, updated_at`
+
+const scheduleRunColumns = `id::text, schedule_id::text, user_id, to_user_id, amount, scheduled_for, status,
+	attempts, error, created_at, updated_at`
+
+type PostgresScheduleRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewScheduleRepository(db *sql.DB, logger *logrus.Logger) *PostgresScheduleRepository {
+	return &PostgresScheduleRepository{db: db, logger: logger}
+}
+
+// CreateSchedule persists an active schedule, filling in its ID, status and
+// timestamps
+func (r *PostgresScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.TransferSchedule) error {
+	if schedule.UserID == "" || schedule.ToUserID == "" {
+		r.logger.WithContext(ctx).Warn("CreateSchedule - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if schedule.UserID == schedule.ToUserID {
+		r.logger.WithContext(ctx).Warn("CreateSchedule - userID and toUserID cannot be the same")
+		return ErrInvalidUserID
+	}
+
+	if !schedule.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("CreateSchedule - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO transfer_schedules
+		(user_id, to_user_id, amount, cron, interval_seconds, status, next_run_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id::text, created_at, updated_at`,
+		schedule.UserID, schedule.ToUserID, schedule.Amount, schedule.Cron, schedule.IntervalSeconds,
+		models.ScheduleActive, schedule.NextRunAt, schedule.CreatedBy,
+	).Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", schedule.UserID).Error("CreateSchedule - Create schedule failed")
+		return err
+	}
+
+	schedule.Status = models.ScheduleActive
+	r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":     schedule.UserID,
+		"scheduleID": schedule.ID,
+		"nextRunAt":  schedule.NextRunAt,
+	}).Info("Transfer schedule created")
+	return nil
+}
+
+// GetSchedule returns the schedule scheduleID of userID
+func (r *PostgresScheduleRepository) GetSchedule(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error) {
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx,
+		`SELECT `+scheduleColumns+`
+		FROM transfer_schedules
+		WHERE user_id = $1 AND id::text = $2`,
+		userID, scheduleID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("scheduleID", scheduleID).Error("GetSchedule - Query schedule failed")
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// ListSchedules returns the schedules of userID, oldest first, including
+// cancelled ones
+func (r *PostgresScheduleRepository) ListSchedules(ctx context.Context, userID string) ([]models.TransferSchedule, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+scheduleColumns+`
+		FROM transfer_schedules
+		WHERE user_id = $1
+		ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListSchedules - Query schedules failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.TransferSchedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListSchedules - Scan schedules failed")
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListSchedules - Iterate schedules failed")
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateScheduleStatus moves a schedule of userID from status from to to and
+// sets its next run. It fails with ErrScheduleStatusChanged if the schedule
+// is no longer in from.
+func (r *PostgresScheduleRepository) UpdateScheduleStatus(ctx context.Context, userID, scheduleID, from, to string, nextRunAt time.Time) (*models.TransferSchedule, error) {
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx,
+		`UPDATE transfer_schedules
+		SET status = $1, next_run_at = $2, updated_at = NOW()
+		WHERE user_id = $3 AND id::text = $4 AND status = $5
+		RETURNING `+scheduleColumns,
+		to, nextRunAt, userID, scheduleID, from,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetSchedule(ctx, userID, scheduleID); err != nil {
+			return nil, err
+		}
+		return nil, ErrScheduleStatusChanged
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("scheduleID", scheduleID).Error("UpdateScheduleStatus - Update schedule failed")
+		return nil, err
+	}
+
+	r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"scheduleID": scheduleID,
+		"from":       from,
+		"to":         to,
+	}).Info("Transfer schedule status changed")
+	return schedule, nil
+}
+
+// ListScheduleRuns returns the latest limit runs of a schedule of userID,
+// newest first
+func (r *PostgresScheduleRepository) ListScheduleRuns(ctx context.Context, userID, scheduleID string, limit int) ([]models.TransferScheduleRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+scheduleRunColumns+`
+		FROM transfer_schedule_runs
+		WHERE user_id = $1 AND schedule_id::text = $2
+		ORDER BY id DESC
+		LIMIT $3`,
+		userID, scheduleID, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("scheduleID", scheduleID).Error("ListScheduleRuns - Query runs failed")
+		return nil, err
+	}
+	return r.collectRuns(ctx, rows, "ListScheduleRuns")
+}
+
+// ClaimDueRuns records a pending run for up to limit active schedules due at
+// now, oldest due first, and advances each schedule to next of it. Schedules
+// next returns the zero time for are cancelled after this run. Schedules
+// locked by a concurrent scheduler are skipped.
+func (r *PostgresScheduleRepository) ClaimDueRuns(ctx context.Context, now time.Time, limit int, next func(models.TransferSchedule) time.Time) ([]models.TransferScheduleRun, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimDueRuns - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+scheduleColumns+`
+		FROM transfer_schedules
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at, id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`,
+		models.ScheduleActive, now, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimDueRuns - Query due schedules failed")
+		return nil, err
+	}
+	var due []models.TransferSchedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			r.logger.WithContext(ctx).WithError(err).Error("ClaimDueRuns - Scan due schedules failed")
+			return nil, err
+		}
+		due = append(due, *schedule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimDueRuns - Iterate due schedules failed")
+		return nil, err
+	}
+
+	var runs []models.TransferScheduleRun
+	for _, schedule := range due {
+		logger := r.logger.WithContext(ctx).WithField("scheduleID", schedule.ID)
+
+		run, err := scanScheduleRun(tx.QueryRowContext(ctx,
+			`INSERT INTO transfer_schedule_runs (schedule_id, user_id, to_user_id, amount, scheduled_for, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (schedule_id, scheduled_for) DO NOTHING
+			RETURNING `+scheduleRunColumns,
+			schedule.ID, schedule.UserID, schedule.ToUserID, schedule.Amount, schedule.NextRunAt, models.ScheduleRunPending,
+		))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The occurrence already ran; only the schedule is advanced
+		case err != nil:
+			logger.WithError(err).Error("ClaimDueRuns - Create run failed")
+			return nil, err
+		default:
+			runs = append(runs, *run)
+		}
+
+		status, nextRunAt := models.ScheduleActive, next(schedule)
+		if nextRunAt.IsZero() {
+			status, nextRunAt = models.ScheduleCancelled, schedule.NextRunAt
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE transfer_schedules
+			SET status = $1, next_run_at = $2, last_run_at = $3, updated_at = NOW()
+			WHERE id::text = $4`,
+			status, nextRunAt, schedule.NextRunAt, schedule.ID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("ClaimDueRuns - Advance schedule failed")
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimDueRuns - Commit DB transaction failed")
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ClaimStaleRuns returns up to limit runs left pending since before
+// staleBefore, whose scheduler stopped before recording the outcome, and
+// counts the new attempt. Runs claimed by a concurrent scheduler are skipped.
+func (r *PostgresScheduleRepository) ClaimStaleRuns(ctx context.Context, staleBefore time.Time, limit int) ([]models.TransferScheduleRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE transfer_schedule_runs
+		SET attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM transfer_schedule_runs
+			WHERE status = $1 AND updated_at < $2
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+scheduleRunColumns,
+		models.ScheduleRunPending, staleBefore, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimStaleRuns - Claim runs failed")
+		return nil, err
+	}
+	return r.collectRuns(ctx, rows, "ClaimStaleRuns")
+}
+
+// CompleteRun records the outcome of a pending run
+func (r *PostgresScheduleRepository) CompleteRun(ctx context.Context, runID, status string, runErr *string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE transfer_schedule_runs
+		SET status = $1, error = $2, updated_at = NOW()
+		WHERE id::text = $3 AND status = $4`,
+		status, runErr, runID, models.ScheduleRunPending,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("runID", runID).Error("CompleteRun - Update run failed")
+	}
+	return err
+}
+
+func (r *PostgresScheduleRepository) collectRuns(ctx context.Context, rows *sql.Rows, method string) ([]models.TransferScheduleRun, error) {
+	defer rows.Close()
+
+	runs := []models.TransferScheduleRun{}
+	for rows.Next() {
+		run, err := scanScheduleRun(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error(method + " - Scan runs failed")
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Iterate runs failed")
+		return nil, err
+	}
+	return runs, nil
+}
+
+func scanSchedule(row rowScanner) (*models.TransferSchedule, error) {
+	var schedule models.TransferSchedule
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.ToUserID,
+		&schedule.Amount,
+		&schedule.Cron,
+		&schedule.IntervalSeconds,
+		&schedule.Status,
+		&schedule.NextRunAt,
+		&schedule.LastRunAt,
+		&schedule.CreatedBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func scanScheduleRun(row rowScanner) (*models.TransferScheduleRun, error) {
+	var run models.TransferScheduleRun
+	err := row.Scan(
+		&run.ID,
+		&run.ScheduleID,
+		&run.UserID,
+		&run.ToUserID,
+		&run.Amount,
+		&run.ScheduledFor,
+		&run.Status,
+		&run.Attempts,
+		&run.Error,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
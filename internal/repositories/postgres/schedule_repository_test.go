@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestScheduleRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewScheduleRepository(mockDB, logrus.New())
+	now := time.Now()
+	interval := int64(3600)
+	scheduleColumns := []string{"id", "user_id", "to_user_id", "amount", "cron", "interval_seconds", "status", "next_run_at", "last_run_at", "created_by", "created_at", "updated_at"}
+	runColumns := []string{"id", "schedule_id", "user_id", "to_user_id", "amount", "scheduled_for", "status", "attempts", "error", "created_at", "updated_at"}
+
+	t.Run("CreateSchedule", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO transfer_schedules`).
+			WithArgs("user1", "user2", decimal.NewFromInt(25), nil, &interval, models.ScheduleActive, now, "user1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("3", now, now))
+
+		schedule := &models.TransferSchedule{
+			UserID:          "user1",
+			ToUserID:        "user2",
+			Amount:          decimal.NewFromInt(25),
+			IntervalSeconds: &interval,
+			NextRunAt:       now,
+			CreatedBy:       "user1",
+		}
+		require.NoError(t, repo.CreateSchedule(ctx, schedule))
+		require.Equal(t, "3", schedule.ID)
+		require.Equal(t, models.ScheduleActive, schedule.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateScheduleStatus", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE transfer_schedules\s+SET status = \$1, next_run_at = \$2`).
+				WithArgs(models.SchedulePaused, now, "user1", "3", models.ScheduleActive).
+				WillReturnRows(sqlmock.NewRows(scheduleColumns).AddRow("3", "user1", "user2", "25", nil, interval, models.SchedulePaused, now, nil, "user1", now, now))
+
+			schedule, err := repo.UpdateScheduleStatus(ctx, "user1", "3", models.ScheduleActive, models.SchedulePaused, now)
+			require.NoError(t, err)
+			require.Equal(t, models.SchedulePaused, schedule.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("status changed", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE transfer_schedules`).
+				WithArgs(models.SchedulePaused, now, "user1", "3", models.ScheduleActive).
+				WillReturnRows(sqlmock.NewRows(scheduleColumns))
+			mock.ExpectQuery(`SELECT id::text, user_id, to_user_id`).WithArgs("user1", "3").
+				WillReturnRows(sqlmock.NewRows(scheduleColumns).AddRow("3", "user1", "user2", "25", nil, interval, models.ScheduleCancelled, now, nil, "user1", now, now))
+
+			_, err := repo.UpdateScheduleStatus(ctx, "user1", "3", models.ScheduleActive, models.SchedulePaused, now)
+			require.ErrorIs(t, err, ErrScheduleStatusChanged)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE transfer_schedules`).WillReturnRows(sqlmock.NewRows(scheduleColumns))
+			mock.ExpectQuery(`SELECT id::text, user_id, to_user_id`).WithArgs("user1", "9").WillReturnRows(sqlmock.NewRows(scheduleColumns))
+
+			_, err := repo.UpdateScheduleStatus(ctx, "user1", "9", models.ScheduleActive, models.SchedulePaused, now)
+			require.ErrorIs(t, err, ErrScheduleNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("ClaimDueRuns", func(t *testing.T) {
+		due := now.Add(-time.Minute)
+		next := due.Add(time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id::text, user_id, to_user_id, amount, cron, interval_seconds, status, next_run_at,\s+last_run_at, created_by, created_at, updated_at\s+FROM transfer_schedules\s+WHERE status = \$1 AND next_run_at <= \$2.*FOR UPDATE SKIP LOCKED`).
+			WithArgs(models.ScheduleActive, now, 10).
+			WillReturnRows(sqlmock.NewRows(scheduleColumns).
+				AddRow("3", "user1", "user2", "25", nil, interval, models.ScheduleActive, due, nil, "user1", now, now).
+				AddRow("4", "user1", "user3", "5", nil, interval, models.ScheduleActive, due, nil, "user1", now, now))
+		mock.ExpectQuery(`INSERT INTO transfer_schedule_runs .* ON CONFLICT \(schedule_id, scheduled_for\) DO NOTHING`).
+			WithArgs("3", "user1", "user2", decimal.NewFromInt(25), due, models.ScheduleRunPending).
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("7", "3", "user1", "user2", "25", due, models.ScheduleRunPending, 1, nil, now, now))
+		mock.ExpectExec(`UPDATE transfer_schedules\s+SET status = \$1, next_run_at = \$2, last_run_at = \$3`).
+			WithArgs(models.ScheduleActive, next, due, "3").WillReturnResult(sqlmock.NewResult(0, 1))
+		// The occurrence of schedule 4 already ran
+		mock.ExpectQuery(`INSERT INTO transfer_schedule_runs`).
+			WithArgs("4", "user1", "user3", decimal.NewFromInt(5), due, models.ScheduleRunPending).
+			WillReturnRows(sqlmock.NewRows(runColumns))
+		mock.ExpectExec(`UPDATE transfer_schedules`).
+			WithArgs(models.ScheduleActive, next, due, "4").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		runs, err := repo.ClaimDueRuns(ctx, now, 10, func(schedule models.TransferSchedule) time.Time {
+			return schedule.NextRunAt.Add(time.Hour)
+		})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Equal(t, "7", runs[0].ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimStaleRuns", func(t *testing.T) {
+		staleBefore := now.Add(-time.Minute)
+		mock.ExpectQuery(`UPDATE transfer_schedule_runs\s+SET attempts = attempts \+ 1`).
+			WithArgs(models.ScheduleRunPending, staleBefore, 10).
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("7", "3", "user1", "user2", "25", now, models.ScheduleRunPending, 2, nil, now, now))
+
+		runs, err := repo.ClaimStaleRuns(ctx, staleBefore, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Equal(t, 2, runs[0].Attempts)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CompleteRun", func(t *testing.T) {
+		reason := "insufficient balance"
+		mock.ExpectExec(`UPDATE transfer_schedule_runs\s+SET status = \$1, error = \$2`).
+			WithArgs(models.ScheduleRunFailed, &reason, "7", models.ScheduleRunPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, repo.CompleteRun(ctx, "7", models.ScheduleRunFailed, &reason))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+type SettingsRepository interface {
+	ListSettings(ctx context.Context) ([]models.Setting, error)
+	PutSetting(ctx context.Context, setting *models.Setting, reason string) error
+	DeleteSetting(ctx context.Context, key, scope, scopeID, actor, reason string) error
+	ListSettingChanges(ctx context.Context, key string, limit int) ([]models.SettingChange, error)
+}
+
+var ErrSettingNotFound = errors.New("setting not found")
+
+type PostgresSettingsRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewSettingsRepository(db *sql.DB, logger *logrus.Logger) *PostgresSettingsRepository {
+	return &PostgresSettingsRepository{db: db, logger: logger}
+}
+
+// ListSettings returns every stored setting across all scopes
+func (r *PostgresSettingsRepository) ListSettings(ctx context.Context) ([]models.Setting, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT key, scope, scope_id, value, updated_by, updated_at
+		FROM settings
+		ORDER BY key, scope, scope_id`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListSettings - Query settings failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []models.Setting
+	for rows.Next() {
+		var setting models.Setting
+		err := rows.Scan(
+			&setting.Key,
+			&setting.Scope,
+			&setting.ScopeID,
+			&setting.Value,
+			&setting.UpdatedBy,
+			&setting.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListSettings - Scan settings failed")
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListSettings - Iterate settings failed")
+		return nil, err
+	}
+	return settings, nil
+}
+
+// PutSetting creates or replaces a setting, with setting.UpdatedBy as the
+// actor, and records the change in the same transaction. UpdatedAt is filled
+// in on success.
+func (r *PostgresSettingsRepository) PutSetting(ctx context.Context, setting *models.Setting, reason string) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"key":     setting.Key,
+		"scope":   setting.Scope,
+		"scopeID": setting.ScopeID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("PutSetting - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldValue *string
+	err = tx.QueryRowContext(ctx,
+		"SELECT value FROM settings WHERE key = $1 AND scope = $2 AND scope_id = $3 FOR UPDATE",
+		setting.Key, setting.Scope, setting.ScopeID,
+	).Scan(&oldValue)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Error("PutSetting - Query current value failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO settings (key, scope, scope_id, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (key, scope, scope_id)
+		DO UPDATE SET value = $4, updated_by = $5, updated_at = NOW()
+		RETURNING updated_at`,
+		setting.Key, setting.Scope, setting.ScopeID, setting.Value, setting.UpdatedBy,
+	).Scan(&setting.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("PutSetting - Upsert setting failed")
+		return err
+	}
+
+	if err = recordSettingChange(ctx, tx, setting.Key, setting.Scope, setting.ScopeID, oldValue, &setting.Value, reason, setting.UpdatedBy); err != nil {
+		logger.WithError(err).Error("PutSetting - Record setting change failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("PutSetting - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("value", setting.Value).Info("Setting updated")
+	return nil
+}
+
+// DeleteSetting removes a setting so the next broader scope applies again,
+// and records the change in the same transaction
+func (r *PostgresSettingsRepository) DeleteSetting(ctx context.Context, key, scope, scopeID, actor, reason string) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"key":     key,
+		"scope":   scope,
+		"scopeID": scopeID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("DeleteSetting - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldValue string
+	err = tx.QueryRowContext(ctx,
+		"DELETE FROM settings WHERE key = $1 AND scope = $2 AND scope_id = $3 RETURNING value",
+		key, scope, scopeID,
+	).Scan(&oldValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("DeleteSetting - Cannot find setting in the database")
+		return ErrSettingNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("DeleteSetting - Delete setting failed")
+		return err
+	}
+
+	if err = recordSettingChange(ctx, tx, key, scope, scopeID, &oldValue, nil, reason, actor); err != nil {
+		logger.WithError(err).Error("DeleteSetting - Record setting change failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("DeleteSetting - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Setting cleared")
+	return nil
+}
+
+// ListSettingChanges returns the most recent setting changes, newest first,
+// optionally only those of key
+func (r *PostgresSettingsRepository) ListSettingChanges(ctx context.Context, key string, limit int) ([]models.SettingChange, error) {
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListSettingChanges - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id::text, key, scope, scope_id, old_value, new_value, reason, actor, created_at
+		FROM setting_changes
+		WHERE $1 = '' OR key = $1
+		ORDER BY id DESC
+		LIMIT $2`,
+		key, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListSettingChanges - Query setting changes failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.SettingChange
+	for rows.Next() {
+		var change models.SettingChange
+		err := rows.Scan(
+			&change.ID,
+			&change.Key,
+			&change.Scope,
+			&change.ScopeID,
+			&change.OldValue,
+			&change.NewValue,
+			&change.Reason,
+			&change.Actor,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListSettingChanges - Scan setting changes failed")
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListSettingChanges - Iterate setting changes failed")
+		return nil, err
+	}
+	return changes, nil
+}
+
+func recordSettingChange(ctx context.Context, tx *sql.Tx, key, scope, scopeID string, oldValue, newValue *string, reason, actor string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO setting_changes (key, scope, scope_id, old_value, new_value, reason, actor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		key, scope, scopeID, oldValue, newValue, reason, actor,
+	)
+	return err
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestSettingsRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewSettingsRepository(mockDB, logrus.New())
+	now := time.Now()
+
+	t.Run("ListSettings", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT key, scope, scope_id, value`).WillReturnRows(sqlmock.NewRows(
+			[]string{"key", "scope", "scope_id", "value", "updated_by", "updated_at"},
+		).AddRow("max_transaction_amount", "default", "", "1000", "admin1", now).
+			AddRow("max_transaction_amount", "wallet", "user1", "50", "admin1", now))
+
+		settings, err := repo.ListSettings(ctx)
+		require.NoError(t, err)
+		require.Len(t, settings, 2)
+		require.Equal(t, "user1", settings[1].ScopeID)
+	})
+
+	t.Run("PutSetting records the previous value", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT value FROM settings`).WithArgs("max_transaction_amount", "wallet", "user1").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("50"))
+		mock.ExpectQuery(`INSERT INTO settings`).WithArgs("max_transaction_amount", "wallet", "user1", "75", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+		mock.ExpectExec(`INSERT INTO setting_changes`).WithArgs("max_transaction_amount", "wallet", "user1", "50", "75", "INC-1", "admin1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		setting := &models.Setting{Key: "max_transaction_amount", Scope: "wallet", ScopeID: "user1", Value: "75", UpdatedBy: "admin1"}
+		require.NoError(t, repo.PutSetting(ctx, setting, "INC-1"))
+		require.Equal(t, now, setting.UpdatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteSetting", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM settings`).WithArgs("max_transaction_amount", "wallet", "user1").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("75"))
+		mock.ExpectExec(`INSERT INTO setting_changes`).WithArgs("max_transaction_amount", "wallet", "user1", "75", nil, "INC-2", "admin1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.DeleteSetting(ctx, "max_transaction_amount", "wallet", "user1", "admin1", "INC-2"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteSetting not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`DELETE FROM settings`).WithArgs("max_transaction_amount", "tenant", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}))
+		mock.ExpectRollback()
+
+		err := repo.DeleteSetting(ctx, "max_transaction_amount", "tenant", "acme", "admin1", "INC-3")
+		require.ErrorIs(t, err, ErrSettingNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListSettingChanges", func(t *testing.T) {
+		mock.ExpectQuery(`FROM setting_changes`).WithArgs("max_transaction_amount", 10).WillReturnRows(sqlmock.NewRows(
+			[]string{"id", "key", "scope", "scope_id", "old_value", "new_value", "reason", "actor", "created_at"},
+		).AddRow("2", "max_transaction_amount", "wallet", "user1", "75", nil, "INC-2", "admin1", now))
+
+		changes, err := repo.ListSettingChanges(ctx, "max_transaction_amount", 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Nil(t, changes[0].NewValue)
+		require.Equal(t, "75", *changes[0].OldValue)
+	})
+}
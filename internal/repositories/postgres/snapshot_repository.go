@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// BalanceHistory returns the balance of a wallet as of a past time
+type BalanceHistory interface {
+	BalanceAt(ctx context.Context, userID string, at time.Time) (decimal.Decimal, error)
+}
+
+// SnapshotRepository reconstructs historical balances from periodic balance
+// snapshots plus the transactions recorded after them
+type SnapshotRepository interface {
+	BalanceHistory
+	TakeSnapshots(ctx context.Context, asOf time.Time) (int, error)
+}
+
+// ledgerEffect is the effect of the transaction t on the balance of the
+// wallet w.user_id. Adjustments carry a signed amount. Receivers of a
+// conversion were credited its converted amount. A transaction can touch both
+// sides of the same wallet (a re-linked merge transfer), so credits and
+// debits are summed independently.
+const ledgerEffect = `CASE WHEN t.to_user_id = w.user_id THEN COALESCE(t.converted_amount, t.amount) ELSE 0 END +
+			CASE WHEN t.from_user_id = w.user_id THEN
+				CASE WHEN t.type IN ('deposit', 'adjustment') THEN t.amount ELSE -t.amount END
+			ELSE 0 END`
+
+// balanceAsOf computes the balance of the wallet w.user_id at $1 as the
+// latest snapshot up to $1 plus the effect of the later transactions up to $1.
+// Failed transactions never moved funds and are skipped.
+const balanceAsOf = `COALESCE(s.balance, 0) + COALESCE((
+		SELECT SUM(` + ledgerEffect + `)
+		FROM transactions t
+		WHERE (t.from_user_id = w.user_id OR t.to_user_id = w.user_id)
+			AND t.status <> 'failed'
+			AND t.created_at > COALESCE(s.taken_at, '-infinity')
+			AND t.created_at <= $1
+	), 0)`
+
+const latestSnapshot = `LEFT JOIN LATERAL (
+		SELECT balance, taken_at FROM balance_snapshots
+		WHERE user_id = w.user_id AND taken_at <= $1
+		ORDER BY taken_at DESC
+		LIMIT 1
+	) s ON TRUE`
+
+type PostgresSnapshotRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewSnapshotRepository(db *sql.DB, logger *logrus.Logger) *PostgresSnapshotRepository {
+	return &PostgresSnapshotRepository{db: db, logger: logger}
+}
+
+// TakeSnapshots records the balance of every wallet as of asOf and returns
+// the number of snapshots written. Balances are derived from the previous
+// snapshot and the ledger rather than read from wallets, so asOf may lag
+// behind now to leave in-flight transactions out.
+func (r *PostgresSnapshotRepository) TakeSnapshots(ctx context.Context, asOf time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO balance_snapshots (user_id, balance, taken_at)
+		SELECT w.user_id, `+balanceAsOf+`, $1
+		FROM wallets w
+		`+latestSnapshot+`
+		ON CONFLICT (user_id, taken_at) DO NOTHING`,
+		asOf,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("asOf", asOf).Error("TakeSnapshots - Insert snapshots failed")
+		return 0, err
+	}
+
+	written, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("TakeSnapshots - Count snapshots failed")
+		return 0, err
+	}
+	return int(written), nil
+}
+
+// BalanceAt returns the balance of the user's wallet at the given time. A
+// wallet created after it had a zero balance.
+func (r *PostgresSnapshotRepository) BalanceAt(ctx context.Context, userID string, at time.Time) (decimal.Decimal, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("BalanceAt - userID cannot be an empty string")
+		return decimal.Zero, ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"at":     at,
+	})
+
+	var balance decimal.Decimal
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+balanceAsOf+`
+		FROM wallets w
+		`+latestSnapshot+`
+		WHERE w.user_id = $2`,
+		at, userID,
+	).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("BalanceAt - Cannot find user in the database")
+		return decimal.Zero, ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("BalanceAt - Query historical balance failed")
+		return decimal.Zero, err
+	}
+
+	return balance, nil
+}
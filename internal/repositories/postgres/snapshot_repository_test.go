@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewSnapshotRepository(mockDB, logrus.New())
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("TakeSnapshots", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO balance_snapshots`).WithArgs(at).WillReturnResult(sqlmock.NewResult(0, 42))
+
+		written, err := repo.TakeSnapshots(ctx, at)
+		require.NoError(t, err)
+		require.Equal(t, 42, written)
+	})
+
+	t.Run("BalanceAt", func(t *testing.T) {
+		mock.ExpectQuery(`FROM balance_snapshots`).WithArgs(at, "user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("75.5"))
+
+		balance, err := repo.BalanceAt(ctx, "user1", at)
+		require.NoError(t, err)
+		require.True(t, balance.Equal(decimal.RequireFromString("75.5")))
+	})
+
+	t.Run("BalanceAt unknown user", func(t *testing.T) {
+		mock.ExpectQuery(`FROM balance_snapshots`).WithArgs(at, "ghost").WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.BalanceAt(ctx, "ghost", at)
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
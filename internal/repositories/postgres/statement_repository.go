@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// StatementRepository reads the ledger of a wallet oldest first, page by
+// page, so statements of any length are generated without holding a
+// connection while they are sent
+type StatementRepository interface {
+	GetStatementEntries(ctx context.Context, userID string, cursor *models.TransactionCursor, from, to time.Time, limit int) ([]models.Transaction, error)
+}
+
+type PostgresStatementRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewStatementRepository(db *sql.DB, logger *logrus.Logger) *PostgresStatementRepository {
+	return &PostgresStatementRepository{db: db, logger: logger}
+}
+
+// GetStatementEntries returns up to limit transactions of userID created in
+// [from, to) after cursor, oldest first. A nil cursor starts at from. Failed
+// transactions never moved funds and are left out.
+func (r *PostgresStatementRepository) GetStatementEntries(ctx context.Context, userID string, cursor *models.TransactionCursor, from, to time.Time, limit int) ([]models.Transaction, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetStatementEntries - userID cannot be an empty string")
+		return nil, ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetStatementEntries - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"from":   from,
+		"to":     to,
+	})
+
+	query := `SELECT id, from_user_id, to_user_id, amount, type, created_at, merged_from, category,
+			from_currency, to_currency, fx_rate, converted_amount,
+			(SELECT sequence FROM transaction_sequences s
+			WHERE s.transaction_id = transactions.id AND s.user_id = $1)
+		FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)
+			AND status <> 'failed'
+			AND created_at >= $2 AND created_at < $3`
+	args := []interface{}{userID, from, to, limit}
+	if cursor != nil {
+		query += ` AND (created_at, id) > ($5, $6)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += `
+		ORDER BY created_at, id
+		LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.WithError(err).Error("GetStatementEntries - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		err := rows.Scan(
+			&txn.ID,
+			&txn.FromUserID,
+			&txn.ToUserID,
+			&txn.Amount,
+			&txn.Type,
+			&txn.CreatedAt,
+			&txn.MergedFrom,
+			&txn.Category,
+			&txn.FromCurrency,
+			&txn.ToCurrency,
+			&txn.FXRate,
+			&txn.ConvertedAmount,
+			&txn.Sequence,
+		)
+		if err != nil {
+			logger.WithError(err).Error("GetStatementEntries - Scan transactions failed")
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("GetStatementEntries - Iterate transactions failed")
+		return nil, err
+	}
+	return transactions, nil
+}
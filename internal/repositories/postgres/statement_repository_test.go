@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestStatementRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewStatementRepository(mockDB, logrus.New())
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, -1, 0)
+	columns := []string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at", "merged_from", "category", "from_currency", "to_currency", "fx_rate", "converted_amount", "sequence"}
+
+	t.Run("first page", func(t *testing.T) {
+		mock.ExpectQuery(`created_at >= \$2 AND created_at < \$3\s+ORDER BY created_at, id\s+LIMIT \$4`).
+			WithArgs("user1", from, to, 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "user1", nil, 100.0, "deposit", from, nil, nil, nil, nil, nil, nil, 1).
+				AddRow(2, "user1", "user2", 50.0, "transfer", from.Add(time.Hour), nil, "rent", nil, nil, nil, nil, 2))
+
+		txns, err := repo.GetStatementEntries(ctx, "user1", nil, from, to, 2)
+		require.NoError(t, err)
+		require.Len(t, txns, 2)
+		require.Equal(t, "deposit", *txns[0].Type)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("following page", func(t *testing.T) {
+		cursor := &models.TransactionCursor{CreatedAt: from.Add(time.Hour), ID: 2}
+		mock.ExpectQuery(`AND \(created_at, id\) > \(\$5, \$6\)\s+ORDER BY created_at, id`).
+			WithArgs("user1", from, to, 2, cursor.CreatedAt, cursor.ID).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		txns, err := repo.GetStatementEntries(ctx, "user1", cursor, from, to, 2)
+		require.NoError(t, err)
+		require.Empty(t, txns)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := repo.GetStatementEntries(ctx, "", nil, from, to, 2)
+		require.ErrorIs(t, err, ErrInvalidUserID)
+		_, err = repo.GetStatementEntries(ctx, "user1", nil, from, to, 0)
+		require.ErrorIs(t, err, ErrInvalidLimit)
+	})
+}
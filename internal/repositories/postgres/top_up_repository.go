@@ -0,0 +1,407 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// TopUpRepository stores the automatic top-up rules of wallets and the runs
+// the top-up worker records when a balance falls below its threshold
+type TopUpRepository interface {
+	PutTopUpRule(ctx context.Context, rule *models.TopUpRule) error
+	GetTopUpRule(ctx context.Context, userID string) (*models.TopUpRule, error)
+	DeleteTopUpRule(ctx context.Context, userID string) error
+	UpdateTopUpRuleStatus(ctx context.Context, userID, from, to string) (*models.TopUpRule, error)
+	ListTopUpRuns(ctx context.Context, userID string, limit int) ([]models.TopUpRun, error)
+	ClaimDueTopUps(ctx context.Context, failedBefore time.Time, limit int) ([]models.TopUpRun, error)
+	ClaimStaleTopUps(ctx context.Context, staleBefore time.Time, limit int) ([]models.TopUpRun, error)
+	CompleteTopUp(ctx context.Context, runID, reference string) error
+	FailTopUp(ctx context.Context, run models.TopUpRun, reason string, reference *string, maxFailures int) (*models.TopUpRule, error)
+}
+
+var (
+	ErrTopUpRuleNotFound      = errors.New("top-up rule not found")
+	ErrTopUpRuleStatusChanged = errors.New("top-up rule status changed concurrently")
+)
+
+const topUpRuleColumns = `user_id, threshold, amount, funding_source, status, consecutive_failures,
+	created_by, created_at, updated_at`
+
+const topUpRunColumns = `id::text, user_id, amount, currency, funding_source, status, attempts,
+	provider_reference, error, created_at, updated_at`
+
+type PostgresTopUpRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTopUpRepository(db *sql.DB, logger *logrus.Logger) *PostgresTopUpRepository {
+	return &PostgresTopUpRepository{db: db, logger: logger}
+}
+
+// PutTopUpRule creates or replaces the top-up rule of a wallet. A replaced
+// rule is active again and its failures are forgotten.
+func (r *PostgresTopUpRepository) PutTopUpRule(ctx context.Context, rule *models.TopUpRule) error {
+	if rule.UserID == "" {
+		r.logger.WithContext(ctx).Warn("PutTopUpRule - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if !rule.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("PutTopUpRule - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO top_up_rules (user_id, threshold, amount, funding_source, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET threshold = EXCLUDED.threshold, amount = EXCLUDED.amount, funding_source = EXCLUDED.funding_source,
+			status = EXCLUDED.status, consecutive_failures = 0, created_by = EXCLUDED.created_by, updated_at = NOW()
+		RETURNING created_at, updated_at`,
+		rule.UserID, rule.Threshold, rule.Amount, rule.FundingSource, models.TopUpRuleActive, rule.CreatedBy,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", rule.UserID).Error("PutTopUpRule - Save rule failed")
+		return err
+	}
+
+	rule.Status, rule.ConsecutiveFailures = models.TopUpRuleActive, 0
+	r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":    rule.UserID,
+		"threshold": rule.Threshold,
+		"amount":    rule.Amount,
+	}).Info("Top-up rule saved")
+	return nil
+}
+
+// GetTopUpRule returns the top-up rule of userID
+func (r *PostgresTopUpRepository) GetTopUpRule(ctx context.Context, userID string) (*models.TopUpRule, error) {
+	rule, err := scanTopUpRule(r.db.QueryRowContext(ctx,
+		`SELECT `+topUpRuleColumns+`
+		FROM top_up_rules
+		WHERE user_id = $1`,
+		userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTopUpRuleNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTopUpRule - Query rule failed")
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteTopUpRule removes the top-up rule of userID. A top-up already
+// triggered still completes.
+func (r *PostgresTopUpRepository) DeleteTopUpRule(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM top_up_rules WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("DeleteTopUpRule - Delete rule failed")
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrTopUpRuleNotFound
+	}
+
+	r.logger.WithContext(ctx).WithField("userID", userID).Info("Top-up rule deleted")
+	return nil
+}
+
+// UpdateTopUpRuleStatus moves the rule of userID from status from to to. A
+// resumed rule starts counting failures afresh. It fails with
+// ErrTopUpRuleStatusChanged if the rule is no longer in from.
+func (r *PostgresTopUpRepository) UpdateTopUpRuleStatus(ctx context.Context, userID, from, to string) (*models.TopUpRule, error) {
+	rule, err := scanTopUpRule(r.db.QueryRowContext(ctx,
+		`UPDATE top_up_rules
+		SET status = $1,
+			consecutive_failures = CASE WHEN $1 = $2 THEN 0 ELSE consecutive_failures END,
+			updated_at = NOW()
+		WHERE user_id = $3 AND status = $4
+		RETURNING `+topUpRuleColumns,
+		to, models.TopUpRuleActive, userID, from,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetTopUpRule(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTopUpRuleStatusChanged
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("UpdateTopUpRuleStatus - Update rule failed")
+		return nil, err
+	}
+
+	r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"from":   from,
+		"to":     to,
+	}).Info("Top-up rule status changed")
+	return rule, nil
+}
+
+// ListTopUpRuns returns the latest limit top-up runs of userID, newest first
+func (r *PostgresTopUpRepository) ListTopUpRuns(ctx context.Context, userID string, limit int) ([]models.TopUpRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+topUpRunColumns+`
+		FROM top_up_runs
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ListTopUpRuns - Query runs failed")
+		return nil, err
+	}
+	return r.collectRuns(ctx, rows, "ListTopUpRuns")
+}
+
+// ClaimDueTopUps records a pending run for up to limit active rules whose
+// wallet is active with an available balance below the threshold. Wallets
+// with a pending run, or whose last run failed at or after failedBefore, are
+// skipped, as are rules locked by a concurrent worker.
+func (r *PostgresTopUpRepository) ClaimDueTopUps(ctx context.Context, failedBefore time.Time, limit int) ([]models.TopUpRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`WITH due AS (
+			SELECT r.user_id, r.amount, w.currency, r.funding_source
+			FROM top_up_rules r
+			JOIN wallets w ON w.user_id = r.user_id
+			WHERE r.status = $1 AND w.status = $2 AND w.balance - w.held < r.threshold
+				AND NOT EXISTS (
+					SELECT 1 FROM top_up_runs t
+					WHERE t.user_id = r.user_id
+						AND (t.status = $3 OR (t.status = $4 AND t.updated_at >= $5))
+				)
+			ORDER BY r.user_id
+			LIMIT $6
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		INSERT INTO top_up_runs (user_id, amount, currency, funding_source, status)
+		SELECT user_id, amount, currency, funding_source, $3 FROM due
+		ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+		RETURNING `+topUpRunColumns,
+		models.TopUpRuleActive, models.WalletStatusActive, models.TopUpRunPending, models.TopUpRunFailed, failedBefore, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimDueTopUps - Claim top-ups failed")
+		return nil, err
+	}
+	return r.collectRuns(ctx, rows, "ClaimDueTopUps")
+}
+
+// ClaimStaleTopUps returns up to limit runs left pending since before
+// staleBefore, whose worker stopped or hit a transient failure before
+// recording the outcome, and counts the new attempt. Runs claimed by a
+// concurrent worker are skipped.
+func (r *PostgresTopUpRepository) ClaimStaleTopUps(ctx context.Context, staleBefore time.Time, limit int) ([]models.TopUpRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE top_up_runs
+		SET attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM top_up_runs
+			WHERE status = $1 AND updated_at < $2
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+topUpRunColumns,
+		models.TopUpRunPending, staleBefore, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimStaleTopUps - Claim runs failed")
+		return nil, err
+	}
+	return r.collectRuns(ctx, rows, "ClaimStaleTopUps")
+}
+
+// CompleteTopUp records a pending run as deposited and clears the failures
+// of its rule
+func (r *PostgresTopUpRepository) CompleteTopUp(ctx context.Context, runID, reference string) error {
+	logger := r.logger.WithContext(ctx).WithField("runID", runID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CompleteTopUp - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx,
+		`UPDATE top_up_runs
+		SET status = $1, provider_reference = $2, error = NULL, updated_at = NOW()
+		WHERE id::text = $3 AND status = $4
+		RETURNING user_id`,
+		models.TopUpRunSucceeded, reference, runID, models.TopUpRunPending,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Completed by a concurrent worker
+		return nil
+	}
+	if err != nil {
+		logger.WithError(err).Error("CompleteTopUp - Update run failed")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE top_up_rules SET consecutive_failures = 0 WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CompleteTopUp - Reset rule failures failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CompleteTopUp - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("userID", userID).Info("Top-up completed")
+	return nil
+}
+
+// FailTopUp records a pending run as failed with reason, counts the failure
+// on its rule and pauses the rule once maxFailures runs failed in a row. The
+// top_up.failed event is recorded in the same transaction. It returns the
+// rule after the failure, nil if it was deleted meanwhile.
+func (r *PostgresTopUpRepository) FailTopUp(ctx context.Context, run models.TopUpRun, reason string, reference *string, maxFailures int) (*models.TopUpRule, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"runID":  run.ID,
+		"userID": run.UserID,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("FailTopUp - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE top_up_runs
+		SET status = $1, provider_reference = $2, error = $3, updated_at = NOW()
+		WHERE id::text = $4 AND status = $5`,
+		models.TopUpRunFailed, reference, reason, run.ID, models.TopUpRunPending,
+	)
+	if err != nil {
+		logger.WithError(err).Error("FailTopUp - Update run failed")
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		// Settled by a concurrent worker
+		return nil, nil
+	}
+
+	rule, err := scanTopUpRule(tx.QueryRowContext(ctx,
+		`UPDATE top_up_rules
+		SET consecutive_failures = consecutive_failures + 1,
+			status = CASE WHEN consecutive_failures + 1 >= $1 THEN $2 ELSE status END,
+			updated_at = NOW()
+		WHERE user_id = $3
+		RETURNING `+topUpRuleColumns,
+		maxFailures, models.TopUpRulePaused, run.UserID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		rule, err = nil, nil
+	}
+	if err != nil {
+		logger.WithError(err).Error("FailTopUp - Count rule failure failed")
+		return nil, err
+	}
+
+	failed := events.TopUpFailed{
+		UserID:        run.UserID,
+		RunID:         run.ID,
+		Amount:        run.Amount,
+		FundingSource: run.FundingSource,
+		Error:         reason,
+	}
+	if rule != nil {
+		failed.ConsecutiveFailures = rule.ConsecutiveFailures
+		failed.Paused = rule.Status == models.TopUpRulePaused
+	}
+	if err := enqueueEvent(ctx, sqlTx{tx}, events.New(events.TypeTopUpFailed, failed), run.UserID); err != nil {
+		logger.WithError(err).Error("FailTopUp - Enqueue event failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("FailTopUp - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"error":  reason,
+		"paused": failed.Paused,
+	}).Warn("Top-up failed")
+	return rule, nil
+}
+
+func (r *PostgresTopUpRepository) collectRuns(ctx context.Context, rows *sql.Rows, method string) ([]models.TopUpRun, error) {
+	defer rows.Close()
+
+	runs := []models.TopUpRun{}
+	for rows.Next() {
+		run, err := scanTopUpRun(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error(method + " - Scan runs failed")
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Iterate runs failed")
+		return nil, err
+	}
+	return runs, nil
+}
+
+func scanTopUpRule(row rowScanner) (*models.TopUpRule, error) {
+	var rule models.TopUpRule
+	err := row.Scan(
+		&rule.UserID,
+		&rule.Threshold,
+		&rule.Amount,
+		&rule.FundingSource,
+		&rule.Status,
+		&rule.ConsecutiveFailures,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func scanTopUpRun(row rowScanner) (*models.TopUpRun, error) {
+	var run models.TopUpRun
+	err := row.Scan(
+		&run.ID,
+		&run.UserID,
+		&run.Amount,
+		&run.Currency,
+		&run.FundingSource,
+		&run.Status,
+		&run.Attempts,
+		&run.ProviderReference,
+		&run.Error,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
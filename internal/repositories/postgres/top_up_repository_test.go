@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestTopUpRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewTopUpRepository(mockDB, logrus.New())
+	now := time.Now()
+	ruleColumns := []string{"user_id", "threshold", "amount", "funding_source", "status", "consecutive_failures", "created_by", "created_at", "updated_at"}
+	runColumns := []string{"id", "user_id", "amount", "currency", "funding_source", "status", "attempts", "provider_reference", "error", "created_at", "updated_at"}
+	run := models.TopUpRun{ID: "7", UserID: "user1", Amount: decimal.NewFromInt(50), FundingSource: "card:tok_visa_4242", Status: models.TopUpRunPending}
+
+	t.Run("PutTopUpRule", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO top_up_rules`).
+			WithArgs("user1", decimal.NewFromInt(20), decimal.NewFromInt(50), "card:tok_visa_4242", models.TopUpRuleActive, "user1").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+
+		rule := &models.TopUpRule{
+			UserID:        "user1",
+			Threshold:     decimal.NewFromInt(20),
+			Amount:        decimal.NewFromInt(50),
+			FundingSource: "card:tok_visa_4242",
+			CreatedBy:     "user1",
+		}
+		require.NoError(t, repo.PutTopUpRule(ctx, rule))
+		require.Equal(t, models.TopUpRuleActive, rule.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateTopUpRuleStatus status changed", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE top_up_rules`).
+			WithArgs(models.TopUpRuleActive, models.TopUpRuleActive, "user1", models.TopUpRulePaused).
+			WillReturnRows(sqlmock.NewRows(ruleColumns))
+		mock.ExpectQuery(`SELECT user_id, threshold`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(ruleColumns).AddRow("user1", "20", "50", "card:tok_visa_4242", models.TopUpRuleActive, 0, "user1", now, now))
+
+		_, err := repo.UpdateTopUpRuleStatus(ctx, "user1", models.TopUpRulePaused, models.TopUpRuleActive)
+		require.ErrorIs(t, err, ErrTopUpRuleStatusChanged)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteTopUpRule not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM top_up_rules`).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.ErrorIs(t, repo.DeleteTopUpRule(ctx, "user1"), ErrTopUpRuleNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimDueTopUps", func(t *testing.T) {
+		mock.ExpectQuery(`WITH due AS .+w.balance - w.held < r.threshold.+INSERT INTO top_up_runs`).
+			WithArgs(models.TopUpRuleActive, models.WalletStatusActive, models.TopUpRunPending, models.TopUpRunFailed, now, 10).
+			WillReturnRows(sqlmock.NewRows(runColumns).AddRow("7", "user1", "50", "EUR", "card:tok_visa_4242", models.TopUpRunPending, 1, nil, nil, now, now))
+
+		runs, err := repo.ClaimDueTopUps(ctx, now, 10)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Equal(t, "EUR", *runs[0].Currency)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FailTopUp", func(t *testing.T) {
+		t.Run("pauses the rule after too many failures", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE top_up_runs`).
+				WithArgs(models.TopUpRunFailed, nil, "declined", "7", models.TopUpRunPending).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE top_up_rules\s+SET consecutive_failures = consecutive_failures \+ 1`).
+				WithArgs(3, models.TopUpRulePaused, "user1").
+				WillReturnRows(sqlmock.NewRows(ruleColumns).AddRow("user1", "20", "50", "card:tok_visa_4242", models.TopUpRulePaused, 3, "user1", now, now))
+			mock.ExpectExec(`INSERT INTO outbox_events`).
+				WithArgs(sqlmock.AnyArg(), events.TypeTopUpFailed, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			rule, err := repo.FailTopUp(ctx, run, "declined", nil, 3)
+			require.NoError(t, err)
+			require.Equal(t, models.TopUpRulePaused, rule.Status)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("run settled concurrently", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE top_up_runs`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			rule, err := repo.FailTopUp(ctx, run, "declined", nil, 3)
+			require.NoError(t, err)
+			require.Nil(t, rule)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("CompleteTopUp", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE top_up_runs`).
+			WithArgs(models.TopUpRunSucceeded, "ch_7", "7", models.TopUpRunPending).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user1"))
+		mock.ExpectExec(`UPDATE top_up_rules SET consecutive_failures = 0`).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.CompleteTopUp(ctx, "7", "ch_7"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
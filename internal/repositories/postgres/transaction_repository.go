@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+// TransactionRepository gives operators access to transactions by status
+type TransactionRepository interface {
+	ListStuck(ctx context.Context, filter models.StuckTransactionFilter) ([]models.StuckTransaction, error)
+	GetTransaction(ctx context.Context, transactionID string) (*models.StuckTransaction, error)
+	UpdateStatus(ctx context.Context, transactionID, from, to string) error
+}
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrStatusChanged       = errors.New("transaction status changed concurrently")
+)
+
+type PostgresTransactionRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTransactionRepository(db *sql.DB, logger *logrus.Logger) *PostgresTransactionRepository {
+	return &PostgresTransactionRepository{db: db, logger: logger}
+}
+
+// stuckTransactionColumns selects a transaction t with its review flags
+const stuckTransactionColumns = `t.id::text, t.type, t.status, t.from_user_id, t.to_user_id, t.amount, t.created_at, t.updated_at,
+	(SELECT string_agg(f.flag, ',' ORDER BY f.flag) FROM transaction_flags f WHERE f.transaction_id = t.id)`
+
+// ListStuck returns the transactions in filter.Status, or any status when it
+// is empty, carrying filter.Flag when set and not updated for longer than
+// filter.OlderThan, oldest first
+func (r *PostgresTransactionRepository) ListStuck(ctx context.Context, filter models.StuckTransactionFilter) ([]models.StuckTransaction, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+stuckTransactionColumns+`
+		FROM transactions t
+		WHERE ($1 = '' OR t.status = $1) AND t.updated_at < $2
+			AND ($4 = '' OR EXISTS (SELECT 1 FROM transaction_flags f WHERE f.transaction_id = t.id AND f.flag = $4))
+		ORDER BY t.updated_at
+		LIMIT $3`,
+		filter.Status, time.Now().Add(-filter.OlderThan), filter.Limit, filter.Flag,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("status", filter.Status).Error("ListStuck - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.StuckTransaction
+	for rows.Next() {
+		var txn models.StuckTransaction
+		if err := scanStuckTransaction(rows, &txn); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListStuck - Scan transaction failed")
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListStuck - Iterate transactions failed")
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetTransaction returns a single transaction with its status
+func (r *PostgresTransactionRepository) GetTransaction(ctx context.Context, transactionID string) (*models.StuckTransaction, error) {
+	var txn models.StuckTransaction
+	err := scanStuckTransaction(r.db.QueryRowContext(ctx,
+		`SELECT `+stuckTransactionColumns+`
+		FROM transactions t
+		WHERE t.id::text = $1`,
+		transactionID,
+	), &txn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("transactionID", transactionID).Error("GetTransaction - Query transaction failed")
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// UpdateStatus moves a transaction from one status to another. It fails with
+// ErrStatusChanged if the transaction is no longer in the from status.
+func (r *PostgresTransactionRepository) UpdateStatus(ctx context.Context, transactionID, from, to string) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE transactions SET status = $1, updated_at = NOW() WHERE id::text = $2 AND status = $3",
+		to, transactionID, from,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("transactionID", transactionID).Error("UpdateStatus - Update transaction failed")
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStuckTransaction(row rowScanner, txn *models.StuckTransaction) error {
+	var flags sql.NullString
+	err := row.Scan(
+		&txn.ID,
+		&txn.Type,
+		&txn.Status,
+		&txn.FromUserID,
+		&txn.ToUserID,
+		&txn.Amount,
+		&txn.CreatedAt,
+		&txn.UpdatedAt,
+		&flags,
+	)
+	if err != nil {
+		return err
+	}
+	if flags.Valid {
+		txn.Flags = strings.Split(flags.String, ",")
+	}
+	return nil
+}
+
+// provenance returns the actor and channel recorded with transactions created
+// by the operation in ctx. Both are NULL outside an operation.
+func provenance(ctx context.Context) (actor, channel sql.NullString) {
+	op, _ := operation.From(ctx)
+	return sql.NullString{String: op.Actor, Valid: op.Actor != ""},
+		sql.NullString{String: op.Channel, Valid: op.Channel != ""}
+}
+
+// transactionDetails returns the memo, reference and metadata recorded with
+// the transaction created by the operation in ctx. Each is NULL when the
+// client did not set it.
+func transactionDetails(ctx context.Context) (memo, reference sql.NullString, metadata sql.Null[[]byte]) {
+	details := operation.DetailsFrom(ctx)
+	if len(details.Metadata) > 0 {
+		// A map of strings always encodes
+		metadata.V, _ = json.Marshal(details.Metadata)
+		metadata.Valid = true
+	}
+	return sql.NullString{String: details.Memo, Valid: details.Memo != ""},
+		sql.NullString{String: details.Reference, Valid: details.Reference != ""},
+		metadata
+}
+
+// metadataColumn scans a nullable JSONB object of strings into a map, nil for
+// NULL
+type metadataColumn map[string]string
+
+func (m *metadataColumn) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, (*map[string]string)(m))
+	case string:
+		return json.Unmarshal([]byte(value), (*map[string]string)(m))
+	default:
+		return fmt.Errorf("cannot scan %T into metadata", src)
+	}
+}
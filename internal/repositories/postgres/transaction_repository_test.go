@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestTransactionRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewTransactionRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"id", "type", "status", "from_user_id", "to_user_id", "amount", "created_at", "updated_at", "flags"}
+
+	t.Run("ListStuck", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT t.id::text, t.type, t.status`).WithArgs(models.TransactionPending, sqlmock.AnyArg(), 50, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("7", "withdrawal", models.TransactionPending, "user1", nil, "25", now, now, nil))
+
+		transactions, err := repo.ListStuck(ctx, models.StuckTransactionFilter{Status: models.TransactionPending, OlderThan: time.Hour, Limit: 50})
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		require.Equal(t, "7", transactions[0].ID)
+		require.Nil(t, transactions[0].ToUserID)
+		require.Nil(t, transactions[0].Flags)
+	})
+
+	t.Run("ListStuck by flag", func(t *testing.T) {
+		mock.ExpectQuery(`EXISTS \(SELECT 1 FROM transaction_flags f`).WithArgs("", sqlmock.AnyArg(), 50, models.FlagSuspicious).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("9", "transfer", models.TransactionCompleted, "user1", "user2", "25", now, now, "reviewed,suspicious"))
+
+		transactions, err := repo.ListStuck(ctx, models.StuckTransactionFilter{Flag: models.FlagSuspicious, Limit: 50})
+		require.NoError(t, err)
+		require.Equal(t, []string{models.FlagReviewed, models.FlagSuspicious}, transactions[0].Flags)
+	})
+
+	t.Run("GetTransaction not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT t.id::text, t.type, t.status`).WithArgs("8").WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetTransaction(ctx, "8")
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE transactions SET status`).WithArgs(models.TransactionEscalated, "7", models.TransactionPending).WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repo.UpdateStatus(ctx, "7", models.TransactionPending, models.TransactionEscalated))
+
+		mock.ExpectExec(`UPDATE transactions SET status`).WithArgs(models.TransactionEscalated, "7", models.TransactionPending).WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, repo.UpdateStatus(ctx, "7", models.TransactionPending, models.TransactionEscalated), ErrStatusChanged)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
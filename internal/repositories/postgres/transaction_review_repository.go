@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// TransactionReviewRepository stores the flags and annotations admins attach
+// to transactions they review
+type TransactionReviewRepository interface {
+	GetTransactionReview(ctx context.Context, transactionID string) (*models.TransactionReview, error)
+	// SetTransactionFlag sets flag on the transaction when set is true and
+	// removes it otherwise
+	SetTransactionFlag(ctx context.Context, transactionID, flag, actor string, set bool) (*models.TransactionReview, error)
+	AnnotateTransaction(ctx context.Context, annotation *models.TransactionAnnotation) error
+}
+
+// queryer runs queries on a database or inside a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type PostgresTransactionReviewRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTransactionReviewRepository(db *sql.DB, logger *logrus.Logger) *PostgresTransactionReviewRepository {
+	return &PostgresTransactionReviewRepository{db: db, logger: logger}
+}
+
+// GetTransactionReview returns the flags and annotations of a transaction
+func (r *PostgresTransactionReviewRepository) GetTransactionReview(ctx context.Context, transactionID string) (*models.TransactionReview, error) {
+	logger := r.logger.WithContext(ctx).WithField("transactionID", transactionID)
+
+	var id string
+	err := r.db.QueryRowContext(ctx, "SELECT id::text FROM transactions WHERE id::text = $1", transactionID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("GetTransactionReview - Query transaction failed")
+		return nil, err
+	}
+
+	review, err := loadReview(ctx, r.db, id)
+	if err != nil {
+		logger.WithError(err).Error("GetTransactionReview - Query review failed")
+		return nil, err
+	}
+	return review, nil
+}
+
+// SetTransactionFlag sets or removes flag and records the
+// transaction.flags_changed event in the same transaction. Changes of the
+// flags of one transaction are applied one after the other; setting a flag
+// the transaction carries, or removing one it does not, records nothing.
+func (r *PostgresTransactionReviewRepository) SetTransactionFlag(ctx context.Context, transactionID, flag, actor string, set bool) (*models.TransactionReview, error) {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"transactionID": transactionID,
+		"flag":          flag,
+		"set":           set,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SetTransactionFlag - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id, fromUserID string
+	var toUserID sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"SELECT id::text, from_user_id, to_user_id FROM transactions WHERE id::text = $1 FOR UPDATE",
+		transactionID,
+	).Scan(&id, &fromUserID, &toUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("SetTransactionFlag - Cannot find transaction in the database")
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetTransactionFlag - Lock transaction failed")
+		return nil, err
+	}
+
+	var result sql.Result
+	if set {
+		result, err = tx.ExecContext(ctx,
+			`INSERT INTO transaction_flags (transaction_id, flag, flagged_by)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (transaction_id, flag) DO NOTHING`,
+			id, flag, actor,
+		)
+	} else {
+		result, err = tx.ExecContext(ctx,
+			"DELETE FROM transaction_flags WHERE transaction_id = $1 AND flag = $2",
+			id, flag,
+		)
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetTransactionFlag - Update flag failed")
+		return nil, err
+	}
+
+	review, err := loadReview(ctx, tx, id)
+	if err != nil {
+		logger.WithError(err).Error("SetTransactionFlag - Query review failed")
+		return nil, err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		payload := events.TransactionFlagsChanged{
+			TransactionID: id,
+			FromUserID:    fromUserID,
+			ToUserID:      toUserID.String,
+			Flags:         make([]string, 0, len(review.Flags)),
+		}
+		if set {
+			payload.Added = flag
+		} else {
+			payload.Removed = flag
+		}
+		for _, f := range review.Flags {
+			payload.Flags = append(payload.Flags, f.Flag)
+		}
+		if err = enqueueEvent(ctx, sqlTx{tx}, events.New(events.TypeTransactionFlagsChanged, payload), fromUserID); err != nil {
+			logger.WithError(err).Error("SetTransactionFlag - Record flags changed event failed")
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SetTransactionFlag - Commit DB transaction failed")
+		return nil, err
+	}
+	return review, nil
+}
+
+// AnnotateTransaction attaches annotation to its transaction, filling in its
+// ID and CreatedAt
+func (r *PostgresTransactionReviewRepository) AnnotateTransaction(ctx context.Context, annotation *models.TransactionAnnotation) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO transaction_annotations (transaction_id, note, created_by)
+		SELECT id, $2, $3 FROM transactions WHERE id::text = $1
+		RETURNING id::text, created_at`,
+		annotation.TransactionID, annotation.Note, annotation.CreatedBy,
+	).Scan(&annotation.ID, &annotation.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.WithContext(ctx).WithField("transactionID", annotation.TransactionID).Warn("AnnotateTransaction - Cannot find transaction in the database")
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("transactionID", annotation.TransactionID).Error("AnnotateTransaction - Insert annotation failed")
+		return err
+	}
+	return nil
+}
+
+// loadReview reads the flags and annotations of the transaction id
+func loadReview(ctx context.Context, q queryer, id string) (*models.TransactionReview, error) {
+	review := &models.TransactionReview{
+		TransactionID: id,
+		Flags:         []models.TransactionFlag{},
+		Annotations:   []models.TransactionAnnotation{},
+	}
+
+	rows, err := q.QueryContext(ctx,
+		`SELECT flag, flagged_by, flagged_at FROM transaction_flags
+		WHERE transaction_id = $1
+		ORDER BY flag`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var flag models.TransactionFlag
+		if err := rows.Scan(&flag.Flag, &flag.FlaggedBy, &flag.FlaggedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		review.Flags = append(review.Flags, flag)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx,
+		`SELECT id::text, transaction_id::text, note, created_by, created_at FROM transaction_annotations
+		WHERE transaction_id = $1
+		ORDER BY id`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var annotation models.TransactionAnnotation
+		if err := rows.Scan(&annotation.ID, &annotation.TransactionID, &annotation.Note, &annotation.CreatedBy, &annotation.CreatedAt); err != nil {
+			return nil, err
+		}
+		review.Annotations = append(review.Annotations, annotation)
+	}
+	return review, rows.Err()
+}
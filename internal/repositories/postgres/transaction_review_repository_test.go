@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestTransactionReviewRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewTransactionReviewRepository(mockDB, logrus.New())
+	now := time.Now()
+	flagColumns := []string{"flag", "flagged_by", "flagged_at"}
+	annotationColumns := []string{"id", "transaction_id", "note", "created_by", "created_at"}
+	lockColumns := []string{"id", "from_user_id", "to_user_id"}
+
+	t.Run("GetTransactionReview", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text FROM transactions`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+		mock.ExpectQuery(`FROM transaction_flags`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(flagColumns).AddRow(models.FlagSuspicious, "admin1", now))
+		mock.ExpectQuery(`FROM transaction_annotations`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(annotationColumns).AddRow("1", "7", "checked with the sender", "admin1", now))
+
+		review, err := repo.GetTransactionReview(ctx, "7")
+		require.NoError(t, err)
+		require.Equal(t, models.FlagSuspicious, review.Flags[0].Flag)
+		require.Equal(t, "checked with the sender", review.Annotations[0].Note)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTransactionReview not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text FROM transactions`).WithArgs("8").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.GetTransactionReview(ctx, "8")
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetTransactionFlag records an event", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transactions WHERE id::text = \$1 FOR UPDATE`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(lockColumns).AddRow("7", "user1", "user2"))
+		mock.ExpectExec(`INSERT INTO transaction_flags`).WithArgs("7", models.FlagSuspicious, "admin1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`FROM transaction_flags`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(flagColumns).AddRow(models.FlagSuspicious, "admin1", now))
+		mock.ExpectQuery(`FROM transaction_annotations`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(annotationColumns))
+		mock.ExpectExec(`INSERT INTO outbox_events`).
+			WithArgs(sqlmock.AnyArg(), events.TypeTransactionFlagsChanged, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		review, err := repo.SetTransactionFlag(ctx, "7", models.FlagSuspicious, "admin1", true)
+		require.NoError(t, err)
+		require.Len(t, review.Flags, 1)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetTransactionFlag removing a missing flag records nothing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(lockColumns).AddRow("7", "user1", nil))
+		mock.ExpectExec(`DELETE FROM transaction_flags`).WithArgs("7", models.FlagReviewed).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FROM transaction_flags`).WillReturnRows(sqlmock.NewRows(flagColumns))
+		mock.ExpectQuery(`FROM transaction_annotations`).WillReturnRows(sqlmock.NewRows(annotationColumns))
+		mock.ExpectCommit()
+
+		review, err := repo.SetTransactionFlag(ctx, "7", models.FlagReviewed, "admin1", false)
+		require.NoError(t, err)
+		require.Empty(t, review.Flags)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AnnotateTransaction not found", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO transaction_annotations`).WithArgs("8", "note", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+		err := repo.AnnotateTransaction(ctx, &models.TransactionAnnotation{TransactionID: "8", Note: "note", CreatedBy: "admin1"})
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
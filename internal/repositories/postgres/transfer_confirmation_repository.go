@@ -0,0 +1,283 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/otp"
+)
+
+// TransferConfirmationRepository stores the transfers waiting for their
+// sender to confirm them with a one-time password
+type TransferConfirmationRepository interface {
+	CreateTransferConfirmation(ctx context.Context, confirmation *models.TransferConfirmation) (bool, error)
+	GetTransferConfirmation(ctx context.Context, senderID, confirmationID string) (*models.TransferConfirmation, error)
+	VerifyTransferConfirmation(ctx context.Context, senderID, confirmationID, code string, maxAttempts int) (*models.TransferConfirmation, error)
+	CompleteTransferConfirmation(ctx context.Context, senderID, confirmationID string) (*models.TransferConfirmation, error)
+	ExpireTransferConfirmations(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+var (
+	ErrTransferConfirmationNotFound   = errors.New("transfer confirmation not found")
+	ErrTransferConfirmationNotPending = errors.New("transfer confirmation is not pending")
+	ErrInvalidConfirmationCode        = errors.New("invalid confirmation code")
+)
+
+const transferConfirmationColumns = `id::text, sender_id, receiver_id, amount, expected_balance, COALESCE(memo, ''), COALESCE(reference_id, ''),
+	metadata, code_hash, attempts, status, created_at, expires_at, confirmed_at`
+
+type PostgresTransferConfirmationRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTransferConfirmationRepository(db *sql.DB, logger *logrus.Logger) *PostgresTransferConfirmationRepository {
+	return &PostgresTransferConfirmationRepository{db: db, logger: logger}
+}
+
+// CreateTransferConfirmation records a pending confirmation with the
+// transaction details and Idempotency-Key in ctx, filling in its ID, status
+// and creation time. The receiver must have a wallet. When the sender used
+// the idempotency key before, confirmation is replaced by the one created
+// then and false is returned.
+func (r *PostgresTransferConfirmationRepository) CreateTransferConfirmation(ctx context.Context, confirmation *models.TransferConfirmation) (bool, error) {
+	if confirmation.SenderID == "" || confirmation.ReceiverID == "" {
+		r.logger.WithContext(ctx).Warn("CreateTransferConfirmation - userID cannot be an empty string")
+		return false, ErrInvalidUserID
+	}
+
+	if confirmation.SenderID == confirmation.ReceiverID {
+		r.logger.WithContext(ctx).Warn("CreateTransferConfirmation - senderID and receiverID cannot be the same")
+		return false, ErrInvalidUserID
+	}
+
+	if !confirmation.Amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("CreateTransferConfirmation - amount cannot be less than zero")
+		return false, ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"senderID":   confirmation.SenderID,
+		"receiverID": confirmation.ReceiverID,
+	})
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1)",
+		confirmation.ReceiverID,
+	).Scan(&exists)
+	if err != nil {
+		logger.WithError(err).Error("CreateTransferConfirmation - Query receiver wallet failed")
+		return false, err
+	}
+	if !exists {
+		logger.Warn("CreateTransferConfirmation - Cannot find receiver wallet in the database")
+		return false, ErrUserNotFound
+	}
+
+	op, _ := operation.From(ctx)
+	memo, reference, metadata := transactionDetails(ctx)
+	confirmation.Status = models.TransferConfirmationPending
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO transfer_confirmations
+		(sender_id, receiver_id, amount, expected_balance, memo, reference_id, metadata, code_hash, status, idempotency_key, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (sender_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING id::text, created_at`,
+		confirmation.SenderID, confirmation.ReceiverID, confirmation.Amount, confirmation.ExpectedBalance, memo, reference, metadata,
+		confirmation.CodeHash, confirmation.Status, nullString(op.IdempotencyKey), confirmation.ExpiresAt,
+	).Scan(&confirmation.ID, &confirmation.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, err := scanTransferConfirmation(r.db.QueryRowContext(ctx,
+			`SELECT `+transferConfirmationColumns+`
+			FROM transfer_confirmations
+			WHERE sender_id = $1 AND idempotency_key = $2`,
+			confirmation.SenderID, op.IdempotencyKey,
+		))
+		if err != nil {
+			logger.WithError(err).Error("CreateTransferConfirmation - Query existing confirmation failed")
+			return false, err
+		}
+		*confirmation = *existing
+		return false, nil
+	}
+	if err != nil {
+		logger.WithError(err).Error("CreateTransferConfirmation - Create confirmation failed")
+		return false, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"confirmationID": confirmation.ID,
+		"expiresAt":      confirmation.ExpiresAt,
+	}).Info("Transfer confirmation requested")
+	return true, nil
+}
+
+// GetTransferConfirmation returns the confirmation confirmationID of a
+// transfer from senderID
+func (r *PostgresTransferConfirmationRepository) GetTransferConfirmation(ctx context.Context, senderID, confirmationID string) (*models.TransferConfirmation, error) {
+	confirmation, err := scanTransferConfirmation(r.db.QueryRowContext(ctx,
+		`SELECT `+transferConfirmationColumns+`
+		FROM transfer_confirmations
+		WHERE id::text = $1 AND sender_id = $2`,
+		confirmationID, senderID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferConfirmationNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("confirmationID", confirmationID).Error("GetTransferConfirmation - Query confirmation failed")
+		return nil, err
+	}
+	return confirmation, nil
+}
+
+// VerifyTransferConfirmation checks code against a pending confirmation.
+// A wrong code counts as an attempt and returns ErrInvalidConfirmationCode;
+// the attempt reaching maxAttempts fails the confirmation. A right code
+// leaves the confirmation pending until it is completed.
+func (r *PostgresTransferConfirmationRepository) VerifyTransferConfirmation(ctx context.Context, senderID, confirmationID, code string, maxAttempts int) (*models.TransferConfirmation, error) {
+	logger := r.logger.WithContext(ctx).WithField("confirmationID", confirmationID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("VerifyTransferConfirmation - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	confirmation, err := scanTransferConfirmation(tx.QueryRowContext(ctx,
+		`SELECT `+transferConfirmationColumns+`
+		FROM transfer_confirmations
+		WHERE id::text = $1 AND sender_id = $2
+		FOR UPDATE`,
+		confirmationID, senderID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("VerifyTransferConfirmation - Cannot find confirmation in the database")
+		return nil, ErrTransferConfirmationNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("VerifyTransferConfirmation - Query confirmation failed")
+		return nil, err
+	}
+	if confirmation.Status != models.TransferConfirmationPending {
+		logger.WithField("current", confirmation.Status).Warn("VerifyTransferConfirmation - Confirmation not pending")
+		return nil, ErrTransferConfirmationNotPending
+	}
+
+	if otp.Matches(confirmation.CodeHash, code) {
+		return confirmation, nil
+	}
+
+	confirmation.Attempts++
+	if confirmation.Attempts >= maxAttempts {
+		confirmation.Status = models.TransferConfirmationFailed
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE transfer_confirmations SET attempts = $1, status = $2 WHERE id::text = $3",
+		confirmation.Attempts, confirmation.Status, confirmationID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("VerifyTransferConfirmation - Record attempt failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("VerifyTransferConfirmation - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"attempts": confirmation.Attempts,
+		"status":   confirmation.Status,
+	}).Warn("VerifyTransferConfirmation - Wrong confirmation code")
+	return nil, ErrInvalidConfirmationCode
+}
+
+// CompleteTransferConfirmation records a pending confirmation of a transfer
+// from senderID as confirmed. It does not move funds.
+func (r *PostgresTransferConfirmationRepository) CompleteTransferConfirmation(ctx context.Context, senderID, confirmationID string) (*models.TransferConfirmation, error) {
+	confirmation, err := scanTransferConfirmation(r.db.QueryRowContext(ctx,
+		`UPDATE transfer_confirmations SET status = $1, confirmed_at = NOW()
+		WHERE id::text = $2 AND sender_id = $3 AND status = $4
+		RETURNING `+transferConfirmationColumns,
+		models.TransferConfirmationConfirmed, confirmationID, senderID, models.TransferConfirmationPending,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.WithContext(ctx).WithField("confirmationID", confirmationID).Warn("CompleteTransferConfirmation - Confirmation not pending")
+		return nil, ErrTransferConfirmationNotPending
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("confirmationID", confirmationID).Error("CompleteTransferConfirmation - Update confirmation failed")
+		return nil, err
+	}
+	return confirmation, nil
+}
+
+// ExpireTransferConfirmations expires up to limit pending confirmations
+// whose expiry is before before, oldest first. Confirmations locked by a
+// concurrent verification are skipped.
+func (r *PostgresTransferConfirmationRepository) ExpireTransferConfirmations(ctx context.Context, before time.Time, limit int) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE transfer_confirmations SET status = $1
+		WHERE id IN (
+			SELECT id FROM transfer_confirmations
+			WHERE status = $2 AND expires_at <= $3
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)`,
+		models.TransferConfirmationExpired, models.TransferConfirmationPending, before, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpireTransferConfirmations - Expire confirmations failed")
+		return 0, err
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		r.logger.WithContext(ctx).WithField("expired", expired).Info("Transfer confirmations expired")
+	}
+	return int(expired), nil
+}
+
+func scanTransferConfirmation(row rowScanner) (*models.TransferConfirmation, error) {
+	var confirmation models.TransferConfirmation
+	var expectedBalance decimal.NullDecimal
+	var metadata metadataColumn
+	err := row.Scan(
+		&confirmation.ID,
+		&confirmation.SenderID,
+		&confirmation.ReceiverID,
+		&confirmation.Amount,
+		&expectedBalance,
+		&confirmation.Memo,
+		&confirmation.ReferenceID,
+		&metadata,
+		&confirmation.CodeHash,
+		&confirmation.Attempts,
+		&confirmation.Status,
+		&confirmation.CreatedAt,
+		&confirmation.ExpiresAt,
+		&confirmation.ConfirmedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if expectedBalance.Valid {
+		confirmation.ExpectedBalance = &expectedBalance.Decimal
+	}
+	confirmation.Metadata = metadata
+	return &confirmation, nil
+}
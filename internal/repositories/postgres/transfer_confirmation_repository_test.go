@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/otp"
+)
+
+func TestTransferConfirmationRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewTransferConfirmationRepository(mockDB, logrus.New())
+	now := time.Now()
+	expiresAt := now.Add(5 * time.Minute)
+	hash := otp.Hash("123456")
+	columns := []string{"id", "sender_id", "receiver_id", "amount", "expected_balance", "memo", "reference_id", "metadata",
+		"code_hash", "attempts", "status", "created_at", "expires_at", "confirmed_at"}
+	row := func(attempts int, status string) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow("7", "user1", "user2", "5000", nil, "Rent", "", nil, hash, attempts, status, now, expiresAt, nil)
+	}
+
+	t.Run("CreateTransferConfirmation records a pending confirmation", func(t *testing.T) {
+		confirmation := &models.TransferConfirmation{SenderID: "user1", ReceiverID: "user2", Amount: decimal.NewFromInt(5000), CodeHash: hash, ExpiresAt: expiresAt}
+		opCtx := operation.With(ctx, operation.Operation{IdempotencyKey: "key-1"})
+
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM wallets WHERE user_id = \$1\)`).WithArgs("user2").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`INSERT INTO transfer_confirmations`).
+			WithArgs("user1", "user2", confirmation.Amount, nil, nil, nil, nil, hash, models.TransferConfirmationPending, "key-1", expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("7", now))
+
+		created, err := repo.CreateTransferConfirmation(opCtx, confirmation)
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, "7", confirmation.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateTransferConfirmation returns the confirmation of a reused key", func(t *testing.T) {
+		confirmation := &models.TransferConfirmation{SenderID: "user1", ReceiverID: "user2", Amount: decimal.NewFromInt(5000), CodeHash: otp.Hash("654321"), ExpiresAt: expiresAt}
+		opCtx := operation.With(ctx, operation.Operation{IdempotencyKey: "key-1"})
+
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("user2").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`INSERT INTO transfer_confirmations`).WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+		mock.ExpectQuery(`FROM transfer_confirmations\s+WHERE sender_id = \$1 AND idempotency_key = \$2`).WithArgs("user1", "key-1").
+			WillReturnRows(row(0, models.TransferConfirmationPending))
+
+		created, err := repo.CreateTransferConfirmation(opCtx, confirmation)
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, "7", confirmation.ID)
+		require.Equal(t, hash, confirmation.CodeHash)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateTransferConfirmation to an unknown receiver", func(t *testing.T) {
+		confirmation := &models.TransferConfirmation{SenderID: "user1", ReceiverID: "ghost", Amount: decimal.NewFromInt(5000)}
+
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("ghost").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := repo.CreateTransferConfirmation(ctx, confirmation)
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("VerifyTransferConfirmation accepts the right code", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transfer_confirmations\s+WHERE id::text = \$1 AND sender_id = \$2\s+FOR UPDATE`).WithArgs("7", "user1").
+			WillReturnRows(row(0, models.TransferConfirmationPending))
+		mock.ExpectRollback()
+
+		confirmation, err := repo.VerifyTransferConfirmation(ctx, "user1", "7", "123456", 5)
+		require.NoError(t, err)
+		require.Equal(t, "Rent", confirmation.Memo)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("VerifyTransferConfirmation fails the confirmation on the last attempt", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transfer_confirmations`).WithArgs("7", "user1").
+			WillReturnRows(row(4, models.TransferConfirmationPending))
+		mock.ExpectExec(`UPDATE transfer_confirmations SET attempts = \$1, status = \$2`).
+			WithArgs(5, models.TransferConfirmationFailed, "7").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.VerifyTransferConfirmation(ctx, "user1", "7", "000000", 5)
+		require.ErrorIs(t, err, ErrInvalidConfirmationCode)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("VerifyTransferConfirmation of a failed confirmation", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM transfer_confirmations`).WithArgs("7", "user1").
+			WillReturnRows(row(5, models.TransferConfirmationFailed))
+		mock.ExpectRollback()
+
+		_, err := repo.VerifyTransferConfirmation(ctx, "user1", "7", "123456", 5)
+		require.ErrorIs(t, err, ErrTransferConfirmationNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CompleteTransferConfirmation of a confirmation no longer pending", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE transfer_confirmations SET status = \$1, confirmed_at = NOW\(\)`).
+			WithArgs(models.TransferConfirmationConfirmed, "7", "user1", models.TransferConfirmationPending).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.CompleteTransferConfirmation(ctx, "user1", "7")
+		require.ErrorIs(t, err, ErrTransferConfirmationNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpireTransferConfirmations", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE transfer_confirmations SET status = \$1\s+WHERE id IN`).
+			WithArgs(models.TransferConfirmationExpired, models.TransferConfirmationPending, now, 100).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		expired, err := repo.ExpireTransferConfirmations(ctx, now, 100)
+		require.NoError(t, err)
+		require.Equal(t, 3, expired)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// TrialBalanceRepository generates and stores the daily trial balances
+type TrialBalanceRepository interface {
+	GenerateTrialBalance(ctx context.Context, day time.Time, generatedBy string) (*models.TrialBalance, error)
+	GetTrialBalance(ctx context.Context, day time.Time) (*models.TrialBalance, error)
+	ListTrialBalances(ctx context.Context, limit int) ([]models.TrialBalance, error)
+}
+
+var (
+	ErrTrialBalanceNotFound = errors.New("trial balance not found")
+	ErrTrialBalanceExists   = errors.New("trial balance already generated")
+)
+
+type PostgresTrialBalanceRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewTrialBalanceRepository(db *sql.DB, logger *logrus.Logger) *PostgresTrialBalanceRepository {
+	return &PostgresTrialBalanceRepository{db: db, logger: logger}
+}
+
+// GenerateTrialBalance computes and stores the trial balance of the UTC day
+// starting at day. It fails with ErrTrialBalanceExists when the day was
+// generated already, by this or another instance.
+func (r *PostgresTrialBalanceRepository) GenerateTrialBalance(ctx context.Context, day time.Time, generatedBy string) (*models.TrialBalance, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	logger := r.logger.WithContext(ctx).WithField("day", start.Format(models.TrialBalanceDayFormat))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Claiming the day first makes a concurrent generation wait and then
+	// find it taken
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO trial_balances (day, generated_by)
+		VALUES ($1, $2)
+		ON CONFLICT (day) DO NOTHING`,
+		start, generatedBy,
+	)
+	if err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Claim day failed")
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, ErrTrialBalanceExists
+	}
+
+	// Each transaction is split into the effect on every wallet it touches:
+	// what it adds is a credit, what it takes a debit
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO trial_balance_currencies (day, currency, debits, credits, transactions)
+		SELECT $1, currency, SUM(GREATEST(-effect, 0)), SUM(GREATEST(effect, 0)), COUNT(DISTINCT id)
+		FROM (
+			SELECT t.id, COALESCE(w.currency, '') AS currency, `+ledgerEffect+` AS effect
+			FROM transactions t
+			JOIN wallets w ON w.user_id = t.from_user_id OR w.user_id = t.to_user_id
+			WHERE t.status <> 'failed' AND t.created_at >= $2 AND t.created_at < $3
+		) entries
+		GROUP BY currency`,
+		start, start, end,
+	)
+	if err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Total currencies failed")
+		return nil, err
+	}
+
+	// Balances as of the last instant of the day
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO trial_balance_accounts (day, account, currency, balance)
+		SELECT $2, w.user_id, w.currency, `+balanceAsOf+`
+		FROM wallets w
+		`+latestSnapshot+`
+		WHERE w.label = $3`,
+		end.Add(-time.Microsecond), start, models.SystemAccountLabel,
+	)
+	if err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Record system accounts failed")
+		return nil, err
+	}
+
+	trialBalance, err := getTrialBalance(ctx, tx, start)
+	if err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Read trial balance failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("GenerateTrialBalance - Commit DB transaction failed")
+		return nil, err
+	}
+
+	logger.WithField("currencies", len(trialBalance.Currencies)).Info("Trial balance generated")
+	return trialBalance, nil
+}
+
+// GetTrialBalance returns the trial balance of the UTC day starting at day
+func (r *PostgresTrialBalanceRepository) GetTrialBalance(ctx context.Context, day time.Time) (*models.TrialBalance, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("GetTrialBalance - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	trialBalance, err := getTrialBalance(ctx, tx, day.UTC().Truncate(24*time.Hour))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrialBalanceNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("day", day).Error("GetTrialBalance - Query trial balance failed")
+		return nil, err
+	}
+	return trialBalance, tx.Commit()
+}
+
+// ListTrialBalances returns the latest days generated, newest first, without
+// their figures
+func (r *PostgresTrialBalanceRepository) ListTrialBalances(ctx context.Context, limit int) ([]models.TrialBalance, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT day::text, generated_by, generated_at
+		FROM trial_balances
+		ORDER BY day DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListTrialBalances - Query trial balances failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	trialBalances := []models.TrialBalance{}
+	for rows.Next() {
+		var trialBalance models.TrialBalance
+		if err := rows.Scan(&trialBalance.Day, &trialBalance.GeneratedBy, &trialBalance.GeneratedAt); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListTrialBalances - Scan trial balance failed")
+			return nil, err
+		}
+		trialBalances = append(trialBalances, trialBalance)
+	}
+	return trialBalances, rows.Err()
+}
+
+// getTrialBalance reads the trial balance of day with its figures inside tx
+func getTrialBalance(ctx context.Context, tx *sql.Tx, day time.Time) (*models.TrialBalance, error) {
+	trialBalance := models.TrialBalance{
+		Currencies:     []models.TrialBalanceCurrency{},
+		SystemAccounts: []models.TrialBalanceAccount{},
+	}
+	err := tx.QueryRowContext(ctx,
+		`SELECT day::text, generated_by, generated_at FROM trial_balances WHERE day = $1`,
+		day,
+	).Scan(&trialBalance.Day, &trialBalance.GeneratedBy, &trialBalance.GeneratedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT NULLIF(currency, ''), debits, credits, transactions
+		FROM trial_balance_currencies
+		WHERE day = $1
+		ORDER BY currency`,
+		day,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var currency models.TrialBalanceCurrency
+		if err := rows.Scan(&currency.Currency, &currency.Debits, &currency.Credits, &currency.Transactions); err != nil {
+			return nil, err
+		}
+		trialBalance.Currencies = append(trialBalance.Currencies, currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx,
+		`SELECT account, currency, balance
+		FROM trial_balance_accounts
+		WHERE day = $1
+		ORDER BY account`,
+		day,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var account models.TrialBalanceAccount
+		if err := rows.Scan(&account.Account, &account.Currency, &account.Balance); err != nil {
+			return nil, err
+		}
+		trialBalance.SystemAccounts = append(trialBalance.SystemAccounts, account)
+	}
+	return &trialBalance, rows.Err()
+}
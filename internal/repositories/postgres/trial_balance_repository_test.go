@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestTrialBalanceRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewTrialBalanceRepository(mockDB, logrus.New())
+	day := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := day.Add(24 * time.Hour)
+	now := time.Now()
+
+	t.Run("GenerateTrialBalance", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO trial_balances`).WithArgs(day, "trial-balance").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO trial_balance_currencies .+GREATEST\(-effect, 0\)`).WithArgs(day, day, end).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO trial_balance_accounts`).WithArgs(end.Add(-time.Microsecond), day, models.SystemAccountLabel).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT day::text, generated_by, generated_at FROM trial_balances`).WithArgs(day).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "generated_by", "generated_at"}).AddRow("2024-05-01", "trial-balance", now))
+		mock.ExpectQuery(`FROM trial_balance_currencies`).WithArgs(day).
+			WillReturnRows(sqlmock.NewRows([]string{"currency", "debits", "credits", "transactions"}).
+				AddRow(nil, "40", "100", 3).
+				AddRow("EUR", "250", "250", 2))
+		mock.ExpectQuery(`FROM trial_balance_accounts`).WithArgs(day).
+			WillReturnRows(sqlmock.NewRows([]string{"account", "currency", "balance"}).AddRow(models.SystemAccountFees, nil, "12.5"))
+		mock.ExpectCommit()
+
+		trialBalance, err := repo.GenerateTrialBalance(ctx, day.Add(5*time.Hour), "trial-balance")
+		require.NoError(t, err)
+		require.Equal(t, "2024-05-01", trialBalance.Day)
+		require.Len(t, trialBalance.Currencies, 2)
+		require.Nil(t, trialBalance.Currencies[0].Currency)
+		require.Equal(t, "EUR", *trialBalance.Currencies[1].Currency)
+		require.True(t, decimal.RequireFromString("12.5").Equal(trialBalance.SystemAccounts[0].Balance))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GenerateTrialBalance already generated", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO trial_balances`).WithArgs(day, "trial-balance").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.GenerateTrialBalance(ctx, day, "trial-balance")
+		require.ErrorIs(t, err, ErrTrialBalanceExists)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTrialBalance not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT day::text, generated_by, generated_at FROM trial_balances`).WithArgs(day).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "generated_by", "generated_at"}))
+		mock.ExpectRollback()
+
+		_, err := repo.GetTrialBalance(ctx, day)
+		require.ErrorIs(t, err, ErrTrialBalanceNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListTrialBalances", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT day::text, generated_by, generated_at\s+FROM trial_balances\s+ORDER BY day DESC`).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "generated_by", "generated_at"}).
+				AddRow("2024-05-02", "trial-balance", now).
+				AddRow("2024-05-01", "trial-balance", now))
+
+		trialBalances, err := repo.ListTrialBalances(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, trialBalances, 2)
+		require.Nil(t, trialBalances[0].Currencies)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
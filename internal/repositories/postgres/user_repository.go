@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// UserRepository registers the users wallets belong to
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User, currencies []string) error
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	GetUserByExternalID(ctx context.Context, externalID string) (*models.User, error)
+}
+
+var ErrUserExists = errors.New("a user with this external ID already exists")
+
+// pgForeignKeyViolation is raised when a wallet or a transaction refers to a
+// user that is not registered
+const pgForeignKeyViolation = "23503"
+
+// isUnregisteredUser reports whether err rejected a write for referring to a
+// user that is not registered
+func isUnregisteredUser(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation
+}
+
+type PostgresUserRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewUserRepository(db *sql.DB, logger *logrus.Logger) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db, logger: logger}
+}
+
+// CreateUser registers a customer with a new UUID, with an empty active
+// wallet in each of currencies and their wallet.created events, in one
+// transaction. The first currency is the wallet of the user, the others are
+// currency sub-accounts. user.ID, Kind, CreatedAt and Wallets are filled in
+// on success.
+func (r *PostgresUserRepository) CreateUser(ctx context.Context, user *models.User, currencies []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("CreateUser - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO users (kind, external_id) VALUES ($1, $2)
+		ON CONFLICT (external_id) DO NOTHING
+		RETURNING id, kind, created_at`,
+		models.UserKindCustomer, user.ExternalID,
+	).Scan(&user.ID, &user.Kind, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.WithContext(ctx).WithField("externalID", *user.ExternalID).Warn("CreateUser - External ID is taken")
+		return ErrUserExists
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("CreateUser - Insert user failed")
+		return err
+	}
+
+	logger := r.logger.WithContext(ctx).WithField("userID", user.ID)
+	wallets := make([]models.Wallet, 0, len(currencies))
+	for i, currency := range currencies {
+		wallet, err := createDefaultWallet(ctx, tx, logger, user.ID, currency, i == 0)
+		if err != nil {
+			return err
+		}
+		wallets = append(wallets, *wallet)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreateUser - Commit DB transaction failed")
+		return err
+	}
+	user.Wallets = wallets
+	return nil
+}
+
+// createDefaultWallet creates the empty active wallet of userID in currency
+// and records its wallet.created event, registering the currency sub-account
+// unless it is the wallet of the user. The currency must have been created
+// by the bootstrap command.
+func createDefaultWallet(ctx context.Context, tx *sql.Tx, logger *logrus.Entry, userID, currency string, primary bool) (*models.Wallet, error) {
+	logger = logger.WithField("currency", currency)
+
+	var known bool
+	err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM currencies WHERE code = $1)",
+		currency,
+	).Scan(&known)
+	if err != nil {
+		logger.WithError(err).Error("CreateUser - Query currency failed")
+		return nil, err
+	}
+	if !known {
+		logger.Warn("CreateUser - Cannot find currency in the database")
+		return nil, ErrCurrencyNotFound
+	}
+
+	accountID := userID
+	if !primary {
+		accountID = models.CurrencyAccount(userID, currency)
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO users (id, kind) VALUES ($1, $2)",
+			accountID, models.UserKindCurrency,
+		)
+		if err != nil {
+			logger.WithError(err).Error("CreateUser - Register currency sub-account failed")
+			return nil, err
+		}
+	}
+
+	wallet := &models.Wallet{UserID: accountID, Status: models.WalletStatusActive, Currency: &currency}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO wallets (user_id, currency, status) VALUES ($1, $2, $3)",
+		accountID, currency, wallet.Status,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CreateUser - Create wallet failed")
+		return nil, err
+	}
+
+	event := events.New(events.TypeWalletCreated, events.WalletLifecycleChanged{
+		UserID:  accountID,
+		Current: events.WalletState{Status: wallet.Status, Currency: currency},
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, accountID); err != nil {
+		logger.WithError(err).Error("CreateUser - Record wallet created event failed")
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetUser returns the registered user userID
+func (r *PostgresUserRepository) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx,
+		"SELECT id, kind, external_id, created_at FROM users WHERE id = $1",
+		userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetUser - Query user failed")
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByExternalID returns the user the integrator identifies as
+// externalID
+func (r *PostgresUserRepository) GetUserByExternalID(ctx context.Context, externalID string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx,
+		"SELECT id, kind, external_id, created_at FROM users WHERE external_id = $1",
+		externalID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("externalID", externalID).Error("GetUserByExternalID - Query user failed")
+		return nil, err
+	}
+	return user, nil
+}
+
+func scanUser(row *sql.Row) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(&user.ID, &user.Kind, &user.ExternalID, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
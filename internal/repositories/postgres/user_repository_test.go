@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewUserRepository(mockDB, logrus.New())
+	now := time.Now()
+	userID := "6f1c2a4e-8a57-4e0b-9a53-3b1f2f0c9d11"
+	externalID := "crm-42"
+	columns := []string{"id", "kind", "external_id", "created_at"}
+
+	expectCurrency := func(currency string, known bool) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies WHERE code = \$1\)`).WithArgs(currency).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(known))
+	}
+
+	t.Run("CreateUser", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users \(kind, external_id\)`).WithArgs(models.UserKindCustomer, &externalID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "created_at"}).AddRow(userID, models.UserKindCustomer, now))
+		mock.ExpectCommit()
+
+		user := &models.User{ExternalID: &externalID}
+		require.NoError(t, repo.CreateUser(ctx, user, nil))
+		require.Equal(t, userID, user.ID)
+		require.Equal(t, models.UserKindCustomer, user.Kind)
+		require.Empty(t, user.Wallets)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateUser with wallets in the default currencies", func(t *testing.T) {
+		eurAccount := userID + ":EUR"
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users \(kind, external_id\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "created_at"}).AddRow(userID, models.UserKindCustomer, now))
+		expectCurrency("USD", true)
+		mock.ExpectExec(`INSERT INTO wallets \(user_id, currency, status\)`).WithArgs(userID, "USD", models.WalletStatusActive).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), "wallet.created", userID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectCurrency("EUR", true)
+		mock.ExpectExec(`INSERT INTO users \(id, kind\)`).WithArgs(eurAccount, models.UserKindCurrency).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO wallets \(user_id, currency, status\)`).WithArgs(eurAccount, "EUR", models.WalletStatusActive).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), "wallet.created", eurAccount, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		user := &models.User{}
+		require.NoError(t, repo.CreateUser(ctx, user, []string{"USD", "EUR"}))
+		require.Len(t, user.Wallets, 2)
+		require.Equal(t, userID, user.Wallets[0].UserID)
+		require.Equal(t, "USD", *user.Wallets[0].Currency)
+		require.Equal(t, eurAccount, user.Wallets[1].UserID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateUser unknown currency", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users \(kind, external_id\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "created_at"}).AddRow(userID, models.UserKindCustomer, now))
+		expectCurrency("XYZ", false)
+		mock.ExpectRollback()
+
+		user := &models.User{}
+		require.ErrorIs(t, repo.CreateUser(ctx, user, []string{"XYZ"}), ErrCurrencyNotFound)
+		require.Empty(t, user.Wallets)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateUser external ID taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "created_at"}))
+		mock.ExpectRollback()
+
+		err := repo.CreateUser(ctx, &models.User{ExternalID: &externalID}, []string{"USD"})
+		require.ErrorIs(t, err, ErrUserExists)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetUser", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE id = \$1`).WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(userID, models.UserKindCustomer, externalID, now))
+
+		user, err := repo.GetUser(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, externalID, *user.ExternalID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetUser not found", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE id = \$1`).WithArgs(userID).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetUser(ctx, userID)
+		require.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetUserByExternalID", func(t *testing.T) {
+		mock.ExpectQuery(`FROM users WHERE external_id = \$1`).WithArgs(externalID).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(userID, models.UserKindCustomer, externalID, now))
+
+		user, err := repo.GetUserByExternalID(ctx, externalID)
+		require.NoError(t, err)
+		require.Equal(t, userID, user.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("isUnregisteredUser", func(t *testing.T) {
+		require.True(t, isUnregisteredUser(&pgconn.PgError{Code: pgForeignKeyViolation}))
+		require.False(t, isUnregisteredUser(sql.ErrNoRows))
+	})
+}
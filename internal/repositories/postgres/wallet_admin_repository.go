@@ -0,0 +1,517 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// WalletAdminRepository gives operators direct control over single wallets:
+// listing them, changing their status and adjusting their balance
+type WalletAdminRepository interface {
+	ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error)
+	SetStatus(ctx context.Context, userID, from, to, reason string) error
+	CloseWallet(ctx context.Context, userID, reason string) error
+	AdjustBalance(ctx context.Context, adjustment *models.BalanceAdjustment) error
+	SetCurrency(ctx context.Context, userID, currency string) error
+}
+
+var (
+	ErrWalletNotFrozen  = errors.New("wallet is not frozen")
+	ErrWalletNotEmpty   = errors.New("wallet still has a balance or held funds")
+	ErrCurrencyNotFound = errors.New("currency not found")
+)
+
+type PostgresWalletAdminRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWalletAdminRepository(db *sql.DB, logger *logrus.Logger) *PostgresWalletAdminRepository {
+	return &PostgresWalletAdminRepository{db: db, logger: logger}
+}
+
+// ListWallets returns up to filter.Limit wallets matching every set filter,
+// ordered by user ID
+func (r *PostgresWalletAdminRepository) ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+	if filter.Limit <= 0 {
+		r.logger.WithContext(ctx).Warn("ListWallets - limit cannot be less than 0")
+		return nil, ErrInvalidLimit
+	}
+
+	var conditions []string
+	var args []interface{}
+	next := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, "status = "+next(*filter.Status))
+	}
+	if filter.Label != nil {
+		conditions = append(conditions, "label = "+next(*filter.Label))
+	}
+	if filter.Country != nil {
+		conditions = append(conditions, "country = "+next(*filter.Country))
+	}
+	if filter.MinBalance != nil {
+		conditions = append(conditions, "balance >= "+next(*filter.MinBalance))
+	}
+	if filter.MaxBalance != nil {
+		conditions = append(conditions, "balance <= "+next(*filter.MaxBalance))
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "metadata @> "+next(metadata))
+	}
+	if filter.After != "" {
+		conditions = append(conditions, "user_id > "+next(filter.After))
+	}
+
+	query := `SELECT user_id, balance, held, status, label, country, currency, metadata FROM wallets`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY user_id LIMIT ` + next(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListWallets - Query wallets failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []models.Wallet
+	for rows.Next() {
+		var wallet models.Wallet
+		var metadata []byte
+		err := rows.Scan(&wallet.UserID, &wallet.Balance, &wallet.Held, &wallet.Status, &wallet.Label, &wallet.Country, &wallet.Currency, &metadata)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListWallets - Scan wallets failed")
+			return nil, err
+		}
+		if err := json.Unmarshal(metadata, &wallet.Metadata); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ListWallets - Decode metadata failed")
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListWallets - Iterate wallets failed")
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// SetStatus moves the wallet of userID from status from to status to and
+// records the lifecycle event. It fails with ErrWalletFrozen or
+// ErrWalletClosed when the wallet is in one of those statuses instead of
+// from, and with ErrWalletNotFrozen when an active wallet was expected to be
+// frozen.
+func (r *PostgresWalletAdminRepository) SetStatus(ctx context.Context, userID, from, to, reason string) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"status": to,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SetStatus - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("SetStatus - Cannot find wallet in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("SetStatus - Query wallet status failed")
+		return err
+	}
+	if status != from {
+		logger.WithField("currentStatus", status).Warn("SetStatus - Wallet is not in the expected status")
+		if err := statusError(status); err != nil {
+			return err
+		}
+		return ErrWalletNotFrozen
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET status = $1 WHERE user_id = $2",
+		to, userID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("SetStatus - Update wallet status failed")
+		return err
+	}
+
+	eventType := events.TypeWalletFrozen
+	if to == models.WalletStatusActive {
+		eventType = events.TypeWalletUnfrozen
+	}
+	event := events.New(eventType, events.WalletLifecycleChanged{
+		UserID:   userID,
+		Previous: &events.WalletState{Status: from},
+		Current:  events.WalletState{Status: to},
+		Reason:   &reason,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+		logger.WithError(err).Error("SetStatus - Record lifecycle event failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SetStatus - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("reason", reason).Info("Wallet status changed")
+	return nil
+}
+
+// CloseWallet closes the wallet of userID for good and records the lifecycle
+// event. Active and frozen wallets can be closed once their balance and held
+// funds are zero; otherwise it fails with ErrWalletNotEmpty. Closing a closed
+// wallet fails with ErrWalletClosed.
+func (r *PostgresWalletAdminRepository) CloseWallet(ctx context.Context, userID, reason string) error {
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CloseWallet - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance, held decimal.Decimal
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&balance, &held, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CloseWallet - Cannot find wallet in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("CloseWallet - Query wallet balance failed")
+		return err
+	}
+
+	if status == models.WalletStatusClosed {
+		logger.Warn("CloseWallet - Wallet is already closed")
+		return ErrWalletClosed
+	}
+
+	if !balance.IsZero() || !held.IsZero() {
+		logger.WithFields(logrus.Fields{
+			"balance": balance,
+			"held":    held,
+		}).Warn("CloseWallet - Wallet is not empty")
+		return ErrWalletNotEmpty
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET status = $1 WHERE user_id = $2",
+		models.WalletStatusClosed, userID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CloseWallet - Update wallet status failed")
+		return err
+	}
+
+	event := events.New(events.TypeWalletClosed, events.WalletLifecycleChanged{
+		UserID:   userID,
+		Previous: &events.WalletState{Status: status},
+		Current:  events.WalletState{Status: models.WalletStatusClosed},
+		Reason:   &reason,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+		logger.WithError(err).Error("CloseWallet - Record lifecycle event failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CloseWallet - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("reason", reason).Info("Wallet closed")
+	return nil
+}
+
+// AdjustBalance applies adjustment.Amount to the wallet as an adjustment
+// transaction and stores the audit record, filling in its ID, transaction ID
+// and creation time. Frozen wallets can be adjusted, closed wallets cannot;
+// a debit cannot take funds held by pending transfers or withdrawals.
+func (r *PostgresWalletAdminRepository) AdjustBalance(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	if adjustment.UserID == "" {
+		r.logger.WithContext(ctx).Warn("AdjustBalance - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if adjustment.Amount.IsZero() {
+		r.logger.WithContext(ctx).Warn("AdjustBalance - amount cannot be zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":     adjustment.UserID,
+		"amount":     adjustment.Amount,
+		"reasonCode": adjustment.ReasonCode,
+		"actor":      adjustment.Actor,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance, held decimal.Decimal
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status FROM wallets WHERE user_id = $1 FOR UPDATE",
+		adjustment.UserID,
+	).Scan(&balance, &held, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("AdjustBalance - Cannot find wallet in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Query wallet balance failed")
+		return err
+	}
+
+	if status == models.WalletStatusClosed {
+		logger.Warn("AdjustBalance - Wallet is closed")
+		return ErrWalletClosed
+	}
+
+	// Credits may leave an overdrawn wallet negative; debits never draw on
+	// the credit line
+	if adjustment.Amount.IsNegative() && balance.Sub(held).Add(adjustment.Amount).IsNegative() {
+		logger.Warn("AdjustBalance - Wallet available balance is too low")
+		return ErrInsufficientBalance
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET balance = balance + $1 WHERE user_id = $2",
+		adjustment.Amount, adjustment.UserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Update wallet balance failed")
+		return err
+	}
+
+	// The transaction amount keeps the sign of the adjustment
+	actor, channel := provenance(ctx)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions
+		(from_user_id, amount, type, created_at, actor, channel)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		adjustment.UserID, adjustment.Amount, "adjustment", time.Now(), actor, channel,
+	).Scan(&adjustment.TransactionID)
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Create transaction record failed")
+		return err
+	}
+
+	sequence, err := assignSequence(ctx, sqlTx{tx}, adjustment.TransactionID, adjustment.UserID)
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Assign sequence number failed")
+		return err
+	}
+
+	var event events.Event
+	if adjustment.Amount.IsPositive() {
+		event = events.New(events.TypeWalletCredited, events.WalletCredited{
+			UserID:        adjustment.UserID,
+			Amount:        adjustment.Amount,
+			TransactionID: adjustment.TransactionID,
+			Sequence:      sequence,
+		})
+	} else {
+		event = events.New(events.TypeWalletDebited, events.WalletDebited{
+			UserID:        adjustment.UserID,
+			Amount:        adjustment.Amount.Neg(),
+			TransactionID: adjustment.TransactionID,
+			Sequence:      sequence,
+		})
+	}
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, adjustment.UserID); err != nil {
+		logger.WithError(err).Error("AdjustBalance - Record balance event failed")
+		return err
+	}
+
+	before, after := auditBalances(balance, adjustment.Amount)
+	err = recordAudit(ctx, sqlTx{tx}, models.AuditEntry{
+		Action:        models.AuditAdjustment,
+		UserID:        adjustment.UserID,
+		TransactionID: adjustment.TransactionID,
+		Before:        before,
+		After:         after,
+		Reason:        adjustment.ReasonCode,
+	})
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Record audit entry failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO balance_adjustments (user_id, amount, reason_code, note, actor, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id::text, created_at`,
+		adjustment.UserID, adjustment.Amount, adjustment.ReasonCode, adjustment.Note, adjustment.Actor, adjustment.TransactionID,
+	).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("AdjustBalance - Create adjustment record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("AdjustBalance - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("adjustmentID", adjustment.ID).Info("Balance adjusted")
+	return nil
+}
+
+// SetCurrency sets the currency the wallet of userID holds, creating an
+// empty wallet when there is none. The currency must have been bootstrapped,
+// otherwise it fails with ErrCurrencyNotFound. A wallet can only change currency while
+// its balance and held funds are zero, failing with ErrWalletNotEmpty, and
+// while no pending transfer is due to it, failing with ErrPendingTransfers.
+// Closed wallets fail with ErrWalletClosed.
+func (r *PostgresWalletAdminRepository) SetCurrency(ctx context.Context, userID, currency string) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":   userID,
+		"currency": currency,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("SetCurrency - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var known bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM currencies WHERE code = $1)",
+		currency,
+	).Scan(&known)
+	if err != nil {
+		logger.WithError(err).Error("SetCurrency - Query currency failed")
+		return err
+	}
+	if !known {
+		logger.Warn("SetCurrency - Cannot find currency in the database")
+		return ErrCurrencyNotFound
+	}
+
+	var created bool
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wallets (user_id, currency) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING true`,
+		userID, currency,
+	).Scan(&created)
+	if isUnregisteredUser(err) {
+		logger.Warn("SetCurrency - User is not registered")
+		return ErrUserNotFound
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Error("SetCurrency - Create wallet failed")
+		return err
+	}
+
+	if created {
+		event := events.New(events.TypeWalletCreated, events.WalletLifecycleChanged{
+			UserID:  userID,
+			Current: events.WalletState{Status: models.WalletStatusActive, Currency: currency},
+		})
+		if err = enqueueEvent(ctx, sqlTx{tx}, event, userID); err != nil {
+			logger.WithError(err).Error("SetCurrency - Record wallet created event failed")
+			return err
+		}
+	} else {
+		var balance, held decimal.Decimal
+		var status string
+		err = tx.QueryRowContext(ctx,
+			"SELECT balance, held, status FROM wallets WHERE user_id = $1 FOR UPDATE",
+			userID,
+		).Scan(&balance, &held, &status)
+		if err != nil {
+			logger.WithError(err).Error("SetCurrency - Query wallet balance failed")
+			return err
+		}
+
+		if status == models.WalletStatusClosed {
+			logger.Warn("SetCurrency - Wallet is closed")
+			return ErrWalletClosed
+		}
+
+		if !balance.IsZero() || !held.IsZero() {
+			logger.WithFields(logrus.Fields{
+				"balance": balance,
+				"held":    held,
+			}).Warn("SetCurrency - Wallet is not empty")
+			return ErrWalletNotEmpty
+		}
+
+		var pending bool
+		err = tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM holds WHERE to_user_id = $1 AND status = $2)",
+			userID, models.HoldPending,
+		).Scan(&pending)
+		if err != nil {
+			logger.WithError(err).Error("SetCurrency - Query pending transfers failed")
+			return err
+		}
+		if pending {
+			logger.Warn("SetCurrency - Wallet has pending incoming transfers")
+			return ErrPendingTransfers
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE wallets SET currency = $1 WHERE user_id = $2",
+			currency, userID,
+		)
+		if err != nil {
+			logger.WithError(err).Error("SetCurrency - Update wallet currency failed")
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("SetCurrency - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("created", created).Info("Wallet currency set")
+	return nil
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+func TestWalletAdminRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWalletAdminRepository(mockDB, logrus.New())
+
+	t.Run("ListWallets", func(t *testing.T) {
+		frozen := models.WalletStatusFrozen
+		minBalance := decimal.NewFromInt(100)
+		mock.ExpectQuery(`SELECT user_id, balance, held, status, label, country, currency, metadata FROM wallets WHERE status = \$1 AND balance >= \$2 AND metadata @> \$3 AND user_id > \$4 ORDER BY user_id LIMIT \$5`).
+			WithArgs(frozen, minBalance, []byte(`{"tier":"gold"}`), "user1", 20).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "label", "country", "currency", "metadata"}).
+				AddRow("user2", "250", "0", frozen, "vip", nil, "EUR", []byte(`{"tier":"gold","crm_id":"C-42"}`)))
+
+		wallets, err := repo.ListWallets(ctx, models.WalletFilter{Status: &frozen, MinBalance: &minBalance, Metadata: map[string]string{"tier": "gold"}, After: "user1", Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, wallets, 1)
+		require.Equal(t, "user2", wallets[0].UserID)
+		require.Equal(t, "vip", *wallets[0].Label)
+		require.Nil(t, wallets[0].Country)
+		require.Equal(t, "EUR", *wallets[0].Currency)
+		require.Equal(t, map[string]string{"tier": "gold", "crm_id": "C-42"}, wallets[0].Metadata)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetStatus", func(t *testing.T) {
+		t.Run("freeze", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.WalletStatusActive))
+			mock.ExpectExec(`UPDATE wallets SET status = \$1`).WithArgs(models.WalletStatusFrozen, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletFrozen, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.SetStatus(ctx, "user1", models.WalletStatusActive, models.WalletStatusFrozen, "fraud review"))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("unfreeze an active wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.WalletStatusActive))
+			mock.ExpectRollback()
+
+			err := repo.SetStatus(ctx, "user1", models.WalletStatusFrozen, models.WalletStatusActive, "cleared")
+			require.ErrorIs(t, err, ErrWalletNotFrozen)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("closed wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.WalletStatusClosed))
+			mock.ExpectRollback()
+
+			err := repo.SetStatus(ctx, "user1", models.WalletStatusActive, models.WalletStatusFrozen, "fraud review")
+			require.ErrorIs(t, err, ErrWalletClosed)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("CloseWallet", func(t *testing.T) {
+		t.Run("empty frozen wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(0.0, 0.0, models.WalletStatusFrozen))
+			mock.ExpectExec(`UPDATE wallets SET status = \$1`).WithArgs(models.WalletStatusClosed, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletClosed, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","previous":{"status":"frozen"},"current":{"status":"closed"},"reason":"customer request"}`), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.CloseWallet(ctx, "user1", "customer request"))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("held funds keep the wallet open", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(0.0, 5.0, models.WalletStatusActive))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.CloseWallet(ctx, "user1", "customer request"), ErrWalletNotEmpty)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already closed", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(0.0, 0.0, models.WalletStatusClosed))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.CloseWallet(ctx, "user1", "customer request"), ErrWalletClosed)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("AdjustBalance", func(t *testing.T) {
+		t.Run("debit", func(t *testing.T) {
+			ctx := operation.With(ctx, operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: models.AdjustmentChargeback})
+			now := time.Now()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(100.0, 20.0, models.WalletStatusFrozen))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(decimal.NewFromInt(-30), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(-30), "adjustment", sqlmock.AnyArg(), "admin1", operation.ChannelAdmin).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("42"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "42").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(7))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletDebited, "user1", []byte(`{"actor":"admin1","channel":"admin","reason":"chargeback"}`), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectQuery(`INSERT INTO balance_adjustments`).WithArgs("user1", decimal.NewFromInt(-30), models.AdjustmentChargeback, "card dispute", "admin1", "42").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("3", now))
+			mock.ExpectCommit()
+
+			adjustment := &models.BalanceAdjustment{UserID: "user1", Amount: decimal.NewFromInt(-30), ReasonCode: models.AdjustmentChargeback, Note: "card dispute", Actor: "admin1"}
+			require.NoError(t, repo.AdjustBalance(ctx, adjustment))
+			require.Equal(t, "3", adjustment.ID)
+			require.Equal(t, "42", adjustment.TransactionID)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("held funds cannot be debited", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(100.0, 80.0, models.WalletStatusActive))
+			mock.ExpectRollback()
+
+			adjustment := &models.BalanceAdjustment{UserID: "user1", Amount: decimal.NewFromInt(-30), ReasonCode: models.AdjustmentWriteOff, Actor: "admin1"}
+			require.ErrorIs(t, repo.AdjustBalance(ctx, adjustment), ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("wallet not found", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("ghost").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}))
+			mock.ExpectRollback()
+
+			adjustment := &models.BalanceAdjustment{UserID: "ghost", Amount: decimal.NewFromInt(10), ReasonCode: models.AdjustmentGoodwill, Actor: "admin1"}
+			require.ErrorIs(t, repo.AdjustBalance(ctx, adjustment), ErrUserNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("SetCurrency", func(t *testing.T) {
+		t.Run("new wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs("EUR").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(`INSERT INTO wallets \(user_id, currency\)`).WithArgs("user1", "EUR").WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCreated, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.SetCurrency(ctx, "user1", "EUR"))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("empty wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs("EUR").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(`INSERT INTO wallets \(user_id, currency\)`).WithArgs("user1", "EUR").WillReturnRows(sqlmock.NewRows([]string{"bool"}))
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(0.0, 0.0, models.WalletStatusActive))
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM holds`).WithArgs("user1", models.HoldPending).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(`UPDATE wallets SET currency = \$1`).WithArgs("EUR", "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.SetCurrency(ctx, "user1", "EUR"))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("wallet with a balance", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs("EUR").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(`INSERT INTO wallets \(user_id, currency\)`).WithArgs("user1", "EUR").WillReturnRows(sqlmock.NewRows([]string{"bool"}))
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(5.0, 0.0, models.WalletStatusActive))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.SetCurrency(ctx, "user1", "EUR"), ErrWalletNotEmpty)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("pending incoming transfer", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs("EUR").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(`INSERT INTO wallets \(user_id, currency\)`).WithArgs("user1", "EUR").WillReturnRows(sqlmock.NewRows([]string{"bool"}))
+			mock.ExpectQuery(`SELECT balance, held, status`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status"}).AddRow(0.0, 0.0, models.WalletStatusActive))
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM holds`).WithArgs("user1", models.HoldPending).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.SetCurrency(ctx, "user1", "EUR"), ErrPendingTransfers)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("unknown currency", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM currencies`).WithArgs("XYZ").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			require.ErrorIs(t, repo.SetCurrency(ctx, "user1", "XYZ"), ErrCurrencyNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// WalletEventRepository reads the event streams of the event-sourced storage
+// engine, which the wallet repository appends to WithEventStreams: balances
+// as of a past time, the integrity of the hash chains and the rebuild of the
+// balances projected from the streams
+type WalletEventRepository interface {
+	BalanceHistory
+	VerifyStreams(ctx context.Context) (*models.StreamVerification, error)
+	RebuildProjections(ctx context.Context) (int, error)
+}
+
+// genesisHash is the previous hash of the first event of a stream
+var genesisHash = strings.Repeat("0", 64)
+
+// eventDelta is the effect of the event e on the balance of its wallet
+const eventDelta = `CASE WHEN e.type IN ('debited', 'transferred_out') THEN -e.amount ELSE e.amount END`
+
+// walletEvent is a money movement in the stream of a wallet
+type walletEvent struct {
+	userID         string
+	version        int64
+	eventType      string
+	amount         decimal.Decimal
+	counterpartyID sql.NullString
+	transactionID  sql.NullString
+	actor          sql.NullString
+	channel        sql.NullString
+	occurredAt     time.Time
+	previousHash   string
+}
+
+// delta is the effect of e on the balance of its wallet
+func (e walletEvent) delta() decimal.Decimal {
+	if e.eventType == models.WalletEventDebited || e.eventType == models.WalletEventTransferredOut {
+		return e.amount.Neg()
+	}
+	return e.amount
+}
+
+// hash chains e to the previous event of its stream. Amounts are hashed at
+// the precision of the ledger and times at the precision of PostgreSQL, so
+// an event read back hashes the same.
+func (e walletEvent) hash() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.previousHash,
+		e.userID,
+		strconv.FormatInt(e.version, 10),
+		e.eventType,
+		e.amount.StringFixed(8),
+		e.counterpartyID.String,
+		e.transactionID.String,
+		e.actor.String,
+		e.channel.String,
+		e.occurredAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// appendEvents appends changes to the streams of their wallets inside tx,
+// after the balances they project were updated. The rows of the wallets must
+// be locked, which serializes the appends to a stream. The stream of a
+// wallet that already held funds opens with its balance before the change.
+func appendEvents(ctx context.Context, tx ledgerTx, changes ...walletEvent) error {
+	actor, channel := provenance(ctx)
+	occurredAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	for _, e := range changes {
+		err := tx.QueryRow(ctx,
+			"SELECT version, hash FROM wallet_events WHERE user_id = $1 ORDER BY version DESC LIMIT 1",
+			e.userID,
+		).Scan(&e.version, &e.previousHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			e.version, e.previousHash = 0, genesisHash
+
+			var balance decimal.Decimal
+			if err := tx.QueryRow(ctx, "SELECT balance FROM wallets WHERE user_id = $1", e.userID).Scan(&balance); err != nil {
+				return err
+			}
+			if opening := balance.Sub(e.delta()); opening.IsPositive() {
+				opened := walletEvent{userID: e.userID, eventType: models.WalletEventOpened, amount: opening}
+				if e.previousHash, err = insertEvent(ctx, tx, opened, 0, genesisHash, actor, channel, occurredAt); err != nil {
+					return err
+				}
+				e.version = 1
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := insertEvent(ctx, tx, e, e.version, e.previousHash, actor, channel, occurredAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertEvent appends e to its stream after the event at version with hash
+// previousHash and returns the hash of e
+func insertEvent(ctx context.Context, tx ledgerTx, e walletEvent, version int64, previousHash string, actor, channel sql.NullString, occurredAt time.Time) (string, error) {
+	e.version, e.previousHash = version+1, previousHash
+	e.actor, e.channel, e.occurredAt = actor, channel, occurredAt
+	hash := e.hash()
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO wallet_events
+		(user_id, version, type, amount, counterparty_id, transaction_id, actor, channel, occurred_at, previous_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6::int, $7, $8, $9, $10, $11)`,
+		e.userID, e.version, e.eventType, e.amount, e.counterpartyID, e.transactionID, e.actor, e.channel, e.occurredAt, e.previousHash, hash,
+	)
+	return hash, err
+}
+
+type PostgresWalletEventRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWalletEventRepository(db *sql.DB, logger *logrus.Logger) *PostgresWalletEventRepository {
+	return &PostgresWalletEventRepository{db: db, logger: logger}
+}
+
+// BalanceAt folds the stream of userID up to at. The stream starts with the
+// first change of the wallet made by the event-sourced engine.
+func (r *PostgresWalletEventRepository) BalanceAt(ctx context.Context, userID string, at time.Time) (decimal.Decimal, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("BalanceAt - userID cannot be an empty string")
+		return decimal.Zero, ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"at":     at,
+	})
+
+	var balance decimal.Decimal
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(CASE WHEN e.occurred_at <= $2 THEN `+eventDelta+` ELSE 0 END), 0), COUNT(*)
+		FROM wallet_events e
+		WHERE e.user_id = $1`,
+		userID, at,
+	).Scan(&balance, &count)
+	if err != nil {
+		logger.WithError(err).Error("BalanceAt - Fold wallet events failed")
+		return decimal.Zero, err
+	}
+	if count == 0 {
+		logger.Warn("BalanceAt - Cannot find wallet events in the database")
+		return decimal.Zero, ErrUserNotFound
+	}
+
+	return balance, nil
+}
+
+// VerifyStreams checks the hash chain of every stream and reports the first
+// broken event of each. Removing the last events of a stream leaves its
+// chain intact; RebuildProjections then finds the balance out of line.
+func (r *PostgresWalletEventRepository) VerifyStreams(ctx context.Context) (*models.StreamVerification, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id, version, type, amount, counterparty_id, transaction_id::text, actor, channel, occurred_at, previous_hash, hash
+		FROM wallet_events
+		ORDER BY user_id, version`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("VerifyStreams - Query wallet events failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	verification := &models.StreamVerification{Breaks: []models.StreamBreak{}}
+	var stream, lastHash string
+	var lastVersion int64
+	var broken bool
+	for rows.Next() {
+		var e walletEvent
+		var hash string
+		err := rows.Scan(&e.userID, &e.version, &e.eventType, &e.amount, &e.counterpartyID, &e.transactionID,
+			&e.actor, &e.channel, &e.occurredAt, &e.previousHash, &hash)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("VerifyStreams - Scan wallet event failed")
+			return nil, err
+		}
+		verification.Events++
+
+		if e.userID != stream {
+			stream, lastVersion, lastHash, broken = e.userID, 0, genesisHash, false
+			verification.Streams++
+		}
+		if broken {
+			continue
+		}
+
+		if e.version != lastVersion+1 || e.previousHash != lastHash || e.hash() != hash {
+			verification.Breaks = append(verification.Breaks, models.StreamBreak{UserID: e.userID, Version: e.version})
+			broken = true
+			continue
+		}
+		lastVersion, lastHash = e.version, hash
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("VerifyStreams - Iterate wallet events failed")
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// RebuildProjections recomputes the balance of every wallet with a stream
+// from its events and returns how many balances it corrected. Changes to
+// wallets wait for the rebuild, so no event is appended meanwhile; reads go
+// on.
+func (r *PostgresWalletEventRepository) RebuildProjections(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("RebuildProjections - Begin DB transaction failed")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Waits for the changes in flight, which lock their wallets before
+	// appending
+	if _, err = tx.ExecContext(ctx, "LOCK TABLE wallets IN EXCLUSIVE MODE"); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("RebuildProjections - Lock wallets failed")
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO wallets (user_id, balance)
+		SELECT e.user_id, SUM(`+eventDelta+`)
+		FROM wallet_events e
+		GROUP BY e.user_id
+		ON CONFLICT (user_id) DO UPDATE SET balance = EXCLUDED.balance
+		WHERE wallets.balance <> EXCLUDED.balance`,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("RebuildProjections - Project balances failed")
+		return 0, err
+	}
+	corrected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("RebuildProjections - Commit DB transaction failed")
+		return 0, err
+	}
+
+	r.logger.WithContext(ctx).WithField("corrected", corrected).Info("Wallet projections rebuilt")
+	return int(corrected), nil
+}
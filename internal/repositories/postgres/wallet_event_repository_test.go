@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestWalletRepository_EventStreams(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWalletRepository(mockDB, logrus.New(), WithEventStreams())
+
+	expectDeposit := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}).AddRow(false, "150"))
+		mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "deposit", sqlmock.AnyArg(), nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+		mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "7").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCredited, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectAudit(mock, 1)
+	}
+
+	t.Run("appends to the stream", func(t *testing.T) {
+		expectDeposit()
+		mock.ExpectQuery(`SELECT version, hash FROM wallet_events`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "hash"}).AddRow(3, "abc"))
+		mock.ExpectExec(`INSERT INTO wallet_events`).
+			WithArgs("user1", int64(4), models.WalletEventCredited, decimal.NewFromInt(100), nil, "7", nil, nil, sqlmock.AnyArg(), "abc", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("opens the stream with the balance held before", func(t *testing.T) {
+		expectDeposit()
+		mock.ExpectQuery(`SELECT version, hash FROM wallet_events`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "hash"}))
+		mock.ExpectQuery(`SELECT balance FROM wallets`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("150"))
+		mock.ExpectExec(`INSERT INTO wallet_events`).
+			WithArgs("user1", int64(1), models.WalletEventOpened, decimal.NewFromInt(50), nil, nil, nil, nil, sqlmock.AnyArg(), genesisHash, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO wallet_events`).
+			WithArgs("user1", int64(2), models.WalletEventCredited, decimal.NewFromInt(100), nil, "7", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// chain returns the events in a stream of userID moving amounts, each
+// chained to the previous one
+func chain(userID string, amounts ...int64) ([]walletEvent, []string) {
+	occurredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stream := make([]walletEvent, 0, len(amounts))
+	hashes := make([]string, 0, len(amounts))
+	previousHash := genesisHash
+	for i, amount := range amounts {
+		e := walletEvent{
+			userID:        userID,
+			version:       int64(i + 1),
+			eventType:     models.WalletEventCredited,
+			amount:        decimal.NewFromInt(amount),
+			transactionID: sql.NullString{String: "1", Valid: true},
+			occurredAt:    occurredAt.Add(time.Duration(i) * time.Minute),
+			previousHash:  previousHash,
+		}
+		previousHash = e.hash()
+		stream = append(stream, e)
+		hashes = append(hashes, previousHash)
+	}
+	return stream, hashes
+}
+
+func TestWalletEventRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWalletEventRepository(mockDB, logrus.New())
+	columns := []string{"user_id", "version", "type", "amount", "counterparty_id", "transaction_id", "actor", "channel", "occurred_at", "previous_hash", "hash"}
+
+	t.Run("BalanceAt", func(t *testing.T) {
+		at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(CASE WHEN e.occurred_at <= \$2`).WithArgs("user1", at).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "count"}).AddRow("42.5", 3))
+		balance, err := repo.BalanceAt(ctx, "user1", at)
+		require.NoError(t, err)
+		assert.True(t, balance.Equal(decimal.RequireFromString("42.5")))
+
+		mock.ExpectQuery(`FROM wallet_events e`).WithArgs("ghost", at).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "count"}).AddRow("0", 0))
+		_, err = repo.BalanceAt(ctx, "ghost", at)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("VerifyStreams", func(t *testing.T) {
+		intact, intactHashes := chain("user1", 100, 20, 5)
+		tampered, tamperedHashes := chain("user2", 10, 30, 40)
+		// Rewritten after it was appended
+		tampered[1].amount = decimal.NewFromInt(3000)
+
+		rows := sqlmock.NewRows(columns)
+		for _, stream := range []struct {
+			events []walletEvent
+			hashes []string
+		}{{intact, intactHashes}, {tampered, tamperedHashes}} {
+			for i, e := range stream.events {
+				rows.AddRow(e.userID, e.version, e.eventType, e.amount.String(), nil, e.transactionID.String, nil, nil, e.occurredAt, e.previousHash, stream.hashes[i])
+			}
+		}
+		mock.ExpectQuery(`SELECT user_id, version, type, amount`).WillReturnRows(rows)
+
+		verification, err := repo.VerifyStreams(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, verification.Streams)
+		assert.Equal(t, 6, verification.Events)
+		assert.Equal(t, []models.StreamBreak{{UserID: "user2", Version: 2}}, verification.Breaks)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("VerifyStreams detects removed events", func(t *testing.T) {
+		stream, hashes := chain("user1", 100, 20, 5)
+		rows := sqlmock.NewRows(columns)
+		for _, i := range []int{0, 2} {
+			e := stream[i]
+			rows.AddRow(e.userID, e.version, e.eventType, e.amount.String(), nil, e.transactionID.String, nil, nil, e.occurredAt, e.previousHash, hashes[i])
+		}
+		mock.ExpectQuery(`SELECT user_id, version, type, amount`).WillReturnRows(rows)
+
+		verification, err := repo.VerifyStreams(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.StreamBreak{{UserID: "user1", Version: 3}}, verification.Breaks)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RebuildProjections", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE wallets IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO wallets \(user_id, balance\)\s+SELECT e.user_id, SUM`).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		corrected, err := repo.RebuildProjections(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, corrected)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
 )
 
 func TestWalletRepository(t *testing.T) {
@@ -24,102 +31,303 @@ func TestWalletRepository(t *testing.T) {
 	t.Run("Deposit", func(t *testing.T) {
 		t.Run("success", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectExec(`INSERT INTO wallets`).WithArgs("user1", 100.0).WillReturnResult(sqlmock.NewResult(1, 1))
-			mock.ExpectExec(`INSERT INTO transactions`).WithArgs("user1", 100.0, "deposit", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}).AddRow(false, "150"))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "deposit", sqlmock.AnyArg(), nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "1").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCredited, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","amount":"100","transaction_id":"1","sequence":1}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+		})
+
+		t.Run("new wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}).AddRow(true, "150"))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCreated, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","previous":null,"current":{"status":"active"},"reason":null}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "deposit", sqlmock.AnyArg(), nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "1").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCredited, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","amount":"100","transaction_id":"1","sequence":1}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("provisioned wallets only", func(t *testing.T) {
+			provisionedOnly := NewWalletRepository(mockDB, logger, WithProvisionedWalletsOnly())
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE wallets SET balance = balance \+ \$2\s+WHERE user_id = \$1 AND status = 'active'`).
+				WithArgs("user1", decimal.NewFromInt(100)).
+				WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("150"))
+			mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "1").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletCredited, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, provisionedOnly.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+
+			// A user without a wallet does not get one
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE wallets SET balance`).WithArgs("ghost", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"balance"}))
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("ghost").WillReturnRows(sqlmock.NewRows([]string{"status"}))
+			mock.ExpectRollback()
+			require.ErrorIs(t, provisionedOnly.Deposit(ctx, "ghost", decimal.NewFromInt(100)), ErrUserNotFound)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("with details", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}).AddRow(false, "150"))
+			mock.ExpectQuery(`INSERT INTO transactions`).
+				WithArgs("user1", decimal.NewFromInt(100), "deposit", sqlmock.AnyArg(), nil, nil, "Top up", "order-42", []byte(`{"invoice":"INV-7"}`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "1").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
 			mock.ExpectCommit()
-			require.NoError(t, repo.Deposit(ctx, "user1", 100.0))
+
+			detailed := operation.WithDetails(ctx, operation.Details{Memo: "Top up", Reference: "order-42", Metadata: map[string]string{"invoice": "INV-7"}})
+			require.NoError(t, repo.Deposit(detailed, "user1", decimal.NewFromInt(100)))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("frozen wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}))
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("frozen"))
+			mock.ExpectRollback()
+			err := repo.Deposit(ctx, "user1", decimal.NewFromInt(100))
+			require.ErrorIs(t, err, ErrWalletFrozen)
+		})
+
+		t.Run("closed wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO wallets`).WithArgs("user1", decimal.NewFromInt(100)).WillReturnRows(sqlmock.NewRows([]string{"created", "balance"}))
+			mock.ExpectQuery(`SELECT status FROM wallets`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("closed"))
+			mock.ExpectRollback()
+			err := repo.Deposit(ctx, "user1", decimal.NewFromInt(100))
+			require.ErrorIs(t, err, ErrWalletClosed)
 		})
 
 		t.Run("invalid amount", func(t *testing.T) {
-			err := repo.Deposit(ctx, "user1", -50.0)
+			err := repo.Deposit(ctx, "user1", decimal.NewFromInt(-50))
 			require.ErrorIs(t, err, ErrInvalidAmount)
 		})
 
 		t.Run("invalid userID", func(t *testing.T) {
-			err := repo.Deposit(ctx, "", 100.0)
+			err := repo.Deposit(ctx, "", decimal.NewFromInt(100))
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 	})
 
 	t.Run("Withdraw", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(150.0, 0.0, "active", 0.0))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "withdrawal", sqlmock.AnyArg(), nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "2").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletDebited, "user1", sqlmock.AnyArg(), []byte(`{"user_id":"user1","amount":"100","transaction_id":"2","sequence":1}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
 		t.Run("insufficient balance", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(50.0))
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(50.0, 0.0, "active", 0.0))
+			mock.ExpectRollback()
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil)
+			require.ErrorIs(t, err, ErrInsufficientBalance)
+		})
+
+		t.Run("held funds are not available", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(150.0, 100.0, "active", 0.0))
+			mock.ExpectRollback()
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil)
+			require.ErrorIs(t, err, ErrInsufficientBalance)
+		})
+
+		t.Run("overdraft records the drawdown", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(30.0, 0.0, "active", 100.0))
+			mock.ExpectExec(`UPDATE wallets SET balance = balance - \$1`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "2").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO overdraft_drawdowns`).WithArgs("2", decimal.NewFromInt(70)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("overdraft limit exceeded", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(-20.0, 0.0, "active", 100.0))
 			mock.ExpectRollback()
-			err := repo.Withdraw(ctx, "user1", 100.0)
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(90), nil)
 			require.ErrorIs(t, err, ErrInsufficientBalance)
 		})
 
+		t.Run("frozen wallet", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(500.0, 0.0, "frozen", 0.0))
+			mock.ExpectRollback()
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil)
+			require.ErrorIs(t, err, ErrWalletFrozen)
+		})
+
 		t.Run("user not found", func(t *testing.T) {
 			mock.ExpectBegin()
 			mock.ExpectQuery(`SELECT balance`).WithArgs("invalid").WillReturnError(sql.ErrNoRows)
 			mock.ExpectRollback()
-			err := repo.Withdraw(ctx, "invalid", 100.0)
+			err := repo.Withdraw(ctx, "invalid", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrUserNotFound)
 		})
 
+		t.Run("expected balance mismatch", func(t *testing.T) {
+			expected := decimal.NewFromInt(80)
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit"}).AddRow(120.0, 0.0, "active", 0.0))
+			mock.ExpectRollback()
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(50), &expected)
+			require.ErrorIs(t, err, ErrBalanceMismatch)
+		})
+
 		t.Run("invalid amount", func(t *testing.T) {
-			err := repo.Withdraw(ctx, "user1", -50.0)
+			err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(-50), nil)
 			require.ErrorIs(t, err, ErrInvalidAmount)
 		})
 
 		t.Run("invalid userID", func(t *testing.T) {
-			err := repo.Withdraw(ctx, "", 100.0)
+			err := repo.Withdraw(ctx, "", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 	})
 
 	t.Run("Transfer", func(t *testing.T) {
+		wallets := func(statuses ...string) *sqlmock.Rows {
+			rows := sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"})
+			for i, status := range statuses {
+				rows.AddRow(fmt.Sprintf("user%d", i+1), 200.0, 0.0, status, "", 0.0)
+			}
+			return rows
+		}
+		expectTransfer := func() {
+			mock.ExpectExec(`UPDATE wallets`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE wallets`).WithArgs(decimal.NewFromInt(100), "user2").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", "user2", decimal.NewFromInt(100), "transfer", sqlmock.AnyArg(), nil, nil, nil, nil, nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user2", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeTransferCompleted, "user1", sqlmock.AnyArg(), []byte(`{"from_user_id":"user1","to_user_id":"user2","amount":"100","transaction_id":"3","from_sequence":1,"to_sequence":1}`), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 2)
+			mock.ExpectCommit()
+		}
+
 		t.Run("success", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(200.0))
-			mock.ExpectExec(`UPDATE wallets`).WithArgs(100.0, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
-			mock.ExpectExec(`UPDATE wallets`).WithArgs(100.0, "user2").WillReturnResult(sqlmock.NewResult(0, 1))
-			mock.ExpectExec(`INSERT INTO transactions`).WithArgs("user1", "user2", 100.0, "transfer", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
-			mock.ExpectCommit()
-			require.NoError(t, repo.Transfer(ctx, "user1", "user2", 100.0))
+			// Both wallets are locked in one statement, in user ID order
+			mock.ExpectQuery(`SELECT user_id, balance, held, status, COALESCE\(currency, ''\), overdraft_limit FROM wallets\s+WHERE user_id IN \(\$1, \$2\)\s+ORDER BY user_id\s+FOR UPDATE`).
+				WithArgs("user1", "user2").WillReturnRows(wallets("active", "active"))
+			expectTransfer()
+			require.NoError(t, repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("deadlock is retried", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active", "active"))
+			mock.ExpectExec(`UPDATE wallets`).WithArgs(decimal.NewFromInt(100), "user1").WillReturnError(&pgconn.PgError{Code: "40P01"})
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active", "active"))
+			expectTransfer()
+			require.NoError(t, repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("deadlock retries are bounded", func(t *testing.T) {
+			for i := 0; i < deadlockAttempts; i++ {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnError(&pgconn.PgError{Code: "40P01"})
+				mock.ExpectRollback()
+			}
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil)
+			var pgErr *pgconn.PgError
+			require.ErrorAs(t, err, &pgErr)
+			require.NoError(t, mock.ExpectationsWereMet())
 		})
 
 		t.Run("invalid sender", func(t *testing.T) {
-			err := repo.Transfer(ctx, "", "user2", 100.0)
+			err := repo.Transfer(ctx, "", "user2", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 
 		t.Run("invalid receiver", func(t *testing.T) {
-			err := repo.Transfer(ctx, "user1", "", 100.0)
+			err := repo.Transfer(ctx, "user1", "", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 
 		t.Run("sender and receiver cannot be the same", func(t *testing.T) {
-			err := repo.Transfer(ctx, "user1", "user1", 100.0)
+			err := repo.Transfer(ctx, "user1", "user1", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 
 		t.Run("sender not found", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).AddRow("user2", 0.0, 0.0, "active", "", 0.0))
 			mock.ExpectRollback()
-			err := repo.Transfer(ctx, "user1", "user2", 100.0)
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrUserNotFound)
 		})
 
 		t.Run("receiver not found", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(200.0))
-			mock.ExpectExec(`UPDATE wallets`).WithArgs(100.0, "user1").WillReturnResult(sqlmock.NewResult(0, 1))
-			mock.ExpectExec(`UPDATE wallets`).WithArgs(100.0, "user2").WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active"))
 			mock.ExpectRollback()
-			err := repo.Transfer(ctx, "user1", "user2", 100.0)
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil)
 			require.ErrorIs(t, err, ErrUserNotFound)
 		})
 
+		t.Run("sender expected balance mismatch", func(t *testing.T) {
+			expected := decimal.NewFromInt(150)
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active", "active"))
+			mock.ExpectRollback()
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), &expected)
+			require.ErrorIs(t, err, ErrBalanceMismatch)
+		})
+
+		t.Run("receiver frozen", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active", "frozen"))
+			mock.ExpectRollback()
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil)
+			require.ErrorIs(t, err, ErrWalletFrozen)
+		})
+
 		t.Run("sender has insufficient balance", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(50.0))
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").WillReturnRows(wallets("active", "active"))
 			mock.ExpectRollback()
-			err := repo.Transfer(ctx, "user1", "user2", 100.0)
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(300), nil)
 			require.ErrorIs(t, err, ErrInsufficientBalance)
 		})
+
+		t.Run("wallets of different currencies", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT user_id`).WithArgs("user1", "user2").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit"}).
+					AddRow("user1", 200.0, 0.0, "active", "EUR", 0.0).AddRow("user2", 0.0, 0.0, "active", "USD", 0.0))
+			mock.ExpectRollback()
+			err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil)
+			require.ErrorIs(t, err, ErrCurrencyMismatch)
+		})
 	})
 
 	t.Run("GetBalance", func(t *testing.T) {
@@ -127,7 +335,7 @@ func TestWalletRepository(t *testing.T) {
 			mock.ExpectQuery(`SELECT balance`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(150.0))
 			balance, err := repo.GetBalance(ctx, "user1")
 			require.NoError(t, err)
-			require.Equal(t, 150.0, balance)
+			require.True(t, decimal.NewFromInt(150).Equal(balance))
 		})
 
 		t.Run("user not found", func(t *testing.T) {
@@ -142,33 +350,350 @@ func TestWalletRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("GetVersionedBalance", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT w.balance, COALESCE\(s.last_sequence, 0\)`).WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"balance", "last_sequence"}).AddRow(150.0, 7))
+			balance, version, err := repo.GetVersionedBalance(ctx, "user1")
+			require.NoError(t, err)
+			require.True(t, decimal.NewFromInt(150).Equal(balance))
+			require.Equal(t, int64(7), version)
+		})
+
+		t.Run("user not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT w.balance`).WithArgs("invalid").WillReturnError(sql.ErrNoRows)
+			_, _, err := repo.GetVersionedBalance(ctx, "invalid")
+			require.ErrorIs(t, err, ErrUserNotFound)
+		})
+	})
+
 	t.Run("GetTransactionHistory", func(t *testing.T) {
 		now := time.Now()
 		t.Run("success", func(t *testing.T) {
 			mock.ExpectQuery(`SELECT`).WithArgs("user1", 10, 0).WillReturnRows(sqlmock.NewRows(
-				[]string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at"},
-			).AddRow(1, "user1", "", 100.0, "deposit", now).AddRow(2, "user1", "user2", 50.0, "transfer", now))
+				[]string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at", "merged_from", "category", "from_currency", "to_currency", "fx_rate", "converted_amount", "fee_for", "round_up_for", "acknowledged_at", "acknowledgment_message", "memo", "reference_id", "metadata", "sequence"},
+			).AddRow(1, "user1", "", 100.0, "deposit", now, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 2).AddRow(2, "user1", "user2", 50.0, "transfer", now, "user7", "rent", nil, nil, nil, nil, nil, nil, now, "Thanks!", "Rent March", "order-42", []byte(`{"invoice":"INV-7"}`), nil).
+				AddRow(3, "user1", models.SystemAccountFees, 0.5, "fee", now, nil, nil, nil, nil, nil, nil, "2", nil, nil, nil, nil, nil, nil, 3).
+				AddRow(4, "user1", "savings:user1", 0.5, "round_up", now, nil, nil, nil, nil, nil, nil, nil, "2", nil, nil, nil, nil, nil, 4))
 
-			txns, err := repo.GetTransactionHistory(ctx, "user1", 10, 0)
+			txns, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 10, 0)
 			require.NoError(t, err)
-			require.Len(t, txns, 2)
+			require.Len(t, txns, 4)
 			require.Equal(t, "deposit", *txns[0].Type)
+			require.Equal(t, "user7", *txns[1].MergedFrom)
+			require.Equal(t, int64(2), *txns[0].Sequence)
+			require.Nil(t, txns[1].Sequence)
+			require.Nil(t, txns[0].Category)
+			require.Equal(t, "rent", *txns[1].Category)
+			require.Nil(t, txns[1].FeeFor)
+			require.Equal(t, "2", *txns[2].FeeFor)
+			require.Equal(t, "2", *txns[3].RoundUpFor)
+			require.Nil(t, txns[0].AcknowledgedAt)
+			require.Equal(t, "Thanks!", *txns[1].AcknowledgmentMessage)
+			require.Equal(t, "Rent March", *txns[1].Memo)
+			require.Equal(t, "order-42", *txns[1].ReferenceID)
+			require.Equal(t, map[string]string{"invoice": "INV-7"}, txns[1].Metadata)
+			require.Nil(t, txns[0].Metadata)
+		})
+
+		t.Run("within window", func(t *testing.T) {
+			from := now.Add(-90 * 24 * time.Hour)
+			mock.ExpectQuery(`WHERE \(from_user_id = \$1 OR to_user_id = \$1\) AND created_at >= \$4\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$2 OFFSET \$3`).
+				WithArgs("user1", 10, 0, from).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			_, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{From: &from}, 10, 0)
+			require.NoError(t, err)
 		})
 
 		t.Run("query error", func(t *testing.T) {
 			mock.ExpectQuery(`SELECT`).WithArgs("user1", 10, 0).WillReturnError(errors.New("query error"))
-			_, err := repo.GetTransactionHistory(ctx, "user1", 10, 0)
+			_, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 10, 0)
 			require.ErrorContains(t, err, "query error")
 		})
 
 		t.Run("invalid userID", func(t *testing.T) {
-			_, err := repo.GetTransactionHistory(ctx, "", 10, 0)
+			_, err := repo.GetTransactionHistory(ctx, "", models.HistoryWindow{}, 10, 0)
 			require.ErrorIs(t, err, ErrInvalidUserID)
 		})
 
 		t.Run("invalid limit", func(t *testing.T) {
-			_, err := repo.GetTransactionHistory(ctx, "user1", 0, 0)
+			_, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 0, 0)
 			require.ErrorIs(t, err, ErrInvalidLimit)
 		})
 	})
+
+	t.Run("GetTransactionsBefore", func(t *testing.T) {
+		now := time.Now()
+		columns := []string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at", "merged_from", "category", "from_currency", "to_currency", "fx_rate", "converted_amount", "fee_for", "round_up_for", "acknowledged_at", "acknowledgment_message", "memo", "reference_id", "metadata", "sequence"}
+
+		t.Run("first page", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT id, from_user_id`).WithArgs("user1", 10).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, "user1", "user2", 50.0, "transfer", now, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 2))
+
+			txns, err := repo.GetTransactionsBefore(ctx, "user1", nil, models.HistoryWindow{}, 10)
+			require.NoError(t, err)
+			require.Len(t, txns, 1)
+		})
+
+		t.Run("after cursor", func(t *testing.T) {
+			mock.ExpectQuery(`AND \(created_at, id\) < \(\$3, \$4\)`).WithArgs("user1", 10, now, int64(2)).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "user1", nil, 100.0, "deposit", now, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 1))
+
+			txns, err := repo.GetTransactionsBefore(ctx, "user1", &models.TransactionCursor{CreatedAt: now, ID: 2}, models.HistoryWindow{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, "deposit", *txns[0].Type)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("within window", func(t *testing.T) {
+			from, to := now.Add(-time.Hour), now
+			mock.ExpectQuery(`AND \(created_at, id\) < \(\$3, \$4\) AND created_at >= \$5 AND created_at < \$6`).
+				WithArgs("user1", 10, now, int64(2), from, to).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			txns, err := repo.GetTransactionsBefore(ctx, "user1", &models.TransactionCursor{CreatedAt: now, ID: 2}, models.HistoryWindow{From: &from, To: &to}, 10)
+			require.NoError(t, err)
+			require.Empty(t, txns)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("default window", func(t *testing.T) {
+			// The cutoff of the recent indexes is part of the statement so
+			// that its cached plan can read them
+			from, cutoff := now.Add(-90*24*time.Hour), time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+			mock.ExpectQuery(`AND created_at >= '2024-05-01T00:00:00Z' AND created_at >= \$3\s+ORDER BY`).
+				WithArgs("user1", 10, from).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			_, err := repo.GetTransactionsBefore(ctx, "user1", nil, models.HistoryWindow{From: &from, Cutoff: &cutoff}, 10)
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("matching a search", func(t *testing.T) {
+			reference, memo := "order-42", "rent"
+			mock.ExpectQuery(`AND reference_id = \$3 AND strpos\(lower\(memo\), lower\(\$4\)\) > 0 AND metadata @> \$5`).
+				WithArgs("user1", 10, reference, memo, []byte(`{"invoice":"INV-7"}`)).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			window := models.HistoryWindow{ReferenceID: &reference, Memo: &memo, Metadata: map[string]string{"invoice": "INV-7"}}
+			txns, err := repo.GetTransactionsBefore(ctx, "user1", nil, window, 10)
+			require.NoError(t, err)
+			require.Empty(t, txns)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("CountTransactions", func(t *testing.T) {
+		memo := "rent"
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM transactions\s+WHERE \(from_user_id = \$1 OR to_user_id = \$1\) AND strpos\(lower\(memo\), lower\(\$2\)\) > 0`).
+			WithArgs("user1", memo).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		count, err := repo.CountTransactions(ctx, "user1", models.HistoryWindow{Memo: &memo})
+		require.NoError(t, err)
+		require.Equal(t, 12, count)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTransactionsBetween", func(t *testing.T) {
+		now := time.Now()
+		from := now.Add(-24 * time.Hour)
+		columns := []string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at", "merged_from", "category", "from_currency", "to_currency", "fx_rate", "converted_amount", "fee_for", "round_up_for", "acknowledged_at", "acknowledgment_message", "memo", "reference_id", "metadata", "sequence"}
+
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectQuery(`created_at >= \$2 AND created_at < \$3\s+ORDER BY created_at, id`).WithArgs("user1", from, now, 10).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "user1", nil, 100.0, "deposit", from, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 1).
+				AddRow(2, "user1", "user2", 50.0, "transfer", now.Add(-time.Hour), nil, "rent", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 2))
+
+			txns, err := repo.GetTransactionsBetween(ctx, "user1", from, now, 10)
+			require.NoError(t, err)
+			require.Len(t, txns, 2)
+			require.Equal(t, "deposit", *txns[0].Type)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			_, err := repo.GetTransactionsBetween(ctx, "user1", from, now, 0)
+			require.ErrorIs(t, err, ErrInvalidLimit)
+		})
+	})
+
+	t.Run("GetTransactionChanges", func(t *testing.T) {
+		now := time.Now()
+		columns := []string{"id", "from_user_id", "to_user_id", "amount", "type", "created_at", "merged_from", "category", "from_currency", "to_currency", "fx_rate", "converted_amount", "fee_for", "round_up_for", "acknowledged_at", "acknowledgment_message", "memo", "reference_id", "metadata", "status", "sequence", "sync_sequence"}
+
+		t.Run("success", func(t *testing.T) {
+			// A transaction whose status changed comes after the newer ones
+			mock.ExpectQuery(`WHERE s.user_id = \$1 AND s.sync_sequence > \$2\s+ORDER BY s.sync_sequence`).WithArgs("user1", int64(4), 10).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(6, "user1", nil, 100.0, "deposit", now, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "completed", 5, 5).
+				AddRow(3, "user1", "user2", 50.0, "transfer", now.Add(-time.Hour), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "failed", 3, 6))
+
+			txns, err := repo.GetTransactionChanges(ctx, "user1", 4, 10)
+			require.NoError(t, err)
+			require.Len(t, txns, 2)
+			require.Equal(t, "failed", *txns[1].Status)
+			require.Equal(t, int64(3), *txns[1].Sequence)
+			require.Equal(t, int64(6), *txns[1].SyncSequence)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("invalid userID", func(t *testing.T) {
+			_, err := repo.GetTransactionChanges(ctx, "", 0, 10)
+			require.ErrorIs(t, err, ErrInvalidUserID)
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			_, err := repo.GetTransactionChanges(ctx, "user1", 0, 0)
+			require.ErrorIs(t, err, ErrInvalidLimit)
+		})
+	})
+
+	t.Run("GetTimeline", func(t *testing.T) {
+		now := time.Now()
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT event_type.*FROM wallet_status_changes.*ORDER BY occurred_at DESC, event_type, id DESC\s+LIMIT \$2 OFFSET \$3`).WithArgs("user1", 10, 0).WillReturnRows(sqlmock.NewRows(
+				[]string{"event_type", "subtype", "reference_id", "from_user_id", "to_user_id", "amount", "occurred_at"},
+			).AddRow("status", "frozen", "3", nil, nil, nil, now).
+				AddRow("transaction", "transfer", "2", "user1", "user2", 50.0, now).
+				AddRow("transaction", "deposit", "1", "user1", nil, 100.0, now.Add(-time.Hour)))
+
+			events, err := repo.GetTimeline(ctx, "user1", 10, 0)
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			require.Equal(t, "status", events[0].Type)
+			require.Equal(t, "frozen", *events[0].Subtype)
+			require.Nil(t, events[0].Amount)
+			require.Equal(t, "transaction", events[1].Type)
+			require.Equal(t, "transfer", *events[1].Subtype)
+			require.Nil(t, events[2].ToUserID)
+		})
+
+		t.Run("invalid userID", func(t *testing.T) {
+			_, err := repo.GetTimeline(ctx, "", 10, 0)
+			require.ErrorIs(t, err, ErrInvalidUserID)
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			_, err := repo.GetTimeline(ctx, "user1", 0, 0)
+			require.ErrorIs(t, err, ErrInvalidLimit)
+		})
+	})
+
+	t.Run("GetTimelineBefore", func(t *testing.T) {
+		now := time.Now()
+		columns := []string{"event_type", "subtype", "reference_id", "from_user_id", "to_user_id", "amount", "occurred_at"}
+
+		t.Run("first page", func(t *testing.T) {
+			mock.ExpectQuery(`FROM wallet_status_changes\s+WHERE user_id = \$1\s+\) AS timeline\s+ORDER BY occurred_at DESC, event_type, id DESC\s+LIMIT \$2$`).
+				WithArgs("user1", 10).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("hold", "active", "4", "user1", nil, 20.0, now))
+
+			events, err := repo.GetTimelineBefore(ctx, "user1", nil, 10)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			require.Equal(t, "hold", events[0].Type)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("after cursor", func(t *testing.T) {
+			cursor := &models.TimelineCursor{OccurredAt: now, Type: "hold", ID: 4}
+			mock.ExpectQuery(`\) AS timeline\s+WHERE occurred_at < \$3 OR \(occurred_at = \$3 AND \(event_type > \$4 OR \(event_type = \$4 AND id < \$5\)\)\)\s+ORDER BY occurred_at DESC, event_type, id DESC\s+LIMIT \$2$`).
+				WithArgs("user1", 10, now, "hold", int64(4)).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("status", "frozen", "3", nil, nil, nil, now))
+
+			events, err := repo.GetTimelineBefore(ctx, "user1", cursor, 10)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			require.Equal(t, "status", events[0].Type)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("invalid userID", func(t *testing.T) {
+			_, err := repo.GetTimelineBefore(ctx, "", nil, 10)
+			require.ErrorIs(t, err, ErrInvalidUserID)
+		})
+
+		t.Run("invalid limit", func(t *testing.T) {
+			_, err := repo.GetTimelineBefore(ctx, "user1", nil, 0)
+			require.ErrorIs(t, err, ErrInvalidLimit)
+		})
+	})
+}
+
+func TestWalletRepository_OptimisticLocking(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWalletRepository(mockDB, logrus.New(), WithOptimisticLocking(2))
+	hundred := decimal.NewFromInt(100)
+
+	expectWithdrawal := func(version int64, swapped bool) {
+		mock.ExpectBegin()
+		// The wallet is read without FOR UPDATE
+		mock.ExpectQuery(`SELECT balance, held, status, overdraft_limit, version FROM wallets WHERE user_id = \$1$`).WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "overdraft_limit", "version"}).AddRow(150.0, 0.0, "active", 0.0, version))
+		affected := int64(0)
+		if swapped {
+			affected = 1
+		}
+		mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1 WHERE user_id = \$2 AND version = \$3`).
+			WithArgs(hundred.Neg(), "user1", version).WillReturnResult(sqlmock.NewResult(0, affected))
+	}
+
+	t.Run("Withdraw", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			expectWithdrawal(7, true)
+			mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "2").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Withdraw(ctx, "user1", hundred, nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("changed wallet is read again", func(t *testing.T) {
+			expectWithdrawal(7, false)
+			mock.ExpectRollback()
+			expectWithdrawal(8, true)
+			mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "2").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			mock.ExpectCommit()
+			require.NoError(t, repo.Withdraw(ctx, "user1", hundred, nil))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("attempts are bounded", func(t *testing.T) {
+			expectWithdrawal(7, false)
+			mock.ExpectRollback()
+			expectWithdrawal(8, false)
+			mock.ExpectRollback()
+			require.ErrorIs(t, repo.Withdraw(ctx, "user1", hundred, nil), ErrWalletContended)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Transfer updates both wallets at their version in user ID order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT user_id, balance, held, status, COALESCE\(currency, ''\), overdraft_limit, version FROM wallets\s+WHERE user_id IN \(\$1, \$2\)$`).
+			WithArgs("user2", "user1").WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance", "held", "status", "currency", "overdraft_limit", "version"}).
+			AddRow("user1", 200.0, 0.0, "active", "", 0.0, 3).
+			AddRow("user2", 200.0, 0.0, "active", "", 0.0, 9))
+		mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(hundred, "user1", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE wallets SET balance = balance \+ \$1`).WithArgs(hundred.Neg(), "user2", int64(9)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO transactions`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+		mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user2", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+		mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "3").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+		mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectAudit(mock, 2)
+		mock.ExpectCommit()
+		require.NoError(t, repo.Transfer(ctx, "user2", "user1", hundred, nil))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// WebhookKeyRepository stores the keys signing the event webhook deliveries
+type WebhookKeyRepository interface {
+	RotateWebhookKey(ctx context.Context, key *models.WebhookSigningKey, overlap time.Duration) error
+	ListWebhookKeys(ctx context.Context) ([]models.WebhookSigningKey, error)
+	// ValidWebhookKeys returns the keys that have not expired, with their
+	// secrets, newest first
+	ValidWebhookKeys(ctx context.Context) ([]models.WebhookSigningKey, error)
+	ExpireWebhookKey(ctx context.Context, id string) (*models.WebhookSigningKey, error)
+}
+
+var (
+	ErrWebhookKeyNotFound = errors.New("webhook signing key not found")
+	ErrWebhookKeyCurrent  = errors.New("the current webhook signing key cannot be expired, rotate it instead")
+)
+
+const webhookKeyColumns = `id::text, secret, created_by, created_at, expires_at`
+
+type PostgresWebhookKeyRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWebhookKeyRepository(db *sql.DB, logger *logrus.Logger) *PostgresWebhookKeyRepository {
+	return &PostgresWebhookKeyRepository{db: db, logger: logger}
+}
+
+// RotateWebhookKey stores key as the current key, filling in its ID and
+// CreatedAt. The previous current key expires after overlap; keys rotated
+// before keep their expiry. Concurrent rotations are applied one after the
+// other.
+func (r *PostgresWebhookKeyRepository) RotateWebhookKey(ctx context.Context, key *models.WebhookSigningKey, overlap time.Duration) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"createdBy": key.CreatedBy,
+		"overlap":   overlap,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("RotateWebhookKey - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE webhook_signing_keys IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		logger.WithError(err).Error("RotateWebhookKey - Lock keys failed")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE webhook_signing_keys SET expires_at = NOW() + make_interval(secs => $1)
+		WHERE expires_at IS NULL`,
+		overlap.Seconds(),
+	)
+	if err != nil {
+		logger.WithError(err).Error("RotateWebhookKey - Expire current key failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO webhook_signing_keys (secret, created_by)
+		VALUES ($1, $2)
+		RETURNING id::text, created_at`,
+		key.Secret, key.CreatedBy,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		logger.WithError(err).Error("RotateWebhookKey - Create key record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("RotateWebhookKey - Commit DB transaction failed")
+		return err
+	}
+
+	logger.WithField("keyID", key.ID).Info("Webhook signing key rotated")
+	return nil
+}
+
+// ListWebhookKeys returns every key, expired ones included, newest first
+func (r *PostgresWebhookKeyRepository) ListWebhookKeys(ctx context.Context) ([]models.WebhookSigningKey, error) {
+	return r.queryKeys(ctx, "ListWebhookKeys",
+		`SELECT `+webhookKeyColumns+` FROM webhook_signing_keys ORDER BY id DESC`,
+	)
+}
+
+func (r *PostgresWebhookKeyRepository) ValidWebhookKeys(ctx context.Context) ([]models.WebhookSigningKey, error) {
+	return r.queryKeys(ctx, "ValidWebhookKeys",
+		`SELECT `+webhookKeyColumns+` FROM webhook_signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY id DESC`,
+	)
+}
+
+// ExpireWebhookKey ends the overlap of a rotated key at once and returns it.
+// Expiring an expired key keeps its original expiry.
+func (r *PostgresWebhookKeyRepository) ExpireWebhookKey(ctx context.Context, id string) (*models.WebhookSigningKey, error) {
+	logger := r.logger.WithContext(ctx).WithField("keyID", id)
+
+	key, err := scanWebhookKey(r.db.QueryRowContext(ctx,
+		`UPDATE webhook_signing_keys SET expires_at = LEAST(expires_at, NOW())
+		WHERE id::text = $1 AND expires_at IS NOT NULL
+		RETURNING `+webhookKeyColumns,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		err = r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_signing_keys WHERE id::text = $1)`, id).Scan(&exists)
+		if err != nil {
+			logger.WithError(err).Error("ExpireWebhookKey - Query key failed")
+			return nil, err
+		}
+		if exists {
+			return nil, ErrWebhookKeyCurrent
+		}
+		return nil, ErrWebhookKeyNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("ExpireWebhookKey - Update key failed")
+		return nil, err
+	}
+
+	logger.Info("Webhook signing key expired")
+	return key, nil
+}
+
+func (r *PostgresWebhookKeyRepository) queryKeys(ctx context.Context, method, query string) ([]models.WebhookSigningKey, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Query keys failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.WebhookSigningKey{}
+	for rows.Next() {
+		key, err := scanWebhookKey(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error(method + " - Scan keys failed")
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(method + " - Iterate keys failed")
+		return nil, err
+	}
+	return keys, nil
+}
+
+func scanWebhookKey(row rowScanner) (*models.WebhookSigningKey, error) {
+	var key models.WebhookSigningKey
+	if err := row.Scan(&key.ID, &key.Secret, &key.CreatedBy, &key.CreatedAt, &key.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
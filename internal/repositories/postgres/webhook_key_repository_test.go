@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+func TestWebhookKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWebhookKeyRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"id", "secret", "created_by", "created_at", "expires_at"}
+
+	t.Run("RotateWebhookKey expires the current key after the overlap", func(t *testing.T) {
+		key := &models.WebhookSigningKey{Secret: "whsec_new", CreatedBy: "admin1"}
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE webhook_signing_keys`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE webhook_signing_keys SET expires_at = NOW\(\) \+ make_interval\(secs => \$1\)\s+WHERE expires_at IS NULL`).
+			WithArgs(float64(86400)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO webhook_signing_keys`).WithArgs("whsec_new", "admin1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("2", now))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.RotateWebhookKey(ctx, key, 24*time.Hour))
+		require.Equal(t, "2", key.ID)
+		require.Equal(t, now, key.CreatedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ValidWebhookKeys", func(t *testing.T) {
+		expiresAt := now.Add(time.Hour)
+		mock.ExpectQuery(`FROM webhook_signing_keys\s+WHERE expires_at IS NULL OR expires_at > NOW\(\)`).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("2", "whsec_new", "admin1", now, nil).
+				AddRow("1", "whsec_old", "admin1", now.Add(-time.Hour), expiresAt))
+
+		keys, err := repo.ValidWebhookKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		require.Equal(t, "whsec_old", keys[1].Secret)
+		require.Equal(t, expiresAt, *keys[1].ExpiresAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpireWebhookKey", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_signing_keys SET expires_at = LEAST\(expires_at, NOW\(\)\)`).WithArgs("1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "whsec_old", "admin1", now, now))
+
+		key, err := repo.ExpireWebhookKey(ctx, "1")
+		require.NoError(t, err)
+		require.Equal(t, now, *key.ExpiresAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpireWebhookKey current key", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_signing_keys`).WithArgs("2").WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("2").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		_, err := repo.ExpireWebhookKey(ctx, "2")
+		require.ErrorIs(t, err, ErrWebhookKeyCurrent)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpireWebhookKey unknown", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_signing_keys`).WithArgs("9").WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs("9").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := repo.ExpireWebhookKey(ctx, "9")
+		require.ErrorIs(t, err, ErrWebhookKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// WebhookSubscriptionRepository stores whether the event webhook is paused
+// and measures the events waiting for delivery
+type WebhookSubscriptionRepository interface {
+	GetWebhookSubscription(ctx context.Context) (*models.WebhookSubscription, error)
+	PauseWebhookSubscription(ctx context.Context, actor, reason string) (*models.WebhookSubscription, error)
+	ResumeWebhookSubscription(ctx context.Context, actor string) (*models.WebhookSubscription, error)
+}
+
+var (
+	ErrWebhookSubscriptionPaused    = errors.New("webhook deliveries are already paused")
+	ErrWebhookSubscriptionNotPaused = errors.New("webhook deliveries are not paused")
+)
+
+const webhookSubscriptionColumns = `paused_at, paused_by, pause_reason, resumed_at, resumed_by`
+
+type PostgresWebhookSubscriptionRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWebhookSubscriptionRepository(db *sql.DB, logger *logrus.Logger) *PostgresWebhookSubscriptionRepository {
+	return &PostgresWebhookSubscriptionRepository{db: db, logger: logger}
+}
+
+// GetWebhookSubscription returns the delivery state and the backlog of
+// unpublished events
+func (r *PostgresWebhookSubscriptionRepository) GetWebhookSubscription(ctx context.Context) (*models.WebhookSubscription, error) {
+	subscription, err := scanWebhookSubscription(r.db.QueryRowContext(ctx,
+		"SELECT "+webhookSubscriptionColumns+" FROM webhook_subscription",
+	))
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("GetWebhookSubscription - Query subscription failed")
+		return nil, err
+	}
+	if err := r.backlog(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// PauseWebhookSubscription stops deliveries until they are resumed
+func (r *PostgresWebhookSubscriptionRepository) PauseWebhookSubscription(ctx context.Context, actor, reason string) (*models.WebhookSubscription, error) {
+	subscription, err := scanWebhookSubscription(r.db.QueryRowContext(ctx,
+		`UPDATE webhook_subscription
+		SET paused_at = NOW(), paused_by = $1, pause_reason = NULLIF($2, ''), resumed_at = NULL, resumed_by = NULL
+		WHERE paused_at IS NULL
+		RETURNING `+webhookSubscriptionColumns,
+		actor, reason,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookSubscriptionPaused
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("PauseWebhookSubscription - Update subscription failed")
+		return nil, err
+	}
+	if err := r.backlog(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// ResumeWebhookSubscription lets the relay deliver the backlog, oldest event
+// first
+func (r *PostgresWebhookSubscriptionRepository) ResumeWebhookSubscription(ctx context.Context, actor string) (*models.WebhookSubscription, error) {
+	subscription, err := scanWebhookSubscription(r.db.QueryRowContext(ctx,
+		`UPDATE webhook_subscription
+		SET paused_at = NULL, resumed_at = NOW(), resumed_by = $1
+		WHERE paused_at IS NOT NULL
+		RETURNING `+webhookSubscriptionColumns,
+		actor,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookSubscriptionNotPaused
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ResumeWebhookSubscription - Update subscription failed")
+		return nil, err
+	}
+	if err := r.backlog(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// backlog fills in the events waiting for delivery
+func (r *PostgresWebhookSubscriptionRepository) backlog(ctx context.Context, subscription *models.WebhookSubscription) error {
+	var lag float64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), MIN(created_at), COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0)
+		FROM outbox_events
+		WHERE published_at IS NULL`,
+	).Scan(&subscription.Backlog.Events, &subscription.Backlog.OldestPendingAt, &lag)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("backlog - Query pending events failed")
+		return err
+	}
+	subscription.Backlog.LagSeconds = int64(lag)
+	return nil
+}
+
+func scanWebhookSubscription(row *sql.Row) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := row.Scan(
+		&subscription.PausedAt,
+		&subscription.PausedBy,
+		&subscription.PauseReason,
+		&subscription.ResumedAt,
+		&subscription.ResumedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
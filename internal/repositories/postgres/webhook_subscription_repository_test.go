@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptionRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWebhookSubscriptionRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"paused_at", "paused_by", "pause_reason", "resumed_at", "resumed_by"}
+	backlogColumns := []string{"count", "min", "lag"}
+
+	t.Run("GetWebhookSubscription with its backlog", func(t *testing.T) {
+		mock.ExpectQuery(`FROM webhook_subscription`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(now, "admin1", "maintenance", nil, nil))
+		mock.ExpectQuery(`SELECT COUNT\(\*\), MIN\(created_at\)`).
+			WillReturnRows(sqlmock.NewRows(backlogColumns).AddRow(42, now.Add(-time.Minute), 60.5))
+
+		subscription, err := repo.GetWebhookSubscription(ctx)
+		require.NoError(t, err)
+		require.True(t, subscription.Paused())
+		require.Equal(t, "maintenance", *subscription.PauseReason)
+		require.Equal(t, int64(42), subscription.Backlog.Events)
+		require.Equal(t, int64(60), subscription.Backlog.LagSeconds)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PauseWebhookSubscription already paused", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_subscription`).WithArgs("admin1", "").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.PauseWebhookSubscription(ctx, "admin1", "")
+		require.ErrorIs(t, err, ErrWebhookSubscriptionPaused)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResumeWebhookSubscription", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_subscription\s+SET paused_at = NULL`).WithArgs("admin1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(nil, "admin1", "maintenance", now, "admin1"))
+		mock.ExpectQuery(`FROM outbox_events`).
+			WillReturnRows(sqlmock.NewRows(backlogColumns).AddRow(0, nil, 0))
+
+		subscription, err := repo.ResumeWebhookSubscription(ctx, "admin1")
+		require.NoError(t, err)
+		require.False(t, subscription.Paused())
+		require.Nil(t, subscription.Backlog.OldestPendingAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResumeWebhookSubscription not paused", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_subscription`).WithArgs("admin1").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.ResumeWebhookSubscription(ctx, "admin1")
+		require.ErrorIs(t, err, ErrWebhookSubscriptionNotPaused)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
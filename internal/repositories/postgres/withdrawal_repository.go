@@ -0,0 +1,459 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+// WithdrawalRepository stores withdrawals to external destinations and the
+// funds they hold until the payout provider has sent them
+type WithdrawalRepository interface {
+	CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) error
+	GetWithdrawal(ctx context.Context, userID, withdrawalID string) (*models.Withdrawal, error)
+	ClaimWithdrawals(ctx context.Context, limit int, staleBefore time.Time) ([]models.Withdrawal, error)
+	AssignRoute(ctx context.Context, withdrawalID string, route models.PayoutRoute) (*models.Withdrawal, error)
+	Failover(ctx context.Context, withdrawalID string, failover models.PayoutFailover) error
+	CompleteWithdrawal(ctx context.Context, withdrawalID, providerReference string) (*models.Withdrawal, error)
+	FailWithdrawal(ctx context.Context, withdrawalID, reason string) (*models.Withdrawal, error)
+}
+
+var (
+	ErrWithdrawalNotFound      = errors.New("withdrawal not found")
+	ErrWithdrawalNotProcessing = errors.New("withdrawal is not processing")
+	ErrInvalidRoute            = errors.New("payout route has no candidates")
+)
+
+const withdrawalColumns = `id::text, user_id, amount, fee, currency, destination, status, attempts, provider, route,
+	provider_reference, error, transaction_id::text, created_at, updated_at`
+
+type PostgresWithdrawalRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewWithdrawalRepository(db *sql.DB, logger *logrus.Logger) *PostgresWithdrawalRepository {
+	return &PostgresWithdrawalRepository{db: db, logger: logger}
+}
+
+// CreateWithdrawal holds withdrawal.Amount and withdrawal.Fee on the wallet
+// and records the withdrawal as requested, filling in its ID, status and
+// timestamps
+func (r *PostgresWithdrawalRepository) CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) error {
+	if withdrawal.UserID == "" {
+		r.logger.WithContext(ctx).Warn("CreateWithdrawal - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	if !withdrawal.Amount.IsPositive() || withdrawal.Fee.IsNegative() {
+		r.logger.WithContext(ctx).Warn("CreateWithdrawal - amount cannot be less than zero")
+		return ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": withdrawal.UserID,
+		"amount": withdrawal.Amount,
+		"fee":    withdrawal.Fee,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CreateWithdrawal - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance, held decimal.Decimal
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT balance, held, status, currency FROM wallets WHERE user_id = $1 FOR UPDATE",
+		withdrawal.UserID,
+	).Scan(&balance, &held, &status, &withdrawal.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("CreateWithdrawal - Cannot find user in the database")
+		return ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("CreateWithdrawal - Query user balance failed")
+		return err
+	}
+
+	if err := statusError(status); err != nil {
+		logger.WithField("status", status).Warn("CreateWithdrawal - Wallet is not active")
+		return err
+	}
+
+	total := withdrawal.Amount.Add(withdrawal.Fee)
+	if balance.Sub(held).LessThan(total) {
+		logger.Warn("CreateWithdrawal - User available balance is too low")
+		return ErrInsufficientBalance
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET held = held + $1 WHERE user_id = $2",
+		total, withdrawal.UserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("CreateWithdrawal - Update held balance failed")
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO withdrawals (user_id, amount, fee, currency, destination, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id::text, created_at, updated_at`,
+		withdrawal.UserID, withdrawal.Amount, withdrawal.Fee, withdrawal.Currency, withdrawal.Destination, models.WithdrawalRequested,
+	).Scan(&withdrawal.ID, &withdrawal.CreatedAt, &withdrawal.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CreateWithdrawal - Create withdrawal record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CreateWithdrawal - Commit DB transaction failed")
+		return err
+	}
+
+	withdrawal.Status = models.WithdrawalRequested
+	logger.WithField("withdrawalID", withdrawal.ID).Info("Withdrawal requested")
+	return nil
+}
+
+// GetWithdrawal returns the withdrawal withdrawalID of userID
+func (r *PostgresWithdrawalRepository) GetWithdrawal(ctx context.Context, userID, withdrawalID string) (*models.Withdrawal, error) {
+	withdrawal, err := scanWithdrawal(r.db.QueryRowContext(ctx,
+		`SELECT `+withdrawalColumns+`
+		FROM withdrawals
+		WHERE user_id = $1 AND id::text = $2`,
+		userID, withdrawalID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWithdrawalNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("withdrawalID", withdrawalID).Error("GetWithdrawal - Query withdrawal failed")
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// ClaimWithdrawals moves up to limit withdrawals to processing, oldest first,
+// and returns them. Withdrawals left processing since before staleBefore are
+// claimed again: their worker stopped or the provider failed transiently.
+// Rows claimed by a concurrent worker are skipped.
+func (r *PostgresWithdrawalRepository) ClaimWithdrawals(ctx context.Context, limit int, staleBefore time.Time) ([]models.Withdrawal, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE withdrawals
+		SET status = $1, attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM withdrawals
+			WHERE status = $2 OR (status = $1 AND updated_at < $3)
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+withdrawalColumns,
+		models.WithdrawalProcessing, models.WithdrawalRequested, staleBefore, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimWithdrawals - Claim withdrawals failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []models.Withdrawal
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("ClaimWithdrawals - Scan withdrawals failed")
+			return nil, err
+		}
+		withdrawals = append(withdrawals, *withdrawal)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ClaimWithdrawals - Iterate withdrawals failed")
+		return nil, err
+	}
+	return withdrawals, nil
+}
+
+// AssignRoute records the route of a processing withdrawal and sends it
+// through the first candidate. A route assigned before, by an earlier
+// attempt or a concurrent worker, is kept; the withdrawal is returned with
+// the route it ends up with.
+func (r *PostgresWithdrawalRepository) AssignRoute(ctx context.Context, withdrawalID string, route models.PayoutRoute) (*models.Withdrawal, error) {
+	logger := r.logger.WithContext(ctx).WithField("withdrawalID", withdrawalID)
+	if len(route.Candidates) == 0 {
+		logger.Warn("AssignRoute - route has no candidates")
+		return nil, ErrInvalidRoute
+	}
+
+	encoded, err := json.Marshal(route)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := scanWithdrawal(r.db.QueryRowContext(ctx,
+		`UPDATE withdrawals
+		SET provider = COALESCE(provider, $1), route = COALESCE(route, $2)
+		WHERE id::text = $3 AND status = $4
+		RETURNING `+withdrawalColumns,
+		route.Candidates[0].Provider, encoded, withdrawalID, models.WithdrawalProcessing,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWithdrawalNotProcessing
+	}
+	if err != nil {
+		logger.WithError(err).Error("AssignRoute - Assign payout route failed")
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// Failover moves a processing withdrawal from failover.From to failover.To
+// and appends the failover to its route. It fails with
+// ErrWithdrawalNotProcessing when the withdrawal was settled or moved to
+// another provider meanwhile.
+func (r *PostgresWithdrawalRepository) Failover(ctx context.Context, withdrawalID string, failover models.PayoutFailover) error {
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"withdrawalID": withdrawalID,
+		"from":         failover.From,
+		"to":           failover.To,
+	})
+
+	encoded, err := json.Marshal(failover)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE withdrawals
+		SET provider = $1,
+			route = jsonb_set(COALESCE(route, '{}'), '{failovers}',
+				COALESCE(route->'failovers', '[]') || jsonb_build_array($2::jsonb)),
+			updated_at = NOW()
+		WHERE id::text = $3 AND status = $4 AND provider = $5`,
+		failover.To, encoded, withdrawalID, models.WithdrawalProcessing, failover.From,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Failover - Update payout provider failed")
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.WithError(err).Error("Failover - Read affected rows failed")
+		return err
+	}
+	if affected == 0 {
+		logger.Warn("Failover - Withdrawal is no longer processing with the provider")
+		return ErrWithdrawalNotProcessing
+	}
+
+	logger.Info("Payout failed over")
+	return nil
+}
+
+// CompleteWithdrawal records a payout sent by the provider: the held amount
+// leaves the wallet as a withdrawal transaction and the held fee is charged.
+// The funds are already gone, so the wallet is debited whatever its status.
+func (r *PostgresWithdrawalRepository) CompleteWithdrawal(ctx context.Context, withdrawalID, providerReference string) (*models.Withdrawal, error) {
+	logger := r.logger.WithContext(ctx).WithField("withdrawalID", withdrawalID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	withdrawal, err := lockProcessingWithdrawal(ctx, tx, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		"UPDATE wallets SET balance = balance - $1, held = held - $2 WHERE user_id = $3 RETURNING balance",
+		withdrawal.Amount, withdrawal.Amount.Add(withdrawal.Fee), withdrawal.UserID,
+	).Scan(&balance)
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Update user balance failed")
+		return nil, err
+	}
+
+	var transactionID string
+	actor, channel := provenance(ctx)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions
+		(from_user_id, amount, type, created_at, actor, channel)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		withdrawal.UserID, withdrawal.Amount, "withdrawal", time.Now(), actor, channel,
+	).Scan(&transactionID)
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Create transaction record failed")
+		return nil, err
+	}
+
+	sequence, err := assignSequence(ctx, sqlTx{tx}, transactionID, withdrawal.UserID)
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Assign sequence number failed")
+		return nil, err
+	}
+
+	event := events.New(events.TypeWalletDebited, events.WalletDebited{
+		UserID:        withdrawal.UserID,
+		Amount:        withdrawal.Amount,
+		TransactionID: transactionID,
+		Sequence:      sequence,
+	})
+	if err = enqueueEvent(ctx, sqlTx{tx}, event, withdrawal.UserID); err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Record wallet debited event failed")
+		return nil, err
+	}
+
+	before, after := auditBalances(balance.Add(withdrawal.Amount), withdrawal.Amount.Neg())
+	err = recordAudit(ctx, sqlTx{tx}, models.AuditEntry{Action: models.AuditWithdrawal, UserID: withdrawal.UserID, TransactionID: transactionID, Before: before, After: after})
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Record audit entry failed")
+		return nil, err
+	}
+
+	if err = chargeFee(ctx, tx, logger, withdrawal.UserID, withdrawal.Fee, transactionID); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE withdrawals
+		SET status = $1, provider_reference = $2, transaction_id = $3, updated_at = NOW()
+		WHERE id::text = $4
+		RETURNING updated_at`,
+		models.WithdrawalCompleted, providerReference, transactionID, withdrawalID,
+	).Scan(&withdrawal.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Update withdrawal failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("CompleteWithdrawal - Commit DB transaction failed")
+		return nil, err
+	}
+
+	withdrawal.Status = models.WithdrawalCompleted
+	withdrawal.ProviderReference = &providerReference
+	withdrawal.TransactionID = &transactionID
+	logger.Info("Withdrawal completed")
+	return withdrawal, nil
+}
+
+// FailWithdrawal records a payout rejected by the provider and returns the
+// held amount and fee to the wallet's available balance
+func (r *PostgresWithdrawalRepository) FailWithdrawal(ctx context.Context, withdrawalID, reason string) (*models.Withdrawal, error) {
+	logger := r.logger.WithContext(ctx).WithField("withdrawalID", withdrawalID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("FailWithdrawal - Begin DB transaction failed")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	withdrawal, err := lockProcessingWithdrawal(ctx, tx, withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE wallets SET held = held - $1 WHERE user_id = $2",
+		withdrawal.Amount.Add(withdrawal.Fee), withdrawal.UserID,
+	)
+	if err != nil {
+		logger.WithError(err).Error("FailWithdrawal - Update held balance failed")
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE withdrawals
+		SET status = $1, error = $2, updated_at = NOW()
+		WHERE id::text = $3
+		RETURNING updated_at`,
+		models.WithdrawalFailed, reason, withdrawalID,
+	).Scan(&withdrawal.UpdatedAt)
+	if err != nil {
+		logger.WithError(err).Error("FailWithdrawal - Update withdrawal failed")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("FailWithdrawal - Commit DB transaction failed")
+		return nil, err
+	}
+
+	withdrawal.Status = models.WithdrawalFailed
+	withdrawal.Error = &reason
+	logger.WithField("reason", reason).Warn("Withdrawal failed")
+	return withdrawal, nil
+}
+
+// lockProcessingWithdrawal locks a withdrawal row for the rest of tx, failing
+// unless it is processing
+func lockProcessingWithdrawal(ctx context.Context, tx *sql.Tx, withdrawalID string) (*models.Withdrawal, error) {
+	withdrawal, err := scanWithdrawal(tx.QueryRowContext(ctx,
+		`SELECT `+withdrawalColumns+`
+		FROM withdrawals
+		WHERE id::text = $1
+		FOR UPDATE`,
+		withdrawalID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWithdrawalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal.Status != models.WithdrawalProcessing {
+		return nil, ErrWithdrawalNotProcessing
+	}
+	return withdrawal, nil
+}
+
+func scanWithdrawal(row rowScanner) (*models.Withdrawal, error) {
+	var withdrawal models.Withdrawal
+	var route []byte
+	err := row.Scan(
+		&withdrawal.ID,
+		&withdrawal.UserID,
+		&withdrawal.Amount,
+		&withdrawal.Fee,
+		&withdrawal.Currency,
+		&withdrawal.Destination,
+		&withdrawal.Status,
+		&withdrawal.Attempts,
+		&withdrawal.Provider,
+		&route,
+		&withdrawal.ProviderReference,
+		&withdrawal.Error,
+		&withdrawal.TransactionID,
+		&withdrawal.CreatedAt,
+		&withdrawal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if route != nil {
+		withdrawal.Route = &models.PayoutRoute{}
+		if err := json.Unmarshal(route, withdrawal.Route); err != nil {
+			return nil, err
+		}
+	}
+	return &withdrawal, nil
+}
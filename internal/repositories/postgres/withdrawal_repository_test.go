@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+)
+
+func TestWithdrawalRepository(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := NewWithdrawalRepository(mockDB, logrus.New())
+	now := time.Now()
+	columns := []string{"id", "user_id", "amount", "fee", "currency", "destination", "status", "attempts", "provider", "route", "provider_reference", "error", "transaction_id", "created_at", "updated_at"}
+
+	t.Run("CreateWithdrawal", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(150.0, 20.0, "active", "EUR"))
+			mock.ExpectExec(`UPDATE wallets SET held = held \+ \$1`).WithArgs(decimal.NewFromInt(102), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`INSERT INTO withdrawals`).WithArgs("user1", decimal.NewFromInt(100), decimal.NewFromInt(2), "EUR", "iban:GB33", models.WithdrawalRequested).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("4", now, now))
+			mock.ExpectCommit()
+
+			withdrawal := &models.Withdrawal{UserID: "user1", Amount: decimal.NewFromInt(100), Fee: decimal.NewFromInt(2), Destination: "iban:GB33"}
+			require.NoError(t, repo.CreateWithdrawal(ctx, withdrawal))
+			require.Equal(t, "4", withdrawal.ID)
+			require.Equal(t, models.WithdrawalRequested, withdrawal.Status)
+			require.Equal(t, "EUR", *withdrawal.Currency)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("the fee must be available too", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(101.0, 0.0, "active", nil))
+			mock.ExpectRollback()
+
+			withdrawal := &models.Withdrawal{UserID: "user1", Amount: decimal.NewFromInt(100), Fee: decimal.NewFromInt(2), Destination: "iban:GB33"}
+			require.ErrorIs(t, repo.CreateWithdrawal(ctx, withdrawal), ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("held funds are not available", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT balance, held, status, currency`).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"balance", "held", "status", "currency"}).AddRow(150.0, 100.0, "active", nil))
+			mock.ExpectRollback()
+
+			withdrawal := &models.Withdrawal{UserID: "user1", Amount: decimal.NewFromInt(100), Destination: "iban:GB33"}
+			require.ErrorIs(t, repo.CreateWithdrawal(ctx, withdrawal), ErrInsufficientBalance)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("GetWithdrawal not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("user1", "9").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetWithdrawal(ctx, "user1", "9")
+		require.ErrorIs(t, err, ErrWithdrawalNotFound)
+	})
+
+	t.Run("ClaimWithdrawals", func(t *testing.T) {
+		staleBefore := now.Add(-time.Minute)
+		mock.ExpectQuery(`UPDATE withdrawals\s+SET status = \$1, attempts = attempts \+ 1`).
+			WithArgs(models.WithdrawalProcessing, models.WithdrawalRequested, staleBefore, 10).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("4", "user1", "100", "0", nil, "iban:GB33", models.WithdrawalProcessing, 1, "bank", nil, nil, nil, nil, now, now))
+
+		withdrawals, err := repo.ClaimWithdrawals(ctx, 10, staleBefore)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		require.Equal(t, models.WithdrawalProcessing, withdrawals[0].Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AssignRoute", func(t *testing.T) {
+		route := models.PayoutRoute{Candidates: []models.PayoutCandidate{{Provider: "bank_b", EstimatedFee: decimal.NewFromInt(1)}}}
+
+		t.Run("keeps the route of earlier attempts", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE withdrawals\s+SET provider = COALESCE\(provider, \$1\), route = COALESCE\(route, \$2\)`).
+				WithArgs("bank_b", sqlmock.AnyArg(), "4", models.WithdrawalProcessing).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("4", "user1", "100", "0", "EUR", "iban:GB33", models.WithdrawalProcessing, 2, "bank_a",
+					`{"candidates": [{"provider": "bank_a", "estimated_fee": "0.5"}, {"provider": "bank_b", "estimated_fee": "1"}]}`, nil, nil, nil, now, now))
+
+			withdrawal, err := repo.AssignRoute(ctx, "4", route)
+			require.NoError(t, err)
+			require.Equal(t, "bank_a", *withdrawal.Provider)
+			require.Len(t, withdrawal.Route.Candidates, 2)
+			require.Equal(t, "bank_b", withdrawal.Route.Candidates[1].Provider)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("not processing", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE withdrawals\s+SET provider`).
+				WithArgs("bank_b", sqlmock.AnyArg(), "4", models.WithdrawalProcessing).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			_, err := repo.AssignRoute(ctx, "4", route)
+			require.ErrorIs(t, err, ErrWithdrawalNotProcessing)
+		})
+
+		t.Run("empty route", func(t *testing.T) {
+			_, err := repo.AssignRoute(ctx, "4", models.PayoutRoute{})
+			require.ErrorIs(t, err, ErrInvalidRoute)
+		})
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		failover := models.PayoutFailover{From: "bank_a", To: "bank_b", Error: "payout provider unavailable", At: now}
+
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectExec(`UPDATE withdrawals\s+SET provider = \$1,\s+route = jsonb_set`).
+				WithArgs("bank_b", sqlmock.AnyArg(), "4", models.WithdrawalProcessing, "bank_a").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			require.NoError(t, repo.Failover(ctx, "4", failover))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("moved by another worker", func(t *testing.T) {
+			mock.ExpectExec(`UPDATE withdrawals\s+SET provider = \$1`).
+				WithArgs("bank_b", sqlmock.AnyArg(), "4", models.WithdrawalProcessing, "bank_a").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			require.ErrorIs(t, repo.Failover(ctx, "4", failover), ErrWithdrawalNotProcessing)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("CompleteWithdrawal", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("4").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("4", "user1", "100", "2", nil, "iban:GB33", models.WithdrawalProcessing, 1, "bank", nil, nil, nil, nil, now, now))
+			mock.ExpectQuery(`UPDATE wallets SET balance = balance - \$1, held = held - \$2`).WithArgs(decimal.NewFromInt(100), decimal.NewFromInt(102), "user1").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("398"))
+			mock.ExpectQuery(`INSERT INTO transactions`).WithArgs("user1", decimal.NewFromInt(100), "withdrawal", sqlmock.AnyArg(), nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("12"))
+			mock.ExpectQuery(`INSERT INTO wallet_sequences`).WithArgs("user1", "12").WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+			mock.ExpectExec(`INSERT INTO outbox_events`).WithArgs(sqlmock.AnyArg(), events.TypeWalletDebited, "user1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+			expectAudit(mock, 1)
+			expectFee(mock, "user1", decimal.NewFromInt(2), "12")
+			mock.ExpectQuery(`UPDATE withdrawals\s+SET status = \$1, provider_reference`).WithArgs(models.WithdrawalCompleted, "po_1", "12", "4").
+				WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+			mock.ExpectCommit()
+
+			withdrawal, err := repo.CompleteWithdrawal(ctx, "4", "po_1")
+			require.NoError(t, err)
+			require.Equal(t, models.WithdrawalCompleted, withdrawal.Status)
+			require.Equal(t, "12", *withdrawal.TransactionID)
+			require.Equal(t, "po_1", *withdrawal.ProviderReference)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("already completed", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("4").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("4", "user1", "100", "0", nil, "iban:GB33", models.WithdrawalCompleted, 1, "bank", nil, "po_1", nil, "12", now, now))
+			mock.ExpectRollback()
+
+			_, err := repo.CompleteWithdrawal(ctx, "4", "po_1")
+			require.ErrorIs(t, err, ErrWithdrawalNotProcessing)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("FailWithdrawal", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id::text, user_id, amount`).WithArgs("5").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("5", "user1", "30", "2", nil, "iban:XX", models.WithdrawalProcessing, 2, "bank", nil, nil, nil, nil, now, now))
+		mock.ExpectExec(`UPDATE wallets SET held = held - \$1`).WithArgs(decimal.NewFromInt(32), "user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE withdrawals\s+SET status = \$1, error`).WithArgs(models.WithdrawalFailed, "invalid destination", "5").
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		withdrawal, err := repo.FailWithdrawal(ctx, "5", "invalid destination")
+		require.NoError(t, err)
+		require.Equal(t, models.WithdrawalFailed, withdrawal.Status)
+		require.Equal(t, "invalid destination", *withdrawal.Error)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+const balanceChannelPrefix = "balance:changed:"
+
+// BalanceNotifier announces balance changes to the requests waiting for
+// them. A notification means the balance may have changed; waiters read the
+// balance version to find out.
+type BalanceNotifier interface {
+	// Notify announces that the balance of userID may have changed
+	Notify(ctx context.Context, userID string) error
+	// Watch returns a channel receiving a value whenever the balance of
+	// userID may have changed, and a function ending the watch. The channel
+	// is closed when the notifier shuts down.
+	Watch(userID string) (<-chan struct{}, func())
+	// Close closes every watch channel, so waiting requests return before
+	// the server stops
+	Close()
+}
+
+// balanceWatchers fans notifications out to the watches of this instance
+type balanceWatchers struct {
+	mu       sync.Mutex
+	closed   bool
+	watchers map[string]map[chan struct{}]struct{}
+}
+
+func (w *balanceWatchers) Watch(userID string) (<-chan struct{}, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if w.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if w.watchers == nil {
+		w.watchers = make(map[string]map[chan struct{}]struct{})
+	}
+	if w.watchers[userID] == nil {
+		w.watchers[userID] = make(map[chan struct{}]struct{})
+	}
+	w.watchers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.watchers[userID][ch]; !ok {
+			return
+		}
+		delete(w.watchers[userID], ch)
+		if len(w.watchers[userID]) == 0 {
+			delete(w.watchers, userID)
+		}
+	}
+}
+
+// wake signals the watches of userID. A watch that has not consumed the
+// previous signal yet is not signalled twice.
+func (w *balanceWatchers) wake(userID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.watchers[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *balanceWatchers) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	for _, watches := range w.watchers {
+		for ch := range watches {
+			close(ch)
+		}
+	}
+	w.watchers = nil
+}
+
+// LocalBalanceNotifier is used when Redis is disabled or unreachable. Only
+// changes made by this instance are announced; waiters still see changes
+// made elsewhere when their wait times out.
+type LocalBalanceNotifier struct {
+	balanceWatchers
+}
+
+func NewLocalBalanceNotifier() *LocalBalanceNotifier {
+	return &LocalBalanceNotifier{}
+}
+
+func (n *LocalBalanceNotifier) Notify(ctx context.Context, userID string) error {
+	n.wake(userID)
+	return nil
+}
+
+// RedisBalanceNotifier publishes balance changes on a Redis channel per
+// wallet. Each instance holds one pattern subscription and fans the messages
+// out to its waiting requests, so waiters do not take Redis connections.
+type RedisBalanceNotifier struct {
+	balanceWatchers
+	client    redis.Cmdable
+	subscribe func(ctx context.Context, patterns ...string) *redis.PubSub
+	logger    *logrus.Logger
+}
+
+func NewBalanceNotifier(client *redis.Client, logger *logrus.Logger) *RedisBalanceNotifier {
+	return &RedisBalanceNotifier{
+		client:    client,
+		subscribe: client.PSubscribe,
+		logger:    logger,
+	}
+}
+
+func (n *RedisBalanceNotifier) Notify(ctx context.Context, userID string) error {
+	if err := n.client.Publish(ctx, balanceChannelPrefix+userID, "").Err(); err != nil {
+		n.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Warn("Notify - Publish balance change failed")
+		return err
+	}
+	return nil
+}
+
+// Run receives the balance changes of every instance until ctx is cancelled.
+// The subscription reconnects by itself when the connection to Redis drops.
+func (n *RedisBalanceNotifier) Run(ctx context.Context) {
+	pubsub := n.subscribe(ctx, balanceChannelPrefix+"*")
+	defer pubsub.Close()
+	n.dispatch(ctx, pubsub.Channel())
+}
+
+func (n *RedisBalanceNotifier) dispatch(ctx context.Context, messages <-chan *redis.Message) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			n.wake(strings.TrimPrefix(message.Channel, balanceChannelPrefix))
+		}
+	}
+}
+
+// NotifyingCacheRepository announces every cached balance change. All
+// operations moving funds refresh the cache once they committed, so they are
+// announced without knowing about the notifier.
+type NotifyingCacheRepository struct {
+	CacheRepository
+	notifier BalanceNotifier
+}
+
+func NewNotifyingCacheRepository(cache CacheRepository, notifier BalanceNotifier) *NotifyingCacheRepository {
+	return &NotifyingCacheRepository{CacheRepository: cache, notifier: notifier}
+}
+
+func (r *NotifyingCacheRepository) InvalidateBalance(ctx context.Context, userID string) error {
+	err := r.CacheRepository.InvalidateBalance(ctx, userID)
+	_ = r.notifier.Notify(ctx, userID)
+	return err
+}
+
+// SetVersionedBalance also announces balances cached by reads. The waiters
+// find the version unchanged and keep waiting.
+func (r *NotifyingCacheRepository) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	stored, err := r.CacheRepository.SetVersionedBalance(ctx, userID, balance, version, ttl)
+	_ = r.notifier.Notify(ctx, userID)
+	return stored, err
+}
@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	mockredis "Crypto.com/mocks"
+)
+
+func TestBalanceNotifier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	notifier := &RedisBalanceNotifier{client: mockClient, logger: logrus.New()}
+	ctx := context.Background()
+
+	t.Run("Notify publishes on the wallet channel", func(t *testing.T) {
+		mockClient.EXPECT().Publish(ctx, "balance:changed:user1", "").Return(redis.NewIntResult(1, nil))
+
+		if err := notifier.Notify(ctx, "user1"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Notify redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().Publish(ctx, "balance:changed:user1", "").Return(redis.NewIntResult(0, mockErr))
+
+		if err := notifier.Notify(ctx, "user1"); !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+
+	t.Run("messages wake the watches of their wallet", func(t *testing.T) {
+		user1, unwatch1 := notifier.Watch("user1")
+		defer unwatch1()
+		user2, unwatch2 := notifier.Watch("user2")
+		defer unwatch2()
+
+		messages := make(chan *redis.Message, 2)
+		messages <- &redis.Message{Channel: "balance:changed:user1"}
+		messages <- &redis.Message{Channel: "balance:changed:user1"}
+		close(messages)
+		notifier.dispatch(ctx, messages)
+
+		select {
+		case <-user1:
+		default:
+			t.Error("Expected user1 to be woken")
+		}
+		select {
+		case <-user1:
+			t.Error("Expected one pending wake-up for user1")
+		case <-user2:
+			t.Error("Expected user2 not to be woken")
+		default:
+		}
+	})
+}
+
+func TestLocalBalanceNotifier(t *testing.T) {
+	notifier := NewLocalBalanceNotifier()
+	ctx := context.Background()
+
+	t.Run("ended watches are not woken", func(t *testing.T) {
+		changes, unwatch := notifier.Watch("user1")
+		unwatch()
+		unwatch()
+
+		_ = notifier.Notify(ctx, "user1")
+		select {
+		case <-changes:
+			t.Error("Expected no wake-up after the watch ended")
+		default:
+		}
+	})
+
+	t.Run("Close ends every watch", func(t *testing.T) {
+		changes, unwatch := notifier.Watch("user1")
+		defer unwatch()
+
+		notifier.Close()
+		if _, ok := <-changes; ok {
+			t.Error("Expected the watch to be closed")
+		}
+		if _, ok := <-mustWatch(notifier, "user2"); ok {
+			t.Error("Expected watches after Close to be closed")
+		}
+	})
+}
+
+func mustWatch(notifier BalanceNotifier, userID string) <-chan struct{} {
+	changes, _ := notifier.Watch(userID)
+	return changes
+}
+
+func TestNotifyingCacheRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mockredis.NewMockCacheRepository(ctrl)
+	notifier := NewLocalBalanceNotifier()
+	cache := NewNotifyingCacheRepository(mockCache, notifier)
+	ctx := context.Background()
+
+	changes, unwatch := notifier.Watch("user1")
+	defer unwatch()
+
+	t.Run("InvalidateBalance notifies even when it fails", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(mockErr)
+
+		if err := cache.InvalidateBalance(ctx, "user1"); !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+		select {
+		case <-changes:
+		default:
+			t.Error("Expected a notification")
+		}
+	})
+
+	t.Run("SetVersionedBalance notifies", func(t *testing.T) {
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", decimal.NewFromInt(5), int64(3), time.Minute).Return(true, nil)
+
+		stored, err := cache.SetVersionedBalance(ctx, "user1", decimal.NewFromInt(5), 3, time.Minute)
+		if err != nil || !stored {
+			t.Errorf("Expected stored, got %v, %v", stored, err)
+		}
+		select {
+		case <-changes:
+		default:
+			t.Error("Expected a notification")
+		}
+	})
+
+	t.Run("reads are passed through", func(t *testing.T) {
+		mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(5), nil)
+
+		if _, err := cache.GetBalance(ctx, "user1"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		select {
+		case <-changes:
+			t.Error("Expected no notification")
+		default:
+		}
+	})
+}
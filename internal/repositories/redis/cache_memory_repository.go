@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// CacheMemoryRepository reads the memory usage of Redis and frees memory by
+// evicting the cached balances of the least active wallets
+type CacheMemoryRepository interface {
+	MemoryUsage(ctx context.Context) (*models.CacheMemoryUsage, error)
+	// SetEvictionPolicy sets the maxmemory-policy Redis applies once it
+	// reaches maxmemory
+	SetEvictionPolicy(ctx context.Context, policy string) error
+	// EvictIdleBalances deletes the cached balances of up to limit wallets
+	// last active before idleSince, least active first, and returns how many
+	// wallets it evicted
+	EvictIdleBalances(ctx context.Context, idleSince time.Time, limit int) (int, error)
+}
+
+// evictIdleScript deletes the balances of up to ARGV[2] wallets tracked in
+// KEYS[1] whose last activity is before ARGV[1], and stops tracking them.
+// Balance keys are derived from the tracked user IDs, which assumes a
+// single Redis node like the other balance scripts.
+var evictIdleScript = redis.NewScript(`
+local users = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, user in ipairs(users) do
+	redis.call('DEL', ARGV[3] .. user)
+	redis.call('ZREM', KEYS[1], user)
+end
+return #users
+`)
+
+type CacheMemoryRepositoryImpl struct {
+	client redis.Cmdable
+	logger *logrus.Logger
+}
+
+func NewCacheMemoryRepository(client redis.Cmdable, logger *logrus.Logger) *CacheMemoryRepositoryImpl {
+	return &CacheMemoryRepositoryImpl{client: client, logger: logger}
+}
+
+func (r *CacheMemoryRepositoryImpl) MemoryUsage(ctx context.Context) (*models.CacheMemoryUsage, error) {
+	info, err := r.client.Info(ctx, "memory").Result()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Warn("MemoryUsage - Read memory info failed")
+		return nil, err
+	}
+
+	var usage models.CacheMemoryUsage
+	for _, line := range strings.Split(info, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		switch name {
+		case "used_memory":
+			usage.Used, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory":
+			usage.Max, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory_policy":
+			usage.Policy = value
+		}
+	}
+	return &usage, nil
+}
+
+func (r *CacheMemoryRepositoryImpl) SetEvictionPolicy(ctx context.Context, policy string) error {
+	if err := r.client.ConfigSet(ctx, "maxmemory-policy", policy).Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("policy", policy).Warn("SetEvictionPolicy - Set maxmemory-policy failed")
+		return err
+	}
+	return nil
+}
+
+func (r *CacheMemoryRepositoryImpl) EvictIdleBalances(ctx context.Context, idleSince time.Time, limit int) (int, error) {
+	evicted, err := evictIdleScript.Run(ctx, r.client, []string{activityKey}, idleSince.UnixMilli(), limit, balanceKey("")).Int()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("idleSince", idleSince).Error("EvictIdleBalances - Evict balances failed")
+		return 0, err
+	}
+	return evicted, nil
+}
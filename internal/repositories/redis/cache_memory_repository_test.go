@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	mockredis "Crypto.com/mocks"
+)
+
+func TestCacheMemoryRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	repo := NewCacheMemoryRepository(mockClient, logrus.New())
+
+	t.Run("MemoryUsage", func(t *testing.T) {
+		info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\nmaxmemory:4194304\r\nmaxmemory_policy:allkeys-lru\r\n"
+		mockClient.EXPECT().Info(gomock.Any(), "memory").Return(redis.NewStringResult(info, nil))
+
+		usage, err := repo.MemoryUsage(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if *usage != (models.CacheMemoryUsage{Used: 1048576, Max: 4194304, Policy: "allkeys-lru"}) {
+			t.Errorf("Unexpected usage %+v", usage)
+		}
+	})
+
+	t.Run("SetEvictionPolicy", func(t *testing.T) {
+		mockClient.EXPECT().ConfigSet(gomock.Any(), "maxmemory-policy", "volatile-lru").Return(redis.NewStatusResult("OK", nil))
+
+		if err := repo.SetEvictionPolicy(context.Background(), "volatile-lru"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("SetEvictionPolicy not allowed", func(t *testing.T) {
+		mockErr := errors.New("ERR unknown command 'CONFIG'")
+		mockClient.EXPECT().ConfigSet(gomock.Any(), gomock.Any(), gomock.Any()).Return(redis.NewStatusResult("", mockErr))
+
+		if err := repo.SetEvictionPolicy(context.Background(), "volatile-lru"); !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+
+	t.Run("EvictIdleBalances", func(t *testing.T) {
+		idleSince := time.UnixMilli(1714564800000)
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"balance-activity"}, int64(1714564800000), 500, "balance:").
+			Return(redis.NewCmdResult(int64(42), nil))
+
+		evicted, err := repo.EvictIdleBalances(context.Background(), idleSince, 500)
+		if err != nil || evicted != 42 {
+			t.Errorf("Expected 42 evictions, got %d (%v)", evicted, err)
+		}
+	})
+}
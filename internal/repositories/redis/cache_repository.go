@@ -6,43 +6,148 @@ import (
 	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"Crypto.com/internal/tracing"
 )
 
 type CacheRepository interface {
-	GetBalance(ctx context.Context, userID string) (float64, error)
-	SetBalance(ctx context.Context, userID string, balance float64) error
+	GetBalance(ctx context.Context, userID string) (decimal.Decimal, error)
+	// SetBalance caches balance for ttl, or for the repository default when
+	// ttl is zero
+	SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error
+	// SetVersionedBalance caches balance unless a balance of a newer version
+	// is cached, and reports whether it was stored
+	SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error)
 	InvalidateBalance(ctx context.Context, userID string) error
+	// ReadThrough returns the cached balance. On a miss it returns redis.Nil
+	// when the caller should load the balance, or ErrBalanceLoading when
+	// another caller is already loading it.
+	ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error)
 }
 
+// loadingMarkerTTL bounds how long a loading marker blocks other callers if
+// its holder never caches the balance
+const loadingMarkerTTL = time.Second
+
+// readThroughScript counts the read of the wallet and returns {1, balance}
+// on a hit, recording the activity of the wallet. On a miss it sets the
+// loading marker if nobody holds it and returns {0}, or {2} when the marker
+// is already held.
+var readThroughScript = redis.NewScript(`
+redis.call('ZINCRBY', KEYS[4], 1, ARGV[3])
+local balance = redis.call('GET', KEYS[1])
+if balance then
+	redis.call('ZADD', KEYS[3], ARGV[2], ARGV[3])
+	return {1, balance}
+end
+if redis.call('SET', KEYS[2], '1', 'NX', 'PX', ARGV[1]) then
+	return {0}
+end
+return {2}
+`)
+
+// setVersionedScript stores the balance and its version unless the cached
+// version is newer, records the activity of the wallet and releases the
+// loading marker. It returns 1 when the balance was stored. The version outlives a deleted balance, so a load that
+// started before an invalidation cannot cache an older balance afterwards.
+var setVersionedScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[2]))
+if current and current > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[4])
+redis.call('ZADD', KEYS[4], ARGV[5], ARGV[6])
+redis.call('DEL', KEYS[3])
+return 1
+`)
+
+// shortenTTLScript lowers the TTL of a cached balance to ARGV[1]
+// milliseconds unless it already expires sooner. It returns 1 when the TTL
+// was lowered.
+var shortenTTLScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl == -1 or ttl > tonumber(ARGV[1]) then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// versionTTLFactor keeps versions longer than the balances they guard
+const versionTTLFactor = 2
+
+// activityKey is a sorted set of the wallets with a cached balance, scored by
+// the time in milliseconds their balance was last cached or read from the
+// cache, so the least active wallets can be evicted first
+const activityKey = "balance-activity"
+
+// readsKey is a sorted set of the wallets whose balance was read through the
+// cache, scored by their decaying read count, so the most read balances can
+// be refreshed before they expire
+const readsKey = "balance-reads"
+
 var (
 	ErrInvalidUserID = errors.New("invalid user ID")
 	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrBalanceLoading reports a cache miss for a balance that another
+	// caller is loading
+	ErrBalanceLoading = errors.New("balance is being loaded")
 )
 
 type CacheRepositoryImpl struct {
 	client redis.Cmdable
 	ttl    time.Duration
 	logger *logrus.Logger
+
+	// ttlScale holds the float64 bits of the factor applied to every TTL
+	ttlScale atomic.Uint64
 }
 
 func NewCacheRepository(client redis.Cmdable, ttl time.Duration, logger *logrus.Logger) *CacheRepositoryImpl {
-	return &CacheRepositoryImpl{
+	r := &CacheRepositoryImpl{
 		client: client,
 		ttl:    ttl,
 		logger: logger,
 	}
+	r.ttlScale.Store(math.Float64bits(1))
+	return r
 }
 
-func (r *CacheRepositoryImpl) GetBalance(ctx context.Context, userID string) (float64, error) {
+// SetTTLScale multiplies the TTL of the balances cached from now on by scale,
+// so balances leave the cache sooner while Redis is short of memory. A scale
+// of 1 restores the configured TTLs.
+func (r *CacheRepositoryImpl) SetTTLScale(scale float64) {
+	r.ttlScale.Store(math.Float64bits(scale))
+}
+
+// cacheTTL returns the TTL to cache a balance with: ttl, or the repository
+// default when zero, scaled by the current TTL scale
+func (r *CacheRepositoryImpl) cacheTTL(ttl time.Duration) time.Duration {
+	if ttl == 0 {
+		ttl = r.ttl
+	}
+	return time.Duration(float64(ttl) * math.Float64frombits(r.ttlScale.Load()))
+}
+
+func (r *CacheRepositoryImpl) GetBalance(ctx context.Context, userID string) (_ decimal.Decimal, err error) {
+	ctx, span := startSpan(ctx, "GetBalance", userID)
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		r.logger.Warn("GetBalance - userID cannot be an empty string")
-		return 0, ErrInvalidUserID
+		r.logger.WithContext(ctx).Warn("GetBalance - userID cannot be an empty string")
+		return decimal.Zero, ErrInvalidUserID
 	}
 
-	logger := r.logger.WithFields(logrus.Fields{
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"userID": userID,
 	})
 
@@ -50,36 +155,39 @@ func (r *CacheRepositoryImpl) GetBalance(ctx context.Context, userID string) (fl
 
 	if errors.Is(err, redis.Nil) {
 		logger.Warn(fmt.Printf("GetBalance - cache miss: key = %v", balanceKey(userID)))
-		return 0, redis.Nil
+		return decimal.Zero, redis.Nil
 	}
 
 	if err != nil {
 		logger.WithError(err).Error(fmt.Printf("GetBalance - get cache error: key = %v", balanceKey(userID)))
-		return 0, err
+		return decimal.Zero, err
 	}
 
-	var balance float64
+	var balance decimal.Decimal
 	err = json.Unmarshal([]byte(val), &balance)
 	if err != nil {
 		logger.WithError(err).Error(fmt.Printf("GetBalance - unmarshal error: key = %v, balance = %v", balanceKey(userID), balance))
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	return balance, nil
 }
 
-func (r *CacheRepositoryImpl) SetBalance(ctx context.Context, userID string, balance float64) error {
+func (r *CacheRepositoryImpl) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "SetBalance", userID)
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		r.logger.Warn("SetBalance - userID cannot be an empty string")
+		r.logger.WithContext(ctx).Warn("SetBalance - userID cannot be an empty string")
 		return ErrInvalidUserID
 	}
 
-	if balance <= 0 {
-		r.logger.Warn("SetBalance - balance must be greater than zero")
+	if !balance.IsPositive() {
+		r.logger.WithContext(ctx).Warn("SetBalance - balance must be greater than zero")
 		return ErrInvalidAmount
 	}
 
-	logger := r.logger.WithFields(logrus.Fields{
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"userID": userID,
 		"amount": balance,
 	})
@@ -90,30 +198,173 @@ func (r *CacheRepositoryImpl) SetBalance(ctx context.Context, userID string, bal
 		return err
 	}
 
-	err = r.client.Set(ctx, balanceKey(userID), serialized, r.ttl).Err()
+	err = r.client.Set(ctx, balanceKey(userID), serialized, r.cacheTTL(ttl)).Err()
 	if err != nil {
 		logger.WithError(err).Error(fmt.Printf("SetBalance - set cache error: key = %v", balanceKey(userID)))
 		return err
 	}
 
+	if err := r.client.ZAdd(ctx, activityKey, redis.Z{Score: float64(time.Now().UnixMilli()), Member: userID}).Err(); err != nil {
+		logger.WithError(err).Warn("SetBalance - record activity error")
+	}
+
+	// Release the loading marker so waiting callers stop polling early
+	if err := r.client.Del(ctx, loadingKey(userID)).Err(); err != nil {
+		logger.WithError(err).WithField("key", loadingKey(userID)).Warn("SetBalance - delete loading marker error")
+	}
+
+	return nil
+}
+
+// SetVersionedBalance caches balance with its version in one round trip. A
+// zero balance is cached too: skipping it would leave the previous balance
+// in place.
+func (r *CacheRepositoryImpl) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "SetVersionedBalance", userID)
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("SetVersionedBalance - userID cannot be an empty string")
+		return false, ErrInvalidUserID
+	}
+
+	if balance.IsNegative() {
+		r.logger.WithContext(ctx).Warn("SetVersionedBalance - balance cannot be negative")
+		return false, ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":  userID,
+		"version": version,
+	})
+
+	serialized, err := json.Marshal(balance)
+	if err != nil {
+		logger.WithError(err).Error("SetVersionedBalance - marshal error")
+		return false, err
+	}
+
+	ttl = r.cacheTTL(ttl)
+	keys := []string{balanceKey(userID), versionKey(userID), loadingKey(userID), activityKey}
+	stored, err := setVersionedScript.Run(ctx, r.client, keys, serialized, version, ttl.Milliseconds(), versionTTLFactor*ttl.Milliseconds(), time.Now().UnixMilli(), userID).Int64()
+	if err != nil {
+		logger.WithError(err).WithField("key", balanceKey(userID)).Error("SetVersionedBalance - script error")
+		return false, err
+	}
+
+	return stored == 1, nil
+}
+
+func (r *CacheRepositoryImpl) InvalidateBalance(ctx context.Context, userID string) (err error) {
+	ctx, span := startSpan(ctx, "InvalidateBalance", userID)
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("InvalidateBalance - userID cannot be an empty string")
+		return ErrInvalidUserID
+	}
+
+	err = r.client.Del(ctx, balanceKey(userID)).Err()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error(fmt.Printf("InvalidateBalance - delete cache error: key = %v", balanceKey(userID)))
+		return err
+	}
+
 	return nil
 }
 
-func (r *CacheRepositoryImpl) InvalidateBalance(ctx context.Context, userID string) error {
+// ShortenBalanceTTL makes the cached balance of userID expire within ttl, for
+// a balance that could not be invalidated
+func (r *CacheRepositoryImpl) ShortenBalanceTTL(ctx context.Context, userID string, ttl time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "ShortenBalanceTTL", userID)
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		r.logger.Warn("InvalidateBalance - userID cannot be an empty string")
+		r.logger.WithContext(ctx).Warn("ShortenBalanceTTL - userID cannot be an empty string")
 		return ErrInvalidUserID
 	}
 
-	err := r.client.Del(ctx, balanceKey(userID)).Err()
+	err = shortenTTLScript.Run(ctx, r.client, []string{balanceKey(userID)}, ttl.Milliseconds()).Err()
 	if err != nil {
-		r.logger.WithError(err).Error(fmt.Printf("InvalidateBalance - delete cache error: key = %v", balanceKey(userID)))
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("ShortenBalanceTTL - script error")
 		return err
 	}
 
 	return nil
 }
 
+// ReadThrough looks up the balance and, on a miss, sets a short-lived
+// loading marker in one round trip, so only one caller across instances
+// loads a missing balance from the database
+func (r *CacheRepositoryImpl) ReadThrough(ctx context.Context, userID string) (_ decimal.Decimal, err error) {
+	ctx, span := startSpan(ctx, "ReadThrough", userID)
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("ReadThrough - userID cannot be an empty string")
+		return decimal.Zero, ErrInvalidUserID
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+	})
+
+	keys := []string{balanceKey(userID), loadingKey(userID), activityKey, readsKey}
+	result, err := readThroughScript.Run(ctx, r.client, keys, loadingMarkerTTL.Milliseconds(), time.Now().UnixMilli(), userID).Slice()
+	if err != nil {
+		logger.WithError(err).WithField("key", balanceKey(userID)).Error("ReadThrough - script error")
+		return decimal.Zero, err
+	}
+
+	status, _ := result[0].(int64)
+	switch status {
+	case 0:
+		logger.WithField("key", balanceKey(userID)).Warn("ReadThrough - cache miss")
+		return decimal.Zero, redis.Nil
+	case 2:
+		return decimal.Zero, ErrBalanceLoading
+	}
+
+	val, _ := result[1].(string)
+	var balance decimal.Decimal
+	if err := json.Unmarshal([]byte(val), &balance); err != nil {
+		logger.WithError(err).WithField("key", balanceKey(userID)).Error("ReadThrough - unmarshal error")
+		return decimal.Zero, err
+	}
+
+	return balance, nil
+}
+
+// startSpan starts a client span for a cache operation, ended by endSpan
+func startSpan(ctx context.Context, operation, userID string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "redis."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperationName(operation),
+			attribute.String("wallet.user_id", userID),
+		),
+	)
+}
+
+// endSpan ends span, recording cache misses as an attribute rather than an
+// error
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, redis.Nil) || errors.Is(err, ErrBalanceLoading) {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
 func balanceKey(userID string) string {
 	return "balance:" + userID
 }
+
+func loadingKey(userID string) string {
+	return "balance:loading:" + userID
+}
+
+func versionKey(userID string) string {
+	return "balance:version:" + userID
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
 	mockredis "Crypto.com/mocks"
@@ -29,8 +30,8 @@ func TestCacheRepository(t *testing.T) {
 		if !errors.Is(err, redis.Nil) {
 			t.Errorf("Expected redis.Nil error, got %v", err)
 		}
-		if balance != 0 {
-			t.Errorf("Expected 0 balance, got %f", balance)
+		if !balance.IsZero() {
+			t.Errorf("Expected 0 balance, got %s", balance)
 		}
 	})
 
@@ -45,7 +46,7 @@ func TestCacheRepository(t *testing.T) {
 	})
 
 	t.Run("GetBalance valid value", func(t *testing.T) {
-		expected := 99.99
+		expected := decimal.NewFromFloat(99.99)
 		serialized, _ := json.Marshal(expected)
 		mockClient.EXPECT().Get(gomock.Any(), "balance:user1").Return(redis.NewStringResult(string(serialized), nil))
 
@@ -53,8 +54,8 @@ func TestCacheRepository(t *testing.T) {
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
-		if balance != expected {
-			t.Errorf("Expected %f, got %f", expected, balance)
+		if !balance.Equal(expected) {
+			t.Errorf("Expected %s, got %s", expected, balance)
 		}
 	})
 
@@ -63,30 +64,44 @@ func TestCacheRepository(t *testing.T) {
 		if !errors.Is(err, ErrInvalidUserID) {
 			t.Errorf("Expected ErrInvalidUserID error, got %v", err)
 		}
-		if balance != 0 {
-			t.Errorf("Expected 0 balance, got %f", balance)
+		if !balance.IsZero() {
+			t.Errorf("Expected 0 balance, got %s", balance)
 		}
 	})
 
 	t.Run("SetBalance success", func(t *testing.T) {
-		val, _ := json.Marshal(50.0)
+		val, _ := json.Marshal(decimal.NewFromInt(50))
 		mockClient.EXPECT().Set(gomock.Any(), "balance:user2", val, 30*time.Minute).Return(redis.NewStatusResult("OK", nil))
+		mockClient.EXPECT().ZAdd(gomock.Any(), "balance-activity", gomock.Any()).Return(redis.NewIntResult(1, nil))
+		mockClient.EXPECT().Del(gomock.Any(), "balance:loading:user2").Return(redis.NewIntResult(1, nil))
 
-		err := repo.SetBalance(context.Background(), "user2", 50.0)
+		err := repo.SetBalance(context.Background(), "user2", decimal.NewFromInt(50), 0)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("SetBalance with ttl", func(t *testing.T) {
+		val, _ := json.Marshal(decimal.NewFromInt(50))
+		mockClient.EXPECT().Set(gomock.Any(), "balance:user2", val, time.Minute).Return(redis.NewStatusResult("OK", nil))
+		mockClient.EXPECT().ZAdd(gomock.Any(), "balance-activity", gomock.Any()).Return(redis.NewIntResult(1, nil))
+		mockClient.EXPECT().Del(gomock.Any(), "balance:loading:user2").Return(redis.NewIntResult(1, nil))
+
+		err := repo.SetBalance(context.Background(), "user2", decimal.NewFromInt(50), time.Minute)
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 	})
 
 	t.Run("SetBalance invalid userID", func(t *testing.T) {
-		err := repo.SetBalance(context.Background(), "", 100.0)
+		err := repo.SetBalance(context.Background(), "", decimal.NewFromInt(100), 0)
 		if !errors.Is(err, ErrInvalidUserID) {
 			t.Errorf("Expected ErrInvalidUserID error, got %v", err)
 		}
 	})
 
 	t.Run("SetBalance invalid amount", func(t *testing.T) {
-		err := repo.SetBalance(context.Background(), "user1", -100.0)
+		err := repo.SetBalance(context.Background(), "user1", decimal.NewFromInt(-100), 0)
 		if !errors.Is(err, ErrInvalidAmount) {
 			t.Errorf("Expected ErrInvalidAmount error, got %v", err)
 		}
@@ -107,4 +122,104 @@ func TestCacheRepository(t *testing.T) {
 			t.Errorf("Unexpected error: %v", err)
 		}
 	})
+
+	t.Run("ReadThrough hit", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"balance:user4", "balance:loading:user4", "balance-activity", "balance-reads"}, int64(1000), gomock.Any(), "user4").
+			Return(redis.NewCmdResult([]interface{}{int64(1), "\"75\""}, nil))
+
+		balance, err := repo.ReadThrough(context.Background(), "user4")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if !balance.Equal(decimal.NewFromInt(75)) {
+			t.Errorf("Expected balance 75, got %s", balance)
+		}
+	})
+
+	t.Run("ReadThrough miss sets the loading marker", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult([]interface{}{int64(0)}, nil))
+
+		_, err := repo.ReadThrough(context.Background(), "user4")
+		if !errors.Is(err, redis.Nil) {
+			t.Errorf("Expected redis.Nil error, got %v", err)
+		}
+	})
+
+	t.Run("ReadThrough miss while another caller loads", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult([]interface{}{int64(2)}, nil))
+
+		_, err := repo.ReadThrough(context.Background(), "user4")
+		if !errors.Is(err, ErrBalanceLoading) {
+			t.Errorf("Expected ErrBalanceLoading error, got %v", err)
+		}
+	})
+
+	t.Run("SetVersionedBalance stored", func(t *testing.T) {
+		keys := []string{"balance:user5", "balance:version:user5", "balance:loading:user5", "balance-activity"}
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), keys, []byte(`"0"`), int64(3), int64(60000), int64(120000), gomock.Any(), "user5").
+			Return(redis.NewCmdResult(int64(1), nil))
+
+		stored, err := repo.SetVersionedBalance(context.Background(), "user5", decimal.Zero, 3, time.Minute)
+		if err != nil || !stored {
+			t.Errorf("Expected zero balance to be stored, got %v (%v)", stored, err)
+		}
+	})
+
+	t.Run("SetVersionedBalance newer version cached", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(int64(0), nil))
+
+		stored, err := repo.SetVersionedBalance(context.Background(), "user5", decimal.NewFromInt(10), 2, 0)
+		if err != nil || stored {
+			t.Errorf("Expected older version to be skipped, got %v (%v)", stored, err)
+		}
+	})
+
+	t.Run("SetVersionedBalance invalid amount", func(t *testing.T) {
+		_, err := repo.SetVersionedBalance(context.Background(), "user5", decimal.NewFromInt(-1), 2, 0)
+		if !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Expected ErrInvalidAmount error, got %v", err)
+		}
+	})
+
+	t.Run("ReadThrough redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(nil, mockErr))
+
+		_, err := repo.ReadThrough(context.Background(), "user4")
+		if !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+
+	t.Run("SetVersionedBalance with a reduced TTL", func(t *testing.T) {
+		repo.SetTTLScale(0.25)
+		defer repo.SetTTLScale(1)
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), int64(450000), int64(900000), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(int64(1), nil))
+
+		stored, err := repo.SetVersionedBalance(context.Background(), "user5", decimal.NewFromInt(10), 4, 0)
+		if err != nil || !stored {
+			t.Errorf("Expected balance to be stored, got %v (%v)", stored, err)
+		}
+	})
+
+	t.Run("ShortenBalanceTTL", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"balance:user6"}, int64(5000)).
+			Return(redis.NewCmdResult(int64(1), nil))
+
+		if err := repo.ShortenBalanceTTL(context.Background(), "user6", 5*time.Second); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ShortenBalanceTTL invalid userID", func(t *testing.T) {
+		err := repo.ShortenBalanceTTL(context.Background(), "", 5*time.Second)
+		if !errors.Is(err, ErrInvalidUserID) {
+			t.Errorf("Expected ErrInvalidUserID error, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// CacheWarmRepository finds the most read wallets whose cached balance is
+// about to expire, so they can be refreshed before readers miss the cache
+type CacheWarmRepository interface {
+	// ExpiringHotBalances returns up to limit wallets read at least minReads
+	// times, most read first, whose balance is not cached or expires within
+	// the given duration
+	ExpiringHotBalances(ctx context.Context, minReads float64, limit int, within time.Duration) ([]string, error)
+	// DecayReads multiplies the read counts by factor and stops tracking the
+	// wallets whose count falls below one read
+	DecayReads(ctx context.Context, factor float64) error
+}
+
+// expiringHotScript returns the wallets among the ARGV[2] most read ones in
+// KEYS[1], read at least ARGV[1] times, whose balance is missing or expires
+// within ARGV[3] milliseconds. Balances cached without a TTL are skipped.
+// Balance keys are derived from the tracked user IDs, which assumes a single
+// Redis node like the other balance scripts.
+var expiringHotScript = redis.NewScript(`
+local users = redis.call('ZREVRANGEBYSCORE', KEYS[1], '+inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local due = {}
+for _, user in ipairs(users) do
+	local ttl = redis.call('PTTL', ARGV[4] .. user)
+	if ttl == -2 or (ttl >= 0 and ttl < tonumber(ARGV[3])) then
+		table.insert(due, user)
+	end
+end
+return due
+`)
+
+// decayReadsScript scales the read counts in KEYS[1] by ARGV[1] and removes
+// the counts below one
+var decayReadsScript = redis.NewScript(`
+redis.call('ZUNIONSTORE', KEYS[1], 1, KEYS[1], 'WEIGHTS', ARGV[1])
+return redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(1')
+`)
+
+type CacheWarmRepositoryImpl struct {
+	client redis.Cmdable
+	logger *logrus.Logger
+}
+
+func NewCacheWarmRepository(client redis.Cmdable, logger *logrus.Logger) *CacheWarmRepositoryImpl {
+	return &CacheWarmRepositoryImpl{client: client, logger: logger}
+}
+
+func (r *CacheWarmRepositoryImpl) ExpiringHotBalances(ctx context.Context, minReads float64, limit int, within time.Duration) ([]string, error) {
+	users, err := expiringHotScript.Run(ctx, r.client, []string{readsKey}, minReads, limit, within.Milliseconds(), balanceKey("")).StringSlice()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ExpiringHotBalances - Find hot balances failed")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *CacheWarmRepositoryImpl) DecayReads(ctx context.Context, factor float64) error {
+	if err := decayReadsScript.Run(ctx, r.client, []string{readsKey}, factor).Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("factor", factor).Error("DecayReads - Decay read counts failed")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	mockredis "Crypto.com/mocks"
+)
+
+func TestCacheWarmRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	repo := NewCacheWarmRepository(mockClient, logrus.New())
+
+	t.Run("ExpiringHotBalances", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"balance-reads"}, 10.0, 100, int64(60000), "balance:").
+			Return(redis.NewCmdResult([]interface{}{"user1", "user2"}, nil))
+
+		users, err := repo.ExpiringHotBalances(context.Background(), 10, 100, time.Minute)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(users, []string{"user1", "user2"}) {
+			t.Errorf("Unexpected users %v", users)
+		}
+	})
+
+	t.Run("ExpiringHotBalances redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(nil, mockErr))
+
+		if _, err := repo.ExpiringHotBalances(context.Background(), 10, 100, time.Minute); !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+
+	t.Run("DecayReads", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"balance-reads"}, 0.5).
+			Return(redis.NewCmdResult(int64(3), nil))
+
+		if err := repo.DecayReads(context.Background(), 0.5); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
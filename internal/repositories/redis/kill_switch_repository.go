@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+)
+
+// killSwitchKey is the hash holding the engaged kill switches by operation
+const killSwitchKey = "killswitch"
+
+// KillSwitchRepository stores the engaged kill switches. An operation without
+// a switch is enabled.
+type KillSwitchRepository interface {
+	// GetKillSwitch returns the switch of op, or nil when op is enabled
+	GetKillSwitch(ctx context.Context, op string) (*models.KillSwitch, error)
+	ListKillSwitches(ctx context.Context) ([]models.KillSwitch, error)
+	SetKillSwitch(ctx context.Context, killSwitch models.KillSwitch) error
+	ClearKillSwitch(ctx context.Context, op string) error
+}
+
+// RedisKillSwitchRepository keeps the switches in a Redis hash, so a switch
+// takes effect on every instance with its next check
+type RedisKillSwitchRepository struct {
+	client redis.Cmdable
+	logger *logrus.Logger
+}
+
+func NewKillSwitchRepository(client redis.Cmdable, logger *logrus.Logger) *RedisKillSwitchRepository {
+	return &RedisKillSwitchRepository{client: client, logger: logger}
+}
+
+func (r *RedisKillSwitchRepository) GetKillSwitch(ctx context.Context, op string) (*models.KillSwitch, error) {
+	value, err := r.client.HGet(ctx, killSwitchKey, op).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("operation", op).Error("GetKillSwitch - Read failed")
+		return nil, err
+	}
+
+	var killSwitch models.KillSwitch
+	if err := json.Unmarshal([]byte(value), &killSwitch); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("operation", op).Error("GetKillSwitch - Decode failed")
+		return nil, err
+	}
+	return &killSwitch, nil
+}
+
+func (r *RedisKillSwitchRepository) ListKillSwitches(ctx context.Context) ([]models.KillSwitch, error) {
+	values, err := r.client.HGetAll(ctx, killSwitchKey).Result()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("ListKillSwitches - Read failed")
+		return nil, err
+	}
+
+	killSwitches := make([]models.KillSwitch, 0, len(values))
+	for op, value := range values {
+		var killSwitch models.KillSwitch
+		if err := json.Unmarshal([]byte(value), &killSwitch); err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("operation", op).Error("ListKillSwitches - Decode failed")
+			return nil, err
+		}
+		killSwitches = append(killSwitches, killSwitch)
+	}
+	sortKillSwitches(killSwitches)
+	return killSwitches, nil
+}
+
+func (r *RedisKillSwitchRepository) SetKillSwitch(ctx context.Context, killSwitch models.KillSwitch) error {
+	value, err := json.Marshal(killSwitch)
+	if err != nil {
+		return err
+	}
+	if err := r.client.HSet(ctx, killSwitchKey, killSwitch.Operation, value).Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("operation", killSwitch.Operation).Error("SetKillSwitch - Write failed")
+		return err
+	}
+	return nil
+}
+
+func (r *RedisKillSwitchRepository) ClearKillSwitch(ctx context.Context, op string) error {
+	if err := r.client.HDel(ctx, killSwitchKey, op).Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("operation", op).Error("ClearKillSwitch - Delete failed")
+		return err
+	}
+	return nil
+}
+
+// LocalKillSwitchRepository is used when Redis is disabled or unreachable.
+// Switches only apply to the instance they were set on and are lost when it
+// restarts.
+type LocalKillSwitchRepository struct {
+	mu       sync.RWMutex
+	switches map[string]models.KillSwitch
+}
+
+func NewLocalKillSwitchRepository() *LocalKillSwitchRepository {
+	return &LocalKillSwitchRepository{switches: make(map[string]models.KillSwitch)}
+}
+
+func (r *LocalKillSwitchRepository) GetKillSwitch(ctx context.Context, op string) (*models.KillSwitch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	killSwitch, ok := r.switches[op]
+	if !ok {
+		return nil, nil
+	}
+	return &killSwitch, nil
+}
+
+func (r *LocalKillSwitchRepository) ListKillSwitches(ctx context.Context) ([]models.KillSwitch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	killSwitches := make([]models.KillSwitch, 0, len(r.switches))
+	for _, killSwitch := range r.switches {
+		killSwitches = append(killSwitches, killSwitch)
+	}
+	sortKillSwitches(killSwitches)
+	return killSwitches, nil
+}
+
+func (r *LocalKillSwitchRepository) SetKillSwitch(ctx context.Context, killSwitch models.KillSwitch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.switches[killSwitch.Operation] = killSwitch
+	return nil
+}
+
+func (r *LocalKillSwitchRepository) ClearKillSwitch(ctx context.Context, op string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.switches, op)
+	return nil
+}
+
+func sortKillSwitches(killSwitches []models.KillSwitch) {
+	sort.Slice(killSwitches, func(i, j int) bool {
+		return killSwitches[i].Operation < killSwitches[j].Operation
+	})
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	mockredis "Crypto.com/mocks"
+)
+
+func TestKillSwitchRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	repo := NewKillSwitchRepository(mockClient, logrus.New())
+	ctx := context.Background()
+
+	disabledAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stored := `{"operation":"payouts","message":"provider outage","disabled_by":"ops1","disabled_at":"2024-05-01T12:00:00Z"}`
+
+	t.Run("GetKillSwitch engaged", func(t *testing.T) {
+		mockClient.EXPECT().HGet(ctx, "killswitch", "payouts").Return(redis.NewStringResult(stored, nil))
+
+		killSwitch, err := repo.GetKillSwitch(ctx, "payouts")
+		require.NoError(t, err)
+		assert.Equal(t, &models.KillSwitch{
+			Operation:  "payouts",
+			Message:    "provider outage",
+			DisabledBy: "ops1",
+			DisabledAt: disabledAt,
+		}, killSwitch)
+	})
+
+	t.Run("GetKillSwitch not engaged", func(t *testing.T) {
+		mockClient.EXPECT().HGet(ctx, "killswitch", "deposits").Return(redis.NewStringResult("", redis.Nil))
+
+		killSwitch, err := repo.GetKillSwitch(ctx, "deposits")
+		require.NoError(t, err)
+		assert.Nil(t, killSwitch)
+	})
+
+	t.Run("GetKillSwitch redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().HGet(ctx, "killswitch", "deposits").Return(redis.NewStringResult("", mockErr))
+
+		_, err := repo.GetKillSwitch(ctx, "deposits")
+		assert.ErrorIs(t, err, mockErr)
+	})
+
+	t.Run("ListKillSwitches sorted by operation", func(t *testing.T) {
+		mockClient.EXPECT().HGetAll(ctx, "killswitch").Return(redis.NewMapStringStringResult(map[string]string{
+			"payouts":  stored,
+			"deposits": `{"operation":"deposits","message":"","disabled_by":"ops2","disabled_at":"2024-05-01T12:00:00Z"}`,
+		}, nil))
+
+		killSwitches, err := repo.ListKillSwitches(ctx)
+		require.NoError(t, err)
+		require.Len(t, killSwitches, 2)
+		assert.Equal(t, "deposits", killSwitches[0].Operation)
+		assert.Equal(t, "payouts", killSwitches[1].Operation)
+	})
+
+	t.Run("SetKillSwitch", func(t *testing.T) {
+		mockClient.EXPECT().HSet(ctx, "killswitch", "payouts", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, key string, values ...interface{}) *redis.IntCmd {
+				assert.JSONEq(t, stored, string(values[1].([]byte)))
+				return redis.NewIntResult(1, nil)
+			})
+
+		err := repo.SetKillSwitch(ctx, models.KillSwitch{
+			Operation:  "payouts",
+			Message:    "provider outage",
+			DisabledBy: "ops1",
+			DisabledAt: disabledAt,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ClearKillSwitch", func(t *testing.T) {
+		mockClient.EXPECT().HDel(ctx, "killswitch", "payouts").Return(redis.NewIntResult(1, nil))
+
+		assert.NoError(t, repo.ClearKillSwitch(ctx, "payouts"))
+	})
+}
+
+func TestLocalKillSwitchRepository(t *testing.T) {
+	repo := NewLocalKillSwitchRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetKillSwitch(ctx, models.KillSwitch{Operation: "transfers"}))
+	require.NoError(t, repo.SetKillSwitch(ctx, models.KillSwitch{Operation: "deposits"}))
+
+	killSwitch, err := repo.GetKillSwitch(ctx, "transfers")
+	require.NoError(t, err)
+	assert.Equal(t, "transfers", killSwitch.Operation)
+
+	killSwitches, err := repo.ListKillSwitches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.KillSwitch{{Operation: "deposits"}, {Operation: "transfers"}}, killSwitches)
+
+	require.NoError(t, repo.ClearKillSwitch(ctx, "transfers"))
+	killSwitch, err = repo.GetKillSwitch(ctx, "transfers")
+	require.NoError(t, err)
+	assert.Nil(t, killSwitch)
+}
@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// LeaderLock elects a single instance to run a background job
+type LeaderLock interface {
+	// Acquire takes the lock or extends it when this instance holds it, and
+	// reports whether this instance is the leader
+	Acquire(ctx context.Context) (bool, error)
+	// Release gives up the lock if this instance holds it
+	Release(ctx context.Context) error
+}
+
+// acquireLockScript extends the lock in KEYS[1] when it holds the token in
+// ARGV[1], takes it when it is free, and returns 1 when the caller holds it
+var acquireLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLockScript deletes the lock in KEYS[1] only while it holds the token
+// in ARGV[1], so an instance never releases a lock another one took over
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLeaderLock is a LeaderLock held in a Redis key with a TTL. The leader
+// must call Acquire again before the TTL expires to keep the lock; a leader
+// that stops is replaced once its lock expires.
+type RedisLeaderLock struct {
+	client redis.Cmdable
+	key    string
+	token  string
+	ttl    time.Duration
+	logger *logrus.Logger
+
+	leader bool
+}
+
+func NewLeaderLock(client redis.Cmdable, key string, ttl time.Duration, logger *logrus.Logger) *RedisLeaderLock {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	return &RedisLeaderLock{
+		client: client,
+		key:    key,
+		token:  hex.EncodeToString(token),
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+func (l *RedisLeaderLock) Acquire(ctx context.Context) (bool, error) {
+	held, err := acquireLockScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		l.logger.WithContext(ctx).WithError(err).WithField("key", l.key).Warn("Acquire - Take leader lock failed")
+		return false, err
+	}
+
+	leader := held == 1
+	if leader != l.leader {
+		l.logger.WithContext(ctx).WithFields(logrus.Fields{"key": l.key, "leader": leader}).Info("Leadership changed")
+		l.leader = leader
+	}
+	return leader, nil
+}
+
+func (l *RedisLeaderLock) Release(ctx context.Context) error {
+	l.leader = false
+	if err := releaseLockScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		l.logger.WithContext(ctx).WithError(err).WithField("key", l.key).Warn("Release - Release leader lock failed")
+		return err
+	}
+	return nil
+}
+
+// LocalLeaderLock is used without Redis: every instance considers itself the
+// leader, which is only safe for jobs that also coordinate in the database
+type LocalLeaderLock struct{}
+
+func NewLocalLeaderLock() *LocalLeaderLock {
+	return &LocalLeaderLock{}
+}
+
+func (l *LocalLeaderLock) Acquire(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+func (l *LocalLeaderLock) Release(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	mockredis "Crypto.com/mocks"
+)
+
+func TestLeaderLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	lock := NewLeaderLock(mockClient, "scheduler:leader", 30*time.Second, logrus.New())
+
+	t.Run("Acquire takes the lock", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"scheduler:leader"}, lock.token, int64(30000)).
+			Return(redis.NewCmdResult(int64(1), nil))
+
+		leader, err := lock.Acquire(context.Background())
+		if err != nil || !leader {
+			t.Errorf("Expected to lead, got %v, %v", leader, err)
+		}
+	})
+
+	t.Run("Acquire while another instance leads", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(int64(0), nil))
+
+		leader, err := lock.Acquire(context.Background())
+		if err != nil || leader {
+			t.Errorf("Expected not to lead, got %v, %v", leader, err)
+		}
+	})
+
+	t.Run("Acquire redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(nil, mockErr))
+
+		leader, err := lock.Acquire(context.Background())
+		if !errors.Is(err, mockErr) || leader {
+			t.Errorf("Expected %v, got %v, %v", mockErr, leader, err)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"scheduler:leader"}, lock.token).
+			Return(redis.NewCmdResult(int64(1), nil))
+
+		if err := lock.Release(context.Background()); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
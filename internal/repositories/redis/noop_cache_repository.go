@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+// NoopCacheRepository is used when Redis is disabled or unreachable. Every
+// read is a cache miss, so callers fall through to the database.
+type NoopCacheRepository struct{}
+
+func NewNoopCacheRepository() *NoopCacheRepository {
+	return &NoopCacheRepository{}
+}
+
+func (r *NoopCacheRepository) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	return decimal.Zero, redis.Nil
+}
+
+func (r *NoopCacheRepository) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error {
+	return nil
+}
+
+func (r *NoopCacheRepository) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (r *NoopCacheRepository) InvalidateBalance(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (r *NoopCacheRepository) ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error) {
+	return decimal.Zero, redis.Nil
+}
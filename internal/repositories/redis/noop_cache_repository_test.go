@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+func TestNoopCacheRepository(t *testing.T) {
+	ctx := context.Background()
+	var repo CacheRepository = NewNoopCacheRepository()
+
+	if err := repo.SetBalance(ctx, "user1", decimal.NewFromInt(100), 0); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if _, err := repo.GetBalance(ctx, "user1"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil error, got %v", err)
+	}
+
+	if _, err := repo.ReadThrough(ctx, "user1"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil error, got %v", err)
+	}
+
+	if err := repo.InvalidateBalance(ctx, "user1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
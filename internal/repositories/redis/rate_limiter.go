@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimit is the outcome of counting a use against a rate limit
+type RateLimit struct {
+	Allowed bool
+	// Remaining uses in the current window
+	Remaining int
+	// ResetIn is the time until the current window ends
+	ResetIn time.Duration
+}
+
+// RateLimiter counts the uses of keys in fixed windows
+type RateLimiter interface {
+	// Allow counts a use of key and reports whether it is within limit uses
+	// in the current window of the given length
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimit, error)
+}
+
+// rateLimitScript counts a use in KEYS[1], starting a window of ARGV[1]
+// milliseconds with the first one, and returns the count and the time left
+// in the window
+var rateLimitScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+// RedisRateLimiter keeps the counts in Redis keys expiring with their
+// window, so a limit holds across instances
+type RedisRateLimiter struct {
+	client redis.Cmdable
+	logger *logrus.Logger
+}
+
+func NewRateLimiter(client redis.Cmdable, logger *logrus.Logger) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client, logger: logger}
+}
+
+func (r *RedisRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimit, error) {
+	values, err := rateLimitScript.Run(ctx, r.client, []string{"ratelimit:" + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("key", key).Error("Allow - Count use failed")
+		return RateLimit{}, err
+	}
+	return rateLimit(int(values[0]), limit, time.Duration(values[1])*time.Millisecond), nil
+}
+
+// LocalRateLimiter is used when Redis is disabled or unreachable. Limits
+// apply per instance.
+type LocalRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]localWindow
+}
+
+type localWindow struct {
+	count  int
+	endsAt time.Time
+}
+
+func NewLocalRateLimiter() *LocalRateLimiter {
+	return &LocalRateLimiter{windows: make(map[string]localWindow)}
+}
+
+func (r *LocalRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	current, ok := r.windows[key]
+	if !ok || !now.Before(current.endsAt) {
+		r.evictExpired(now)
+		current = localWindow{endsAt: now.Add(window)}
+	}
+	current.count++
+	r.windows[key] = current
+	return rateLimit(current.count, limit, current.endsAt.Sub(now)), nil
+}
+
+// evictExpired drops the windows that ended, so keys used once do not
+// accumulate
+func (r *LocalRateLimiter) evictExpired(now time.Time) {
+	for key, window := range r.windows {
+		if !now.Before(window.endsAt) {
+			delete(r.windows, key)
+		}
+	}
+}
+
+func rateLimit(count, limit int, resetIn time.Duration) RateLimit {
+	return RateLimit{
+		Allowed:   count <= limit,
+		Remaining: max(limit-count, 0),
+		ResetIn:   resetIn,
+	}
+}
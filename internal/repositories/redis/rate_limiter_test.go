@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	mockredis "Crypto.com/mocks"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	limiter := NewRateLimiter(mockClient, logrus.New())
+
+	t.Run("Allow within the limit", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"ratelimit:faucet:key:1"}, int64(3600000)).
+			Return(redis.NewCmdResult([]interface{}{int64(2), int64(1800000)}, nil))
+
+		limit, err := limiter.Allow(context.Background(), "faucet:key:1", 5, time.Hour)
+		if err != nil || !limit.Allowed || limit.Remaining != 3 || limit.ResetIn != 30*time.Minute {
+			t.Errorf("Expected 3 remaining for 30m, got %+v, %v", limit, err)
+		}
+	})
+
+	t.Run("Allow over the limit", func(t *testing.T) {
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult([]interface{}{int64(6), int64(60000)}, nil))
+
+		limit, err := limiter.Allow(context.Background(), "faucet:key:1", 5, time.Hour)
+		if err != nil || limit.Allowed || limit.Remaining != 0 {
+			t.Errorf("Expected to be limited, got %+v, %v", limit, err)
+		}
+	})
+
+	t.Run("Allow redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(redis.NewCmdResult(nil, mockErr))
+
+		if _, err := limiter.Allow(context.Background(), "faucet:key:1", 5, time.Hour); !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+}
+
+func TestLocalRateLimiter(t *testing.T) {
+	limiter := NewLocalRateLimiter()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if limit, _ := limiter.Allow(ctx, "a", 2, time.Hour); !limit.Allowed {
+			t.Fatalf("Use %d should be allowed", i+1)
+		}
+	}
+	if limit, _ := limiter.Allow(ctx, "a", 2, time.Hour); limit.Allowed {
+		t.Error("Third use should be limited")
+	}
+	if limit, _ := limiter.Allow(ctx, "b", 2, time.Hour); !limit.Allowed {
+		t.Error("Another key should have its own window")
+	}
+
+	if limit, _ := limiter.Allow(ctx, "c", 1, time.Millisecond); !limit.Allowed {
+		t.Fatal("First use should be allowed")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if limit, _ := limiter.Allow(ctx, "c", 1, time.Millisecond); !limit.Allowed {
+		t.Error("A new window should start once the last one ended")
+	}
+}
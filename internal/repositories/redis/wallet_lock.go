@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// walletLockPoll is how often a contended wallet lock is retried
+const walletLockPoll = 10 * time.Millisecond
+
+var ErrLockNotAcquired = errors.New("wallet lock not acquired in time")
+
+// WalletLock serializes operations on the same wallets across instances
+type WalletLock interface {
+	// Lock takes the locks of userIDs, waiting while other operations hold
+	// them, and returns a function releasing them
+	Lock(ctx context.Context, userIDs ...string) (func(), error)
+}
+
+// RedisWalletLock holds one Redis key with a TTL per locked wallet. The TTL
+// bounds how long a stopped instance blocks a wallet; an operation outliving
+// it loses its lock but stays correct, since the database still locks the
+// wallet rows.
+type RedisWalletLock struct {
+	client redis.Cmdable
+	ttl    time.Duration
+	wait   time.Duration
+	logger *logrus.Logger
+}
+
+func NewWalletLock(client redis.Cmdable, ttl, wait time.Duration, logger *logrus.Logger) *RedisWalletLock {
+	return &RedisWalletLock{
+		client: client,
+		ttl:    ttl,
+		wait:   wait,
+		logger: logger,
+	}
+}
+
+// Lock takes the locks in user ID order, so operations locking the same
+// wallets, like transfers in opposite directions, cannot wait on each other.
+// It returns ErrLockNotAcquired when the locks are not free within the wait
+// time; the locks it took are released again.
+func (l *RedisWalletLock) Lock(parent context.Context, userIDs ...string) (func(), error) {
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, walletLockKey(userID))
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	value := hex.EncodeToString(token)
+
+	ctx, cancel := context.WithTimeout(parent, l.wait)
+	defer cancel()
+
+	held := make([]string, 0, len(keys))
+	release := func() {
+		// Release even when the operation's context is cancelled
+		releaseCtx := context.WithoutCancel(parent)
+		for _, key := range held {
+			if err := releaseLockScript.Run(releaseCtx, l.client, []string{key}, value).Err(); err != nil {
+				l.logger.WithContext(parent).WithError(err).WithField("key", key).Warn("Lock - Release wallet lock failed")
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if err := l.acquire(ctx, key, value); err != nil {
+			release()
+			if parent.Err() != nil {
+				return nil, parent.Err()
+			}
+			return nil, err
+		}
+		held = append(held, key)
+	}
+	return release, nil
+}
+
+// acquire takes the lock in key, retrying until ctx is done
+func (l *RedisWalletLock) acquire(ctx context.Context, key, value string) error {
+	poll := time.NewTicker(walletLockPoll)
+	defer poll.Stop()
+
+	for {
+		taken, err := l.client.SetNX(ctx, key, value, l.ttl).Result()
+		if err != nil && ctx.Err() == nil {
+			l.logger.WithContext(ctx).WithError(err).WithField("key", key).Warn("Lock - Take wallet lock failed")
+			return err
+		}
+		if taken {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			l.logger.WithContext(ctx).WithField("key", key).Warn("Lock - Wallet lock still held by another operation")
+			return ErrLockNotAcquired
+		case <-poll.C:
+		}
+	}
+}
+
+func walletLockKey(userID string) string {
+	return "wallet:lock:" + userID
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	mockredis "Crypto.com/mocks"
+)
+
+func TestWalletLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockredis.NewMockCmdable(ctrl)
+	lock := NewWalletLock(mockClient, 10*time.Second, 50*time.Millisecond, logrus.New())
+	ctx := context.Background()
+
+	t.Run("Lock takes the locks in user ID order", func(t *testing.T) {
+		var token string
+		gomock.InOrder(
+			mockClient.EXPECT().SetNX(gomock.Any(), "wallet:lock:user1", gomock.Any(), 10*time.Second).
+				DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) *redis.BoolCmd {
+					token = value.(string)
+					return redis.NewBoolResult(true, nil)
+				}),
+			mockClient.EXPECT().SetNX(gomock.Any(), "wallet:lock:user2", gomock.Any(), 10*time.Second).Return(redis.NewBoolResult(true, nil)),
+		)
+
+		unlock, err := lock.Lock(ctx, "user2", "user1", "user2")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"wallet:lock:user1"}, token).Return(redis.NewCmdResult(int64(1), nil))
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"wallet:lock:user2"}, token).Return(redis.NewCmdResult(int64(1), nil))
+		unlock()
+	})
+
+	t.Run("Lock gives up on a held lock and releases the others", func(t *testing.T) {
+		mockClient.EXPECT().SetNX(gomock.Any(), "wallet:lock:user1", gomock.Any(), gomock.Any()).Return(redis.NewBoolResult(true, nil))
+		mockClient.EXPECT().SetNX(gomock.Any(), "wallet:lock:user2", gomock.Any(), gomock.Any()).Return(redis.NewBoolResult(false, nil)).MinTimes(2)
+		mockClient.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"wallet:lock:user1"}, gomock.Any()).Return(redis.NewCmdResult(int64(1), nil))
+
+		_, err := lock.Lock(ctx, "user1", "user2")
+		if !errors.Is(err, ErrLockNotAcquired) {
+			t.Errorf("Expected ErrLockNotAcquired, got %v", err)
+		}
+	})
+
+	t.Run("Lock redis error", func(t *testing.T) {
+		mockErr := errors.New("connection failed")
+		mockClient.EXPECT().SetNX(gomock.Any(), "wallet:lock:user3", gomock.Any(), gomock.Any()).Return(redis.NewBoolResult(false, mockErr))
+
+		_, err := lock.Lock(ctx, "user3")
+		if !errors.Is(err, mockErr) {
+			t.Errorf("Expected %v, got %v", mockErr, err)
+		}
+	})
+}
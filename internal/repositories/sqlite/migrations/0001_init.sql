@@ -0,0 +1,53 @@
+-- Timestamps are stored as UTC text in the driver's format so they compare
+-- correctly with bound time.Time parameters
+CREATE TABLE wallets (
+    user_id TEXT PRIMARY KEY,
+    balance TEXT NOT NULL DEFAULT '0',
+    status TEXT NOT NULL DEFAULT 'active',
+    label TEXT,
+    country TEXT
+);
+
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_user_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    to_user_id TEXT
+);
+
+CREATE TABLE transfer_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sender_id TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    total_count INTEGER NOT NULL,
+    succeeded_count INTEGER NOT NULL,
+    failed_count INTEGER NOT NULL,
+    total_amount TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE transfer_batch_items (
+    batch_id INTEGER NOT NULL REFERENCES transfer_batches (id),
+    item_index INTEGER NOT NULL,
+    receiver_id TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error_code TEXT,
+    error TEXT,
+    PRIMARY KEY (batch_id, item_index)
+);
+
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_transactions_from_user ON transactions (from_user_id, created_at);
+CREATE INDEX idx_transactions_to_user ON transactions (to_user_id, created_at);
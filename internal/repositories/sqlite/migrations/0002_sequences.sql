@@ -0,0 +1,14 @@
+-- Per-wallet transaction sequence numbers. wallet_sequences holds the last
+-- number handed out for each wallet.
+CREATE TABLE wallet_sequences (
+    user_id TEXT PRIMARY KEY,
+    last_sequence INTEGER NOT NULL
+);
+
+CREATE TABLE transaction_sequences (
+    transaction_id INTEGER NOT NULL REFERENCES transactions (id),
+    user_id TEXT NOT NULL,
+    sequence INTEGER NOT NULL,
+    PRIMARY KEY (transaction_id, user_id),
+    UNIQUE (user_id, sequence)
+);
@@ -0,0 +1,4 @@
+-- Actor and channel of the operation that created each transaction. Both
+-- are NULL for transactions created outside an operation.
+ALTER TABLE transactions ADD COLUMN actor TEXT;
+ALTER TABLE transactions ADD COLUMN channel TEXT;
@@ -0,0 +1,5 @@
+-- Memo, reference and key-value metadata, as a JSON object, attached by the
+-- client to the transactions it creates
+ALTER TABLE transactions ADD COLUMN memo TEXT;
+ALTER TABLE transactions ADD COLUMN reference_id TEXT;
+ALTER TABLE transactions ADD COLUMN metadata TEXT;
@@ -0,0 +1,4 @@
+-- Currency and initial key-value metadata, as a JSON object, of provisioned
+-- wallets. Wallets created by a deposit have neither.
+ALTER TABLE wallets ADD COLUMN currency TEXT;
+ALTER TABLE wallets ADD COLUMN metadata TEXT;
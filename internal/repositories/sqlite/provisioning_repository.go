@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// SQLiteProvisioningRepository implements postgres.ProvisioningRepository on
+// SQLite. There is no currencies table or event outbox, so currencies are not
+// checked and no wallet.created event is recorded.
+type SQLiteProvisioningRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewProvisioningRepository(db *sql.DB, logger *logrus.Logger) *SQLiteProvisioningRepository {
+	return &SQLiteProvisioningRepository{db: db, logger: logger}
+}
+
+// ProvisionWallet creates the empty wallet of wallet.UserID in its currency,
+// status and metadata
+func (r *SQLiteProvisioningRepository) ProvisionWallet(ctx context.Context, newWallet models.NewWallet) (*models.Wallet, error) {
+	userID := newWallet.UserID
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("ProvisionWallet - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+	logger := r.logger.WithContext(ctx).WithField("userID", userID)
+
+	var metadata *string
+	if len(newWallet.Metadata) > 0 {
+		// A map of strings always encodes
+		raw, _ := json.Marshal(newWallet.Metadata)
+		encoded := string(raw)
+		metadata = &encoded
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO wallets (user_id, status, currency, metadata) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO NOTHING`,
+		userID, newWallet.Status, newWallet.Currency, metadata,
+	)
+	if err != nil {
+		logger.WithError(err).Error("ProvisionWallet - Create wallet failed")
+		return nil, err
+	}
+	if created, err := result.RowsAffected(); err != nil || created == 0 {
+		logger.Warn("ProvisionWallet - Wallet already exists")
+		return nil, postgres.ErrWalletAlreadyExists
+	}
+
+	wallet := &models.Wallet{
+		UserID:   userID,
+		Balance:  decimal.Zero,
+		Held:     decimal.Zero,
+		Status:   newWallet.Status,
+		Currency: newWallet.Currency,
+	}
+	if len(newWallet.Metadata) > 0 {
+		wallet.Metadata = newWallet.Metadata
+	}
+
+	logger.WithField("status", wallet.Status).Info("Wallet provisioned")
+	return wallet, nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens the SQLite database at path. A single connection is used so
+// write transactions are serialized, which stands in for the row locks the
+// Postgres repositories rely on.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_loc=UTC")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Migrate applies the embedded migrations that have not been applied yet, in
+// version order, each in its own transaction
+func Migrate(ctx context.Context, db *sql.DB, logger *logrus.Logger) error {
+	_, err := db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("Migrate - Create migrations table failed")
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/")
+		version, err := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		if err != nil {
+			return err
+		}
+
+		var applied bool
+		err = db.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)",
+			version,
+		).Scan(&applied)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).Error("Migrate - Query applied migrations failed")
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if err := apply(ctx, db, version, string(script)); err != nil {
+			logger.WithContext(ctx).WithError(err).WithField("migration", base).Error("Migrate - Apply migration failed")
+			return err
+		}
+		logger.WithContext(ctx).WithField("migration", base).Info("Migration applied")
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, version int, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,732 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// SQLiteWalletRepository implements postgres.WalletRepository on SQLite.
+// Balances are stored as text and computed in Go so amounts stay exact.
+type SQLiteWalletRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+	// provisionedOnly rejects deposits to wallets that were not provisioned
+	// instead of creating them
+	provisionedOnly bool
+}
+
+// WalletRepositoryOption configures a SQLiteWalletRepository
+type WalletRepositoryOption func(*SQLiteWalletRepository)
+
+// WithProvisionedWalletsOnly makes deposits to a user without a wallet fail
+// with postgres.ErrUserNotFound, like the Postgres repository with the same
+// option
+func WithProvisionedWalletsOnly() WalletRepositoryOption {
+	return func(r *SQLiteWalletRepository) {
+		r.provisionedOnly = true
+	}
+}
+
+func NewWalletRepository(db *sql.DB, logger *logrus.Logger, opts ...WalletRepositoryOption) *SQLiteWalletRepository {
+	r := &SQLiteWalletRepository{db: db, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Deposit adds amount to the user's balance, creating the wallet if needed
+// unless only provisioned wallets are credited
+func (r *SQLiteWalletRepository) Deposit(ctx context.Context, userID string, amount decimal.Decimal) error {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("Deposit - userID cannot be an empty string")
+		return postgres.ErrInvalidUserID
+	}
+
+	if !amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("Deposit - amount cannot be less than zero")
+		return postgres.ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"amount": amount,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Deposit - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	balance, status, err := walletState(ctx, tx, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows) && r.provisionedOnly:
+		logger.Warn("Deposit - Wallet is not provisioned")
+		return postgres.ErrUserNotFound
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO wallets (user_id, balance) VALUES ($1, $2)",
+			userID, amount,
+		)
+	case err != nil:
+	case status == models.WalletStatusFrozen:
+		logger.Warn("Deposit - Wallet is frozen")
+		return postgres.ErrWalletFrozen
+	default:
+		err = setBalance(ctx, tx, userID, balance.Add(amount))
+	}
+	if err != nil {
+		logger.WithError(err).Error("Deposit - Update balance failed")
+		return err
+	}
+
+	if err = insertTransaction(ctx, tx, userID, nil, amount, "deposit"); err != nil {
+		logger.WithError(err).Error("Deposit - Create transaction record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("Deposit - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Deposit successful")
+	return nil
+}
+
+// Withdraw deducts amount from user's balance if sufficient funds. When
+// expectedBalance is set, the withdrawal only proceeds if the balance still
+// equals it.
+func (r *SQLiteWalletRepository) Withdraw(ctx context.Context, userID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("Withdraw - userID cannot be an empty string")
+		return postgres.ErrInvalidUserID
+	}
+
+	if !amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("Withdraw - amount cannot be less than zero")
+		return postgres.ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"amount": amount,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Withdraw - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	balance, err := debit(ctx, tx, userID, amount, expectedBalance)
+	if err != nil {
+		logger.WithError(err).Warn("Withdraw - Debit rejected")
+		return err
+	}
+
+	if err = setBalance(ctx, tx, userID, balance); err != nil {
+		logger.WithError(err).Error("Withdraw - Update user balance failed")
+		return err
+	}
+
+	if err = insertTransaction(ctx, tx, userID, nil, amount, "withdrawal"); err != nil {
+		logger.WithError(err).Error("Withdraw - Create transaction record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("Withdraw - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Withdraw successful")
+	return nil
+}
+
+// Transfer moves funds between two users atomically. expectedBalance applies
+// to the sender, see Withdraw.
+func (r *SQLiteWalletRepository) Transfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+	if fromUserID == "" || toUserID == "" {
+		r.logger.WithContext(ctx).Warn("Transfer - fromUserID and toUserID cannot be an empty string")
+		return postgres.ErrInvalidUserID
+	}
+
+	if fromUserID == toUserID {
+		r.logger.WithContext(ctx).Warn("Transfer - fromUserID and toUserID cannot be the same")
+		return postgres.ErrInvalidUserID
+	}
+
+	if !amount.IsPositive() {
+		r.logger.WithContext(ctx).Warn("Transfer - amount cannot be less than zero")
+		return postgres.ErrInvalidAmount
+	}
+
+	logger := r.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"fromUserID": fromUserID,
+		"toUserID":   toUserID,
+		"amount":     amount,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.WithError(err).Error("Transfer - Begin DB transaction failed")
+		return err
+	}
+	defer tx.Rollback()
+
+	senderBalance, err := debit(ctx, tx, fromUserID, amount, expectedBalance)
+	if err != nil {
+		logger.WithError(err).Warn("Transfer - Debit rejected")
+		return err
+	}
+
+	receiverBalance, status, err := walletState(ctx, tx, toUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("Transfer - Cannot find receiver in the database")
+		return postgres.ErrUserNotFound
+	}
+	if err != nil {
+		logger.WithError(err).Error("Transfer - Query receiver failed")
+		return err
+	}
+	if status == models.WalletStatusFrozen {
+		logger.Warn("Transfer - Receiver wallet is frozen")
+		return postgres.ErrWalletFrozen
+	}
+
+	if err = setBalance(ctx, tx, fromUserID, senderBalance); err != nil {
+		logger.WithError(err).Error("Transfer - Update sender balance failed")
+		return err
+	}
+	if err = setBalance(ctx, tx, toUserID, receiverBalance.Add(amount)); err != nil {
+		logger.WithError(err).Error("Transfer - Update receiver balance failed")
+		return err
+	}
+
+	if err = insertTransaction(ctx, tx, fromUserID, &toUserID, amount, "transfer"); err != nil {
+		logger.WithError(err).Error("Transfer - Create transaction record failed")
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.WithError(err).Error("Transfer - Commit DB transaction failed")
+		return err
+	}
+
+	logger.Info("Transfer successful")
+	return nil
+}
+
+// GetBalance returns current wallet balance
+func (r *SQLiteWalletRepository) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetBalance - userID cannot be an empty string")
+		return decimal.Zero, postgres.ErrInvalidUserID
+	}
+
+	var balance decimal.Decimal
+	err := r.db.QueryRowContext(ctx,
+		"SELECT balance FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, postgres.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetBalance - Query user balance failed")
+		return decimal.Zero, err
+	}
+
+	return balance, nil
+}
+
+// GetVersionedBalance returns current wallet balance with the last sequence
+// number of the wallet's ledger as its version
+func (r *SQLiteWalletRepository) GetVersionedBalance(ctx context.Context, userID string) (decimal.Decimal, int64, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetVersionedBalance - userID cannot be an empty string")
+		return decimal.Zero, 0, postgres.ErrInvalidUserID
+	}
+
+	var balance decimal.Decimal
+	var version int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT w.balance, COALESCE(s.last_sequence, 0)
+		FROM wallets w
+		LEFT JOIN wallet_sequences s ON s.user_id = w.user_id
+		WHERE w.user_id = $1`,
+		userID,
+	).Scan(&balance, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, 0, postgres.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetVersionedBalance - Query user balance failed")
+		return decimal.Zero, 0, err
+	}
+
+	return balance, version, nil
+}
+
+// GetTransactionHistory returns paginated transaction history within window.
+//
+// Deprecated: use GetTransactionsBefore.
+func (r *SQLiteWalletRepository) GetTransactionHistory(ctx context.Context, userID string, window models.HistoryWindow, limit, offset int) ([]models.Transaction, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTransactionHistory - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTransactionHistory - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	filter, args := windowFilter(window, []interface{}{userID})
+	args = append(args, limit, offset)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT CAST(id AS TEXT), from_user_id, to_user_id, amount, type, created_at, memo, reference_id, metadata,
+			(SELECT sequence FROM transaction_sequences s
+			WHERE s.transaction_id = transactions.id AND s.user_id = $1)
+		FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)`+filter+
+			fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionHistory - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		err := rows.Scan(&txn.ID, &txn.FromUserID, &txn.ToUserID, &txn.Amount, &txn.Type, &txn.CreatedAt,
+			&txn.Memo, &txn.ReferenceID, (*metadataColumn)(&txn.Metadata), &txn.Sequence)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionHistory - Scan transactions failed")
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+	return transactions, rows.Err()
+}
+
+// GetTransactionsBefore returns up to limit transactions within window older
+// than cursor, newest first. A nil cursor starts at the most recent
+// transaction.
+func (r *SQLiteWalletRepository) GetTransactionsBefore(ctx context.Context, userID string, cursor *models.TransactionCursor, window models.HistoryWindow, limit int) ([]models.Transaction, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTransactionsBefore - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTransactionsBefore - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	query := `SELECT CAST(id AS TEXT), from_user_id, to_user_id, amount, type, created_at, memo, reference_id, metadata,
+			(SELECT sequence FROM transaction_sequences s
+			WHERE s.transaction_id = transactions.id AND s.user_id = $1)
+		FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)`
+	args := []interface{}{userID}
+	// SQLite numbers $N parameters by first appearance, so they are added in
+	// query order
+	if cursor != nil {
+		query += ` AND (created_at < $2 OR (created_at = $2 AND id < $3))`
+		args = append(args, cursor.CreatedAt.UTC(), cursor.ID)
+	}
+	filter, args := windowFilter(window, args)
+	args = append(args, limit)
+	query += filter + fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionsBefore - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		err := rows.Scan(&txn.ID, &txn.FromUserID, &txn.ToUserID, &txn.Amount, &txn.Type, &txn.CreatedAt,
+			&txn.Memo, &txn.ReferenceID, (*metadataColumn)(&txn.Metadata), &txn.Sequence)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionsBefore - Scan transactions failed")
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+	return transactions, rows.Err()
+}
+
+// CountTransactions returns the number of transactions of userID within
+// window
+func (r *SQLiteWalletRepository) CountTransactions(ctx context.Context, userID string, window models.HistoryWindow) (int, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("CountTransactions - userID cannot be an empty string")
+		return 0, postgres.ErrInvalidUserID
+	}
+
+	filter, args := windowFilter(window, []interface{}{userID})
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)`+filter,
+		args...,
+	).Scan(&count)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("CountTransactions - Count transactions failed")
+		return 0, err
+	}
+	return count, nil
+}
+
+// windowFilter returns the conditions bounding a history to window. SQLite
+// numbers $N parameters by first appearance, so the filter has to follow the
+// conditions of args in the query.
+func windowFilter(window models.HistoryWindow, args []interface{}) (string, []interface{}) {
+	var filter string
+	if window.From != nil {
+		args = append(args, window.From.UTC())
+		filter += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if window.To != nil {
+		args = append(args, window.To.UTC())
+		filter += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	if window.ReferenceID != nil {
+		args = append(args, *window.ReferenceID)
+		filter += fmt.Sprintf(" AND reference_id = $%d", len(args))
+	}
+	if window.Memo != nil {
+		args = append(args, *window.Memo)
+		filter += fmt.Sprintf(" AND instr(lower(memo), lower($%d)) > 0", len(args))
+	}
+	for _, key := range slices.Sorted(maps.Keys(window.Metadata)) {
+		args = append(args, fmt.Sprintf("$.%q", key), window.Metadata[key])
+		filter += fmt.Sprintf(" AND json_extract(metadata, $%d) = $%d", len(args)-1, len(args))
+	}
+	return filter, args
+}
+
+// metadataColumn scans a nullable JSON object of strings into a map, nil for
+// NULL
+type metadataColumn map[string]string
+
+func (m *metadataColumn) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, (*map[string]string)(m))
+	case string:
+		return json.Unmarshal([]byte(value), (*map[string]string)(m))
+	default:
+		return fmt.Errorf("cannot scan %T into metadata", src)
+	}
+}
+
+// GetTransactionsBetween returns up to limit transactions created in
+// [from, to), oldest first
+func (r *SQLiteWalletRepository) GetTransactionsBetween(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.Transaction, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTransactionsBetween - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTransactionsBetween - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT CAST(id AS TEXT), from_user_id, to_user_id, amount, type, created_at, memo, reference_id, metadata,
+			(SELECT sequence FROM transaction_sequences s
+			WHERE s.transaction_id = transactions.id AND s.user_id = $1)
+		FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)
+			AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id
+		LIMIT $4`,
+		userID, from.UTC(), to.UTC(), limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionsBetween - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		err := rows.Scan(&txn.ID, &txn.FromUserID, &txn.ToUserID, &txn.Amount, &txn.Type, &txn.CreatedAt,
+			&txn.Memo, &txn.ReferenceID, (*metadataColumn)(&txn.Metadata), &txn.Sequence)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionsBetween - Scan transactions failed")
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+	return transactions, rows.Err()
+}
+
+// GetTransactionChanges returns up to limit transactions of the ledger of
+// userID after the sync sequence since, in sync order. Transactions are
+// recorded completed and never change here, so the sync order is the ledger
+// order.
+func (r *SQLiteWalletRepository) GetTransactionChanges(ctx context.Context, userID string, since int64, limit int) ([]models.Transaction, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTransactionChanges - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTransactionChanges - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT CAST(t.id AS TEXT), t.from_user_id, t.to_user_id, t.amount, t.type, t.created_at,
+			t.memo, t.reference_id, t.metadata, s.sequence
+		FROM transaction_sequences s
+		JOIN transactions t ON t.id = s.transaction_id
+		WHERE s.user_id = $1 AND s.sequence > $2
+		ORDER BY s.sequence
+		LIMIT $3`,
+		userID, since, limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionChanges - Query transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var txn models.Transaction
+		err := rows.Scan(&txn.ID, &txn.FromUserID, &txn.ToUserID, &txn.Amount, &txn.Type, &txn.CreatedAt,
+			&txn.Memo, &txn.ReferenceID, (*metadataColumn)(&txn.Metadata), &txn.Sequence)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTransactionChanges - Scan transactions failed")
+			return nil, err
+		}
+		status := models.TransactionCompleted
+		txn.Status = &status
+		txn.SyncSequence = txn.Sequence
+		transactions = append(transactions, txn)
+	}
+	return transactions, rows.Err()
+}
+
+// GetTimeline returns a paginated, chronologically ordered feed of all events
+// touching the user's wallet
+func (r *SQLiteWalletRepository) GetTimeline(ctx context.Context, userID string, limit, offset int) ([]models.TimelineEvent, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTimeline - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTimeline - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT 'transaction', type, CAST(id AS TEXT), from_user_id, to_user_id, amount, created_at
+		FROM transactions
+		WHERE from_user_id = $1 OR to_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTimeline - Query timeline failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TimelineEvent
+	for rows.Next() {
+		var event models.TimelineEvent
+		err := rows.Scan(&event.Type, &event.Subtype, &event.ReferenceID, &event.FromUserID, &event.ToUserID, &event.Amount, &event.OccurredAt)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTimeline - Scan timeline failed")
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetTimelineBefore returns up to limit events of the user's timeline after
+// cursor, newest first. A nil cursor starts at the most recent event.
+func (r *SQLiteWalletRepository) GetTimelineBefore(ctx context.Context, userID string, cursor *models.TimelineCursor, limit int) ([]models.TimelineEvent, error) {
+	if userID == "" {
+		r.logger.WithContext(ctx).Warn("GetTimelineBefore - userID cannot be an empty string")
+		return nil, postgres.ErrInvalidUserID
+	}
+
+	if limit <= 0 {
+		r.logger.WithContext(ctx).Warn("GetTimelineBefore - limit cannot be less than 0")
+		return nil, postgres.ErrInvalidLimit
+	}
+
+	query := `SELECT 'transaction', type, CAST(id AS TEXT), from_user_id, to_user_id, amount, created_at
+		FROM transactions
+		WHERE (from_user_id = $1 OR to_user_id = $1)`
+	args := []interface{}{userID}
+	// Transactions are the only events, so those at the instant of a cursor
+	// of another type all follow it or all precede it, as on PostgreSQL
+	if cursor != nil {
+		query += ` AND (created_at < $2 OR (created_at = $2 AND ($3 < 'transaction' OR ($3 = 'transaction' AND id < $4))))`
+		args = append(args, cursor.OccurredAt.UTC(), cursor.Type, cursor.ID)
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTimelineBefore - Query timeline failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TimelineEvent
+	for rows.Next() {
+		var event models.TimelineEvent
+		err := rows.Scan(&event.Type, &event.Subtype, &event.ReferenceID, &event.FromUserID, &event.ToUserID, &event.Amount, &event.OccurredAt)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("GetTimelineBefore - Scan timeline failed")
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// walletState reads the balance and status of a wallet within tx
+func walletState(ctx context.Context, tx *sql.Tx, userID string) (decimal.Decimal, string, error) {
+	var balance decimal.Decimal
+	var status string
+	err := tx.QueryRowContext(ctx,
+		"SELECT balance, status FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&balance, &status)
+	return balance, status, err
+}
+
+// debit checks that amount can be taken from the wallet and returns the
+// resulting balance
+func debit(ctx context.Context, tx *sql.Tx, userID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) (decimal.Decimal, error) {
+	balance, status, err := walletState(ctx, tx, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return decimal.Zero, postgres.ErrUserNotFound
+	case err != nil:
+		return decimal.Zero, err
+	case status == models.WalletStatusFrozen:
+		return decimal.Zero, postgres.ErrWalletFrozen
+	case expectedBalance != nil && !balance.Equal(*expectedBalance):
+		return decimal.Zero, postgres.ErrBalanceMismatch
+	case balance.LessThan(amount):
+		return decimal.Zero, postgres.ErrInsufficientBalance
+	}
+	return balance.Sub(amount), nil
+}
+
+func setBalance(ctx context.Context, tx *sql.Tx, userID string, balance decimal.Decimal) error {
+	_, err := tx.ExecContext(ctx,
+		"UPDATE wallets SET balance = $1 WHERE user_id = $2",
+		balance, userID,
+	)
+	return err
+}
+
+// insertTransaction records a transaction with the provenance and details of
+// the operation in ctx and numbers it in the ledger of each wallet it touches
+func insertTransaction(ctx context.Context, tx *sql.Tx, fromUserID string, toUserID *string, amount decimal.Decimal, txnType string) error {
+	op, _ := operation.From(ctx)
+	details := operation.DetailsFrom(ctx)
+	var metadata sql.NullString
+	if len(details.Metadata) > 0 {
+		// A map of strings always encodes
+		encoded, _ := json.Marshal(details.Metadata)
+		metadata = sql.NullString{String: string(encoded), Valid: true}
+	}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO transactions (from_user_id, to_user_id, amount, type, created_at, actor, channel, memo, reference_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		fromUserID, toUserID, amount, txnType, time.Now().UTC(),
+		sql.NullString{String: op.Actor, Valid: op.Actor != ""},
+		sql.NullString{String: op.Channel, Valid: op.Channel != ""},
+		sql.NullString{String: details.Memo, Valid: details.Memo != ""},
+		sql.NullString{String: details.Reference, Valid: details.Reference != ""},
+		metadata,
+	)
+	if err != nil {
+		return err
+	}
+	transactionID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err = assignSequence(ctx, tx, transactionID, fromUserID); err != nil {
+		return err
+	}
+	if toUserID != nil {
+		return assignSequence(ctx, tx, transactionID, *toUserID)
+	}
+	return nil
+}
+
+// assignSequence gives the transaction the next number in the wallet's
+// ledger. Writes are serialized, so the numbers have no gaps.
+func assignSequence(ctx context.Context, tx *sql.Tx, transactionID int64, userID string) error {
+	var sequence int64
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO wallet_sequences (user_id, last_sequence)
+		VALUES ($1, 1)
+		ON CONFLICT (user_id) DO UPDATE SET last_sequence = last_sequence + 1
+		RETURNING last_sequence`,
+		userID,
+	).Scan(&sequence)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO transaction_sequences (transaction_id, user_id, sequence) VALUES ($1, $2, $3)",
+		transactionID, userID, sequence,
+	)
+	return err
+}
@@ -0,0 +1,295 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := Open(filepath.Join(t.TempDir(), "wallet.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, Migrate(context.Background(), db, logrus.New()))
+	// Applied migrations are tracked, so a second run is a no-op
+	require.NoError(t, Migrate(context.Background(), db, logrus.New()))
+	return db
+}
+
+func TestWalletRepository(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewWalletRepository(db, logrus.New())
+
+	t.Run("deposit creates wallet", func(t *testing.T) {
+		require.NoError(t, repo.Deposit(ctx, "user1", decimal.RequireFromString("100.10")))
+		require.NoError(t, repo.Deposit(ctx, "user1", decimal.RequireFromString("0.20")))
+
+		balance, err := repo.GetBalance(ctx, "user1")
+		require.NoError(t, err)
+		require.True(t, balance.Equal(decimal.RequireFromString("100.30")), balance.String())
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		err := repo.Withdraw(ctx, "user1", decimal.NewFromInt(1000), nil)
+		require.ErrorIs(t, err, postgres.ErrInsufficientBalance)
+
+		stale := decimal.NewFromInt(1)
+		err = repo.Withdraw(ctx, "user1", decimal.NewFromInt(10), &stale)
+		require.ErrorIs(t, err, postgres.ErrBalanceMismatch)
+
+		require.NoError(t, repo.Withdraw(ctx, "user1", decimal.RequireFromString("0.30"), nil))
+
+		err = repo.Withdraw(ctx, "nobody", decimal.NewFromInt(1), nil)
+		require.ErrorIs(t, err, postgres.ErrUserNotFound)
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		err := repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil)
+		require.ErrorIs(t, err, postgres.ErrUserNotFound)
+
+		require.NoError(t, repo.Deposit(ctx, "user2", decimal.NewFromInt(1)))
+		require.NoError(t, repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(40), nil))
+
+		balance, err := repo.GetBalance(ctx, "user2")
+		require.NoError(t, err)
+		require.True(t, balance.Equal(decimal.NewFromInt(41)), balance.String())
+	})
+
+	t.Run("versioned balance", func(t *testing.T) {
+		balance, version, err := repo.GetVersionedBalance(ctx, "user2")
+		require.NoError(t, err)
+		require.True(t, balance.Equal(decimal.NewFromInt(41)), balance.String())
+		// The deposit and the incoming transfer
+		require.Equal(t, int64(2), version)
+	})
+
+	t.Run("frozen wallet", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, "UPDATE wallets SET status = $1 WHERE user_id = $2", models.WalletStatusFrozen, "user2")
+		require.NoError(t, err)
+
+		require.ErrorIs(t, repo.Deposit(ctx, "user2", decimal.NewFromInt(5)), postgres.ErrWalletFrozen)
+		require.ErrorIs(t, repo.Transfer(ctx, "user1", "user2", decimal.NewFromInt(5), nil), postgres.ErrWalletFrozen)
+	})
+
+	t.Run("history and timeline", func(t *testing.T) {
+		history, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		require.Equal(t, "transfer", *history[0].Type)
+		require.Equal(t, "user2", *history[0].ToUserID)
+
+		// Sequences number the user's ledger without gaps, newest first
+		for i, txn := range history {
+			require.Equal(t, int64(len(history)-i), *txn.Sequence)
+		}
+
+		// Walking the history with cursors yields the same order
+		var walked []string
+		var cursor *models.TransactionCursor
+		for {
+			page, err := repo.GetTransactionsBefore(ctx, "user1", cursor, models.HistoryWindow{}, 3)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			for _, txn := range page {
+				walked = append(walked, *txn.ID)
+			}
+			last := page[len(page)-1]
+			id, err := strconv.ParseInt(*last.ID, 10, 64)
+			require.NoError(t, err)
+			cursor = &models.TransactionCursor{CreatedAt: *last.CreatedAt, ID: id}
+		}
+		require.Len(t, walked, 4)
+		for i, txn := range history {
+			require.Equal(t, *txn.ID, walked[i])
+		}
+
+		// Windows bound both listings
+		hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+		recent, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{From: &hourAgo, To: &inAnHour}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 4)
+		future, err := repo.GetTransactionsBefore(ctx, "user1", cursor, models.HistoryWindow{From: &inAnHour}, 3)
+		require.NoError(t, err)
+		require.Empty(t, future)
+		old, err := repo.GetTransactionHistory(ctx, "user1", models.HistoryWindow{To: &hourAgo}, 10, 0)
+		require.NoError(t, err)
+		require.Empty(t, old)
+
+		timeline, err := repo.GetTimeline(ctx, "user2", 1, 0)
+		require.NoError(t, err)
+		require.Len(t, timeline, 1)
+		require.Equal(t, models.TimelineEventTransaction, timeline[0].Type)
+		require.Equal(t, "transfer", *timeline[0].Subtype)
+
+		// The cursor resumes right after the transfer
+		before, err := repo.GetTimelineBefore(ctx, "user1", nil, 1)
+		require.NoError(t, err)
+		require.Equal(t, timeline[0].ReferenceID, before[0].ReferenceID)
+		id, err := strconv.ParseInt(*before[0].ReferenceID, 10, 64)
+		require.NoError(t, err)
+		rest, err := repo.GetTimelineBefore(ctx, "user1", &models.TimelineCursor{OccurredAt: *before[0].OccurredAt, Type: before[0].Type, ID: id}, 100)
+		require.NoError(t, err)
+		require.Len(t, rest, len(history)-1)
+		require.NotEqual(t, *before[0].ReferenceID, *rest[0].ReferenceID)
+
+		// The period covers the whole ledger, oldest first
+		period, err := repo.GetTransactionsBetween(ctx, "user1", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, period, len(history))
+		for i, txn := range period {
+			require.Equal(t, *history[len(history)-1-i].ID, *txn.ID)
+		}
+
+		period, err = repo.GetTransactionsBetween(ctx, "user1", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, period)
+	})
+
+	t.Run("transaction details", func(t *testing.T) {
+		detailed := operation.WithDetails(ctx, operation.Details{
+			Memo:      "Invoice for March",
+			Reference: "order-42",
+			Metadata:  map[string]string{"invoice": "INV-7", "channel": "web"},
+		})
+		require.NoError(t, repo.Deposit(detailed, "user3", decimal.NewFromInt(5)))
+		require.NoError(t, repo.Deposit(ctx, "user3", decimal.NewFromInt(1)))
+
+		history, err := repo.GetTransactionHistory(ctx, "user3", models.HistoryWindow{}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Nil(t, history[0].Memo)
+		require.Nil(t, history[0].Metadata)
+		require.Equal(t, "order-42", *history[1].ReferenceID)
+		require.Equal(t, map[string]string{"invoice": "INV-7", "channel": "web"}, history[1].Metadata)
+
+		reference, memo := "order-42", "march"
+		for _, window := range []models.HistoryWindow{
+			{ReferenceID: &reference},
+			{Memo: &memo},
+			{Metadata: map[string]string{"invoice": "INV-7", "channel": "web"}},
+		} {
+			filtered, err := repo.GetTransactionHistory(ctx, "user3", window, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, filtered, 1)
+			require.Equal(t, *history[1].ID, *filtered[0].ID)
+		}
+
+		filtered, err := repo.GetTransactionsBefore(ctx, "user3", nil, models.HistoryWindow{Metadata: map[string]string{"invoice": "INV-8"}}, 10)
+		require.NoError(t, err)
+		require.Empty(t, filtered)
+
+		// Counts follow the same windows
+		count, err := repo.CountTransactions(ctx, "user1", models.HistoryWindow{})
+		require.NoError(t, err)
+		require.Equal(t, 4, count)
+		count, err = repo.CountTransactions(ctx, "user3", models.HistoryWindow{Memo: &memo})
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("transaction changes", func(t *testing.T) {
+		changes, err := repo.GetTransactionChanges(ctx, "user3", 0, 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, int64(1), *changes[0].SyncSequence)
+		require.Equal(t, models.TransactionCompleted, *changes[0].Status)
+
+		changes, err = repo.GetTransactionChanges(ctx, "user3", 1, 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, int64(2), *changes[0].Sequence)
+
+		changes, err = repo.GetTransactionChanges(ctx, "user3", 2, 10)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+}
+
+func TestProvisionedWallets(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewWalletRepository(db, logrus.New(), WithProvisionedWalletsOnly())
+	provisioning := NewProvisioningRepository(db, logrus.New())
+
+	err := repo.Deposit(ctx, "user1", decimal.NewFromInt(10))
+	require.ErrorIs(t, err, postgres.ErrUserNotFound)
+
+	currency := "EUR"
+	wallet, err := provisioning.ProvisionWallet(ctx, models.NewWallet{
+		UserID:   "user1",
+		Currency: &currency,
+		Metadata: map[string]string{"customer": "C-42"},
+		Status:   models.WalletStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "EUR", *wallet.Currency)
+	require.True(t, wallet.Balance.IsZero())
+
+	_, err = provisioning.ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Status: models.WalletStatusActive})
+	require.ErrorIs(t, err, postgres.ErrWalletAlreadyExists)
+
+	require.NoError(t, repo.Deposit(ctx, "user1", decimal.NewFromInt(10)))
+	balance, err := repo.GetBalance(ctx, "user1")
+	require.NoError(t, err)
+	require.True(t, balance.Equal(decimal.NewFromInt(10)), balance.String())
+
+	_, err = provisioning.ProvisionWallet(ctx, models.NewWallet{UserID: "user2", Status: models.WalletStatusFrozen})
+	require.NoError(t, err)
+	require.ErrorIs(t, repo.Deposit(ctx, "user2", decimal.NewFromInt(10)), postgres.ErrWalletFrozen)
+}
+
+// The batch and idempotency repositories only use portable SQL and are
+// shared with the Postgres deployment
+func TestSharedRepositories(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	t.Run("batches", func(t *testing.T) {
+		repo := postgres.NewBatchRepository(db, logrus.New())
+		batch := &models.TransferBatch{
+			SenderID:    "user1",
+			Mode:        models.BatchModeBestEffort,
+			TotalCount:  1,
+			TotalAmount: decimal.RequireFromString("12.5"),
+			Items: []models.TransferBatchItem{
+				{Index: 0, ReceiverID: "user2", Amount: decimal.RequireFromString("12.5"), Status: models.BatchItemSucceeded},
+			},
+		}
+		require.NoError(t, repo.CreateBatch(ctx, batch))
+
+		got, err := repo.GetBatch(ctx, batch.ID)
+		require.NoError(t, err)
+		require.True(t, got.TotalAmount.Equal(batch.TotalAmount))
+		require.Len(t, got.Items, 1)
+	})
+
+	t.Run("idempotency keys", func(t *testing.T) {
+		repo := postgres.NewIdempotencyRepository(db, time.Hour, logrus.New())
+		record := &models.IdempotencyRecord{UserID: "user1", Key: "key1", Operation: "deposit", RequestHash: "hash"}
+
+		_, reserved, err := repo.Reserve(ctx, record)
+		require.NoError(t, err)
+		require.True(t, reserved)
+		require.NoError(t, repo.Complete(ctx, "user1", "key1"))
+
+		got, reserved, err := repo.Reserve(ctx, record)
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, models.IdempotencyCompleted, got.Status)
+	})
+}
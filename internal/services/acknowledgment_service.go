@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const maxAcknowledgmentMessage = 280
+
+var ErrInvalidAcknowledgment = errors.New("message must be at most 280 characters")
+
+// AcknowledgmentService lets receivers acknowledge the transfers they
+// received, with a thank-you message for the sender
+type AcknowledgmentService struct {
+	repo   postgres.AcknowledgmentRepository
+	logger *logrus.Logger
+}
+
+func NewAcknowledgmentService(repo postgres.AcknowledgmentRepository, logger *logrus.Logger) *AcknowledgmentService {
+	return &AcknowledgmentService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Acknowledge records receiverID acknowledging the transfer transactionID
+// with message, trimmed; a blank message is left out
+func (s *AcknowledgmentService) Acknowledge(ctx context.Context, receiverID, transactionID, message string) (*models.TransferAcknowledgment, error) {
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxAcknowledgmentMessage {
+		return nil, ErrInvalidAcknowledgment
+	}
+
+	var text *string
+	if message != "" {
+		text = &message
+	}
+	return s.repo.AcknowledgeTransfer(ctx, receiverID, transactionID, text)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestAcknowledgmentService_Acknowledge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAcknowledgmentRepository(ctrl)
+	service := NewAcknowledgmentService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("trims the message", func(t *testing.T) {
+		mockRepo.EXPECT().AcknowledgeTransfer(ctx, "user2", "12", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, message *string) (*models.TransferAcknowledgment, error) {
+				require.NotNil(t, message)
+				assert.Equal(t, "Thanks!", *message)
+				return &models.TransferAcknowledgment{TransactionID: "12", Message: message}, nil
+			})
+
+		acknowledgment, err := service.Acknowledge(ctx, "user2", "12", "  Thanks!\n")
+		require.NoError(t, err)
+		assert.Equal(t, "12", acknowledgment.TransactionID)
+	})
+
+	t.Run("leaves a blank message out", func(t *testing.T) {
+		mockRepo.EXPECT().AcknowledgeTransfer(ctx, "user2", "12", nil).Return(&models.TransferAcknowledgment{TransactionID: "12"}, nil)
+
+		_, err := service.Acknowledge(ctx, "user2", "12", "   ")
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects a long message", func(t *testing.T) {
+		_, err := service.Acknowledge(ctx, "user2", "12", strings.Repeat("é", 281))
+		assert.ErrorIs(t, err, ErrInvalidAcknowledgment)
+	})
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const analyticsMonthLayout = "2006-01"
+
+var ErrInvalidMonth = errors.New("month must be formatted as YYYY-MM")
+
+// AnalyticsService summarises the ledger of wallets by calendar month
+type AnalyticsService struct {
+	repo   postgres.AnalyticsRepository
+	logger *logrus.Logger
+}
+
+func NewAnalyticsService(repo postgres.AnalyticsRepository, logger *logrus.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Monthly summarises the income, spending and round-ups of userID over month,
+// formatted as YYYY-MM in UTC. An empty month is the current one.
+func (s *AnalyticsService) Monthly(ctx context.Context, userID, month string) (*models.MonthlyAnalytics, error) {
+	from := time.Now().UTC()
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month != "" {
+		parsed, err := time.Parse(analyticsMonthLayout, month)
+		if err != nil {
+			return nil, ErrInvalidMonth
+		}
+		from = parsed
+	}
+
+	summary, err := s.repo.Summarize(ctx, userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	summary.Month = from.Format(analyticsMonthLayout)
+	return summary, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestAnalyticsService_Monthly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAnalyticsRepository(ctrl)
+	service := NewAnalyticsService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("given month", func(t *testing.T) {
+		from := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+		mockRepo.EXPECT().Summarize(ctx, "user1", from, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)).
+			Return(&models.MonthlyAnalytics{UserID: "user1"}, nil)
+
+		summary, err := service.Monthly(ctx, "user1", "2026-12")
+		require.NoError(t, err)
+		assert.Equal(t, "2026-12", summary.Month)
+	})
+
+	t.Run("current month by default", func(t *testing.T) {
+		mockRepo.EXPECT().Summarize(ctx, "user1", gomock.Any(), gomock.Any()).Return(&models.MonthlyAnalytics{UserID: "user1"}, nil)
+
+		summary, err := service.Monthly(ctx, "user1", "")
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().UTC().Format("2006-01"), summary.Month)
+	})
+
+	t.Run("invalid month", func(t *testing.T) {
+		_, err := service.Monthly(ctx, "user1", "2026-13")
+		assert.ErrorIs(t, err, ErrInvalidMonth)
+	})
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/auth"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	service := NewAPIKeyService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1"})
+
+	t.Run("key is returned once and only its hash stored", func(t *testing.T) {
+		var stored *models.APIKey
+		mockRepo.EXPECT().CreateAPIKey(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key *models.APIKey) error {
+			stored = key
+			return nil
+		})
+
+		key, secret, err := service.Create(ctx, models.APIKey{Name: " settlement ", Subject: "settlement", Scopes: []string{auth.ScopeMoneyMovement}})
+		require.NoError(t, err)
+		assert.Equal(t, "settlement", key.Name)
+		assert.Equal(t, "admin1", stored.CreatedBy)
+		assert.Equal(t, []string{}, stored.Roles)
+
+		id, plain, err := auth.ParseAPIKey(secret)
+		require.NoError(t, err)
+		assert.Equal(t, stored.ID, id)
+		assert.True(t, auth.MatchAPIKeySecret(plain, stored.SecretHash))
+	})
+
+	past := time.Now().Add(-time.Hour)
+	for name, key := range map[string]models.APIKey{
+		"no scopes":       {Name: "reporting", Subject: "reporting"},
+		"unknown scope":   {Name: "reporting", Subject: "reporting", Scopes: []string{"write"}},
+		"unknown role":    {Name: "reporting", Subject: "reporting", Scopes: []string{auth.ScopeRead}, Roles: []string{"root"}},
+		"no subject":      {Name: "reporting", Scopes: []string{auth.ScopeRead}},
+		"already expired": {Name: "reporting", Subject: "reporting", Scopes: []string{auth.ScopeRead}, ExpiresAt: &past},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := service.Create(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidAPIKeyRequest)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	service := NewAPIKeyService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	id, secret, hash := auth.NewAPIKey()
+	stored := func() *models.APIKey {
+		return &models.APIKey{ID: id, SecretHash: hash, Subject: "settlement", Roles: []string{auth.RoleInternal}, Scopes: []string{auth.ScopeMoneyMovement}}
+	}
+
+	t.Run("valid key", func(t *testing.T) {
+		mockRepo.EXPECT().GetAPIKey(ctx, id).Return(stored(), nil)
+		mockRepo.EXPECT().TouchAPIKey(ctx, id, gomock.Any()).Return(nil)
+
+		principal, err := service.Authenticate(ctx, secret)
+		require.NoError(t, err)
+		assert.Equal(t, auth.Principal{Subject: "settlement", Roles: []string{auth.RoleInternal}, APIKeyID: id, Scopes: []string{auth.ScopeMoneyMovement}}, principal)
+	})
+
+	t.Run("recent use is not written again", func(t *testing.T) {
+		key := stored()
+		recently := time.Now().Add(-time.Second)
+		key.LastUsedAt = &recently
+		mockRepo.EXPECT().GetAPIKey(ctx, id).Return(key, nil)
+
+		_, err := service.Authenticate(ctx, secret)
+		assert.NoError(t, err)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		mockRepo.EXPECT().GetAPIKey(ctx, id).Return(stored(), nil)
+
+		_, err := service.Authenticate(ctx, "wk_"+id+"_00")
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		key := stored()
+		revoked := time.Now()
+		key.RevokedAt = &revoked
+		mockRepo.EXPECT().GetAPIKey(ctx, id).Return(key, nil)
+
+		_, err := service.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		key := stored()
+		expired := time.Now().Add(-time.Minute)
+		key.ExpiresAt = &expired
+		mockRepo.EXPECT().GetAPIKey(ctx, id).Return(key, nil)
+
+		_, err := service.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		mockRepo.EXPECT().GetAPIKey(ctx, "0000000000000000").Return(nil, postgres.ErrAPIKeyNotFound)
+
+		_, err := service.Authenticate(ctx, "wk_0000000000000000_"+"ab")
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+
+	t.Run("malformed key", func(t *testing.T) {
+		_, err := service.Authenticate(ctx, "not-a-key")
+		assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+	})
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	defaultAuditListLimit = 50
+	maxAuditListLimit     = 500
+	// auditExportPageSize is the number of entries read from the audit log
+	// and sent to the client at a time
+	auditExportPageSize = 1000
+)
+
+var ErrInvalidAuditAction = errors.New("action must be one of deposit, withdrawal, transfer, fee, adjustment, limit_set, limit_removed")
+
+// AuditService lets admins search and export the audit log of balance and
+// limit changes
+type AuditService struct {
+	repo   postgres.AuditRepository
+	logger *logrus.Logger
+}
+
+func NewAuditService(repo postgres.AuditRepository, logger *logrus.Logger) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// List returns a page of entries matching filter, newest first, and the
+// entry ID to continue before, which is zero on the last page
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, int64, error) {
+	if err := validateAuditFilter(filter); err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditListLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditListLimit)
+
+	entries, err := s.repo.ListAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var nextBefore int64
+	if len(entries) == filter.Limit {
+		nextBefore = entries[len(entries)-1].ID
+	}
+	return entries, nextBefore, nil
+}
+
+// Validate checks filter before an export starts, while its errors can still
+// be reported as such
+func (s *AuditService) Validate(filter models.AuditFilter) error {
+	return validateAuditFilter(filter)
+}
+
+// Export writes every entry matching filter to out as CSV, newest first,
+// reading the audit log a page at a time and flushing each page to the
+// client. The limit of filter is ignored.
+func (s *AuditService) Export(ctx context.Context, filter models.AuditFilter, out io.Writer) error {
+	if err := validateAuditFilter(filter); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"id", "created_at", "action", "user_id", "target", "transaction_id", "before", "after",
+		"actor", "channel", "client_ip", "request_id", "reason"})
+	if err != nil {
+		return err
+	}
+
+	filter.Limit = auditExportPageSize
+	exported := 0
+	for {
+		page, err := s.repo.ListAuditEntries(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page {
+			if err := w.Write(auditRecord(entry)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if flusher, ok := out.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		exported += len(page)
+		if len(page) < auditExportPageSize {
+			break
+		}
+		filter.Before = page[len(page)-1].ID
+	}
+
+	s.logger.WithContext(ctx).WithField("entries", exported).Info("Audit log exported")
+	return nil
+}
+
+func validateAuditFilter(filter models.AuditFilter) error {
+	if filter.Action != nil && !slices.Contains(models.AuditActions, *filter.Action) {
+		return ErrInvalidAuditAction
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return ErrInvalidPeriod
+	}
+	return nil
+}
+
+func auditRecord(entry models.AuditEntry) []string {
+	return []string{
+		strconv.FormatInt(entry.ID, 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.Action,
+		csvCell(entry.UserID),
+		entry.Target,
+		entry.TransactionID,
+		auditValuesCell(entry.Before),
+		auditValuesCell(entry.After),
+		csvCell(entry.Actor),
+		entry.Channel,
+		entry.ClientIP,
+		csvCell(entry.RequestID),
+		csvCell(entry.Reason),
+	}
+}
+
+// auditValuesCell encodes values as JSON, empty when there are none
+func auditValuesCell(values models.AuditValues) string {
+	if values == nil {
+		return ""
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// csvCell keeps spreadsheets from evaluating client-supplied text starting
+// with = or a similar character as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestAuditService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAuditRepository(ctrl)
+	service := NewAuditService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("List applies the default limit and returns the next cursor", func(t *testing.T) {
+		entries := make([]models.AuditEntry, defaultAuditListLimit)
+		for i := range entries {
+			entries[i].ID = int64(100 - i)
+		}
+		mockRepo.EXPECT().ListAuditEntries(ctx, models.AuditFilter{Limit: defaultAuditListLimit}).Return(entries, nil)
+
+		got, nextBefore, err := service.List(ctx, models.AuditFilter{})
+		require.NoError(t, err)
+		assert.Len(t, got, defaultAuditListLimit)
+		assert.Equal(t, int64(51), nextBefore)
+	})
+
+	t.Run("List caps the limit", func(t *testing.T) {
+		mockRepo.EXPECT().ListAuditEntries(ctx, models.AuditFilter{Limit: maxAuditListLimit}).Return([]models.AuditEntry{{ID: 1}}, nil)
+
+		_, nextBefore, err := service.List(ctx, models.AuditFilter{Limit: 10000})
+		require.NoError(t, err)
+		assert.Zero(t, nextBefore)
+	})
+
+	t.Run("List rejects unknown actions and empty periods", func(t *testing.T) {
+		action := "refund"
+		_, _, err := service.List(ctx, models.AuditFilter{Action: &action})
+		assert.ErrorIs(t, err, ErrInvalidAuditAction)
+
+		now := time.Now()
+		_, _, err = service.List(ctx, models.AuditFilter{From: &now, To: &now})
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+
+	t.Run("Export pages through the audit log", func(t *testing.T) {
+		userID := "user1"
+		first := make([]models.AuditEntry, auditExportPageSize)
+		for i := range first {
+			first[i] = models.AuditEntry{ID: int64(auditExportPageSize + 1 - i), Action: models.AuditDeposit, UserID: userID}
+		}
+		last := []models.AuditEntry{{
+			ID:        1,
+			Action:    models.AuditAdjustment,
+			UserID:    userID,
+			Before:    models.AuditValues{"balance": decimal.NewFromInt(10)},
+			After:     models.AuditValues{"balance": decimal.NewFromInt(5)},
+			Actor:     "=admin",
+			ClientIP:  "10.0.0.1",
+			Reason:    "chargeback",
+			CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		}}
+		gomock.InOrder(
+			mockRepo.EXPECT().ListAuditEntries(ctx, models.AuditFilter{UserID: &userID, Limit: auditExportPageSize}).Return(first, nil),
+			mockRepo.EXPECT().ListAuditEntries(ctx, models.AuditFilter{UserID: &userID, Before: 2, Limit: auditExportPageSize}).Return(last, nil),
+		)
+
+		var out bytes.Buffer
+		require.NoError(t, service.Export(ctx, models.AuditFilter{UserID: &userID, Limit: 5}, &out))
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, auditExportPageSize+2)
+		assert.Equal(t, "id", records[0][0])
+		assert.Equal(t, []string{"1", "2026-01-02T03:04:05Z", "adjustment", "user1", "", "", `{"balance":"10"}`, `{"balance":"5"}`,
+			"'=admin", "", "10.0.0.1", "", "chargeback"}, records[len(records)-1])
+	})
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// backgroundTaskTimeout bounds a task started on behalf of a request, such
+// as refreshing a cached balance
+const backgroundTaskTimeout = 5 * time.Second
+
+// backgroundTasks runs work that outlives the request starting it. A task
+// keeps the values of the request context, such as its operation, but not
+// its deadline or cancellation; it runs for at most timeout and is cancelled
+// when the tasks are closed at shutdown.
+type backgroundTasks struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newBackgroundTasks(timeout time.Duration) *backgroundTasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundTasks{ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+// Go starts task unless the tasks are closed, in which case it is dropped
+func (b *backgroundTasks) Go(ctx context.Context, task func(context.Context)) bool {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return false
+	}
+	b.running.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.running.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.timeout)
+		defer cancel()
+		stop := context.AfterFunc(b.ctx, cancel)
+		defer stop()
+		task(ctx)
+	}()
+	return true
+}
+
+// Wait blocks until the running tasks have returned
+func (b *backgroundTasks) Wait() {
+	b.running.Wait()
+}
+
+// Close stops starting tasks and waits for the running ones. When ctx ends
+// first, the running tasks are cancelled and Close returns ctx's error once
+// they have returned.
+func (b *backgroundTasks) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
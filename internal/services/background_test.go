@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundTasks(t *testing.T) {
+	t.Run("outlives the request", func(t *testing.T) {
+		tasks := newBackgroundTasks(time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		result := make(chan error, 1)
+		require.True(t, tasks.Go(ctx, func(ctx context.Context) {
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			result <- ctx.Err()
+		}))
+		tasks.Wait()
+		assert.NoError(t, <-result)
+	})
+
+	t.Run("close waits for running tasks and drops new ones", func(t *testing.T) {
+		tasks := newBackgroundTasks(time.Second)
+		finished := make(chan struct{})
+		tasks.Go(context.Background(), func(ctx context.Context) {
+			time.Sleep(10 * time.Millisecond)
+			close(finished)
+		})
+
+		require.NoError(t, tasks.Close(context.Background()))
+		select {
+		case <-finished:
+		default:
+			t.Fatal("Close returned before the task finished")
+		}
+		assert.False(t, tasks.Go(context.Background(), func(context.Context) {}))
+	})
+
+	t.Run("close cancels tasks when shutdown times out", func(t *testing.T) {
+		tasks := newBackgroundTasks(time.Minute)
+		tasks.Go(context.Background(), func(ctx context.Context) {
+			<-ctx.Done()
+		})
+
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, tasks.Close(shutdown), context.DeadlineExceeded)
+	})
+}
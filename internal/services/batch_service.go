@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/apierror"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	ErrUnsupportedBatchMode     = errors.New("unsupported batch mode")
+	ErrEmptyBatch               = errors.New("batch must contain at least one transfer")
+	ErrAtomicBatchesUnsupported = errors.New("atomic batches are not supported")
+)
+
+// BatchTransferItem is a single transfer requested as part of a batch
+type BatchTransferItem struct {
+	ReceiverID string
+	Amount     decimal.Decimal
+}
+
+type BatchService struct {
+	wallets *WalletService
+	repo    postgres.BatchRepository
+	logger  *logrus.Logger
+	// atomic enables the atomic mode, which needs Postgres row locking
+	atomic bool
+}
+
+// BatchServiceOption configures optional BatchService features
+type BatchServiceOption func(*BatchService)
+
+// WithAtomicBatches enables batches applied in a single database transaction
+func WithAtomicBatches() BatchServiceOption {
+	return func(s *BatchService) {
+		s.atomic = true
+	}
+}
+
+func NewBatchService(wallets *WalletService, repo postgres.BatchRepository, logger *logrus.Logger, opts ...BatchServiceOption) *BatchService {
+	s := &BatchService{
+		wallets: wallets,
+		repo:    repo,
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// BatchTransfer executes the transfers of a batch and records a summary that
+// can be retrieved later through GetBatch. In best-effort mode each transfer
+// is applied independently: failures are reported per item and do not roll
+// back the transfers that succeeded. In atomic mode the batch is applied in a
+// single database transaction and the first failing transfer, reported as a
+// *postgres.BatchItemError, rejects the whole batch.
+func (s *BatchService) BatchTransfer(ctx context.Context, senderID, mode string, items []BatchTransferItem) (*models.TransferBatch, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if err := s.wallets.checkKillSwitch(ctx, models.KillSwitchTransfers); err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"senderID": senderID,
+		"mode":     mode,
+		"count":    len(items),
+	})
+	logger.Debug("Processing batch transfer")
+
+	batch := &models.TransferBatch{
+		SenderID:   senderID,
+		Mode:       mode,
+		TotalCount: len(items),
+		Items:      make([]models.TransferBatchItem, 0, len(items)),
+	}
+
+	// The transfers of the batch are recorded as arriving through the batch
+	// channel, on behalf of the caller
+	itemCtx := operation.WithChannel(ctx, operation.ChannelBatch)
+	switch mode {
+	case models.BatchModeBestEffort:
+		return s.bestEffort(ctx, itemCtx, logger, batch, items)
+	case models.BatchModeAtomic:
+		if !s.atomic {
+			return nil, ErrAtomicBatchesUnsupported
+		}
+		return s.allOrNothing(itemCtx, logger, batch, items)
+	default:
+		return nil, ErrUnsupportedBatchMode
+	}
+}
+
+func (s *BatchService) bestEffort(ctx, itemCtx context.Context, logger *logrus.Entry, batch *models.TransferBatch, items []BatchTransferItem) (*models.TransferBatch, error) {
+	for i, item := range items {
+		result := models.TransferBatchItem{
+			Index:      i,
+			ReceiverID: item.ReceiverID,
+			Amount:     item.Amount,
+			Status:     models.BatchItemSucceeded,
+		}
+
+		if err := s.wallets.Transfer(itemCtx, batch.SenderID, item.ReceiverID, item.Amount, nil); err != nil {
+			code, message := batchErrorCode(err), err.Error()
+			result.Status = models.BatchItemFailed
+			result.ErrorCode = &code
+			result.Error = &message
+			batch.FailedCount++
+		} else {
+			batch.SucceededCount++
+			batch.TotalAmount = batch.TotalAmount.Add(item.Amount)
+		}
+
+		batch.Items = append(batch.Items, result)
+	}
+
+	if err := s.repo.CreateBatch(ctx, batch); err != nil {
+		logger.WithError(err).Error("BatchTransfer - Persist batch summary failed")
+		return batch, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"batchID":   batch.ID,
+		"succeeded": batch.SucceededCount,
+		"failed":    batch.FailedCount,
+	}).Info("Batch transfer processed")
+	return batch, nil
+}
+
+// allOrNothing checks the limits of the sender against the whole batch, then
+// applies it in one database transaction
+func (s *BatchService) allOrNothing(ctx context.Context, logger *logrus.Entry, batch *models.TransferBatch, items []BatchTransferItem) (*models.TransferBatch, error) {
+	amounts := make([]decimal.Decimal, 0, len(items))
+	userIDs := []string{batch.SenderID}
+	for i, item := range items {
+		if err := s.wallets.checkAmount(ctx, batch.SenderID, item.Amount); err != nil {
+			return nil, &postgres.BatchItemError{Index: i, ReceiverID: item.ReceiverID, Err: err}
+		}
+		batch.Items = append(batch.Items, models.TransferBatchItem{
+			Index:      i,
+			ReceiverID: item.ReceiverID,
+			Amount:     item.Amount,
+		})
+		amounts = append(amounts, item.Amount)
+		userIDs = append(userIDs, item.ReceiverID)
+	}
+
+	if s.wallets.limits != nil {
+		if err := s.wallets.limits.CheckBatch(ctx, batch.SenderID, models.LimitOperationTransfer, amounts); err != nil {
+			return nil, err
+		}
+	}
+	for i, item := range items {
+		if err := s.wallets.checkCompliance(ctx, item.ReceiverID, models.ComplianceTransferIn, item.Amount, ""); err != nil {
+			return nil, &postgres.BatchItemError{Index: i, ReceiverID: item.ReceiverID, Err: err}
+		}
+	}
+
+	unlock, err := s.wallets.lockWallets(ctx, userIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	err = s.wallets.instrument("batch_transfer", func() error {
+		return s.repo.ApplyAtomic(ctx, batch)
+	})
+	if err != nil {
+		logger.WithError(err).Warn("BatchTransfer - Atomic batch rejected")
+		return nil, err
+	}
+	s.wallets.refreshBalances(ctx, "batch_transfer", userIDs...)
+
+	logger.WithFields(logrus.Fields{
+		"batchID": batch.ID,
+		"total":   batch.TotalAmount,
+	}).Info("Batch transfer processed")
+	return batch, nil
+}
+
+func (s *BatchService) GetBatch(ctx context.Context, batchID string) (*models.TransferBatch, error) {
+	return s.repo.GetBatch(ctx, batchID)
+}
+
+// batchErrorCode maps a transfer error to the API error code reported on
+// failed batch items
+func batchErrorCode(err error) string {
+	switch {
+	case errors.Is(err, postgres.ErrInsufficientBalance):
+		return apierror.CodeInsufficientBalance
+	case errors.Is(err, postgres.ErrUserNotFound):
+		return apierror.CodeUserNotFound
+	case errors.Is(err, postgres.ErrInvalidAmount):
+		return apierror.CodeInvalidAmount
+	case errors.Is(err, postgres.ErrInvalidUserID):
+		return apierror.CodeInvalidUserID
+	case errors.Is(err, postgres.ErrWalletFrozen):
+		return apierror.CodeWalletFrozen
+	case errors.Is(err, postgres.ErrWalletClosed):
+		return apierror.CodeWalletClosed
+	case errors.Is(err, ErrAmountExceedsLimit):
+		return apierror.CodeAmountExceedsLimit
+	case errors.Is(err, ErrLimitExceeded):
+		return apierror.CodeLimitExceeded
+	default:
+		return apierror.CodeInternal
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestBatchService_BatchTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockBatchRepo := mocks.NewMockBatchRepository(ctrl)
+	logger := logrus.New()
+	service := NewBatchService(NewWalletService(mockRepo, mockCache, logger), mockBatchRepo, logger)
+
+	t.Run("best effort with partial failure", func(t *testing.T) {
+		ctx := context.Background()
+		batchCtx := withOperation(operation.Operation{Channel: operation.ChannelBatch})
+		mockRepo.EXPECT().Transfer(batchCtx, "user1", "user2", decimal.NewFromInt(10), nil).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(batchCtx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(batchCtx, "user2").Return(nil)
+		mockRepo.EXPECT().Transfer(batchCtx, "user1", "user3", decimal.NewFromInt(500), nil).Return(postgres.ErrInsufficientBalance)
+		mockBatchRepo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, batch *models.TransferBatch) error {
+			batch.ID = "1"
+			return nil
+		})
+
+		batch, err := service.BatchTransfer(ctx, "user1", models.BatchModeBestEffort, []BatchTransferItem{
+			{ReceiverID: "user2", Amount: decimal.NewFromInt(10)},
+			{ReceiverID: "user3", Amount: decimal.NewFromInt(500)},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", batch.ID)
+		assert.Equal(t, 2, batch.TotalCount)
+		assert.Equal(t, 1, batch.SucceededCount)
+		assert.Equal(t, 1, batch.FailedCount)
+		assert.True(t, decimal.NewFromInt(10).Equal(batch.TotalAmount))
+		assert.Equal(t, models.BatchItemSucceeded, batch.Items[0].Status)
+		assert.Equal(t, models.BatchItemFailed, batch.Items[1].Status)
+		assert.Equal(t, "INSUFFICIENT_BALANCE", *batch.Items[1].ErrorCode)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		_, err := service.BatchTransfer(context.Background(), "user1", models.BatchModeBestEffort, nil)
+		assert.ErrorIs(t, err, ErrEmptyBatch)
+	})
+
+	t.Run("unsupported mode", func(t *testing.T) {
+		_, err := service.BatchTransfer(context.Background(), "user1", "unknown", []BatchTransferItem{{ReceiverID: "user2", Amount: decimal.NewFromInt(1)}})
+		assert.ErrorIs(t, err, ErrUnsupportedBatchMode)
+	})
+
+	t.Run("atomic batches need the option", func(t *testing.T) {
+		_, err := service.BatchTransfer(context.Background(), "user1", models.BatchModeAtomic, []BatchTransferItem{{ReceiverID: "user2", Amount: decimal.NewFromInt(1)}})
+		assert.ErrorIs(t, err, ErrAtomicBatchesUnsupported)
+	})
+
+	t.Run("persist summary error", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Transfer(gomock.Any(), "user1", "user2", decimal.NewFromInt(10), nil).Return(postgres.ErrUserNotFound)
+		mockBatchRepo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(errors.New("db error"))
+
+		batch, err := service.BatchTransfer(ctx, "user1", models.BatchModeBestEffort, []BatchTransferItem{{ReceiverID: "user2", Amount: decimal.NewFromInt(10)}})
+		assert.ErrorContains(t, err, "db error")
+		assert.Equal(t, "USER_NOT_FOUND", *batch.Items[0].ErrorCode)
+	})
+}
+
+func TestBatchService_AtomicBatchTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockBatchRepo := mocks.NewMockBatchRepository(ctrl)
+	mockLimitsRepo := mocks.NewMockLimitsRepository(ctrl)
+	logger := logrus.New()
+	wallets := NewWalletService(mockRepo, mockCache, logger, WithLimits(NewLimitsService(mockLimitsRepo, logger)))
+	service := NewBatchService(wallets, mockBatchRepo, logger, WithAtomicBatches())
+	items := []BatchTransferItem{
+		{ReceiverID: "user2", Amount: decimal.NewFromInt(10)},
+		{ReceiverID: "user3", Amount: decimal.NewFromInt(20)},
+	}
+
+	t.Run("applied in one transaction", func(t *testing.T) {
+		ctx := context.Background()
+		batchCtx := withOperation(operation.Operation{Channel: operation.ChannelBatch})
+		mockLimitsRepo.EXPECT().ListLimits(batchCtx, "user1").Return(nil, nil)
+		mockBatchRepo.EXPECT().ApplyAtomic(batchCtx, gomock.Any()).DoAndReturn(func(_ context.Context, batch *models.TransferBatch) error {
+			assert.Len(t, batch.Items, 2)
+			batch.ID, batch.SucceededCount = "2", 2
+			return nil
+		})
+		mockCache.EXPECT().InvalidateBalance(batchCtx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(batchCtx, "user2").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(batchCtx, "user3").Return(nil)
+
+		batch, err := service.BatchTransfer(ctx, "user1", models.BatchModeAtomic, items)
+		assert.NoError(t, err)
+		assert.Equal(t, "2", batch.ID)
+	})
+
+	t.Run("limits apply to the whole batch", func(t *testing.T) {
+		mockLimitsRepo.EXPECT().ListLimits(gomock.Any(), "user1").Return([]models.Limit{
+			{Operation: models.LimitOperationTransfer, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(100)},
+		}, nil)
+		mockLimitsRepo.EXPECT().Usage(gomock.Any(), "user1", models.LimitOperationTransfer, gomock.Any()).
+			Return(models.LimitUsage{Amount: decimal.NewFromInt(75), Count: 3}, nil)
+
+		_, err := service.BatchTransfer(context.Background(), "user1", models.BatchModeAtomic, items)
+		var exceeded *LimitExceededError
+		assert.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, "25", exceeded.Remaining.String())
+	})
+
+	t.Run("failing transfer rejects the batch", func(t *testing.T) {
+		mockLimitsRepo.EXPECT().ListLimits(gomock.Any(), "user1").Return(nil, nil)
+		mockBatchRepo.EXPECT().ApplyAtomic(gomock.Any(), gomock.Any()).
+			Return(&postgres.BatchItemError{Index: 1, ReceiverID: "user3", Err: postgres.ErrWalletFrozen})
+
+		batch, err := service.BatchTransfer(context.Background(), "user1", models.BatchModeAtomic, items)
+		assert.Nil(t, batch)
+		assert.ErrorIs(t, err, postgres.ErrWalletFrozen)
+	})
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var ErrInvalidCurrency = errors.New("currency code is required and decimals must be between 0 and 8")
+
+// maxCurrencyDecimals is the scale amounts are stored with
+const maxCurrencyDecimals = 8
+
+// DefaultBootstrapPlan is what a new environment starts with: the fee,
+// treasury and suspense accounts, the supported currencies and conservative
+// default limits to be tuned through the admin API
+func DefaultBootstrapPlan() models.BootstrapPlan {
+	return models.BootstrapPlan{
+		SystemAccounts: []string{
+			models.SystemAccountFees,
+			models.SystemAccountTreasury,
+			models.SystemAccountSuspense,
+		},
+		Currencies: []models.Currency{
+			{Code: "BTC", Decimals: 8},
+			{Code: "ETH", Decimals: 8},
+			{Code: "USDC", Decimals: 6},
+			{Code: "USDT", Decimals: 6},
+		},
+		Limits: []models.Limit{
+			{Operation: models.LimitOperationWithdrawal, Kind: models.LimitSingleAmount, Value: decimal.NewFromInt(10000)},
+			{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(50000)},
+			{Operation: models.LimitOperationWithdrawal, Kind: models.LimitHourlyCount, Value: decimal.NewFromInt(20)},
+			{Operation: models.LimitOperationTransfer, Kind: models.LimitSingleAmount, Value: decimal.NewFromInt(10000)},
+			{Operation: models.LimitOperationTransfer, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(50000)},
+			{Operation: models.LimitOperationTransfer, Kind: models.LimitHourlyCount, Value: decimal.NewFromInt(100)},
+		},
+	}
+}
+
+// BootstrapService prepares a fresh database so new environments are
+// reproducible without manual inserts. Running it again only creates what is
+// missing.
+type BootstrapService struct {
+	repo   postgres.BootstrapRepository
+	logger *logrus.Logger
+}
+
+func NewBootstrapService(repo postgres.BootstrapRepository, logger *logrus.Logger) *BootstrapService {
+	return &BootstrapService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Run validates the plan and creates the records it lists that do not exist
+// yet. Limits are recorded as set by the actor of the operation.
+func (s *BootstrapService) Run(ctx context.Context, plan models.BootstrapPlan) (models.BootstrapResult, error) {
+	for _, currency := range plan.Currencies {
+		if currency.Code == "" || currency.Decimals < 0 || currency.Decimals > maxCurrencyDecimals {
+			return models.BootstrapResult{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency.Code)
+		}
+	}
+
+	op, _ := operation.From(ctx)
+	limits := make([]models.Limit, 0, len(plan.Limits))
+	for _, limit := range plan.Limits {
+		if err := validateLimit(limit.Operation, limit.Kind, limit.Value); err != nil {
+			return models.BootstrapResult{}, fmt.Errorf("%s %s: %w", limit.Operation, limit.Kind, err)
+		}
+		if limit.Reason == "" {
+			limit.Reason = "bootstrap default"
+		}
+		limit.UpdatedBy = op.Actor
+		limits = append(limits, limit)
+	}
+	plan.Limits = limits
+
+	result, err := s.repo.Bootstrap(ctx, plan)
+	if err != nil {
+		return models.BootstrapResult{}, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"systemAccounts": result.SystemAccounts,
+		"currencies":     result.Currencies,
+		"limits":         result.Limits,
+	}).Info("Bootstrap completed")
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestBootstrapService_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBootstrapRepository(ctrl)
+	service := NewBootstrapService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "bootstrap", Channel: operation.ChannelJob})
+
+	t.Run("default plan", func(t *testing.T) {
+		mockRepo.EXPECT().Bootstrap(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, plan models.BootstrapPlan) (models.BootstrapResult, error) {
+			assert.Len(t, plan.SystemAccounts, 3)
+			for _, limit := range plan.Limits {
+				assert.Empty(t, limit.UserID)
+				assert.Equal(t, "bootstrap", limit.UpdatedBy)
+				assert.Equal(t, "bootstrap default", limit.Reason)
+			}
+			return models.BootstrapResult{SystemAccounts: 3, Currencies: len(plan.Currencies), Limits: len(plan.Limits)}, nil
+		})
+
+		result, err := service.Run(ctx, DefaultBootstrapPlan())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.SystemAccounts)
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		_, err := service.Run(ctx, models.BootstrapPlan{Currencies: []models.Currency{{Code: "DOGE", Decimals: 10}}})
+		assert.ErrorIs(t, err, ErrInvalidCurrency)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := service.Run(ctx, models.BootstrapPlan{Limits: []models.Limit{
+			{Operation: models.LimitOperationTransfer, Kind: models.LimitDailyCount, Value: decimal.RequireFromString("2.5")},
+		}})
+		assert.ErrorIs(t, err, ErrInvalidLimitValue)
+	})
+}
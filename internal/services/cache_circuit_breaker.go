@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// Cache circuit states
+const (
+	CircuitClosed   = "closed"
+	CircuitHalfOpen = "half-open"
+	CircuitOpen     = "open"
+)
+
+// ErrCacheCircuitOpen is returned instead of calling the cache while its
+// circuit is open
+var ErrCacheCircuitOpen = errors.New("cache circuit open")
+
+// CacheCircuitConfig configures when the cache circuit opens and closes
+type CacheCircuitConfig struct {
+	// Failures is the number of consecutive failed cache calls opening the
+	// circuit
+	Failures int
+	// OpenTimeout is how long the circuit stays open before one call probes
+	// the cache again
+	OpenTimeout time.Duration
+}
+
+// BreakableCache is a cache a CacheCircuitBreaker can guard
+type BreakableCache interface {
+	redis.CacheRepository
+	CacheTTLShortener
+}
+
+// CacheCircuitBreaker stops calling the balance cache after consecutive
+// failures, so a Redis outage costs each request an immediate
+// ErrCacheCircuitOpen instead of a timeout. Callers already treat cache
+// errors as misses: balances are read from the database and failed
+// invalidations are retried. After OpenTimeout one call probes the cache;
+// its success closes the circuit and its failure opens it again.
+//
+// Misses, balances being loaded elsewhere and cancelled calls are answers,
+// not failures.
+type CacheCircuitBreaker struct {
+	cache   BreakableCache
+	config  CacheCircuitConfig
+	metrics *metrics.Metrics
+	logger  *logrus.Logger
+	now     func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// probing is set while the call probing a half-open circuit runs
+	probing bool
+}
+
+func NewCacheCircuitBreaker(cache BreakableCache, config CacheCircuitConfig, m *metrics.Metrics, logger *logrus.Logger) *CacheCircuitBreaker {
+	m.SetCacheCircuitState(circuitStateValue(CircuitClosed))
+	return &CacheCircuitBreaker{
+		cache:   cache,
+		config:  config,
+		metrics: m,
+		logger:  logger,
+		now:     time.Now,
+		state:   CircuitClosed,
+	}
+}
+
+// State returns the current state of the circuit
+func (b *CacheCircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Check fails while the circuit is open, for the readiness probe
+func (b *CacheCircuitBreaker) Check(ctx context.Context) error {
+	if b.State() == CircuitOpen {
+		return ErrCacheCircuitOpen
+	}
+	return nil
+}
+
+func (b *CacheCircuitBreaker) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	if err := b.allow(ctx); err != nil {
+		return decimal.Zero, err
+	}
+	balance, err := b.cache.GetBalance(ctx, userID)
+	b.record(ctx, err)
+	return balance, err
+}
+
+func (b *CacheCircuitBreaker) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error {
+	if err := b.allow(ctx); err != nil {
+		return err
+	}
+	err := b.cache.SetBalance(ctx, userID, balance, ttl)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *CacheCircuitBreaker) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	if err := b.allow(ctx); err != nil {
+		return false, err
+	}
+	stored, err := b.cache.SetVersionedBalance(ctx, userID, balance, version, ttl)
+	b.record(ctx, err)
+	return stored, err
+}
+
+func (b *CacheCircuitBreaker) InvalidateBalance(ctx context.Context, userID string) error {
+	if err := b.allow(ctx); err != nil {
+		return err
+	}
+	err := b.cache.InvalidateBalance(ctx, userID)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *CacheCircuitBreaker) ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error) {
+	if err := b.allow(ctx); err != nil {
+		return decimal.Zero, err
+	}
+	balance, err := b.cache.ReadThrough(ctx, userID)
+	b.record(ctx, err)
+	return balance, err
+}
+
+func (b *CacheCircuitBreaker) ShortenBalanceTTL(ctx context.Context, userID string, ttl time.Duration) error {
+	if err := b.allow(ctx); err != nil {
+		return err
+	}
+	err := b.cache.ShortenBalanceTTL(ctx, userID, ttl)
+	b.record(ctx, err)
+	return err
+}
+
+// allow admits a call unless the circuit is open, or half-open with its
+// probe running. The first call after OpenTimeout becomes the probe.
+func (b *CacheCircuitBreaker) allow(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.config.OpenTimeout {
+			b.metrics.RecordCacheCircuitRejection()
+			return ErrCacheCircuitOpen
+		}
+		b.setState(ctx, CircuitHalfOpen)
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			b.metrics.RecordCacheCircuitRejection()
+			return ErrCacheCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record counts the outcome of an admitted call. Any answer from the cache
+// closes the circuit; a failure opens it once Failures follow each other,
+// or at once when probing.
+func (b *CacheCircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !cacheFailed(err) {
+		b.failures = 0
+		b.probing = false
+		if b.state != CircuitClosed {
+			b.setState(ctx, CircuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.config.Failures) {
+		b.probing = false
+		b.openedAt = b.now()
+		b.setState(ctx, CircuitOpen)
+	}
+}
+
+// setState moves the circuit to state; b.mu must be held
+func (b *CacheCircuitBreaker) setState(ctx context.Context, state string) {
+	logger := b.logger.WithContext(ctx).WithFields(logrus.Fields{"from": b.state, "to": state})
+	switch state {
+	case CircuitOpen:
+		logger.WithField("failures", b.failures).Error("Cache circuit opened, serving balances from the database")
+	case CircuitClosed:
+		logger.Info("Cache circuit closed")
+	}
+	b.state = state
+	b.metrics.SetCacheCircuitState(circuitStateValue(state))
+}
+
+// cacheFailed reports whether err means the cache could not answer
+func cacheFailed(err error) bool {
+	return err != nil &&
+		!errors.Is(err, goredis.Nil) &&
+		!errors.Is(err, redis.ErrBalanceLoading) &&
+		!errors.Is(err, redis.ErrInvalidUserID) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// circuitStateValue is the value of state in the wallet_cache_circuit_state
+// metric
+func circuitStateValue(state string) int {
+	switch state {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	default:
+		return 0
+	}
+}
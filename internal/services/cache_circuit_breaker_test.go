@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/mocks"
+)
+
+// breakableCache adds TTL shortening to the mocked cache
+type breakableCache struct {
+	*mocks.MockCacheRepository
+	ttlShortenRecorder
+}
+
+func TestCacheCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	redisErr := errors.New("i/o timeout")
+	config := CacheCircuitConfig{Failures: 3, OpenTimeout: 30 * time.Second}
+
+	newBreaker := func(t *testing.T) (*CacheCircuitBreaker, *mocks.MockCacheRepository, *time.Time) {
+		mockCache := mocks.NewMockCacheRepository(gomock.NewController(t))
+		breaker := NewCacheCircuitBreaker(&breakableCache{MockCacheRepository: mockCache}, config, nil, logrus.New())
+		now := time.Now()
+		breaker.now = func() time.Time { return now }
+		return breaker, mockCache, &now
+	}
+
+	t.Run("consecutive failures open the circuit", func(t *testing.T) {
+		breaker, mockCache, _ := newBreaker(t)
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, redisErr).Times(3)
+
+		for i := 0; i < 3; i++ {
+			_, err := breaker.ReadThrough(ctx, "user1")
+			require.ErrorIs(t, err, redisErr)
+		}
+		assert.Equal(t, CircuitOpen, breaker.State())
+		assert.ErrorIs(t, breaker.Check(ctx), ErrCacheCircuitOpen)
+
+		// The cache is not called while the circuit is open
+		_, err := breaker.ReadThrough(ctx, "user1")
+		assert.ErrorIs(t, err, ErrCacheCircuitOpen)
+		assert.ErrorIs(t, breaker.InvalidateBalance(ctx, "user1"), ErrCacheCircuitOpen)
+	})
+
+	t.Run("misses are not failures", func(t *testing.T) {
+		breaker, mockCache, _ := newBreaker(t)
+		gomock.InOrder(
+			mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, redisErr).Times(2),
+			mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, goredis.Nil),
+			mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, redisErr).Times(2),
+		)
+
+		for i := 0; i < 5; i++ {
+			_, _ = breaker.ReadThrough(ctx, "user1")
+		}
+		assert.Equal(t, CircuitClosed, breaker.State())
+	})
+
+	t.Run("a probe after the open timeout closes the circuit", func(t *testing.T) {
+		breaker, mockCache, now := newBreaker(t)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(redisErr).Times(3)
+		for i := 0; i < 3; i++ {
+			_ = breaker.InvalidateBalance(ctx, "user1")
+		}
+
+		*now = now.Add(config.OpenTimeout)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		require.NoError(t, breaker.InvalidateBalance(ctx, "user1"))
+		assert.Equal(t, CircuitClosed, breaker.State())
+		assert.NoError(t, breaker.Check(ctx))
+	})
+
+	t.Run("a failed probe opens the circuit again", func(t *testing.T) {
+		breaker, mockCache, now := newBreaker(t)
+		mockCache.EXPECT().SetBalance(ctx, "user1", decimal.NewFromInt(10), time.Duration(0)).Return(redisErr).Times(4)
+		for i := 0; i < 3; i++ {
+			_ = breaker.SetBalance(ctx, "user1", decimal.NewFromInt(10), 0)
+		}
+
+		*now = now.Add(config.OpenTimeout)
+		assert.ErrorIs(t, breaker.SetBalance(ctx, "user1", decimal.NewFromInt(10), 0), redisErr)
+		assert.Equal(t, CircuitOpen, breaker.State())
+		assert.ErrorIs(t, breaker.SetBalance(ctx, "user1", decimal.NewFromInt(10), 0), ErrCacheCircuitOpen)
+	})
+
+	t.Run("only one probe runs at a time", func(t *testing.T) {
+		breaker, _, _ := newBreaker(t)
+		breaker.state = CircuitHalfOpen
+		breaker.probing = true
+
+		_, err := breaker.GetBalance(ctx, "user1")
+		assert.ErrorIs(t, err, ErrCacheCircuitOpen)
+	})
+}
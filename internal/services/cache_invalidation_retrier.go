@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// maxPendingInvalidations bounds the retry set, so a long Redis outage does
+// not grow it without limit. Balances failing beyond it are only bounded by
+// their cache TTL.
+const maxPendingInvalidations = 10000
+
+// CacheTTLShortener makes a cached balance expire sooner
+type CacheTTLShortener interface {
+	ShortenBalanceTTL(ctx context.Context, userID string, ttl time.Duration) error
+}
+
+// CacheInvalidationRetrier keeps a balance whose invalidation failed, on a
+// transient Redis error, from being served stale until its TTL expires. The
+// balance is recorded in a retry set and invalidated again on each interval;
+// until that succeeds or a newer balance is cached, the next read shortens
+// its TTL to staleTTL. Failed invalidations still return their error.
+//
+// The retry set is held in memory, as Redis is what is failing: a balance
+// read through another instance keeps its TTL until the retry succeeds.
+type CacheInvalidationRetrier struct {
+	redis.CacheRepository
+	shortener CacheTTLShortener
+	staleTTL  time.Duration
+	metrics   *metrics.Metrics
+	logger    *logrus.Logger
+
+	// pending maps the balances whose invalidation failed to whether their
+	// TTL was shortened since
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+func NewCacheInvalidationRetrier(cache redis.CacheRepository, shortener CacheTTLShortener, staleTTL time.Duration, m *metrics.Metrics, logger *logrus.Logger) *CacheInvalidationRetrier {
+	return &CacheInvalidationRetrier{
+		CacheRepository: cache,
+		shortener:       shortener,
+		staleTTL:        staleTTL,
+		metrics:         m,
+		logger:          logger,
+		pending:         make(map[string]bool),
+	}
+}
+
+// InvalidateBalance invalidates the cached balance of userID, recording it
+// for a retry when that fails
+func (r *CacheInvalidationRetrier) InvalidateBalance(ctx context.Context, userID string) error {
+	err := r.CacheRepository.InvalidateBalance(ctx, userID)
+	if errors.Is(err, redis.ErrInvalidUserID) {
+		return err
+	}
+	r.metrics.RecordCacheInvalidation(err)
+
+	if err == nil {
+		r.resolve(userID)
+		return nil
+	}
+
+	r.mu.Lock()
+	if _, ok := r.pending[userID]; ok || len(r.pending) < maxPendingInvalidations {
+		// A change after a shortened TTL shortens it again
+		r.pending[userID] = false
+	} else {
+		r.logger.WithContext(ctx).WithField("userID", userID).Warn("InvalidateBalance - Retry set is full, balance kept until its TTL expires")
+	}
+	count := len(r.pending)
+	r.mu.Unlock()
+
+	r.metrics.SetPendingInvalidations(count)
+	return err
+}
+
+func (r *CacheInvalidationRetrier) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	r.shorten(ctx, userID)
+	return r.CacheRepository.GetBalance(ctx, userID)
+}
+
+func (r *CacheInvalidationRetrier) ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error) {
+	r.shorten(ctx, userID)
+	return r.CacheRepository.ReadThrough(ctx, userID)
+}
+
+// SetBalance caches balance. The balance is fresh, so a pending invalidation
+// of the balance it replaces is dropped.
+func (r *CacheInvalidationRetrier) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error {
+	err := r.CacheRepository.SetBalance(ctx, userID, balance, ttl)
+	if err == nil {
+		r.resolve(userID)
+	}
+	return err
+}
+
+// SetVersionedBalance caches balance unless a newer one is cached. Either way
+// the cached balance is at least as fresh as balance, so a pending
+// invalidation is dropped.
+func (r *CacheInvalidationRetrier) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	stored, err := r.CacheRepository.SetVersionedBalance(ctx, userID, balance, version, ttl)
+	if err == nil {
+		r.resolve(userID)
+	}
+	return stored, err
+}
+
+// Run retries the pending invalidations on each interval until ctx is
+// cancelled
+func (r *CacheInvalidationRetrier) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "cache-invalidation-retrier", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Retry(ctx)
+		}
+	}
+}
+
+// Retry invalidates the pending balances again and returns how many are
+// still pending
+func (r *CacheInvalidationRetrier) Retry(ctx context.Context) int {
+	r.mu.Lock()
+	userIDs := make([]string, 0, len(r.pending))
+	for userID := range r.pending {
+		userIDs = append(userIDs, userID)
+	}
+	r.mu.Unlock()
+
+	resolved := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		err := r.CacheRepository.InvalidateBalance(ctx, userID)
+		r.metrics.RecordInvalidationRetry(err)
+		if err == nil {
+			r.resolve(userID)
+			resolved++
+		}
+	}
+
+	r.mu.Lock()
+	count := len(r.pending)
+	r.mu.Unlock()
+	r.metrics.SetPendingInvalidations(count)
+
+	if resolved > 0 {
+		r.logger.WithContext(ctx).WithFields(logrus.Fields{"resolved": resolved, "pending": count}).Info("Failed cache invalidations retried")
+	}
+	return count
+}
+
+// resolve drops the pending invalidation of userID, if any
+func (r *CacheInvalidationRetrier) resolve(userID string) {
+	r.mu.Lock()
+	_, ok := r.pending[userID]
+	delete(r.pending, userID)
+	count := len(r.pending)
+	r.mu.Unlock()
+
+	if ok {
+		r.metrics.SetPendingInvalidations(count)
+	}
+}
+
+// shorten lowers the TTL of the cached balance of userID to staleTTL when its
+// invalidation is pending, once per failed invalidation
+func (r *CacheInvalidationRetrier) shorten(ctx context.Context, userID string) {
+	if r.shortener == nil {
+		return
+	}
+
+	r.mu.Lock()
+	shortened, ok := r.pending[userID]
+	if !ok || shortened {
+		r.mu.Unlock()
+		return
+	}
+	r.pending[userID] = true
+	r.mu.Unlock()
+
+	if err := r.shortener.ShortenBalanceTTL(ctx, userID, r.staleTTL); err != nil {
+		r.mu.Lock()
+		if _, ok := r.pending[userID]; ok {
+			r.pending[userID] = false
+		}
+		r.mu.Unlock()
+		return
+	}
+	r.logger.WithContext(ctx).WithFields(logrus.Fields{"userID": userID, "ttl": r.staleTTL}).Warn("Cached balance TTL shortened after a failed invalidation")
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/mocks"
+)
+
+type ttlShortenRecorder struct {
+	userIDs []string
+	err     error
+}
+
+func (r *ttlShortenRecorder) ShortenBalanceTTL(ctx context.Context, userID string, ttl time.Duration) error {
+	r.userIDs = append(r.userIDs, userID)
+	return r.err
+}
+
+func TestCacheInvalidationRetrier(t *testing.T) {
+	ctx := context.Background()
+	redisErr := errors.New("connection reset")
+
+	t.Run("failed invalidation shortens the TTL on the next read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCacheRepository(ctrl)
+		shortener := &ttlShortenRecorder{}
+		retrier := NewCacheInvalidationRetrier(mockCache, shortener, 5*time.Second, nil, logrus.New())
+
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(redisErr)
+		require.ErrorIs(t, retrier.InvalidateBalance(ctx, "user1"), redisErr)
+
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.NewFromInt(10), nil).Times(2)
+		mockCache.EXPECT().ReadThrough(ctx, "user2").Return(decimal.NewFromInt(20), nil)
+		_, err := retrier.ReadThrough(ctx, "user1")
+		require.NoError(t, err)
+		_, err = retrier.ReadThrough(ctx, "user1")
+		require.NoError(t, err)
+		_, err = retrier.ReadThrough(ctx, "user2")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user1"}, shortener.userIDs)
+	})
+
+	t.Run("failed shortening is tried again on the next read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCacheRepository(ctrl)
+		shortener := &ttlShortenRecorder{err: redisErr}
+		retrier := NewCacheInvalidationRetrier(mockCache, shortener, 5*time.Second, nil, logrus.New())
+
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(redisErr)
+		_ = retrier.InvalidateBalance(ctx, "user1")
+
+		mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.Zero, redisErr).Times(2)
+		_, _ = retrier.GetBalance(ctx, "user1")
+		_, _ = retrier.GetBalance(ctx, "user1")
+		assert.Equal(t, []string{"user1", "user1"}, shortener.userIDs)
+	})
+
+	t.Run("retry resolves pending invalidations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCacheRepository(ctrl)
+		retrier := NewCacheInvalidationRetrier(mockCache, &ttlShortenRecorder{}, 5*time.Second, nil, logrus.New())
+
+		mockCache.EXPECT().InvalidateBalance(ctx, gomock.Any()).Return(redisErr).Times(2)
+		_ = retrier.InvalidateBalance(ctx, "user1")
+		_ = retrier.InvalidateBalance(ctx, "user2")
+
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(redisErr)
+		assert.Equal(t, 1, retrier.Retry(ctx))
+
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+		assert.Equal(t, 0, retrier.Retry(ctx))
+		assert.Equal(t, 0, retrier.Retry(ctx))
+	})
+
+	t.Run("caching a newer balance drops the pending invalidation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCacheRepository(ctrl)
+		shortener := &ttlShortenRecorder{}
+		retrier := NewCacheInvalidationRetrier(mockCache, shortener, 5*time.Second, nil, logrus.New())
+
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(redisErr)
+		_ = retrier.InvalidateBalance(ctx, "user1")
+
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", decimal.NewFromInt(10), int64(4), time.Duration(0)).Return(false, nil)
+		_, err := retrier.SetVersionedBalance(ctx, "user1", decimal.NewFromInt(10), 4, 0)
+		require.NoError(t, err)
+
+		mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(12), nil)
+		_, err = retrier.GetBalance(ctx, "user1")
+		require.NoError(t, err)
+		assert.Empty(t, shortener.userIDs)
+		assert.Equal(t, 0, retrier.Retry(ctx))
+	})
+}
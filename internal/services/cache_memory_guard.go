@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// Memory pressure levels of the balance cache
+const (
+	CachePressureNormal = iota
+	CachePressureHigh
+	CachePressureCritical
+)
+
+var cachePressureNames = []string{"normal", "high", "critical"}
+
+// Eviction reasons
+const (
+	evictionIdle     = "idle"
+	evictionPressure = "pressure"
+)
+
+// maxEvictionBatches bounds the batches evicted by one check, so a guard that
+// cannot bring memory down does not keep Redis busy
+const maxEvictionBatches = 10
+
+// CacheTTLScaler shortens the TTL balances are cached with
+type CacheTTLScaler interface {
+	SetTTLScale(scale float64)
+}
+
+// CacheMemoryConfig sets when the cache memory guard steps in. Ratios are
+// fractions of the limit.
+type CacheMemoryConfig struct {
+	// Limit is the memory in bytes the cache is guarded against, the Redis
+	// maxmemory when zero
+	Limit int64
+	// From HighRatio on, balances are cached for TTLScale of their TTL
+	HighRatio float64
+	TTLScale  float64
+	// From CriticalRatio on, the balances of the least active wallets are
+	// evicted, EvictBatch at a time, until memory is below it
+	CriticalRatio float64
+	EvictBatch    int
+	// IdleAfter evicts the balances of wallets inactive for that long
+	// whatever the pressure, zero never
+	IdleAfter time.Duration
+	// EvictionPolicy is applied as the Redis maxmemory-policy when set
+	EvictionPolicy string
+}
+
+// CacheMemoryStatus is the outcome of a memory check
+type CacheMemoryStatus struct {
+	Used     int64
+	Limit    int64
+	Pressure int
+	Evicted  int
+}
+
+// CacheMemoryGuard keeps Redis away from its memory limit so an
+// out-of-memory Redis does not take the cache tier down. Every instance
+// reduces the TTL of the balances it caches under high pressure; only the
+// instance holding the lock evicts balances.
+type CacheMemoryGuard struct {
+	repo    redis.CacheMemoryRepository
+	cache   CacheTTLScaler
+	lock    redis.LeaderLock
+	metrics *metrics.Metrics
+	config  CacheMemoryConfig
+	logger  *logrus.Logger
+
+	mu       sync.Mutex
+	pressure int
+}
+
+func NewCacheMemoryGuard(repo redis.CacheMemoryRepository, cache CacheTTLScaler, lock redis.LeaderLock, m *metrics.Metrics, config CacheMemoryConfig, logger *logrus.Logger) *CacheMemoryGuard {
+	return &CacheMemoryGuard{
+		repo:    repo,
+		cache:   cache,
+		lock:    lock,
+		metrics: m,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// Run checks memory immediately and then on each interval until ctx is
+// cancelled
+func (g *CacheMemoryGuard) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "cache-memory-guard", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = g.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads the memory usage of Redis, restores the eviction policy if it
+// drifted, adjusts the cache TTLs to the pressure and, on the leader, evicts
+// idle balances and the least active ones while memory is critical
+func (g *CacheMemoryGuard) Check(ctx context.Context) (*CacheMemoryStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	usage, err := g.repo.MemoryUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy := g.config.EvictionPolicy; policy != "" && usage.Policy != policy {
+		if err := g.repo.SetEvictionPolicy(ctx, policy); err == nil {
+			g.logger.WithContext(ctx).WithFields(logrus.Fields{"previous": usage.Policy, "policy": policy}).Info("Cache eviction policy set")
+		}
+	}
+
+	status := &CacheMemoryStatus{Used: usage.Used, Limit: g.config.Limit}
+	if status.Limit == 0 {
+		status.Limit = usage.Max
+	}
+	status.Pressure = g.pressureOf(status.Used, status.Limit)
+	g.setPressure(ctx, status)
+
+	defer func() {
+		g.metrics.ObserveCacheMemory(status.Used, status.Limit, status.Pressure)
+	}()
+
+	if leader, err := g.lock.Acquire(ctx); err != nil || !leader {
+		return status, nil
+	}
+
+	if g.config.IdleAfter > 0 {
+		evicted, err := g.evict(ctx, time.Now().Add(-g.config.IdleAfter), evictionIdle)
+		status.Evicted += evicted
+		if err != nil {
+			return status, err
+		}
+	}
+
+	for batch := 0; status.Pressure == CachePressureCritical && batch < maxEvictionBatches; batch++ {
+		evicted, err := g.evict(ctx, time.Now(), evictionPressure)
+		status.Evicted += evicted
+		if err != nil {
+			return status, err
+		}
+		if evicted == 0 {
+			break
+		}
+
+		usage, err := g.repo.MemoryUsage(ctx)
+		if err != nil {
+			return status, err
+		}
+		status.Used = usage.Used
+		status.Pressure = g.pressureOf(status.Used, status.Limit)
+	}
+	g.setPressure(ctx, status)
+
+	if status.Evicted > 0 {
+		g.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"evicted": status.Evicted,
+			"used":    status.Used,
+			"limit":   status.Limit,
+		}).Info("Cached balances evicted")
+	}
+	return status, nil
+}
+
+// evict evicts the balances of wallets last active before idleSince, one
+// batch at a time, up to maxEvictionBatches. Under pressure it evicts a
+// single batch so the caller can measure memory again.
+func (g *CacheMemoryGuard) evict(ctx context.Context, idleSince time.Time, reason string) (int, error) {
+	total := 0
+	for batch := 0; batch < maxEvictionBatches; batch++ {
+		evicted, err := g.repo.EvictIdleBalances(ctx, idleSince, g.config.EvictBatch)
+		total += evicted
+		g.metrics.RecordCacheEvictions(reason, evicted)
+		if err != nil || reason == evictionPressure || evicted < g.config.EvictBatch {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (g *CacheMemoryGuard) pressureOf(used, limit int64) int {
+	if limit <= 0 {
+		return CachePressureNormal
+	}
+	ratio := float64(used) / float64(limit)
+	switch {
+	case ratio >= g.config.CriticalRatio:
+		return CachePressureCritical
+	case ratio >= g.config.HighRatio:
+		return CachePressureHigh
+	}
+	return CachePressureNormal
+}
+
+// setPressure reduces the cache TTLs while the pressure is not normal and
+// logs the changes of pressure, which alerting can match on
+func (g *CacheMemoryGuard) setPressure(ctx context.Context, status *CacheMemoryStatus) {
+	if status.Pressure == g.pressure {
+		return
+	}
+
+	scale := 1.0
+	if status.Pressure != CachePressureNormal {
+		scale = g.config.TTLScale
+	}
+	if g.pressure == CachePressureNormal || status.Pressure == CachePressureNormal {
+		g.cache.SetTTLScale(scale)
+	}
+
+	logger := g.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"previous": cachePressureNames[g.pressure],
+		"pressure": cachePressureNames[status.Pressure],
+		"used":     status.Used,
+		"limit":    status.Limit,
+		"ttlScale": scale,
+	})
+	switch status.Pressure {
+	case CachePressureCritical:
+		logger.Error("Cache memory pressure changed")
+	case CachePressureHigh:
+		logger.Warn("Cache memory pressure changed")
+	default:
+		logger.Info("Cache memory pressure changed")
+	}
+	g.pressure = status.Pressure
+}
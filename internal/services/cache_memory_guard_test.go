@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/mocks"
+)
+
+type ttlScaleRecorder struct {
+	scales []float64
+}
+
+func (r *ttlScaleRecorder) SetTTLScale(scale float64) {
+	r.scales = append(r.scales, scale)
+}
+
+func TestCacheMemoryGuard_Check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCacheMemoryRepository(ctrl)
+	cache := &ttlScaleRecorder{}
+	guard := NewCacheMemoryGuard(mockRepo, cache, redis.NewLocalLeaderLock(), nil, CacheMemoryConfig{
+		HighRatio:      0.75,
+		TTLScale:       0.25,
+		CriticalRatio:  0.9,
+		EvictBatch:     100,
+		EvictionPolicy: "volatile-lru",
+	}, logrus.New())
+	ctx := context.Background()
+
+	t.Run("normal pressure restores the eviction policy", func(t *testing.T) {
+		mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 500, Max: 1000, Policy: "noeviction"}, nil)
+		mockRepo.EXPECT().SetEvictionPolicy(ctx, "volatile-lru").Return(nil)
+
+		status, err := guard.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CachePressureNormal, status.Pressure)
+		assert.Equal(t, int64(1000), status.Limit)
+		assert.Empty(t, cache.scales)
+	})
+
+	t.Run("high pressure reduces the TTLs", func(t *testing.T) {
+		mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 800, Max: 1000, Policy: "volatile-lru"}, nil)
+
+		status, err := guard.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CachePressureHigh, status.Pressure)
+		assert.Zero(t, status.Evicted)
+		assert.Equal(t, []float64{0.25}, cache.scales)
+	})
+
+	t.Run("critical pressure evicts until memory is below the threshold", func(t *testing.T) {
+		gomock.InOrder(
+			mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 950, Max: 1000, Policy: "volatile-lru"}, nil),
+			mockRepo.EXPECT().EvictIdleBalances(ctx, gomock.Any(), 100).Return(100, nil),
+			mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 920, Max: 1000, Policy: "volatile-lru"}, nil),
+			mockRepo.EXPECT().EvictIdleBalances(ctx, gomock.Any(), 100).Return(100, nil),
+			mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 850, Max: 1000, Policy: "volatile-lru"}, nil),
+		)
+
+		status, err := guard.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CachePressureHigh, status.Pressure)
+		assert.Equal(t, 200, status.Evicted)
+		assert.Equal(t, int64(850), status.Used)
+	})
+
+	t.Run("back to normal restores the TTLs", func(t *testing.T) {
+		mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 100, Max: 1000, Policy: "volatile-lru"}, nil)
+
+		status, err := guard.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CachePressureNormal, status.Pressure)
+		assert.Equal(t, []float64{0.25, 1}, cache.scales)
+	})
+
+	t.Run("unlimited memory is never under pressure", func(t *testing.T) {
+		mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 1 << 40, Policy: "volatile-lru"}, nil)
+
+		status, err := guard.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CachePressureNormal, status.Pressure)
+	})
+}
+
+func TestCacheMemoryGuard_IdleEviction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCacheMemoryRepository(ctrl)
+	guard := NewCacheMemoryGuard(mockRepo, &ttlScaleRecorder{}, redis.NewLocalLeaderLock(), nil, CacheMemoryConfig{
+		Limit:         1000,
+		HighRatio:     0.75,
+		TTLScale:      0.25,
+		CriticalRatio: 0.9,
+		EvictBatch:    100,
+		IdleAfter:     24 * time.Hour,
+	}, logrus.New())
+	ctx := context.Background()
+
+	evicted := []int{100, 30}
+	mockRepo.EXPECT().MemoryUsage(ctx).Return(&models.CacheMemoryUsage{Used: 100}, nil)
+	mockRepo.EXPECT().EvictIdleBalances(ctx, gomock.Any(), 100).Times(2).
+		DoAndReturn(func(_ context.Context, idleSince time.Time, _ int) (int, error) {
+			assert.GreaterOrEqual(t, time.Since(idleSince), 24*time.Hour)
+			count := evicted[0]
+			evicted = evicted[1:]
+			return count, nil
+		})
+
+	status, err := guard.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 130, status.Evicted)
+}
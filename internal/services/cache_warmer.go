@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// BalanceWarmer caches the current balance of a wallet
+type BalanceWarmer interface {
+	WarmBalance(ctx context.Context, userID string) error
+}
+
+// CacheWarmConfig sets which balances the cache warmer refreshes
+type CacheWarmConfig struct {
+	// MinReads is the decayed read count from which a wallet is hot
+	MinReads float64
+	// Batch bounds the balances refreshed by one run
+	Batch int
+	// LeadTime refreshes the balances expiring within it, so it should
+	// exceed the interval between runs
+	LeadTime time.Duration
+	// HalfLife halves the read counts, so wallets no longer read cool down
+	HalfLife time.Duration
+}
+
+// CacheWarmer refreshes the cached balances of the most read wallets before
+// they expire, so a popular balance does not send every reader to the
+// database at once when its TTL runs out. Reads through the cache are
+// counted in Redis by every instance; only the instance holding the lock
+// refreshes balances and decays the counts.
+type CacheWarmer struct {
+	repo    redis.CacheWarmRepository
+	wallets BalanceWarmer
+	lock    redis.LeaderLock
+	metrics *metrics.Metrics
+	config  CacheWarmConfig
+	logger  *logrus.Logger
+	now     func() time.Time
+
+	mu sync.Mutex
+	// decayedAt is when this instance last decayed the read counts, zero
+	// until it first leads
+	decayedAt time.Time
+}
+
+func NewCacheWarmer(repo redis.CacheWarmRepository, wallets BalanceWarmer, lock redis.LeaderLock, m *metrics.Metrics, config CacheWarmConfig, logger *logrus.Logger) *CacheWarmer {
+	return &CacheWarmer{
+		repo:    repo,
+		wallets: wallets,
+		lock:    lock,
+		metrics: m,
+		config:  config,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Run warms the cache immediately and then on each interval until ctx is
+// cancelled
+func (w *CacheWarmer) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "cache-warmer", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = w.Warm(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Warm refreshes the hot balances missing from the cache or expiring within
+// the lead time, on the leader, and returns how many it refreshed. Wallets
+// that no longer exist are skipped and cool down with the read counts.
+func (w *CacheWarmer) Warm(ctx context.Context) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if leader, err := w.lock.Acquire(ctx); err != nil || !leader {
+		w.decayedAt = time.Time{}
+		return 0, err
+	}
+
+	userIDs, err := w.repo.ExpiringHotBalances(ctx, w.config.MinReads, w.config.Batch, w.config.LeadTime)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	for _, userID := range userIDs {
+		err := w.wallets.WarmBalance(ctx, userID)
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			continue
+		}
+		w.metrics.RecordCacheWarm(err)
+		if err != nil {
+			w.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Warn("Warm - Refresh cached balance failed")
+			continue
+		}
+		warmed++
+	}
+
+	if err := w.decay(ctx); err != nil {
+		return warmed, err
+	}
+
+	if warmed > 0 {
+		w.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"warmed": warmed,
+			"due":    len(userIDs),
+		}).Debug("Hot balances warmed")
+	}
+	return warmed, nil
+}
+
+// decay scales the read counts down by the time elapsed since the last
+// decay, halving them every HalfLife
+func (w *CacheWarmer) decay(ctx context.Context) error {
+	now := w.now()
+	if w.decayedAt.IsZero() {
+		w.decayedAt = now
+		return nil
+	}
+
+	factor := math.Pow(0.5, float64(now.Sub(w.decayedAt))/float64(w.config.HalfLife))
+	if err := w.repo.DecayReads(ctx, factor); err != nil {
+		return err
+	}
+	w.decayedAt = now
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/mocks"
+)
+
+type fakeBalanceWarmer struct {
+	warmed []string
+	errs   map[string]error
+}
+
+func (w *fakeBalanceWarmer) WarmBalance(_ context.Context, userID string) error {
+	if err := w.errs[userID]; err != nil {
+		return err
+	}
+	w.warmed = append(w.warmed, userID)
+	return nil
+}
+
+type followerLock struct{}
+
+func (followerLock) Acquire(context.Context) (bool, error) { return false, nil }
+func (followerLock) Release(context.Context) error         { return nil }
+
+func TestCacheWarmer_Warm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCacheWarmRepository(ctrl)
+	config := CacheWarmConfig{MinReads: 10, Batch: 100, LeadTime: time.Minute, HalfLife: 10 * time.Minute}
+	ctx := context.Background()
+
+	t.Run("refreshes the expiring hot balances and decays the reads", func(t *testing.T) {
+		wallets := &fakeBalanceWarmer{errs: map[string]error{
+			"ghost": postgres.ErrUserNotFound,
+			"user3": errors.New("connection refused"),
+		}}
+		warmer := NewCacheWarmer(mockRepo, wallets, redis.NewLocalLeaderLock(), nil, config, logrus.New())
+		now := time.Now()
+		warmer.now = func() time.Time { return now }
+
+		mockRepo.EXPECT().ExpiringHotBalances(ctx, 10.0, 100, time.Minute).Return([]string{"user1", "ghost", "user3", "user2"}, nil)
+		warmed, err := warmer.Warm(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, warmed)
+		assert.Equal(t, []string{"user1", "user2"}, wallets.warmed)
+
+		// Reads decay from the second run, by the time elapsed since the first
+		now = now.Add(5 * time.Minute)
+		mockRepo.EXPECT().ExpiringHotBalances(ctx, 10.0, 100, time.Minute).Return(nil, nil)
+		mockRepo.EXPECT().DecayReads(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, factor float64) error {
+			assert.InDelta(t, 0.7071, factor, 0.0001)
+			return nil
+		})
+		warmed, err = warmer.Warm(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, warmed)
+	})
+
+	t.Run("only the leader warms", func(t *testing.T) {
+		warmer := NewCacheWarmer(mockRepo, &fakeBalanceWarmer{}, followerLock{}, nil, config, logrus.New())
+
+		warmed, err := warmer.Warm(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, warmed)
+	})
+
+	t.Run("a failed lookup warms nothing", func(t *testing.T) {
+		redisErr := errors.New("i/o timeout")
+		warmer := NewCacheWarmer(mockRepo, &fakeBalanceWarmer{}, redis.NewLocalLeaderLock(), nil, config, logrus.New())
+		mockRepo.EXPECT().ExpiringHotBalances(ctx, 10.0, 100, time.Minute).Return(nil, redisErr)
+
+		_, err := warmer.Warm(ctx)
+		assert.ErrorIs(t, err, redisErr)
+	})
+}
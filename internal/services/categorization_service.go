@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const recategorizeBatchSize = 1000
+
+var (
+	ErrInvalidRule          = errors.New("rule needs a category and at least one criterion")
+	ErrInvalidAmountRange   = errors.New("min_amount cannot be above max_amount")
+	ErrUnknownRuleType      = errors.New("type must be deposit, withdrawal, transfer, adjustment, fee or round_up")
+	ErrUnknownRuleChannel   = errors.New("channel must be api, admin, batch or job")
+	ErrRecategorizationBusy = errors.New("a recategorization is already running")
+)
+
+var (
+	transactionTypes  = []string{"deposit", "withdrawal", "transfer", "adjustment", models.TransactionTypeFee, models.TransactionTypeRoundUp}
+	operationChannels = []string{operation.ChannelAPI, operation.ChannelAdmin, operation.ChannelBatch, operation.ChannelJob}
+)
+
+// CategorizationService manages the rules that tag transactions with a
+// category. Rules apply to transactions as they are recorded; after rules
+// change, a recategorization reapplies them to recorded transactions in the
+// background. The progress of the latest run is kept by the instance that
+// started it.
+type CategorizationService struct {
+	repo   postgres.CategorizationRepository
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	latest  *models.RecategorizationRun
+	running bool
+}
+
+func NewCategorizationService(repo postgres.CategorizationRepository, logger *logrus.Logger) *CategorizationService {
+	return &CategorizationService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListRules returns every rule in the order they are evaluated
+func (s *CategorizationService) ListRules(ctx context.Context) ([]models.CategorizationRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+// CreateRule validates and stores a rule. The actor of the operation is
+// recorded as its author.
+func (s *CategorizationService) CreateRule(ctx context.Context, rule models.CategorizationRule) (*models.CategorizationRule, error) {
+	if rule.Category == "" ||
+		(rule.CounterpartyPattern == nil && rule.MinAmount == nil && rule.MaxAmount == nil && rule.Type == nil && rule.Channel == nil) {
+		return nil, ErrInvalidRule
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && rule.MinAmount.GreaterThan(*rule.MaxAmount) {
+		return nil, ErrInvalidAmountRange
+	}
+	if rule.Type != nil && !slices.Contains(transactionTypes, *rule.Type) {
+		return nil, ErrUnknownRuleType
+	}
+	if rule.Channel != nil && !slices.Contains(operationChannels, *rule.Channel) {
+		return nil, ErrUnknownRuleChannel
+	}
+
+	op, _ := operation.From(ctx)
+	rule.CreatedBy = op.Actor
+	if err := s.repo.CreateRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule removes a rule
+func (s *CategorizationService) DeleteRule(ctx context.Context, ruleID string) error {
+	return s.repo.DeleteRule(ctx, ruleID)
+}
+
+// StartRecategorization reapplies the rules to the transactions created at or
+// after since, or to every transaction without it, in the background
+func (s *CategorizationService) StartRecategorization(ctx context.Context, since *time.Time) (models.RecategorizationRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return models.RecategorizationRun{}, ErrRecategorizationBusy
+	}
+
+	run := &models.RecategorizationRun{Since: since, StartedAt: time.Now()}
+	s.latest, s.running = run, true
+
+	go s.recategorize(jobContext(ctx, "recategorization"), run)
+	return *run, nil
+}
+
+// LatestRecategorization returns the progress of the latest run started by
+// this instance
+func (s *CategorizationService) LatestRecategorization() (models.RecategorizationRun, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return models.RecategorizationRun{}, false
+	}
+	return *s.latest, true
+}
+
+func (s *CategorizationService) recategorize(ctx context.Context, run *models.RecategorizationRun) {
+	finish := func(err error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := time.Now()
+		run.FinishedAt = &now
+		if err != nil {
+			message := err.Error()
+			run.Error = &message
+		}
+		s.running = false
+	}
+
+	var afterID int64
+	for {
+		lastID, scanned, changed, err := s.repo.RecategorizeNext(ctx, afterID, run.Since, recategorizeBatchSize)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("Recategorization failed")
+			finish(err)
+			return
+		}
+		if scanned == 0 {
+			break
+		}
+
+		s.mu.Lock()
+		run.Scanned += scanned
+		run.Changed += changed
+		s.mu.Unlock()
+		afterID = lastID
+	}
+
+	finish(nil)
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"scanned": run.Scanned,
+		"changed": run.Changed,
+	}).Info("Recategorization completed")
+}
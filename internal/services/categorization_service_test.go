@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestCategorizationService_CreateRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCategorizationRepository(ctrl)
+	service := NewCategorizationService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	pattern := "merchant:%"
+	min, max := decimal.NewFromInt(100), decimal.NewFromInt(10)
+	transfer, wire := "transfer", "wire"
+
+	t.Run("records the author", func(t *testing.T) {
+		mockRepo.EXPECT().CreateRule(ctx, &models.CategorizationRule{Category: "shopping", CounterpartyPattern: &pattern, Type: &transfer, CreatedBy: "admin1"}).
+			DoAndReturn(func(_ context.Context, rule *models.CategorizationRule) error {
+				rule.ID = "7"
+				return nil
+			})
+
+		rule, err := service.CreateRule(ctx, models.CategorizationRule{Category: "shopping", CounterpartyPattern: &pattern, Type: &transfer})
+		assert.NoError(t, err)
+		assert.Equal(t, "7", rule.ID)
+	})
+
+	invalid := []struct {
+		name string
+		rule models.CategorizationRule
+		err  error
+	}{
+		{"no category", models.CategorizationRule{Type: &transfer}, ErrInvalidRule},
+		{"no criteria", models.CategorizationRule{Category: "shopping"}, ErrInvalidRule},
+		{"inverted range", models.CategorizationRule{Category: "large", MinAmount: &min, MaxAmount: &max}, ErrInvalidAmountRange},
+		{"unknown type", models.CategorizationRule{Category: "wires", Type: &wire}, ErrUnknownRuleType},
+		{"unknown channel", models.CategorizationRule{Category: "wires", Channel: &wire}, ErrUnknownRuleChannel},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateRule(ctx, tt.rule)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestCategorizationService_Recategorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCategorizationRepository(ctrl)
+	service := NewCategorizationService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	_, ok := service.LatestRecategorization()
+	assert.False(t, ok)
+
+	t.Run("runs batch by batch", func(t *testing.T) {
+		since := time.Now().Add(-24 * time.Hour)
+		release := make(chan struct{})
+		gomock.InOrder(
+			mockRepo.EXPECT().RecategorizeNext(gomock.Any(), int64(0), &since, recategorizeBatchSize).DoAndReturn(
+				func(context.Context, int64, *time.Time, int) (int64, int, int, error) {
+					<-release
+					return 1000, 1000, 12, nil
+				}),
+			mockRepo.EXPECT().RecategorizeNext(gomock.Any(), int64(1000), &since, recategorizeBatchSize).Return(int64(1400), 400, 3, nil),
+			mockRepo.EXPECT().RecategorizeNext(gomock.Any(), int64(1400), &since, recategorizeBatchSize).Return(int64(0), 0, 0, nil),
+		)
+
+		_, err := service.StartRecategorization(ctx, &since)
+		require.NoError(t, err)
+		_, err = service.StartRecategorization(ctx, nil)
+		assert.ErrorIs(t, err, ErrRecategorizationBusy)
+		close(release)
+
+		require.Eventually(t, func() bool {
+			run, _ := service.LatestRecategorization()
+			return run.FinishedAt != nil
+		}, time.Second, 10*time.Millisecond)
+		run, _ := service.LatestRecategorization()
+		assert.Equal(t, 1400, run.Scanned)
+		assert.Equal(t, 15, run.Changed)
+		assert.Nil(t, run.Error)
+	})
+
+	t.Run("failure is reported", func(t *testing.T) {
+		mockRepo.EXPECT().RecategorizeNext(gomock.Any(), int64(0), nil, recategorizeBatchSize).Return(int64(0), 0, 0, errors.New("connection reset"))
+
+		_, err := service.StartRecategorization(ctx, nil)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			run, _ := service.LatestRecategorization()
+			return run.FinishedAt != nil
+		}, time.Second, 10*time.Millisecond)
+		run, _ := service.LatestRecategorization()
+		assert.Equal(t, "connection reset", *run.Error)
+	})
+}
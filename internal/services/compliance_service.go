@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	ErrComplianceDenied    = errors.New("operation not permitted by the compliance policy")
+	ErrInvalidJurisdiction = errors.New("jurisdiction must be a two-letter country code, or empty for the default policy")
+	ErrInvalidPolicy       = errors.New("allowed currencies must be currency codes and max_balance must be positive")
+)
+
+var jurisdictionPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// complianceDecisionsLimit caps the decisions returned by a listing
+const complianceDecisionsLimit = 100
+
+// ComplianceDeniedError reports the rule of a policy an operation broke. It
+// matches ErrComplianceDenied.
+type ComplianceDeniedError struct {
+	Rule          string `json:"rule"`
+	Jurisdiction  string `json:"jurisdiction"`
+	PolicyVersion int    `json:"policy_version"`
+}
+
+func (e *ComplianceDeniedError) Error() string {
+	jurisdiction := e.Jurisdiction
+	if jurisdiction == "" {
+		jurisdiction = "default"
+	}
+	return fmt.Sprintf("%s rule of the %s compliance policy does not permit the operation", e.Rule, jurisdiction)
+}
+
+func (e *ComplianceDeniedError) Is(target error) bool {
+	return target == ErrComplianceDenied
+}
+
+// ComplianceService evaluates operations against the compliance policy of
+// the user's registered jurisdiction: the currencies they may hold, their
+// maximum balance and whether they may withdraw to external destinations.
+// Every evaluation under a policy is recorded with the policy version it was
+// decided on. Users whose jurisdiction has no policy fall back to the default
+// policy; without one operations are allowed and not recorded. Like limits,
+// policies are checked before the operation is applied, so concurrent
+// deposits can together overshoot a maximum balance.
+type ComplianceService struct {
+	repo   postgres.ComplianceRepository
+	logger *logrus.Logger
+}
+
+func NewComplianceService(repo postgres.ComplianceRepository, logger *logrus.Logger) *ComplianceService {
+	return &ComplianceService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Check evaluates an operation of amount on the wallet of userID and returns
+// a *ComplianceDeniedError when its policy does not permit it. An empty
+// currency skips the currency rule: only converted transfers carry one.
+func (s *ComplianceService) Check(ctx context.Context, userID, op string, amount decimal.Decimal, currency string) error {
+	subject, err := s.repo.Subject(ctx, userID)
+	if err != nil {
+		return err
+	}
+	policy := subject.Policy
+	if policy == nil {
+		return nil
+	}
+
+	decision := &models.ComplianceDecision{
+		UserID:        userID,
+		Operation:     op,
+		Amount:        amount,
+		Currency:      currency,
+		Jurisdiction:  subject.Jurisdiction,
+		PolicyVersion: policy.Version,
+		Allowed:       true,
+	}
+	if rule := brokenRule(policy, subject.Balance, op, amount, currency); rule != "" {
+		decision.Allowed = false
+		decision.Rule = &rule
+	}
+	if err := s.repo.RecordDecision(ctx, decision); err != nil {
+		return err
+	}
+
+	if !decision.Allowed {
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"userID":        userID,
+			"operation":     op,
+			"jurisdiction":  subject.Jurisdiction,
+			"policyVersion": policy.Version,
+			"rule":          *decision.Rule,
+		}).Warn("Operation denied by compliance policy")
+		return &ComplianceDeniedError{
+			Rule:          *decision.Rule,
+			Jurisdiction:  policy.Jurisdiction,
+			PolicyVersion: policy.Version,
+		}
+	}
+	return nil
+}
+
+// brokenRule returns the rule of policy the operation breaks, or an empty
+// string when the policy permits it
+func brokenRule(policy *models.CompliancePolicy, balance decimal.Decimal, op string, amount decimal.Decimal, currency string) string {
+	if currency != "" && len(policy.AllowedCurrencies) > 0 && !slices.Contains(policy.AllowedCurrencies, currency) {
+		return models.ComplianceRuleCurrency
+	}
+	switch op {
+	case models.ComplianceDeposit, models.ComplianceTransferIn:
+		if policy.MaxBalance != nil && balance.Add(amount).GreaterThan(*policy.MaxBalance) {
+			return models.ComplianceRuleMaxBalance
+		}
+	case models.ComplianceExternalWithdrawal:
+		if !policy.ExternalWithdrawals {
+			return models.ComplianceRuleExternalWithdrawal
+		}
+	}
+	return ""
+}
+
+// Policies returns the policy in effect for every jurisdiction
+func (s *ComplianceService) Policies(ctx context.Context) ([]models.CompliancePolicy, error) {
+	return s.repo.ListPolicies(ctx)
+}
+
+// PolicyVersions returns every version of the policy of a jurisdiction,
+// newest first
+func (s *ComplianceService) PolicyVersions(ctx context.Context, jurisdiction string) ([]models.CompliancePolicy, error) {
+	jurisdiction, err := normalizeJurisdiction(jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.PolicyVersions(ctx, jurisdiction)
+}
+
+// SetPolicy records a new version of the policy of a jurisdiction, or of the
+// default policy with an empty jurisdiction. The actor of the operation is
+// recorded.
+func (s *ComplianceService) SetPolicy(ctx context.Context, policy models.CompliancePolicy) (*models.CompliancePolicy, error) {
+	jurisdiction, err := normalizeJurisdiction(policy.Jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MaxBalance != nil && !policy.MaxBalance.IsPositive() {
+		return nil, ErrInvalidPolicy
+	}
+	currencies := make([]string, 0, len(policy.AllowedCurrencies))
+	for _, currency := range policy.AllowedCurrencies {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if currency == "" || len(currency) > 10 {
+			return nil, ErrInvalidPolicy
+		}
+		if !slices.Contains(currencies, currency) {
+			currencies = append(currencies, currency)
+		}
+	}
+
+	op, _ := operation.From(ctx)
+	policy.Jurisdiction = jurisdiction
+	policy.AllowedCurrencies = currencies
+	policy.CreatedBy = op.Actor
+	if err := s.repo.CreatePolicyVersion(ctx, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Decisions returns the latest decisions on operations of userID
+func (s *ComplianceService) Decisions(ctx context.Context, userID string) ([]models.ComplianceDecision, error) {
+	return s.repo.ListDecisions(ctx, userID, complianceDecisionsLimit)
+}
+
+// normalizeJurisdiction upper-cases a country code; "default" and the empty
+// string name the default policy
+func normalizeJurisdiction(jurisdiction string) (string, error) {
+	jurisdiction = strings.ToUpper(jurisdiction)
+	if jurisdiction == "" || jurisdiction == "DEFAULT" {
+		return "", nil
+	}
+	if !jurisdictionPattern.MatchString(jurisdiction) {
+		return "", ErrInvalidJurisdiction
+	}
+	return jurisdiction, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestComplianceService_Check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockComplianceRepository(ctrl)
+	service := NewComplianceService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	maxBalance := decimal.NewFromInt(1000)
+	subject := &models.ComplianceSubject{
+		UserID:       "user1",
+		Jurisdiction: "SG",
+		Balance:      decimal.NewFromInt(900),
+		Policy: &models.CompliancePolicy{
+			Jurisdiction:      "SG",
+			Version:           3,
+			AllowedCurrencies: []string{"USDC"},
+			MaxBalance:        &maxBalance,
+		},
+	}
+
+	t.Run("allowed decision is recorded", func(t *testing.T) {
+		mockRepo.EXPECT().Subject(ctx, "user1").Return(subject, nil)
+		mockRepo.EXPECT().RecordDecision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, decision *models.ComplianceDecision) error {
+			assert.True(t, decision.Allowed)
+			assert.Equal(t, 3, decision.PolicyVersion)
+			assert.Equal(t, "SG", decision.Jurisdiction)
+			return nil
+		})
+
+		assert.NoError(t, service.Check(ctx, "user1", models.ComplianceDeposit, decimal.NewFromInt(100), ""))
+	})
+
+	t.Run("max balance", func(t *testing.T) {
+		mockRepo.EXPECT().Subject(ctx, "user1").Return(subject, nil)
+		mockRepo.EXPECT().RecordDecision(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, decision *models.ComplianceDecision) error {
+			assert.False(t, decision.Allowed)
+			assert.Equal(t, models.ComplianceRuleMaxBalance, *decision.Rule)
+			return nil
+		})
+
+		err := service.Check(ctx, "user1", models.ComplianceTransferIn, decimal.RequireFromString("100.01"), "")
+		assert.ErrorIs(t, err, ErrComplianceDenied)
+		assert.Equal(t, &ComplianceDeniedError{Rule: models.ComplianceRuleMaxBalance, Jurisdiction: "SG", PolicyVersion: 3}, err)
+	})
+
+	t.Run("currency", func(t *testing.T) {
+		mockRepo.EXPECT().Subject(ctx, "user1").Return(subject, nil)
+		mockRepo.EXPECT().RecordDecision(ctx, gomock.Any()).Return(nil)
+
+		err := service.Check(ctx, "user1", models.ComplianceDeposit, decimal.NewFromInt(1), "BTC")
+		assert.Equal(t, &ComplianceDeniedError{Rule: models.ComplianceRuleCurrency, Jurisdiction: "SG", PolicyVersion: 3}, err)
+	})
+
+	t.Run("external withdrawals", func(t *testing.T) {
+		mockRepo.EXPECT().Subject(ctx, "user1").Return(subject, nil)
+		mockRepo.EXPECT().RecordDecision(ctx, gomock.Any()).Return(nil)
+
+		err := service.Check(ctx, "user1", models.ComplianceExternalWithdrawal, decimal.NewFromInt(1), "")
+		assert.ErrorIs(t, err, ErrComplianceDenied)
+	})
+
+	t.Run("no policy applies", func(t *testing.T) {
+		mockRepo.EXPECT().Subject(ctx, "user2").Return(&models.ComplianceSubject{UserID: "user2"}, nil)
+
+		assert.NoError(t, service.Check(ctx, "user2", models.ComplianceExternalWithdrawal, decimal.NewFromInt(1), ""))
+	})
+}
+
+func TestComplianceService_SetPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockComplianceRepository(ctrl)
+	service := NewComplianceService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("new version", func(t *testing.T) {
+		mockRepo.EXPECT().CreatePolicyVersion(ctx, &models.CompliancePolicy{
+			Jurisdiction:        "SG",
+			AllowedCurrencies:   []string{"USDC", "BTC"},
+			ExternalWithdrawals: true,
+			Reason:              "MAS guidance",
+			CreatedBy:           "admin1",
+		}).Return(nil)
+
+		_, err := service.SetPolicy(ctx, models.CompliancePolicy{
+			Jurisdiction:        "sg",
+			AllowedCurrencies:   []string{"usdc", "BTC", "USDC"},
+			ExternalWithdrawals: true,
+			Reason:              "MAS guidance",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := service.SetPolicy(ctx, models.CompliancePolicy{Jurisdiction: "SGP"})
+		assert.ErrorIs(t, err, ErrInvalidJurisdiction)
+		zero := decimal.Zero
+		_, err = service.SetPolicy(ctx, models.CompliancePolicy{Jurisdiction: "default", MaxBalance: &zero})
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+		_, err = service.SetPolicy(ctx, models.CompliancePolicy{AllowedCurrencies: []string{" "}})
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+	})
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// ConsistencyChecker scans wallets for data that disagrees: transactions
+// crediting missing wallets, balances below minus their overdraft limit,
+// cached balances differing from stored ones and stored balances differing
+// from the ledger. A check produces a repair plan; applying a reviewed plan
+// repairs each issue that still stands under audit.
+type ConsistencyChecker struct {
+	repo      postgres.ConsistencyRepository
+	cache     redis.CacheRepository
+	batchSize int
+	logger    *logrus.Logger
+}
+
+// NewConsistencyChecker creates a checker reading batchSize wallets at a
+// time. Without a cache, cached balances are not checked.
+func NewConsistencyChecker(repo postgres.ConsistencyRepository, cache redis.CacheRepository, batchSize int, logger *logrus.Logger) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		repo:      repo,
+		cache:     cache,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Check scans every wallet and returns the repair plan of the issues found
+func (c *ConsistencyChecker) Check(ctx context.Context) (*models.RepairPlan, error) {
+	plan := &models.RepairPlan{GeneratedAt: time.Now().UTC(), Issues: []models.ConsistencyIssue{}}
+
+	orphaned, err := c.repo.OrphanedTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan.Issues = append(plan.Issues, orphaned...)
+
+	after := ""
+	for {
+		wallets, err := c.repo.CheckWallets(ctx, after, c.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, wallet := range wallets {
+			plan.Issues = append(plan.Issues, c.checkWallet(ctx, wallet)...)
+		}
+		plan.Checked += len(wallets)
+		if len(wallets) < c.batchSize {
+			break
+		}
+		after = wallets[len(wallets)-1].UserID
+	}
+
+	c.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"checked": plan.Checked,
+		"issues":  len(plan.Issues),
+	}).Info("Consistency check completed")
+	return plan, nil
+}
+
+func (c *ConsistencyChecker) checkWallet(ctx context.Context, wallet models.WalletConsistency) []models.ConsistencyIssue {
+	var issues []models.ConsistencyIssue
+	balance, ledger := wallet.Balance, wallet.Ledger
+
+	if !balance.Equal(ledger) {
+		issues = append(issues, models.ConsistencyIssue{
+			Kind:    models.IssueLedgerImbalance,
+			UserID:  wallet.UserID,
+			Balance: &balance,
+			Ledger:  &ledger,
+			Repair:  models.RepairSetBalance,
+		})
+	}
+
+	// Setting the ledger balance repairs a balance beyond the credit line the
+	// ledger does not share; a ledger beyond it is an overdraft to look into
+	floor := wallet.OverdraftLimit.Neg()
+	if balance.LessThan(floor) {
+		issue := models.ConsistencyIssue{
+			Kind:    models.IssueNegativeBalance,
+			UserID:  wallet.UserID,
+			Balance: &balance,
+			Ledger:  &ledger,
+		}
+		if ledger.LessThan(floor) && wallet.Status == models.WalletStatusActive {
+			issue.Repair = models.RepairFreezeWallet
+		}
+		issues = append(issues, issue)
+	}
+
+	if c.cache != nil {
+		cached, err := c.cache.GetBalance(ctx, wallet.UserID)
+		switch {
+		case errors.Is(err, goredis.Nil):
+		case err != nil:
+			c.logger.WithContext(ctx).WithError(err).WithField("userID", wallet.UserID).Warn("checkWallet - Read cached balance failed")
+		case !cached.Equal(balance):
+			issues = append(issues, models.ConsistencyIssue{
+				Kind:    models.IssueCacheMismatch,
+				UserID:  wallet.UserID,
+				Balance: &balance,
+				Cached:  &cached,
+				Repair:  models.RepairInvalidateCache,
+			})
+		}
+	}
+	return issues
+}
+
+// Apply repairs the issues of plan in order and reports the outcome of each.
+// The operation in ctx must name an actor and a reason, which are recorded
+// with every repair. A repair whose wallet changed since the plan was made
+// is not applied. Stored balances that change drop their cached balance.
+func (c *ConsistencyChecker) Apply(ctx context.Context, plan *models.RepairPlan) []models.RepairOutcome {
+	outcomes := make([]models.RepairOutcome, 0, len(plan.Issues))
+	for _, issue := range plan.Issues {
+		outcome := models.RepairOutcome{Issue: issue, Status: models.RepairApplied}
+
+		var err error
+		switch issue.Repair {
+		case "":
+			outcome.Status = models.RepairManual
+		case models.RepairInvalidateCache:
+			if c.cache == nil {
+				err = errors.New("no cache to invalidate")
+				break
+			}
+			if err = c.cache.InvalidateBalance(ctx, issue.UserID); err == nil {
+				err = c.repo.RecordRepair(ctx, issue)
+			}
+		default:
+			if err = c.repo.ApplyRepair(ctx, issue); err == nil && c.cache != nil {
+				_ = c.cache.InvalidateBalance(ctx, issue.UserID)
+			}
+		}
+
+		switch {
+		case errors.Is(err, postgres.ErrRepairStale):
+			outcome.Status = models.RepairStale
+		case err != nil:
+			outcome.Status, outcome.Error = models.RepairFailed, err.Error()
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestConsistencyChecker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockConsistencyRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	checker := NewConsistencyChecker(mockRepo, mockCache, 2, logrus.New())
+	ctx := context.Background()
+
+	t.Run("Check", func(t *testing.T) {
+		orphaned := models.ConsistencyIssue{Kind: models.IssueOrphanedTransactions, UserID: "ghost", Repair: models.RepairCreateWallet}
+		mockRepo.EXPECT().OrphanedTransactions(ctx).Return([]models.ConsistencyIssue{orphaned}, nil)
+		mockRepo.EXPECT().CheckWallets(ctx, "", 2).Return([]models.WalletConsistency{
+			{UserID: "user1", Status: models.WalletStatusActive, Balance: decimal.NewFromInt(10), Ledger: decimal.NewFromInt(10)},
+			{UserID: "user2", Status: models.WalletStatusActive, Balance: decimal.NewFromInt(-4), Ledger: decimal.NewFromInt(6)},
+		}, nil)
+		mockRepo.EXPECT().CheckWallets(ctx, "user2", 2).Return([]models.WalletConsistency{
+			{UserID: "user3", Status: models.WalletStatusActive, Balance: decimal.NewFromInt(-2), Ledger: decimal.NewFromInt(-2)},
+			// Within its credit line
+			{UserID: "user4", Status: models.WalletStatusActive, Balance: decimal.NewFromInt(-2), Ledger: decimal.NewFromInt(-2), OverdraftLimit: decimal.NewFromInt(5)},
+		}, nil)
+		mockRepo.EXPECT().CheckWallets(ctx, "user4", 2).Return(nil, nil)
+		mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(8), nil)
+		mockCache.EXPECT().GetBalance(ctx, "user2").Return(decimal.Zero, goredis.Nil)
+		mockCache.EXPECT().GetBalance(ctx, "user3").Return(decimal.Zero, assert.AnError)
+		mockCache.EXPECT().GetBalance(ctx, "user4").Return(decimal.NewFromInt(-2), nil)
+
+		plan, err := checker.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, plan.Checked)
+
+		var found []string
+		for _, issue := range plan.Issues {
+			found = append(found, issue.UserID+":"+issue.Kind+":"+issue.Repair)
+		}
+		assert.Equal(t, []string{
+			"ghost:orphaned_transactions:create_wallet",
+			"user1:cache_mismatch:invalidate_cache",
+			"user2:ledger_imbalance:set_balance",
+			"user2:negative_balance:",
+			"user3:negative_balance:freeze_wallet",
+		}, found)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		imbalance := models.ConsistencyIssue{Kind: models.IssueLedgerImbalance, UserID: "user2", Repair: models.RepairSetBalance}
+		freeze := models.ConsistencyIssue{Kind: models.IssueNegativeBalance, UserID: "user3", Repair: models.RepairFreezeWallet}
+		cached := models.ConsistencyIssue{Kind: models.IssueCacheMismatch, UserID: "user1", Repair: models.RepairInvalidateCache}
+		negative := models.ConsistencyIssue{Kind: models.IssueNegativeBalance, UserID: "user2"}
+
+		mockRepo.EXPECT().ApplyRepair(ctx, imbalance).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+		mockRepo.EXPECT().ApplyRepair(ctx, freeze).Return(postgres.ErrRepairStale)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockRepo.EXPECT().RecordRepair(ctx, cached).Return(assert.AnError)
+
+		outcomes := checker.Apply(ctx, &models.RepairPlan{Issues: []models.ConsistencyIssue{imbalance, freeze, cached, negative}})
+		require.Len(t, outcomes, 4)
+		assert.Equal(t, models.RepairApplied, outcomes[0].Status)
+		assert.Equal(t, models.RepairStale, outcomes[1].Status)
+		assert.Equal(t, models.RepairFailed, outcomes[2].Status)
+		assert.Equal(t, assert.AnError.Error(), outcomes[2].Error)
+		assert.Equal(t, models.RepairManual, outcomes[3].Status)
+	})
+}
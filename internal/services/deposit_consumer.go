@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// DepositConsumer applies queued deposits. Up to workers wallets are
+// processed concurrently; the deposits of one wallet are applied one at a
+// time in the order they were queued.
+type DepositConsumer struct {
+	repo      postgres.DepositQueueRepository
+	cache     redis.CacheRepository
+	batchSize int
+	workers   int
+	logger    *logrus.Logger
+}
+
+func NewDepositConsumer(repo postgres.DepositQueueRepository, cache redis.CacheRepository, batchSize, workers int, logger *logrus.Logger) *DepositConsumer {
+	return &DepositConsumer{
+		repo:      repo,
+		cache:     cache,
+		batchSize: batchSize,
+		workers:   max(workers, 1),
+		logger:    logger,
+	}
+}
+
+// Run drains the queue immediately and then on each interval until ctx is
+// cancelled
+func (c *DepositConsumer) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "deposit-consumer", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = c.Drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain applies pending deposits, batchSize wallets at a time, until no
+// wallet has deposits this consumer can apply. It returns the number of
+// deposits processed.
+func (c *DepositConsumer) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		users, err := c.repo.PendingUsers(ctx, c.batchSize)
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).WithField("processed", total).Error("Drain - Query pending users failed")
+			return total, err
+		}
+		if len(users) == 0 {
+			break
+		}
+
+		// Wallets locked by other consumers or failing to apply leave
+		// nothing processed; stop until the next interval
+		processed := c.applyAll(ctx, users)
+		total += processed
+		if processed == 0 {
+			break
+		}
+	}
+
+	if total > 0 {
+		c.logger.WithContext(ctx).WithField("processed", total).Debug("Deposit queue drained")
+	}
+	return total, nil
+}
+
+// applyAll spreads users over the workers and returns the number of deposits
+// processed
+func (c *DepositConsumer) applyAll(ctx context.Context, users []string) int {
+	var processed atomic.Int64
+	work := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.workers, len(users)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range work {
+				processed.Add(int64(c.applyWallet(ctx, userID)))
+			}
+		}()
+	}
+
+	for _, userID := range users {
+		work <- userID
+	}
+	close(work)
+	wg.Wait()
+	return int(processed.Load())
+}
+
+// applyWallet applies the pending deposits of userID in order until none is
+// left or one cannot be applied, then invalidates the cached balance
+func (c *DepositConsumer) applyWallet(ctx context.Context, userID string) int {
+	processed := 0
+	for ctx.Err() == nil {
+		deposit, err := c.repo.ApplyNext(ctx, userID)
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("applyWallet - Apply queued deposit failed")
+			break
+		}
+		if deposit == nil {
+			break
+		}
+		processed++
+	}
+
+	if processed > 0 {
+		_ = c.cache.InvalidateBalance(ctx, userID)
+	}
+	return processed
+}
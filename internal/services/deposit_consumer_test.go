@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestDepositConsumer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockDepositQueueRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	consumer := NewDepositConsumer(mockRepo, mockCache, 10, 2, logrus.New())
+	ctx := context.Background()
+
+	t.Run("applies every wallet in queue order", func(t *testing.T) {
+		mockRepo.EXPECT().PendingUsers(ctx, 10).Return([]string{"user1", "user2"}, nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().ApplyNext(ctx, "user1").Return(&models.QueuedDeposit{ID: "1", Status: models.DepositApplied}, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, "user1").Return(&models.QueuedDeposit{ID: "3", Status: models.DepositFailed}, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, "user1").Return(nil, nil),
+			mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil),
+		)
+		gomock.InOrder(
+			mockRepo.EXPECT().ApplyNext(ctx, "user2").Return(&models.QueuedDeposit{ID: "2", Status: models.DepositApplied}, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, "user2").Return(nil, nil),
+			mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil),
+		)
+		mockRepo.EXPECT().PendingUsers(ctx, 10).Return(nil, nil)
+
+		processed, err := consumer.Drain(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, processed)
+	})
+
+	t.Run("stops when no wallet can be processed", func(t *testing.T) {
+		mockRepo.EXPECT().PendingUsers(ctx, 10).Return([]string{"user1"}, nil)
+		// Locked by another consumer
+		mockRepo.EXPECT().ApplyNext(ctx, "user1").Return(nil, nil)
+
+		processed, err := consumer.Drain(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+	})
+
+	t.Run("apply failure leaves the wallet for the next run", func(t *testing.T) {
+		mockRepo.EXPECT().PendingUsers(ctx, 10).Return([]string{"user1"}, nil)
+		mockRepo.EXPECT().ApplyNext(ctx, "user1").Return(nil, errors.New("connection reset"))
+
+		processed, err := consumer.Drain(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+	})
+
+	t.Run("pending users error", func(t *testing.T) {
+		mockErr := errors.New("connection refused")
+		mockRepo.EXPECT().PendingUsers(ctx, 10).Return(nil, mockErr)
+
+		_, err := consumer.Drain(ctx)
+		assert.ErrorIs(t, err, mockErr)
+	})
+}
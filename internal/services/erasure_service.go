@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+const (
+	defaultErasureLimit = 50
+	maxErasureLimit     = 500
+)
+
+var ErrInvalidErasureStatus = errors.New("status must be pending, completed or cancelled")
+
+// ErasureService closes wallets on request of their owner and erases their
+// personal data once the retention period has passed, keeping an auditable
+// record of every erasure
+type ErasureService struct {
+	repo      postgres.ErasureRepository
+	cache     redis.CacheRepository
+	retention time.Duration
+	batchSize int
+	logger    *logrus.Logger
+}
+
+// NewErasureService creates an ErasureService that erases personal data
+// retention after it was requested, at most batchSize erasures per run
+func NewErasureService(repo postgres.ErasureRepository, cache redis.CacheRepository, retention time.Duration, batchSize int, logger *logrus.Logger) *ErasureService {
+	return &ErasureService{
+		repo:      repo,
+		cache:     cache,
+		retention: retention,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Request closes the wallet of userID and schedules the erasure of its
+// personal data after the retention period. The wallet must be empty. The
+// actor of the operation is recorded; reason must not hold personal data as
+// it outlives the erasure.
+func (s *ErasureService) Request(ctx context.Context, userID, reason string) (*models.DataErasure, error) {
+	ctx = operation.WithReason(ctx, reason)
+	op, _ := operation.From(ctx)
+	erasure := &models.DataErasure{
+		UserID:      &userID,
+		Reason:      reason,
+		RequestedBy: op.Actor,
+		EraseAfter:  time.Now().Add(s.retention),
+	}
+
+	if err := s.repo.RequestErasure(ctx, erasure); err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, userID)
+
+	return erasure, nil
+}
+
+// Cancel cancels a pending erasure. The wallet stays closed.
+func (s *ErasureService) Cancel(ctx context.Context, erasureID string) (*models.DataErasure, error) {
+	return s.repo.CancelErasure(ctx, erasureID)
+}
+
+// Get returns an erasure
+func (s *ErasureService) Get(ctx context.Context, erasureID string) (*models.DataErasure, error) {
+	return s.repo.GetErasure(ctx, erasureID)
+}
+
+// List returns the latest erasures with status, of any status when empty,
+// newest first
+func (s *ErasureService) List(ctx context.Context, status string, limit int) ([]models.DataErasure, error) {
+	switch status {
+	case "", models.ErasurePending, models.ErasureCompleted, models.ErasureCancelled:
+	default:
+		return nil, ErrInvalidErasureStatus
+	}
+	if limit <= 0 {
+		limit = defaultErasureLimit
+	}
+	return s.repo.ListErasures(ctx, status, min(limit, maxErasureLimit))
+}
+
+// Run purges the erasures due immediately and then on each interval until
+// ctx is cancelled
+func (s *ErasureService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "erasure", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.PurgeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue erases the personal data of up to batchSize erasures whose
+// retention period has passed and returns how many it erased
+func (s *ErasureService) PurgeDue(ctx context.Context) (int, error) {
+	erased := 0
+	for erased < s.batchSize {
+		_, userID, err := s.repo.EraseNext(ctx)
+		if errors.Is(err, postgres.ErrNoErasureDue) {
+			break
+		}
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("PurgeDue - Erase personal data failed, will retry")
+			return erased, err
+		}
+
+		// The cache must not keep the erased user ID
+		_ = s.cache.InvalidateBalance(ctx, userID)
+		_ = s.cache.InvalidateBalance(ctx, models.SavingsAccount(userID))
+		erased++
+	}
+	return erased, nil
+}
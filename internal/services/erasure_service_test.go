@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestErasureService_Request(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockErasureRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewErasureService(mockRepo, mockCache, 30*24*time.Hour, 10, logrus.New())
+
+	t.Run("schedules the erasure after the retention period", func(t *testing.T) {
+		ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+		opCtx := withOperation(operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: "deletion request"})
+		mockRepo.EXPECT().RequestErasure(opCtx, gomock.Any()).DoAndReturn(func(_ context.Context, erasure *models.DataErasure) error {
+			assert.Equal(t, "user1", *erasure.UserID)
+			assert.Equal(t, "admin1", erasure.RequestedBy)
+			assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), erasure.EraseAfter, time.Minute)
+			return nil
+		})
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+
+		erasure, err := service.Request(ctx, "user1", "deletion request")
+		require.NoError(t, err)
+		assert.Equal(t, "deletion request", erasure.Reason)
+	})
+
+	t.Run("cache untouched on failure", func(t *testing.T) {
+		mockRepo.EXPECT().RequestErasure(gomock.Any(), gomock.Any()).Return(postgres.ErrWalletNotEmpty)
+
+		_, err := service.Request(context.Background(), "user1", "deletion request")
+		assert.ErrorIs(t, err, postgres.ErrWalletNotEmpty)
+	})
+}
+
+func TestErasureService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockErasureRepository(ctrl)
+	service := NewErasureService(mockRepo, mocks.NewMockCacheRepository(ctrl), time.Hour, 10, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ListErasures(ctx, models.ErasurePending, defaultErasureLimit).Return([]models.DataErasure{}, nil)
+	_, err := service.List(ctx, models.ErasurePending, 0)
+	require.NoError(t, err)
+
+	mockRepo.EXPECT().ListErasures(ctx, "", maxErasureLimit).Return([]models.DataErasure{}, nil)
+	_, err = service.List(ctx, "", 10000)
+	require.NoError(t, err)
+
+	_, err = service.List(ctx, "erased", 10)
+	assert.ErrorIs(t, err, ErrInvalidErasureStatus)
+}
+
+func TestErasureService_PurgeDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockErasureRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewErasureService(mockRepo, mockCache, time.Hour, 2, logrus.New())
+	ctx := context.Background()
+
+	t.Run("stops when none is due", func(t *testing.T) {
+		gomock.InOrder(
+			mockRepo.EXPECT().EraseNext(ctx).Return(&models.DataErasure{ID: "1"}, "user1", nil),
+			mockRepo.EXPECT().EraseNext(ctx).Return(nil, "", postgres.ErrNoErasureDue),
+		)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, models.SavingsAccount("user1")).Return(nil)
+
+		erased, err := service.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, erased)
+	})
+
+	t.Run("stops at the batch size", func(t *testing.T) {
+		mockRepo.EXPECT().EraseNext(ctx).Return(&models.DataErasure{ID: "2"}, "user2", nil).Times(2)
+		mockCache.EXPECT().InvalidateBalance(ctx, gomock.Any()).Return(nil).Times(4)
+
+		erased, err := service.PurgeDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, erased)
+	})
+
+	t.Run("reports failures", func(t *testing.T) {
+		failure := errors.New("connection reset")
+		mockRepo.EXPECT().EraseNext(ctx).Return(nil, "", failure)
+
+		erased, err := service.PurgeDue(ctx)
+		assert.ErrorIs(t, err, failure)
+		assert.Zero(t, erased)
+	})
+}
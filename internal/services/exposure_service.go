@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+type ExposureService struct {
+	repo    postgres.ExposureRepository
+	windows []int
+	logger  *logrus.Logger
+}
+
+func NewExposureService(repo postgres.ExposureRepository, windows []int, logger *logrus.Logger) *ExposureService {
+	return &ExposureService{
+		repo:    repo,
+		windows: windows,
+		logger:  logger,
+	}
+}
+
+// Run materializes the exposures of every configured window immediately and
+// then on each interval until ctx is cancelled
+func (s *ExposureService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = s.RefreshAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshAll recomputes the exposures of every configured window
+func (s *ExposureService) RefreshAll(ctx context.Context) error {
+	var errs []error
+	for _, window := range s.windows {
+		start := time.Now()
+		pairs, err := s.repo.Refresh(ctx, window)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("windowDays", window).Error("RefreshAll - Refresh exposures failed")
+			errs = append(errs, err)
+			continue
+		}
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"windowDays": window,
+			"pairs":      pairs,
+			"duration":   time.Since(start),
+		}).Debug("Exposures refreshed")
+	}
+	return errors.Join(errs...)
+}
+
+// GetExposure returns the exposure between two users. Pairs without
+// transfers in the window have a zero exposure.
+func (s *ExposureService) GetExposure(ctx context.Context, userID, counterpartyID string, windowDays int) (*models.Exposure, error) {
+	exposure, err := s.repo.GetExposure(ctx, userID, counterpartyID, windowDays)
+	if errors.Is(err, postgres.ErrExposureNotFound) {
+		userA, userB := userID, counterpartyID
+		if userB < userA {
+			userA, userB = userB, userA
+		}
+		return &models.Exposure{UserA: userA, UserB: userB, WindowDays: windowDays, Volume: decimal.Zero}, nil
+	}
+	return exposure, err
+}
+
+// ExceedsExposure reports whether the volume exchanged between two users
+// within the window reached threshold, for use by risk rules
+func (s *ExposureService) ExceedsExposure(ctx context.Context, userID, counterpartyID string, windowDays int, threshold decimal.Decimal) (bool, error) {
+	exposure, err := s.GetExposure(ctx, userID, counterpartyID, windowDays)
+	if err != nil {
+		return false, err
+	}
+	return exposure.Volume.GreaterThanOrEqual(threshold), nil
+}
+
+func (s *ExposureService) ListExposures(ctx context.Context, filter models.ExposureFilter) ([]models.Exposure, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	return s.repo.ListExposures(ctx, filter)
+}
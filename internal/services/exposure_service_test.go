@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestExposureService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExposureRepository(ctrl)
+	service := NewExposureService(mockRepo, []int{7, 30}, logrus.New())
+
+	t.Run("refresh all windows", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Refresh(ctx, 7).Return(3, nil)
+		mockRepo.EXPECT().Refresh(ctx, 30).Return(0, errors.New("db error"))
+
+		err := service.RefreshAll(ctx)
+		assert.ErrorContains(t, err, "db error")
+	})
+
+	t.Run("exceeds exposure", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetExposure(ctx, "user1", "user2", 7).Return(&models.Exposure{Volume: decimal.NewFromInt(60000)}, nil)
+
+		exceeds, err := service.ExceedsExposure(ctx, "user1", "user2", 7, decimal.NewFromInt(50000))
+		assert.NoError(t, err)
+		assert.True(t, exceeds)
+	})
+
+	t.Run("no transfers means zero exposure", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetExposure(ctx, "user2", "user1", 7).Return(nil, postgres.ErrExposureNotFound)
+
+		exposure, err := service.GetExposure(ctx, "user2", "user1", 7)
+		assert.NoError(t, err)
+		assert.Equal(t, "user1", exposure.UserA)
+		assert.True(t, exposure.Volume.IsZero())
+	})
+
+	t.Run("list applies default limit", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().ListExposures(ctx, models.ExposureFilter{WindowDays: 7, Limit: 50}).Return(nil, nil)
+
+		_, err := service.ListExposures(ctx, models.ExposureFilter{WindowDays: 7})
+		assert.NoError(t, err)
+	})
+}
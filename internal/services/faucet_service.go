@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/redis"
+)
+
+var (
+	ErrInvalidFaucetAmount = errors.New("faucet amount must be positive and within the faucet maximum")
+	ErrFaucetRateLimited   = errors.New("faucet rate limit reached, retry later")
+)
+
+// FaucetLimitError reports a faucet request over the rate limit of its key
+type FaucetLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *FaucetLimitError) Error() string {
+	return ErrFaucetRateLimited.Error()
+}
+
+func (e *FaucetLimitError) Unwrap() error {
+	return ErrFaucetRateLimited
+}
+
+// RetryAfterSeconds is the Retry-After value of the error, rounded up to a
+// whole second
+func (e *FaucetLimitError) RetryAfterSeconds() string {
+	return strconv.Itoa(int((e.RetryAfter + time.Second - 1) / time.Second))
+}
+
+// FaucetConfig sets what the sandbox faucet credits and how often
+type FaucetConfig struct {
+	// Amount credited when a request does not name one
+	Amount decimal.Decimal
+	// MaxAmount is the most a single request may credit
+	MaxAmount decimal.Decimal
+	// Limit requests are allowed per key in each Window
+	Limit  int
+	Window time.Duration
+}
+
+// FaucetService credits test funds to wallets in sandbox mode, so
+// integrators can fund their own test wallets. Each credit is a deposit with
+// the reason "faucet", subject to the usual deposit checks.
+type FaucetService struct {
+	wallets *WalletService
+	limiter redis.RateLimiter
+	config  FaucetConfig
+	logger  *logrus.Logger
+}
+
+func NewFaucetService(wallets *WalletService, limiter redis.RateLimiter, config FaucetConfig, logger *logrus.Logger) *FaucetService {
+	return &FaucetService{
+		wallets: wallets,
+		limiter: limiter,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// Credit deposits amount, or the default faucet amount when zero, into the
+// wallet of userID. Requests count against the limit of key, which
+// identifies the API key or token subject of the caller.
+func (s *FaucetService) Credit(ctx context.Context, key, userID string, amount decimal.Decimal) (*models.FaucetCredit, error) {
+	if amount.IsZero() {
+		amount = s.config.Amount
+	}
+	if !amount.IsPositive() || amount.GreaterThan(s.config.MaxAmount) {
+		return nil, ErrInvalidFaucetAmount
+	}
+
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"key":    key,
+		"userID": userID,
+		"amount": amount,
+	})
+
+	limit, err := s.limiter.Allow(ctx, "faucet:"+key, s.config.Limit, s.config.Window)
+	if err != nil {
+		logger.WithError(err).Error("Credit - Check rate limit failed")
+		return nil, err
+	}
+	if !limit.Allowed {
+		logger.WithField("retryAfter", limit.ResetIn).Warn("Faucet rate limit reached")
+		return nil, &FaucetLimitError{RetryAfter: limit.ResetIn}
+	}
+
+	if err := s.wallets.Deposit(operation.WithReason(ctx, "faucet"), userID, amount); err != nil {
+		return nil, err
+	}
+	balance, err := s.wallets.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Faucet funds credited")
+	return &models.FaucetCredit{
+		UserID:    userID,
+		Amount:    amount,
+		Balance:   balance,
+		Remaining: limit.Remaining,
+		ResetsAt:  time.Now().Add(limit.ResetIn).UTC().Truncate(time.Second),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/mocks"
+)
+
+func TestFaucetService_Credit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	wallets := NewWalletService(mockRepo, mockCache, logrus.New())
+	service := NewFaucetService(wallets, redis.NewLocalRateLimiter(), FaucetConfig{
+		Amount:    decimal.NewFromInt(100),
+		MaxAmount: decimal.NewFromInt(1000),
+		Limit:     2,
+		Window:    time.Hour,
+	}, logrus.New())
+	ctx := context.Background()
+
+	t.Run("credits the default amount", func(t *testing.T) {
+		mockRepo.EXPECT().Deposit(gomock.Any(), "user1", decimal.NewFromInt(100)).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(gomock.Any(), "user1").Return(nil)
+		mockCache.EXPECT().ReadThrough(gomock.Any(), "user1").Return(decimal.NewFromInt(100), nil)
+
+		credit, err := service.Credit(ctx, "key:1", "user1", decimal.Zero)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(credit.Amount))
+		assert.Equal(t, 1, credit.Remaining)
+	})
+
+	t.Run("rejects amounts above the maximum", func(t *testing.T) {
+		_, err := service.Credit(ctx, "key:1", "user1", decimal.NewFromInt(1001))
+		assert.ErrorIs(t, err, ErrInvalidFaucetAmount)
+	})
+
+	t.Run("limits requests per key", func(t *testing.T) {
+		mockRepo.EXPECT().Deposit(gomock.Any(), "user1", decimal.NewFromInt(500)).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(gomock.Any(), "user1").Return(nil)
+		mockCache.EXPECT().ReadThrough(gomock.Any(), "user1").Return(decimal.NewFromInt(600), nil)
+
+		_, err := service.Credit(ctx, "key:1", "user1", decimal.NewFromInt(500))
+		require.NoError(t, err)
+
+		_, err = service.Credit(ctx, "key:1", "user1", decimal.Zero)
+		var limitErr *FaucetLimitError
+		require.True(t, errors.As(err, &limitErr))
+		assert.ErrorIs(t, err, ErrFaucetRateLimited)
+		assert.Equal(t, "3600", limitErr.RetryAfterSeconds())
+	})
+
+	t.Run("other keys keep their own limit", func(t *testing.T) {
+		mockRepo.EXPECT().Deposit(gomock.Any(), "user2", decimal.NewFromInt(100)).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(gomock.Any(), "user2").Return(nil)
+		mockCache.EXPECT().ReadThrough(gomock.Any(), "user2").Return(decimal.NewFromInt(100), nil)
+
+		_, err := service.Credit(ctx, "key:2", "user2", decimal.Zero)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	ErrUnknownFeeOperation = errors.New("operation must be withdrawal or transfer")
+	ErrInvalidFee          = errors.New("min_amount, flat_fee and percent_fee cannot be negative and percent_fee cannot exceed 100")
+)
+
+var feeOperations = []string{models.FeeOperationWithdrawal, models.FeeOperationTransfer}
+
+// FeeService manages the fee tiers of withdrawals and transfers and quotes
+// the fee of an operation. Fees are charged in the currency of the paying
+// wallet, on top of the amount, and credited to the fee account.
+type FeeService struct {
+	repo   postgres.FeeRepository
+	logger *logrus.Logger
+}
+
+func NewFeeService(repo postgres.FeeRepository, logger *logrus.Logger) *FeeService {
+	return &FeeService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListFees returns every fee tier
+func (s *FeeService) ListFees(ctx context.Context) ([]models.Fee, error) {
+	return s.repo.ListFees(ctx)
+}
+
+// CreateFee validates and stores a fee tier. The actor of the operation is
+// recorded as its author.
+func (s *FeeService) CreateFee(ctx context.Context, fee models.Fee) (*models.Fee, error) {
+	if err := validateFee(&fee); err != nil {
+		return nil, err
+	}
+	op, _ := operation.From(ctx)
+	fee.UpdatedBy = op.Actor
+	if err := s.repo.CreateFee(ctx, &fee); err != nil {
+		return nil, err
+	}
+	return &fee, nil
+}
+
+// UpdateFee validates and replaces the fee tier feeID
+func (s *FeeService) UpdateFee(ctx context.Context, feeID string, fee models.Fee) (*models.Fee, error) {
+	if err := validateFee(&fee); err != nil {
+		return nil, err
+	}
+	op, _ := operation.From(ctx)
+	fee.ID, fee.UpdatedBy = feeID, op.Actor
+	if err := s.repo.UpdateFee(ctx, &fee); err != nil {
+		return nil, err
+	}
+	return &fee, nil
+}
+
+// DeleteFee removes a fee tier
+func (s *FeeService) DeleteFee(ctx context.Context, feeID string) error {
+	return s.repo.DeleteFee(ctx, feeID)
+}
+
+// Quote returns the fee userID pays for an operation of amount, zero when no
+// tier applies
+func (s *FeeService) Quote(ctx context.Context, userID, op string, amount decimal.Decimal) (decimal.Decimal, error) {
+	currency, err := s.repo.WalletCurrency(ctx, userID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	fees, err := s.repo.ListFees(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	tier, ok := applicableFee(fees, op, currency, amount)
+	if !ok {
+		return decimal.Zero, nil
+	}
+	return tier.Charge(amount), nil
+}
+
+// applicableFee returns the tier of op with the highest minimum up to amount.
+// The tiers of currency take precedence; only when it has none do the tiers
+// without a currency apply.
+func applicableFee(fees []models.Fee, op, currency string, amount decimal.Decimal) (models.Fee, bool) {
+	tiers := func(currency string) []models.Fee {
+		var matching []models.Fee
+		for _, fee := range fees {
+			if fee.Operation == op && fee.Currency == currency {
+				matching = append(matching, fee)
+			}
+		}
+		return matching
+	}
+	matching := tiers(currency)
+	if len(matching) == 0 && currency != "" {
+		matching = tiers("")
+	}
+
+	var applicable models.Fee
+	found := false
+	for _, fee := range matching {
+		if fee.MinAmount.LessThanOrEqual(amount) && (!found || fee.MinAmount.GreaterThan(applicable.MinAmount)) {
+			applicable, found = fee, true
+		}
+	}
+	return applicable, found
+}
+
+func validateFee(fee *models.Fee) error {
+	if !slices.Contains(feeOperations, fee.Operation) {
+		return ErrUnknownFeeOperation
+	}
+	fee.Currency = strings.ToUpper(strings.TrimSpace(fee.Currency))
+	if fee.Currency != "" && !currencyPattern.MatchString(fee.Currency) {
+		return ErrInvalidCurrencyCode
+	}
+	if fee.MinAmount.IsNegative() || fee.FlatFee.IsNegative() || fee.PercentFee.IsNegative() ||
+		fee.PercentFee.GreaterThan(decimal.NewFromInt(100)) {
+		return ErrInvalidFee
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestFeeService_CreateFee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockFeeRepository(ctrl)
+	service := NewFeeService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("records the author and normalizes the currency", func(t *testing.T) {
+		mockRepo.EXPECT().CreateFee(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, fee *models.Fee) error {
+			assert.Equal(t, "EUR", fee.Currency)
+			assert.Equal(t, "admin1", fee.UpdatedBy)
+			fee.ID = "4"
+			return nil
+		})
+
+		fee, err := service.CreateFee(ctx, models.Fee{Operation: models.FeeOperationTransfer, Currency: " eur ", FlatFee: decimal.NewFromInt(1)})
+		require.NoError(t, err)
+		assert.Equal(t, "4", fee.ID)
+	})
+
+	invalid := []struct {
+		name string
+		fee  models.Fee
+		err  error
+	}{
+		{"unknown operation", models.Fee{Operation: "deposit"}, ErrUnknownFeeOperation},
+		{"invalid currency", models.Fee{Operation: models.FeeOperationTransfer, Currency: "E-UR"}, ErrInvalidCurrencyCode},
+		{"negative flat fee", models.Fee{Operation: models.FeeOperationTransfer, FlatFee: decimal.NewFromInt(-1)}, ErrInvalidFee},
+		{"percent above 100", models.Fee{Operation: models.FeeOperationWithdrawal, PercentFee: decimal.NewFromInt(101)}, ErrInvalidFee},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateFee(ctx, tt.fee)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestFeeService_Quote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockFeeRepository(ctrl)
+	service := NewFeeService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	fees := []models.Fee{
+		{Operation: models.FeeOperationTransfer, FlatFee: decimal.RequireFromString("0.5")},
+		{Operation: models.FeeOperationTransfer, MinAmount: decimal.NewFromInt(1000), PercentFee: decimal.RequireFromString("0.1")},
+		{Operation: models.FeeOperationTransfer, Currency: "EUR", FlatFee: decimal.NewFromInt(1), PercentFee: decimal.NewFromInt(1)},
+		{Operation: models.FeeOperationWithdrawal, Currency: "EUR", MinAmount: decimal.NewFromInt(10), FlatFee: decimal.NewFromInt(2)},
+	}
+	mockRepo.EXPECT().ListFees(ctx).Return(fees, nil).AnyTimes()
+
+	tests := []struct {
+		name      string
+		currency  string
+		operation string
+		amount    string
+		want      string
+	}{
+		{"default tier", "", models.FeeOperationTransfer, "100", "0.5"},
+		{"higher default tier", "USD", models.FeeOperationTransfer, "2000", "2"},
+		{"currency tier replaces the defaults", "EUR", models.FeeOperationTransfer, "2000", "21"},
+		{"below every tier", "EUR", models.FeeOperationWithdrawal, "5", "0"},
+		{"no tier for the operation", "", models.FeeOperationWithdrawal, "100", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().WalletCurrency(ctx, "user1").Return(tt.currency, nil)
+
+			fee, err := service.Quote(ctx, "user1", tt.operation, decimal.RequireFromString(tt.amount))
+			require.NoError(t, err)
+			assert.True(t, fee.Equal(decimal.RequireFromString(tt.want)), "fee %s", fee)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const freezeBatchSize = 500
+
+var (
+	ErrNotAFreezeJob  = errors.New("only freeze jobs can be reverted")
+	ErrJobNotFinished = errors.New("job has not completed yet")
+)
+
+type FreezeService struct {
+	repo   postgres.FreezeRepository
+	logger *logrus.Logger
+}
+
+func NewFreezeService(repo postgres.FreezeRepository, logger *logrus.Logger) *FreezeService {
+	return &FreezeService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Preview returns the number of active wallets a freeze with the criteria would affect
+func (s *FreezeService) Preview(ctx context.Context, criteria models.FreezeCriteria) (int, error) {
+	return s.repo.CountWallets(ctx, criteria)
+}
+
+// StartFreeze creates a bulk freeze job and processes it in the background.
+// The returned job can be polled through GetJob for progress.
+func (s *FreezeService) StartFreeze(ctx context.Context, criteria models.FreezeCriteria, reason string) (*models.FreezeJob, error) {
+	if _, err := s.repo.CountWallets(ctx, criteria); err != nil {
+		return nil, err
+	}
+
+	job := &models.FreezeJob{
+		Action:   models.FreezeActionFreeze,
+		Criteria: criteria,
+		Reason:   reason,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.run(jobContext(ctx, reason), *job)
+	return job, nil
+}
+
+// StartUnfreeze reverts a completed freeze job by unfreezing the wallets of
+// its cohort that are still frozen
+func (s *FreezeService) StartUnfreeze(ctx context.Context, freezeJobID, reason string) (*models.FreezeJob, error) {
+	parent, err := s.repo.GetJob(ctx, freezeJobID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Action != models.FreezeActionFreeze {
+		return nil, ErrNotAFreezeJob
+	}
+	if parent.Status != models.FreezeJobCompleted && parent.Status != models.FreezeJobFailed {
+		return nil, ErrJobNotFinished
+	}
+
+	job := &models.FreezeJob{
+		Action:      models.FreezeActionUnfreeze,
+		Criteria:    parent.Criteria,
+		ParentJobID: &parent.ID,
+		Reason:      reason,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.run(jobContext(ctx, reason), *job)
+	return job, nil
+}
+
+func (s *FreezeService) GetJob(ctx context.Context, jobID string) (*models.FreezeJob, error) {
+	return s.repo.GetJob(ctx, jobID)
+}
+
+// jobContext detaches a job from the request that started it. The job runs
+// in the job channel on behalf of the request's actor.
+func jobContext(ctx context.Context, reason string) context.Context {
+	ctx = operation.WithChannel(context.WithoutCancel(ctx), operation.ChannelJob)
+	return operation.WithReason(ctx, reason)
+}
+
+// run snapshots the job cohort and applies the action batch by batch so that
+// progress is observable while the job executes
+func (s *FreezeService) run(ctx context.Context, job models.FreezeJob) {
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"jobID":  job.ID,
+		"action": job.Action,
+	})
+
+	fail := func(err error) {
+		logger.WithError(err).Error("Freeze job failed")
+		message := err.Error()
+		_ = s.repo.FinishJob(ctx, job.ID, models.FreezeJobFailed, &message)
+	}
+
+	total, err := s.repo.SnapshotCohort(ctx, &job)
+	if err != nil {
+		fail(err)
+		return
+	}
+	logger.WithField("total", total).Info("Freeze job started")
+
+	for {
+		processed, err := s.repo.ApplyNext(ctx, &job, freezeBatchSize)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if processed == 0 {
+			break
+		}
+	}
+
+	if err := s.repo.FinishJob(ctx, job.ID, models.FreezeJobCompleted, nil); err != nil {
+		return
+	}
+	logger.WithField("processed", job.Processed).Info("Freeze job completed")
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestFreezeService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockFreezeRepository(ctrl)
+	service := NewFreezeService(mockRepo, logrus.New())
+	label := "merchant"
+	criteria := models.FreezeCriteria{Label: &label}
+
+	t.Run("preview", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().CountWallets(ctx, criteria).Return(12, nil)
+
+		count, err := service.Preview(ctx, criteria)
+		assert.NoError(t, err)
+		assert.Equal(t, 12, count)
+	})
+
+	t.Run("run processes cohort in batches", func(t *testing.T) {
+		ctx := context.Background()
+		job := models.FreezeJob{ID: "1", Action: models.FreezeActionFreeze, Criteria: criteria}
+		gomock.InOrder(
+			mockRepo.EXPECT().SnapshotCohort(ctx, gomock.Any()).Return(700, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, gomock.Any(), freezeBatchSize).Return(500, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, gomock.Any(), freezeBatchSize).Return(200, nil),
+			mockRepo.EXPECT().ApplyNext(ctx, gomock.Any(), freezeBatchSize).Return(0, nil),
+			mockRepo.EXPECT().FinishJob(ctx, "1", models.FreezeJobCompleted, nil).Return(nil),
+		)
+
+		service.run(ctx, job)
+	})
+
+	t.Run("run records failure", func(t *testing.T) {
+		ctx := context.Background()
+		job := models.FreezeJob{ID: "2", Action: models.FreezeActionFreeze, Criteria: criteria}
+		mockRepo.EXPECT().SnapshotCohort(ctx, gomock.Any()).Return(0, errors.New("db error"))
+		mockRepo.EXPECT().FinishJob(ctx, "2", models.FreezeJobFailed, gomock.Any()).Return(nil)
+
+		service.run(ctx, job)
+	})
+
+	t.Run("unfreeze requires a finished freeze job", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetJob(ctx, "3").Return(&models.FreezeJob{ID: "3", Action: models.FreezeActionFreeze, Status: models.FreezeJobRunning}, nil)
+
+		_, err := service.StartUnfreeze(ctx, "3", "incident resolved")
+		assert.ErrorIs(t, err, ErrJobNotFinished)
+	})
+
+	t.Run("unfreeze rejects unfreeze jobs", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetJob(ctx, "4").Return(&models.FreezeJob{ID: "4", Action: models.FreezeActionUnfreeze, Status: models.FreezeJobCompleted}, nil)
+
+		_, err := service.StartUnfreeze(ctx, "4", "incident resolved")
+		assert.ErrorIs(t, err, ErrNotAFreezeJob)
+	})
+}
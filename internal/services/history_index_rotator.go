@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// historyIndexPeriod is how long the partial indexes of recent transactions
+// are kept before they are rebuilt for a later cutoff. Their cutoff stays a
+// period older than the DefaultHistoryWindow, so a default window keeps
+// using them while its pages are read.
+const historyIndexPeriod = 7 * 24 * time.Hour
+
+// HistoryIndexRotator keeps the partial indexes of recent transactions
+// covering the DefaultHistoryWindow, so default histories read an index
+// holding a few months of transactions rather than every transaction. Older
+// ranges read the full history indexes.
+type HistoryIndexRotator struct {
+	repo   postgres.HistoryIndexRepository
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+func NewHistoryIndexRotator(repo postgres.HistoryIndexRepository, logger *logrus.Logger) *HistoryIndexRotator {
+	return &HistoryIndexRotator{repo: repo, logger: logger, now: time.Now}
+}
+
+// Run rotates the indexes immediately and then on each interval until ctx
+// is cancelled
+func (r *HistoryIndexRotator) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "history-index-rotator", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = r.Rotate(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate builds the indexes of the current cutoff and drops the previous
+// ones. The cutoff moves once a period, so most runs change nothing.
+func (r *HistoryIndexRotator) Rotate(ctx context.Context) error {
+	cutoff := RecentHistoryCutoff(r.now())
+	if _, err := r.repo.RotateRecentIndexes(ctx, cutoff); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithField("cutoff", cutoff).Error("Rotate - Rotate history indexes failed, will retry")
+		return err
+	}
+	return nil
+}
+
+// RecentHistoryCutoff returns the oldest creation time the partial indexes
+// of recent transactions cover at now: the start of the period a period
+// before the DefaultHistoryWindow
+func RecentHistoryCutoff(now time.Time) time.Time {
+	return now.UTC().Add(-DefaultHistoryWindow - historyIndexPeriod).Truncate(historyIndexPeriod)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/mocks"
+)
+
+func TestHistoryIndexRotator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockHistoryIndexRepository(ctrl)
+	rotator := NewHistoryIndexRotator(mockRepo, logrus.New())
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 1, 15, 0, 0, 0, time.UTC)
+	rotator.now = func() time.Time { return now }
+
+	t.Run("Rotate", func(t *testing.T) {
+		mockRepo.EXPECT().RotateRecentIndexes(ctx, RecentHistoryCutoff(now)).Return(true, nil)
+		assert.NoError(t, rotator.Rotate(ctx))
+	})
+
+	t.Run("Rotate failure", func(t *testing.T) {
+		mockRepo.EXPECT().RotateRecentIndexes(ctx, gomock.Any()).Return(false, assert.AnError)
+		assert.ErrorIs(t, rotator.Rotate(ctx), assert.AnError)
+	})
+}
+
+func TestRecentHistoryCutoff(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 15, 0, 0, 0, time.UTC)
+	cutoff := RecentHistoryCutoff(now)
+
+	// The cutoff stays at least a period before the default window and moves
+	// once a period
+	assert.False(t, cutoff.After(now.Add(-DefaultHistoryWindow-historyIndexPeriod)))
+	assert.True(t, cutoff.After(now.Add(-DefaultHistoryWindow-2*historyIndexPeriod)))
+	assert.Equal(t, cutoff, RecentHistoryCutoff(now.Add(time.Hour)))
+	assert.Equal(t, cutoff, cutoff.Truncate(24*time.Hour))
+}
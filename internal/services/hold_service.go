@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// HoldService runs two-phase transfers: creating one holds the amount on the
+// sender's wallet, capturing it moves the funds and cancelling it releases them
+type HoldService struct {
+	repo       postgres.HoldRepository
+	cache      redis.CacheRepository
+	settings   *SettingsService
+	limits     *LimitsService
+	compliance *ComplianceService
+	switches   *KillSwitchService
+	logger     *logrus.Logger
+}
+
+// NewHoldService creates the hold service. With nil settings and limits
+// pending transfers are not subject to transaction limits, and with a nil
+// compliance service not to the policy of the receiver. With nil switches
+// pending transfers cannot be disabled.
+func NewHoldService(repo postgres.HoldRepository, cache redis.CacheRepository, settings *SettingsService, limits *LimitsService, compliance *ComplianceService, switches *KillSwitchService, logger *logrus.Logger) *HoldService {
+	return &HoldService{
+		repo:       repo,
+		cache:      cache,
+		settings:   settings,
+		limits:     limits,
+		compliance: compliance,
+		switches:   switches,
+		logger:     logger,
+	}
+}
+
+// CreatePendingTransfer holds amount on the sender's wallet until the
+// transfer is captured or cancelled
+func (s *HoldService) CreatePendingTransfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal) (*models.Hold, error) {
+	if err := s.checkKillSwitch(ctx); err != nil {
+		return nil, err
+	}
+	if s.settings != nil {
+		if err := s.settings.CheckAmount(ctx, fromUserID, amount); err != nil {
+			return nil, err
+		}
+	}
+	if s.limits != nil {
+		if err := s.limits.Check(ctx, fromUserID, models.LimitOperationTransfer, amount); err != nil {
+			return nil, err
+		}
+	}
+	if s.compliance != nil {
+		if err := s.compliance.Check(ctx, toUserID, models.ComplianceTransferIn, amount, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	hold := &models.Hold{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+	}
+	if err := s.repo.CreateHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetPendingTransfer returns a transfer the user is sending or receiving
+func (s *HoldService) GetPendingTransfer(ctx context.Context, userID, holdID string) (*models.Hold, error) {
+	hold, err := s.repo.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.FromUserID != userID && hold.ToUserID != userID {
+		return nil, postgres.ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// Capture completes a pending transfer sent by userID. Cancelling stays
+// possible while transfers are disabled, capturing does not.
+func (s *HoldService) Capture(ctx context.Context, userID, holdID string) (*models.Hold, error) {
+	if err := s.checkKillSwitch(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.checkSender(ctx, userID, holdID); err != nil {
+		return nil, err
+	}
+
+	hold, err := s.repo.CaptureHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, hold.FromUserID)
+	_ = s.cache.InvalidateBalance(ctx, hold.ToUserID)
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"holdID":        holdID,
+		"transactionID": *hold.TransactionID,
+	}).Info("Pending transfer captured")
+	return hold, nil
+}
+
+// Cancel releases the funds held by a pending transfer sent by userID
+func (s *HoldService) Cancel(ctx context.Context, userID, holdID string) (*models.Hold, error) {
+	if err := s.checkSender(ctx, userID, holdID); err != nil {
+		return nil, err
+	}
+
+	hold, err := s.repo.ReleaseHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).WithField("holdID", holdID).Info("Pending transfer cancelled")
+	return hold, nil
+}
+
+// checkKillSwitch rejects pending transfers while transfers are disabled
+func (s *HoldService) checkKillSwitch(ctx context.Context) error {
+	if s.switches == nil {
+		return nil
+	}
+	return s.switches.Check(ctx, models.KillSwitchTransfers)
+}
+
+// checkSender hides transfers the user did not send. The sender of a hold
+// never changes, so checking outside the capture/release transaction is safe.
+func (s *HoldService) checkSender(ctx context.Context, userID, holdID string) error {
+	hold, err := s.repo.GetHold(ctx, holdID)
+	if err != nil {
+		return err
+	}
+	if hold.FromUserID != userID {
+		return postgres.ErrHoldNotFound
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestHoldService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockHoldRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewHoldService(mockRepo, mockCache, nil, nil, nil, nil, logrus.New())
+
+	pending := func() *models.Hold {
+		return &models.Hold{ID: "5", FromUserID: "user1", ToUserID: "user2", Amount: decimal.NewFromInt(100), Status: models.HoldPending}
+	}
+
+	t.Run("capture invalidates both balances", func(t *testing.T) {
+		ctx := context.Background()
+		transactionID := "9"
+		captured := pending()
+		captured.Status = models.HoldCaptured
+		captured.TransactionID = &transactionID
+		gomock.InOrder(
+			mockRepo.EXPECT().GetHold(ctx, "5").Return(pending(), nil),
+			mockRepo.EXPECT().CaptureHold(ctx, "5").Return(captured, nil),
+		)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+
+		hold, err := service.Capture(ctx, "user1", "5")
+		assert.NoError(t, err)
+		assert.Equal(t, models.HoldCaptured, hold.Status)
+	})
+
+	t.Run("receiver cannot cancel", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetHold(ctx, "5").Return(pending(), nil)
+
+		_, err := service.Cancel(ctx, "user2", "5")
+		assert.ErrorIs(t, err, postgres.ErrHoldNotFound)
+	})
+
+	t.Run("receiver can view", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetHold(ctx, "5").Return(pending(), nil)
+
+		hold, err := service.GetPendingTransfer(ctx, "user2", "5")
+		assert.NoError(t, err)
+		assert.Equal(t, "5", hold.ID)
+	})
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+)
+
+var (
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress  = errors.New("a request with this idempotency key is in progress")
+	ErrIdempotencyUnsupported = errors.New("idempotency keys are not supported")
+)
+
+// idempotent runs apply at most once per idempotency key of the operation in
+// ctx. A repeated request with the same key returns the original (successful)
+// result without applying it again; failed requests release the key so they
+// can be retried.
+func (s *WalletService) idempotent(ctx context.Context, userID, name string, params []interface{}, apply func() error) error {
+	op, _ := operation.From(ctx)
+	key := op.IdempotencyKey
+	if key == "" {
+		return apply()
+	}
+	if s.idempotency == nil {
+		return ErrIdempotencyUnsupported
+	}
+
+	logger := s.logger.WithContext(ctx).WithFields(op.Fields())
+
+	record, reserved, err := s.idempotency.Reserve(ctx, &models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		Operation:   name,
+		RequestHash: requestHash(name, params),
+	})
+	if err != nil {
+		return err
+	}
+
+	if !reserved {
+		switch {
+		case record.Operation != name || record.RequestHash != requestHash(name, params):
+			logger.Warn("Idempotency key reused for a different request")
+			return ErrIdempotencyKeyReused
+		case record.Status == models.IdempotencyInProgress:
+			return ErrIdempotencyInProgress
+		default:
+			logger.Info("Replaying idempotent request")
+			return nil
+		}
+	}
+
+	if err := apply(); err != nil {
+		_ = s.idempotency.Release(ctx, userID, key)
+		return err
+	}
+
+	if err := s.idempotency.Complete(ctx, userID, key); err != nil {
+		// The operation was applied; a retry will see the key in progress
+		// rather than applying it twice
+		logger.WithError(err).Error("Complete idempotency key failed")
+	}
+	return nil
+}
+
+func requestHash(operation string, params []interface{}) string {
+	parts := make([]string, 0, len(params)+1)
+	parts = append(parts, operation)
+	for _, param := range params {
+		if amount, ok := param.(*decimal.Decimal); ok {
+			if amount == nil {
+				parts = append(parts, "<nil>")
+				continue
+			}
+			param = *amount
+		}
+		parts = append(parts, fmt.Sprint(param))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
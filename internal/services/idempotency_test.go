@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestWalletService_Idempotency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithIdempotency(mockIdempotency))
+	amount := decimal.NewFromInt(100)
+
+	t.Run("first request is applied", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key1")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+				return record, true, nil
+			})
+		mockRepo.EXPECT().Deposit(ctx, "user1", amount).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockIdempotency.EXPECT().Complete(ctx, "user1", "key1").Return(nil)
+
+		assert.NoError(t, service.Deposit(ctx, "user1", amount))
+	})
+
+	t.Run("repeated request is not applied again", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key1")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+				existing := *record
+				existing.Status = models.IdempotencyCompleted
+				return &existing, false, nil
+			})
+
+		assert.NoError(t, service.Deposit(ctx, "user1", amount))
+	})
+
+	t.Run("key reused with different amount", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key1")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).Return(&models.IdempotencyRecord{
+			Operation:   "deposit",
+			RequestHash: requestHash("deposit", []interface{}{decimal.NewFromInt(50)}),
+			Status:      models.IdempotencyCompleted,
+		}, false, nil)
+
+		err := service.Deposit(ctx, "user1", amount)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key2")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).Return(&models.IdempotencyRecord{
+			Operation:   "withdraw",
+			RequestHash: requestHash("withdraw", []interface{}{amount, (*decimal.Decimal)(nil)}),
+			Status:      models.IdempotencyInProgress,
+		}, false, nil)
+
+		err := service.Withdraw(ctx, "user1", amount, nil)
+		assert.ErrorIs(t, err, ErrIdempotencyInProgress)
+	})
+
+	t.Run("failed request releases the key", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key3")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+				return record, true, nil
+			})
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", amount, nil).Return(errors.New("db error"))
+		mockIdempotency.EXPECT().Release(ctx, "user1", "key3").Return(nil)
+
+		err := service.Transfer(ctx, "user1", "user2", amount, nil)
+		assert.ErrorContains(t, err, "db error")
+	})
+
+	t.Run("requests without key bypass the store", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Deposit(ctx, "user1", amount).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		assert.NoError(t, service.Deposit(ctx, "user1", amount))
+	})
+}
+
+func TestRequestHash(t *testing.T) {
+	expected := decimal.RequireFromString("10.50")
+	assert.Equal(t,
+		requestHash("withdraw", []interface{}{decimal.RequireFromString("100.00"), &expected}),
+		requestHash("withdraw", []interface{}{decimal.NewFromInt(100), &expected}),
+	)
+	assert.NotEqual(t,
+		requestHash("withdraw", []interface{}{decimal.NewFromInt(100), &expected}),
+		requestHash("withdraw", []interface{}{decimal.NewFromInt(100), nil}),
+	)
+}
+
+func TestWalletService_QueueDeposit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockDeposits := mocks.NewMockDepositQueueRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New(), WithIdempotency(mockIdempotency), WithDepositQueue(mockDeposits))
+	amount := decimal.NewFromInt(100)
+
+	replay := func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+		existing := *record
+		existing.Status = models.IdempotencyCompleted
+		return &existing, false, nil
+	}
+
+	t.Run("queues the deposit", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key1")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+				return record, true, nil
+			})
+		mockDeposits.EXPECT().Enqueue(ctx, gomock.Any(), "key1").DoAndReturn(
+			func(_ context.Context, deposit *models.QueuedDeposit, _ string) error {
+				deposit.ID, deposit.Status = "7", models.DepositPending
+				return nil
+			})
+		mockIdempotency.EXPECT().Complete(ctx, "user1", "key1").Return(nil)
+
+		deposit, err := service.QueueDeposit(ctx, "user1", amount)
+		assert.NoError(t, err)
+		assert.Equal(t, "7", deposit.ID)
+	})
+
+	t.Run("retry returns the queued deposit", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key1")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(replay)
+		mockDeposits.EXPECT().GetQueuedDepositByKey(ctx, "user1", "key1").Return(&models.QueuedDeposit{ID: "7", Status: models.DepositApplied}, nil)
+
+		deposit, err := service.QueueDeposit(ctx, "user1", amount)
+		assert.NoError(t, err)
+		assert.Equal(t, "7", deposit.ID)
+	})
+
+	t.Run("retry of a synchronous deposit", func(t *testing.T) {
+		ctx := operation.WithIdempotencyKey(context.Background(), "key2")
+		mockIdempotency.EXPECT().Reserve(ctx, gomock.Any()).DoAndReturn(replay)
+		mockDeposits.EXPECT().GetQueuedDepositByKey(ctx, "user1", "key2").Return(nil, postgres.ErrQueuedDepositNotFound)
+
+		deposit, err := service.QueueDeposit(ctx, "user1", amount)
+		assert.NoError(t, err)
+		assert.Nil(t, deposit)
+	})
+
+	t.Run("unsupported without a queue", func(t *testing.T) {
+		_, err := NewWalletService(mockRepo, nil, logrus.New()).QueueDeposit(context.Background(), "user1", amount)
+		assert.ErrorIs(t, err, ErrQueuedDepositsUnsupported)
+	})
+}
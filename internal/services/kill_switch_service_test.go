@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestKillSwitchService_Check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockKillSwitchRepository(ctrl)
+	service := NewKillSwitchService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("engaged", func(t *testing.T) {
+		mockRepo.EXPECT().GetKillSwitch(ctx, models.KillSwitchDeposits).
+			Return(&models.KillSwitch{Operation: models.KillSwitchDeposits, Message: "bank outage"}, nil)
+
+		err := service.Check(ctx, models.KillSwitchDeposits)
+		assert.ErrorIs(t, err, ErrOperationDisabled)
+		assert.EqualError(t, err, "deposits are temporarily disabled: bank outage")
+	})
+
+	t.Run("not engaged", func(t *testing.T) {
+		mockRepo.EXPECT().GetKillSwitch(ctx, models.KillSwitchDeposits).Return(nil, nil)
+
+		assert.NoError(t, service.Check(ctx, models.KillSwitchDeposits))
+	})
+
+	t.Run("unreadable switches allow the operation", func(t *testing.T) {
+		mockRepo.EXPECT().GetKillSwitch(ctx, models.KillSwitchDeposits).Return(nil, errors.New("connection refused"))
+
+		assert.NoError(t, service.Check(ctx, models.KillSwitchDeposits))
+	})
+}
+
+func TestKillSwitchService_DisableEnable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockKillSwitchRepository(ctrl)
+	service := NewKillSwitchService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("disable records the operator", func(t *testing.T) {
+		mockRepo.EXPECT().SetKillSwitch(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, killSwitch models.KillSwitch) error {
+			assert.Equal(t, models.KillSwitchPayouts, killSwitch.Operation)
+			assert.Equal(t, "provider outage", killSwitch.Message)
+			assert.Equal(t, "admin1", killSwitch.DisabledBy)
+			assert.False(t, killSwitch.DisabledAt.IsZero())
+			return nil
+		})
+
+		killSwitch, err := service.Disable(ctx, models.KillSwitchPayouts, "provider outage")
+		require.NoError(t, err)
+		assert.Equal(t, "admin1", killSwitch.DisabledBy)
+	})
+
+	t.Run("enable", func(t *testing.T) {
+		mockRepo.EXPECT().ClearKillSwitch(ctx, models.KillSwitchPayouts).Return(nil)
+
+		assert.NoError(t, service.Enable(ctx, models.KillSwitchPayouts))
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		_, err := service.Disable(ctx, "refunds", "outage")
+		assert.ErrorIs(t, err, ErrUnknownKillSwitch)
+		assert.ErrorIs(t, service.Enable(ctx, "refunds"), ErrUnknownKillSwitch)
+	})
+}
+
+func TestWithdrawalWorker_PayoutsDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWithdrawalRepository(ctrl)
+	mockSwitches := mocks.NewMockKillSwitchRepository(ctrl)
+	worker := NewWithdrawalWorker(mockRepo, nil, nil, NewKillSwitchService(mockSwitches, logrus.New()), 10, 0, logrus.New())
+	ctx := context.Background()
+
+	// Nothing is claimed, so requested withdrawals wait for payouts to resume
+	mockSwitches.EXPECT().GetKillSwitch(ctx, models.KillSwitchPayouts).Return(&models.KillSwitch{Operation: models.KillSwitchPayouts}, nil)
+
+	settled, err := worker.ProcessBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, settled)
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	ErrLimitExceeded     = errors.New("transaction limit exceeded")
+	ErrUnknownLimit      = errors.New("operation must be withdrawal or transfer and kind one of single_amount, daily_amount, weekly_amount, hourly_count, daily_count")
+	ErrInvalidLimitValue = errors.New("limit value must be positive, and a whole number for count limits")
+
+	ErrNoLimitToIncrease         = errors.New("no limit applies to this operation and kind")
+	ErrLimitNotIncreased         = errors.New("requested value must be above the current limit")
+	ErrInvalidLimitRequestFilter = errors.New("status must be pending, approved or rejected")
+)
+
+// limitRequestsLimit caps the increase requests returned by a listing
+const limitRequestsLimit = 100
+
+var limitOperations = []string{models.LimitOperationWithdrawal, models.LimitOperationTransfer}
+
+type limitKind struct {
+	// window is zero for limits on a single operation
+	window time.Duration
+	// count limits cap the number of operations instead of their amount
+	count bool
+}
+
+var limitKinds = map[string]limitKind{
+	models.LimitSingleAmount: {},
+	models.LimitDailyAmount:  {window: 24 * time.Hour},
+	models.LimitWeeklyAmount: {window: 7 * 24 * time.Hour},
+	models.LimitHourlyCount:  {window: time.Hour, count: true},
+	models.LimitDailyCount:   {window: 24 * time.Hour, count: true},
+}
+
+// LimitExceededError reports the limit an operation would break and how much
+// of it is already used. It matches ErrLimitExceeded.
+type LimitExceededError struct {
+	Operation string          `json:"operation"`
+	Kind      string          `json:"kind"`
+	Value     decimal.Decimal `json:"value"`
+	Override  bool            `json:"override"`
+	Used      decimal.Decimal `json:"used"`
+	Remaining decimal.Decimal `json:"remaining"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %s for %s exceeded", e.Kind, e.Value, e.Operation)
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// LimitsService enforces per-user transaction limits on withdrawals and
+// transfers: a maximum single amount, daily and weekly amount caps and hourly
+// and daily count limits. Defaults apply to every user unless overridden.
+// Limits are checked before the operation is applied, so concurrent requests
+// of one user can together overshoot a cap by the requests in flight.
+//
+// Users may ask for a limit to be raised. Increases up to the auto approve
+// ratio above the current value apply at once; larger ones wait for an admin.
+type LimitsService struct {
+	repo             postgres.LimitsRepository
+	autoApproveRatio decimal.Decimal
+	logger           *logrus.Logger
+}
+
+type LimitsServiceOption func(*LimitsService)
+
+// WithAutoApproveRatio approves increase requests of at most ratio above the
+// current limit, so 0.5 approves raising a limit of 1000 up to 1500. With a
+// zero ratio every request waits for an admin.
+func WithAutoApproveRatio(ratio decimal.Decimal) LimitsServiceOption {
+	return func(s *LimitsService) {
+		s.autoApproveRatio = ratio
+	}
+}
+
+func NewLimitsService(repo postgres.LimitsRepository, logger *logrus.Logger, opts ...LimitsServiceOption) *LimitsService {
+	s := &LimitsService{
+		repo:   repo,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Check returns a *LimitExceededError when a txnType of amount would break
+// one of the limits of userID
+func (s *LimitsService) Check(ctx context.Context, userID, txnType string, amount decimal.Decimal) error {
+	return s.CheckBatch(ctx, userID, txnType, []decimal.Decimal{amount})
+}
+
+// CheckBatch is Check for several operations of userID applied together: each
+// amount is held to the single amount limit, their total and count to the
+// window limits
+func (s *LimitsService) CheckBatch(ctx context.Context, userID, txnType string, amounts []decimal.Decimal) error {
+	limits, err := s.Effective(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	total := decimal.Sum(decimal.Zero, amounts...)
+	largest := decimal.Max(decimal.Zero, amounts...)
+	operations := decimal.NewFromInt(int64(len(amounts)))
+
+	usage := map[time.Duration]models.LimitUsage{}
+	for _, limit := range limits {
+		if limit.Operation != txnType {
+			continue
+		}
+
+		exceeded := &LimitExceededError{
+			Operation: limit.Operation,
+			Kind:      limit.Kind,
+			Value:     limit.Value,
+			Override:  limit.UserID != "",
+		}
+		kind := limitKinds[limit.Kind]
+		if kind.window == 0 {
+			if largest.GreaterThan(limit.Value) {
+				exceeded.Remaining = limit.Value
+				return s.exceeded(ctx, userID, exceeded)
+			}
+			continue
+		}
+
+		used, ok := usage[kind.window]
+		if !ok {
+			used, err = s.repo.Usage(ctx, userID, txnType, time.Now().Add(-kind.window))
+			if err != nil {
+				return err
+			}
+			usage[kind.window] = used
+		}
+
+		if kind.count {
+			count := decimal.NewFromInt(int64(used.Count))
+			if count.Add(operations).GreaterThan(limit.Value) {
+				exceeded.Used = count
+				exceeded.Remaining = decimal.Max(limit.Value.Sub(count), decimal.Zero)
+				return s.exceeded(ctx, userID, exceeded)
+			}
+		} else if used.Amount.Add(total).GreaterThan(limit.Value) {
+			exceeded.Used = used.Amount
+			exceeded.Remaining = decimal.Max(limit.Value.Sub(used.Amount), decimal.Zero)
+			return s.exceeded(ctx, userID, exceeded)
+		}
+	}
+	return nil
+}
+
+func (s *LimitsService) exceeded(ctx context.Context, userID string, err *LimitExceededError) error {
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID":    userID,
+		"operation": err.Operation,
+		"kind":      err.Kind,
+		"limit":     err.Value,
+		"used":      err.Used,
+	}).Warn("Transaction limit exceeded")
+	return err
+}
+
+// Defaults returns the limits that apply to users without an override
+func (s *LimitsService) Defaults(ctx context.Context) ([]models.Limit, error) {
+	return s.repo.ListLimits(ctx, "")
+}
+
+// Effective returns the limits that apply to userID: the user's own limits
+// and the defaults they do not override
+func (s *LimitsService) Effective(ctx context.Context, userID string) ([]models.Limit, error) {
+	limits, err := s.repo.ListLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := make([]models.Limit, 0, len(limits))
+	for _, limit := range limits {
+		if limit.UserID == "" && slices.ContainsFunc(limits, func(override models.Limit) bool {
+			return override.UserID != "" && override.Operation == limit.Operation && override.Kind == limit.Kind
+		}) {
+			continue
+		}
+		effective = append(effective, limit)
+	}
+	return effective, nil
+}
+
+// Set stores a limit of userID, or a default with an empty userID. The actor
+// of the operation is recorded.
+func (s *LimitsService) Set(ctx context.Context, userID, txnType, kind string, value decimal.Decimal, reason string) (*models.Limit, error) {
+	if err := validateLimit(txnType, kind, value); err != nil {
+		return nil, err
+	}
+
+	op, _ := operation.From(ctx)
+	limit := &models.Limit{
+		UserID:    userID,
+		Operation: txnType,
+		Kind:      kind,
+		Value:     value,
+		Reason:    reason,
+		UpdatedBy: op.Actor,
+	}
+	if err := s.repo.PutLimit(ctx, limit); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+func validateLimit(txnType, kind string, value decimal.Decimal) error {
+	if !slices.Contains(limitOperations, txnType) {
+		return ErrUnknownLimit
+	}
+	definition, ok := limitKinds[kind]
+	if !ok {
+		return ErrUnknownLimit
+	}
+	if !value.IsPositive() || (definition.count && !value.IsInteger()) {
+		return ErrInvalidLimitValue
+	}
+	return nil
+}
+
+// Clear removes a limit of userID, or a default with an empty userID
+func (s *LimitsService) Clear(ctx context.Context, userID, txnType, kind string) error {
+	return s.repo.DeleteLimit(ctx, userID, txnType, kind)
+}
+
+// Status returns the limits that apply to userID with how much of each window
+// limit the user has used and has left
+func (s *LimitsService) Status(ctx context.Context, userID string) ([]models.LimitStatus, error) {
+	limits, err := s.Effective(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type usageKey struct {
+		operation string
+		window    time.Duration
+	}
+	usage := map[usageKey]models.LimitUsage{}
+	statuses := make([]models.LimitStatus, 0, len(limits))
+	for _, limit := range limits {
+		status := models.LimitStatus{Limit: limit}
+		kind := limitKinds[limit.Kind]
+		if kind.window == 0 {
+			statuses = append(statuses, status)
+			continue
+		}
+
+		key := usageKey{operation: limit.Operation, window: kind.window}
+		used, ok := usage[key]
+		if !ok {
+			used, err = s.repo.Usage(ctx, userID, limit.Operation, time.Now().Add(-kind.window))
+			if err != nil {
+				return nil, err
+			}
+			usage[key] = used
+		}
+
+		amount := used.Amount
+		if kind.count {
+			amount = decimal.NewFromInt(int64(used.Count))
+		}
+		remaining := decimal.Max(limit.Value.Sub(amount), decimal.Zero)
+		status.Used = &amount
+		status.Remaining = &remaining
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// RequestIncrease asks for a limit of userID to be raised to value. The
+// request is approved at once when the increase is within the auto approve
+// ratio and otherwise stays pending until an admin decides. The actor of the
+// operation is recorded as the requester.
+func (s *LimitsService) RequestIncrease(ctx context.Context, userID, txnType, kind string, value decimal.Decimal, reason string) (*models.LimitIncreaseRequest, error) {
+	if err := validateLimit(txnType, kind, value); err != nil {
+		return nil, err
+	}
+
+	limits, err := s.Effective(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(limits, func(limit models.Limit) bool {
+		return limit.Operation == txnType && limit.Kind == kind
+	})
+	if index < 0 {
+		return nil, ErrNoLimitToIncrease
+	}
+	current := limits[index].Value
+	if !value.GreaterThan(current) {
+		return nil, ErrLimitNotIncreased
+	}
+
+	op, _ := operation.From(ctx)
+	request := &models.LimitIncreaseRequest{
+		UserID:         userID,
+		Operation:      txnType,
+		Kind:           kind,
+		CurrentValue:   current,
+		RequestedValue: value,
+		Reason:         reason,
+		Status:         models.LimitRequestPending,
+		RequestedBy:    op.Actor,
+	}
+	if s.autoApproveRatio.IsPositive() && value.LessThanOrEqual(current.Mul(decimal.NewFromInt(1).Add(s.autoApproveRatio))) {
+		request.Status = models.LimitRequestApproved
+		request.AutoApproved = true
+	}
+
+	if err := s.repo.CreateIncreaseRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// IncreaseRequests returns the latest increase requests of userID, or of
+// every user with an empty userID, optionally only those with status
+func (s *LimitsService) IncreaseRequests(ctx context.Context, userID, status string) ([]models.LimitIncreaseRequest, error) {
+	switch status {
+	case "", models.LimitRequestPending, models.LimitRequestApproved, models.LimitRequestRejected:
+	default:
+		return nil, ErrInvalidLimitRequestFilter
+	}
+	return s.repo.ListIncreaseRequests(ctx, userID, status, limitRequestsLimit)
+}
+
+// ApproveIncrease approves a pending increase request and raises the limit,
+// with the actor of the operation as the approver
+func (s *LimitsService) ApproveIncrease(ctx context.Context, requestID, reason string) (*models.LimitIncreaseRequest, error) {
+	op, _ := operation.From(ctx)
+	return s.repo.DecideIncreaseRequest(ctx, requestID, models.LimitRequestApproved, op.Actor, reason)
+}
+
+// RejectIncrease rejects a pending increase request, with the actor of the
+// operation as the reviewer
+func (s *LimitsService) RejectIncrease(ctx context.Context, requestID, reason string) (*models.LimitIncreaseRequest, error) {
+	op, _ := operation.From(ctx)
+	return s.repo.DecideIncreaseRequest(ctx, requestID, models.LimitRequestRejected, op.Actor, reason)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestLimitsService_Check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLimitsRepository(ctrl)
+	service := NewLimitsService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	limits := []models.Limit{
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitSingleAmount, Value: decimal.NewFromInt(500)},
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(1000)},
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyCount, Value: decimal.NewFromInt(5)},
+		{Operation: models.LimitOperationTransfer, Kind: models.LimitHourlyCount, Value: decimal.NewFromInt(3)},
+		// Overrides the default daily cap
+		{UserID: "user1", Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(2000)},
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+		// Daily amount and count share one usage query
+		mockRepo.EXPECT().Usage(ctx, "user1", models.LimitOperationWithdrawal, gomock.Any()).Return(models.LimitUsage{Amount: decimal.NewFromInt(1200), Count: 2}, nil)
+
+		assert.NoError(t, service.Check(ctx, "user1", models.LimitOperationWithdrawal, decimal.NewFromInt(400)))
+	})
+
+	t.Run("single amount", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+
+		err := service.Check(ctx, "user1", models.LimitOperationWithdrawal, decimal.NewFromInt(600))
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.Equal(t, &LimitExceededError{
+			Operation: models.LimitOperationWithdrawal,
+			Kind:      models.LimitSingleAmount,
+			Value:     decimal.NewFromInt(500),
+			Remaining: decimal.NewFromInt(500),
+		}, err)
+	})
+
+	t.Run("daily amount override", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+		mockRepo.EXPECT().Usage(ctx, "user1", models.LimitOperationWithdrawal, gomock.Any()).Return(models.LimitUsage{Amount: decimal.NewFromInt(1800), Count: 2}, nil)
+
+		err := service.Check(ctx, "user1", models.LimitOperationWithdrawal, decimal.NewFromInt(300))
+		assert.Equal(t, &LimitExceededError{
+			Operation: models.LimitOperationWithdrawal,
+			Kind:      models.LimitDailyAmount,
+			Value:     decimal.NewFromInt(2000),
+			Override:  true,
+			Used:      decimal.NewFromInt(1800),
+			Remaining: decimal.NewFromInt(200),
+		}, err)
+	})
+
+	t.Run("velocity", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user2").Return(limits[:4], nil)
+		mockRepo.EXPECT().Usage(ctx, "user2", models.LimitOperationTransfer, gomock.Any()).Return(models.LimitUsage{Amount: decimal.NewFromInt(30), Count: 3}, nil)
+
+		err := service.Check(ctx, "user2", models.LimitOperationTransfer, decimal.NewFromInt(1))
+		var exceeded *LimitExceededError
+		assert.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, models.LimitHourlyCount, exceeded.Kind)
+		assert.Equal(t, "hourly_count limit of 3 for transfer exceeded", err.Error())
+	})
+}
+
+func TestLimitsService_Set(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLimitsRepository(ctrl)
+	service := NewLimitsService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("override", func(t *testing.T) {
+		mockRepo.EXPECT().PutLimit(ctx, &models.Limit{
+			UserID:    "user1",
+			Operation: models.LimitOperationTransfer,
+			Kind:      models.LimitWeeklyAmount,
+			Value:     decimal.NewFromInt(25000),
+			Reason:    "verified business",
+			UpdatedBy: "admin1",
+		}).Return(nil)
+
+		_, err := service.Set(ctx, "user1", models.LimitOperationTransfer, models.LimitWeeklyAmount, decimal.NewFromInt(25000), "verified business")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := service.Set(ctx, "user1", "deposit", models.LimitDailyAmount, decimal.NewFromInt(10), "")
+		assert.ErrorIs(t, err, ErrUnknownLimit)
+		_, err = service.Set(ctx, "user1", models.LimitOperationTransfer, "monthly_amount", decimal.NewFromInt(10), "")
+		assert.ErrorIs(t, err, ErrUnknownLimit)
+		_, err = service.Set(ctx, "user1", models.LimitOperationTransfer, models.LimitDailyCount, decimal.RequireFromString("2.5"), "")
+		assert.ErrorIs(t, err, ErrInvalidLimitValue)
+		_, err = service.Set(ctx, "user1", models.LimitOperationTransfer, models.LimitDailyAmount, decimal.Zero, "")
+		assert.ErrorIs(t, err, ErrInvalidLimitValue)
+	})
+}
+
+func TestLimitsService_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLimitsRepository(ctrl)
+	service := NewLimitsService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ListLimits(ctx, "user1").Return([]models.Limit{
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitSingleAmount, Value: decimal.NewFromInt(500)},
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(1000)},
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyCount, Value: decimal.NewFromInt(2)},
+	}, nil)
+	mockRepo.EXPECT().Usage(ctx, "user1", models.LimitOperationWithdrawal, gomock.Any()).Return(models.LimitUsage{Amount: decimal.NewFromInt(400), Count: 3}, nil)
+
+	statuses, err := service.Status(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Nil(t, statuses[0].Used)
+	assert.True(t, statuses[1].Remaining.Equal(decimal.NewFromInt(600)))
+	assert.True(t, statuses[2].Used.Equal(decimal.NewFromInt(3)))
+	assert.True(t, statuses[2].Remaining.IsZero())
+}
+
+func TestLimitsService_RequestIncrease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockLimitsRepository(ctrl)
+	service := NewLimitsService(mockRepo, logrus.New(), WithAutoApproveRatio(decimal.RequireFromString("0.5")))
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "user1", Channel: operation.ChannelAPI})
+	limits := []models.Limit{
+		{Operation: models.LimitOperationWithdrawal, Kind: models.LimitDailyAmount, Value: decimal.NewFromInt(1000)},
+	}
+
+	t.Run("small increase is approved", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+		mockRepo.EXPECT().CreateIncreaseRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request *models.LimitIncreaseRequest) error {
+			assert.Equal(t, models.LimitRequestApproved, request.Status)
+			assert.True(t, request.AutoApproved)
+			assert.True(t, request.CurrentValue.Equal(decimal.NewFromInt(1000)))
+			assert.Equal(t, "user1", request.RequestedBy)
+			return nil
+		})
+
+		_, err := service.RequestIncrease(ctx, "user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, decimal.NewFromInt(1500), "rent")
+		assert.NoError(t, err)
+	})
+
+	t.Run("large increase waits for an admin", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+		mockRepo.EXPECT().CreateIncreaseRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request *models.LimitIncreaseRequest) error {
+			assert.Equal(t, models.LimitRequestPending, request.Status)
+			assert.False(t, request.AutoApproved)
+			return nil
+		})
+
+		_, err := service.RequestIncrease(ctx, "user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, decimal.NewFromInt(1501), "house")
+		assert.NoError(t, err)
+	})
+
+	t.Run("not an increase", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+
+		_, err := service.RequestIncrease(ctx, "user1", models.LimitOperationWithdrawal, models.LimitDailyAmount, decimal.NewFromInt(1000), "")
+		assert.ErrorIs(t, err, ErrLimitNotIncreased)
+	})
+
+	t.Run("no limit applies", func(t *testing.T) {
+		mockRepo.EXPECT().ListLimits(ctx, "user1").Return(limits, nil)
+
+		_, err := service.RequestIncrease(ctx, "user1", models.LimitOperationTransfer, models.LimitDailyAmount, decimal.NewFromInt(1000), "")
+		assert.ErrorIs(t, err, ErrNoLimitToIncrease)
+	})
+}
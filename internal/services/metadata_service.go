@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// Bounds of the metadata of a wallet
+const (
+	MaxMetadataKeys        = 50
+	maxMetadataValueLength = 500
+)
+
+var ErrInvalidMetadata = errors.New("metadata holds at most 50 keys of 1 to 40 letters, digits, _ or -, with values of at most 500 characters")
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,40}$`)
+
+// MetadataService lets the client application owning a wallet store
+// key-value metadata on it, such as its own customer reference
+type MetadataService struct {
+	repo   postgres.MetadataRepository
+	logger *logrus.Logger
+}
+
+func NewMetadataService(repo postgres.MetadataRepository, logger *logrus.Logger) *MetadataService {
+	return &MetadataService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Get returns the metadata of the wallet of userID
+func (s *MetadataService) Get(ctx context.Context, userID string) (*models.WalletMetadata, error) {
+	return s.repo.GetMetadata(ctx, userID)
+}
+
+// Set replaces the metadata of the wallet of userID. When expectedVersion
+// is set the metadata must still be at that version.
+func (s *MetadataService) Set(ctx context.Context, userID string, metadata map[string]string, expectedVersion *int64) (*models.WalletMetadata, error) {
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	walletMetadata := &models.WalletMetadata{UserID: userID, Metadata: metadata}
+	if err := s.repo.SetMetadata(ctx, walletMetadata, expectedVersion); err != nil {
+		return nil, err
+	}
+	return walletMetadata, nil
+}
+
+// validateMetadata checks metadata against the bounds of wallet metadata.
+// Admin listings filter with the same rules.
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return ErrInvalidMetadata
+	}
+	for key, value := range metadata {
+		if !metadataKeyPattern.MatchString(key) || utf8.RuneCountInString(value) > maxMetadataValueLength {
+			return ErrInvalidMetadata
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestMetadataService_Set(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMetadataRepository(ctrl)
+	service := NewMetadataService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("replaces the metadata", func(t *testing.T) {
+		expected := int64(3)
+		mockRepo.EXPECT().SetMetadata(ctx, gomock.Any(), &expected).DoAndReturn(
+			func(_ context.Context, metadata *models.WalletMetadata, _ *int64) error {
+				assert.Equal(t, "user1", metadata.UserID)
+				assert.Equal(t, map[string]string{"crm_id": "C-42"}, metadata.Metadata)
+				metadata.Version = 4
+				return nil
+			})
+
+		metadata, err := service.Set(ctx, "user1", map[string]string{"crm_id": "C-42"}, &expected)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), metadata.Version)
+	})
+
+	t.Run("clears the metadata", func(t *testing.T) {
+		mockRepo.EXPECT().SetMetadata(ctx, &models.WalletMetadata{UserID: "user1", Metadata: map[string]string{}}, nil).Return(nil)
+
+		_, err := service.Set(ctx, "user1", nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("passes version mismatches on", func(t *testing.T) {
+		expected := int64(1)
+		mockRepo.EXPECT().SetMetadata(ctx, gomock.Any(), &expected).Return(postgres.ErrMetadataVersionMismatch)
+
+		_, err := service.Set(ctx, "user1", map[string]string{"crm_id": "C-42"}, &expected)
+		assert.ErrorIs(t, err, postgres.ErrMetadataVersionMismatch)
+	})
+
+	t.Run("rejects metadata out of bounds", func(t *testing.T) {
+		tooMany := map[string]string{}
+		for i := 0; i <= MaxMetadataKeys; i++ {
+			tooMany[fmt.Sprintf("key%d", i)] = "value"
+		}
+
+		for name, metadata := range map[string]map[string]string{
+			"too many keys": tooMany,
+			"empty key":     {"": "value"},
+			"long key":      {strings.Repeat("k", 41): "value"},
+			"invalid key":   {"crm id": "value"},
+			"long value":    {"note": strings.Repeat("é", 501)},
+		} {
+			_, err := service.Set(ctx, "user1", metadata, nil)
+			assert.ErrorIs(t, err, ErrInvalidMetadata, name)
+		}
+	})
+}
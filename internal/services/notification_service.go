@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// MinDigestWindow and MaxDigestWindow bound how long the events of a user
+// wait for their digest
+const (
+	MinDigestWindow = 5 * time.Minute
+	MaxDigestWindow = 24 * time.Hour
+)
+
+var ErrInvalidNotificationPreference = errors.New("mode must be immediate or digest, and window_seconds between 300 and 86400")
+
+// NotificationService lets users choose between a notification per money
+// movement and digests, and sends the digests that are due. Events of users
+// in digest mode are added to their digest as they are published.
+type NotificationService struct {
+	repo          postgres.NotificationRepository
+	defaultWindow time.Duration
+	batchSize     int
+	logger        *logrus.Logger
+}
+
+// NewNotificationService creates a NotificationService whose digests cover
+// defaultWindow unless users choose their own, and which sends at most
+// batchSize digests per run
+func NewNotificationService(repo postgres.NotificationRepository, defaultWindow time.Duration, batchSize int, logger *logrus.Logger) *NotificationService {
+	return &NotificationService{
+		repo:          repo,
+		defaultWindow: defaultWindow,
+		batchSize:     batchSize,
+		logger:        logger,
+	}
+}
+
+// Get returns how userID is notified
+func (s *NotificationService) Get(ctx context.Context, userID string) (*models.NotificationPreference, error) {
+	return s.repo.GetNotificationPreference(ctx, userID)
+}
+
+// Set changes how userID is notified. A digest covers window, or the
+// default window of the service when nil.
+func (s *NotificationService) Set(ctx context.Context, userID, mode string, window *time.Duration) (*models.NotificationPreference, error) {
+	preference := &models.NotificationPreference{UserID: userID, Mode: mode}
+	switch mode {
+	case models.NotificationImmediate:
+	case models.NotificationDigest:
+		digestWindow := s.defaultWindow
+		if window != nil {
+			digestWindow = *window
+		}
+		if digestWindow < MinDigestWindow || digestWindow > MaxDigestWindow {
+			return nil, ErrInvalidNotificationPreference
+		}
+		preference.WindowSeconds = int(digestWindow / time.Second)
+	default:
+		return nil, ErrInvalidNotificationPreference
+	}
+
+	if err := s.repo.SetNotificationPreference(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// Run sends the digests due immediately and then on each interval until ctx
+// is cancelled
+func (s *NotificationService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "notification-digests", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.SendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends up to batchSize digests whose window has passed and returns
+// how many it sent
+func (s *NotificationService) SendDue(ctx context.Context) (int, error) {
+	userIDs, err := s.repo.DueDigests(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("SendDue - Query due digests failed, will retry")
+		return 0, err
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		summarized, err := s.repo.SendDigest(ctx, userID)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Error("SendDue - Send digest failed, will retry")
+			return sent, err
+		}
+		if summarized > 0 {
+			sent++
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestNotificationService_Set(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	service := NewNotificationService(mockRepo, time.Hour, 100, logrus.New())
+	ctx := context.Background()
+
+	t.Run("digests cover the default window", func(t *testing.T) {
+		mockRepo.EXPECT().SetNotificationPreference(ctx, &models.NotificationPreference{UserID: "user1", Mode: models.NotificationDigest, WindowSeconds: 3600}).Return(nil)
+
+		preference, err := service.Set(ctx, "user1", models.NotificationDigest, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3600, preference.WindowSeconds)
+	})
+
+	t.Run("digests cover the chosen window", func(t *testing.T) {
+		window := 15 * time.Minute
+		mockRepo.EXPECT().SetNotificationPreference(ctx, &models.NotificationPreference{UserID: "user1", Mode: models.NotificationDigest, WindowSeconds: 900}).Return(nil)
+
+		_, err := service.Set(ctx, "user1", models.NotificationDigest, &window)
+		require.NoError(t, err)
+	})
+
+	t.Run("switches back to immediate", func(t *testing.T) {
+		mockRepo.EXPECT().SetNotificationPreference(ctx, &models.NotificationPreference{UserID: "user1", Mode: models.NotificationImmediate}).Return(nil)
+
+		_, err := service.Set(ctx, "user1", models.NotificationImmediate, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects an unknown mode or a window out of bounds", func(t *testing.T) {
+		_, err := service.Set(ctx, "user1", "weekly", nil)
+		assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+
+		for _, window := range []time.Duration{time.Minute, 48 * time.Hour} {
+			_, err = service.Set(ctx, "user1", models.NotificationDigest, &window)
+			assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+		}
+	})
+}
+
+func TestNotificationService_SendDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	service := NewNotificationService(mockRepo, time.Hour, 100, logrus.New())
+	ctx := context.Background()
+
+	t.Run("sends the due digests", func(t *testing.T) {
+		mockRepo.EXPECT().DueDigests(ctx, gomock.Any(), 100).Return([]string{"user1", "user2"}, nil)
+		mockRepo.EXPECT().SendDigest(ctx, "user1").Return(3, nil)
+		// Sent by another instance
+		mockRepo.EXPECT().SendDigest(ctx, "user2").Return(0, nil)
+
+		sent, err := service.SendDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		mockRepo.EXPECT().DueDigests(ctx, gomock.Any(), 100).Return([]string{"user1", "user2"}, nil)
+		mockRepo.EXPECT().SendDigest(ctx, "user1").Return(0, errors.New("connection reset"))
+
+		sent, err := service.SendDue(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 0, sent)
+	})
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// OutboxRelay forwards events recorded in the outbox to a publisher
+type OutboxRelay struct {
+	outbox       postgres.OutboxRepository
+	publisher    events.Publisher
+	batchSize    int
+	logger       *logrus.Logger
+	subscription *WebhookSubscriptionService
+}
+
+// OutboxRelayOption configures optional OutboxRelay features
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithWebhookSubscription holds deliveries back while integrators pause them
+func WithWebhookSubscription(subscription *WebhookSubscriptionService) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.subscription = subscription
+	}
+}
+
+func NewOutboxRelay(outbox postgres.OutboxRepository, publisher events.Publisher, batchSize int, logger *logrus.Logger, opts ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run flushes the outbox immediately and then on each interval until ctx is
+// cancelled
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = r.Flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes pending events batch by batch until the outbox is drained
+// or publishing fails. It returns the number of events published, none while
+// deliveries are paused.
+func (r *OutboxRelay) Flush(ctx context.Context) (int, error) {
+	if r.subscription != nil && !r.subscription.Deliverable(ctx) {
+		return 0, nil
+	}
+
+	total := 0
+	for {
+		published, err := r.outbox.Dispatch(ctx, r.batchSize, r.publisher.Publish)
+		total += published
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithField("published", total).Error("Flush - Dispatch events failed")
+			return total, err
+		}
+		if published < r.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		r.logger.WithContext(ctx).WithField("published", total).Debug("Outbox flushed")
+	}
+	return total, nil
+}
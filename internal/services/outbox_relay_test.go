@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+	mockPublisher := mocks.NewMockPublisher(ctrl)
+	relay := NewOutboxRelay(mockOutbox, mockPublisher, 2, logrus.New())
+
+	frozen := events.New(events.TypeWalletFrozen, events.WalletLifecycleChanged{
+		UserID:   "user1",
+		Previous: &events.WalletState{Status: "active"},
+		Current:  events.WalletState{Status: "frozen"},
+	})
+	created := events.New(events.TypeWalletCreated, events.WalletLifecycleChanged{
+		UserID:  "user2",
+		Current: events.WalletState{Status: "active"},
+	})
+
+	// dispatch hands the given events to the relay's publish func like the
+	// repository does, stopping at the first failure
+	dispatch := func(pending ...events.Event) func(context.Context, int, func(context.Context, events.Event) error) (int, error) {
+		return func(ctx context.Context, limit int, publish func(context.Context, events.Event) error) (int, error) {
+			for i, event := range pending {
+				if err := publish(ctx, event); err != nil {
+					return i, err
+				}
+			}
+			return len(pending), nil
+		}
+	}
+
+	t.Run("publishes lifecycle events until drained", func(t *testing.T) {
+		ctx := context.Background()
+		gomock.InOrder(
+			mockOutbox.EXPECT().Dispatch(ctx, 2, gomock.Any()).DoAndReturn(dispatch(frozen, created)),
+			mockOutbox.EXPECT().Dispatch(ctx, 2, gomock.Any()).DoAndReturn(dispatch()),
+		)
+		gomock.InOrder(
+			mockPublisher.EXPECT().Publish(ctx, frozen).Return(nil),
+			mockPublisher.EXPECT().Publish(ctx, created).Return(nil),
+		)
+
+		published, err := relay.Flush(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, published)
+	})
+
+	t.Run("stops on publish failure", func(t *testing.T) {
+		ctx := context.Background()
+		mockOutbox.EXPECT().Dispatch(ctx, 2, gomock.Any()).DoAndReturn(dispatch(frozen, created))
+		mockPublisher.EXPECT().Publish(ctx, frozen).Return(nil)
+		mockPublisher.EXPECT().Publish(ctx, created).Return(errors.New("broker unavailable"))
+
+		published, err := relay.Flush(ctx)
+		assert.ErrorContains(t, err, "broker unavailable")
+		assert.Equal(t, 1, published)
+	})
+
+	t.Run("holds deliveries back while paused", func(t *testing.T) {
+		ctx := context.Background()
+		mockSubscriptions := mocks.NewMockWebhookSubscriptionRepository(ctrl)
+		subscription := NewWebhookSubscriptionService(mockSubscriptions, WebhookBacklogConfig{}, nil, logrus.New())
+		paused := NewOutboxRelay(mockOutbox, mockPublisher, 2, logrus.New(), WithWebhookSubscription(subscription))
+		now := time.Now()
+		mockSubscriptions.EXPECT().GetWebhookSubscription(ctx).Return(&models.WebhookSubscription{PausedAt: &now}, nil)
+
+		published, err := paused.Flush(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, published)
+	})
+}
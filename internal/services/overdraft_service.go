@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var ErrInvalidOverdraftLimit = errors.New("overdraft limit cannot be negative")
+
+// OverdraftService manages the credit lines that let withdrawals and
+// transfers take a wallet's balance below zero
+type OverdraftService struct {
+	repo   postgres.OverdraftRepository
+	logger *logrus.Logger
+}
+
+func NewOverdraftService(repo postgres.OverdraftRepository, logger *logrus.Logger) *OverdraftService {
+	return &OverdraftService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Get returns the credit line of the wallet of userID
+func (s *OverdraftService) Get(ctx context.Context, userID string) (*models.Overdraft, error) {
+	return s.repo.GetOverdraft(ctx, userID)
+}
+
+// SetLimit sets the credit line of the wallet of userID. A limit of zero
+// removes it.
+func (s *OverdraftService) SetLimit(ctx context.Context, userID string, limit decimal.Decimal) (*models.Overdraft, error) {
+	if limit.IsNegative() {
+		return nil, ErrInvalidOverdraftLimit
+	}
+
+	overdraft, err := s.repo.SetOverdraftLimit(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	op, _ := operation.From(ctx)
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": userID,
+		"limit":  limit,
+		"actor":  op.Actor,
+	}).Info("Overdraft limit set")
+	return overdraft, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestOverdraftService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockOverdraftRepository(ctrl)
+	service := NewOverdraftService(mockRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("SetLimit", func(t *testing.T) {
+		limit := decimal.NewFromInt(250)
+		mockRepo.EXPECT().SetOverdraftLimit(ctx, "user1", limit).Return(&models.Overdraft{UserID: "user1", Limit: limit}, nil)
+
+		overdraft, err := service.SetLimit(ctx, "user1", limit)
+		require.NoError(t, err)
+		assert.True(t, limit.Equal(overdraft.Limit))
+	})
+
+	t.Run("SetLimit rejects negative limits", func(t *testing.T) {
+		_, err := service.SetLimit(ctx, "user1", decimal.NewFromInt(-1))
+		assert.ErrorIs(t, err, ErrInvalidOverdraftLimit)
+	})
+}
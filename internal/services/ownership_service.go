@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// OwnershipService reassigns wallets to new user IDs and merges duplicate
+// wallets, e.g. after identity verification
+type OwnershipService struct {
+	repo   postgres.OwnershipRepository
+	cache  redis.CacheRepository
+	logger *logrus.Logger
+}
+
+func NewOwnershipService(repo postgres.OwnershipRepository, cache redis.CacheRepository, logger *logrus.Logger) *OwnershipService {
+	return &OwnershipService{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// ReassignWallet moves a wallet and its history from previousUserID to
+// newUserID. The actor of the operation is recorded.
+func (s *OwnershipService) ReassignWallet(ctx context.Context, previousUserID, newUserID, reason string) (*models.OwnershipChange, error) {
+	ctx = operation.WithReason(ctx, reason)
+	op, _ := operation.From(ctx)
+	change := &models.OwnershipChange{
+		PreviousUserID: previousUserID,
+		NewUserID:      newUserID,
+		Reason:         reason,
+		Actor:          op.Actor,
+	}
+
+	if err := s.repo.ReassignWallet(ctx, change); err != nil {
+		return nil, err
+	}
+
+	// The previous key must not keep serving a balance for a wallet that no
+	// longer exists under that ID
+	_ = s.cache.InvalidateBalance(ctx, previousUserID)
+	_ = s.cache.InvalidateBalance(ctx, newUserID)
+
+	return change, nil
+}
+
+// MergeWallets merges the duplicate wallet sourceUserID into targetUserID,
+// closing the source, and returns the merge report. The actor of the
+// operation is recorded.
+func (s *OwnershipService) MergeWallets(ctx context.Context, sourceUserID, targetUserID, reason string) (*models.WalletMerge, error) {
+	ctx = operation.WithReason(ctx, reason)
+	op, _ := operation.From(ctx)
+	merge := &models.WalletMerge{
+		SourceUserID: sourceUserID,
+		TargetUserID: targetUserID,
+		Reason:       reason,
+		Actor:        op.Actor,
+	}
+
+	if err := s.repo.MergeWallets(ctx, merge); err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, sourceUserID)
+	_ = s.cache.InvalidateBalance(ctx, targetUserID)
+
+	return merge, nil
+}
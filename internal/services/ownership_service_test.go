@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestOwnershipService_ReassignWallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockOwnershipRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewOwnershipService(mockRepo, mockCache, logrus.New())
+
+	t.Run("records actor and invalidates both balances", func(t *testing.T) {
+		ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+		// The reason travels with the operation to the recorded event
+		opCtx := withOperation(operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: "account merge"})
+		mockRepo.EXPECT().ReassignWallet(opCtx, &models.OwnershipChange{
+			PreviousUserID: "user1",
+			NewUserID:      "user9",
+			Reason:         "account merge",
+			Actor:          "admin1",
+		}).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user9").Return(nil)
+
+		change, err := service.ReassignWallet(ctx, "user1", "user9", "account merge")
+		assert.NoError(t, err)
+		assert.Equal(t, "admin1", change.Actor)
+	})
+
+	t.Run("cache untouched on failure", func(t *testing.T) {
+		mockRepo.EXPECT().ReassignWallet(gomock.Any(), gomock.Any()).Return(postgres.ErrWalletExists)
+
+		_, err := service.ReassignWallet(context.Background(), "user1", "user2", "account merge")
+		assert.ErrorIs(t, err, postgres.ErrWalletExists)
+	})
+}
+
+func TestOwnershipService_MergeWallets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockOwnershipRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewOwnershipService(mockRepo, mockCache, logrus.New())
+
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+	opCtx := withOperation(operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: "duplicate"})
+	mockRepo.EXPECT().MergeWallets(opCtx, &models.WalletMerge{
+		SourceUserID: "user1",
+		TargetUserID: "user9",
+		Reason:       "duplicate",
+		Actor:        "admin1",
+	}).Return(nil)
+	mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+	mockCache.EXPECT().InvalidateBalance(opCtx, "user9").Return(nil)
+
+	merge, err := service.MergeWallets(ctx, "user1", "user9", "duplicate")
+	assert.NoError(t, err)
+	assert.Equal(t, "user9", merge.TargetUserID)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	// MinPaymentRequestTTL and MaxPaymentRequestTTL bound how long a payment
+	// request stays open
+	MinPaymentRequestTTL = time.Minute
+	MaxPaymentRequestTTL = 30 * 24 * time.Hour
+
+	maxPaymentRequestNote = 140
+
+	defaultPaymentRequestLimit = 50
+	maxPaymentRequestLimit     = 200
+
+	// paymentRequestExpiryGrace keeps a request pending for a while after it
+	// expired, so a transfer accepted just before the expiry is recorded
+	paymentRequestExpiryGrace = time.Minute
+)
+
+var (
+	ErrInvalidPaymentRequest       = errors.New("a payment request needs a payer other than the requester, a note of at most 140 characters and expires_in_seconds between 60 and 2592000")
+	ErrInvalidPaymentRequestFilter = errors.New("side must be incoming or outgoing, and status pending, accepted, declined or expired")
+	ErrPaymentRequestExpired       = errors.New("payment request expired")
+)
+
+// PaymentRequestInput describes a payment request to create. ExpiresIn
+// defaults to the TTL of the service.
+type PaymentRequestInput struct {
+	PayerID   string
+	Amount    decimal.Decimal
+	Note      string
+	ExpiresIn *time.Duration
+}
+
+// PaymentRequestService lets users request payments from each other. The
+// payer accepts a request, which transfers the amount to the requester, or
+// declines it; requests left open expire.
+type PaymentRequestService struct {
+	repo      postgres.PaymentRequestRepository
+	wallets   *WalletService
+	ttl       time.Duration
+	batchSize int
+	logger    *logrus.Logger
+}
+
+// NewPaymentRequestService creates a PaymentRequestService whose requests
+// expire after ttl unless they set their own expiry, and which expires at
+// most batchSize requests per run
+func NewPaymentRequestService(repo postgres.PaymentRequestRepository, wallets *WalletService, ttl time.Duration, batchSize int, logger *logrus.Logger) *PaymentRequestService {
+	return &PaymentRequestService{
+		repo:      repo,
+		wallets:   wallets,
+		ttl:       ttl,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Create requests input.Amount from input.PayerID on behalf of requesterID
+func (s *PaymentRequestService) Create(ctx context.Context, requesterID string, input PaymentRequestInput) (*models.PaymentRequest, error) {
+	ttl := s.ttl
+	if input.ExpiresIn != nil {
+		ttl = *input.ExpiresIn
+	}
+	if ttl < MinPaymentRequestTTL || ttl > MaxPaymentRequestTTL || utf8.RuneCountInString(input.Note) > maxPaymentRequestNote {
+		return nil, ErrInvalidPaymentRequest
+	}
+
+	request := &models.PaymentRequest{
+		RequesterID: requesterID,
+		PayerID:     input.PayerID,
+		Amount:      input.Amount,
+		Note:        input.Note,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.repo.CreatePaymentRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// Get returns a request sent or received by userID
+func (s *PaymentRequestService) Get(ctx context.Context, userID, requestID string) (*models.PaymentRequest, error) {
+	return s.repo.GetPaymentRequest(ctx, userID, requestID)
+}
+
+// List returns the latest requests of userID, newest first, received when
+// side is incoming, sent when outgoing, both when empty, and with status
+// unless it is empty
+func (s *PaymentRequestService) List(ctx context.Context, userID, side, status string, limit int) ([]models.PaymentRequest, error) {
+	switch side {
+	case "", models.PaymentRequestIncoming, models.PaymentRequestOutgoing:
+	default:
+		return nil, ErrInvalidPaymentRequestFilter
+	}
+	switch status {
+	case "", models.PaymentRequestPending, models.PaymentRequestAccepted, models.PaymentRequestDeclined, models.PaymentRequestExpired:
+	default:
+		return nil, ErrInvalidPaymentRequestFilter
+	}
+	if limit <= 0 {
+		limit = defaultPaymentRequestLimit
+	}
+	return s.repo.ListPaymentRequests(ctx, userID, side, status, min(limit, maxPaymentRequestLimit))
+}
+
+// Accept transfers the amount of a pending request received by payerID to
+// its requester and records the request as accepted. The transfer goes
+// through the limits, compliance checks and fees of any transfer; it is keyed
+// by the request, so retrying an acceptance never pays twice.
+func (s *PaymentRequestService) Accept(ctx context.Context, payerID, requestID string) (*models.PaymentRequest, error) {
+	request, err := s.repo.GetPaymentRequest(ctx, payerID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.PayerID != payerID {
+		return nil, postgres.ErrPaymentRequestNotFound
+	}
+	if request.Status != models.PaymentRequestPending {
+		return nil, postgres.ErrPaymentRequestNotPending
+	}
+	if !time.Now().Before(request.ExpiresAt) {
+		return nil, ErrPaymentRequestExpired
+	}
+
+	ctx = operation.WithReason(ctx, "payment request "+request.ID)
+	transferCtx := operation.WithIdempotencyKey(ctx, "payment-request-"+request.ID)
+	if err := s.wallets.Transfer(transferCtx, payerID, request.RequesterID, request.Amount, nil); err != nil {
+		return nil, err
+	}
+
+	accepted, err := s.repo.DecidePaymentRequest(ctx, payerID, requestID, models.PaymentRequestAccepted)
+	if err != nil {
+		// The amount was transferred; accepting again replays the transfer
+		// and records the request
+		s.logger.WithContext(ctx).WithError(err).WithField("requestID", requestID).Error("Accept - Record accepted request failed")
+		return nil, err
+	}
+	return accepted, nil
+}
+
+// Decline declines a pending request received by payerID
+func (s *PaymentRequestService) Decline(ctx context.Context, payerID, requestID string) (*models.PaymentRequest, error) {
+	return s.repo.DecidePaymentRequest(ctx, payerID, requestID, models.PaymentRequestDeclined)
+}
+
+// Run expires the requests left open immediately and then on each interval
+// until ctx is cancelled
+func (s *PaymentRequestService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "payment-requests", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.ExpireDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireDue expires up to batchSize pending requests past their expiry and
+// returns how many it expired
+func (s *PaymentRequestService) ExpireDue(ctx context.Context) (int, error) {
+	expired, err := s.repo.ExpirePaymentRequests(ctx, time.Now().Add(-paymentRequestExpiryGrace), s.batchSize)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("ExpireDue - Expire payment requests failed, will retry")
+		return 0, err
+	}
+	return expired, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestPaymentRequestService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPaymentRequestRepository(ctrl)
+	service := NewPaymentRequestService(mockRepo, nil, 7*24*time.Hour, 100, logrus.New())
+	ctx := context.Background()
+
+	t.Run("expires after the default TTL", func(t *testing.T) {
+		mockRepo.EXPECT().CreatePaymentRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request *models.PaymentRequest) error {
+			assert.Equal(t, "user1", request.RequesterID)
+			assert.Equal(t, "user2", request.PayerID)
+			assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), request.ExpiresAt, time.Minute)
+			return nil
+		})
+
+		_, err := service.Create(ctx, "user1", PaymentRequestInput{PayerID: "user2", Amount: decimal.NewFromInt(20), Note: "Dinner"})
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects an expiry out of bounds", func(t *testing.T) {
+		expiresIn := 31 * 24 * time.Hour
+		_, err := service.Create(ctx, "user1", PaymentRequestInput{PayerID: "user2", Amount: decimal.NewFromInt(20), ExpiresIn: &expiresIn})
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+
+	t.Run("rejects a long note", func(t *testing.T) {
+		note := string(make([]rune, 141))
+		_, err := service.Create(ctx, "user1", PaymentRequestInput{PayerID: "user2", Amount: decimal.NewFromInt(20), Note: note})
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+}
+
+func TestPaymentRequestService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPaymentRequestRepository(ctrl)
+	service := NewPaymentRequestService(mockRepo, nil, time.Hour, 100, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ListPaymentRequests(ctx, "user2", models.PaymentRequestIncoming, models.PaymentRequestPending, defaultPaymentRequestLimit).
+		Return([]models.PaymentRequest{}, nil)
+	_, err := service.List(ctx, "user2", models.PaymentRequestIncoming, models.PaymentRequestPending, 0)
+	require.NoError(t, err)
+
+	_, err = service.List(ctx, "user2", "sideways", "", 10)
+	assert.ErrorIs(t, err, ErrInvalidPaymentRequestFilter)
+	_, err = service.List(ctx, "user2", "", "paid", 10)
+	assert.ErrorIs(t, err, ErrInvalidPaymentRequestFilter)
+}
+
+func TestPaymentRequestService_Accept(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPaymentRequestRepository(ctrl)
+	mockWallets := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	logger := logrus.New()
+	wallets := NewWalletService(mockWallets, mockCache, logger, WithIdempotency(mockIdempotency))
+	service := NewPaymentRequestService(mockRepo, wallets, time.Hour, 100, logger)
+	ctx := context.Background()
+
+	pending := func() *models.PaymentRequest {
+		return &models.PaymentRequest{
+			ID:          "9",
+			RequesterID: "user1",
+			PayerID:     "user2",
+			Amount:      decimal.NewFromInt(20),
+			Status:      models.PaymentRequestPending,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("transfers the amount under the key of the request", func(t *testing.T) {
+		opCtx := withOperation(operation.Operation{Reason: "payment request 9"})
+		transferCtx := withOperation(operation.Operation{Reason: "payment request 9", IdempotencyKey: "payment-request-9"})
+		mockRepo.EXPECT().GetPaymentRequest(ctx, "user2", "9").Return(pending(), nil)
+		mockIdempotency.EXPECT().Reserve(transferCtx, gomock.Any()).DoAndReturn(func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+			return record, true, nil
+		})
+		mockWallets.EXPECT().Transfer(transferCtx, "user2", "user1", decimal.NewFromInt(20), nil).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(transferCtx, gomock.Any()).Return(nil).Times(2)
+		mockIdempotency.EXPECT().Complete(transferCtx, "user2", "payment-request-9").Return(nil)
+		accepted := pending()
+		accepted.Status = models.PaymentRequestAccepted
+		mockRepo.EXPECT().DecidePaymentRequest(opCtx, "user2", "9", models.PaymentRequestAccepted).Return(accepted, nil)
+
+		request, err := service.Accept(ctx, "user2", "9")
+		require.NoError(t, err)
+		assert.Equal(t, models.PaymentRequestAccepted, request.Status)
+	})
+
+	t.Run("the requester cannot accept", func(t *testing.T) {
+		mockRepo.EXPECT().GetPaymentRequest(ctx, "user1", "9").Return(pending(), nil)
+
+		_, err := service.Accept(ctx, "user1", "9")
+		assert.ErrorIs(t, err, postgres.ErrPaymentRequestNotFound)
+	})
+
+	t.Run("an expired request is not paid", func(t *testing.T) {
+		expired := pending()
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		mockRepo.EXPECT().GetPaymentRequest(ctx, "user2", "9").Return(expired, nil)
+
+		_, err := service.Accept(ctx, "user2", "9")
+		assert.ErrorIs(t, err, ErrPaymentRequestExpired)
+	})
+
+	t.Run("a failed transfer leaves the request pending", func(t *testing.T) {
+		mockRepo.EXPECT().GetPaymentRequest(ctx, "user2", "9").Return(pending(), nil)
+		mockIdempotency.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+			return record, true, nil
+		})
+		mockWallets.EXPECT().Transfer(gomock.Any(), "user2", "user1", decimal.NewFromInt(20), nil).Return(postgres.ErrInsufficientBalance)
+		mockIdempotency.EXPECT().Release(gomock.Any(), "user2", "payment-request-9").Return(nil)
+
+		_, err := service.Accept(ctx, "user2", "9")
+		assert.ErrorIs(t, err, postgres.ErrInsufficientBalance)
+	})
+}
+
+func TestPaymentRequestService_ExpireDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPaymentRequestRepository(ctrl)
+	service := NewPaymentRequestService(mockRepo, nil, time.Hour, 100, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ExpirePaymentRequests(ctx, gomock.Any(), 100).DoAndReturn(func(_ context.Context, before time.Time, _ int) (int, error) {
+		assert.GreaterOrEqual(t, time.Since(before), paymentRequestExpiryGrace)
+		return 3, nil
+	})
+
+	expired, err := service.ExpireDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, expired)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var ErrInvalidInitialStatus = errors.New("status must be active or frozen")
+
+// ProvisionOptions are the optional settings of a new wallet
+type ProvisionOptions struct {
+	// Currency defaults to the default currency
+	Currency string
+	Metadata map[string]string
+	// Status defaults to active; a frozen wallet waits for an operator to
+	// unfreeze it, for example after a compliance review
+	Status string
+}
+
+// ProvisioningService creates wallets when their users are created. Unless
+// implicit wallet creation is enabled, deposits to users without a wallet
+// fail, so every wallet goes through it. Wallets are created in the default
+// currency unless the caller picks one.
+type ProvisioningService struct {
+	repo            postgres.ProvisioningRepository
+	defaultCurrency string
+	logger          *logrus.Logger
+}
+
+// NewProvisioningService creates a ProvisioningService whose wallets are in
+// defaultCurrency, or without a currency when it is empty
+func NewProvisioningService(repo postgres.ProvisioningRepository, defaultCurrency string, logger *logrus.Logger) *ProvisioningService {
+	return &ProvisioningService{
+		repo:            repo,
+		defaultCurrency: defaultCurrency,
+		logger:          logger,
+	}
+}
+
+// Provision creates the empty wallet of userID with options
+func (s *ProvisioningService) Provision(ctx context.Context, userID string, options ProvisionOptions) (*models.Wallet, error) {
+	currency := options.Currency
+	if currency == "" {
+		currency = s.defaultCurrency
+	}
+
+	wallet := models.NewWallet{UserID: userID, Metadata: options.Metadata, Status: options.Status}
+	if currency != "" {
+		if !currencyPattern.MatchString(currency) {
+			return nil, ErrInvalidCurrencyCode
+		}
+		wallet.Currency = &currency
+	}
+	if err := validateMetadata(options.Metadata); err != nil {
+		return nil, err
+	}
+	switch wallet.Status {
+	case "":
+		wallet.Status = models.WalletStatusActive
+	case models.WalletStatusActive, models.WalletStatusFrozen:
+	default:
+		return nil, ErrInvalidInitialStatus
+	}
+	return s.repo.ProvisionWallet(ctx, wallet)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestProvisioningService_Provision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockProvisioningRepository(ctrl)
+	ctx := context.Background()
+	eur, usd := "EUR", "USD"
+
+	t.Run("uses the default currency", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, eur, logrus.New())
+		mockRepo.EXPECT().ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Currency: &eur, Status: models.WalletStatusActive}).Return(&models.Wallet{UserID: "user1", Currency: &eur}, nil)
+
+		wallet, err := service.Provision(ctx, "user1", ProvisionOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, eur, *wallet.Currency)
+	})
+
+	t.Run("the caller picks the currency", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, eur, logrus.New())
+		mockRepo.EXPECT().ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Currency: &usd, Status: models.WalletStatusActive}).Return(&models.Wallet{UserID: "user1", Currency: &usd}, nil)
+
+		_, err := service.Provision(ctx, "user1", ProvisionOptions{Currency: usd})
+		require.NoError(t, err)
+	})
+
+	t.Run("without a default currency", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, "", logrus.New())
+		mockRepo.EXPECT().ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Status: models.WalletStatusActive}).Return(&models.Wallet{UserID: "user1"}, nil)
+
+		_, err := service.Provision(ctx, "user1", ProvisionOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects an invalid currency", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, "", logrus.New())
+
+		_, err := service.Provision(ctx, "user1", ProvisionOptions{Currency: "euro"})
+		assert.ErrorIs(t, err, ErrInvalidCurrencyCode)
+	})
+
+	t.Run("a frozen wallet with metadata", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, "", logrus.New())
+		metadata := map[string]string{"customer": "C-42"}
+		mockRepo.EXPECT().ProvisionWallet(ctx, models.NewWallet{UserID: "user1", Metadata: metadata, Status: models.WalletStatusFrozen}).
+			Return(&models.Wallet{UserID: "user1", Status: models.WalletStatusFrozen, Metadata: metadata}, nil)
+
+		wallet, err := service.Provision(ctx, "user1", ProvisionOptions{Metadata: metadata, Status: models.WalletStatusFrozen})
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletStatusFrozen, wallet.Status)
+	})
+
+	t.Run("rejects a closed initial status", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, "", logrus.New())
+
+		_, err := service.Provision(ctx, "user1", ProvisionOptions{Status: models.WalletStatusClosed})
+		assert.ErrorIs(t, err, ErrInvalidInitialStatus)
+	})
+
+	t.Run("rejects invalid metadata", func(t *testing.T) {
+		service := NewProvisioningService(mockRepo, "", logrus.New())
+
+		_, err := service.Provision(ctx, "user1", ProvisionOptions{Metadata: map[string]string{"bad key": "x"}})
+		assert.ErrorIs(t, err, ErrInvalidMetadata)
+	})
+}
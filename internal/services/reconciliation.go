@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+)
+
+// maxReconciliationTransactions bounds the transactions compared in one
+// reconciliation; integrators with busier wallets reconcile shorter periods
+const maxReconciliationTransactions = 10000
+
+var (
+	ErrInvalidPeriod          = errors.New("period must end after it starts")
+	ErrReconciliationTooLarge = errors.New("period holds too many transactions to reconcile, use a shorter period")
+)
+
+// Reconcile compares an integrator's view of a period with the ledger of
+// userID. Transactions are ordered oldest first, as in the ledger.
+func (s *WalletService) Reconcile(ctx context.Context, userID string, claim models.ReconciliationClaim) (*models.Reconciliation, error) {
+	if !claim.To.After(claim.From) {
+		return nil, ErrInvalidPeriod
+	}
+
+	transactions, err := s.repo.GetTransactionsBetween(ctx, userID, claim.From, claim.To, maxReconciliationTransactions+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) > maxReconciliationTransactions {
+		return nil, ErrReconciliationTooLarge
+	}
+
+	result := &models.Reconciliation{
+		UserID: userID,
+		From:   claim.From,
+		To:     claim.To,
+		Ledger: models.ReconciliationTotals{Count: len(transactions), Sum: decimal.Zero},
+		Claimed: models.ReconciliationTotals{
+			Count:             claim.Count,
+			Sum:               claim.Sum,
+			LastTransactionID: claim.LastTransactionID,
+		},
+		Missing: []models.Transaction{},
+		Extra:   []string{},
+	}
+	for _, txn := range transactions {
+		result.Ledger.Sum = result.Ledger.Sum.Add(signedAmount(txn, userID))
+	}
+	if len(transactions) > 0 {
+		result.Ledger.LastTransactionID = *transactions[len(transactions)-1].ID
+	}
+
+	if claim.TransactionIDs != nil {
+		result.Missing, result.Extra = diffTransactions(transactions, claim.TransactionIDs)
+	} else {
+		result.Missing, result.Extra = transactionsAfter(transactions, claim.LastTransactionID)
+	}
+
+	result.Matched = result.Ledger.Count == result.Claimed.Count &&
+		result.Ledger.Sum.Equal(result.Claimed.Sum) &&
+		result.Ledger.LastTransactionID == result.Claimed.LastTransactionID &&
+		len(result.Missing) == 0 && len(result.Extra) == 0
+	return result, nil
+}
+
+// diffTransactions returns the transactions whose IDs are not in ids and the
+// IDs that match no transaction
+func diffTransactions(transactions []models.Transaction, ids []string) ([]models.Transaction, []string) {
+	claimed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		claimed[id] = true
+	}
+
+	missing := []models.Transaction{}
+	known := make(map[string]bool, len(transactions))
+	for _, txn := range transactions {
+		known[*txn.ID] = true
+		if !claimed[*txn.ID] {
+			missing = append(missing, txn)
+		}
+	}
+
+	extra := []string{}
+	for _, id := range ids {
+		if !known[id] {
+			extra = append(extra, id)
+			// Report duplicates once
+			known[id] = true
+		}
+	}
+	return missing, extra
+}
+
+// transactionsAfter returns the transactions following lastID. An unknown
+// lastID cannot be placed in the ledger, so it is returned as extra and every
+// transaction of the period is missing.
+func transactionsAfter(transactions []models.Transaction, lastID string) ([]models.Transaction, []string) {
+	if lastID == "" {
+		return append([]models.Transaction{}, transactions...), []string{}
+	}
+	for i, txn := range transactions {
+		if *txn.ID == lastID {
+			return append([]models.Transaction{}, transactions[i+1:]...), []string{}
+		}
+	}
+	return append([]models.Transaction{}, transactions...), []string{lastID}
+}
+
+// signedAmount returns the effect of txn on the balance of userID.
+// Adjustments carry a signed amount and receivers of a conversion are
+// credited its converted amount. A transaction can touch both sides of the
+// same wallet (a re-linked merge transfer), so credits and debits are applied
+// independently.
+func signedAmount(txn models.Transaction, userID string) decimal.Decimal {
+	if txn.Amount == nil {
+		return decimal.Zero
+	}
+
+	amount := decimal.Zero
+	if txn.ToUserID != nil && *txn.ToUserID == userID {
+		if txn.ConvertedAmount != nil {
+			amount = amount.Add(*txn.ConvertedAmount)
+		} else {
+			amount = amount.Add(*txn.Amount)
+		}
+	}
+	if txn.FromUserID != nil && *txn.FromUserID == userID {
+		if txn.Type != nil && (*txn.Type == "deposit" || *txn.Type == "adjustment") {
+			amount = amount.Add(*txn.Amount)
+		} else {
+			amount = amount.Sub(*txn.Amount)
+		}
+	}
+	return amount
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	defaultReconciliationLimit = 20
+	maxReconciliationLimit     = 100
+	defaultReportLimit         = 100
+	maxReportLimit             = 1000
+)
+
+// ReconciliationService periodically recomputes the balance of every wallet
+// from its ledger and records the wallets whose stored balance differs. Runs
+// progress batch by batch and persist their position, so a run interrupted by
+// a restart resumes where it stopped, and any instance can carry it on. The
+// mismatches of the latest completed run are exported as a gauge.
+type ReconciliationService struct {
+	repo      postgres.ReconciliationRepository
+	metrics   *metrics.Metrics
+	every     time.Duration
+	batchSize int
+	logger    *logrus.Logger
+}
+
+// NewReconciliationService creates the reconciliation job. A run starts when
+// the previous one started at least every ago.
+func NewReconciliationService(repo postgres.ReconciliationRepository, m *metrics.Metrics, every time.Duration, batchSize int, logger *logrus.Logger) *ReconciliationService {
+	return &ReconciliationService{
+		repo:      repo,
+		metrics:   m,
+		every:     every,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run reconciles immediately and then on each interval until ctx is
+// cancelled
+func (s *ReconciliationService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "reconciliation", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.ReconcileDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileDue resumes the running run, or starts one when the latest run
+// started at least every ago, and carries it to completion. It returns the
+// run processed, nil when none was due.
+func (s *ReconciliationService) ReconcileDue(ctx context.Context) (*models.ReconciliationRun, error) {
+	latest, err := s.repo.LatestReconciliation(ctx)
+	switch {
+	case errors.Is(err, postgres.ErrReconciliationRunNotFound):
+		latest = nil
+	case err != nil:
+		return nil, err
+	}
+
+	run := latest
+	if latest == nil || latest.Status != models.ReconciliationRunning {
+		if latest != nil && time.Since(latest.StartedAt) < s.every {
+			// Keep the gauge current on instances that did not run it
+			s.metrics.SetReconciliationMismatches(latest.Mismatched)
+			return nil, nil
+		}
+		op, _ := operation.From(ctx)
+		if run, err = s.repo.StartReconciliation(ctx, op.Actor); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.reconcile(ctx, run)
+}
+
+// Start starts a run straight away, or returns the one already running. The
+// run proceeds in the background.
+func (s *ReconciliationService) Start(ctx context.Context) (*models.ReconciliationRun, error) {
+	op, _ := operation.From(ctx)
+	run, err := s.repo.StartReconciliation(ctx, op.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	go func(ctx context.Context) {
+		_, _ = s.reconcile(ctx, run)
+	}(jobContext(ctx, "reconciliation"))
+	return run, nil
+}
+
+// List returns the latest runs, newest first
+func (s *ReconciliationService) List(ctx context.Context, limit int) ([]models.ReconciliationRun, error) {
+	if limit <= 0 {
+		limit = defaultReconciliationLimit
+	}
+	return s.repo.ListReconciliations(ctx, min(limit, maxReconciliationLimit))
+}
+
+func (s *ReconciliationService) Get(ctx context.Context, runID string) (*models.ReconciliationRun, error) {
+	return s.repo.GetReconciliation(ctx, runID)
+}
+
+// Reports returns the wallets whose balance differed from their ledger in
+// the run, the largest differences first
+func (s *ReconciliationService) Reports(ctx context.Context, runID string, limit int) ([]models.ReconciliationReport, error) {
+	if limit <= 0 {
+		limit = defaultReportLimit
+	}
+	return s.repo.ListReconciliationReports(ctx, runID, min(limit, maxReportLimit))
+}
+
+// reconcile checks the wallets of run batch by batch until it completes or
+// ctx is cancelled
+func (s *ReconciliationService) reconcile(ctx context.Context, run *models.ReconciliationRun) (*models.ReconciliationRun, error) {
+	logger := s.logger.WithContext(ctx).WithField("runID", run.ID)
+	start := time.Now()
+
+	for run.Status == models.ReconciliationRunning {
+		if err := ctx.Err(); err != nil {
+			return run, err
+		}
+
+		next, err := s.repo.ReconcileNext(ctx, run.ID, s.batchSize)
+		if err != nil {
+			logger.WithError(err).Error("reconcile - Reconcile batch failed, will resume")
+			return run, err
+		}
+		run = next
+	}
+
+	s.metrics.SetReconciliationMismatches(run.Mismatched)
+	logger = logger.WithFields(logrus.Fields{
+		"checked":    run.Checked,
+		"mismatched": run.Mismatched,
+		"duration":   time.Since(start),
+	})
+	if run.Mismatched > 0 {
+		logger.Warn("Reconciliation found balances differing from the ledger")
+	} else {
+		logger.Info("Reconciliation completed")
+	}
+	return run, nil
+}
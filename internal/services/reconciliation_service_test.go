@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestReconciliationService_ReconcileDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockReconciliationRepository(ctrl)
+	service := NewReconciliationService(mockRepo, nil, 24*time.Hour, 500, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "reconciliation", Channel: operation.ChannelJob})
+
+	run := func(status string, checked, mismatched int, startedAt time.Time) *models.ReconciliationRun {
+		return &models.ReconciliationRun{ID: "3", Status: status, Checked: checked, Mismatched: mismatched, StartedAt: startedAt}
+	}
+
+	t.Run("first run", func(t *testing.T) {
+		mockRepo.EXPECT().LatestReconciliation(ctx).Return(nil, postgres.ErrReconciliationRunNotFound)
+		mockRepo.EXPECT().StartReconciliation(ctx, "reconciliation").Return(run(models.ReconciliationRunning, 0, 0, time.Now()), nil)
+		mockRepo.EXPECT().ReconcileNext(ctx, "3", 500).Return(run(models.ReconciliationRunning, 500, 1, time.Now()), nil)
+		mockRepo.EXPECT().ReconcileNext(ctx, "3", 500).Return(run(models.ReconciliationCompleted, 620, 2, time.Now()), nil)
+
+		completed, err := service.ReconcileDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 620, completed.Checked)
+		assert.Equal(t, 2, completed.Mismatched)
+	})
+
+	t.Run("resumes an interrupted run", func(t *testing.T) {
+		interrupted := run(models.ReconciliationRunning, 1000, 0, time.Now().Add(-48*time.Hour))
+		mockRepo.EXPECT().LatestReconciliation(ctx).Return(interrupted, nil)
+		mockRepo.EXPECT().ReconcileNext(ctx, "3", 500).Return(run(models.ReconciliationCompleted, 1200, 0, interrupted.StartedAt), nil)
+
+		completed, err := service.ReconcileDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReconciliationCompleted, completed.Status)
+	})
+
+	t.Run("not due", func(t *testing.T) {
+		mockRepo.EXPECT().LatestReconciliation(ctx).Return(run(models.ReconciliationCompleted, 620, 2, time.Now().Add(-time.Hour)), nil)
+
+		completed, err := service.ReconcileDue(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, completed)
+	})
+
+	t.Run("a failed batch leaves the run to resume", func(t *testing.T) {
+		mockRepo.EXPECT().LatestReconciliation(ctx).Return(run(models.ReconciliationRunning, 500, 0, time.Now()), nil)
+		mockRepo.EXPECT().ReconcileNext(ctx, "3", 500).Return(nil, assert.AnError)
+
+		current, err := service.ReconcileDue(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 500, current.Checked)
+	})
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/mocks"
+)
+
+func TestWalletService_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New())
+	ctx := context.Background()
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	amount := func(value int64) *decimal.Decimal {
+		d := decimal.NewFromInt(value)
+		return &d
+	}
+	ledger := []models.Transaction{
+		{ID: proto.String("1"), FromUserID: proto.String("user1"), Amount: amount(100), Type: proto.String("deposit")},
+		{ID: proto.String("2"), FromUserID: proto.String("user1"), ToUserID: proto.String("user2"), Amount: amount(30), Type: proto.String("transfer")},
+		{ID: proto.String("3"), FromUserID: proto.String("user3"), ToUserID: proto.String("user1"), Amount: amount(5), Type: proto.String("transfer")},
+		{ID: proto.String("4"), FromUserID: proto.String("user1"), Amount: amount(25), Type: proto.String("withdrawal")},
+	}
+
+	t.Run("matching claim", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(ledger, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{
+			From: from, To: to, Count: 4, Sum: decimal.NewFromInt(50), LastTransactionID: "4",
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Matched)
+		assert.True(t, result.Ledger.Sum.Equal(decimal.NewFromInt(50)), result.Ledger.Sum.String())
+		assert.Empty(t, result.Missing)
+		assert.Empty(t, result.Extra)
+	})
+
+	t.Run("claim behind the ledger", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(ledger, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{
+			From: from, To: to, Count: 2, Sum: decimal.NewFromInt(70), LastTransactionID: "2",
+		})
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Equal(t, "4", result.Ledger.LastTransactionID)
+		assert.Len(t, result.Missing, 2)
+		assert.Equal(t, "3", *result.Missing[0].ID)
+		assert.Empty(t, result.Extra)
+	})
+
+	t.Run("unknown last transaction", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(ledger, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{
+			From: from, To: to, Count: 5, Sum: decimal.NewFromInt(50), LastTransactionID: "9",
+		})
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Len(t, result.Missing, 4)
+		assert.Equal(t, []string{"9"}, result.Extra)
+	})
+
+	t.Run("transaction list", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(ledger, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{
+			From: from, To: to, Count: 4, Sum: decimal.NewFromInt(50), LastTransactionID: "4",
+			TransactionIDs: []string{"1", "2", "4", "7", "7"},
+		})
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Len(t, result.Missing, 1)
+		assert.Equal(t, "3", *result.Missing[0].ID)
+		assert.Equal(t, []string{"7"}, result.Extra)
+	})
+
+	t.Run("adjustments carry their sign", func(t *testing.T) {
+		adjustments := []models.Transaction{
+			{ID: proto.String("5"), FromUserID: proto.String("user1"), Amount: amount(40), Type: proto.String("adjustment")},
+			{ID: proto.String("6"), FromUserID: proto.String("user1"), Amount: amount(-15), Type: proto.String("adjustment")},
+		}
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(adjustments, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{
+			From: from, To: to, Count: 2, Sum: decimal.NewFromInt(25), LastTransactionID: "6",
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Matched, result.Ledger.Sum.String())
+	})
+
+	t.Run("empty period", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(nil, nil)
+
+		result, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{From: from, To: to})
+		assert.NoError(t, err)
+		assert.True(t, result.Matched)
+	})
+
+	t.Run("period too large", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).
+			Return(make([]models.Transaction, maxReconciliationTransactions+1), nil)
+
+		_, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{From: from, To: to})
+		assert.ErrorIs(t, err, ErrReconciliationTooLarge)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		_, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{From: to, To: from})
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockErr := errors.New("connection refused")
+		mockRepo.EXPECT().GetTransactionsBetween(ctx, "user1", from, to, maxReconciliationTransactions+1).Return(nil, mockErr)
+
+		_, err := service.Reconcile(ctx, "user1", models.ReconciliationClaim{From: from, To: to})
+		assert.ErrorIs(t, err, mockErr)
+	})
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	defaultStuckLimit = 50
+	maxStuckLimit     = 500
+)
+
+var (
+	ErrInvalidStuckStatus      = errors.New("status must be pending or escalated")
+	ErrTransactionFilterNeeded = errors.New("status or flag is required")
+	ErrActionNotAllowed        = errors.New("action is not available for this transaction")
+)
+
+// Remediator retries or fails the stuck transactions of one pipeline. A
+// multi-step pipeline registers a remediator for the transaction type it
+// records; its state machine decides what retrying or failing a step means.
+type Remediator interface {
+	Retry(ctx context.Context, txn models.StuckTransaction) error
+	Fail(ctx context.Context, txn models.StuckTransaction, reason string) error
+}
+
+// RemediationService lets operators find transactions stuck in a non-final
+// status and move them on
+type RemediationService struct {
+	repo        postgres.TransactionRepository
+	remediators map[string]Remediator
+	logger      *logrus.Logger
+}
+
+func NewRemediationService(repo postgres.TransactionRepository, logger *logrus.Logger) *RemediationService {
+	return &RemediationService{
+		repo:        repo,
+		remediators: make(map[string]Remediator),
+		logger:      logger,
+	}
+}
+
+// Register makes retry and fail available for transactions of txnType
+func (s *RemediationService) Register(txnType string, remediator Remediator) {
+	s.remediators[txnType] = remediator
+}
+
+// ListStuck returns matching transactions with the actions available on each.
+// Transactions flagged for review are listed whatever their status unless
+// the filter also sets one.
+func (s *RemediationService) ListStuck(ctx context.Context, filter models.StuckTransactionFilter) ([]models.StuckTransaction, error) {
+	if filter.Status == "" && filter.Flag == "" {
+		return nil, ErrTransactionFilterNeeded
+	}
+	if filter.Status != "" && filter.Status != models.TransactionPending && filter.Status != models.TransactionEscalated {
+		return nil, ErrInvalidStuckStatus
+	}
+	if filter.Flag != "" && !slices.Contains(models.TransactionFlags, filter.Flag) {
+		return nil, ErrInvalidTransactionFlag
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultStuckLimit
+	}
+	filter.Limit = min(filter.Limit, maxStuckLimit)
+
+	transactions, err := s.repo.ListStuck(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactions[i].Actions = s.actions(transactions[i])
+	}
+	return transactions, nil
+}
+
+// Remediate applies action to a stuck transaction and returns its new state
+func (s *RemediationService) Remediate(ctx context.Context, transactionID, action, reason string) (*models.StuckTransaction, error) {
+	txn, err := s.repo.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(s.actions(*txn), action) {
+		return nil, ErrActionNotAllowed
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"transactionID": transactionID,
+		"type":          txn.Type,
+		"status":        txn.Status,
+		"action":        action,
+		"reason":        reason,
+	}).Info("Remediating stuck transaction")
+
+	actionCtx := operation.WithReason(ctx, reason)
+	switch action {
+	case models.RemediationEscalate:
+		err = s.repo.UpdateStatus(actionCtx, transactionID, txn.Status, models.TransactionEscalated)
+	case models.RemediationRetry:
+		err = s.remediators[txn.Type].Retry(actionCtx, *txn)
+	case models.RemediationFail:
+		err = s.remediators[txn.Type].Fail(actionCtx, *txn, reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err = s.repo.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	txn.Actions = s.actions(*txn)
+	return txn, nil
+}
+
+// actions implements the operator side of the state machine: pending
+// transactions can be escalated, and both pending and escalated ones can be
+// retried or failed by the pipeline that owns them
+func (s *RemediationService) actions(txn models.StuckTransaction) []string {
+	actions := []string{}
+	if txn.Status != models.TransactionPending && txn.Status != models.TransactionEscalated {
+		return actions
+	}
+	if _, ok := s.remediators[txn.Type]; ok {
+		actions = append(actions, models.RemediationRetry, models.RemediationFail)
+	}
+	if txn.Status == models.TransactionPending {
+		actions = append(actions, models.RemediationEscalate)
+	}
+	return actions
+}
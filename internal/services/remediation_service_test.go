@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+type fakeRemediator struct {
+	retried []string
+	failed  []string
+}
+
+func (f *fakeRemediator) Retry(ctx context.Context, txn models.StuckTransaction) error {
+	f.retried = append(f.retried, txn.ID)
+	return nil
+}
+
+func (f *fakeRemediator) Fail(ctx context.Context, txn models.StuckTransaction, reason string) error {
+	f.failed = append(f.failed, txn.ID)
+	return nil
+}
+
+func TestRemediationService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionRepository(ctrl)
+	remediator := &fakeRemediator{}
+	service := NewRemediationService(mockRepo, logrus.New())
+	service.Register("withdrawal", remediator)
+
+	pending := func(id, txnType string) *models.StuckTransaction {
+		return &models.StuckTransaction{ID: id, Type: txnType, Status: models.TransactionPending}
+	}
+
+	t.Run("list annotates actions", func(t *testing.T) {
+		ctx := context.Background()
+		filter := models.StuckTransactionFilter{Status: models.TransactionPending, OlderThan: time.Hour}
+		mockRepo.EXPECT().ListStuck(ctx, models.StuckTransactionFilter{Status: models.TransactionPending, OlderThan: time.Hour, Limit: 50}).
+			Return([]models.StuckTransaction{*pending("1", "withdrawal"), *pending("2", "transfer")}, nil)
+
+		transactions, err := service.ListStuck(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"retry", "fail", "escalate"}, transactions[0].Actions)
+		assert.Equal(t, []string{"escalate"}, transactions[1].Actions)
+	})
+
+	t.Run("list rejects final statuses", func(t *testing.T) {
+		_, err := service.ListStuck(context.Background(), models.StuckTransactionFilter{Status: models.TransactionCompleted})
+		assert.ErrorIs(t, err, ErrInvalidStuckStatus)
+	})
+
+	t.Run("list by flag alone", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().ListStuck(ctx, models.StuckTransactionFilter{Flag: models.FlagSuspicious, Limit: 50}).
+			Return([]models.StuckTransaction{}, nil)
+
+		_, err := service.ListStuck(ctx, models.StuckTransactionFilter{Flag: models.FlagSuspicious})
+		assert.NoError(t, err)
+	})
+
+	t.Run("list rejects unknown flags", func(t *testing.T) {
+		_, err := service.ListStuck(context.Background(), models.StuckTransactionFilter{Flag: "fraud"})
+		assert.ErrorIs(t, err, ErrInvalidTransactionFlag)
+	})
+
+	t.Run("list requires a status or flag", func(t *testing.T) {
+		_, err := service.ListStuck(context.Background(), models.StuckTransactionFilter{})
+		assert.ErrorIs(t, err, ErrTransactionFilterNeeded)
+	})
+
+	t.Run("escalate", func(t *testing.T) {
+		ctx := context.Background()
+		escalated := pending("2", "transfer")
+		escalated.Status = models.TransactionEscalated
+		gomock.InOrder(
+			mockRepo.EXPECT().GetTransaction(ctx, "2").Return(pending("2", "transfer"), nil),
+			mockRepo.EXPECT().UpdateStatus(withOperation(operation.Operation{Reason: "needs investigation"}), "2", models.TransactionPending, models.TransactionEscalated).Return(nil),
+			mockRepo.EXPECT().GetTransaction(ctx, "2").Return(escalated, nil),
+		)
+
+		txn, err := service.Remediate(ctx, "2", models.RemediationEscalate, "needs investigation")
+		assert.NoError(t, err)
+		assert.Equal(t, models.TransactionEscalated, txn.Status)
+		assert.Empty(t, txn.Actions)
+	})
+
+	t.Run("retry delegates to pipeline", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetTransaction(ctx, "1").Return(pending("1", "withdrawal"), nil).Times(2)
+
+		_, err := service.Remediate(ctx, "1", models.RemediationRetry, "provider recovered")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, remediator.retried)
+	})
+
+	t.Run("action without pipeline", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetTransaction(ctx, "2").Return(pending("2", "transfer"), nil)
+
+		_, err := service.Remediate(ctx, "2", models.RemediationFail, "stuck")
+		assert.ErrorIs(t, err, ErrActionNotAllowed)
+	})
+}
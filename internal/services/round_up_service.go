@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+var ErrInvalidRoundUpUnit = errors.New("unit must be positive")
+
+// RoundUpService manages the round-up rules of wallets; the RoundUpWorker
+// applies them
+type RoundUpService struct {
+	repo   postgres.RoundUpRepository
+	logger *logrus.Logger
+}
+
+func NewRoundUpService(repo postgres.RoundUpRepository, logger *logrus.Logger) *RoundUpService {
+	return &RoundUpService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// PutRule rounds the transfers of userID up to the next multiple of unit,
+// replacing any previous rule. The actor of the operation is recorded.
+func (s *RoundUpService) PutRule(ctx context.Context, userID string, unit decimal.Decimal) (*models.RoundUpRule, error) {
+	if !unit.IsPositive() {
+		return nil, ErrInvalidRoundUpUnit
+	}
+
+	op, _ := operation.From(ctx)
+	rule := &models.RoundUpRule{
+		UserID:    userID,
+		Unit:      unit,
+		CreatedBy: op.Actor,
+	}
+	if err := s.repo.PutRoundUpRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRule returns the round-up rule of userID with its savings balance
+func (s *RoundUpService) GetRule(ctx context.Context, userID string) (*models.RoundUpRule, error) {
+	return s.repo.GetRoundUpRule(ctx, userID)
+}
+
+// DeleteRule stops rounding up the transfers of userID
+func (s *RoundUpService) DeleteRule(ctx context.Context, userID string) error {
+	return s.repo.DeleteRoundUpRule(ctx, userID)
+}
+
+// RoundUpWorker moves the round-ups of completed transfers to the savings
+// sub-accounts of their senders. Each transfer is rounded up once, however
+// often it is seen; a transfer the wallet cannot cover the round-up of is
+// skipped rather than retried. While transfers are disabled nothing is moved.
+type RoundUpWorker struct {
+	repo      postgres.RoundUpRepository
+	cache     redis.CacheRepository
+	switches  *KillSwitchService
+	batchSize int
+	logger    *logrus.Logger
+}
+
+func NewRoundUpWorker(repo postgres.RoundUpRepository, cache redis.CacheRepository, switches *KillSwitchService, batchSize int, logger *logrus.Logger) *RoundUpWorker {
+	return &RoundUpWorker{
+		repo:      repo,
+		cache:     cache,
+		switches:  switches,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Run processes round-ups immediately and then on each interval until ctx is
+// cancelled
+func (w *RoundUpWorker) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "round-up-worker", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = w.ProcessBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch handles up to batchSize pending round-ups. It returns the
+// number of round-ups saved.
+func (w *RoundUpWorker) ProcessBatch(ctx context.Context) (int, error) {
+	if w.switches != nil {
+		if err := w.switches.Check(ctx, models.KillSwitchTransfers); err != nil {
+			w.logger.WithContext(ctx).WithError(err).Debug("ProcessBatch - Transfers disabled, skipping")
+			return 0, nil
+		}
+	}
+
+	pending, err := w.repo.PendingRoundUps(ctx, w.batchSize)
+	if err != nil {
+		w.logger.WithContext(ctx).WithError(err).Error("ProcessBatch - Query pending round-ups failed")
+		return 0, err
+	}
+
+	saved := 0
+	for _, roundUp := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+
+		status, err := w.repo.SaveRoundUp(ctx, roundUp)
+		if err != nil {
+			w.logger.WithContext(ctx).WithError(err).WithField("transferID", roundUp.TransferID).Warn("ProcessBatch - Save round-up failed, will retry")
+			continue
+		}
+		if status == models.RoundUpSaved {
+			_ = w.cache.InvalidateBalance(ctx, roundUp.UserID)
+			_ = w.cache.InvalidateBalance(ctx, models.SavingsAccount(roundUp.UserID))
+			saved++
+		}
+	}
+
+	if len(pending) > 0 {
+		w.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"pending": len(pending),
+			"saved":   saved,
+		}).Debug("Round-ups processed")
+	}
+	return saved, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestRoundUpService_PutRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRoundUpRepository(ctrl)
+	service := NewRoundUpService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "user1", Channel: operation.ChannelAPI})
+
+	t.Run("records the author", func(t *testing.T) {
+		mockRepo.EXPECT().PutRoundUpRule(ctx, &models.RoundUpRule{
+			UserID:    "user1",
+			Unit:      decimal.NewFromInt(1),
+			CreatedBy: "user1",
+		}).Return(nil)
+
+		rule, err := service.PutRule(ctx, "user1", decimal.NewFromInt(1))
+		require.NoError(t, err)
+		assert.Equal(t, "user1", rule.CreatedBy)
+	})
+
+	t.Run("rejects a zero unit", func(t *testing.T) {
+		_, err := service.PutRule(ctx, "user1", decimal.Zero)
+		assert.ErrorIs(t, err, ErrInvalidRoundUpUnit)
+	})
+}
+
+func TestRoundUpWorker_ProcessBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRoundUpRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	worker := NewRoundUpWorker(mockRepo, mockCache, nil, 100, logrus.New())
+	ctx := context.Background()
+
+	saved := models.RoundUp{TransferID: "12", UserID: "user1", Amount: decimal.RequireFromString("0.75")}
+	skipped := models.RoundUp{TransferID: "14", UserID: "user2", Amount: decimal.RequireFromString("0.20")}
+	failed := models.RoundUp{TransferID: "15", UserID: "user3", Amount: decimal.RequireFromString("0.10")}
+
+	mockRepo.EXPECT().PendingRoundUps(ctx, 100).Return([]models.RoundUp{saved, skipped, failed}, nil)
+	mockRepo.EXPECT().SaveRoundUp(ctx, saved).Return(models.RoundUpSaved, nil)
+	mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+	mockCache.EXPECT().InvalidateBalance(ctx, "savings:user1").Return(nil)
+	mockRepo.EXPECT().SaveRoundUp(ctx, skipped).Return(models.RoundUpSkipped, nil)
+	mockRepo.EXPECT().SaveRoundUp(ctx, failed).Return("", assert.AnError)
+
+	count, err := worker.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/cron"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+var (
+	ErrInvalidSchedule           = errors.New("set either a valid cron expression or an interval_seconds of at least 60, and a start_at that is not in the past")
+	ErrInvalidScheduleTransition = errors.New("transfer schedule cannot change to this status")
+)
+
+const (
+	// MinScheduleInterval keeps interval schedules from running more often
+	// than cron schedules can
+	MinScheduleInterval = time.Minute
+
+	scheduleRunsLimit = 50
+)
+
+// TransferScheduleRequest describes a schedule to create. Exactly one of
+// Cron and IntervalSeconds is set. The first run is at StartAt, or at the
+// first cron activation at or after it, defaulting to now.
+type TransferScheduleRequest struct {
+	ToUserID        string
+	Amount          decimal.Decimal
+	Cron            *string
+	IntervalSeconds *int64
+	StartAt         *time.Time
+}
+
+// ScheduleService manages recurring transfers; the Scheduler executes them
+type ScheduleService struct {
+	repo   postgres.ScheduleRepository
+	logger *logrus.Logger
+}
+
+func NewScheduleService(repo postgres.ScheduleRepository, logger *logrus.Logger) *ScheduleService {
+	return &ScheduleService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Create schedules recurring transfers from userID. The actor of the
+// operation is recorded.
+func (s *ScheduleService) Create(ctx context.Context, userID string, request TransferScheduleRequest) (*models.TransferSchedule, error) {
+	now := time.Now()
+	start := now
+	if request.StartAt != nil {
+		if request.StartAt.Before(now.Add(-time.Minute)) {
+			return nil, ErrInvalidSchedule
+		}
+		start = *request.StartAt
+	}
+
+	schedule := &models.TransferSchedule{
+		UserID:          userID,
+		ToUserID:        request.ToUserID,
+		Amount:          request.Amount,
+		Cron:            request.Cron,
+		IntervalSeconds: request.IntervalSeconds,
+	}
+	switch {
+	case request.Cron != nil && request.IntervalSeconds == nil:
+		expr, err := cron.Parse(*request.Cron)
+		if err != nil {
+			return nil, ErrInvalidSchedule
+		}
+		// Next is strictly after its argument, so a start on an activation
+		// runs at the start
+		schedule.NextRunAt = expr.Next(start.Add(-time.Nanosecond))
+		if schedule.NextRunAt.IsZero() {
+			return nil, ErrInvalidSchedule
+		}
+	case request.IntervalSeconds != nil && request.Cron == nil:
+		if time.Duration(*request.IntervalSeconds)*time.Second < MinScheduleInterval {
+			return nil, ErrInvalidSchedule
+		}
+		schedule.NextRunAt = start
+	default:
+		return nil, ErrInvalidSchedule
+	}
+
+	op, _ := operation.From(ctx)
+	schedule.CreatedBy = op.Actor
+	if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Get returns a schedule of userID
+func (s *ScheduleService) Get(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error) {
+	return s.repo.GetSchedule(ctx, userID, scheduleID)
+}
+
+// List returns the schedules of userID
+func (s *ScheduleService) List(ctx context.Context, userID string) ([]models.TransferSchedule, error) {
+	return s.repo.ListSchedules(ctx, userID)
+}
+
+// Runs returns the latest runs of a schedule of userID, newest first
+func (s *ScheduleService) Runs(ctx context.Context, userID, scheduleID string) ([]models.TransferScheduleRun, error) {
+	if _, err := s.repo.GetSchedule(ctx, userID, scheduleID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListScheduleRuns(ctx, userID, scheduleID, scheduleRunsLimit)
+}
+
+// Pause stops an active schedule until it is resumed
+func (s *ScheduleService) Pause(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error) {
+	schedule, err := s.repo.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != models.ScheduleActive {
+		return nil, ErrInvalidScheduleTransition
+	}
+	return s.repo.UpdateScheduleStatus(ctx, userID, scheduleID, models.ScheduleActive, models.SchedulePaused, schedule.NextRunAt)
+}
+
+// Resume restarts a paused schedule at its next occurrence from now; the
+// occurrences missed while paused are skipped
+func (s *ScheduleService) Resume(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error) {
+	schedule, err := s.repo.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != models.SchedulePaused {
+		return nil, ErrInvalidScheduleTransition
+	}
+
+	nextRunAt := schedule.NextRunAt
+	if nextRunAt.Before(time.Now()) {
+		nextRunAt = nextRun(*schedule, time.Now())
+	}
+	return s.repo.UpdateScheduleStatus(ctx, userID, scheduleID, models.SchedulePaused, models.ScheduleActive, nextRunAt)
+}
+
+// Cancel stops a schedule for good
+func (s *ScheduleService) Cancel(ctx context.Context, userID, scheduleID string) (*models.TransferSchedule, error) {
+	schedule, err := s.repo.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status == models.ScheduleCancelled {
+		return nil, ErrInvalidScheduleTransition
+	}
+	return s.repo.UpdateScheduleStatus(ctx, userID, scheduleID, schedule.Status, models.ScheduleCancelled, schedule.NextRunAt)
+}
+
+// nextRun returns the first occurrence of schedule after now. Interval
+// schedules keep their phase; the zero time means the schedule never runs
+// again.
+func nextRun(schedule models.TransferSchedule, now time.Time) time.Time {
+	if schedule.Cron != nil {
+		expr, err := cron.Parse(*schedule.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return expr.Next(now)
+	}
+	if schedule.IntervalSeconds == nil || *schedule.IntervalSeconds <= 0 {
+		return time.Time{}
+	}
+
+	interval := time.Duration(*schedule.IntervalSeconds) * time.Second
+	next := schedule.NextRunAt.Add(interval)
+	if next.After(now) {
+		return next
+	}
+	return next.Add((now.Sub(next)/interval + 1) * interval)
+}
+
+// Scheduler executes the due runs of transfer schedules through the
+// WalletService. Only the instance holding the leader lock executes runs;
+// claiming runs in the database additionally keeps an occurrence from
+// running twice. Each run transfers under its own idempotency key, so a run
+// claimed again after its scheduler stopped is not applied twice. A failed
+// run is recorded and not retried; the schedule continues with its next
+// occurrence.
+type Scheduler struct {
+	repo       postgres.ScheduleRepository
+	wallets    *WalletService
+	lock       redis.LeaderLock
+	batchSize  int
+	retryAfter time.Duration
+	logger     *logrus.Logger
+}
+
+func NewScheduler(repo postgres.ScheduleRepository, wallets *WalletService, lock redis.LeaderLock, batchSize int, retryAfter time.Duration, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		repo:       repo,
+		wallets:    wallets,
+		lock:       lock,
+		batchSize:  batchSize,
+		retryAfter: retryAfter,
+		logger:     logger,
+	}
+}
+
+// Run executes due runs immediately and then on each interval while this
+// instance is the leader, until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "scheduler", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer func() { _ = s.lock.Release(context.WithoutCancel(ctx)) }()
+
+	for {
+		if leader, err := s.lock.Acquire(ctx); err == nil && leader {
+			_, _ = s.ProcessDue(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue executes the runs left pending by a stopped scheduler and then
+// up to batchSize due runs. It returns the number of runs recorded as
+// succeeded or failed.
+func (s *Scheduler) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	stale, err := s.repo.ClaimStaleRuns(ctx, now.Add(-s.retryAfter), s.batchSize)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("ProcessDue - Claim stale runs failed")
+		return 0, err
+	}
+	due, err := s.repo.ClaimDueRuns(ctx, now, s.batchSize, func(schedule models.TransferSchedule) time.Time {
+		return nextRun(schedule, now)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("ProcessDue - Claim due runs failed")
+		return 0, err
+	}
+
+	recorded := 0
+	for _, run := range append(stale, due...) {
+		if ctx.Err() != nil {
+			break
+		}
+		if s.execute(ctx, run) {
+			recorded++
+		}
+	}
+
+	if len(stale)+len(due) > 0 {
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"claimed":  len(stale) + len(due),
+			"recorded": recorded,
+		}).Debug("Scheduled transfers processed")
+	}
+	return recorded, nil
+}
+
+// execute transfers the amount of run and records the outcome. It reports
+// whether the outcome was recorded.
+func (s *Scheduler) execute(ctx context.Context, run models.TransferScheduleRun) bool {
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"scheduleID": run.ScheduleID,
+		"runID":      run.ID,
+		"attempt":    run.Attempts,
+	})
+
+	runCtx := operation.WithReason(ctx, "transfer schedule "+run.ScheduleID)
+	runCtx = operation.WithIdempotencyKey(runCtx, "transfer-schedule-run-"+run.ID)
+	err := s.wallets.Transfer(runCtx, run.UserID, run.ToUserID, run.Amount, nil)
+
+	// The run stays pending and is claimed again once retryAfter has passed
+	if errors.Is(err, ErrIdempotencyInProgress) || errors.Is(err, ErrWalletBusy) || errors.Is(err, ErrOperationDisabled) || ctx.Err() != nil {
+		logger.WithError(err).Warn("execute - Transfer interrupted, will retry")
+		return false
+	}
+
+	status := models.ScheduleRunSucceeded
+	var runErr *string
+	if err != nil {
+		status = models.ScheduleRunFailed
+		message := err.Error()
+		runErr = &message
+		logger.WithError(err).Warn("execute - Scheduled transfer failed")
+	}
+
+	if err := s.repo.CompleteRun(ctx, run.ID, status, runErr); err != nil {
+		logger.WithError(err).Error("execute - Record run outcome failed")
+		return false
+	}
+	return true
+}
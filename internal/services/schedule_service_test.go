@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+	"Crypto.com/mocks"
+)
+
+func TestScheduleService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockScheduleRepository(ctrl)
+	service := NewScheduleService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "user1", Channel: operation.ChannelAPI})
+	amount := decimal.NewFromInt(25)
+	daily := "0 9 * * *"
+	hourly := int64(3600)
+	start := time.Now().Add(time.Hour).UTC().Truncate(time.Minute)
+
+	t.Run("cron schedule starts at its first activation", func(t *testing.T) {
+		mockRepo.EXPECT().CreateSchedule(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, schedule *models.TransferSchedule) error {
+			assert.Equal(t, 9, schedule.NextRunAt.Hour())
+			assert.False(t, schedule.NextRunAt.Before(start))
+			assert.Equal(t, "user1", schedule.CreatedBy)
+			return nil
+		})
+
+		_, err := service.Create(ctx, "user1", TransferScheduleRequest{ToUserID: "user2", Amount: amount, Cron: &daily, StartAt: &start})
+		assert.NoError(t, err)
+	})
+
+	t.Run("interval schedule starts at start_at", func(t *testing.T) {
+		mockRepo.EXPECT().CreateSchedule(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, schedule *models.TransferSchedule) error {
+			assert.Equal(t, start, schedule.NextRunAt)
+			return nil
+		})
+
+		_, err := service.Create(ctx, "user1", TransferScheduleRequest{ToUserID: "user2", Amount: amount, IntervalSeconds: &hourly, StartAt: &start})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		badCron := "0 25 * * *"
+		tooOften := int64(10)
+		past := time.Now().Add(-time.Hour)
+		for name, request := range map[string]TransferScheduleRequest{
+			"neither":        {ToUserID: "user2", Amount: amount},
+			"both":           {ToUserID: "user2", Amount: amount, Cron: &daily, IntervalSeconds: &hourly},
+			"bad cron":       {ToUserID: "user2", Amount: amount, Cron: &badCron},
+			"short interval": {ToUserID: "user2", Amount: amount, IntervalSeconds: &tooOften},
+			"past start":     {ToUserID: "user2", Amount: amount, IntervalSeconds: &hourly, StartAt: &past},
+		} {
+			_, err := service.Create(ctx, "user1", request)
+			assert.ErrorIs(t, err, ErrInvalidSchedule, name)
+		}
+	})
+}
+
+func TestScheduleService_Transitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockScheduleRepository(ctrl)
+	service := NewScheduleService(mockRepo, logrus.New())
+	ctx := context.Background()
+	hourly := int64(3600)
+	schedule := func(status string, nextRunAt time.Time) *models.TransferSchedule {
+		return &models.TransferSchedule{ID: "3", UserID: "user1", IntervalSeconds: &hourly, Status: status, NextRunAt: nextRunAt}
+	}
+	later := time.Now().Add(time.Hour)
+
+	t.Run("pause an active schedule", func(t *testing.T) {
+		mockRepo.EXPECT().GetSchedule(ctx, "user1", "3").Return(schedule(models.ScheduleActive, later), nil)
+		mockRepo.EXPECT().UpdateScheduleStatus(ctx, "user1", "3", models.ScheduleActive, models.SchedulePaused, later).
+			Return(schedule(models.SchedulePaused, later), nil)
+
+		_, err := service.Pause(ctx, "user1", "3")
+		assert.NoError(t, err)
+	})
+
+	t.Run("resume skips missed occurrences", func(t *testing.T) {
+		missed := time.Now().Add(-150 * time.Minute)
+		mockRepo.EXPECT().GetSchedule(ctx, "user1", "3").Return(schedule(models.SchedulePaused, missed), nil)
+		mockRepo.EXPECT().UpdateScheduleStatus(ctx, "user1", "3", models.SchedulePaused, models.ScheduleActive, missed.Add(3*time.Hour)).
+			Return(schedule(models.ScheduleActive, missed.Add(3*time.Hour)), nil)
+
+		_, err := service.Resume(ctx, "user1", "3")
+		assert.NoError(t, err)
+	})
+
+	t.Run("cancelled schedules stay cancelled", func(t *testing.T) {
+		mockRepo.EXPECT().GetSchedule(ctx, "user1", "3").Return(schedule(models.ScheduleCancelled, later), nil)
+
+		_, err := service.Resume(ctx, "user1", "3")
+		assert.ErrorIs(t, err, ErrInvalidScheduleTransition)
+	})
+}
+
+func TestScheduler_ProcessDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockScheduleRepository(ctrl)
+	mockWallets := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	logger := logrus.New()
+	wallets := NewWalletService(mockWallets, mockCache, logger, WithIdempotency(mockIdempotency))
+	scheduler := NewScheduler(mockRepo, wallets, redis.NewLocalLeaderLock(), 10, time.Minute, logger)
+	ctx := context.Background()
+
+	run := func(id string) models.TransferScheduleRun {
+		return models.TransferScheduleRun{ID: id, ScheduleID: "3", UserID: "user1", ToUserID: "user2", Amount: decimal.NewFromInt(25), Status: models.ScheduleRunPending, Attempts: 1}
+	}
+	reserve := func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+		return record, true, nil
+	}
+
+	mockRepo.EXPECT().ClaimStaleRuns(ctx, gomock.Any(), 10).Return([]models.TransferScheduleRun{run("6")}, nil)
+	mockRepo.EXPECT().ClaimDueRuns(ctx, gomock.Any(), 10, gomock.Any()).Return([]models.TransferScheduleRun{run("7")}, nil)
+
+	// The stale run is executed under its own idempotency key
+	opCtx := withOperation(operation.Operation{Reason: "transfer schedule 3", IdempotencyKey: "transfer-schedule-run-6"})
+	mockIdempotency.EXPECT().Reserve(opCtx, gomock.Any()).DoAndReturn(reserve)
+	mockWallets.EXPECT().Transfer(opCtx, "user1", "user2", decimal.NewFromInt(25), nil).Return(nil)
+	mockCache.EXPECT().InvalidateBalance(opCtx, gomock.Any()).Return(nil).Times(2)
+	mockIdempotency.EXPECT().Complete(opCtx, "user1", "transfer-schedule-run-6").Return(nil)
+	mockRepo.EXPECT().CompleteRun(ctx, "6", models.ScheduleRunSucceeded, nil).Return(nil)
+
+	// A failed transfer is recorded with its error
+	mockIdempotency.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(reserve)
+	mockWallets.EXPECT().Transfer(gomock.Any(), "user1", "user2", decimal.NewFromInt(25), nil).Return(postgres.ErrInsufficientBalance)
+	mockIdempotency.EXPECT().Release(gomock.Any(), "user1", "transfer-schedule-run-7").Return(nil)
+	mockRepo.EXPECT().CompleteRun(ctx, "7", models.ScheduleRunFailed, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, runErr *string) error {
+		assert.Equal(t, postgres.ErrInsufficientBalance.Error(), *runErr)
+		return nil
+	})
+
+	recorded, err := scheduler.ProcessDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, recorded)
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 17, 0, 0, time.UTC)
+	hourly := int64(3600)
+	daily := "0 9 * * *"
+
+	assert.Equal(t,
+		time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		nextRun(models.TransferSchedule{IntervalSeconds: &hourly, NextRunAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)}, now))
+	// Occurrences missed while the scheduler was down are skipped
+	assert.Equal(t,
+		time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		nextRun(models.TransferSchedule{IntervalSeconds: &hourly, NextRunAt: time.Date(2024, 5, 1, 4, 30, 0, 0, time.UTC)}, now))
+	assert.Equal(t,
+		time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC),
+		nextRun(models.TransferSchedule{Cron: &daily}, now))
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// Runtime setting keys
+const (
+	// SettingBalanceCacheTTL is how long a balance stays cached, in seconds
+	SettingBalanceCacheTTL = "balance_cache_ttl"
+	// SettingMaxTransactionAmount caps the amount of a single deposit,
+	// withdrawal or transfer. Zero means no limit.
+	SettingMaxTransactionAmount = "max_transaction_amount"
+)
+
+var (
+	ErrUnknownSetting      = errors.New("unknown setting")
+	ErrInvalidSettingValue = errors.New("invalid setting value")
+	ErrInvalidSettingScope = errors.New("invalid setting scope")
+	ErrAmountExceedsLimit  = errors.New("amount exceeds the transaction limit")
+)
+
+type settingDefinition struct {
+	builtin  string
+	validate func(value string) bool
+}
+
+// settingDefinitions lists every setting with its built-in value, used when
+// no scope overrides it
+var settingDefinitions = map[string]settingDefinition{
+	SettingBalanceCacheTTL: {
+		builtin: "3600",
+		validate: func(value string) bool {
+			seconds, err := strconv.Atoi(value)
+			return err == nil && seconds > 0
+		},
+	},
+	SettingMaxTransactionAmount: {
+		builtin: "0",
+		validate: func(value string) bool {
+			amount, err := decimal.NewFromString(value)
+			return err == nil && !amount.IsNegative()
+		},
+	},
+}
+
+type settingKey struct {
+	key, scope, scopeID string
+}
+
+// SettingsService manages runtime settings layered by scope: defaults, then
+// tenant, then currency, then wallet. Stored settings are cached in memory
+// and reloaded after the refresh interval, or right away after a change made
+// through this instance, so resolving a setting is a few map lookups.
+type SettingsService struct {
+	repo    postgres.SettingsRepository
+	refresh time.Duration
+	logger  *logrus.Logger
+
+	mu       sync.RWMutex
+	values   map[settingKey]string
+	loadedAt time.Time
+}
+
+func NewSettingsService(repo postgres.SettingsRepository, refresh time.Duration, logger *logrus.Logger) *SettingsService {
+	return &SettingsService{
+		repo:    repo,
+		refresh: refresh,
+		logger:  logger,
+	}
+}
+
+// Resolve returns the effective value of key for target, from the most
+// specific scope that sets it
+func (s *SettingsService) Resolve(ctx context.Context, key string, target models.SettingTarget) (models.ResolvedSetting, error) {
+	definition, ok := settingDefinitions[key]
+	if !ok {
+		return models.ResolvedSetting{}, ErrUnknownSetting
+	}
+
+	values, err := s.current(ctx)
+	if err != nil {
+		return models.ResolvedSetting{}, err
+	}
+
+	scopes := []struct{ scope, scopeID string }{
+		{models.SettingScopeWallet, target.UserID},
+		{models.SettingScopeCurrency, target.Currency},
+		{models.SettingScopeTenant, target.TenantID},
+		{models.SettingScopeDefault, ""},
+	}
+	for _, scope := range scopes {
+		if scope.scope != models.SettingScopeDefault && scope.scopeID == "" {
+			continue
+		}
+		if value, ok := values[settingKey{key, scope.scope, scope.scopeID}]; ok {
+			return models.ResolvedSetting{Key: key, Value: value, Scope: scope.scope, ScopeID: scope.scopeID}, nil
+		}
+	}
+	return models.ResolvedSetting{Key: key, Value: definition.builtin, Scope: models.SettingScopeBuiltin}, nil
+}
+
+// Effective resolves every setting for target, ordered by key
+func (s *SettingsService) Effective(ctx context.Context, target models.SettingTarget) ([]models.ResolvedSetting, error) {
+	keys := make([]string, 0, len(settingDefinitions))
+	for key := range settingDefinitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := make([]models.ResolvedSetting, 0, len(keys))
+	for _, key := range keys {
+		setting, err := s.Resolve(ctx, key, target)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, setting)
+	}
+	return resolved, nil
+}
+
+// BalanceCacheTTL returns how long the balance of userID may stay cached
+func (s *SettingsService) BalanceCacheTTL(ctx context.Context, userID string) (time.Duration, error) {
+	setting, err := s.Resolve(ctx, SettingBalanceCacheTTL, models.SettingTarget{UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.Atoi(setting.Value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// CheckAmount returns ErrAmountExceedsLimit when amount is above the
+// transaction limit of userID
+func (s *SettingsService) CheckAmount(ctx context.Context, userID string, amount decimal.Decimal) error {
+	setting, err := s.Resolve(ctx, SettingMaxTransactionAmount, models.SettingTarget{UserID: userID})
+	if err != nil {
+		return err
+	}
+	limit, err := decimal.NewFromString(setting.Value)
+	if err != nil {
+		return err
+	}
+	if limit.IsPositive() && amount.GreaterThan(limit) {
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"userID": userID,
+			"amount": amount,
+			"limit":  limit,
+			"scope":  setting.Scope,
+		}).Warn("Amount exceeds the transaction limit")
+		return ErrAmountExceedsLimit
+	}
+	return nil
+}
+
+// List returns every stored setting
+func (s *SettingsService) List(ctx context.Context) ([]models.Setting, error) {
+	return s.repo.ListSettings(ctx)
+}
+
+// Set stores value for key at scope. The actor of the operation is recorded.
+func (s *SettingsService) Set(ctx context.Context, key, scope, scopeID, value, reason string) (*models.Setting, error) {
+	if err := validateSettingScope(key, scope, scopeID); err != nil {
+		return nil, err
+	}
+	if !settingDefinitions[key].validate(value) {
+		return nil, ErrInvalidSettingValue
+	}
+
+	op, _ := operation.From(ctx)
+	setting := &models.Setting{Key: key, Scope: scope, ScopeID: scopeID, Value: value, UpdatedBy: op.Actor}
+	if err := s.repo.PutSetting(ctx, setting, reason); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return setting, nil
+}
+
+// Clear removes key at scope so the next broader scope applies again
+func (s *SettingsService) Clear(ctx context.Context, key, scope, scopeID, reason string) error {
+	if err := validateSettingScope(key, scope, scopeID); err != nil {
+		return err
+	}
+
+	op, _ := operation.From(ctx)
+	if err := s.repo.DeleteSetting(ctx, key, scope, scopeID, op.Actor, reason); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Changes returns the audit trail of setting changes, newest first
+func (s *SettingsService) Changes(ctx context.Context, key string, limit int) ([]models.SettingChange, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.ListSettingChanges(ctx, key, limit)
+}
+
+// current returns the cached settings, reloading them once they are older
+// than the refresh interval. Stale settings are kept if a reload fails.
+func (s *SettingsService) current(ctx context.Context) (map[settingKey]string, error) {
+	s.mu.RLock()
+	values, fresh := s.values, time.Since(s.loadedAt) < s.refresh
+	s.mu.RUnlock()
+	if values != nil && fresh {
+		return values, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values != nil && time.Since(s.loadedAt) < s.refresh {
+		return s.values, nil
+	}
+
+	settings, err := s.repo.ListSettings(ctx)
+	if err != nil {
+		if s.values != nil {
+			s.logger.WithContext(ctx).WithError(err).Warn("Reload settings failed, using cached settings")
+			return s.values, nil
+		}
+		return nil, err
+	}
+
+	values = make(map[settingKey]string, len(settings))
+	for _, setting := range settings {
+		values[settingKey{setting.Key, setting.Scope, setting.ScopeID}] = setting.Value
+	}
+	s.values, s.loadedAt = values, time.Now()
+	return values, nil
+}
+
+func (s *SettingsService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func validateSettingScope(key, scope, scopeID string) error {
+	if _, ok := settingDefinitions[key]; !ok {
+		return ErrUnknownSetting
+	}
+	switch scope {
+	case models.SettingScopeDefault:
+		if scopeID != "" {
+			return ErrInvalidSettingScope
+		}
+	case models.SettingScopeTenant, models.SettingScopeCurrency, models.SettingScopeWallet:
+		if scopeID == "" {
+			return ErrInvalidSettingScope
+		}
+	default:
+		return ErrInvalidSettingScope
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestSettingsService_Resolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSettingsRepository(ctrl)
+	service := NewSettingsService(mockRepo, time.Minute, logrus.New())
+
+	ctx := context.Background()
+	// Loaded once and served from memory afterwards
+	mockRepo.EXPECT().ListSettings(ctx).Return([]models.Setting{
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeDefault, Value: "1000"},
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeTenant, ScopeID: "acme", Value: "500"},
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeCurrency, ScopeID: "BTC", Value: "2"},
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeWallet, ScopeID: "user1", Value: "50"},
+	}, nil)
+
+	tests := []struct {
+		name   string
+		target models.SettingTarget
+		want   models.ResolvedSetting
+	}{
+		{"wallet overrides everything", models.SettingTarget{TenantID: "acme", Currency: "BTC", UserID: "user1"},
+			models.ResolvedSetting{Key: SettingMaxTransactionAmount, Value: "50", Scope: models.SettingScopeWallet, ScopeID: "user1"}},
+		{"currency overrides tenant", models.SettingTarget{TenantID: "acme", Currency: "BTC", UserID: "user2"},
+			models.ResolvedSetting{Key: SettingMaxTransactionAmount, Value: "2", Scope: models.SettingScopeCurrency, ScopeID: "BTC"}},
+		{"tenant overrides default", models.SettingTarget{TenantID: "acme", UserID: "user2"},
+			models.ResolvedSetting{Key: SettingMaxTransactionAmount, Value: "500", Scope: models.SettingScopeTenant, ScopeID: "acme"}},
+		{"default", models.SettingTarget{UserID: "user2"},
+			models.ResolvedSetting{Key: SettingMaxTransactionAmount, Value: "1000", Scope: models.SettingScopeDefault}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := service.Resolve(ctx, SettingMaxTransactionAmount, tt.target)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, resolved)
+		})
+	}
+
+	t.Run("builtin", func(t *testing.T) {
+		ttl, err := service.BalanceCacheTTL(ctx, "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, ttl)
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		_, err := service.Resolve(ctx, "fee", models.SettingTarget{})
+		assert.ErrorIs(t, err, ErrUnknownSetting)
+	})
+}
+
+func TestSettingsService_CheckAmount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSettingsRepository(ctrl)
+	service := NewSettingsService(mockRepo, time.Minute, logrus.New())
+
+	ctx := context.Background()
+	mockRepo.EXPECT().ListSettings(ctx).Return([]models.Setting{
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeWallet, ScopeID: "user1", Value: "50"},
+	}, nil)
+
+	assert.NoError(t, service.CheckAmount(ctx, "user1", decimal.NewFromInt(50)))
+	assert.ErrorIs(t, service.CheckAmount(ctx, "user1", decimal.NewFromInt(51)), ErrAmountExceedsLimit)
+	// The built-in limit of zero means unlimited
+	assert.NoError(t, service.CheckAmount(ctx, "user2", decimal.NewFromInt(1000000)))
+}
+
+func TestSettingsService_Set(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSettingsRepository(ctrl)
+	service := NewSettingsService(mockRepo, time.Minute, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("records actor and reloads", func(t *testing.T) {
+		mockRepo.EXPECT().ListSettings(ctx).Return(nil, nil)
+		_, err := service.Resolve(ctx, SettingBalanceCacheTTL, models.SettingTarget{})
+		assert.NoError(t, err)
+
+		mockRepo.EXPECT().PutSetting(ctx, &models.Setting{
+			Key:       SettingBalanceCacheTTL,
+			Scope:     models.SettingScopeDefault,
+			Value:     "60",
+			UpdatedBy: "admin1",
+		}, "INC-1").Return(nil)
+		_, err = service.Set(ctx, SettingBalanceCacheTTL, models.SettingScopeDefault, "", "60", "INC-1")
+		assert.NoError(t, err)
+
+		mockRepo.EXPECT().ListSettings(ctx).Return([]models.Setting{
+			{Key: SettingBalanceCacheTTL, Scope: models.SettingScopeDefault, Value: "60"},
+		}, nil)
+		ttl, err := service.BalanceCacheTTL(ctx, "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, ttl)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := service.Set(ctx, SettingBalanceCacheTTL, models.SettingScopeDefault, "", "-1", "INC-2")
+		assert.ErrorIs(t, err, ErrInvalidSettingValue)
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		_, err := service.Set(ctx, SettingMaxTransactionAmount, models.SettingScopeWallet, "", "10", "INC-3")
+		assert.ErrorIs(t, err, ErrInvalidSettingScope)
+	})
+}
+
+func TestSettingsService_ReloadFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSettingsRepository(ctrl)
+	service := NewSettingsService(mockRepo, 0, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ListSettings(ctx).Return(nil, errors.New("db down"))
+	_, err := service.Resolve(ctx, SettingMaxTransactionAmount, models.SettingTarget{})
+	assert.Error(t, err, "nothing cached yet")
+
+	mockRepo.EXPECT().ListSettings(ctx).Return([]models.Setting{
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeDefault, Value: "10"},
+	}, nil)
+	mockRepo.EXPECT().ListSettings(ctx).Return(nil, errors.New("db down"))
+	_, _ = service.Resolve(ctx, SettingMaxTransactionAmount, models.SettingTarget{})
+
+	resolved, err := service.Resolve(ctx, SettingMaxTransactionAmount, models.SettingTarget{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10", resolved.Value, "stale settings are kept")
+}
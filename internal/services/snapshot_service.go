@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// SnapshotService periodically records wallet balance snapshots, which bound
+// the number of transactions replayed to answer historical balance queries
+type SnapshotService struct {
+	repo   postgres.SnapshotRepository
+	lag    time.Duration
+	logger *logrus.Logger
+}
+
+// NewSnapshotService creates the snapshot job. Snapshots are taken as of lag
+// ago so transactions still in flight are not missed.
+func NewSnapshotService(repo postgres.SnapshotRepository, lag time.Duration, logger *logrus.Logger) *SnapshotService {
+	return &SnapshotService{
+		repo:   repo,
+		lag:    lag,
+		logger: logger,
+	}
+}
+
+// Run takes snapshots on each interval until ctx is cancelled
+func (s *SnapshotService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.Snapshot(ctx)
+		}
+	}
+}
+
+// Snapshot records the balance of every wallet as of lag ago
+func (s *SnapshotService) Snapshot(ctx context.Context) (int, error) {
+	asOf := time.Now().Add(-s.lag).UTC()
+	start := time.Now()
+	written, err := s.repo.TakeSnapshots(ctx, asOf)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Snapshot - Take balance snapshots failed")
+		return 0, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"asOf":     asOf,
+		"wallets":  written,
+		"duration": time.Since(start),
+	}).Debug("Balance snapshots taken")
+	return written, nil
+}
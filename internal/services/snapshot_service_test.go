@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/mocks"
+)
+
+func TestSnapshotService_Snapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSnapshotRepository(ctrl)
+	service := NewSnapshotService(mockRepo, time.Minute, logrus.New())
+
+	ctx := context.Background()
+	before := time.Now()
+	mockRepo.EXPECT().TakeSnapshots(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, asOf time.Time) (int, error) {
+		assert.True(t, asOf.Before(before.Add(-time.Minute+time.Second)), "snapshots must lag behind now")
+		return 3, nil
+	})
+
+	written, err := service.Snapshot(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, written)
+}
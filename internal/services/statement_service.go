@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/statements"
+)
+
+// statementPageSize is the number of transactions read from the ledger and
+// sent to the client at a time
+const statementPageSize = 500
+
+// StatementService generates account statements from the ledger. The opening
+// balance is reconstructed from balance snapshots; every line carries the
+// running balance, so the last one matches the closing balance.
+type StatementService struct {
+	repo      postgres.StatementRepository
+	snapshots postgres.SnapshotRepository
+	logger    *logrus.Logger
+}
+
+func NewStatementService(repo postgres.StatementRepository, snapshots postgres.SnapshotRepository, logger *logrus.Logger) *StatementService {
+	return &StatementService{
+		repo:      repo,
+		snapshots: snapshots,
+		logger:    logger,
+	}
+}
+
+// Prepare validates the period [from, to) and returns the statement header
+// with its opening balance. Nothing is written yet, so its errors can still
+// be reported as such.
+func (s *StatementService) Prepare(ctx context.Context, userID string, from, to time.Time) (*models.Statement, error) {
+	// The ledger keeps timestamps to the microsecond
+	from, to = from.Truncate(time.Microsecond), to.Truncate(time.Microsecond)
+	if !to.After(from) {
+		return nil, ErrInvalidPeriod
+	}
+	if from.After(time.Now()) {
+		return nil, ErrFutureTimestamp
+	}
+
+	// The balance as of from includes the transactions at from, which the
+	// statement lists
+	opening, err := s.snapshots.BalanceAt(ctx, userID, from.Add(-time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Statement{
+		UserID:         userID,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		GeneratedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Write renders statement through out, reading the ledger a page at a time
+// and flushing each page to the client
+func (s *StatementService) Write(ctx context.Context, statement *models.Statement, out statements.Renderer) error {
+	if err := out.Begin(*statement); err != nil {
+		return err
+	}
+
+	balance := statement.OpeningBalance
+	var cursor *models.TransactionCursor
+	lines := 0
+	for {
+		page, err := s.repo.GetStatementEntries(ctx, statement.UserID, cursor, statement.From, statement.To, statementPageSize)
+		if err != nil {
+			return err
+		}
+		for _, txn := range page {
+			amount := signedAmount(txn, statement.UserID)
+			balance = balance.Add(amount)
+			if err := out.Line(statementLine(txn, statement.UserID, amount, balance)); err != nil {
+				return err
+			}
+		}
+		lines += len(page)
+		if len(page) < statementPageSize {
+			break
+		}
+
+		last := page[len(page)-1]
+		id, err := strconv.ParseInt(*last.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		cursor = &models.TransactionCursor{CreatedAt: *last.CreatedAt, ID: id}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"userID": statement.UserID,
+		"from":   statement.From,
+		"to":     statement.To,
+		"lines":  lines,
+	}).Debug("Statement generated")
+	return out.End(balance)
+}
+
+func statementLine(txn models.Transaction, userID string, amount, balance decimal.Decimal) models.StatementLine {
+	line := models.StatementLine{
+		TransactionID: *txn.ID,
+		Date:          *txn.CreatedAt,
+		Amount:        amount,
+		Balance:       balance,
+	}
+	if txn.Type != nil {
+		line.Type = *txn.Type
+	}
+	if txn.Category != nil {
+		line.Category = *txn.Category
+	}
+	// A re-linked merge transfer has the wallet on both sides
+	from, to := stringValue(txn.FromUserID), stringValue(txn.ToUserID)
+	switch {
+	case from == userID && to != "" && to != userID:
+		line.Counterparty = to
+	case to == userID && from != "" && from != userID:
+		line.Counterparty = from
+	}
+	return line
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/statements"
+	"Crypto.com/mocks"
+)
+
+func TestStatementService_Prepare(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSnapshots := mocks.NewMockSnapshotRepository(ctrl)
+	service := NewStatementService(mocks.NewMockStatementRepository(ctrl), mockSnapshots, logrus.New())
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("opening balance leaves out the first instant", func(t *testing.T) {
+		mockSnapshots.EXPECT().BalanceAt(ctx, "user1", from.Add(-time.Microsecond)).Return(decimal.NewFromInt(100), nil)
+
+		statement, err := service.Prepare(ctx, "user1", from, from.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		assert.True(t, statement.OpeningBalance.Equal(decimal.NewFromInt(100)))
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		_, err := service.Prepare(ctx, "user1", from, from)
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+		_, err = service.Prepare(ctx, "user1", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+		assert.ErrorIs(t, err, ErrFutureTimestamp)
+	})
+}
+
+func TestStatementService_Write(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockStatementRepository(ctrl)
+	service := NewStatementService(mockRepo, nil, logrus.New())
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statement := &models.Statement{UserID: "user1", From: from, To: from.AddDate(0, 1, 0), OpeningBalance: decimal.NewFromInt(10)}
+
+	// A full first page, then the rest
+	first := make([]models.Transaction, statementPageSize)
+	for i := range first {
+		first[i] = statementTransaction(i+1, "user0", "user1", "transfer", from.Add(time.Minute))
+	}
+	last := first[statementPageSize-1]
+	gomock.InOrder(
+		mockRepo.EXPECT().GetStatementEntries(ctx, "user1", nil, statement.From, statement.To, statementPageSize).Return(first, nil),
+		mockRepo.EXPECT().GetStatementEntries(ctx, "user1", &models.TransactionCursor{CreatedAt: *last.CreatedAt, ID: statementPageSize},
+			statement.From, statement.To, statementPageSize).
+			Return([]models.Transaction{
+				statementTransaction(1001, "user1", "user2", "transfer", from.Add(time.Hour)),
+				statementTransaction(1002, "user1", "user1", "transfer", from.Add(time.Hour)),
+			}, nil),
+	)
+
+	var out bytes.Buffer
+	renderer, err := statements.New(models.StatementFormatCSV, &out)
+	require.NoError(t, err)
+	require.NoError(t, service.Write(ctx, statement, renderer))
+
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, rows, statementPageSize+5)
+	assert.Equal(t, "2024-05-01T00:01:00Z,1,transfer,user0,,1,11", rows[2])
+	// Received 500, sent 1, and a merge transfer to itself changes nothing
+	assert.Equal(t, "2024-05-01T01:00:00Z,1001,transfer,user2,,-1,509", rows[statementPageSize+2])
+	assert.Equal(t, "2024-05-01T01:00:00Z,1002,transfer,,,0,509", rows[statementPageSize+3])
+	assert.Equal(t, "2024-06-01T00:00:00Z,,closing_balance,,,,509", rows[statementPageSize+4])
+}
+
+func statementTransaction(id int, fromUserID, toUserID, txnType string, createdAt time.Time) models.Transaction {
+	txnID := strconv.Itoa(id)
+	amount := decimal.NewFromInt(1)
+	return models.Transaction{
+		ID:         &txnID,
+		FromUserID: &fromUserID,
+		ToUserID:   &toUserID,
+		Amount:     &amount,
+		Type:       &txnType,
+		CreatedAt:  &createdAt,
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/funding"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+var (
+	ErrInvalidTopUpRule       = errors.New("threshold cannot be negative, amount must be positive and funding_source is required, up to 255 characters")
+	ErrInvalidTopUpTransition = errors.New("top-up rule cannot change to this status")
+)
+
+const (
+	// MaxTopUpFailures is the number of top-ups failing in a row after which
+	// a rule pauses itself, so a declined funding source is not charged again
+	// until the user resumes the rule
+	MaxTopUpFailures = 3
+
+	topUpRunsLimit = 50
+)
+
+// TopUpService manages the automatic top-up rules of wallets; the
+// TopUpWorker executes them
+type TopUpService struct {
+	repo   postgres.TopUpRepository
+	logger *logrus.Logger
+}
+
+func NewTopUpService(repo postgres.TopUpRepository, logger *logrus.Logger) *TopUpService {
+	return &TopUpService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// PutRule sets the top-up rule of userID, replacing any previous one. The
+// actor of the operation is recorded.
+func (s *TopUpService) PutRule(ctx context.Context, userID string, threshold, amount decimal.Decimal, fundingSource string) (*models.TopUpRule, error) {
+	fundingSource = strings.TrimSpace(fundingSource)
+	if threshold.IsNegative() || !amount.IsPositive() || fundingSource == "" || len(fundingSource) > 255 {
+		return nil, ErrInvalidTopUpRule
+	}
+
+	op, _ := operation.From(ctx)
+	rule := &models.TopUpRule{
+		UserID:        userID,
+		Threshold:     threshold,
+		Amount:        amount,
+		FundingSource: fundingSource,
+		CreatedBy:     op.Actor,
+	}
+	if err := s.repo.PutTopUpRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRule returns the top-up rule of userID
+func (s *TopUpService) GetRule(ctx context.Context, userID string) (*models.TopUpRule, error) {
+	return s.repo.GetTopUpRule(ctx, userID)
+}
+
+// DeleteRule removes the top-up rule of userID
+func (s *TopUpService) DeleteRule(ctx context.Context, userID string) error {
+	return s.repo.DeleteTopUpRule(ctx, userID)
+}
+
+// Runs returns the latest top-ups of userID, newest first
+func (s *TopUpService) Runs(ctx context.Context, userID string) ([]models.TopUpRun, error) {
+	return s.repo.ListTopUpRuns(ctx, userID, topUpRunsLimit)
+}
+
+// Pause stops an active rule from triggering until it is resumed
+func (s *TopUpService) Pause(ctx context.Context, userID string) (*models.TopUpRule, error) {
+	return s.transition(ctx, userID, models.TopUpRuleActive, models.TopUpRulePaused)
+}
+
+// Resume restarts a paused rule, including one paused after failing
+// repeatedly
+func (s *TopUpService) Resume(ctx context.Context, userID string) (*models.TopUpRule, error) {
+	return s.transition(ctx, userID, models.TopUpRulePaused, models.TopUpRuleActive)
+}
+
+func (s *TopUpService) transition(ctx context.Context, userID, from, to string) (*models.TopUpRule, error) {
+	rule, err := s.repo.GetTopUpRule(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Status != from {
+		return nil, ErrInvalidTopUpTransition
+	}
+	return s.repo.UpdateTopUpRuleStatus(ctx, userID, from, to)
+}
+
+// TopUpWorker tops up wallets whose available balance fell below the
+// threshold of their rule. It collects the amount from the funding source
+// through a funding.FundingProvider and deposits it through the
+// WalletService. A declined charge, or a deposit refused after the charge was
+// collected, fails the run and raises a top_up.failed event; after any other
+// error the run stays pending and is claimed again once retryAfter has
+// passed. Each run is charged and deposited under its own idempotency key,
+// so a retried run is collected and deposited once. While deposits are
+// disabled nothing is claimed.
+type TopUpWorker struct {
+	repo       postgres.TopUpRepository
+	wallets    *WalletService
+	provider   funding.FundingProvider
+	switches   *KillSwitchService
+	batchSize  int
+	retryAfter time.Duration
+	logger     *logrus.Logger
+}
+
+func NewTopUpWorker(repo postgres.TopUpRepository, wallets *WalletService, provider funding.FundingProvider, switches *KillSwitchService, batchSize int, retryAfter time.Duration, logger *logrus.Logger) *TopUpWorker {
+	return &TopUpWorker{
+		repo:       repo,
+		wallets:    wallets,
+		provider:   provider,
+		switches:   switches,
+		batchSize:  batchSize,
+		retryAfter: retryAfter,
+		logger:     logger,
+	}
+}
+
+// Run processes top-ups immediately and then on each interval until ctx is
+// cancelled
+func (w *TopUpWorker) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "top-up-worker", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = w.ProcessBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch retries the runs left pending and then triggers up to
+// batchSize new top-ups. It returns the number of runs completed or failed.
+func (w *TopUpWorker) ProcessBatch(ctx context.Context) (int, error) {
+	if w.switches != nil {
+		if err := w.switches.Check(ctx, models.KillSwitchDeposits); err != nil {
+			w.logger.WithContext(ctx).WithError(err).Debug("ProcessBatch - Deposits disabled, skipping")
+			return 0, nil
+		}
+	}
+
+	now := time.Now()
+	stale, err := w.repo.ClaimStaleTopUps(ctx, now.Add(-w.retryAfter), w.batchSize)
+	if err != nil {
+		w.logger.WithContext(ctx).WithError(err).Error("ProcessBatch - Claim stale top-ups failed")
+		return 0, err
+	}
+	due, err := w.repo.ClaimDueTopUps(ctx, now.Add(-w.retryAfter), w.batchSize)
+	if err != nil {
+		w.logger.WithContext(ctx).WithError(err).Error("ProcessBatch - Claim due top-ups failed")
+		return 0, err
+	}
+
+	settled := 0
+	for _, run := range append(stale, due...) {
+		if ctx.Err() != nil {
+			break
+		}
+		if w.process(ctx, run) {
+			settled++
+		}
+	}
+
+	if len(stale)+len(due) > 0 {
+		w.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"claimed": len(stale) + len(due),
+			"settled": settled,
+		}).Debug("Top-ups processed")
+	}
+	return settled, nil
+}
+
+// process collects and deposits the amount of run and records the outcome.
+// It reports whether the run was settled.
+func (w *TopUpWorker) process(ctx context.Context, run models.TopUpRun) bool {
+	logger := w.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"runID":   run.ID,
+		"userID":  run.UserID,
+		"attempt": run.Attempts,
+	})
+
+	charge := funding.Charge{
+		TopUpID: run.ID,
+		UserID:  run.UserID,
+		Amount:  run.Amount,
+		Source:  run.FundingSource,
+	}
+	if run.Currency != nil {
+		charge.Currency = *run.Currency
+	}
+	reference, err := w.provider.Collect(ctx, charge)
+	switch {
+	case errors.Is(err, funding.ErrDeclined):
+		return w.fail(ctx, logger, run, err.Error(), nil)
+	case err != nil:
+		logger.WithError(err).Warn("process - Funding charge failed, will retry")
+		return false
+	}
+
+	depositCtx := operation.WithReason(ctx, "top-up "+run.ID)
+	depositCtx = operation.WithIdempotencyKey(depositCtx, "top-up-run-"+run.ID)
+	err = w.wallets.Deposit(depositCtx, run.UserID, run.Amount)
+
+	// The run stays pending and is claimed again once retryAfter has passed;
+	// the provider deduplicates the charge on the run ID
+	if errors.Is(err, ErrIdempotencyInProgress) || errors.Is(err, ErrOperationDisabled) || ctx.Err() != nil {
+		logger.WithError(err).Warn("process - Deposit interrupted, will retry")
+		return false
+	}
+	if err != nil {
+		// The charge was collected: the alert carries its reference so the
+		// funds can be returned
+		return w.fail(ctx, logger, run, fmt.Sprintf("charge %s collected but not deposited: %v", reference, err), &reference)
+	}
+
+	if err := w.repo.CompleteTopUp(ctx, run.ID, reference); err != nil {
+		logger.WithError(err).Error("process - Record top-up failed")
+		return false
+	}
+	return true
+}
+
+func (w *TopUpWorker) fail(ctx context.Context, logger *logrus.Entry, run models.TopUpRun, reason string, reference *string) bool {
+	if _, err := w.repo.FailTopUp(ctx, run, reason, reference, MaxTopUpFailures); err != nil {
+		logger.WithError(err).Error("process - Record top-up failure failed")
+		return false
+	}
+	return true
+}
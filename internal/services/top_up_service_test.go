@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/funding"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestTopUpService_PutRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTopUpRepository(ctrl)
+	service := NewTopUpService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "user1", Channel: operation.ChannelAPI})
+
+	t.Run("records the author", func(t *testing.T) {
+		mockRepo.EXPECT().PutTopUpRule(ctx, &models.TopUpRule{
+			UserID:        "user1",
+			Threshold:     decimal.NewFromInt(20),
+			Amount:        decimal.NewFromInt(50),
+			FundingSource: "card:tok_visa_4242",
+			CreatedBy:     "user1",
+		}).Return(nil)
+
+		rule, err := service.PutRule(ctx, "user1", decimal.NewFromInt(20), decimal.NewFromInt(50), " card:tok_visa_4242 ")
+		require.NoError(t, err)
+		assert.Equal(t, "card:tok_visa_4242", rule.FundingSource)
+	})
+
+	t.Run("rejects a negative threshold", func(t *testing.T) {
+		_, err := service.PutRule(ctx, "user1", decimal.NewFromInt(-1), decimal.NewFromInt(50), "card:tok_visa_4242")
+		assert.ErrorIs(t, err, ErrInvalidTopUpRule)
+	})
+
+	t.Run("requires a funding source", func(t *testing.T) {
+		_, err := service.PutRule(ctx, "user1", decimal.NewFromInt(20), decimal.NewFromInt(50), " ")
+		assert.ErrorIs(t, err, ErrInvalidTopUpRule)
+	})
+}
+
+func TestTopUpService_Transitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTopUpRepository(ctrl)
+	service := NewTopUpService(mockRepo, logrus.New())
+	ctx := context.Background()
+	rule := func(status string) *models.TopUpRule {
+		return &models.TopUpRule{UserID: "user1", Status: status}
+	}
+
+	t.Run("resume a rule paused after failures", func(t *testing.T) {
+		mockRepo.EXPECT().GetTopUpRule(ctx, "user1").Return(rule(models.TopUpRulePaused), nil)
+		mockRepo.EXPECT().UpdateTopUpRuleStatus(ctx, "user1", models.TopUpRulePaused, models.TopUpRuleActive).Return(rule(models.TopUpRuleActive), nil)
+
+		resumed, err := service.Resume(ctx, "user1")
+		require.NoError(t, err)
+		assert.Equal(t, models.TopUpRuleActive, resumed.Status)
+	})
+
+	t.Run("paused rules cannot be paused again", func(t *testing.T) {
+		mockRepo.EXPECT().GetTopUpRule(ctx, "user1").Return(rule(models.TopUpRulePaused), nil)
+
+		_, err := service.Pause(ctx, "user1")
+		assert.ErrorIs(t, err, ErrInvalidTopUpTransition)
+	})
+}
+
+func TestTopUpWorker_ProcessBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTopUpRepository(ctrl)
+	mockWallets := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	mockProvider := mocks.NewMockFundingProvider(ctrl)
+	logger := logrus.New()
+	wallets := NewWalletService(mockWallets, mockCache, logger, WithIdempotency(mockIdempotency))
+	worker := NewTopUpWorker(mockRepo, wallets, mockProvider, nil, 10, time.Minute, logger)
+	ctx := context.Background()
+
+	run := func(id, userID string) models.TopUpRun {
+		return models.TopUpRun{ID: id, UserID: userID, Amount: decimal.NewFromInt(50), FundingSource: "card:tok_visa_4242", Status: models.TopUpRunPending, Attempts: 1}
+	}
+	reserve := func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+		return record, true, nil
+	}
+
+	mockRepo.EXPECT().ClaimStaleTopUps(ctx, gomock.Any(), 10).Return([]models.TopUpRun{run("6", "user1")}, nil)
+	mockRepo.EXPECT().ClaimDueTopUps(ctx, gomock.Any(), 10).Return([]models.TopUpRun{run("7", "user2"), run("8", "user3"), run("9", "user4")}, nil)
+
+	// The stale run is collected and deposited under its own idempotency key
+	mockProvider.EXPECT().Collect(ctx, funding.Charge{TopUpID: "6", UserID: "user1", Amount: decimal.NewFromInt(50), Source: "card:tok_visa_4242"}).Return("ch_6", nil)
+	opCtx := withOperation(operation.Operation{Reason: "top-up 6", IdempotencyKey: "top-up-run-6"})
+	mockIdempotency.EXPECT().Reserve(opCtx, gomock.Any()).DoAndReturn(reserve)
+	mockWallets.EXPECT().Deposit(opCtx, "user1", decimal.NewFromInt(50)).Return(nil)
+	mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+	mockIdempotency.EXPECT().Complete(opCtx, "user1", "top-up-run-6").Return(nil)
+	mockRepo.EXPECT().CompleteTopUp(ctx, "6", "ch_6").Return(nil)
+
+	// A declined charge fails the run without a deposit
+	mockProvider.EXPECT().Collect(ctx, gomock.Any()).Return("", funding.ErrDeclined)
+	mockRepo.EXPECT().FailTopUp(ctx, run("7", "user2"), funding.ErrDeclined.Error(), nil, MaxTopUpFailures).Return(&models.TopUpRule{Status: models.TopUpRuleActive, ConsecutiveFailures: 1}, nil)
+
+	// A transient provider error leaves the run pending
+	mockProvider.EXPECT().Collect(ctx, gomock.Any()).Return("", assert.AnError)
+
+	// A collected charge the wallet refuses fails with its reference
+	mockProvider.EXPECT().Collect(ctx, gomock.Any()).Return("ch_9", nil)
+	mockIdempotency.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(reserve)
+	mockWallets.EXPECT().Deposit(gomock.Any(), "user4", decimal.NewFromInt(50)).Return(postgres.ErrWalletFrozen)
+	mockIdempotency.EXPECT().Release(gomock.Any(), "user4", "top-up-run-9").Return(nil)
+	mockRepo.EXPECT().FailTopUp(ctx, run("9", "user4"), gomock.Any(), gomock.Any(), MaxTopUpFailures).
+		DoAndReturn(func(_ context.Context, _ models.TopUpRun, reason string, reference *string, _ int) (*models.TopUpRule, error) {
+			assert.Contains(t, reason, "ch_9")
+			assert.Equal(t, "ch_9", *reference)
+			return nil, nil
+		})
+
+	settled, err := worker.ProcessBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, settled)
+}
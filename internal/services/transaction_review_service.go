@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const maxAnnotationLength = 1000
+
+var (
+	ErrInvalidTransactionFlag = errors.New("flag must be one of suspicious, reviewed, training_excluded")
+	ErrInvalidAnnotation      = errors.New("note must be between 1 and 1000 characters")
+)
+
+// TransactionReviewService lets admins flag the transactions they review for
+// fraud and annotate them. Flag changes are published as events, so fraud
+// tooling and the data warehouse see them.
+type TransactionReviewService struct {
+	repo   postgres.TransactionReviewRepository
+	logger *logrus.Logger
+}
+
+func NewTransactionReviewService(repo postgres.TransactionReviewRepository, logger *logrus.Logger) *TransactionReviewService {
+	return &TransactionReviewService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Get returns the flags and annotations of a transaction
+func (s *TransactionReviewService) Get(ctx context.Context, transactionID string) (*models.TransactionReview, error) {
+	return s.repo.GetTransactionReview(ctx, transactionID)
+}
+
+// Flag sets flag on a transaction. Setting a flag it carries changes
+// nothing.
+func (s *TransactionReviewService) Flag(ctx context.Context, transactionID, flag string) (*models.TransactionReview, error) {
+	return s.setFlag(ctx, transactionID, flag, true)
+}
+
+// Unflag removes flag from a transaction. Removing a flag it does not carry
+// changes nothing.
+func (s *TransactionReviewService) Unflag(ctx context.Context, transactionID, flag string) (*models.TransactionReview, error) {
+	return s.setFlag(ctx, transactionID, flag, false)
+}
+
+func (s *TransactionReviewService) setFlag(ctx context.Context, transactionID, flag string, set bool) (*models.TransactionReview, error) {
+	if !slices.Contains(models.TransactionFlags, flag) {
+		return nil, ErrInvalidTransactionFlag
+	}
+
+	op, _ := operation.From(ctx)
+	review, err := s.repo.SetTransactionFlag(ctx, transactionID, flag, op.Actor, set)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"transactionID": transactionID,
+		"flag":          flag,
+		"set":           set,
+		"actor":         op.Actor,
+	}).Info("Transaction flag changed")
+	return review, nil
+}
+
+// Annotate attaches a note to a transaction
+func (s *TransactionReviewService) Annotate(ctx context.Context, transactionID, note string) (*models.TransactionAnnotation, error) {
+	note = strings.TrimSpace(note)
+	if note == "" || utf8.RuneCountInString(note) > maxAnnotationLength {
+		return nil, ErrInvalidAnnotation
+	}
+
+	op, _ := operation.From(ctx)
+	annotation := &models.TransactionAnnotation{
+		TransactionID: transactionID,
+		Note:          note,
+		CreatedBy:     op.Actor,
+	}
+	if err := s.repo.AnnotateTransaction(ctx, annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestTransactionReviewService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransactionReviewRepository(ctrl)
+	service := NewTransactionReviewService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin-1", Channel: operation.ChannelAdmin})
+
+	t.Run("Flag records the actor", func(t *testing.T) {
+		review := &models.TransactionReview{TransactionID: "7"}
+		mockRepo.EXPECT().SetTransactionFlag(ctx, "7", models.FlagSuspicious, "admin-1", true).Return(review, nil)
+
+		got, err := service.Flag(ctx, "7", models.FlagSuspicious)
+		require.NoError(t, err)
+		assert.Same(t, review, got)
+	})
+
+	t.Run("Unflag", func(t *testing.T) {
+		mockRepo.EXPECT().SetTransactionFlag(ctx, "7", models.FlagTrainingExcluded, "admin-1", false).Return(&models.TransactionReview{}, nil)
+
+		_, err := service.Unflag(ctx, "7", models.FlagTrainingExcluded)
+		require.NoError(t, err)
+	})
+
+	t.Run("Flag rejects unknown flags", func(t *testing.T) {
+		_, err := service.Flag(ctx, "7", "fraud")
+		assert.ErrorIs(t, err, ErrInvalidTransactionFlag)
+	})
+
+	t.Run("Annotate trims the note", func(t *testing.T) {
+		mockRepo.EXPECT().AnnotateTransaction(ctx, &models.TransactionAnnotation{
+			TransactionID: "7",
+			Note:          "matches a known mule pattern",
+			CreatedBy:     "admin-1",
+		}).Return(nil)
+
+		annotation, err := service.Annotate(ctx, "7", "  matches a known mule pattern\n")
+		require.NoError(t, err)
+		assert.Equal(t, "admin-1", annotation.CreatedBy)
+	})
+
+	t.Run("Annotate rejects empty and long notes", func(t *testing.T) {
+		_, err := service.Annotate(ctx, "7", "   ")
+		assert.ErrorIs(t, err, ErrInvalidAnnotation)
+
+		_, err = service.Annotate(ctx, "7", strings.Repeat("x", maxAnnotationLength+1))
+		assert.ErrorIs(t, err, ErrInvalidAnnotation)
+	})
+}
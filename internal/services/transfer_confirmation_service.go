@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/otp"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// transferConfirmationExpiryGrace keeps a confirmation pending for a while
+// after it expired, so a transfer confirmed just before the expiry is
+// recorded
+const transferConfirmationExpiryGrace = time.Minute
+
+var (
+	ErrTransferConfirmationExpired = errors.New("transfer confirmation expired")
+	ErrConfirmationCodeUndelivered = errors.New("the confirmation code could not be sent, try again later")
+)
+
+// TransferConfirmationConfig configures the confirmation of high-value
+// transfers
+type TransferConfirmationConfig struct {
+	// Threshold is the amount above which transfers need a confirmation
+	Threshold decimal.Decimal
+	// TTL is how long a confirmation can be confirmed
+	TTL time.Duration
+	// MaxAttempts is the number of wrong codes failing a confirmation
+	MaxAttempts int
+	// BatchSize is the number of confirmations expired per run
+	BatchSize int
+}
+
+// TransferConfirmationService holds transfers above a threshold until their
+// sender confirms them with a one-time password sent through the notifier.
+// The confirmed transfer goes through the limits, compliance checks and fees
+// of any transfer when it is made.
+type TransferConfirmationService struct {
+	repo     postgres.TransferConfirmationRepository
+	wallets  *WalletService
+	notifier otp.Notifier
+	config   TransferConfirmationConfig
+	logger   *logrus.Logger
+}
+
+func NewTransferConfirmationService(repo postgres.TransferConfirmationRepository, wallets *WalletService, notifier otp.Notifier, config TransferConfirmationConfig, logger *logrus.Logger) *TransferConfirmationService {
+	return &TransferConfirmationService{
+		repo:     repo,
+		wallets:  wallets,
+		notifier: notifier,
+		config:   config,
+		logger:   logger,
+	}
+}
+
+// Required reports whether a transfer of amount needs a confirmation
+func (s *TransferConfirmationService) Required(amount decimal.Decimal) bool {
+	return amount.GreaterThan(s.config.Threshold)
+}
+
+// Request records a transfer waiting for confirmation, with the transaction
+// details in ctx, and sends its code to the sender. Retrying a request with
+// the same Idempotency-Key returns the confirmation created first without
+// sending another code; reusing the key for another transfer fails with
+// ErrIdempotencyKeyReused.
+func (s *TransferConfirmationService) Request(ctx context.Context, senderID, receiverID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) (*models.TransferConfirmation, error) {
+	code, err := otp.NewCode()
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation := &models.TransferConfirmation{
+		SenderID:        senderID,
+		ReceiverID:      receiverID,
+		Amount:          amount,
+		ExpectedBalance: expectedBalance,
+		CodeHash:        otp.Hash(code),
+		ExpiresAt:       time.Now().Add(s.config.TTL),
+	}
+	created, err := s.repo.CreateTransferConfirmation(ctx, confirmation)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		if confirmation.ReceiverID != receiverID || !confirmation.Amount.Equal(amount) {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return confirmation, nil
+	}
+
+	err = s.notifier.Send(ctx, otp.Message{
+		UserID:         senderID,
+		ConfirmationID: confirmation.ID,
+		ReceiverID:     receiverID,
+		Amount:         amount,
+		Code:           code,
+		ExpiresAt:      confirmation.ExpiresAt,
+	})
+	if err != nil {
+		// The confirmation cannot be confirmed without its code and expires
+		s.logger.WithContext(ctx).WithError(err).WithField("confirmationID", confirmation.ID).Error("Request - Send confirmation code failed")
+		return nil, ErrConfirmationCodeUndelivered
+	}
+	return confirmation, nil
+}
+
+// Get returns a confirmation of a transfer from senderID
+func (s *TransferConfirmationService) Get(ctx context.Context, senderID, confirmationID string) (*models.TransferConfirmation, error) {
+	return s.repo.GetTransferConfirmation(ctx, senderID, confirmationID)
+}
+
+// Confirm makes the transfer of a pending confirmation if code is its
+// one-time password, and records the confirmation as confirmed. The transfer
+// is keyed by the confirmation, so confirming again after a failure never
+// transfers twice. A transfer rejected, for example for lack of funds, leaves
+// the confirmation pending.
+func (s *TransferConfirmationService) Confirm(ctx context.Context, senderID, confirmationID, code string) (*models.TransferConfirmation, error) {
+	confirmation, err := s.repo.GetTransferConfirmation(ctx, senderID, confirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if confirmation.Status != models.TransferConfirmationPending {
+		return nil, postgres.ErrTransferConfirmationNotPending
+	}
+	if !time.Now().Before(confirmation.ExpiresAt) {
+		return nil, ErrTransferConfirmationExpired
+	}
+
+	confirmation, err = s.repo.VerifyTransferConfirmation(ctx, senderID, confirmationID, code, s.config.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = operation.WithReason(ctx, "transfer confirmation "+confirmation.ID)
+	transferCtx := operation.WithIdempotencyKey(ctx, "transfer-confirmation-"+confirmation.ID)
+	// The details were checked when the transfer was requested
+	transferCtx = operation.WithDetails(transferCtx, operation.Details{
+		Memo:      confirmation.Memo,
+		Reference: confirmation.ReferenceID,
+		Metadata:  confirmation.Metadata,
+	})
+	err = s.wallets.Transfer(transferCtx, senderID, confirmation.ReceiverID, confirmation.Amount, confirmation.ExpectedBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := s.repo.CompleteTransferConfirmation(ctx, senderID, confirmationID)
+	if err != nil {
+		// The amount was transferred; confirming again replays the transfer
+		// and records the confirmation
+		s.logger.WithContext(ctx).WithError(err).WithField("confirmationID", confirmationID).Error("Confirm - Record confirmed transfer failed")
+		return nil, err
+	}
+	return confirmed, nil
+}
+
+// Run expires the confirmations left pending immediately and then on each
+// interval until ctx is cancelled
+func (s *TransferConfirmationService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "transfer-confirmations", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.ExpireDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireDue expires up to the batch size of pending confirmations past
+// their expiry and returns how many it expired
+func (s *TransferConfirmationService) ExpireDue(ctx context.Context) (int, error) {
+	expired, err := s.repo.ExpireTransferConfirmations(ctx, time.Now().Add(-transferConfirmationExpiryGrace), s.config.BatchSize)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("ExpireDue - Expire transfer confirmations failed, will retry")
+		return 0, err
+	}
+	return expired, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/otp"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+type fakeNotifier struct {
+	sent []otp.Message
+	err  error
+}
+
+func (n *fakeNotifier) Send(_ context.Context, message otp.Message) error {
+	n.sent = append(n.sent, message)
+	return n.err
+}
+
+var testConfirmationConfig = TransferConfirmationConfig{
+	Threshold:   decimal.NewFromInt(1000),
+	TTL:         5 * time.Minute,
+	MaxAttempts: 5,
+	BatchSize:   100,
+}
+
+func TestTransferConfirmationService_Required(t *testing.T) {
+	service := NewTransferConfirmationService(nil, nil, nil, testConfirmationConfig, logrus.New())
+
+	assert.False(t, service.Required(decimal.NewFromInt(1000)))
+	assert.True(t, service.Required(decimal.NewFromFloat(1000.01)))
+}
+
+func TestTransferConfirmationService_Request(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransferConfirmationRepository(ctrl)
+	notifier := &fakeNotifier{}
+	service := NewTransferConfirmationService(mockRepo, nil, notifier, testConfirmationConfig, logrus.New())
+	ctx := context.Background()
+	amount := decimal.NewFromInt(5000)
+
+	t.Run("sends the code of a new confirmation", func(t *testing.T) {
+		notifier.sent = nil
+		var hash string
+		mockRepo.EXPECT().CreateTransferConfirmation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, confirmation *models.TransferConfirmation) (bool, error) {
+			assert.WithinDuration(t, time.Now().Add(5*time.Minute), confirmation.ExpiresAt, time.Minute)
+			hash = confirmation.CodeHash
+			confirmation.ID = "7"
+			confirmation.Status = models.TransferConfirmationPending
+			return true, nil
+		})
+
+		confirmation, err := service.Request(ctx, "user1", "user2", amount, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "7", confirmation.ID)
+		require.Len(t, notifier.sent, 1)
+		assert.Equal(t, "user1", notifier.sent[0].UserID)
+		assert.True(t, otp.Matches(hash, notifier.sent[0].Code))
+	})
+
+	t.Run("a retry returns the existing confirmation without sending another code", func(t *testing.T) {
+		notifier.sent = nil
+		mockRepo.EXPECT().CreateTransferConfirmation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, confirmation *models.TransferConfirmation) (bool, error) {
+			confirmation.ID = "7"
+			return false, nil
+		})
+
+		confirmation, err := service.Request(ctx, "user1", "user2", amount, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "7", confirmation.ID)
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("a key reused for another transfer is rejected", func(t *testing.T) {
+		mockRepo.EXPECT().CreateTransferConfirmation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, confirmation *models.TransferConfirmation) (bool, error) {
+			confirmation.Amount = decimal.NewFromInt(2000)
+			return false, nil
+		})
+
+		_, err := service.Request(ctx, "user1", "user2", amount, nil)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("an undelivered code fails the request", func(t *testing.T) {
+		notifier.err = errors.New("connection refused")
+		defer func() { notifier.err = nil }()
+		mockRepo.EXPECT().CreateTransferConfirmation(ctx, gomock.Any()).Return(true, nil)
+
+		_, err := service.Request(ctx, "user1", "user2", amount, nil)
+		assert.ErrorIs(t, err, ErrConfirmationCodeUndelivered)
+	})
+}
+
+func TestTransferConfirmationService_Confirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransferConfirmationRepository(ctrl)
+	mockWallets := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockIdempotency := mocks.NewMockIdempotencyRepository(ctrl)
+	logger := logrus.New()
+	wallets := NewWalletService(mockWallets, mockCache, logger, WithIdempotency(mockIdempotency))
+	service := NewTransferConfirmationService(mockRepo, wallets, &fakeNotifier{}, testConfirmationConfig, logger)
+	ctx := context.Background()
+
+	pending := func() *models.TransferConfirmation {
+		return &models.TransferConfirmation{
+			ID:         "7",
+			SenderID:   "user1",
+			ReceiverID: "user2",
+			Amount:     decimal.NewFromInt(5000),
+			Memo:       "Rent",
+			Status:     models.TransferConfirmationPending,
+			ExpiresAt:  time.Now().Add(time.Minute),
+		}
+	}
+
+	t.Run("transfers the amount under the key of the confirmation", func(t *testing.T) {
+		opCtx := withOperation(operation.Operation{Reason: "transfer confirmation 7"})
+		mockRepo.EXPECT().GetTransferConfirmation(ctx, "user1", "7").Return(pending(), nil)
+		mockRepo.EXPECT().VerifyTransferConfirmation(ctx, "user1", "7", "123456", 5).Return(pending(), nil)
+		mockIdempotency.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+			assert.Equal(t, "transfer-confirmation-7", record.Key)
+			return record, true, nil
+		})
+		mockWallets.EXPECT().Transfer(gomock.Any(), "user1", "user2", decimal.NewFromInt(5000), nil).DoAndReturn(func(ctx context.Context, _, _ string, _ decimal.Decimal, _ *decimal.Decimal) error {
+			details := operation.DetailsFrom(ctx)
+			assert.Equal(t, "Rent", details.Memo)
+			return nil
+		})
+		mockCache.EXPECT().InvalidateBalance(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockIdempotency.EXPECT().Complete(gomock.Any(), "user1", "transfer-confirmation-7").Return(nil)
+		confirmed := pending()
+		confirmed.Status = models.TransferConfirmationConfirmed
+		mockRepo.EXPECT().CompleteTransferConfirmation(opCtx, "user1", "7").Return(confirmed, nil)
+
+		confirmation, err := service.Confirm(ctx, "user1", "7", "123456")
+		require.NoError(t, err)
+		assert.Equal(t, models.TransferConfirmationConfirmed, confirmation.Status)
+	})
+
+	t.Run("a wrong code does not transfer", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransferConfirmation(ctx, "user1", "7").Return(pending(), nil)
+		mockRepo.EXPECT().VerifyTransferConfirmation(ctx, "user1", "7", "000000", 5).Return(nil, postgres.ErrInvalidConfirmationCode)
+
+		_, err := service.Confirm(ctx, "user1", "7", "000000")
+		assert.ErrorIs(t, err, postgres.ErrInvalidConfirmationCode)
+	})
+
+	t.Run("an expired confirmation is not transferred", func(t *testing.T) {
+		expired := pending()
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+		mockRepo.EXPECT().GetTransferConfirmation(ctx, "user1", "7").Return(expired, nil)
+
+		_, err := service.Confirm(ctx, "user1", "7", "123456")
+		assert.ErrorIs(t, err, ErrTransferConfirmationExpired)
+	})
+
+	t.Run("a failed confirmation cannot be confirmed", func(t *testing.T) {
+		failed := pending()
+		failed.Status = models.TransferConfirmationFailed
+		mockRepo.EXPECT().GetTransferConfirmation(ctx, "user1", "7").Return(failed, nil)
+
+		_, err := service.Confirm(ctx, "user1", "7", "123456")
+		assert.ErrorIs(t, err, postgres.ErrTransferConfirmationNotPending)
+	})
+}
+
+func TestTransferConfirmationService_ExpireDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTransferConfirmationRepository(ctrl)
+	service := NewTransferConfirmationService(mockRepo, nil, nil, testConfirmationConfig, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ExpireTransferConfirmations(ctx, gomock.Any(), 100).DoAndReturn(func(_ context.Context, before time.Time, _ int) (int, error) {
+		assert.GreaterOrEqual(t, time.Since(before), transferConfirmationExpiryGrace)
+		return 2, nil
+	})
+
+	expired, err := service.ExpireDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+}
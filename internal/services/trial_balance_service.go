@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	defaultTrialBalanceLimit = 30
+	maxTrialBalanceLimit     = 366
+)
+
+var ErrInvalidDay = errors.New("day must be formatted as YYYY-MM-DD")
+
+// TrialBalanceService generates the trial balance of every UTC day once it
+// has ended, giving finance a daily closing artifact
+type TrialBalanceService struct {
+	repo   postgres.TrialBalanceRepository
+	logger *logrus.Logger
+}
+
+func NewTrialBalanceService(repo postgres.TrialBalanceRepository, logger *logrus.Logger) *TrialBalanceService {
+	return &TrialBalanceService{repo: repo, logger: logger}
+}
+
+// Run generates the trial balance of the previous day immediately and then
+// on each interval until ctx is cancelled
+func (s *TrialBalanceService) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "trial-balance", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.GenerateDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GenerateDue generates the trial balance of the previous UTC day unless it
+// exists. It returns the trial balance generated, nil when none was due.
+func (s *TrialBalanceService) GenerateDue(ctx context.Context) (*models.TrialBalance, error) {
+	day := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	op, _ := operation.From(ctx)
+
+	trialBalance, err := s.repo.GenerateTrialBalance(ctx, day, op.Actor)
+	if errors.Is(err, postgres.ErrTrialBalanceExists) {
+		return nil, nil
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("day", day.Format(models.TrialBalanceDayFormat)).Error("GenerateDue - Generate trial balance failed, will retry")
+		return nil, err
+	}
+	return trialBalance, nil
+}
+
+// List returns the latest days generated, newest first
+func (s *TrialBalanceService) List(ctx context.Context, limit int) ([]models.TrialBalance, error) {
+	if limit <= 0 {
+		limit = defaultTrialBalanceLimit
+	}
+	return s.repo.ListTrialBalances(ctx, min(limit, maxTrialBalanceLimit))
+}
+
+// Get returns the trial balance of day, formatted as YYYY-MM-DD
+func (s *TrialBalanceService) Get(ctx context.Context, day string) (*models.TrialBalance, error) {
+	parsed, err := time.Parse(models.TrialBalanceDayFormat, day)
+	if err != nil {
+		return nil, ErrInvalidDay
+	}
+	return s.repo.GetTrialBalance(ctx, parsed)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestTrialBalanceService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTrialBalanceRepository(ctrl)
+	service := NewTrialBalanceService(mockRepo, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "trial-balance", Channel: operation.ChannelJob})
+	yesterday := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+
+	t.Run("GenerateDue generates the previous day", func(t *testing.T) {
+		generated := &models.TrialBalance{Day: yesterday.Format(models.TrialBalanceDayFormat)}
+		mockRepo.EXPECT().GenerateTrialBalance(ctx, yesterday, "trial-balance").Return(generated, nil)
+
+		trialBalance, err := service.GenerateDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, generated, trialBalance)
+	})
+
+	t.Run("GenerateDue once a day", func(t *testing.T) {
+		mockRepo.EXPECT().GenerateTrialBalance(ctx, yesterday, "trial-balance").Return(nil, postgres.ErrTrialBalanceExists)
+
+		trialBalance, err := service.GenerateDue(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, trialBalance)
+	})
+
+	t.Run("GenerateDue failure", func(t *testing.T) {
+		mockRepo.EXPECT().GenerateTrialBalance(ctx, yesterday, "trial-balance").Return(nil, assert.AnError)
+
+		_, err := service.GenerateDue(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		mockRepo.EXPECT().GetTrialBalance(ctx, time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)).
+			Return(&models.TrialBalance{Day: "2024-05-01"}, nil)
+
+		trialBalance, err := service.Get(ctx, "2024-05-01")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-01", trialBalance.Day)
+
+		_, err = service.Get(ctx, "May 1")
+		assert.ErrorIs(t, err, ErrInvalidDay)
+	})
+
+	t.Run("List caps the limit", func(t *testing.T) {
+		mockRepo.EXPECT().ListTrialBalances(ctx, 30).Return([]models.TrialBalance{}, nil)
+		mockRepo.EXPECT().ListTrialBalances(ctx, 366).Return([]models.TrialBalance{}, nil)
+
+		_, err := service.List(ctx, 0)
+		require.NoError(t, err)
+		_, err = service.List(ctx, 5000)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const maxExternalIDLength = 255
+
+var ErrInvalidExternalID = errors.New("external_id must be at most 255 characters")
+
+// userIDPattern matches the lowercase UUIDs users are registered with, and
+// the currency sub-accounts of their other default currencies
+var userIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}(:[A-Z0-9]{3,10})?$`)
+
+// ValidUserID reports whether userID is a UUID as users are registered with,
+// or a currency sub-account of one
+func ValidUserID(userID string) bool {
+	return userIDPattern.MatchString(userID)
+}
+
+// UserService registers users with their wallets. Deposits, transfers and
+// provisioning are rejected for users that are not registered.
+type UserService struct {
+	repo       postgres.UserRepository
+	currencies []string
+	logger     *logrus.Logger
+}
+
+// NewUserService creates a UserService registering users with a wallet in
+// each of the default currencies, or without a wallet when there are none
+func NewUserService(repo postgres.UserRepository, currencies []string, logger *logrus.Logger) *UserService {
+	return &UserService{
+		repo:       repo,
+		currencies: currencies,
+		logger:     logger,
+	}
+}
+
+// CreateUser registers a customer, identified by the integrator as
+// externalID when it is not empty, with their wallets
+func (s *UserService) CreateUser(ctx context.Context, externalID string) (*models.User, error) {
+	if len(externalID) > maxExternalIDLength {
+		return nil, ErrInvalidExternalID
+	}
+
+	user := &models.User{}
+	if externalID != "" {
+		user.ExternalID = &externalID
+	}
+	if err := s.repo.CreateUser(ctx, user, s.currencies); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUser returns the registered user userID
+func (s *UserService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	if !ValidUserID(userID) {
+		return nil, postgres.ErrInvalidUserID
+	}
+	return s.repo.GetUser(ctx, userID)
+}
+
+// FindUser returns the user the integrator identifies as externalID
+func (s *UserService) FindUser(ctx context.Context, externalID string) (*models.User, error) {
+	if externalID == "" || len(externalID) > maxExternalIDLength {
+		return nil, ErrInvalidExternalID
+	}
+	return s.repo.GetUserByExternalID(ctx, externalID)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestUserService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	currencies := []string{"USD", "EUR"}
+	service := NewUserService(mockRepo, currencies, logrus.New())
+	ctx := context.Background()
+	userID := "6f1c2a4e-8a57-4e0b-9a53-3b1f2f0c9d11"
+
+	t.Run("CreateUser with an external ID", func(t *testing.T) {
+		mockRepo.EXPECT().CreateUser(ctx, gomock.Any(), currencies).DoAndReturn(func(_ context.Context, user *models.User, _ []string) error {
+			assert.Equal(t, "crm-42", *user.ExternalID)
+			user.ID = userID
+			return nil
+		})
+
+		user, err := service.CreateUser(ctx, "crm-42")
+		require.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+	})
+
+	t.Run("CreateUser without an external ID", func(t *testing.T) {
+		mockRepo.EXPECT().CreateUser(ctx, &models.User{}, currencies).Return(nil)
+
+		_, err := service.CreateUser(ctx, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("CreateUser rejects a long external ID", func(t *testing.T) {
+		_, err := service.CreateUser(ctx, strings.Repeat("x", maxExternalIDLength+1))
+		assert.ErrorIs(t, err, ErrInvalidExternalID)
+	})
+
+	t.Run("ValidUserID accepts currency sub-accounts", func(t *testing.T) {
+		assert.True(t, ValidUserID(models.CurrencyAccount(userID, "EUR")))
+		assert.False(t, ValidUserID(userID+":eur"))
+		assert.False(t, ValidUserID(models.SavingsAccount(userID)))
+	})
+
+	t.Run("GetUser rejects user IDs that are not UUIDs", func(t *testing.T) {
+		_, err := service.GetUser(ctx, "user1")
+		assert.ErrorIs(t, err, postgres.ErrInvalidUserID)
+	})
+
+	t.Run("FindUser", func(t *testing.T) {
+		mockRepo.EXPECT().GetUserByExternalID(ctx, "crm-42").Return(&models.User{ID: userID}, nil)
+
+		user, err := service.FindUser(ctx, "crm-42")
+		require.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+	})
+}
+
+func TestValidUserID(t *testing.T) {
+	assert.True(t, ValidUserID("6f1c2a4e-8a57-4e0b-9a53-3b1f2f0c9d11"))
+	assert.False(t, ValidUserID("6F1C2A4E-8A57-4E0B-9A53-3B1F2F0C9D11"))
+	assert.False(t, ValidUserID("user1"))
+	assert.False(t, ValidUserID(""))
+	assert.False(t, ValidUserID(models.SavingsAccount("6f1c2a4e-8a57-4e0b-9a53-3b1f2f0c9d11")))
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+const (
+	defaultWalletListLimit = 50
+	maxWalletListLimit     = 500
+)
+
+var (
+	ErrInvalidReasonCode   = errors.New("reason_code must be one of correction, chargeback, fee_refund, goodwill, write_off")
+	ErrInvalidCurrencyCode = errors.New("currency must be a code of 3 to 10 letters or digits")
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z0-9]{3,10}$`)
+
+// ValidCurrencyCode reports whether code is a currency code once trimmed
+// and upper-cased, as the services store it
+func ValidCurrencyCode(code string) bool {
+	return currencyPattern.MatchString(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// WalletAdminService lets operators list wallets, freeze, unfreeze and close
+// single wallets and adjust balances manually. Frozen wallets keep rejecting
+// deposits, withdrawals and transfers until they are unfrozen; closed wallets
+// reject them for good.
+type WalletAdminService struct {
+	repo   postgres.WalletAdminRepository
+	cache  redis.CacheRepository
+	logger *logrus.Logger
+}
+
+func NewWalletAdminService(repo postgres.WalletAdminRepository, cache redis.CacheRepository, logger *logrus.Logger) *WalletAdminService {
+	return &WalletAdminService{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// ListWallets returns a page of wallets matching filter and the user ID to
+// continue after, which is empty on the last page
+func (s *WalletAdminService) ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, string, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultWalletListLimit
+	}
+	filter.Limit = min(filter.Limit, maxWalletListLimit)
+	if err := validateMetadata(filter.Metadata); err != nil {
+		return nil, "", err
+	}
+
+	wallets, err := s.repo.ListWallets(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextAfter string
+	if len(wallets) == filter.Limit {
+		nextAfter = wallets[len(wallets)-1].UserID
+	}
+	return wallets, nextAfter, nil
+}
+
+// Freeze freezes the active wallet of userID
+func (s *WalletAdminService) Freeze(ctx context.Context, userID, reason string) error {
+	return s.setStatus(ctx, userID, models.WalletStatusActive, models.WalletStatusFrozen, reason)
+}
+
+// Unfreeze reactivates the frozen wallet of userID
+func (s *WalletAdminService) Unfreeze(ctx context.Context, userID, reason string) error {
+	return s.setStatus(ctx, userID, models.WalletStatusFrozen, models.WalletStatusActive, reason)
+}
+
+// Close closes the empty wallet of userID. Closed wallets reject every
+// further operation and cannot be reopened.
+func (s *WalletAdminService) Close(ctx context.Context, userID, reason string) error {
+	ctx = operation.WithReason(ctx, reason)
+	if err := s.repo.CloseWallet(ctx, userID, reason); err != nil {
+		return err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, userID)
+	return nil
+}
+
+func (s *WalletAdminService) setStatus(ctx context.Context, userID, from, to, reason string) error {
+	ctx = operation.WithReason(ctx, reason)
+	if err := s.repo.SetStatus(ctx, userID, from, to, reason); err != nil {
+		return err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, userID)
+	return nil
+}
+
+// AdjustBalance credits a positive amount to or debits a negative amount from
+// the wallet of userID and returns the audit record. The actor of the
+// operation is recorded.
+func (s *WalletAdminService) AdjustBalance(ctx context.Context, userID string, amount decimal.Decimal, reasonCode, note string) (*models.BalanceAdjustment, error) {
+	if !slices.Contains(models.AdjustmentReasonCodes, reasonCode) {
+		return nil, ErrInvalidReasonCode
+	}
+
+	ctx = operation.WithReason(ctx, reasonCode)
+	op, _ := operation.From(ctx)
+	adjustment := &models.BalanceAdjustment{
+		UserID:     userID,
+		Amount:     amount,
+		ReasonCode: reasonCode,
+		Note:       note,
+		Actor:      op.Actor,
+	}
+
+	if err := s.repo.AdjustBalance(ctx, adjustment); err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.InvalidateBalance(ctx, userID)
+	return adjustment, nil
+}
+
+// SetCurrency sets the currency the wallet of userID holds and returns the
+// normalized code. A missing wallet is created empty; a wallet holding funds
+// cannot change currency.
+func (s *WalletAdminService) SetCurrency(ctx context.Context, userID, currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyPattern.MatchString(currency) {
+		return "", ErrInvalidCurrencyCode
+	}
+	if err := s.repo.SetCurrency(ctx, userID, currency); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestWalletAdminService_ListWallets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletAdminRepository(ctrl)
+	service := NewWalletAdminService(mockRepo, nil, logrus.New())
+	ctx := context.Background()
+
+	t.Run("default limit", func(t *testing.T) {
+		mockRepo.EXPECT().ListWallets(ctx, models.WalletFilter{Limit: defaultWalletListLimit}).Return(nil, nil)
+
+		_, nextAfter, err := service.ListWallets(ctx, models.WalletFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, nextAfter)
+	})
+
+	t.Run("limit is capped", func(t *testing.T) {
+		frozen := models.WalletStatusFrozen
+		mockRepo.EXPECT().ListWallets(ctx, models.WalletFilter{Status: &frozen, Limit: maxWalletListLimit}).
+			Return([]models.Wallet{{UserID: "user1", Status: frozen}}, nil)
+
+		wallets, _, err := service.ListWallets(ctx, models.WalletFilter{Status: &frozen, Limit: 10000})
+		assert.NoError(t, err)
+		assert.Len(t, wallets, 1)
+	})
+
+	t.Run("full page continues after the last wallet", func(t *testing.T) {
+		mockRepo.EXPECT().ListWallets(ctx, models.WalletFilter{After: "user1", Limit: 2}).
+			Return([]models.Wallet{{UserID: "user2"}, {UserID: "user3"}}, nil)
+
+		_, nextAfter, err := service.ListWallets(ctx, models.WalletFilter{After: "user1", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "user3", nextAfter)
+	})
+
+	t.Run("rejects an invalid metadata filter", func(t *testing.T) {
+		_, _, err := service.ListWallets(ctx, models.WalletFilter{Metadata: map[string]string{"crm id": "C-42"}})
+		assert.ErrorIs(t, err, ErrInvalidMetadata)
+	})
+}
+
+func TestWalletAdminService_Freeze(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletAdminRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewWalletAdminService(mockRepo, mockCache, logrus.New())
+	ctx := context.Background()
+
+	t.Run("freeze", func(t *testing.T) {
+		opCtx := withOperation(operation.Operation{Reason: "fraud review"})
+		mockRepo.EXPECT().SetStatus(opCtx, "user1", models.WalletStatusActive, models.WalletStatusFrozen, "fraud review").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+
+		assert.NoError(t, service.Freeze(ctx, "user1", "fraud review"))
+	})
+
+	t.Run("unfreeze an active wallet", func(t *testing.T) {
+		mockRepo.EXPECT().SetStatus(gomock.Any(), "user1", models.WalletStatusFrozen, models.WalletStatusActive, "cleared").Return(postgres.ErrWalletNotFrozen)
+
+		assert.ErrorIs(t, service.Unfreeze(ctx, "user1", "cleared"), postgres.ErrWalletNotFrozen)
+	})
+}
+
+func TestWalletAdminService_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletAdminRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewWalletAdminService(mockRepo, mockCache, logrus.New())
+	ctx := context.Background()
+
+	t.Run("close", func(t *testing.T) {
+		opCtx := withOperation(operation.Operation{Reason: "customer request"})
+		mockRepo.EXPECT().CloseWallet(opCtx, "user1", "customer request").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+
+		assert.NoError(t, service.Close(ctx, "user1", "customer request"))
+	})
+
+	t.Run("wallet not empty", func(t *testing.T) {
+		mockRepo.EXPECT().CloseWallet(gomock.Any(), "user1", "customer request").Return(postgres.ErrWalletNotEmpty)
+
+		assert.ErrorIs(t, service.Close(ctx, "user1", "customer request"), postgres.ErrWalletNotEmpty)
+	})
+}
+
+func TestWalletAdminService_AdjustBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletAdminRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewWalletAdminService(mockRepo, mockCache, logrus.New())
+
+	t.Run("records actor and invalidates the balance", func(t *testing.T) {
+		ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+		opCtx := withOperation(operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin, Reason: models.AdjustmentChargeback})
+		mockRepo.EXPECT().AdjustBalance(opCtx, &models.BalanceAdjustment{
+			UserID:     "user1",
+			Amount:     decimal.NewFromInt(-15),
+			ReasonCode: models.AdjustmentChargeback,
+			Note:       "card dispute",
+			Actor:      "admin1",
+		}).DoAndReturn(func(_ context.Context, adjustment *models.BalanceAdjustment) error {
+			adjustment.ID, adjustment.TransactionID = "3", "42"
+			return nil
+		})
+		mockCache.EXPECT().InvalidateBalance(opCtx, "user1").Return(nil)
+
+		adjustment, err := service.AdjustBalance(ctx, "user1", decimal.NewFromInt(-15), models.AdjustmentChargeback, "card dispute")
+		assert.NoError(t, err)
+		assert.Equal(t, "42", adjustment.TransactionID)
+	})
+
+	t.Run("unknown reason code", func(t *testing.T) {
+		_, err := service.AdjustBalance(context.Background(), "user1", decimal.NewFromInt(5), "bonus", "")
+		assert.ErrorIs(t, err, ErrInvalidReasonCode)
+	})
+
+	t.Run("cache untouched on failure", func(t *testing.T) {
+		mockRepo.EXPECT().AdjustBalance(gomock.Any(), gomock.Any()).Return(postgres.ErrInsufficientBalance)
+
+		_, err := service.AdjustBalance(context.Background(), "user1", decimal.NewFromInt(-500), models.AdjustmentWriteOff, "")
+		assert.ErrorIs(t, err, postgres.ErrInsufficientBalance)
+	})
+}
+
+func TestWalletAdminService_SetCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletAdminRepository(ctrl)
+	service := NewWalletAdminService(mockRepo, mocks.NewMockCacheRepository(ctrl), logrus.New())
+	ctx := context.Background()
+
+	t.Run("normalizes the code", func(t *testing.T) {
+		mockRepo.EXPECT().SetCurrency(ctx, "user1", "EUR").Return(nil)
+
+		currency, err := service.SetCurrency(ctx, "user1", " eur ")
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", currency)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		for _, currency := range []string{"", "EU", "E-UR", "EURODOLLARS"} {
+			_, err := service.SetCurrency(ctx, "user1", currency)
+			assert.ErrorIs(t, err, ErrInvalidCurrencyCode, currency)
+		}
+	})
+
+	t.Run("wallet not empty", func(t *testing.T) {
+		mockRepo.EXPECT().SetCurrency(ctx, "user1", "USD").Return(postgres.ErrWalletNotEmpty)
+
+		_, err := service.SetCurrency(ctx, "user1", "USD")
+		assert.ErrorIs(t, err, postgres.ErrWalletNotEmpty)
+	})
+}
+
+// withOperation matches a context carrying exactly op
+func withOperation(op operation.Operation) gomock.Matcher {
+	return operationMatcher{op}
+}
+
+type operationMatcher struct {
+	want operation.Operation
+}
+
+func (m operationMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	op, _ := operation.From(ctx)
+	return op == m.want
+}
+
+func (m operationMatcher) String() string {
+	return fmt.Sprintf("context with operation %+v", m.want)
+}
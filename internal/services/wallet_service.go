@@ -2,82 +2,1121 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 
+	"Crypto.com/internal/metrics"
 	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/rates"
 	"Crypto.com/internal/repositories/postgres"
 	"Crypto.com/internal/repositories/redis"
 )
 
+var (
+	ErrInvalidCursor             = errors.New("invalid cursor")
+	ErrInvalidHistoryRange       = errors.New("to must be after from, and full_history cannot be combined with a range")
+	ErrFutureTimestamp           = errors.New("timestamp is in the future")
+	ErrBalanceHistoryUnsupported = errors.New("historical balances are not supported")
+	ErrQueuedDepositsUnsupported = errors.New("queued deposits are not supported")
+	ErrWalletBusy                = errors.New("wallet is busy with another operation, retry shortly")
+	ErrConversionTooSmall        = errors.New("amount is too small to convert into the currency of the receiving wallet")
+	ErrInvalidTransactionDetails = errors.New("memo holds at most 280 characters, reference_id at most 128, and metadata at most 50 keys of 1 to 40 letters, digits, _ or -, with values of at most 500 characters")
+)
+
+const (
+	// cacheLoadWait bounds how long GetBalance waits for another instance to
+	// cache a balance before querying the database itself
+	cacheLoadWait = 100 * time.Millisecond
+	cacheLoadPoll = 10 * time.Millisecond
+
+	// DefaultHistoryWindow bounds histories read without an explicit range,
+	// so routine reads stay on recent rows
+	DefaultHistoryWindow = 90 * 24 * time.Hour
+
+	// DefaultSyncLimit and MaxSyncLimit bound the transactions of one sync
+	DefaultSyncLimit = 100
+	MaxSyncLimit     = 500
+
+	// Bounds of the memo and reference a client attaches to a transaction
+	maxMemoLength      = 280
+	maxReferenceLength = 128
+)
+
 type WalletService struct {
-	repo   postgres.WalletRepository
-	cache  redis.CacheRepository
-	logger *logrus.Logger
+	repo        postgres.WalletRepository
+	cache       redis.CacheRepository
+	idempotency postgres.IdempotencyRepository
+	holds       postgres.HoldRepository
+	overdrafts  postgres.OverdraftRepository
+	history     postgres.BalanceHistory
+	deposits    postgres.DepositQueueRepository
+	settings    *SettingsService
+	limits      *LimitsService
+	compliance  *ComplianceService
+	switches    *KillSwitchService
+	fees        *FeeService
+	conversions postgres.ConversionRepository
+	rates       rates.FXRateProvider
+	locks       redis.WalletLock
+	notifier    redis.BalanceNotifier
+	metrics     *metrics.Metrics
+	logger      *logrus.Logger
+	// writeThrough caches the balances an operation committed instead of
+	// invalidating them
+	writeThrough bool
+
+	// background runs asynchronous cache refreshes detached from the request
+	// that triggered them, so shutdown can wait for them before closing the
+	// cache client
+	background *backgroundTasks
+	// loads collapses concurrent database reads of the same balance
+	loads singleflight.Group
+}
+
+// WalletServiceOption configures optional WalletService dependencies
+type WalletServiceOption func(*WalletService)
+
+// WithIdempotency enables Idempotency-Key support for money movements
+func WithIdempotency(repo postgres.IdempotencyRepository) WalletServiceOption {
+	return func(s *WalletService) {
+		s.idempotency = repo
+	}
+}
+
+// WithHolds reports funds held by pending transfers in balance details
+func WithHolds(repo postgres.HoldRepository) WalletServiceOption {
+	return func(s *WalletService) {
+		s.holds = repo
+	}
+}
+
+// WithOverdrafts reports credit lines in balance details
+func WithOverdrafts(repo postgres.OverdraftRepository) WalletServiceOption {
+	return func(s *WalletService) {
+		s.overdrafts = repo
+	}
+}
+
+// WithBalanceHistory enables historical balance queries
+func WithBalanceHistory(repo postgres.BalanceHistory) WalletServiceOption {
+	return func(s *WalletService) {
+		s.history = repo
+	}
+}
+
+// WithDepositQueue lets trusted sources queue deposits for the deposit
+// consumer instead of applying them synchronously
+func WithDepositQueue(repo postgres.DepositQueueRepository) WalletServiceOption {
+	return func(s *WalletService) {
+		s.deposits = repo
+	}
+}
+
+// WithSettings applies runtime settings: per-wallet transaction limits and
+// balance cache TTLs
+func WithSettings(settings *SettingsService) WalletServiceOption {
+	return func(s *WalletService) {
+		s.settings = settings
+	}
+}
+
+// WithLimits enforces per-user withdrawal and transfer limits
+func WithLimits(limits *LimitsService) WalletServiceOption {
+	return func(s *WalletService) {
+		s.limits = limits
+	}
+}
+
+// WithCompliance evaluates deposits and incoming transfers against the
+// compliance policy of the receiving wallet
+func WithCompliance(compliance *ComplianceService) WalletServiceOption {
+	return func(s *WalletService) {
+		s.compliance = compliance
+	}
+}
+
+// WithKillSwitches rejects deposits, withdrawals and transfers while an
+// operator has disabled them
+func WithKillSwitches(switches *KillSwitchService) WalletServiceOption {
+	return func(s *WalletService) {
+		s.switches = switches
+	}
+}
+
+// WithFees charges the fees of withdrawals and transfers to the paying
+// wallet
+func WithFees(fees *FeeService) WalletServiceOption {
+	return func(s *WalletService) {
+		s.fees = fees
+	}
+}
+
+// WithConversions converts transfers between wallets of different currencies
+// at the rates of provider. Without it such transfers fail with
+// ErrCurrencyMismatch.
+func WithConversions(repo postgres.ConversionRepository, provider rates.FXRateProvider) WalletServiceOption {
+	return func(s *WalletService) {
+		s.conversions = repo
+		s.rates = provider
+	}
+}
+
+// WithWalletLock serializes withdrawals and transfers per wallet across
+// instances before they reach the database
+func WithWalletLock(locks redis.WalletLock) WalletServiceOption {
+	return func(s *WalletService) {
+		s.locks = locks
+	}
 }
 
-func NewWalletService(repo postgres.WalletRepository, cache redis.CacheRepository, logger *logrus.Logger) *WalletService {
-	return &WalletService{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
+// WithBalanceNotifier wakes requests waiting for a balance change as soon
+// as it is announced. Without it waiters only see changes when their wait
+// times out.
+func WithBalanceNotifier(notifier redis.BalanceNotifier) WalletServiceOption {
+	return func(s *WalletService) {
+		s.notifier = notifier
 	}
 }
 
-func (s *WalletService) Deposit(ctx context.Context, userID string, amount float64) error {
-	s.logger.WithFields(logrus.Fields{
+// WithWriteThrough caches the new balances of a committed operation before
+// it returns, so the next read sees them even while another instance loads
+// an older balance. Balances are cached with their ledger sequence as version
+// and an older version never replaces a newer one.
+func WithWriteThrough() WalletServiceOption {
+	return func(s *WalletService) {
+		s.writeThrough = true
+	}
+}
+
+// WithMetrics records operation counts, cache lookups and database
+// transaction durations
+func WithMetrics(m *metrics.Metrics) WalletServiceOption {
+	return func(s *WalletService) {
+		s.metrics = m
+	}
+}
+
+func NewWalletService(repo postgres.WalletRepository, cache redis.CacheRepository, logger *logrus.Logger, opts ...WalletServiceOption) *WalletService {
+	s := &WalletService{
+		repo:       repo,
+		cache:      cache,
+		logger:     logger,
+		background: newBackgroundTasks(backgroundTaskTimeout),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *WalletService) Deposit(ctx context.Context, userID string, amount decimal.Decimal) error {
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"userID": userID,
 		"amount": amount,
 	}).Debug("Processing deposit")
 
-	err := s.repo.Deposit(ctx, userID, amount)
-	if err == nil {
-		_ = s.cache.InvalidateBalance(ctx, userID)
+	if err := s.checkKillSwitch(ctx, models.KillSwitchDeposits); err != nil {
+		return err
 	}
-	return err
+	if err := s.checkAmount(ctx, userID, amount); err != nil {
+		return err
+	}
+
+	return s.idempotent(ctx, userID, "deposit", []interface{}{amount}, func() error {
+		if err := s.checkCompliance(ctx, userID, models.ComplianceDeposit, amount, ""); err != nil {
+			return err
+		}
+		err := s.instrument("deposit", func() error {
+			return s.repo.Deposit(ctx, userID, amount)
+		})
+		if err == nil {
+			s.refreshBalances(ctx, "deposit", userID)
+		}
+		return err
+	})
 }
 
-func (s *WalletService) Withdraw(ctx context.Context, userID string, amount float64) error {
-	err := s.repo.Withdraw(ctx, userID, amount)
-	if err == nil {
-		_ = s.cache.InvalidateBalance(ctx, userID)
+// QueueDeposit records a deposit for the deposit consumer to apply and
+// returns it. A retry with the same Idempotency-Key returns the deposit queued
+// by the first request; nil is returned if that request was a synchronous
+// deposit, which has already been applied.
+func (s *WalletService) QueueDeposit(ctx context.Context, userID string, amount decimal.Decimal) (*models.QueuedDeposit, error) {
+	if s.deposits == nil {
+		return nil, ErrQueuedDepositsUnsupported
 	}
-	return err
+
+	if err := s.checkKillSwitch(ctx, models.KillSwitchDeposits); err != nil {
+		return nil, err
+	}
+	if err := s.checkAmount(ctx, userID, amount); err != nil {
+		return nil, err
+	}
+
+	op, _ := operation.From(ctx)
+	key := op.IdempotencyKey
+	var deposit *models.QueuedDeposit
+	// Queued and synchronous deposits share the "deposit" operation so a key
+	// cannot apply the same deposit once in each mode
+	err := s.idempotent(ctx, userID, "deposit", []interface{}{amount}, func() error {
+		if err := s.checkCompliance(ctx, userID, models.ComplianceDeposit, amount, ""); err != nil {
+			return err
+		}
+		deposit = &models.QueuedDeposit{UserID: userID, Amount: amount}
+		return s.deposits.Enqueue(ctx, deposit, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit == nil {
+		deposit, err = s.deposits.GetQueuedDepositByKey(ctx, userID, key)
+		if errors.Is(err, postgres.ErrQueuedDepositNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return deposit, nil
 }
 
-func (s *WalletService) Transfer(ctx context.Context, fromUserID, toUserID string, amount float64) error {
-	err := s.repo.Transfer(ctx, fromUserID, toUserID, amount)
-	if err == nil {
-		// Invalidate both accounts
-		_ = s.cache.InvalidateBalance(ctx, fromUserID)
-		_ = s.cache.InvalidateBalance(ctx, toUserID)
+// GetQueuedDeposit returns a queued deposit of userID and its progress
+func (s *WalletService) GetQueuedDeposit(ctx context.Context, userID, depositID string) (*models.QueuedDeposit, error) {
+	if s.deposits == nil {
+		return nil, ErrQueuedDepositsUnsupported
 	}
-	return err
+	return s.deposits.GetQueuedDeposit(ctx, userID, depositID)
+}
+
+// Withdraw deducts amount from the user's wallet. A non-nil expectedBalance
+// acts as a precondition: the withdrawal fails with ErrBalanceMismatch if the
+// balance changed since the client read it.
+func (s *WalletService) Withdraw(ctx context.Context, userID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+	if err := s.checkKillSwitch(ctx, models.KillSwitchWithdrawals); err != nil {
+		return err
+	}
+	if err := s.checkAmount(ctx, userID, amount); err != nil {
+		return err
+	}
+
+	return s.idempotent(ctx, userID, "withdraw", []interface{}{amount, expectedBalance}, func() error {
+		unlock, err := s.lockWallets(ctx, userID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := s.checkLimits(ctx, userID, models.LimitOperationWithdrawal, amount); err != nil {
+			return err
+		}
+		fee, err := s.quoteFee(ctx, userID, models.FeeOperationWithdrawal, amount)
+		if err != nil {
+			return err
+		}
+		err = s.instrument("withdraw", func() error {
+			if fee.IsPositive() {
+				return s.fees.repo.WithdrawWithFee(ctx, userID, amount, fee, expectedBalance)
+			}
+			return s.repo.Withdraw(ctx, userID, amount, expectedBalance)
+		})
+		if err == nil {
+			s.refreshBalances(ctx, "withdraw", charged(fee, userID)...)
+		}
+		return err
+	})
+}
+
+// Transfer moves amount between two wallets. expectedBalance applies to the
+// sender, see Withdraw.
+func (s *WalletService) Transfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
+	if err := s.checkKillSwitch(ctx, models.KillSwitchTransfers); err != nil {
+		return err
+	}
+	if err := s.checkAmount(ctx, fromUserID, amount); err != nil {
+		return err
+	}
+
+	return s.idempotent(ctx, fromUserID, "transfer", []interface{}{toUserID, amount, expectedBalance}, func() error {
+		unlock, err := s.lockWallets(ctx, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := s.checkLimits(ctx, fromUserID, models.LimitOperationTransfer, amount); err != nil {
+			return err
+		}
+		conversion, err := s.quoteConversion(ctx, fromUserID, toUserID, amount)
+		if err != nil {
+			return err
+		}
+		// The receiving wallet is evaluated on what it is credited
+		credited, currency := amount, ""
+		if conversion != nil {
+			credited, currency = conversion.ConvertedAmount, conversion.ToCurrency
+		}
+		if err := s.checkCompliance(ctx, toUserID, models.ComplianceTransferIn, credited, currency); err != nil {
+			return err
+		}
+		fee, err := s.quoteFee(ctx, fromUserID, models.FeeOperationTransfer, amount)
+		if err != nil {
+			return err
+		}
+		err = s.instrument("transfer", func() error {
+			if fee.IsPositive() {
+				return s.fees.repo.TransferWithFee(ctx, fromUserID, toUserID, amount, fee, conversion, expectedBalance)
+			}
+			if conversion != nil {
+				return s.conversions.ConvertTransfer(ctx, fromUserID, toUserID, amount, *conversion, expectedBalance)
+			}
+			return s.repo.Transfer(ctx, fromUserID, toUserID, amount, expectedBalance)
+		})
+		if err == nil {
+			// Refresh both accounts
+			s.refreshBalances(ctx, "transfer", charged(fee, fromUserID, toUserID)...)
+		}
+		return err
+	})
 }
 
-func (s *WalletService) GetBalance(ctx context.Context, userID string) (float64, error) {
-	// Check cache first
-	if balance, err := s.cache.GetBalance(ctx, userID); err == nil {
+func (s *WalletService) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	// Check cache first. On a miss the cache also tells us whether another
+	// instance is already loading the balance.
+	balance, err := s.cache.ReadThrough(ctx, userID)
+	s.metrics.RecordCacheLookup(err == nil)
+	if err == nil {
 		return balance, nil
 	}
+	if errors.Is(err, redis.ErrBalanceLoading) {
+		if balance, ok := s.awaitCachedBalance(ctx, userID); ok {
+			return balance, nil
+		}
+	}
 
 	// Fallback to database
-	balance, err := s.repo.GetBalance(ctx, userID)
+	return s.loadBalance(ctx, userID)
+}
+
+// loadBalance reads the balance from the database and caches it. Concurrent
+// loads of the same wallet share a single query.
+func (s *WalletService) loadBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
+	loaded, err, _ := s.loads.Do(userID, func() (interface{}, error) {
+		if s.writeThrough {
+			return s.loadVersionedBalance(ctx, userID)
+		}
+
+		balance, err := s.repo.GetBalance(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Update cache
+		s.background.Go(ctx, func(ctx context.Context) {
+			_ = s.cache.SetBalance(ctx, userID, balance, s.cacheTTL(ctx, userID))
+		})
+
+		return balance, nil
+	})
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
+	return loaded.(decimal.Decimal), nil
+}
 
-	// Update cache
-	go func() {
-		_ = s.cache.SetBalance(ctx, userID, balance)
-	}()
+// loadVersionedBalance reads the balance with its version and caches it
+// unless an operation committed since has cached a newer one
+func (s *WalletService) loadVersionedBalance(ctx context.Context, userID string) (interface{}, error) {
+	balance, version, err := s.repo.GetVersionedBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.background.Go(ctx, func(ctx context.Context) {
+		_, _ = s.cache.SetVersionedBalance(ctx, userID, balance, version, s.cacheTTL(ctx, userID))
+	})
 
 	return balance, nil
 }
 
-func (s *WalletService) GetTransactionHistory(ctx context.Context, userID string, limit, offset int) ([]models.Transaction, error) {
+// WarmBalance reads the balance of userID from the database and caches it
+// with a fresh TTL, the way a read missing the cache would. Without write
+// through, empty balances are left uncached like SetBalance leaves them.
+func (s *WalletService) WarmBalance(ctx context.Context, userID string) error {
+	if s.writeThrough {
+		balance, version, err := s.repo.GetVersionedBalance(ctx, userID)
+		if err != nil {
+			return err
+		}
+		_, err = s.cache.SetVersionedBalance(ctx, userID, balance, version, s.cacheTTL(ctx, userID))
+		return err
+	}
+
+	balance, err := s.repo.GetBalance(ctx, userID)
+	if err != nil || !balance.IsPositive() {
+		return err
+	}
+	return s.cache.SetBalance(ctx, userID, balance, s.cacheTTL(ctx, userID))
+}
+
+// awaitCachedBalance polls the cache while another instance loads the
+// balance. It gives up after cacheLoadWait so a slow or failed load on the
+// other instance costs at most that much latency.
+func (s *WalletService) awaitCachedBalance(ctx context.Context, userID string) (decimal.Decimal, bool) {
+	deadline := time.NewTimer(cacheLoadWait)
+	defer deadline.Stop()
+	poll := time.NewTicker(cacheLoadPoll)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return decimal.Zero, false
+		case <-deadline.C:
+			return decimal.Zero, false
+		case <-poll.C:
+			if balance, err := s.cache.GetBalance(ctx, userID); err == nil {
+				return balance, true
+			}
+		}
+	}
+}
+
+// instrument times the database transaction of a wallet operation and counts
+// its outcome
+func (s *WalletService) instrument(operation string, apply func() error) error {
+	start := time.Now()
+	err := apply()
+	s.metrics.ObserveDBTransaction(operation, time.Since(start))
+
+	outcome := metrics.OutcomeSuccess
+	switch {
+	case errors.Is(err, postgres.ErrInsufficientBalance):
+		outcome = metrics.OutcomeInsufficientBalance
+	case err != nil:
+		outcome = metrics.OutcomeError
+	}
+	s.metrics.RecordOperation(operation, outcome)
+	return err
+}
+
+// refreshBalances brings the cached balances of userIDs up to date after
+// operation committed, writing them through or invalidating them
+func (s *WalletService) refreshBalances(ctx context.Context, operation string, userIDs ...string) {
+	if s.writeThrough {
+		s.writeBalances(ctx, operation, userIDs...)
+		return
+	}
+	s.invalidateBalances(ctx, operation, userIDs...)
+}
+
+// writeBalances caches the balances of userIDs as of right after operation
+// committed. A balance that cannot be read or cached is invalidated instead,
+// so a failure costs a cache miss rather than a stale read. The lag is
+// recorded like an invalidation.
+func (s *WalletService) writeBalances(ctx context.Context, operation string, userIDs ...string) {
+	committed := time.Now()
+	var failed error
+	for _, userID := range userIDs {
+		balance, version, err := s.repo.GetVersionedBalance(ctx, userID)
+		if err == nil {
+			_, err = s.cache.SetVersionedBalance(ctx, userID, balance, version, s.cacheTTL(ctx, userID))
+		}
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Warn("Write balance to cache failed, invalidating")
+			if err := s.cache.InvalidateBalance(ctx, userID); err != nil {
+				failed = err
+			}
+		}
+	}
+	s.metrics.ObserveCacheInvalidation(operation, time.Since(committed), failed)
+}
+
+// invalidateBalances drops the cached balances of userIDs right after
+// operation committed. Until it completes, reads can still be served the
+// balance from before the operation; the time this takes is recorded as the
+// invalidation lag.
+func (s *WalletService) invalidateBalances(ctx context.Context, operation string, userIDs ...string) {
+	committed := time.Now()
+	var failed error
+	for _, userID := range userIDs {
+		if err := s.cache.InvalidateBalance(ctx, userID); err != nil {
+			failed = err
+		}
+	}
+	s.metrics.ObserveCacheInvalidation(operation, time.Since(committed), failed)
+}
+
+// lockWallets takes the wallet locks of userIDs, if a wallet lock is
+// configured, and returns the function releasing them. The lock only takes
+// contention off the database, which still locks the wallet rows, so an
+// operation proceeds unlocked when Redis fails.
+func (s *WalletService) lockWallets(ctx context.Context, userIDs ...string) (func(), error) {
+	if s.locks == nil {
+		return func() {}, nil
+	}
+
+	unlock, err := s.locks.Lock(ctx, userIDs...)
+	switch {
+	case err == nil:
+		return unlock, nil
+	case errors.Is(err, redis.ErrLockNotAcquired):
+		return nil, ErrWalletBusy
+	case ctx.Err() != nil:
+		return nil, err
+	default:
+		s.logger.WithContext(ctx).WithError(err).WithField("userIDs", userIDs).Warn("Wallet lock unavailable, proceeding without it")
+		return func() {}, nil
+	}
+}
+
+// checkKillSwitch rejects op while its kill switch is engaged. It runs
+// before the idempotent operation so a disabled operation is rejected without
+// touching the database, retries included.
+func (s *WalletService) checkKillSwitch(ctx context.Context, op string) error {
+	if s.switches == nil {
+		return nil
+	}
+	return s.switches.Check(ctx, op)
+}
+
+// checkAmount enforces the transaction limit of the wallet, if any
+func (s *WalletService) checkAmount(ctx context.Context, userID string, amount decimal.Decimal) error {
+	if s.settings == nil {
+		return nil
+	}
+	return s.settings.CheckAmount(ctx, userID, amount)
+}
+
+// checkLimits enforces the withdrawal and transfer limits of the wallet, if
+// any. It runs inside the idempotent operation so a replayed request is not
+// counted against its own limits.
+func (s *WalletService) checkLimits(ctx context.Context, userID, txnType string, amount decimal.Decimal) error {
+	if s.limits == nil {
+		return nil
+	}
+	return s.limits.Check(ctx, userID, txnType, amount)
+}
+
+// checkCompliance evaluates an operation crediting the wallet against its
+// compliance policy, if any. Like checkLimits it runs inside the idempotent
+// operation so a replayed request is not evaluated twice.
+func (s *WalletService) checkCompliance(ctx context.Context, userID, op string, amount decimal.Decimal, currency string) error {
+	if s.compliance == nil {
+		return nil
+	}
+	return s.compliance.Check(ctx, userID, op, amount, currency)
+}
+
+// quoteFee returns the fee of an operation, zero when fees are not enabled
+func (s *WalletService) quoteFee(ctx context.Context, userID, op string, amount decimal.Decimal) (decimal.Decimal, error) {
+	if s.fees == nil {
+		return decimal.Zero, nil
+	}
+	return s.fees.Quote(ctx, userID, op, amount)
+}
+
+// charged adds the fee account to the wallets an operation changed when it
+// charged a fee
+func charged(fee decimal.Decimal, userIDs ...string) []string {
+	if fee.IsPositive() {
+		return append(userIDs, models.SystemAccountFees)
+	}
+	return userIDs
+}
+
+// quoteConversion prices a transfer between wallets of different currencies
+// at the current rate. It returns nil when the wallets hold the same
+// currency, either has none, or conversions are not enabled.
+func (s *WalletService) quoteConversion(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal) (*models.Conversion, error) {
+	if s.conversions == nil {
+		return nil, nil
+	}
+	currencies, err := s.conversions.WalletCurrencies(ctx, fromUserID, toUserID)
+	if err != nil {
+		return nil, err
+	}
+	from, to := currencies[fromUserID], currencies[toUserID]
+	if from == "" || to == "" || from == to {
+		return nil, nil
+	}
+
+	table, err := s.rates.Rates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := table.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	// Rounding down never credits more than the sender paid for
+	converted := amount.Mul(rate).Truncate(8)
+	if !converted.IsPositive() {
+		return nil, ErrConversionTooSmall
+	}
+	return &models.Conversion{
+		FromCurrency:    from,
+		ToCurrency:      to,
+		Rate:            rate,
+		ConvertedAmount: converted,
+	}, nil
+}
+
+// cacheTTL returns the balance cache TTL of the wallet, zero for the cache
+// default
+func (s *WalletService) cacheTTL(ctx context.Context, userID string) time.Duration {
+	if s.settings == nil {
+		return 0
+	}
+	ttl, err := s.settings.BalanceCacheTTL(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("userID", userID).Warn("Resolve balance cache TTL failed")
+		return 0
+	}
+	return ttl
+}
+
+// Wait blocks until background cache writes have finished
+func (s *WalletService) Wait() {
+	s.background.Wait()
+}
+
+// Close stops starting background cache writes and waits for the running
+// ones, cancelling them when ctx ends first
+func (s *WalletService) Close(ctx context.Context) error {
+	return s.background.Close(ctx)
+}
+
+// GetCurrentBalance reads the balance of userID and its version from the
+// database, bypassing the cache, for callers that must not act on a stale
+// balance
+func (s *WalletService) GetCurrentBalance(ctx context.Context, userID string) (decimal.Decimal, int64, error) {
+	return s.repo.GetVersionedBalance(ctx, userID)
+}
+
+// GetBalanceDetails splits the wallet balance into the amount held by
+// pending transfers and withdrawals and the amount available for new
+// operations, which includes what is left of the wallet's credit line.
+// Without a hold repository nothing is ever held, and without an overdraft
+// repository wallets have no credit line.
+func (s *WalletService) GetBalanceDetails(ctx context.Context, userID string) (models.Balance, error) {
+	total, err := s.GetBalance(ctx, userID)
+	if err != nil {
+		return models.Balance{}, err
+	}
+
+	held := decimal.Zero
+	if s.holds != nil {
+		held, err = s.holds.GetHeldBalance(ctx, userID)
+		if err != nil {
+			return models.Balance{}, err
+		}
+	}
+
+	limit := decimal.Zero
+	if s.overdrafts != nil {
+		overdraft, err := s.overdrafts.GetOverdraft(ctx, userID)
+		if err != nil {
+			return models.Balance{}, err
+		}
+		limit = overdraft.Limit
+	}
+
+	return models.Balance{
+		Total:          total,
+		Held:           held,
+		Available:      total.Sub(held).Add(limit),
+		OverdraftLimit: limit,
+		OverdraftUsed:  models.OverdraftUsed(total),
+	}, nil
+}
+
+// WaitForBalanceChange returns the balance of userID as soon as its version
+// is newer than since, or its current balance once timeout passes. The
+// version is read from the database on every notification, so missed or
+// spurious notifications cost latency, never a wrong answer.
+func (s *WalletService) WaitForBalanceChange(ctx context.Context, userID string, since int64, timeout time.Duration) (models.BalanceChange, error) {
+	var changes <-chan struct{}
+	if s.notifier != nil {
+		var unwatch func()
+		changes, unwatch = s.notifier.Watch(userID)
+		defer unwatch()
+	}
+
+	expiry := time.NewTimer(timeout)
+	defer expiry.Stop()
+
+	expired := false
+	for {
+		balance, version, err := s.repo.GetVersionedBalance(ctx, userID)
+		if err != nil {
+			return models.BalanceChange{}, err
+		}
+		if version > since || expired {
+			return models.BalanceChange{Balance: balance, Version: version, Changed: version > since}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return models.BalanceChange{}, ctx.Err()
+		case <-expiry.C:
+			expired = true
+		case _, ok := <-changes:
+			// The notifier closes the watch when the server shuts down
+			if !ok {
+				expired = true
+			}
+		}
+	}
+}
+
+// StreamWallet calls send with the transactions of userID recorded or
+// changed after the sync sequence since and the current balance, and then
+// with every new transaction and balance until ctx is cancelled, send fails
+// or the notifier shuts down. A negative since starts after the latest
+// transaction. Changes are read from the database whenever the notifier
+// announces one and at least every poll, which sends a keep-alive when
+// nothing changed, so changes announced elsewhere without Redis or status
+// changes that leave the balance alone are delivered too.
+func (s *WalletService) StreamWallet(ctx context.Context, userID string, since int64, poll time.Duration, send func(models.WalletUpdate) error) error {
+	var changes <-chan struct{}
+	if s.notifier != nil {
+		var unwatch func()
+		changes, unwatch = s.notifier.Watch(userID)
+		defer unwatch()
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	version := int64(-1)
+	for {
+		balance, current, err := s.repo.GetVersionedBalance(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if since < 0 {
+			since = current
+		}
+
+		sent := false
+		for more := true; more; {
+			sync, err := s.SyncTransactions(ctx, userID, since, MaxSyncLimit)
+			if err != nil {
+				return err
+			}
+			for _, txn := range sync.Transactions {
+				if err := send(models.WalletUpdate{Type: models.WalletUpdateTransaction, Transaction: txn}); err != nil {
+					return err
+				}
+			}
+			since, more = sync.NextSince, sync.HasMore
+			sent = sent || len(sync.Transactions) > 0
+		}
+
+		if current != version {
+			// The first balance is the one the stream starts from
+			change := models.BalanceChange{Balance: balance, Version: current, Changed: version >= 0}
+			if err := send(models.WalletUpdate{Type: models.WalletUpdateBalance, Balance: change}); err != nil {
+				return err
+			}
+			version = current
+			sent = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !sent {
+				if err := send(models.WalletUpdate{Type: models.WalletUpdateKeepAlive}); err != nil {
+					return err
+				}
+			}
+		case _, ok := <-changes:
+			// The notifier closes the watch when the server shuts down
+			if !ok {
+				return nil
+			}
+		}
+	}
+}
+
+// GetBalanceAt returns the wallet balance as of at, reconstructed from
+// balance snapshots and the ledger, or from the wallet events with the
+// event-sourced storage engine. It bypasses the cache.
+func (s *WalletService) GetBalanceAt(ctx context.Context, userID string, at time.Time) (decimal.Decimal, error) {
+	if s.history == nil {
+		return decimal.Zero, ErrBalanceHistoryUnsupported
+	}
+	if at.After(time.Now()) {
+		return decimal.Zero, ErrFutureTimestamp
+	}
+	return s.history.BalanceAt(ctx, userID, at)
+}
+
+// NewHistoryWindow returns the window a history request reads: the range
+// [from, to) when either bound is given, every transaction with fullHistory
+// and the DefaultHistoryWindow otherwise. The default window starts where the
+// one of the first page did when cursor continues a listing, so pages neither
+// skip nor repeat the transactions crossing its start. It never starts before
+// the partial indexes of recent transactions.
+func NewHistoryWindow(from, to *time.Time, fullHistory bool, cursor string) (models.HistoryWindow, error) {
+	switch {
+	case fullHistory && (from != nil || to != nil):
+		return models.HistoryWindow{}, ErrInvalidHistoryRange
+	case fullHistory:
+		return models.HistoryWindow{}, nil
+	case from != nil && to != nil && !to.After(*from):
+		return models.HistoryWindow{}, ErrInvalidHistoryRange
+	case from != nil || to != nil:
+		return models.HistoryWindow{From: from, To: to}, nil
+	}
+
+	now := time.Now()
+	since := now.Add(-DefaultHistoryWindow)
+	if cursor != "" {
+		position, err := decodeTransactionCursor(cursor)
+		if err != nil {
+			return models.HistoryWindow{}, err
+		}
+		if position.Since != nil {
+			since = *position.Since
+		}
+	}
+	cutoff := RecentHistoryCutoff(now)
+	if since.Before(cutoff) {
+		since = cutoff
+	}
+	return models.HistoryWindow{From: &since, Cutoff: &cutoff}, nil
+}
+
+// FilterHistoryWindow narrows window to the transactions with filter's
+// reference, a memo containing its memo and every pair of its metadata.
+// Filters are bounded like the details they match.
+func FilterHistoryWindow(window models.HistoryWindow, filter operation.Details) (models.HistoryWindow, error) {
+	if err := validateTransactionDetails(filter); err != nil {
+		return models.HistoryWindow{}, err
+	}
+	if filter.Reference != "" {
+		window.ReferenceID = &filter.Reference
+	}
+	if filter.Memo != "" {
+		window.Memo = &filter.Memo
+	}
+	if len(filter.Metadata) > 0 {
+		window.Metadata = filter.Metadata
+	}
+	return window, nil
+}
+
+// WithTransactionDetails returns a copy of ctx recording details with the
+// transaction of its operation, once they are checked against their bounds.
+// Metadata follows the rules of wallet metadata.
+func WithTransactionDetails(ctx context.Context, details operation.Details) (context.Context, error) {
+	if err := validateTransactionDetails(details); err != nil {
+		return nil, err
+	}
+	if details.IsZero() {
+		return ctx, nil
+	}
+	return operation.WithDetails(ctx, details), nil
+}
+
+func validateTransactionDetails(details operation.Details) error {
+	if utf8.RuneCountInString(details.Memo) > maxMemoLength || utf8.RuneCountInString(details.Reference) > maxReferenceLength {
+		return ErrInvalidTransactionDetails
+	}
+	if validateMetadata(details.Metadata) != nil {
+		return ErrInvalidTransactionDetails
+	}
+	return nil
+}
+
+func (s *WalletService) GetTransactionHistory(ctx context.Context, userID string, window models.HistoryWindow, limit, offset int) ([]models.Transaction, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.GetTransactionHistory(ctx, userID, window, limit, offset)
+}
+
+// CountTransactions returns the number of transactions of userID within
+// window
+func (s *WalletService) CountTransactions(ctx context.Context, userID string, window models.HistoryWindow) (int, error) {
+	return s.repo.CountTransactions(ctx, userID, window)
+}
+
+// GetTransactionPage returns up to limit transactions within window older
+// than cursor, newest first, and the cursor of the following page. An empty
+// cursor starts at the most recent transaction; the returned cursor is empty
+// once the window is exhausted.
+func (s *WalletService) GetTransactionPage(ctx context.Context, userID, cursor string, window models.HistoryWindow, limit int) ([]models.Transaction, string, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	var position *models.TransactionCursor
+	if cursor != "" {
+		decoded, err := decodeTransactionCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		position = decoded
+	}
+
+	// Fetch one extra row to know whether another page follows
+	transactions, err := s.repo.GetTransactionsBefore(ctx, userID, position, window, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(transactions) <= limit {
+		return transactions, "", nil
+	}
+
+	transactions = transactions[:limit]
+	return transactions, EncodeTransactionCursor(transactions[limit-1], window), nil
+}
+
+// SyncTransactions returns up to limit transactions of the wallet of userID
+// recorded or changed since the sync sequence since, so an offline client
+// can catch up without reading its history again. A client starts at 0 and
+// passes the returned NextSince on the following sync.
+func (s *WalletService) SyncTransactions(ctx context.Context, userID string, since int64, limit int) (*models.TransactionSync, error) {
+	if limit <= 0 || limit > MaxSyncLimit {
+		limit = DefaultSyncLimit
+	}
+
+	// Fetch one extra row to know whether more changes follow
+	transactions, err := s.repo.GetTransactionChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &models.TransactionSync{Transactions: transactions, NextSince: since}
+	if len(transactions) > limit {
+		changes.Transactions = transactions[:limit]
+		changes.HasMore = true
+	}
+	if n := len(changes.Transactions); n > 0 {
+		changes.NextSince = *changes.Transactions[n-1].SyncSequence
+	}
+	if changes.Transactions == nil {
+		changes.Transactions = []models.Transaction{}
+	}
+	return changes, nil
+}
+
+// EncodeTransactionCursor returns the opaque cursor of the page following txn
+// within window
+func EncodeTransactionCursor(txn models.Transaction, window models.HistoryWindow) string {
+	if txn.ID == nil || txn.CreatedAt == nil {
+		return ""
+	}
+	raw := txn.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + *txn.ID
+	if window.Cutoff != nil && window.From != nil {
+		raw += "," + window.From.UTC().Format(time.RFC3339Nano)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(cursor string) (*models.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// Cursors issued before default windows were pinned have no start
+	parts := strings.Split(string(raw), ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, ErrInvalidCursor
+	}
+
+	var position models.TransactionCursor
+	if position.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if position.ID, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if len(parts) == 3 {
+		since, err := time.Parse(time.RFC3339Nano, parts[2])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		position.Since = &since
+	}
+	return &position, nil
+}
+
+func (s *WalletService) GetTimeline(ctx context.Context, userID string, limit, offset int) ([]models.TimelineEvent, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
-	return s.repo.GetTransactionHistory(ctx, userID, limit, offset)
+	return s.repo.GetTimeline(ctx, userID, limit, offset)
+}
+
+// GetTimelinePage returns up to limit events of the timeline of userID after
+// cursor, an empty cursor starting at the most recent event, and the cursor
+// of the next page, empty on the last page.
+func (s *WalletService) GetTimelinePage(ctx context.Context, userID, cursor string, limit int) ([]models.TimelineEvent, string, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	var position *models.TimelineCursor
+	if cursor != "" {
+		decoded, err := decodeTimelineCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		position = decoded
+	}
+
+	// Fetch one extra event to know whether another page follows
+	events, err := s.repo.GetTimelineBefore(ctx, userID, position, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(events) <= limit {
+		return events, "", nil
+	}
+
+	events = events[:limit]
+	return events, EncodeTimelineCursor(events[limit-1]), nil
+}
+
+// EncodeTimelineCursor returns the opaque cursor of the page following event
+func EncodeTimelineCursor(event models.TimelineEvent) string {
+	if event.OccurredAt == nil || event.ReferenceID == nil {
+		return ""
+	}
+	raw := event.OccurredAt.UTC().Format(time.RFC3339Nano) + "," + event.Type + "," + *event.ReferenceID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTimelineCursor(cursor string) (*models.TimelineCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ",")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	position := models.TimelineCursor{Type: parts[1]}
+	if position.OccurredAt, err = time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if position.ID, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &position, nil
 }
@@ -2,18 +2,26 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	goredis "github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 
 	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/rates"
 	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
 	"Crypto.com/mocks"
 )
 
@@ -28,28 +36,87 @@ func TestWalletService_Deposit(t *testing.T) {
 
 	t.Run("successful deposit", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Deposit(ctx, "user1", 100.0).Return(nil)
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(100)).Return(nil)
 		mockCache.EXPECT().InvalidateBalance(gomock.Any(), "user1").Return(nil)
 
-		err := service.Deposit(ctx, "user1", 100.0)
+		err := service.Deposit(ctx, "user1", decimal.NewFromInt(100))
 		assert.NoError(t, err)
 	})
 
 	t.Run("invalid amount", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Deposit(ctx, "user1", -50.0).Return(postgres.ErrInvalidAmount)
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(-50)).Return(postgres.ErrInvalidAmount)
 
-		err := service.Deposit(ctx, "user1", -50.0)
+		err := service.Deposit(ctx, "user1", decimal.NewFromInt(-50))
 		assert.ErrorIs(t, err, postgres.ErrInvalidAmount)
 	})
 
 	t.Run("repository error", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Deposit(ctx, "user1", 100.0).Return(errors.New("db error"))
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(100)).Return(errors.New("db error"))
 
-		err := service.Deposit(ctx, "user1", 100.0)
+		err := service.Deposit(ctx, "user1", decimal.NewFromInt(100))
 		assert.ErrorContains(t, err, "db error")
 	})
+
+	t.Run("frozen wallet", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(100)).Return(postgres.ErrWalletFrozen)
+
+		err := service.Deposit(ctx, "user1", decimal.NewFromInt(100))
+		assert.ErrorIs(t, err, postgres.ErrWalletFrozen)
+	})
+}
+
+func TestWalletService_WriteThrough(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithWriteThrough())
+	ctx := context.Background()
+
+	t.Run("deposit caches the new balance before returning", func(t *testing.T) {
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(100)).Return(nil)
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(250), int64(8), nil)
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", decimal.NewFromInt(250), int64(8), time.Duration(0)).Return(true, nil)
+
+		err := service.Deposit(ctx, "user1", decimal.NewFromInt(100))
+		assert.NoError(t, err)
+	})
+
+	t.Run("transfer caches both balances", func(t *testing.T) {
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(50), nil).Return(nil)
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(200), int64(9), nil)
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user2").Return(decimal.NewFromInt(50), int64(3), nil)
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", decimal.NewFromInt(200), int64(9), gomock.Any()).Return(true, nil)
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user2", decimal.NewFromInt(50), int64(3), gomock.Any()).Return(true, nil)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(50), nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("failed cache write invalidates", func(t *testing.T) {
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(10), nil).Return(nil)
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(190), int64(10), nil)
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", gomock.Any(), int64(10), gomock.Any()).Return(false, errors.New("connection refused"))
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(10), nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("cache miss loads a versioned balance", func(t *testing.T) {
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, goredis.Nil)
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(190), int64(10), nil)
+		mockCache.EXPECT().SetVersionedBalance(gomock.Any(), "user1", decimal.NewFromInt(190), int64(10), gomock.Any()).Return(true, nil)
+
+		balance, err := service.GetBalance(ctx, "user1")
+		assert.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(190).Equal(balance))
+		service.Wait()
+	})
 }
 
 func TestWalletService_Withdraw(t *testing.T) {
@@ -62,20 +129,37 @@ func TestWalletService_Withdraw(t *testing.T) {
 
 	t.Run("successful withdrawal", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Withdraw(ctx, "user1", 50.0).Return(nil)
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(50), nil).Return(nil)
 		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
 
-		err := service.Withdraw(ctx, "user1", 50.0)
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(50), nil)
 		assert.NoError(t, err)
 	})
 
 	t.Run("insufficient funds", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Withdraw(ctx, "user1", 100.0).Return(postgres.ErrInsufficientBalance)
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(100), nil).Return(postgres.ErrInsufficientBalance)
 
-		err := service.Withdraw(ctx, "user1", 100.0)
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(100), nil)
 		assert.ErrorIs(t, err, postgres.ErrInsufficientBalance)
 	})
+
+	t.Run("frozen wallet", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(50), nil).Return(postgres.ErrWalletFrozen)
+
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(50), nil)
+		assert.ErrorIs(t, err, postgres.ErrWalletFrozen)
+	})
+
+	t.Run("expected balance mismatch", func(t *testing.T) {
+		ctx := context.Background()
+		expected := decimal.NewFromInt(30)
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(10), &expected).Return(postgres.ErrBalanceMismatch)
+
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(10), &expected)
+		assert.ErrorIs(t, err, postgres.ErrBalanceMismatch)
+	})
 }
 
 func TestWalletService_Transfer(t *testing.T) {
@@ -88,29 +172,194 @@ func TestWalletService_Transfer(t *testing.T) {
 
 	t.Run("successful transfer", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", 75.0).Return(nil)
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil).Return(nil)
 		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
 		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
 
-		err := service.Transfer(ctx, "user1", "user2", 75.0)
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil)
 		assert.NoError(t, err)
 	})
 
 	t.Run("same user transfer", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Transfer(ctx, "user1", "user1", 10.0).Return(postgres.ErrInvalidUserID)
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user1", decimal.NewFromInt(10), nil).Return(postgres.ErrInvalidUserID)
 
-		err := service.Transfer(context.Background(), "user1", "user1", 10.0)
+		err := service.Transfer(context.Background(), "user1", "user1", decimal.NewFromInt(10), nil)
 		assert.ErrorIs(t, err, postgres.ErrInvalidUserID)
 	})
 
 	t.Run("invalid amount", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", -5.0).Return(postgres.ErrInvalidAmount)
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(-5), nil).Return(postgres.ErrInvalidAmount)
 
-		err := service.Transfer(context.Background(), "user1", "user2", -5.0)
+		err := service.Transfer(context.Background(), "user1", "user2", decimal.NewFromInt(-5), nil)
 		assert.ErrorIs(t, err, postgres.ErrInvalidAmount)
 	})
+
+	t.Run("frozen wallet", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil).Return(postgres.ErrWalletFrozen)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil)
+		assert.ErrorIs(t, err, postgres.ErrWalletFrozen)
+	})
+}
+
+func TestWalletService_Conversions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockConversions := mocks.NewMockConversionRepository(ctrl)
+	mockRates := mocks.NewMockFXRateProvider(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithConversions(mockConversions, mockRates))
+	ctx := context.Background()
+
+	table, err := rates.ParseTable("USD", "EUR=0.9")
+	require.NoError(t, err)
+
+	t.Run("converts between currencies", func(t *testing.T) {
+		mockConversions.EXPECT().WalletCurrencies(ctx, "user1", "user2").Return(map[string]string{"user1": "USD", "user2": "EUR"}, nil)
+		mockRates.EXPECT().Rates(ctx).Return(table, nil)
+		mockConversions.EXPECT().ConvertTransfer(ctx, "user1", "user2", decimal.NewFromInt(100), gomock.Any(), nil).
+			DoAndReturn(func(_ context.Context, _, _ string, _ decimal.Decimal, conversion models.Conversion, _ *decimal.Decimal) error {
+				assert.Equal(t, "USD", conversion.FromCurrency)
+				assert.Equal(t, "EUR", conversion.ToCurrency)
+				assert.True(t, conversion.Rate.Equal(decimal.RequireFromString("0.9")))
+				assert.True(t, conversion.ConvertedAmount.Equal(decimal.NewFromInt(90)))
+				return nil
+			})
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+
+		assert.NoError(t, service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(100), nil))
+	})
+
+	t.Run("same currency transfers directly", func(t *testing.T) {
+		mockConversions.EXPECT().WalletCurrencies(ctx, "user1", "user2").Return(map[string]string{"user1": "EUR", "user2": "EUR"}, nil)
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+
+		assert.NoError(t, service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil))
+	})
+
+	t.Run("unsupported currency", func(t *testing.T) {
+		mockConversions.EXPECT().WalletCurrencies(ctx, "user1", "user2").Return(map[string]string{"user1": "USD", "user2": "JPY"}, nil)
+		mockRates.EXPECT().Rates(ctx).Return(table, nil)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil)
+		assert.ErrorIs(t, err, rates.ErrUnsupportedCurrency)
+	})
+
+	t.Run("rates unavailable", func(t *testing.T) {
+		mockConversions.EXPECT().WalletCurrencies(ctx, "user1", "user2").Return(map[string]string{"user1": "USD", "user2": "EUR"}, nil)
+		mockRates.EXPECT().Rates(ctx).Return(rates.Table{}, rates.ErrUnavailable)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil)
+		assert.ErrorIs(t, err, rates.ErrUnavailable)
+	})
+
+	t.Run("amount too small to convert", func(t *testing.T) {
+		mockConversions.EXPECT().WalletCurrencies(ctx, "user1", "user2").Return(map[string]string{"user1": "EUR", "user2": "USD"}, nil)
+		mockRates.EXPECT().Rates(ctx).Return(rates.Table{Base: "USD", Rates: map[string]decimal.Decimal{"EUR": decimal.NewFromInt(1000000000)}}, nil)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.RequireFromString("0.00000001"), nil)
+		assert.ErrorIs(t, err, ErrConversionTooSmall)
+	})
+}
+
+func TestWalletService_Fees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockFees := mocks.NewMockFeeRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithFees(NewFeeService(mockFees, logrus.New())))
+	ctx := context.Background()
+
+	mockFees.EXPECT().ListFees(ctx).Return([]models.Fee{
+		{Operation: models.FeeOperationWithdrawal, FlatFee: decimal.NewFromInt(2)},
+		{Operation: models.FeeOperationTransfer, MinAmount: decimal.NewFromInt(100), PercentFee: decimal.NewFromInt(1)},
+	}, nil).AnyTimes()
+	mockFees.EXPECT().WalletCurrency(ctx, "user1").Return("", nil).AnyTimes()
+
+	t.Run("withdrawal is charged its fee", func(t *testing.T) {
+		mockFees.EXPECT().WithdrawWithFee(ctx, "user1", decimal.NewFromInt(50), gomock.Any(), nil).
+			DoAndReturn(func(_ context.Context, _ string, _, fee decimal.Decimal, _ *decimal.Decimal) error {
+				assert.True(t, fee.Equal(decimal.NewFromInt(2)))
+				return nil
+			})
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, models.SystemAccountFees).Return(nil)
+
+		assert.NoError(t, service.Withdraw(ctx, "user1", decimal.NewFromInt(50), nil))
+	})
+
+	t.Run("transfer is charged its fee", func(t *testing.T) {
+		mockFees.EXPECT().TransferWithFee(ctx, "user1", "user2", decimal.NewFromInt(200), gomock.Any(), nil, nil).
+			DoAndReturn(func(_ context.Context, _, _ string, _, fee decimal.Decimal, _ *models.Conversion, _ *decimal.Decimal) error {
+				assert.True(t, fee.Equal(decimal.NewFromInt(2)))
+				return nil
+			})
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, models.SystemAccountFees).Return(nil)
+
+		assert.NoError(t, service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(200), nil))
+	})
+
+	t.Run("transfer below every tier is free", func(t *testing.T) {
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user2").Return(nil)
+
+		assert.NoError(t, service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil))
+	})
+}
+
+func TestWalletService_WalletLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockLock := mocks.NewMockWalletLock(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithWalletLock(mockLock))
+	ctx := context.Background()
+
+	t.Run("transfer holds both wallet locks", func(t *testing.T) {
+		unlocked := false
+		mockLock.EXPECT().Lock(ctx, "user1", "user2").Return(func() { unlocked = true }, nil)
+		mockRepo.EXPECT().Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil).DoAndReturn(
+			func(context.Context, string, string, decimal.Decimal, *decimal.Decimal) error {
+				assert.False(t, unlocked)
+				return nil
+			})
+		mockCache.EXPECT().InvalidateBalance(ctx, gomock.Any()).Return(nil).Times(2)
+
+		err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(75), nil)
+		assert.NoError(t, err)
+		assert.True(t, unlocked)
+	})
+
+	t.Run("busy wallet", func(t *testing.T) {
+		mockLock.EXPECT().Lock(ctx, "user1").Return(nil, redis.ErrLockNotAcquired)
+
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(10), nil)
+		assert.ErrorIs(t, err, ErrWalletBusy)
+	})
+
+	t.Run("unavailable lock falls back to database locking", func(t *testing.T) {
+		mockLock.EXPECT().Lock(ctx, "user1").Return(nil, errors.New("connection refused"))
+		mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(10), nil).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		err := service.Withdraw(ctx, "user1", decimal.NewFromInt(10), nil)
+		assert.NoError(t, err)
+	})
 }
 
 func TestWalletService_GetBalance(t *testing.T) {
@@ -123,21 +372,444 @@ func TestWalletService_GetBalance(t *testing.T) {
 
 	t.Run("cache hit", func(t *testing.T) {
 		ctx := context.Background()
-		mockCache.EXPECT().GetBalance(ctx, "user1").Return(150.0, nil)
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.NewFromInt(150), nil)
 
 		balance, err := service.GetBalance(ctx, "user1")
 		assert.NoError(t, err)
-		assert.Equal(t, 150.0, balance)
+		assert.Equal(t, decimal.NewFromInt(150), balance)
 	})
 
 	t.Run("cache miss", func(t *testing.T) {
 		ctx := context.Background()
-		mockCache.EXPECT().GetBalance(ctx, "user1").Return(0.0, goredis.Nil)
-		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(200.0, nil)
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, goredis.Nil)
+		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(200), nil)
+		mockCache.EXPECT().SetBalance(gomock.Any(), "user1", decimal.NewFromInt(200), time.Duration(0)).Return(nil)
+
+		balance, err := service.GetBalance(ctx, "user1")
+		service.Wait()
+		assert.NoError(t, err)
+		assert.Equal(t, decimal.NewFromInt(200), balance)
+	})
+
+	t.Run("cache error falls back to database", func(t *testing.T) {
+		ctx := context.Background()
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, errors.New("connection refused"))
+		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(200), nil)
+		mockCache.EXPECT().SetBalance(gomock.Any(), "user1", decimal.NewFromInt(200), time.Duration(0)).Return(nil)
 
 		balance, err := service.GetBalance(ctx, "user1")
+		service.Wait()
 		assert.NoError(t, err)
-		assert.Equal(t, 200.0, balance)
+		assert.Equal(t, decimal.NewFromInt(200), balance)
+	})
+
+	t.Run("waits for another instance to load", func(t *testing.T) {
+		ctx := context.Background()
+		gomock.InOrder(
+			mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, redis.ErrBalanceLoading),
+			mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.Zero, goredis.Nil),
+			mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(300), nil),
+		)
+
+		balance, err := service.GetBalance(ctx, "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, decimal.NewFromInt(300), balance)
+	})
+
+	t.Run("loads itself when the other instance is too slow", func(t *testing.T) {
+		ctx := context.Background()
+		mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, redis.ErrBalanceLoading)
+		mockCache.EXPECT().GetBalance(ctx, "user1").Return(decimal.Zero, goredis.Nil).AnyTimes()
+		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(200), nil)
+		mockCache.EXPECT().SetBalance(gomock.Any(), "user1", decimal.NewFromInt(200), time.Duration(0)).Return(nil)
+
+		balance, err := service.GetBalance(ctx, "user1")
+		service.Wait()
+		assert.NoError(t, err)
+		assert.Equal(t, decimal.NewFromInt(200), balance)
+	})
+}
+
+func TestWalletService_GetBalanceSingleflight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New())
+
+	ctx := context.Background()
+	release := make(chan struct{})
+	mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.Zero, goredis.Nil).Times(5)
+	mockRepo.EXPECT().GetBalance(ctx, "user1").DoAndReturn(func(context.Context, string) (decimal.Decimal, error) {
+		<-release
+		return decimal.NewFromInt(200), nil
+	})
+	mockCache.EXPECT().SetBalance(gomock.Any(), "user1", decimal.NewFromInt(200), time.Duration(0)).Return(nil)
+
+	var wg sync.WaitGroup
+	balances := make([]decimal.Decimal, 5)
+	for i := range balances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			balances[i], _ = service.GetBalance(ctx, "user1")
+		}(i)
+	}
+	// Give every caller time to join the in-flight load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	service.Wait()
+
+	for _, balance := range balances {
+		assert.True(t, balance.Equal(decimal.NewFromInt(200)))
+	}
+}
+
+func TestWalletService_GetCurrentBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, mocks.NewMockCacheRepository(ctrl), logrus.New())
+	ctx := context.Background()
+
+	// The cache is not consulted
+	mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(95), int64(42), nil)
+
+	balance, version, err := service.GetCurrentBalance(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, decimal.NewFromInt(95), balance)
+	assert.Equal(t, int64(42), version)
+}
+
+func TestWalletService_WarmBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	ctx := context.Background()
+
+	t.Run("caches the balance read from the database", func(t *testing.T) {
+		service := NewWalletService(mockRepo, mockCache, logrus.New())
+		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(decimal.NewFromInt(200), nil)
+		mockCache.EXPECT().SetBalance(ctx, "user1", decimal.NewFromInt(200), time.Duration(0)).Return(nil)
+
+		assert.NoError(t, service.WarmBalance(ctx, "user1"))
+	})
+
+	t.Run("leaves an empty balance uncached", func(t *testing.T) {
+		service := NewWalletService(mockRepo, mockCache, logrus.New())
+		mockRepo.EXPECT().GetBalance(ctx, "user1").Return(decimal.Zero, nil)
+
+		assert.NoError(t, service.WarmBalance(ctx, "user1"))
+	})
+
+	t.Run("caches the versioned balance with write through", func(t *testing.T) {
+		service := NewWalletService(mockRepo, mockCache, logrus.New(), WithWriteThrough())
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.Zero, int64(7), nil)
+		mockCache.EXPECT().SetVersionedBalance(ctx, "user1", decimal.Zero, int64(7), time.Duration(0)).Return(false, nil)
+
+		assert.NoError(t, service.WarmBalance(ctx, "user1"))
+	})
+}
+
+func TestWalletService_GetTransactionPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New())
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	txn := func(id string) models.Transaction {
+		return models.Transaction{ID: proto.String(id), CreatedAt: &createdAt}
+	}
+
+	t.Run("next cursor round trips", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetTransactionsBefore(ctx, "user1", (*models.TransactionCursor)(nil), models.HistoryWindow{}, 3).
+			Return([]models.Transaction{txn("9"), txn("8"), txn("7")}, nil)
+
+		page, next, err := service.GetTransactionPage(ctx, "user1", "", models.HistoryWindow{}, 2)
+		assert.NoError(t, err)
+		assert.Len(t, page, 2)
+		assert.NotEmpty(t, next)
+
+		mockRepo.EXPECT().GetTransactionsBefore(ctx, "user1", &models.TransactionCursor{CreatedAt: createdAt, ID: 8}, models.HistoryWindow{}, 3).
+			Return([]models.Transaction{txn("7")}, nil)
+
+		page, next, err = service.GetTransactionPage(ctx, "user1", next, models.HistoryWindow{}, 2)
+		assert.NoError(t, err)
+		assert.Len(t, page, 1)
+		assert.Empty(t, next)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, _, err := service.GetTransactionPage(context.Background(), "user1", "not-a-cursor", models.HistoryWindow{}, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestWalletService_SyncTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New())
+
+	txn := func(id string, syncSequence int64) models.Transaction {
+		return models.Transaction{ID: proto.String(id), SyncSequence: proto.Int64(syncSequence)}
+	}
+	ctx := context.Background()
+
+	t.Run("more changes follow", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionChanges(ctx, "user1", int64(4), 3).
+			Return([]models.Transaction{txn("5", 5), txn("2", 6), txn("7", 7)}, nil)
+
+		changes, err := service.SyncTransactions(ctx, "user1", 4, 2)
+		assert.NoError(t, err)
+		assert.Len(t, changes.Transactions, 2)
+		assert.Equal(t, int64(6), changes.NextSince)
+		assert.True(t, changes.HasMore)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		mockRepo.EXPECT().GetTransactionChanges(ctx, "user1", int64(7), DefaultSyncLimit+1).Return(nil, nil)
+
+		changes, err := service.SyncTransactions(ctx, "user1", 7, MaxSyncLimit+1)
+		assert.NoError(t, err)
+		assert.Empty(t, changes.Transactions)
+		assert.NotNil(t, changes.Transactions)
+		assert.Equal(t, int64(7), changes.NextSince)
+		assert.False(t, changes.HasMore)
+	})
+}
+
+func TestWalletService_GetBalanceDetails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockHolds := mocks.NewMockHoldRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithHolds(mockHolds))
+
+	ctx := context.Background()
+	mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.NewFromInt(150), nil)
+	mockHolds.EXPECT().GetHeldBalance(ctx, "user1").Return(decimal.NewFromInt(40), nil)
+
+	balance, err := service.GetBalanceDetails(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, balance.Total.Equal(decimal.NewFromInt(150)))
+	assert.True(t, balance.Held.Equal(decimal.NewFromInt(40)))
+	assert.True(t, balance.Available.Equal(decimal.NewFromInt(110)))
+}
+
+func TestWalletService_GetBalanceDetailsOverdrawn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	mockOverdrafts := mocks.NewMockOverdraftRepository(ctrl)
+	service := NewWalletService(mockRepo, mockCache, logrus.New(), WithOverdrafts(mockOverdrafts))
+
+	ctx := context.Background()
+	mockCache.EXPECT().ReadThrough(ctx, "user1").Return(decimal.NewFromInt(-30), nil)
+	mockOverdrafts.EXPECT().GetOverdraft(ctx, "user1").Return(&models.Overdraft{UserID: "user1", Limit: decimal.NewFromInt(100)}, nil)
+
+	balance, err := service.GetBalanceDetails(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, balance.Available.Equal(decimal.NewFromInt(70)))
+	assert.True(t, balance.OverdraftLimit.Equal(decimal.NewFromInt(100)))
+	assert.True(t, balance.OverdraftUsed.Equal(decimal.NewFromInt(30)))
+}
+
+func TestWalletService_TransactionLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockSettings := mocks.NewMockSettingsRepository(ctrl)
+	settings := NewSettingsService(mockSettings, time.Minute, logrus.New())
+	service := NewWalletService(mockRepo, nil, logrus.New(), WithSettings(settings))
+
+	ctx := context.Background()
+	mockSettings.EXPECT().ListSettings(ctx).Return([]models.Setting{
+		{Key: SettingMaxTransactionAmount, Scope: models.SettingScopeWallet, ScopeID: "user1", Value: "100"},
+	}, nil)
+
+	// Rejected before reaching the repository
+	err := service.Withdraw(ctx, "user1", decimal.NewFromInt(150), nil)
+	assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+	err = service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(150), nil)
+	assert.ErrorIs(t, err, ErrAmountExceedsLimit)
+}
+
+func TestWalletService_KillSwitches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	switches := redis.NewLocalKillSwitchRepository()
+	service := NewWalletService(mockRepo, redis.NewNoopCacheRepository(), logrus.New(), WithKillSwitches(NewKillSwitchService(switches, logrus.New())))
+
+	ctx := context.Background()
+	_ = switches.SetKillSwitch(ctx, models.KillSwitch{Operation: models.KillSwitchTransfers, Message: "ledger incident"})
+
+	// Rejected before reaching the repository
+	err := service.Transfer(ctx, "user1", "user2", decimal.NewFromInt(10), nil)
+	var disabled *OperationDisabledError
+	assert.ErrorAs(t, err, &disabled)
+	assert.Equal(t, &OperationDisabledError{Operation: models.KillSwitchTransfers, Message: "ledger incident"}, disabled)
+	assert.ErrorIs(t, err, ErrOperationDisabled)
+
+	// Other operations are not affected
+	mockRepo.EXPECT().Withdraw(ctx, "user1", decimal.NewFromInt(10), nil).Return(nil)
+	err = service.Withdraw(ctx, "user1", decimal.NewFromInt(10), nil)
+	assert.NoError(t, err)
+}
+
+func TestWalletService_GetBalanceAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockSnapshots := mocks.NewMockSnapshotRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New(), WithBalanceHistory(mockSnapshots))
+
+	t.Run("past timestamp", func(t *testing.T) {
+		ctx := context.Background()
+		at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		mockSnapshots.EXPECT().BalanceAt(ctx, "user1", at).Return(decimal.NewFromInt(75), nil)
+
+		balance, err := service.GetBalanceAt(ctx, "user1", at)
+		assert.NoError(t, err)
+		assert.True(t, balance.Equal(decimal.NewFromInt(75)))
+	})
+
+	t.Run("future timestamp", func(t *testing.T) {
+		_, err := service.GetBalanceAt(context.Background(), "user1", time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, ErrFutureTimestamp)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewWalletService(mockRepo, nil, logrus.New()).GetBalanceAt(context.Background(), "user1", time.Now())
+		assert.ErrorIs(t, err, ErrBalanceHistoryUnsupported)
+	})
+}
+
+func TestWalletService_WaitForBalanceChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	notifier := redis.NewLocalBalanceNotifier()
+	cache := redis.NewNotifyingCacheRepository(mockCache, notifier)
+	service := NewWalletService(mockRepo, cache, logrus.New(), WithBalanceNotifier(notifier))
+	ctx := context.Background()
+
+	t.Run("newer version returns at once", func(t *testing.T) {
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(75), int64(4), nil)
+
+		change, err := service.WaitForBalanceChange(ctx, "user1", 3, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, models.BalanceChange{Balance: decimal.NewFromInt(75), Version: 4, Changed: true}, change)
+	})
+
+	t.Run("deposit wakes the waiter", func(t *testing.T) {
+		gomock.InOrder(
+			mockRepo.EXPECT().GetVersionedBalance(gomock.Any(), "user1").Return(decimal.NewFromInt(75), int64(4), nil),
+			mockRepo.EXPECT().GetVersionedBalance(gomock.Any(), "user1").Return(decimal.NewFromInt(175), int64(5), nil),
+		)
+		mockRepo.EXPECT().Deposit(ctx, "user1", decimal.NewFromInt(100)).Return(nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		result := make(chan models.BalanceChange)
+		go func() {
+			change, _ := service.WaitForBalanceChange(ctx, "user1", 4, time.Minute)
+			result <- change
+		}()
+
+		// The deposit may be announced before the waiter watches; announce
+		// it again until the waiter has read the new version
+		assert.NoError(t, service.Deposit(ctx, "user1", decimal.NewFromInt(100)))
+		for {
+			select {
+			case change := <-result:
+				assert.True(t, change.Changed)
+				assert.Equal(t, int64(5), change.Version)
+				return
+			case <-time.After(10 * time.Millisecond):
+				_ = notifier.Notify(ctx, "user1")
+			}
+		}
+	})
+
+	t.Run("timeout returns the current balance", func(t *testing.T) {
+		mockRepo.EXPECT().GetVersionedBalance(ctx, "user2").Return(decimal.NewFromInt(10), int64(2), nil).Times(2)
+
+		change, err := service.WaitForBalanceChange(ctx, "user2", 2, 10*time.Millisecond)
+		assert.NoError(t, err)
+		assert.False(t, change.Changed)
+		assert.Equal(t, int64(2), change.Version)
+	})
+}
+
+func TestWalletService_StreamWallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	notifier := redis.NewLocalBalanceNotifier()
+	service := NewWalletService(mockRepo, nil, logrus.New(), WithBalanceNotifier(notifier))
+
+	t.Run("starts after the latest transaction and pushes new ones", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		id, sequence := "8", int64(5)
+		gomock.InOrder(
+			mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(75), int64(4), nil),
+			mockRepo.EXPECT().GetTransactionChanges(ctx, "user1", int64(4), MaxSyncLimit+1).Return(nil, nil),
+			mockRepo.EXPECT().GetVersionedBalance(ctx, "user1").Return(decimal.NewFromInt(175), int64(5), nil),
+			mockRepo.EXPECT().GetTransactionChanges(ctx, "user1", int64(4), MaxSyncLimit+1).
+				Return([]models.Transaction{{ID: &id, SyncSequence: &sequence}}, nil),
+		)
+
+		var updates []models.WalletUpdate
+		err := service.StreamWallet(ctx, "user1", -1, time.Minute, func(update models.WalletUpdate) error {
+			updates = append(updates, update)
+			if len(updates) == 1 {
+				// The deposit is announced once the stream watches the wallet
+				return notifier.Notify(ctx, "user1")
+			}
+			if len(updates) == 3 {
+				cancel()
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		require.Len(t, updates, 3)
+		assert.Equal(t, models.WalletUpdate{Type: models.WalletUpdateBalance, Balance: models.BalanceChange{Balance: decimal.NewFromInt(75), Version: 4}}, updates[0])
+		assert.Equal(t, models.WalletUpdateTransaction, updates[1].Type)
+		assert.Equal(t, &id, updates[1].Transaction.ID)
+		assert.Equal(t, models.WalletUpdateBalance, updates[2].Type)
+		assert.True(t, updates[2].Balance.Changed)
+		assert.Equal(t, int64(5), updates[2].Balance.Version)
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		mockRepo.EXPECT().GetVersionedBalance(gomock.Any(), "ghost").Return(decimal.Zero, int64(0), postgres.ErrUserNotFound)
+
+		err := service.StreamWallet(context.Background(), "ghost", -1, time.Minute, func(models.WalletUpdate) error {
+			t.Fatal("nothing is sent")
+			return nil
+		})
+		assert.ErrorIs(t, err, postgres.ErrUserNotFound)
 	})
 }
 
@@ -151,19 +823,179 @@ func TestWalletService_GetTransactionHistory(t *testing.T) {
 	t.Run("default limit", func(t *testing.T) {
 		ctx := context.Background()
 		ct := time.Now()
-		expected := []models.Transaction{{CreatedAt: &ct, Amount: proto.Float64(100.0)}}
-		mockRepo.EXPECT().GetTransactionHistory(ctx, "user1", 50, 0).Return(expected, nil)
+		amount := decimal.NewFromInt(100)
+		expected := []models.Transaction{{CreatedAt: &ct, Amount: &amount}}
+		mockRepo.EXPECT().GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 50, 0).Return(expected, nil)
 
-		result, err := service.GetTransactionHistory(ctx, "user1", 0, 0)
+		result, err := service.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 0, 0)
 		assert.NoError(t, err)
 		assert.Len(t, result, 1)
 	})
 
 	t.Run("custom limit", func(t *testing.T) {
 		ctx := context.Background()
-		mockRepo.EXPECT().GetTransactionHistory(ctx, "user1", 75, 10).Return(nil, nil)
+		mockRepo.EXPECT().GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 75, 10).Return(nil, nil)
+
+		_, err := service.GetTransactionHistory(ctx, "user1", models.HistoryWindow{}, 75, 10)
+		assert.NoError(t, err)
+	})
+}
+
+func TestNewHistoryWindow(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	t.Run("recent by default", func(t *testing.T) {
+		window, err := NewHistoryWindow(nil, nil, false, "")
+		assert.NoError(t, err)
+		assert.Nil(t, window.To)
+		assert.Equal(t, RecentHistoryCutoff(time.Now()), *window.Cutoff)
+		assert.WithinDuration(t, time.Now().Add(-DefaultHistoryWindow), *window.From, time.Minute)
+	})
+
+	t.Run("default window pinned by the cursor", func(t *testing.T) {
+		first, err := NewHistoryWindow(nil, nil, false, "")
+		require.NoError(t, err)
+		id, createdAt := "7", time.Now()
+		cursor := EncodeTransactionCursor(models.Transaction{ID: &id, CreatedAt: &createdAt}, first)
+
+		next, err := NewHistoryWindow(nil, nil, false, cursor)
+		require.NoError(t, err)
+		assert.True(t, first.From.Equal(*next.From))
+
+		// Cursors issued before windows were pinned start a new window
+		legacy := base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + ",7"))
+		next, err = NewHistoryWindow(nil, nil, false, legacy)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-DefaultHistoryWindow), *next.From, time.Minute)
+	})
+
+	t.Run("pinned window never starts before the recent indexes", func(t *testing.T) {
+		old := models.HistoryWindow{From: &from, Cutoff: &from}
+		id, createdAt := "7", time.Now()
+		cursor := EncodeTransactionCursor(models.Transaction{ID: &id, CreatedAt: &createdAt}, old)
+
+		window, err := NewHistoryWindow(nil, nil, false, cursor)
+		require.NoError(t, err)
+		assert.Equal(t, RecentHistoryCutoff(time.Now()), *window.From)
+	})
+
+	t.Run("explicit range", func(t *testing.T) {
+		window, err := NewHistoryWindow(&from, &to, false, "")
+		assert.NoError(t, err)
+		assert.Equal(t, models.HistoryWindow{From: &from, To: &to}, window)
+
+		window, err = NewHistoryWindow(nil, &to, false, "")
+		assert.NoError(t, err)
+		assert.Nil(t, window.From)
+	})
+
+	t.Run("full history", func(t *testing.T) {
+		window, err := NewHistoryWindow(nil, nil, true, "")
+		assert.NoError(t, err)
+		assert.Equal(t, models.HistoryWindow{}, window)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewHistoryWindow(&to, &from, false, "")
+		assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+		_, err = NewHistoryWindow(&from, nil, true, "")
+		assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+		_, err = NewHistoryWindow(nil, nil, false, "not a cursor")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestFilterHistoryWindow(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	window, err := FilterHistoryWindow(models.HistoryWindow{From: &from}, operation.Details{
+		Reference: "order-42",
+		Metadata:  map[string]string{"invoice": "INV-7"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &from, window.From)
+	assert.Equal(t, "order-42", *window.ReferenceID)
+	assert.Nil(t, window.Memo)
+	assert.Equal(t, map[string]string{"invoice": "INV-7"}, window.Metadata)
+
+	_, err = FilterHistoryWindow(models.HistoryWindow{}, operation.Details{Metadata: map[string]string{"not a key": "x"}})
+	assert.ErrorIs(t, err, ErrInvalidTransactionDetails)
+}
+
+func TestWithTransactionDetails(t *testing.T) {
+	details := operation.Details{Memo: "Dinner", Reference: "order-42"}
+	ctx, err := WithTransactionDetails(context.Background(), details)
+	assert.NoError(t, err)
+	assert.Equal(t, details, operation.DetailsFrom(ctx))
+
+	_, err = WithTransactionDetails(context.Background(), operation.Details{Memo: strings.Repeat("a", maxMemoLength+1)})
+	assert.ErrorIs(t, err, ErrInvalidTransactionDetails)
+	_, err = WithTransactionDetails(context.Background(), operation.Details{Reference: strings.Repeat("a", maxReferenceLength+1)})
+	assert.ErrorIs(t, err, ErrInvalidTransactionDetails)
+}
+
+func TestWalletService_GetTimeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New())
+
+	t.Run("default limit", func(t *testing.T) {
+		ctx := context.Background()
+		expected := []models.TimelineEvent{{Type: models.TimelineEventTransaction, Subtype: proto.String("deposit")}}
+		mockRepo.EXPECT().GetTimeline(ctx, "user1", 50, 0).Return(expected, nil)
 
-		_, err := service.GetTransactionHistory(ctx, "user1", 75, 10)
+		result, err := service.GetTimeline(ctx, "user1", 0, 0)
 		assert.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctx := context.Background()
+		mockRepo.EXPECT().GetTimeline(ctx, "user1", 20, 40).Return(nil, errors.New("db error"))
+
+		_, err := service.GetTimeline(ctx, "user1", 20, 40)
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestWalletService_GetTimelinePage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWalletRepository(ctrl)
+	service := NewWalletService(mockRepo, nil, logrus.New())
+	ctx := context.Background()
+	occurredAt := time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC)
+
+	t.Run("next cursor resumes after the last event", func(t *testing.T) {
+		events := []models.TimelineEvent{
+			{Type: models.TimelineEventTransaction, ReferenceID: proto.String("9"), OccurredAt: &occurredAt},
+			{Type: models.TimelineEventHold, ReferenceID: proto.String("7"), OccurredAt: &occurredAt},
+			{Type: models.TimelineEventHold, ReferenceID: proto.String("6"), OccurredAt: &occurredAt},
+		}
+		mockRepo.EXPECT().GetTimelineBefore(ctx, "user1", nil, 3).Return(events, nil)
+
+		page, cursor, err := service.GetTimelinePage(ctx, "user1", "", 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		require.NotEmpty(t, cursor)
+
+		mockRepo.EXPECT().GetTimelineBefore(ctx, "user1", &models.TimelineCursor{OccurredAt: occurredAt, Type: models.TimelineEventHold, ID: 7}, 3).
+			Return(events[2:], nil)
+
+		page, cursor, err = service.GetTimelinePage(ctx, "user1", cursor, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		require.Empty(t, cursor)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", "bm90LWEtY3Vyc29y", EncodeTimelineCursor(models.TimelineEvent{Type: "hold", ReferenceID: proto.String("x"), OccurredAt: &occurredAt})} {
+			_, _, err := service.GetTimelinePage(ctx, "user1", cursor, 10)
+			require.ErrorIs(t, err, ErrInvalidCursor)
+		}
 	})
 }
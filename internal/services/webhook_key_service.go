@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+const (
+	// maxWebhookKeyOverlap bounds how long a rotated key keeps signing
+	maxWebhookKeyOverlap = 30 * 24 * time.Hour
+	// webhookKeysTTL bounds how long an instance signs with the keys it read
+	// last, so a rotation reaches every instance within it
+	webhookKeysTTL = 30 * time.Second
+	// webhookSecretPrefix marks signing secrets in configuration files and
+	// secret scanners
+	webhookSecretPrefix = "whsec_"
+)
+
+var ErrInvalidWebhookKeyOverlap = errors.New("overlap must be between 0 and 30 days")
+
+// WebhookKeyService rotates the keys signing the event webhook deliveries
+// and provides them to the webhook publisher. A rotation creates a new key
+// and lets the previous one sign alongside it for an overlap window, so
+// receivers can switch secrets without dropping deliveries.
+type WebhookKeyService struct {
+	repo    postgres.WebhookKeyRepository
+	overlap time.Duration
+	logger  *logrus.Logger
+
+	mu       sync.Mutex
+	keys     []events.SigningKey
+	loadedAt time.Time
+}
+
+// NewWebhookKeyService creates the service. overlap is the window of
+// rotations that do not set their own.
+func NewWebhookKeyService(repo postgres.WebhookKeyRepository, overlap time.Duration, logger *logrus.Logger) *WebhookKeyService {
+	return &WebhookKeyService{
+		repo:    repo,
+		overlap: overlap,
+		logger:  logger,
+	}
+}
+
+// Rotate creates a new current key and returns it with its secret, which
+// cannot be shown again. The previous current key signs along with it for
+// overlap, the default window when nil; 0 stops it at once.
+func (s *WebhookKeyService) Rotate(ctx context.Context, overlap *time.Duration) (*models.WebhookSigningKey, string, error) {
+	window := s.overlap
+	if overlap != nil {
+		window = *overlap
+	}
+	if window < 0 || window > maxWebhookKeyOverlap {
+		return nil, "", ErrInvalidWebhookKeyOverlap
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	op, _ := operation.From(ctx)
+	key := models.WebhookSigningKey{
+		Secret:    webhookSecretPrefix + hex.EncodeToString(secret),
+		CreatedBy: op.Actor,
+	}
+	if err := s.repo.RotateWebhookKey(ctx, &key, window); err != nil {
+		return nil, "", err
+	}
+	s.invalidate()
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"keyID":   key.ID,
+		"overlap": window,
+	}).Info("Webhook deliveries signed with the new key")
+	return &key, key.Secret, nil
+}
+
+// List returns every key without secrets, newest first
+func (s *WebhookKeyService) List(ctx context.Context) ([]models.WebhookSigningKey, error) {
+	return s.repo.ListWebhookKeys(ctx)
+}
+
+// Expire stops a rotated key from signing before its overlap ends, once
+// receivers have switched to its successor
+func (s *WebhookKeyService) Expire(ctx context.Context, id string) (*models.WebhookSigningKey, error) {
+	key, err := s.repo.ExpireWebhookKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return key, nil
+}
+
+// SigningKeys returns the keys that have not expired, newest first. They are
+// read again after webhookKeysTTL, or at once after a change made through
+// this instance.
+func (s *WebhookKeyService) SigningKeys(ctx context.Context) ([]events.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys != nil && time.Since(s.loadedAt) < webhookKeysTTL {
+		return s.keys, nil
+	}
+
+	valid, err := s.repo.ValidWebhookKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]events.SigningKey, 0, len(valid))
+	for _, key := range valid {
+		keys = append(keys, events.SigningKey{ID: key.ID, Secret: key.Secret})
+	}
+	s.keys, s.loadedAt = keys, time.Now()
+	return keys, nil
+}
+
+func (s *WebhookKeyService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/events"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestWebhookKeyService_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookKeyRepository(ctrl)
+	service := NewWebhookKeyService(mockRepo, 24*time.Hour, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+
+	t.Run("default overlap", func(t *testing.T) {
+		mockRepo.EXPECT().RotateWebhookKey(ctx, gomock.Any(), 24*time.Hour).
+			DoAndReturn(func(_ context.Context, key *models.WebhookSigningKey, _ time.Duration) error {
+				assert.Equal(t, "admin1", key.CreatedBy)
+				key.ID = "2"
+				return nil
+			})
+
+		key, secret, err := service.Rotate(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "2", key.ID)
+		assert.True(t, strings.HasPrefix(secret, "whsec_"))
+		assert.Len(t, secret, len("whsec_")+64)
+	})
+
+	t.Run("explicit overlap", func(t *testing.T) {
+		overlap := time.Duration(0)
+		mockRepo.EXPECT().RotateWebhookKey(ctx, gomock.Any(), overlap).Return(nil)
+
+		_, _, err := service.Rotate(ctx, &overlap)
+		require.NoError(t, err)
+	})
+
+	t.Run("overlap out of range", func(t *testing.T) {
+		overlap := 31 * 24 * time.Hour
+		_, _, err := service.Rotate(ctx, &overlap)
+		assert.ErrorIs(t, err, ErrInvalidWebhookKeyOverlap)
+	})
+}
+
+func TestWebhookKeyService_SigningKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookKeyRepository(ctrl)
+	service := NewWebhookKeyService(mockRepo, time.Hour, logrus.New())
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ValidWebhookKeys(ctx).Return([]models.WebhookSigningKey{
+		{ID: "2", Secret: "whsec_new"},
+		{ID: "1", Secret: "whsec_old"},
+	}, nil)
+
+	want := []events.SigningKey{{ID: "2", Secret: "whsec_new"}, {ID: "1", Secret: "whsec_old"}}
+	keys, err := service.SigningKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, keys)
+
+	// Served from memory until they expire or change
+	keys, err = service.SigningKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, keys)
+
+	mockRepo.EXPECT().ExpireWebhookKey(ctx, "1").Return(&models.WebhookSigningKey{ID: "1"}, nil)
+	mockRepo.EXPECT().ValidWebhookKeys(ctx).Return([]models.WebhookSigningKey{{ID: "2", Secret: "whsec_new"}}, nil)
+	_, err = service.Expire(ctx, "1")
+	require.NoError(t, err)
+
+	keys, err = service.SigningKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []events.SigningKey{{ID: "2", Secret: "whsec_new"}}, keys)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/metrics"
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/repositories/postgres"
+)
+
+// backlogLimitActor resumes deliveries whose backlog reached its limit
+const backlogLimitActor = "outbox-relay"
+
+// WebhookBacklogConfig bounds the backlog of the event webhook. A pause is
+// lifted once Limit events wait for delivery, so the outbox cannot grow
+// without bound; 0 keeps deliveries paused whatever the backlog. The
+// subscription is behind from AlertEvents events or events older than
+// AlertLag; 0 disables either alert.
+type WebhookBacklogConfig struct {
+	Limit       int64
+	AlertEvents int64
+	AlertLag    time.Duration
+}
+
+// WebhookSubscriptionService lets integrators pause the event webhook during
+// their maintenance and resume it once they are back. Events recorded
+// meanwhile stay in the outbox and are delivered in order on resumption.
+type WebhookSubscriptionService struct {
+	repo    postgres.WebhookSubscriptionRepository
+	config  WebhookBacklogConfig
+	metrics *metrics.Metrics
+	logger  *logrus.Logger
+
+	mu     sync.Mutex
+	behind bool
+}
+
+func NewWebhookSubscriptionService(repo postgres.WebhookSubscriptionRepository, config WebhookBacklogConfig, m *metrics.Metrics, logger *logrus.Logger) *WebhookSubscriptionService {
+	return &WebhookSubscriptionService{
+		repo:    repo,
+		config:  config,
+		metrics: m,
+		logger:  logger,
+	}
+}
+
+// Get returns the delivery state and the backlog
+func (s *WebhookSubscriptionService) Get(ctx context.Context) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.GetWebhookSubscription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Backlog.Limit = s.config.Limit
+	return subscription, nil
+}
+
+// Pause stops deliveries until they are resumed or the backlog reaches its
+// limit
+func (s *WebhookSubscriptionService) Pause(ctx context.Context, reason string) (*models.WebhookSubscription, error) {
+	op, _ := operation.From(ctx)
+	subscription, err := s.repo.PauseWebhookSubscription(ctx, op.Actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Backlog.Limit = s.config.Limit
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"actor":  op.Actor,
+		"reason": reason,
+	}).Warn("Webhook deliveries paused")
+	return subscription, nil
+}
+
+// Resume lets the relay deliver the backlog, oldest event first, on its next
+// poll
+func (s *WebhookSubscriptionService) Resume(ctx context.Context) (*models.WebhookSubscription, error) {
+	op, _ := operation.From(ctx)
+	subscription, err := s.repo.ResumeWebhookSubscription(ctx, op.Actor)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Backlog.Limit = s.config.Limit
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"actor":   op.Actor,
+		"backlog": subscription.Backlog.Events,
+	}).Info("Webhook deliveries resumed")
+	return subscription, nil
+}
+
+// Deliverable reports whether the relay may deliver events. It exports the
+// backlog, logs when the subscription falls behind or catches up, and lifts
+// a pause whose backlog reached the limit. Nothing is delivered while the
+// state cannot be read.
+func (s *WebhookSubscriptionService) Deliverable(ctx context.Context) bool {
+	subscription, err := s.repo.GetWebhookSubscription(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Deliverable - Read webhook subscription failed")
+		return false
+	}
+	backlog := subscription.Backlog
+
+	if subscription.Paused() && s.config.Limit > 0 && backlog.Events >= s.config.Limit {
+		ctx = operation.With(ctx, operation.Operation{Actor: backlogLimitActor, Channel: operation.ChannelJob})
+		if _, err := s.repo.ResumeWebhookSubscription(ctx, backlogLimitActor); err != nil && !errors.Is(err, postgres.ErrWebhookSubscriptionNotPaused) {
+			s.logger.WithContext(ctx).WithError(err).Error("Deliverable - Resume webhook subscription failed")
+			return false
+		}
+		subscription.PausedAt = nil
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"backlog": backlog.Events,
+			"limit":   s.config.Limit,
+		}).Error("Webhook backlog reached its limit, deliveries resumed")
+	}
+
+	s.metrics.ObserveWebhookBacklog(backlog.Events, backlog.LagSeconds, subscription.Paused())
+	s.alert(ctx, backlog, subscription.Paused())
+	return !subscription.Paused()
+}
+
+// alert logs the changes between a subscription that keeps up and one that
+// is behind, which alerting can match on
+func (s *WebhookSubscriptionService) alert(ctx context.Context, backlog models.WebhookBacklog, paused bool) {
+	behind := (s.config.AlertEvents > 0 && backlog.Events >= s.config.AlertEvents) ||
+		(s.config.AlertLag > 0 && time.Duration(backlog.LagSeconds)*time.Second >= s.config.AlertLag)
+
+	s.mu.Lock()
+	changed := behind != s.behind
+	s.behind = behind
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"backlog":    backlog.Events,
+		"lagSeconds": backlog.LagSeconds,
+		"paused":     paused,
+	})
+	if behind {
+		logger.Error("Webhook subscription is falling behind")
+	} else {
+		logger.Info("Webhook subscription caught up")
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/mocks"
+)
+
+func TestWebhookSubscriptionService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookSubscriptionRepository(ctrl)
+	config := WebhookBacklogConfig{Limit: 1000, AlertEvents: 100, AlertLag: time.Minute}
+	service := NewWebhookSubscriptionService(mockRepo, config, nil, logrus.New())
+	ctx := operation.With(context.Background(), operation.Operation{Actor: "admin1", Channel: operation.ChannelAdmin})
+	now := time.Now()
+
+	t.Run("Pause records the actor", func(t *testing.T) {
+		mockRepo.EXPECT().PauseWebhookSubscription(ctx, "admin1", "maintenance").
+			Return(&models.WebhookSubscription{PausedAt: &now}, nil)
+
+		subscription, err := service.Pause(ctx, "maintenance")
+		require.NoError(t, err)
+		assert.True(t, subscription.Paused())
+		assert.Equal(t, int64(1000), subscription.Backlog.Limit)
+	})
+
+	t.Run("Deliverable while not paused", func(t *testing.T) {
+		mockRepo.EXPECT().GetWebhookSubscription(gomock.Any()).Return(&models.WebhookSubscription{}, nil)
+
+		assert.True(t, service.Deliverable(ctx))
+	})
+
+	t.Run("not Deliverable while paused", func(t *testing.T) {
+		mockRepo.EXPECT().GetWebhookSubscription(gomock.Any()).
+			Return(&models.WebhookSubscription{PausedAt: &now, Backlog: models.WebhookBacklog{Events: 999}}, nil)
+
+		assert.False(t, service.Deliverable(ctx))
+		assert.True(t, service.behind)
+	})
+
+	t.Run("the backlog limit lifts the pause", func(t *testing.T) {
+		mockRepo.EXPECT().GetWebhookSubscription(gomock.Any()).
+			Return(&models.WebhookSubscription{PausedAt: &now, Backlog: models.WebhookBacklog{Events: 1000}}, nil)
+		mockRepo.EXPECT().ResumeWebhookSubscription(gomock.Any(), backlogLimitActor).Return(&models.WebhookSubscription{}, nil)
+
+		assert.True(t, service.Deliverable(ctx))
+	})
+
+	t.Run("catches up once the backlog is delivered", func(t *testing.T) {
+		mockRepo.EXPECT().GetWebhookSubscription(gomock.Any()).Return(&models.WebhookSubscription{}, nil)
+
+		assert.True(t, service.Deliverable(ctx))
+		assert.False(t, service.behind)
+	})
+
+	t.Run("nothing is delivered while the state is unknown", func(t *testing.T) {
+		mockRepo.EXPECT().GetWebhookSubscription(gomock.Any()).Return(nil, assert.AnError)
+
+		assert.False(t, service.Deliverable(ctx))
+	})
+}
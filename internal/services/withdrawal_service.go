@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/operation"
+	"Crypto.com/internal/payouts"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/internal/repositories/redis"
+)
+
+// WithdrawalService accepts withdrawals to external destinations. Requesting
+// one holds the amount on the wallet; the withdrawal worker sends the payout
+// and settles the hold.
+type WithdrawalService struct {
+	repo       postgres.WithdrawalRepository
+	settings   *SettingsService
+	limits     *LimitsService
+	compliance *ComplianceService
+	switches   *KillSwitchService
+	fees       *FeeService
+	logger     *logrus.Logger
+}
+
+// NewWithdrawalService creates the withdrawal service. With nil settings and
+// limits withdrawals are not subject to transaction limits, and with a nil
+// compliance service not to the policy of the user. With nil switches
+// withdrawals cannot be disabled, and with nil fees they are free.
+func NewWithdrawalService(repo postgres.WithdrawalRepository, settings *SettingsService, limits *LimitsService, compliance *ComplianceService, switches *KillSwitchService, fees *FeeService, logger *logrus.Logger) *WithdrawalService {
+	return &WithdrawalService{
+		repo:       repo,
+		settings:   settings,
+		limits:     limits,
+		compliance: compliance,
+		switches:   switches,
+		fees:       fees,
+		logger:     logger,
+	}
+}
+
+// RequestWithdrawal holds amount and its fee on the wallet of userID and
+// queues its payout to destination
+func (s *WithdrawalService) RequestWithdrawal(ctx context.Context, userID string, amount decimal.Decimal, destination string) (*models.Withdrawal, error) {
+	if s.switches != nil {
+		if err := s.switches.Check(ctx, models.KillSwitchWithdrawals); err != nil {
+			return nil, err
+		}
+	}
+	if s.settings != nil {
+		if err := s.settings.CheckAmount(ctx, userID, amount); err != nil {
+			return nil, err
+		}
+	}
+	if s.limits != nil {
+		if err := s.limits.Check(ctx, userID, models.LimitOperationWithdrawal, amount); err != nil {
+			return nil, err
+		}
+	}
+	if s.compliance != nil {
+		if err := s.compliance.Check(ctx, userID, models.ComplianceExternalWithdrawal, amount, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	withdrawal := &models.Withdrawal{
+		UserID:      userID,
+		Amount:      amount,
+		Destination: destination,
+	}
+	if s.fees != nil {
+		fee, err := s.fees.Quote(ctx, userID, models.FeeOperationWithdrawal, amount)
+		if err != nil {
+			return nil, err
+		}
+		withdrawal.Fee = fee
+	}
+	if err := s.repo.CreateWithdrawal(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// GetWithdrawal returns a withdrawal of userID and its progress
+func (s *WithdrawalService) GetWithdrawal(ctx context.Context, userID, withdrawalID string) (*models.Withdrawal, error) {
+	return s.repo.GetWithdrawal(ctx, userID, withdrawalID)
+}
+
+// WithdrawalWorker sends the payouts of requested withdrawals through the
+// providers of a payouts.Router. Each withdrawal is assigned the healthiest
+// provider before its first attempt and keeps it for the retries. A rejected
+// payout fails the withdrawal; after any other
+// provider error the withdrawal stays processing and is claimed again once
+// retryAfter has passed. While payouts are disabled withdrawals are left
+// requested and nothing is claimed.
+type WithdrawalWorker struct {
+	repo       postgres.WithdrawalRepository
+	router     *payouts.Router
+	cache      redis.CacheRepository
+	switches   *KillSwitchService
+	batchSize  int
+	retryAfter time.Duration
+	logger     *logrus.Logger
+}
+
+func NewWithdrawalWorker(repo postgres.WithdrawalRepository, router *payouts.Router, cache redis.CacheRepository, switches *KillSwitchService, batchSize int, retryAfter time.Duration, logger *logrus.Logger) *WithdrawalWorker {
+	return &WithdrawalWorker{
+		repo:       repo,
+		router:     router,
+		cache:      cache,
+		switches:   switches,
+		batchSize:  batchSize,
+		retryAfter: retryAfter,
+		logger:     logger,
+	}
+}
+
+// Run processes withdrawals immediately and then on each interval until ctx
+// is cancelled
+func (w *WithdrawalWorker) Run(ctx context.Context, interval time.Duration) {
+	ctx = operation.With(ctx, operation.Operation{Actor: "withdrawal-worker", Channel: operation.ChannelJob})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = w.ProcessBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims up to batchSize withdrawals and sends their payouts. It
+// returns the number of withdrawals completed or failed.
+func (w *WithdrawalWorker) ProcessBatch(ctx context.Context) (int, error) {
+	if w.switches != nil {
+		if err := w.switches.Check(ctx, models.KillSwitchPayouts); err != nil {
+			w.logger.WithContext(ctx).WithError(err).Debug("ProcessBatch - Payouts disabled, skipping")
+			return 0, nil
+		}
+	}
+
+	withdrawals, err := w.repo.ClaimWithdrawals(ctx, w.batchSize, time.Now().Add(-w.retryAfter))
+	if err != nil {
+		w.logger.WithContext(ctx).WithError(err).Error("ProcessBatch - Claim withdrawals failed")
+		return 0, err
+	}
+
+	settled := 0
+	for _, withdrawal := range withdrawals {
+		if ctx.Err() != nil {
+			break
+		}
+		if w.process(ctx, withdrawal) {
+			settled++
+		}
+	}
+
+	if len(withdrawals) > 0 {
+		w.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"claimed": len(withdrawals),
+			"settled": settled,
+		}).Debug("Withdrawals processed")
+	}
+	return settled, nil
+}
+
+// process sends the payout of withdrawal and records the outcome. It reports
+// whether the withdrawal was settled.
+func (w *WithdrawalWorker) process(ctx context.Context, withdrawal models.Withdrawal) bool {
+	logger := w.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"withdrawalID": withdrawal.ID,
+		"userID":       withdrawal.UserID,
+		"attempt":      withdrawal.Attempts,
+	})
+
+	payout := payouts.Payout{
+		WithdrawalID: withdrawal.ID,
+		UserID:       withdrawal.UserID,
+		Amount:       withdrawal.Amount,
+		Destination:  withdrawal.Destination,
+	}
+	if withdrawal.Currency != nil {
+		payout.Currency = *withdrawal.Currency
+	}
+
+	// The route is recorded before the payout is sent, so a retry after a
+	// crash cannot send it through another provider a second time
+	if withdrawal.Provider == nil {
+		route := w.router.Route(payout)
+		if len(route) == 0 {
+			logger.Warn("process - No payout provider accepts the withdrawal")
+			_, err := w.repo.FailWithdrawal(ctx, withdrawal.ID, "no payout provider accepts the currency and amount")
+			return w.settled(logger, err)
+		}
+		routed, err := w.repo.AssignRoute(ctx, withdrawal.ID, models.PayoutRoute{Candidates: route})
+		if errors.Is(err, postgres.ErrWithdrawalNotProcessing) {
+			return false
+		}
+		if err != nil {
+			logger.WithError(err).Error("process - Assign payout route failed")
+			return false
+		}
+		withdrawal = *routed
+	}
+
+	provider, reference, err := w.send(ctx, withdrawal, payout, logger)
+	logger = logger.WithField("provider", provider)
+	switch {
+	case errors.Is(err, postgres.ErrWithdrawalNotProcessing):
+		return false
+	case errors.Is(err, payouts.ErrRejected):
+		_, err = w.repo.FailWithdrawal(ctx, withdrawal.ID, err.Error())
+	case err != nil:
+		logger.WithError(err).Warn("process - Payout failed, will retry")
+		return false
+	default:
+		_, err = w.repo.CompleteWithdrawal(ctx, withdrawal.ID, reference)
+		if err == nil {
+			_ = w.cache.InvalidateBalance(ctx, withdrawal.UserID)
+			if withdrawal.Fee.IsPositive() {
+				_ = w.cache.InvalidateBalance(ctx, models.SystemAccountFees)
+			}
+		}
+	}
+	return w.settled(logger, err)
+}
+
+// send pays out through the provider of withdrawal and returns the provider
+// that handled the payout last. A provider that is unavailable fails over to
+// the next candidate of the route, each tried at most once; the last one
+// tried keeps the withdrawal for the retry.
+func (w *WithdrawalWorker) send(ctx context.Context, withdrawal models.Withdrawal, payout payouts.Payout, logger *logrus.Entry) (string, string, error) {
+	provider := *withdrawal.Provider
+	tried := map[string]bool{}
+	for {
+		tried[provider] = true
+		reference, err := w.router.Pay(ctx, provider, payout)
+		if !errors.Is(err, payouts.ErrUnavailable) || ctx.Err() != nil {
+			return provider, reference, err
+		}
+
+		next := nextCandidate(withdrawal.Route, provider, tried)
+		if next == "" {
+			return provider, "", err
+		}
+		failover := models.PayoutFailover{From: provider, To: next, Error: err.Error(), At: time.Now().UTC()}
+		if err := w.repo.Failover(ctx, withdrawal.ID, failover); err != nil {
+			if !errors.Is(err, postgres.ErrWithdrawalNotProcessing) {
+				logger.WithError(err).Error("send - Record payout failover failed")
+			}
+			return provider, "", err
+		}
+		logger.WithError(err).WithFields(logrus.Fields{
+			"provider": provider,
+			"next":     next,
+		}).Warn("send - Payout provider unavailable, failing over")
+		provider = next
+	}
+}
+
+// nextCandidate returns the candidate of route after provider that was not
+// tried yet, wrapping around, or an empty string when there is none
+func nextCandidate(route *models.PayoutRoute, provider string, tried map[string]bool) string {
+	if route == nil {
+		return ""
+	}
+	candidates := route.Candidates
+	start := 0
+	for i, candidate := range candidates {
+		if candidate.Provider == provider {
+			start = i + 1
+		}
+	}
+	for i := range candidates {
+		candidate := candidates[(start+i)%len(candidates)].Provider
+		if !tried[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// settled reports whether recording the outcome of a payout settled the
+// withdrawal
+func (w *WithdrawalWorker) settled(logger *logrus.Entry, err error) bool {
+	// A worker that reclaimed the withdrawal after retryAfter settled it first
+	if errors.Is(err, postgres.ErrWithdrawalNotProcessing) {
+		return false
+	}
+	if err != nil {
+		logger.WithError(err).Error("process - Record payout outcome failed")
+		return false
+	}
+	return true
+}
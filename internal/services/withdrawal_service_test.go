@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+	"Crypto.com/internal/payouts"
+	"Crypto.com/internal/repositories/postgres"
+	"Crypto.com/mocks"
+)
+
+func TestWithdrawalService_RequestWithdrawal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWithdrawalRepository(ctrl)
+	service := NewWithdrawalService(mockRepo, nil, nil, nil, nil, nil, logrus.New())
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.EXPECT().CreateWithdrawal(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, withdrawal *models.Withdrawal) error {
+			assert.Equal(t, "iban:GB33", withdrawal.Destination)
+			withdrawal.ID, withdrawal.Status = "4", models.WithdrawalRequested
+			return nil
+		})
+
+		withdrawal, err := service.RequestWithdrawal(ctx, "user1", decimal.NewFromInt(100), "iban:GB33")
+		assert.NoError(t, err)
+		assert.Equal(t, "4", withdrawal.ID)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		mockRepo.EXPECT().CreateWithdrawal(ctx, gomock.Any()).Return(postgres.ErrInsufficientBalance)
+
+		_, err := service.RequestWithdrawal(ctx, "user1", decimal.NewFromInt(100), "iban:GB33")
+		assert.ErrorIs(t, err, postgres.ErrInsufficientBalance)
+	})
+}
+
+func TestWithdrawalWorker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWithdrawalRepository(ctrl)
+	mockProvider := mocks.NewMockPayoutProvider(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	router := payouts.NewRouter([]payouts.NamedProvider{{Name: "bank", Provider: mockProvider}}, nil)
+	worker := NewWithdrawalWorker(mockRepo, router, mockCache, nil, 10, time.Minute, logrus.New())
+	ctx := context.Background()
+
+	bank := "bank"
+	withdrawal := func(id string) models.Withdrawal {
+		return models.Withdrawal{ID: id, UserID: "user1", Amount: decimal.NewFromInt(25), Destination: "iban:GB33", Status: models.WithdrawalProcessing, Attempts: 2, Provider: &bank}
+	}
+	payout := func(id string) payouts.Payout {
+		return payouts.Payout{WithdrawalID: id, UserID: "user1", Amount: decimal.NewFromInt(25), Destination: "iban:GB33"}
+	}
+
+	t.Run("settles each payout by its outcome", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{withdrawal("1"), withdrawal("2"), withdrawal("3")}, nil)
+
+		mockProvider.EXPECT().Pay(ctx, payout("1")).Return("po_1", nil)
+		mockRepo.EXPECT().CompleteWithdrawal(ctx, "1", "po_1").Return(&models.Withdrawal{}, nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		rejected := fmt.Errorf("%w: provider responded with status 422", payouts.ErrRejected)
+		mockProvider.EXPECT().Pay(ctx, payout("2")).Return("", rejected)
+		mockRepo.EXPECT().FailWithdrawal(ctx, "2", rejected.Error()).Return(&models.Withdrawal{}, nil)
+
+		// Transient errors leave the withdrawal processing for a retry
+		mockProvider.EXPECT().Pay(ctx, payout("3")).Return("", errors.New("connection reset"))
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, settled)
+	})
+
+	t.Run("assigns a provider before the first attempt", func(t *testing.T) {
+		first := withdrawal("1")
+		first.Attempts, first.Provider = 1, nil
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{first}, nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().AssignRoute(ctx, "1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, route models.PayoutRoute) (*models.Withdrawal, error) {
+				require.Len(t, route.Candidates, 1)
+				assert.Equal(t, "bank", route.Candidates[0].Provider)
+				routed := withdrawal("1")
+				routed.Route = &route
+				return &routed, nil
+			}),
+			mockProvider.EXPECT().Pay(ctx, payout("1")).Return("po_1", nil),
+		)
+		mockRepo.EXPECT().CompleteWithdrawal(ctx, "1", "po_1").Return(&models.Withdrawal{}, nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, settled)
+	})
+
+	t.Run("provider no longer configured", func(t *testing.T) {
+		removed := "bank_old"
+		stranded := withdrawal("1")
+		stranded.Provider = &removed
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{stranded}, nil)
+
+		// The withdrawal is retried, never sent through another provider
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+
+	t.Run("withdrawal settled by another worker", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{withdrawal("1")}, nil)
+		mockProvider.EXPECT().Pay(ctx, payout("1")).Return("po_1", nil)
+		mockRepo.EXPECT().CompleteWithdrawal(ctx, "1", "po_1").Return(nil, postgres.ErrWithdrawalNotProcessing)
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+
+	t.Run("claims only stale processing withdrawals", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, staleBefore time.Time) ([]models.Withdrawal, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Minute), staleBefore, time.Second)
+			return nil, nil
+		})
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		mockErr := errors.New("connection refused")
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return(nil, mockErr)
+
+		_, err := worker.ProcessBatch(ctx)
+		assert.ErrorIs(t, err, mockErr)
+	})
+}
+
+func TestWithdrawalWorker_Failover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWithdrawalRepository(ctrl)
+	mockBankA := mocks.NewMockPayoutProvider(ctrl)
+	mockBankB := mocks.NewMockPayoutProvider(ctrl)
+	mockCache := mocks.NewMockCacheRepository(ctrl)
+	maxAmount := decimal.NewFromInt(1000)
+	router := payouts.NewRouter([]payouts.NamedProvider{
+		{Name: "bank_a", Provider: mockBankA, Rules: payouts.Rules{Currencies: []string{"EUR"}}},
+		{Name: "bank_b", Provider: mockBankB, Rules: payouts.Rules{MaxAmount: &maxAmount, FixedFee: decimal.NewFromInt(1)}},
+	}, nil)
+	worker := NewWithdrawalWorker(mockRepo, router, mockCache, nil, 10, time.Minute, logrus.New())
+	ctx := context.Background()
+
+	eur, bankA := "EUR", "bank_a"
+	route := &models.PayoutRoute{Candidates: []models.PayoutCandidate{{Provider: "bank_a"}, {Provider: "bank_b"}}}
+	withdrawal := models.Withdrawal{ID: "1", UserID: "user1", Amount: decimal.NewFromInt(25), Currency: &eur, Status: models.WithdrawalProcessing, Attempts: 1, Provider: &bankA, Route: route}
+	payout := payouts.Payout{WithdrawalID: "1", UserID: "user1", Amount: decimal.NewFromInt(25), Currency: "EUR"}
+	unavailable := fmt.Errorf("%w: provider responded with status 503", payouts.ErrUnavailable)
+
+	t.Run("fails over to the next candidate", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{withdrawal}, nil)
+		gomock.InOrder(
+			mockBankA.EXPECT().Pay(ctx, payout).Return("", unavailable),
+			mockRepo.EXPECT().Failover(ctx, "1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, failover models.PayoutFailover) error {
+				assert.Equal(t, "bank_a", failover.From)
+				assert.Equal(t, "bank_b", failover.To)
+				assert.Equal(t, unavailable.Error(), failover.Error)
+				return nil
+			}),
+			mockBankB.EXPECT().Pay(ctx, payout).Return("po_1", nil),
+			mockRepo.EXPECT().CompleteWithdrawal(ctx, "1", "po_1").Return(&models.Withdrawal{}, nil),
+		)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, settled)
+	})
+
+	t.Run("every candidate unavailable", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{withdrawal}, nil)
+		mockBankA.EXPECT().Pay(ctx, payout).Return("", unavailable)
+		mockRepo.EXPECT().Failover(ctx, "1", gomock.Any()).Return(nil)
+		mockBankB.EXPECT().Pay(ctx, payout).Return("", unavailable)
+
+		// bank_b keeps the withdrawal for the retry
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+
+	t.Run("ambiguous failures do not fail over", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{withdrawal}, nil)
+		mockBankA.EXPECT().Pay(ctx, payout).Return("", errors.New("context deadline exceeded"))
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+
+	t.Run("routes by currency", func(t *testing.T) {
+		usd := "USD"
+		unrouted := models.Withdrawal{ID: "2", UserID: "user1", Amount: decimal.NewFromInt(25), Currency: &usd, Status: models.WithdrawalProcessing, Attempts: 1}
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{unrouted}, nil)
+		mockRepo.EXPECT().AssignRoute(ctx, "2", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, route models.PayoutRoute) (*models.Withdrawal, error) {
+			require.Len(t, route.Candidates, 1)
+			assert.Equal(t, "bank_b", route.Candidates[0].Provider)
+			assert.True(t, route.Candidates[0].EstimatedFee.Equal(decimal.NewFromInt(1)))
+			routed := unrouted
+			routed.Provider, routed.Route = &route.Candidates[0].Provider, &route
+			return &routed, nil
+		})
+		mockBankB.EXPECT().Pay(ctx, payouts.Payout{WithdrawalID: "2", UserID: "user1", Amount: decimal.NewFromInt(25), Currency: "USD"}).Return("po_2", nil)
+		mockRepo.EXPECT().CompleteWithdrawal(ctx, "2", "po_2").Return(&models.Withdrawal{}, nil)
+		mockCache.EXPECT().InvalidateBalance(ctx, "user1").Return(nil)
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, settled)
+	})
+
+	t.Run("no provider accepts the withdrawal", func(t *testing.T) {
+		mockRepo.EXPECT().ClaimWithdrawals(ctx, 10, gomock.Any()).Return([]models.Withdrawal{{ID: "3", UserID: "user1", Amount: decimal.NewFromInt(5000), Status: models.WithdrawalProcessing}}, nil)
+		mockRepo.EXPECT().FailWithdrawal(ctx, "3", gomock.Any()).Return(&models.Withdrawal{}, nil)
+
+		settled, err := worker.ProcessBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, settled)
+	})
+}
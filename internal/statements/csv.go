@@ -0,0 +1,73 @@
+package statements
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+)
+
+// csvRenderer lists the opening balance, the transactions and the closing
+// balance in one table, so the file can be imported as is
+type csvRenderer struct {
+	out io.Writer
+	csv *csv.Writer
+	to  time.Time
+}
+
+func newCSVRenderer(w io.Writer) *csvRenderer {
+	return &csvRenderer{out: w, csv: csv.NewWriter(w)}
+}
+
+func (r *csvRenderer) Begin(statement models.Statement) error {
+	r.to = statement.To
+	if err := r.csv.Write([]string{"date", "transaction_id", "type", "counterparty", "category", "amount", "balance"}); err != nil {
+		return err
+	}
+	return r.csv.Write([]string{formatTime(statement.From), "", "opening_balance", "", "", "", statement.OpeningBalance.String()})
+}
+
+func (r *csvRenderer) Line(line models.StatementLine) error {
+	return r.csv.Write([]string{
+		formatTime(line.Date),
+		line.TransactionID,
+		cell(line.Type),
+		cell(line.Counterparty),
+		cell(line.Category),
+		line.Amount.String(),
+		line.Balance.String(),
+	})
+}
+
+func (r *csvRenderer) End(closingBalance decimal.Decimal) error {
+	if err := r.csv.Write([]string{formatTime(r.to), "", "closing_balance", "", "", "", closingBalance.String()}); err != nil {
+		return err
+	}
+	return r.Flush()
+}
+
+func (r *csvRenderer) Flush() error {
+	r.csv.Flush()
+	if err := r.csv.Error(); err != nil {
+		return err
+	}
+	flush(r.out)
+	return nil
+}
+
+// cell keeps spreadsheets from evaluating text such as a user ID starting
+// with = as a formula
+func cell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,248 @@
+package statements
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+)
+
+// A4 portrait, in points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	lineHeight = 14
+	fontSize   = 9
+)
+
+// Objects written before the pages. The page tree is written last, once the
+// pages are known, under its reserved number.
+const (
+	catalogObject   = 1
+	pagesObject     = 2
+	regularFont     = 3
+	boldFont        = 4
+	firstPageObject = 5
+)
+
+// column is a column of the transaction table. Amounts are right aligned on
+// x, other columns left aligned and cut to width characters.
+type column struct {
+	title string
+	x     float64
+	width int
+	right bool
+}
+
+var columns = []column{
+	{title: "Date", x: margin, width: 16},
+	{title: "Transaction", x: 125, width: 12},
+	{title: "Type", x: 190, width: 12},
+	{title: "Counterparty", x: 255, width: 22},
+	{title: "Category", x: 370, width: 14},
+	{title: "Amount", x: 480, right: true},
+	{title: "Balance", x: pageWidth - margin, right: true},
+}
+
+// pdfRenderer writes a PDF with the standard Helvetica fonts, which readers
+// provide, so no font is embedded. Each page is written as soon as it is
+// full; the cross-reference table is built from the offsets of the objects
+// written so far.
+type pdfRenderer struct {
+	out     io.Writer
+	buf     *bufio.Writer
+	offset  int
+	offsets map[int]int
+	next    int
+	pages   []int
+
+	statement models.Statement
+	page      bytes.Buffer
+	y         float64
+	err       error
+}
+
+func newPDFRenderer(w io.Writer) *pdfRenderer {
+	return &pdfRenderer{
+		out:     w,
+		buf:     bufio.NewWriter(w),
+		offsets: make(map[int]int),
+		next:    firstPageObject,
+	}
+}
+
+func (r *pdfRenderer) Begin(statement models.Statement) error {
+	r.statement = statement
+	r.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	r.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	r.object(regularFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	r.object(boldFont, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	r.startPage()
+	r.text(boldFont, 14, margin, r.y, "Account statement")
+	r.y -= 2 * lineHeight
+	r.summary("Wallet", statement.UserID)
+	r.summary("Period", formatTime(statement.From)+" to "+formatTime(statement.To))
+	r.summary("Generated", formatTime(statement.GeneratedAt))
+	r.summary("Opening balance", statement.OpeningBalance.String())
+	r.y -= lineHeight
+	r.headings()
+	return r.err
+}
+
+func (r *pdfRenderer) Line(line models.StatementLine) error {
+	if r.y < margin+2*lineHeight {
+		r.endPage()
+		r.startPage()
+		r.headings()
+	}
+	r.row(regularFont, []string{
+		line.Date.UTC().Format("2006-01-02 15:04"),
+		line.TransactionID,
+		line.Type,
+		line.Counterparty,
+		line.Category,
+		line.Amount.String(),
+		line.Balance.String(),
+	})
+	return r.err
+}
+
+func (r *pdfRenderer) End(closingBalance decimal.Decimal) error {
+	r.y -= lineHeight / 2
+	r.summary("Closing balance", closingBalance.String())
+	r.endPage()
+
+	kids := make([]string, len(r.pages))
+	for i, page := range r.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	r.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(r.pages)))
+
+	xref := r.offset
+	r.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", r.next))
+	for number := 1; number < r.next; number++ {
+		r.write(fmt.Sprintf("%010d 00000 n \n", r.offsets[number]))
+	}
+	r.write(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", r.next, catalogObject, xref))
+	return r.Flush()
+}
+
+// Flush sends the pages completed so far
+func (r *pdfRenderer) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	flush(r.out)
+	return nil
+}
+
+func (r *pdfRenderer) startPage() {
+	r.page.Reset()
+	r.y = pageHeight - margin - lineHeight
+}
+
+// endPage writes the content of the current page and the page itself
+func (r *pdfRenderer) endPage() {
+	r.text(regularFont, fontSize, margin, margin/2, fmt.Sprintf("Page %d", len(r.pages)+1))
+
+	content := r.next
+	page := r.next + 1
+	r.next += 2
+	r.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", r.page.Len(), r.page.String()))
+	r.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F%d %d 0 R /F%d %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, regularFont, regularFont, boldFont, boldFont, content))
+	r.pages = append(r.pages, page)
+}
+
+func (r *pdfRenderer) summary(label, value string) {
+	r.text(boldFont, fontSize, margin, r.y, label)
+	r.text(regularFont, fontSize, margin+90, r.y, value)
+	r.y -= lineHeight
+}
+
+func (r *pdfRenderer) headings() {
+	titles := make([]string, len(columns))
+	for i, column := range columns {
+		titles[i] = column.title
+	}
+	r.row(boldFont, titles)
+}
+
+func (r *pdfRenderer) row(font int, cells []string) {
+	for i, column := range columns {
+		value := cells[i]
+		if column.right {
+			r.text(font, fontSize, column.x-textWidth(value, fontSize), r.y, value)
+			continue
+		}
+		if runes := []rune(value); len(runes) > column.width {
+			value = string(runes[:column.width-1]) + "~"
+		}
+		r.text(font, fontSize, column.x, r.y, value)
+	}
+	r.y -= lineHeight
+}
+
+// text draws value at x, y. Font numbers double as resource names.
+func (r *pdfRenderer) text(font, size int, x, y float64, value string) {
+	fmt.Fprintf(&r.page, "BT /F%d %d Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapeText(value))
+}
+
+func (r *pdfRenderer) object(number int, body string) {
+	r.offsets[number] = r.offset
+	r.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, body))
+}
+
+func (r *pdfRenderer) write(s string) {
+	if r.err != nil {
+		return
+	}
+	n, err := r.buf.WriteString(s)
+	r.offset += n
+	r.err = err
+}
+
+// escapeText makes value the body of a PDF string literal. Text is written
+// as printable ASCII; other characters are replaced.
+func escapeText(value string) string {
+	var b strings.Builder
+	for _, c := range value {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c < 0x20 || c > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// textWidth returns the width of an amount in Helvetica, in points. Digits
+// share one width; other characters are taken as wide as a digit.
+func textWidth(value string, size int) float64 {
+	width := 0.0
+	for _, c := range value {
+		switch c {
+		case '.':
+			width += 278
+		case '-':
+			width += 333
+		default:
+			width += 556
+		}
+	}
+	return width * float64(size) / 1000
+}
@@ -0,0 +1,54 @@
+// Package statements renders account statements. Renderers write each line
+// as it is produced, so a statement is streamed to the client while the
+// ledger is still being read.
+package statements
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"Crypto.com/internal/models"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format, use csv or pdf")
+
+// Renderer writes a statement: Begin once, Line for every transaction, oldest
+// first, and End with the closing balance. A statement without its closing
+// balance was interrupted.
+type Renderer interface {
+	Begin(statement models.Statement) error
+	Line(line models.StatementLine) error
+	End(closingBalance decimal.Decimal) error
+	// Flush sends the buffered output to the client
+	Flush() error
+}
+
+// ContentType returns the media type of format
+func ContentType(format string) string {
+	if format == models.StatementFormatPDF {
+		return "application/pdf"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// New returns the renderer of format writing to w
+func New(format string, w io.Writer) (Renderer, error) {
+	switch format {
+	case models.StatementFormatCSV:
+		return newCSVRenderer(w), nil
+	case models.StatementFormatPDF:
+		return newPDFRenderer(w), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// flush pushes what reached w to the client when w is a response
+func flush(w io.Writer) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
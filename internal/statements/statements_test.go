@@ -0,0 +1,100 @@
+package statements
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Crypto.com/internal/models"
+)
+
+var (
+	from      = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statement = models.Statement{
+		UserID:         "user1",
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: decimal.NewFromInt(100),
+		GeneratedAt:    from.AddDate(0, 1, 1),
+	}
+)
+
+func line(id int, counterparty string, amount, balance int64) models.StatementLine {
+	return models.StatementLine{
+		TransactionID: strconv.Itoa(id),
+		Date:          from.Add(time.Duration(id) * time.Hour),
+		Type:          "transfer",
+		Counterparty:  counterparty,
+		Amount:        decimal.NewFromInt(amount),
+		Balance:       decimal.NewFromInt(balance),
+	}
+}
+
+func render(t *testing.T, format string, lines ...models.StatementLine) string {
+	var out bytes.Buffer
+	renderer, err := New(format, &out)
+	require.NoError(t, err)
+	require.NoError(t, renderer.Begin(statement))
+	balance := statement.OpeningBalance
+	for _, l := range lines {
+		require.NoError(t, renderer.Line(l))
+		balance = l.Balance
+	}
+	require.NoError(t, renderer.End(balance))
+	return out.String()
+}
+
+func TestCSV(t *testing.T) {
+	out := render(t, models.StatementFormatCSV, line(1, "user2", -30, 70), line(2, "=HYPERLINK(\"x\")", 5, 75))
+
+	assert.Equal(t, `date,transaction_id,type,counterparty,category,amount,balance
+2024-05-01T00:00:00Z,,opening_balance,,,,100
+2024-05-01T01:00:00Z,1,transfer,user2,,-30,70
+2024-05-01T02:00:00Z,2,transfer,"'=HYPERLINK(""x"")",,5,75
+2024-06-01T00:00:00Z,,closing_balance,,,,75
+`, out)
+}
+
+func TestPDF(t *testing.T) {
+	lines := make([]models.StatementLine, 0, 120)
+	for i := 1; i <= 120; i++ {
+		lines = append(lines, line(i, "üser (2)", 1, 100+int64(i)))
+	}
+	out := render(t, models.StatementFormatPDF, lines...)
+
+	require.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Contains(t, out, `(?ser \(2\)) Tj`)
+	assert.Contains(t, out, "(Closing balance) Tj")
+
+	// Every entry of the cross-reference table points at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, _ := strconv.Atoi(startxref[1])
+	require.True(t, strings.HasPrefix(out[xref:], "xref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(out[offset:], fmt.Sprintf("%d 0 obj\n", i+1)), "object %d", i+1)
+	}
+
+	// 120 lines do not fit one page
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindStringSubmatch(out)
+	require.NotNil(t, pages)
+	count, _ := strconv.Atoi(pages[1])
+	assert.Greater(t, count, 1)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("xlsx", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	assert.Equal(t, "application/pdf", ContentType(models.StatementFormatPDF))
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	// Continue the trace of an incoming request
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Propagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	ctx, request := Start(ctx, "POST /api/v1/wallets/:userID/transfer")
+	_, db := Start(ctx, "postgres.Transfer")
+	End(db, errors.New("insufficient balance"))
+	_, cache := Start(ctx, "redis.InvalidateBalance")
+	End(cache, nil)
+	End(request, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	}
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "insufficient balance", spans[0].Status().Description)
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
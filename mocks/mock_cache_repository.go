@@ -7,8 +7,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	decimal "github.com/shopspring/decimal"
 )
 
 // MockCacheRepository is a mock of CacheRepository interface.
@@ -35,10 +37,10 @@ func (m *MockCacheRepository) EXPECT() *MockCacheRepositoryMockRecorder {
 }
 
 // GetBalance mocks base method.
-func (m *MockCacheRepository) GetBalance(ctx context.Context, userID string) (float64, error) {
+func (m *MockCacheRepository) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBalance", ctx, userID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -63,16 +65,46 @@ func (mr *MockCacheRepositoryMockRecorder) InvalidateBalance(ctx, userID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateBalance", reflect.TypeOf((*MockCacheRepository)(nil).InvalidateBalance), ctx, userID)
 }
 
+// ReadThrough mocks base method.
+func (m *MockCacheRepository) ReadThrough(ctx context.Context, userID string) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadThrough", ctx, userID)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadThrough indicates an expected call of ReadThrough.
+func (mr *MockCacheRepositoryMockRecorder) ReadThrough(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadThrough", reflect.TypeOf((*MockCacheRepository)(nil).ReadThrough), ctx, userID)
+}
+
 // SetBalance mocks base method.
-func (m *MockCacheRepository) SetBalance(ctx context.Context, userID string, balance float64) error {
+func (m *MockCacheRepository) SetBalance(ctx context.Context, userID string, balance decimal.Decimal, ttl time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetBalance", ctx, userID, balance)
+	ret := m.ctrl.Call(m, "SetBalance", ctx, userID, balance, ttl)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetBalance indicates an expected call of SetBalance.
-func (mr *MockCacheRepositoryMockRecorder) SetBalance(ctx, userID, balance interface{}) *gomock.Call {
+func (mr *MockCacheRepositoryMockRecorder) SetBalance(ctx, userID, balance, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBalance", reflect.TypeOf((*MockCacheRepository)(nil).SetBalance), ctx, userID, balance, ttl)
+}
+
+// SetVersionedBalance mocks base method.
+func (m *MockCacheRepository) SetVersionedBalance(ctx context.Context, userID string, balance decimal.Decimal, version int64, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersionedBalance", ctx, userID, balance, version, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVersionedBalance indicates an expected call of SetVersionedBalance.
+func (mr *MockCacheRepositoryMockRecorder) SetVersionedBalance(ctx, userID, balance, version, ttl interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBalance", reflect.TypeOf((*MockCacheRepository)(nil).SetBalance), ctx, userID, balance)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersionedBalance", reflect.TypeOf((*MockCacheRepository)(nil).SetVersionedBalance), ctx, userID, balance, version, ttl)
 }
@@ -7,9 +7,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "Crypto.com/internal/models"
 	gomock "github.com/golang/mock/gomock"
+	decimal "github.com/shopspring/decimal"
 )
 
 // MockWalletRepository is a mock of WalletRepository interface.
@@ -35,8 +37,23 @@ func (m *MockWalletRepository) EXPECT() *MockWalletRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountTransactions mocks base method.
+func (m *MockWalletRepository) CountTransactions(ctx context.Context, userID string, window models.HistoryWindow) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTransactions", ctx, userID, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTransactions indicates an expected call of CountTransactions.
+func (mr *MockWalletRepositoryMockRecorder) CountTransactions(ctx, userID, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTransactions", reflect.TypeOf((*MockWalletRepository)(nil).CountTransactions), ctx, userID, window)
+}
+
 // Deposit mocks base method.
-func (m *MockWalletRepository) Deposit(ctx context.Context, userID string, amount float64) error {
+func (m *MockWalletRepository) Deposit(ctx context.Context, userID string, amount decimal.Decimal) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Deposit", ctx, userID, amount)
 	ret0, _ := ret[0].(error)
@@ -50,10 +67,10 @@ func (mr *MockWalletRepositoryMockRecorder) Deposit(ctx, userID, amount interfac
 }
 
 // GetBalance mocks base method.
-func (m *MockWalletRepository) GetBalance(ctx context.Context, userID string) (float64, error) {
+func (m *MockWalletRepository) GetBalance(ctx context.Context, userID string) (decimal.Decimal, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBalance", ctx, userID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -64,45 +81,136 @@ func (mr *MockWalletRepositoryMockRecorder) GetBalance(ctx, userID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockWalletRepository)(nil).GetBalance), ctx, userID)
 }
 
+// GetTimeline mocks base method.
+func (m *MockWalletRepository) GetTimeline(ctx context.Context, userID string, limit, offset int) ([]models.TimelineEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimeline", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]models.TimelineEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimeline indicates an expected call of GetTimeline.
+func (mr *MockWalletRepositoryMockRecorder) GetTimeline(ctx, userID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimeline", reflect.TypeOf((*MockWalletRepository)(nil).GetTimeline), ctx, userID, limit, offset)
+}
+
+// GetTimelineBefore mocks base method.
+func (m *MockWalletRepository) GetTimelineBefore(ctx context.Context, userID string, cursor *models.TimelineCursor, limit int) ([]models.TimelineEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimelineBefore", ctx, userID, cursor, limit)
+	ret0, _ := ret[0].([]models.TimelineEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimelineBefore indicates an expected call of GetTimelineBefore.
+func (mr *MockWalletRepositoryMockRecorder) GetTimelineBefore(ctx, userID, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimelineBefore", reflect.TypeOf((*MockWalletRepository)(nil).GetTimelineBefore), ctx, userID, cursor, limit)
+}
+
+// GetTransactionChanges mocks base method.
+func (m *MockWalletRepository) GetTransactionChanges(ctx context.Context, userID string, since int64, limit int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionChanges", ctx, userID, since, limit)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionChanges indicates an expected call of GetTransactionChanges.
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionChanges(ctx, userID, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionChanges", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionChanges), ctx, userID, since, limit)
+}
+
 // GetTransactionHistory mocks base method.
-func (m *MockWalletRepository) GetTransactionHistory(ctx context.Context, userID string, limit, offset int) ([]models.Transaction, error) {
+func (m *MockWalletRepository) GetTransactionHistory(ctx context.Context, userID string, window models.HistoryWindow, limit, offset int) ([]models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransactionHistory", ctx, userID, limit, offset)
+	ret := m.ctrl.Call(m, "GetTransactionHistory", ctx, userID, window, limit, offset)
 	ret0, _ := ret[0].([]models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTransactionHistory indicates an expected call of GetTransactionHistory.
-func (mr *MockWalletRepositoryMockRecorder) GetTransactionHistory(ctx, userID, limit, offset interface{}) *gomock.Call {
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionHistory(ctx, userID, window, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionHistory", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionHistory), ctx, userID, window, limit, offset)
+}
+
+// GetTransactionsBefore mocks base method.
+func (m *MockWalletRepository) GetTransactionsBefore(ctx context.Context, userID string, cursor *models.TransactionCursor, window models.HistoryWindow, limit int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsBefore", ctx, userID, cursor, window, limit)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionsBefore indicates an expected call of GetTransactionsBefore.
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionsBefore(ctx, userID, cursor, window, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsBefore", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionsBefore), ctx, userID, cursor, window, limit)
+}
+
+// GetTransactionsBetween mocks base method.
+func (m *MockWalletRepository) GetTransactionsBetween(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsBetween", ctx, userID, from, to, limit)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionsBetween indicates an expected call of GetTransactionsBetween.
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionsBetween(ctx, userID, from, to, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsBetween", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionsBetween), ctx, userID, from, to, limit)
+}
+
+// GetVersionedBalance mocks base method.
+func (m *MockWalletRepository) GetVersionedBalance(ctx context.Context, userID string) (decimal.Decimal, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersionedBalance", ctx, userID)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetVersionedBalance indicates an expected call of GetVersionedBalance.
+func (mr *MockWalletRepositoryMockRecorder) GetVersionedBalance(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionHistory", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionHistory), ctx, userID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersionedBalance", reflect.TypeOf((*MockWalletRepository)(nil).GetVersionedBalance), ctx, userID)
 }
 
 // Transfer mocks base method.
-func (m *MockWalletRepository) Transfer(ctx context.Context, fromUserID, toUserID string, amount float64) error {
+func (m *MockWalletRepository) Transfer(ctx context.Context, fromUserID, toUserID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUserID, amount)
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUserID, amount, expectedBalance)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Transfer indicates an expected call of Transfer.
-func (mr *MockWalletRepositoryMockRecorder) Transfer(ctx, fromUserID, toUserID, amount interface{}) *gomock.Call {
+func (mr *MockWalletRepositoryMockRecorder) Transfer(ctx, fromUserID, toUserID, amount, expectedBalance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockWalletRepository)(nil).Transfer), ctx, fromUserID, toUserID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockWalletRepository)(nil).Transfer), ctx, fromUserID, toUserID, amount, expectedBalance)
 }
 
 // Withdraw mocks base method.
-func (m *MockWalletRepository) Withdraw(ctx context.Context, userID string, amount float64) error {
+func (m *MockWalletRepository) Withdraw(ctx context.Context, userID string, amount decimal.Decimal, expectedBalance *decimal.Decimal) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Withdraw", ctx, userID, amount)
+	ret := m.ctrl.Call(m, "Withdraw", ctx, userID, amount, expectedBalance)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Withdraw indicates an expected call of Withdraw.
-func (mr *MockWalletRepositoryMockRecorder) Withdraw(ctx, userID, amount interface{}) *gomock.Call {
+func (mr *MockWalletRepositoryMockRecorder) Withdraw(ctx, userID, amount, expectedBalance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockWalletRepository)(nil).Withdraw), ctx, userID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockWalletRepository)(nil).Withdraw), ctx, userID, amount, expectedBalance)
 }
@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"runtime"
+)
+
+// Populated at build time via -ldflags, e.g.
+//
+//	go build -ldflags "-X Crypto.com/pkg/buildinfo.Version=v1.2.0 \
+//	  -X Crypto.com/pkg/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X Crypto.com/pkg/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: Date,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -0,0 +1,223 @@
+// Package consumer helps services that consume wallet events handle each
+// event once although the outbox delivers them at least once. A Consumer
+// wraps an event handler with:
+//
+//   - deduplication on the event ID, backed by a Store
+//   - ordered processing: events with the same key, by default the wallet,
+//     are handled one at a time in arrival order
+//   - poison message handling: an event that keeps failing is handed to a
+//     dead letter function so it stops blocking the events behind it
+//
+// The package only depends on the JSON envelope of events, not on the wallet
+// service internals.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Event is the envelope of a wallet event as delivered to consumers. Data
+// and Operation are left undecoded for the handler.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Operation  json.RawMessage `json:"operation,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Handler processes one event. Returning an error asks for the event to be
+// delivered again.
+type Handler func(ctx context.Context, event Event) error
+
+// KeyFunc returns the key events are ordered by. Events with different keys
+// may be handled concurrently.
+type KeyFunc func(event Event) string
+
+// DeadLetterFunc receives an event that failed MaxAttempts times together
+// with its last error. Once it returns nil the event counts as handled.
+type DeadLetterFunc func(ctx context.Context, event Event, err error) error
+
+// ErrClosed is returned for events handed to a closed Consumer
+var ErrClosed = errors.New("consumer is closed")
+
+// WalletKey orders events by the wallet they were recorded for, the same
+// key the outbox delivers them in order for: the user ID, or the sender of a
+// transfer. Events without a wallet share the empty key.
+func WalletKey(event Event) string {
+	var data struct {
+		UserID         string `json:"user_id"`
+		FromUserID     string `json:"from_user_id"`
+		PreviousUserID string `json:"previous_user_id"`
+	}
+	_ = json.Unmarshal(event.Data, &data)
+
+	switch {
+	case data.UserID != "":
+		return data.UserID
+	case data.FromUserID != "":
+		return data.FromUserID
+	default:
+		return data.PreviousUserID
+	}
+}
+
+// Config configures a Consumer. The zero value of each field picks its
+// default.
+type Config struct {
+	// Store remembers handled events. Defaults to a MemoryStore, which only
+	// deduplicates within the process.
+	Store Store
+	// Key orders events. Defaults to WalletKey.
+	Key KeyFunc
+	// Workers is the number of events handled concurrently. Defaults to 1.
+	Workers int
+	// MaxAttempts is the number of failed attempts after which an event is
+	// dead-lettered. Zero retries forever.
+	MaxAttempts int
+	// DeadLetter receives poison events. Required with MaxAttempts.
+	DeadLetter DeadLetterFunc
+}
+
+// Consumer handles events with deduplication, per-key ordering and poison
+// message handling. It is safe for concurrent use.
+type Consumer struct {
+	handler Handler
+	config  Config
+
+	shards []chan job
+	wg     sync.WaitGroup
+	// lifecycle is held for reading while an event is handed to a worker
+	lifecycle sync.RWMutex
+	closed    bool
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+type job struct {
+	ctx   context.Context
+	event Event
+	done  chan error
+}
+
+// New starts a Consumer calling handler. Close stops it.
+func New(handler Handler, config Config) *Consumer {
+	if config.Store == nil {
+		config.Store = NewMemoryStore(0)
+	}
+	if config.Key == nil {
+		config.Key = WalletKey
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	c := &Consumer{
+		handler:  handler,
+		config:   config,
+		shards:   make([]chan job, config.Workers),
+		attempts: map[string]int{},
+	}
+	for i := range c.shards {
+		c.shards[i] = make(chan job)
+		c.wg.Add(1)
+		go c.work(c.shards[i])
+	}
+	return c
+}
+
+// Handle processes event and returns once it has been handled, skipped as a
+// duplicate or dead-lettered. An error means the event should be delivered
+// again.
+func (c *Consumer) Handle(ctx context.Context, event Event) error {
+	// Events of one key always go to the same worker, which handles them in
+	// the order they arrive
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(c.config.Key(event)))
+	shard := c.shards[hash.Sum32()%uint32(len(c.shards))]
+	done := make(chan error, 1)
+
+	c.lifecycle.RLock()
+	if c.closed {
+		c.lifecycle.RUnlock()
+		return ErrClosed
+	}
+	select {
+	case shard <- job{ctx: ctx, event: event, done: done}:
+		c.lifecycle.RUnlock()
+	case <-ctx.Done():
+		c.lifecycle.RUnlock()
+		return ctx.Err()
+	}
+	return <-done
+}
+
+// Close stops the workers once the events being handled are done
+func (c *Consumer) Close() {
+	c.lifecycle.Lock()
+	if c.closed {
+		c.lifecycle.Unlock()
+		return
+	}
+	c.closed = true
+	for _, shard := range c.shards {
+		close(shard)
+	}
+	c.lifecycle.Unlock()
+	c.wg.Wait()
+}
+
+func (c *Consumer) work(jobs <-chan job) {
+	defer c.wg.Done()
+	for j := range jobs {
+		j.done <- c.process(j.ctx, j.event)
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, event Event) error {
+	processed, err := c.config.Store.Processed(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return nil
+	}
+
+	if err := c.handler(ctx, event); err != nil {
+		if !c.poisoned(event.ID) {
+			return err
+		}
+		if dlqErr := c.config.DeadLetter(ctx, event, err); dlqErr != nil {
+			return errors.Join(err, dlqErr)
+		}
+	}
+
+	c.forget(event.ID)
+	return c.config.Store.MarkProcessed(ctx, event.ID)
+}
+
+// poisoned counts a failed attempt and reports whether the event has used up
+// its attempts. Attempts are counted in memory, across redeliveries to this
+// process.
+func (c *Consumer) poisoned(eventID string) bool {
+	if c.config.MaxAttempts <= 0 || c.config.DeadLetter == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts[eventID]++
+	return c.attempts[eventID] >= c.config.MaxAttempts
+}
+
+func (c *Consumer) forget(eventID string) {
+	c.mu.Lock()
+	delete(c.attempts, eventID)
+	c.mu.Unlock()
+}
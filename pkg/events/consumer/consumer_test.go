@@ -0,0 +1,149 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func walletEvent(id, userID string) Event {
+	return Event{ID: id, Type: "wallet.credited", Data: []byte(`{"user_id":"` + userID + `"}`)}
+}
+
+func TestConsumer_Deduplicates(t *testing.T) {
+	var calls int
+	c := New(func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	}, Config{})
+	defer c.Close()
+
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_2", "user1")))
+	assert.Equal(t, 2, calls)
+}
+
+func TestConsumer_FailedEventsAreRetried(t *testing.T) {
+	errUnavailable := errors.New("downstream unavailable")
+	var calls int
+	c := New(func(ctx context.Context, event Event) error {
+		calls++
+		if calls == 1 {
+			return errUnavailable
+		}
+		return nil
+	}, Config{})
+	defer c.Close()
+
+	assert.ErrorIs(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")), errUnavailable)
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	assert.Equal(t, 2, calls)
+}
+
+func TestConsumer_OrdersPerKey(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]string{}
+	c := New(func(ctx context.Context, event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		key := WalletKey(event)
+		seen[key] = append(seen[key], event.ID)
+		return nil
+	}, Config{Workers: 4})
+	defer c.Close()
+
+	// Deliveries of one wallet are sequential, as from the outbox; wallets
+	// are delivered concurrently
+	var wg sync.WaitGroup
+	for _, user := range []string{"user1", "user2", "user3"} {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			for _, id := range []string{"a", "b", "c"} {
+				assert.NoError(t, c.Handle(context.Background(), walletEvent(user+id, user)))
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	for _, user := range []string{"user1", "user2", "user3"} {
+		assert.Equal(t, []string{user + "a", user + "b", user + "c"}, seen[user])
+	}
+}
+
+func TestConsumer_DeadLettersPoisonEvents(t *testing.T) {
+	errMalformed := errors.New("malformed payload")
+	var deadLettered []string
+	var calls int
+	c := New(func(ctx context.Context, event Event) error {
+		calls++
+		return errMalformed
+	}, Config{
+		MaxAttempts: 2,
+		DeadLetter: func(ctx context.Context, event Event, err error) error {
+			assert.ErrorIs(t, err, errMalformed)
+			deadLettered = append(deadLettered, event.ID)
+			return nil
+		},
+	})
+	defer c.Close()
+
+	assert.ErrorIs(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")), errMalformed)
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	require.NoError(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"evt_1"}, deadLettered)
+}
+
+func TestConsumer_Closed(t *testing.T) {
+	c := New(func(ctx context.Context, event Event) error { return nil }, Config{})
+	c.Close()
+	c.Close()
+	assert.ErrorIs(t, c.Handle(context.Background(), walletEvent("evt_1", "user1")), ErrClosed)
+}
+
+func TestWalletKey(t *testing.T) {
+	assert.Equal(t, "user1", WalletKey(walletEvent("evt_1", "user1")))
+	assert.Equal(t, "user2", WalletKey(Event{Data: []byte(`{"from_user_id":"user2","to_user_id":"user3"}`)}))
+	assert.Equal(t, "user4", WalletKey(Event{Data: []byte(`{"previous_user_id":"user4"}`)}))
+	assert.Equal(t, "", WalletKey(Event{}))
+}
+
+func TestConsumer_HTTPHandler(t *testing.T) {
+	c := New(func(ctx context.Context, event Event) error {
+		if event.Type == "wallet.debited" {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}, Config{})
+	defer c.Close()
+	server := httptest.NewServer(c.HTTPHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"handled", `{"id":"evt_1","type":"wallet.credited","data":{"user_id":"user1"}}`, http.StatusNoContent},
+		{"failed", `{"id":"evt_2","type":"wallet.debited","data":{"user_id":"user1"}}`, http.StatusInternalServerError},
+		{"not an event", `{"type":"wallet.credited"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL, "application/json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
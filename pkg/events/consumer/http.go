@@ -0,0 +1,31 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPHandler receives the events the webhook publisher POSTs. It answers
+// 204 once an event is handled, 400 to bodies that are not an event, which
+// the publisher would only retry in vain, and 500 to ask for a redelivery.
+func (c *Consumer) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.ID == "" {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+
+		if err := c.Handle(r.Context(), event); err != nil {
+			http.Error(w, "event not handled", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,119 @@
+package consumer
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Store remembers the IDs of handled events
+type Store interface {
+	// Processed reports whether eventID was marked processed
+	Processed(ctx context.Context, eventID string) (bool, error)
+	// MarkProcessed records eventID as processed
+	MarkProcessed(ctx context.Context, eventID string) error
+}
+
+const defaultMemoryStoreSize = 10000
+
+// MemoryStore keeps the IDs of the most recently handled events in memory.
+// It does not survive restarts, so it only suits handlers that are safe to
+// run again for an event after one.
+type MemoryStore struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List
+	ids   map[string]*list.Element
+}
+
+// NewMemoryStore remembers up to max event IDs, evicting the oldest first.
+// Zero keeps 10000.
+func NewMemoryStore(max int) *MemoryStore {
+	if max <= 0 {
+		max = defaultMemoryStoreSize
+	}
+	return &MemoryStore{
+		max:   max,
+		order: list.New(),
+		ids:   map[string]*list.Element{},
+	}
+}
+
+func (s *MemoryStore) Processed(_ context.Context, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.ids[eventID]
+	return ok, nil
+}
+
+func (s *MemoryStore) MarkProcessed(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ids[eventID]; ok {
+		return nil
+	}
+	s.ids[eventID] = s.order.PushBack(eventID)
+	if s.order.Len() > s.max {
+		oldest := s.order.Front()
+		s.order.Remove(oldest)
+		delete(s.ids, oldest.Value.(string))
+	}
+	return nil
+}
+
+// SQLSchema creates the table SQLStore records handled events in. It is not
+// part of the wallet migrations: consumers create it in their own database.
+const SQLSchema = `CREATE TABLE IF NOT EXISTS processed_events (
+	consumer VARCHAR(100) NOT NULL,
+	event_id VARCHAR(64) NOT NULL,
+	processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (consumer, event_id)
+)`
+
+// SQLStore records handled events in the processed_events table of a
+// PostgreSQL database, so deduplication survives restarts and is shared by
+// the instances of a consumer. Several consumers can share the table.
+type SQLStore struct {
+	db       *sql.DB
+	consumer string
+}
+
+func NewSQLStore(db *sql.DB, consumer string) *SQLStore {
+	return &SQLStore{db: db, consumer: consumer}
+}
+
+func (s *SQLStore) Processed(ctx context.Context, eventID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM processed_events WHERE consumer = $1 AND event_id = $2)`,
+		s.consumer, eventID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (s *SQLStore) MarkProcessed(ctx context.Context, eventID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2)
+			ON CONFLICT (consumer, event_id) DO NOTHING`,
+		s.consumer, eventID,
+	)
+	return err
+}
+
+// MarkProcessedTx records eventID within tx and reports whether it was new.
+// Handlers writing to the same database call it in their own transaction and
+// skip the event when it returns false, which makes the side effects and the
+// mark commit together: exactly once even across crashes.
+func (s *SQLStore) MarkProcessedTx(ctx context.Context, tx *sql.Tx, eventID string) (bool, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2)
+			ON CONFLICT (consumer, event_id) DO NOTHING`,
+		s.consumer, eventID,
+	)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	return inserted == 1, err
+}
@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	require.NoError(t, store.MarkProcessed(ctx, "evt_1"))
+	require.NoError(t, store.MarkProcessed(ctx, "evt_2"))
+	processed, _ := store.Processed(ctx, "evt_1")
+	assert.True(t, processed)
+
+	// The oldest ID is evicted once the store is full
+	require.NoError(t, store.MarkProcessed(ctx, "evt_3"))
+	processed, _ = store.Processed(ctx, "evt_1")
+	assert.False(t, processed)
+	processed, _ = store.Processed(ctx, "evt_3")
+	assert.True(t, processed)
+}
+
+func TestSQLStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	store := NewSQLStore(db, "crm-sync")
+	ctx := context.Background()
+
+	t.Run("Processed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM processed_events WHERE consumer = \$1 AND event_id = \$2\)`).
+			WithArgs("crm-sync", "evt_1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		processed, err := store.Processed(ctx, "evt_1")
+		require.NoError(t, err)
+		assert.True(t, processed)
+	})
+
+	t.Run("MarkProcessed", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO processed_events \(consumer, event_id\) VALUES \(\$1, \$2\)\s+ON CONFLICT \(consumer, event_id\) DO NOTHING`).
+			WithArgs("crm-sync", "evt_1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, store.MarkProcessed(ctx, "evt_1"))
+	})
+
+	t.Run("MarkProcessedTx reports duplicates", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO processed_events`).
+			WithArgs("crm-sync", "evt_1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		inserted, err := store.MarkProcessedTx(ctx, tx, "evt_1")
+		require.NoError(t, err)
+		assert.False(t, inserted)
+		require.NoError(t, tx.Rollback())
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}